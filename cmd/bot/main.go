@@ -1,27 +1,82 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 
 	"jolly-okurb/internal/bot"
 	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/correlation"
+	"jolly-okurb/internal/events"
+	"jolly-okurb/internal/logship"
+	"jolly-okurb/internal/logx"
+	"jolly-okurb/internal/metrics"
+	"jolly-okurb/internal/sentry"
+	"jolly-okurb/internal/setup"
+	"jolly-okurb/internal/stats"
+	"jolly-okurb/internal/store"
+	"jolly-okurb/internal/web"
 )
 
+// version is set at build time with -ldflags "-X main.version=...".
+var version = "dev"
+
+// logger is the logger of the bot's startup and commands.
+var logger = logx.For(logx.Bot)
+
 func main() {
+	slog.SetDefault(slog.New(correlation.NewHandler(slog.NewTextHandler(os.Stderr, nil))))
+
+	// init writes the config that Load needs, and upgrade-config rewrites it,
+	// so they run without one
+	if len(os.Args) > 1 && os.Args[1] == "init" {
+		os.Exit(runInit(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "upgrade-config" {
+		os.Exit(runUpgradeConfig())
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
-		slog.Error("failed to load config", "error", err)
+		logger.Error("failed to load config", "error", err)
+		os.Exit(1)
+	}
+	for _, change := range cfg.Migrations {
+		logger.Info("config file migrated in memory; run upgrade-config to save it", "change", change, "path", os.Getenv("CONFIG_FILE"))
+	}
+
+	logOpts := &slog.HandlerOptions{Level: cfg.LogLevel}
+	var handler slog.Handler = slog.NewTextHandler(os.Stderr, logOpts)
+	slog.SetDefault(slog.New(correlation.NewHandler(logx.Sample(handler, cfg.LogDebugSample))))
+
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(cfg, os.Args[1:]))
+	}
+
+	shipper, err := openLogShipper(cfg)
+	if err != nil {
+		logx.For(logx.Logs).Error("failed to set up log shipping", "error", err)
 		os.Exit(1)
 	}
+	if shipper != nil {
+		defer shipper.Close() // Last, to send the shutdown logs
+		handler = logx.Tee(handler, slog.NewJSONHandler(shipper, logOpts))
+		slog.SetDefault(slog.New(correlation.NewHandler(logx.Sample(handler, cfg.LogDebugSample))))
+	}
 
 	dg, err := discordgo.New("Bot " + cfg.Token)
 	if err != nil {
-		slog.Error("failed to create Discord session", "error", err)
+		logger.Error("failed to create Discord session", "error", err)
 		os.Exit(1)
 	}
 
@@ -29,28 +84,440 @@ func main() {
 	dg.ShouldRetryOnRateLimit = true
 	dg.MaxRestRetries = 3
 
-	b := bot.New(cfg)
+	sink, err := metrics.New(cfg.MetricsBackend, cfg.MetricsAddr, cfg.MetricsPrefix)
+	if err != nil {
+		logx.For(logx.Metrics).Error("failed to create metrics sink", "error", err)
+		os.Exit(1)
+	}
+	defer sink.Close()
+	sink = metrics.Bound(sink, cfg.MetricsLabelLimit)
+
+	st, err := openStore(cfg)
+	if errors.Is(err, store.ErrLocked) {
+		logx.For(logx.Store).Error("store is in use; is another bot or import-stats running on it?", "path", cfg.StorePath)
+		os.Exit(1)
+	}
+	if err != nil {
+		logx.For(logx.Store).Error("failed to open store", "path", cfg.StorePath, "error", err)
+		os.Exit(1)
+	}
+
+	var reporter *sentry.Client
+	if cfg.SentryDSN != "" {
+		reporter, err = sentry.New(cfg.SentryDSN)
+		if err != nil {
+			logger.Error("failed to create Sentry client", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	opts := []bot.Option{bot.WithMetrics(sink), bot.WithStore(st), bot.WithSentry(reporter), bot.WithVersion(version)}
+	if cfg.AuditExportPath != "" {
+		export, err := os.OpenFile(cfg.AuditExportPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			logger.Error("failed to open audit export", "path", cfg.AuditExportPath, "error", err)
+			os.Exit(1)
+		}
+		defer export.Close()
+		opts = append(opts, bot.WithAuditExport(export))
+	}
+
+	bus, err := openEventBus(cfg, st)
+	if err != nil {
+		logx.For(logx.Events).Error("failed to set up event publishing", "error", err)
+		os.Exit(1)
+	}
+	defer bus.Close()
+	opts = append(opts, bot.WithEvents(bus))
+
+	b := bot.New(cfg, opts...)
 
-	dg.AddHandler(b.OnReady)
-	dg.AddHandler(b.OnReactionAdd)
-	dg.AddHandler(b.OnMessageCreate)
+	dg.AddHandler(bot.Recover(b, b.OnReady))
+	dg.AddHandler(bot.Recover(b, b.OnReactionAdd))
+	dg.AddHandler(bot.Recover(b, b.OnReactionRemove))
+	dg.AddHandler(bot.Recover(b, b.OnReactionRemoveAll))
+	dg.AddHandler(bot.Recover(b, b.OnMessageCreate))
+	dg.AddHandler(bot.Recover(b, b.OnInteractionCreate))
+	dg.AddHandler(bot.Recover(b, b.OnChannelCreate))
+	dg.AddHandler(bot.Recover(b, b.OnChannelUpdate))
+	dg.AddHandler(bot.Recover(b, b.OnChannelDelete))
+	dg.AddHandler(bot.Recover(b, b.OnGuildCreate))
+	dg.AddHandler(bot.Recover(b, b.OnGuildEmojisUpdate))
+	dg.AddHandler(bot.Recover(b, b.OnGuildMembersChunk))
+	dg.AddHandler(bot.Recover(b, b.OnGuildMemberAdd))
+	dg.AddHandler(bot.Recover(b, b.OnGuildMemberUpdate))
+	dg.AddHandler(bot.Recover(b, b.OnGuildMemberRemove))
+	dg.AddHandler(bot.Recover(b, b.OnRateLimit))
 
-	dg.Identify.Intents = discordgo.IntentsGuildMessages |
-		discordgo.IntentsGuildMessageReactions |
-		discordgo.IntentGuildMembers |
-		discordgo.IntentMessageContent
+	dg.Identify.Intents = bot.Intents(cfg)
 
 	if err := dg.Open(); err != nil {
-		slog.Error("failed to open connection", "error", err)
+		logger.Error("failed to open connection", "error", err)
 		os.Exit(1)
 	}
 	defer dg.Close()
 
-	slog.Info("bot is running")
+	ctx, stop := context.WithCancel(context.Background())
+	httpDone := make(chan struct{})
+	if cfg.HTTPAddr != "" {
+		srv := web.New(cfg.HTTPAddr, b)
+		go func() {
+			defer close(httpDone)
+			if err := srv.Run(ctx); err != nil {
+				logx.For(logx.HTTP).Error("http server stopped", "error", err)
+			}
+		}()
+	} else {
+		close(httpDone)
+	}
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go reloadConfig(reload, b)
+
+	logger.Info("bot is running")
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM)
 	<-sc
 
-	slog.Info("shutting down")
+	logger.Info("shutting down")
+	stop()
+	<-httpDone
 	b.Shutdown()
+	if err := flushStore(st); err != nil {
+		logx.For(logx.Store).Error("failed to write store", "path", cfg.StorePath, "error", err)
+	}
+}
+
+// reloadConfig reloads the config each time a signal arrives on sig, and
+// applies what can change while the bot runs. Only values from CONFIG_FILE
+// can change: the environment is read again, but is the same as at startup.
+// A config that fails to load is logged and the current one kept.
+func reloadConfig(sig <-chan os.Signal, b *bot.Bot) {
+	for range sig {
+		if os.Getenv("CONFIG_FILE") == "" {
+			logger.Warn("SIGHUP only reloads CONFIG_FILE, which is not set; restart the bot to apply changes to the environment")
+			continue
+		}
+		if names := config.EnvReloadable(); len(names) > 0 {
+			logger.Warn("settings set in the environment win over CONFIG_FILE and are not reloaded; restart the bot to change them", "settings", names)
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			logger.Error("failed to reload config, keeping the current one", "error", err)
+			continue
+		}
+		b.Reload(cfg)
+		logger.Info("config reloaded")
+	}
+}
+
+// openEventBus subscribes the configured brokers and webhooks to a new event
+// bus. It returns nil if none are configured.
+func openEventBus(cfg *config.Config, st store.Store) (*events.Bus, error) {
+	if cfg.NATSURL == "" && cfg.MQTTURL == "" && len(cfg.Webhooks) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	bus := events.NewBus()
+	if cfg.NATSURL != "" {
+		nats, err := events.DialNATS(ctx, cfg.NATSURL, cfg.NATSSubject)
+		if err != nil {
+			return nil, err
+		}
+		bus.Subscribe("nats", nats)
+	}
+	if cfg.MQTTURL != "" {
+		mqtt, err := events.DialMQTT(ctx, cfg.MQTTURL, cfg.MQTTTopic)
+		if err != nil {
+			bus.Close()
+			return nil, err
+		}
+		// Continue today's counts from the stats recorded before a restart
+		days, err := stats.New(st).Days(time.Now(), 1)
+		if err != nil {
+			logx.For(logx.Events).Warn("failed to load today's stats for MQTT", "error", err)
+		} else {
+			s := stats.Summarize(days)
+			mqtt.SetCounts(events.DayCounts{Date: days[0].Date, Replaced: s.Replaced, Deleted: s.Deleted})
+		}
+		bus.Subscribe("mqtt", mqtt)
+	}
+	for i, hook := range cfg.Webhooks {
+		bus.Subscribe(fmt.Sprintf("webhook %d", i+1), events.NewWebhook(hook)) // Not the URL, which often holds a key
+	}
+	return bus, nil
+}
+
+// runUpgradeConfig rewrites CONFIG_FILE in the current format, keeping the
+// original next to it with a .bak suffix.
+func runUpgradeConfig() int {
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		fmt.Fprintln(os.Stderr, "CONFIG_FILE is not set")
+		return 2
+	}
+	f, changes, err := config.ReadFile(path)
+	if err != nil {
+		logger.Error("failed to read config file", "path", path, "error", err)
+		return 1
+	}
+	if len(changes) == 0 {
+		fmt.Printf("%s is already at version %d.\n", path, config.FileVersion)
+		return 0
+	}
+
+	tmp := path + ".new"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600) // It may hold the token
+	if err != nil {
+		logger.Error("failed to create config file", "path", tmp, "error", err)
+		return 1
+	}
+	if err := f.Write(out); err != nil {
+		out.Close()
+		logger.Error("failed to write config file", "path", tmp, "error", err)
+		return 1
+	}
+	if err := out.Close(); err != nil {
+		logger.Error("failed to write config file", "path", tmp, "error", err)
+		return 1
+	}
+	if err := os.Rename(path, path+".bak"); err != nil {
+		logger.Error("failed to back up config file", "path", path, "error", err)
+		return 1
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		logger.Error("failed to replace config file", "path", path, "error", err)
+		return 1
+	}
+	for _, change := range changes {
+		fmt.Println("- " + change)
+	}
+	fmt.Printf("Upgraded %s to version %d; the original is at %s.bak.\n", path, config.FileVersion, path)
+	return 0
+}
+
+// openLogShipper starts shipping logs to the configured service. It returns
+// nil if none is configured.
+func openLogShipper(cfg *config.Config) (*logship.Shipper, error) {
+	switch cfg.LogShipper {
+	case config.LogShipperLoki:
+		loki, err := logship.NewLoki(cfg.LokiURL)
+		if err != nil {
+			return nil, err
+		}
+		return logship.New(loki), nil
+	case config.LogShipperCloudWatch:
+		creds := logship.Credentials{
+			AccessKeyID:     cfg.AWSAccessKeyID,
+			SecretAccessKey: cfg.AWSSecretAccessKey,
+			SessionToken:    cfg.AWSSessionToken,
+		}
+		return logship.New(logship.NewCloudWatch(cfg.AWSRegion, cfg.CloudWatchLogGroup, cfg.CloudWatchLogStream, creds)), nil
+	default:
+		return nil, nil
+	}
+}
+
+// flushStore writes the changes a file store has not written yet.
+func flushStore(st store.Store) error {
+	if f, ok := st.(*store.File); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// storeLock is the lock on STORE_PATH, held until the process exits. Keeping
+// it referenced stops the garbage collector from closing its file, which
+// would release it.
+var storeLock *store.Lock
+
+// openStore opens the configured store, in memory if STORE_PATH is not set.
+// A store file is locked first, so that the bot and import-stats never write
+// it at the same time; store.ErrLocked means another process has it open.
+func openStore(cfg *config.Config) (store.Store, error) {
+	if cfg.StorePath == "" {
+		return store.NewMemory(), nil
+	}
+	lock, err := store.LockFile(cfg.StorePath)
+	if err != nil {
+		return nil, err
+	}
+	storeLock = lock
+	switch {
+	case len(cfg.StoreKey) > 0:
+		return store.OpenEncryptedFile(cfg.StorePath, cfg.StoreKey)
+	default:
+		return store.OpenFile(cfg.StorePath)
+	}
+}
+
+// runCommand runs a one-off command instead of the bot and returns the exit code.
+func runCommand(cfg *config.Config, args []string) int {
+	switch args[0] {
+	case "invite":
+		appID, err := bot.ApplicationID(cfg.Token)
+		if err != nil {
+			logger.Error("failed to build invite link", "error", err)
+			return 1
+		}
+		fmt.Println(bot.InviteURL(appID, cfg))
+		return 0
+	case "doctor":
+		return runDoctor(cfg)
+	case "simulate":
+		return runSimulate(cfg, args[1:])
+	case "import-stats":
+		return runImportStats(cfg, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q (expected init, upgrade-config, invite, doctor, simulate, or import-stats)\n", args[0])
+		return 2
+	}
+}
+
+// runDoctor checks the Discord-side prerequisites and prints a checklist.
+// It fails if any check fails.
+func runDoctor(cfg *config.Config) int {
+	dg, err := discordgo.New("Bot " + cfg.Token)
+	if err != nil {
+		logger.Error("failed to create Discord session", "error", err)
+		return 1
+	}
+	ok, err := bot.WriteChecklist(os.Stdout, bot.New(cfg).Doctor(dg))
+	if err != nil {
+		logger.Error("failed to write checklist", "error", err)
+		return 1
+	}
+	if !ok {
+		return 1
+	}
+	return 0
+}
+
+// runSimulate reports what the bot would have done with an exported channel history.
+func runSimulate(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	input := fs.String("input", "", "DiscordChatExporter JSON export of a channel")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *input == "" {
+		fmt.Fprintln(os.Stderr, "usage: bot simulate --input export.json")
+		return 2
+	}
+
+	f, err := os.Open(*input)
+	if err != nil {
+		logger.Error("failed to open export", "path", *input, "error", err)
+		return 1
+	}
+	defer f.Close()
+
+	report, err := bot.New(cfg).Simulate(f)
+	if err != nil {
+		logger.Error("simulation failed", "path", *input, "error", err)
+		return 1
+	}
+	if err := report.WriteText(os.Stdout); err != nil {
+		logger.Error("failed to write simulation report", "error", err)
+		return 1
+	}
+	return 0
+}
+
+// runImportStats adds counts from before the bot, such as manual tallies, to
+// the stats store. The bot must be stopped first: it keeps the store in
+// memory and would overwrite the imported counts, so import-stats refuses to
+// run while the bot holds the store's lock.
+func runImportStats(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("import-stats", flag.ContinueOnError)
+	input := fs.String("csv", "", "CSV with the header date,user_id,replaced,deleted")
+	force := fs.Bool("force", false, "import a file again even though it was imported before")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *input == "" {
+		fmt.Fprintln(os.Stderr, "usage: bot import-stats --csv file [--force]  (stop the bot first)")
+		return 2
+	}
+	if cfg.StorePath == "" {
+		fmt.Fprintln(os.Stderr, "import-stats needs STORE_PATH, or the imported stats are lost on exit")
+		return 2
+	}
+
+	data, err := os.ReadFile(*input)
+	if err != nil {
+		logger.Error("failed to read stats CSV", "path", *input, "error", err)
+		return 1
+	}
+	st, err := openStore(cfg)
+	if errors.Is(err, store.ErrLocked) {
+		fmt.Fprintf(os.Stderr, "%s is in use; stop the bot before importing stats\n", cfg.StorePath)
+		return 1
+	}
+	if err != nil {
+		logx.For(logx.Store).Error("failed to open store", "path", cfg.StorePath, "error", err)
+		return 1
+	}
+	n, err := stats.New(st).ImportCSV(data, *force)
+	if errors.Is(err, stats.ErrAlreadyImported) {
+		fmt.Fprintf(os.Stderr, "%s: %v; pass --force to add its counts again\n", *input, err)
+		return 1
+	}
+	if err != nil {
+		logger.Error("failed to import stats", "path", *input, "error", err)
+		return 1
+	}
+	if err := flushStore(st); err != nil {
+		logx.For(logx.Store).Error("failed to write store", "path", cfg.StorePath, "error", err)
+		return 1
+	}
+	fmt.Printf("Imported %d rows from %s\n", n, *input)
+	return 0
+}
+
+// runInit asks for the required settings, checking them against Discord,
+// and writes them to an .envrc file.
+func runInit(args []string) int {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	output := fs.String("output", ".envrc", "file to write the settings to")
+	force := fs.Bool("force", false, "overwrite the file if it exists")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if *force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	} else if _, err := os.Stat(*output); err == nil {
+		fmt.Fprintf(os.Stderr, "%s already exists; pass --force to overwrite it or --output to write elsewhere\n", *output)
+		return 2
+	}
+
+	answers, err := setup.Run(os.Stdin, os.Stdout, func(token string) (setup.API, error) {
+		return discordgo.New("Bot " + strings.TrimPrefix(token, "Bot "))
+	})
+	if err != nil {
+		logger.Error("setup did not finish", "error", err)
+		return 1
+	}
+
+	f, err := os.OpenFile(*output, flags, 0o600) // It holds the token
+	if err != nil {
+		logger.Error("failed to create config file", "path", *output, "error", err)
+		return 1
+	}
+	if err := answers.WriteEnvrc(f); err != nil {
+		f.Close()
+		logger.Error("failed to write config file", "path", *output, "error", err)
+		return 1
+	}
+	if err := f.Close(); err != nil {
+		logger.Error("failed to write config file", "path", *output, "error", err)
+		return 1
+	}
+	fmt.Printf("\nWrote %s. Load it with `source %s` (or direnv), then start the bot.\n", *output, *output)
+	return 0
 }
@@ -0,0 +1,256 @@
+// Package actions performs the bot's changes on Discord. Every mutation,
+// whether it removes a reaction, adds one, deletes a message, sends a DM,
+// posts, or uploads an emoji, goes through an Executor, which enforces what applies to all of
+// them in one place: read-only mode, dry run, jitter, the action budget and
+// feature limits, audit log reasons, retries of transient failures of
+// idempotent actions, and metrics.
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/logx"
+	"jolly-okurb/internal/metrics"
+	"jolly-okurb/internal/ratelimit"
+	"jolly-okurb/internal/settings"
+)
+
+var logger = logx.For(logx.Actions)
+
+// Kind is what a mutation does, as tagged on its metrics.
+type Kind string
+
+const (
+	RemoveReaction Kind = "remove_reaction"
+	AddReaction    Kind = "add_reaction"
+	DeleteMessage  Kind = "delete_message"
+	SendDM         Kind = "dm"
+	Post           Kind = "post" // Messages, including reposts through webhooks
+	Pin            Kind = "pin"
+	Unpin          Kind = "unpin"
+	Typing         Kind = "typing"
+	CreateEmoji    Kind = "create_emoji"
+	DeleteEmoji    Kind = "delete_emoji"
+)
+
+// idempotent reports whether an action of kind k leaves Discord the same
+// however often it is performed, so it can be retried after a failure that
+// may have taken effect anyway, such as a timeout. Posts, DMs, and emoji
+// uploads could be duplicated, so they are not.
+func (k Kind) idempotent() bool {
+	switch k {
+	case RemoveReaction, AddReaction, DeleteMessage, Pin, Unpin, Typing, DeleteEmoji:
+		return true
+	}
+	return false
+}
+
+// Retry policy for transient failures of idempotent actions: exponential
+// backoff from retryDelay, giving up after retries more attempts. Discord's rate limits are waited out
+// by discordgo itself and never get here.
+const (
+	retries    = 2
+	retryDelay = 500 * time.Millisecond
+)
+
+var (
+	// ErrReadOnly is returned for every action in read-only mode.
+	ErrReadOnly = errors.New("read-only mode, not changing anything on Discord")
+	// ErrDryRun is returned for moderation actions in dry-run mode.
+	ErrDryRun = errors.New("dry run, only logged")
+)
+
+// Skipped reports whether err means the executor deliberately did not
+// perform an action, as opposed to the action failing.
+func Skipped(err error) bool {
+	return errors.Is(err, ErrReadOnly) || errors.Is(err, ErrDryRun)
+}
+
+// Action is one mutation on Discord. Policy, ChannelID, MessageID, UserID,
+// EmojiID, and Comment are also the fields of the AUDIT_LOG_REASON template.
+type Action struct {
+	Kind      Kind
+	Policy    string // The moderation policy it enforces; empty for reports, alerts, and the like
+	ChannelID string
+	MessageID string
+	UserID    string           // Author of the message or reaction, or recipient of the DM
+	EmojiID   string           // Emoji it deletes; empty for other kinds
+	Rule      string           // Name of the emoji rule that matched; empty if none
+	Feature   settings.Feature // Whose limit it counts against; empty for none
+	Comment   string           // Why an operator started the operation it is part of; set from the context if empty
+	Feedback  bool             // Shows progress of another action, so it cannot wait behind the limits slowing that down
+
+	// Do performs the action, passing opts on to the request if Discord
+	// audits it.
+	Do func(opts ...discordgo.RequestOption) error
+}
+
+type commentKey struct{}
+
+// WithComment returns a copy of ctx whose actions carry comment, the reason an
+// operator gave for the operation they are part of, such as a rescan.
+func WithComment(ctx context.Context, comment string) context.Context {
+	if comment == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, commentKey{}, comment)
+}
+
+// CommentFrom returns the comment ctx carries, if any.
+func CommentFrom(ctx context.Context) string {
+	comment, _ := ctx.Value(commentKey{}).(string)
+	return comment
+}
+
+// Policy is what an Executor enforces.
+type Policy struct {
+	ReadOnly  bool                                   // Refuse every action
+	DryRun    func(channelID string) bool            // Whether moderation actions in a channel are only logged
+	Budget    *ratelimit.Bucket                      // Shared by every action; nil means unlimited
+	Limits    map[settings.Feature]*ratelimit.Bucket // Per feature, on top of Budget
+	RuleLimit func(rule string) *ratelimit.Bucket    // Per emoji rule, on top of Limits; nil means unlimited
+	JitterMin time.Duration                          // Least random delay before an action that counts against a feature limit
+	JitterMax time.Duration                          // Most of it; 0 means no delay
+	Reason    func(Action) string                    // Audit log reason of moderation actions
+	Metrics   metrics.Sink
+}
+
+// Executor performs actions under a Policy. A nil *Executor performs them
+// under none.
+type Executor struct {
+	policy     Policy
+	retryDelay time.Duration
+}
+
+// New returns an Executor that enforces p.
+func New(p Policy) *Executor {
+	if p.Metrics == nil {
+		p.Metrics = metrics.Nop{}
+	}
+	return &Executor{policy: p, retryDelay: retryDelay}
+}
+
+// Do performs a, unless read-only mode or dry run rule it out, in which case
+// it returns ErrReadOnly or ErrDryRun. Dry run applies to actions with a
+// Policy only, as reports and alerts are how moderators see what dry run
+// would do. Do waits for a's limits, unless it is Feedback, and retries
+// failures that may pass if a is idempotent; other actions get one attempt.
+//
+// An action that counts against a feature limit, the first request of a
+// replacement or deletion, waits a random jitter first, so the bot does not
+// answer the instant a skull appears, nor all at once when a target user
+// skulls many messages in a row.
+func (e *Executor) Do(ctx context.Context, a Action) error {
+	if e == nil {
+		return a.Do()
+	}
+	if a.Comment == "" {
+		a.Comment = CommentFrom(ctx)
+	}
+	tags := []string{"kind:" + string(a.Kind)}
+	if e.policy.ReadOnly {
+		metrics.Incr(e.policy.Metrics, metrics.ReadOnlyBlocked, tags...)
+		logger.DebugContext(ctx, "read-only: action refused", "kind", a.Kind, "channel_id", a.ChannelID, "message_id", a.MessageID)
+		return ErrReadOnly
+	}
+	if a.Policy != "" && e.policy.DryRun != nil && e.policy.DryRun(a.ChannelID) {
+		metrics.Incr(e.policy.Metrics, metrics.DryRunActions, tags...)
+		logger.InfoContext(ctx, "dry run: would act", "kind", a.Kind, "policy", a.Policy, "channel_id", a.ChannelID, "message_id", a.MessageID, "user_id", a.UserID)
+		return ErrDryRun
+	}
+
+	if a.Feature != "" {
+		if err := e.jitter(ctx); err != nil {
+			return err
+		}
+		if err := e.wait(ctx, e.policy.Limits[a.Feature], "feature:"+string(a.Feature)); err != nil {
+			return err
+		}
+		if a.Rule != "" && e.policy.RuleLimit != nil {
+			if err := e.wait(ctx, e.policy.RuleLimit(a.Rule), "rule:"+a.Rule); err != nil {
+				return err
+			}
+		}
+	}
+	if !a.Feedback {
+		if err := e.wait(ctx, e.policy.Budget, ""); err != nil {
+			return err
+		}
+	}
+
+	var opts []discordgo.RequestOption
+	if a.Policy != "" && e.policy.Reason != nil {
+		// Header values must be ASCII, so Discord expects the reason URL-encoded
+		opts = append(opts, discordgo.WithAuditLogReason(url.PathEscape(e.policy.Reason(a))))
+	}
+	for n := 0; ; n++ {
+		err := a.Do(opts...)
+		if err == nil {
+			metrics.Incr(e.policy.Metrics, metrics.ActionsPerformed, tags...)
+			return nil
+		}
+		if !transient(err) || !a.Kind.idempotent() || n == retries {
+			metrics.Incr(e.policy.Metrics, metrics.ActionsFailed, tags...)
+			return err
+		}
+		metrics.Incr(e.policy.Metrics, metrics.ActionsRetried, tags...)
+		logger.WarnContext(ctx, "action failed, retrying", "kind", a.Kind, "channel_id", a.ChannelID, "message_id", a.MessageID, "attempt", n+1, "error", err)
+		select {
+		case <-time.After(e.retryDelay << n):
+		case <-ctx.Done():
+			return fmt.Errorf("%w (gave up retrying: %w)", err, ctx.Err())
+		}
+	}
+}
+
+// wait blocks until bucket permits another action. limit names it as a
+// metric tag, such as "feature:deletion" or "rule:sob"; empty means the
+// shared budget.
+func (e *Executor) wait(ctx context.Context, bucket *ratelimit.Bucket, limit string) error {
+	if bucket.Allow() {
+		return nil
+	}
+	if limit == "" {
+		metrics.Incr(e.policy.Metrics, metrics.ActionsThrottled)
+		logger.DebugContext(ctx, "action budget exhausted, waiting")
+	} else {
+		metrics.Incr(e.policy.Metrics, metrics.ActionsThrottled, limit)
+		logger.DebugContext(ctx, "action limit exhausted, waiting", "limit", limit)
+	}
+	return bucket.Wait(ctx)
+}
+
+// jitter sleeps for a random duration from JitterMin to JitterMax.
+func (e *Executor) jitter(ctx context.Context) error {
+	lo, hi := e.policy.JitterMin, e.policy.JitterMax
+	if hi <= 0 {
+		return nil
+	}
+	d := lo + rand.N(hi-lo+1)
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// transient reports whether err may pass on a retry: a server error on
+// Discord's side, or a network failure.
+func transient(err error) bool {
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) {
+		return restErr.Response != nil && restErr.Response.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
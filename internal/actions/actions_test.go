@@ -0,0 +1,303 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/metrics"
+	"jolly-okurb/internal/ratelimit"
+	"jolly-okurb/internal/settings"
+)
+
+// countingSink records counter totals by name.
+type countingSink struct {
+	metrics.Nop
+	counts map[string]int64
+}
+
+func (c *countingSink) Count(name string, n int64, tags ...string) {
+	c.counts[name] += n
+}
+
+// restError returns a Discord API error with status.
+func restError(status int) error {
+	return &discordgo.RESTError{Response: &http.Response{StatusCode: status, Status: http.StatusText(status)}}
+}
+
+func TestExecutor_Do(t *testing.T) {
+	dryRunIn := func(channelID string) bool { return channelID == "dry" }
+	tests := []struct {
+		name        string
+		readOnly    bool
+		action      Action
+		errs        []error // Returned by successive attempts; nil after they run out
+		wantErr     error
+		wantCalls   int
+		wantMetrics map[string]int64
+	}{
+		{
+			name:        "performed",
+			action:      Action{Kind: DeleteMessage, Policy: "p", ChannelID: "live"},
+			wantCalls:   1,
+			wantMetrics: map[string]int64{metrics.ActionsPerformed: 1},
+		},
+		{
+			name:        "read-only refuses everything",
+			readOnly:    true,
+			action:      Action{Kind: Post, ChannelID: "live"},
+			wantErr:     ErrReadOnly,
+			wantMetrics: map[string]int64{metrics.ReadOnlyBlocked: 1},
+		},
+		{
+			name:        "dry run logs moderation",
+			action:      Action{Kind: RemoveReaction, Policy: "p", ChannelID: "dry"},
+			wantErr:     ErrDryRun,
+			wantMetrics: map[string]int64{metrics.DryRunActions: 1},
+		},
+		{
+			name:        "dry run leaves reports alone",
+			action:      Action{Kind: Post, ChannelID: "dry"},
+			wantCalls:   1,
+			wantMetrics: map[string]int64{metrics.ActionsPerformed: 1},
+		},
+		{
+			name:        "retries server errors",
+			action:      Action{Kind: AddReaction, ChannelID: "live"},
+			errs:        []error{restError(http.StatusBadGateway), &url.Error{Op: "Post", Err: context.DeadlineExceeded}},
+			wantCalls:   3,
+			wantMetrics: map[string]int64{metrics.ActionsRetried: 2, metrics.ActionsPerformed: 1},
+		},
+		{
+			name:        "gives up after retries",
+			action:      Action{Kind: AddReaction, ChannelID: "live"},
+			errs:        []error{restError(500), restError(500), restError(500), restError(500)},
+			wantErr:     &discordgo.RESTError{},
+			wantCalls:   retries + 1,
+			wantMetrics: map[string]int64{metrics.ActionsRetried: retries, metrics.ActionsFailed: 1},
+		},
+		{
+			name:        "does not retry posts, which could be duplicated",
+			action:      Action{Kind: Post, ChannelID: "live"},
+			errs:        []error{&url.Error{Op: "Post", Err: context.DeadlineExceeded}},
+			wantErr:     context.DeadlineExceeded,
+			wantCalls:   1,
+			wantMetrics: map[string]int64{metrics.ActionsFailed: 1, metrics.ActionsRetried: 0},
+		},
+		{
+			name:        "does not retry client errors",
+			action:      Action{Kind: DeleteMessage, ChannelID: "live"},
+			errs:        []error{restError(http.StatusForbidden)},
+			wantErr:     &discordgo.RESTError{},
+			wantCalls:   1,
+			wantMetrics: map[string]int64{metrics.ActionsFailed: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &countingSink{counts: make(map[string]int64)}
+			e := New(Policy{ReadOnly: tt.readOnly, DryRun: dryRunIn, Metrics: sink})
+			e.retryDelay = time.Millisecond
+
+			calls := 0
+			tt.action.Do = func(...discordgo.RequestOption) error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			}
+			err := e.Do(context.Background(), tt.action)
+
+			switch want := tt.wantErr.(type) {
+			case nil:
+				if err != nil {
+					t.Errorf("Do() error = %v, want nil", err)
+				}
+			case *discordgo.RESTError:
+				if !errors.As(err, &want) {
+					t.Errorf("Do() error = %v, want a Discord API error", err)
+				}
+			default:
+				if !errors.Is(err, want) {
+					t.Errorf("Do() error = %v, want %v", err, want)
+				}
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			for name, want := range tt.wantMetrics {
+				if sink.counts[name] != want {
+					t.Errorf("%s = %d, want %d", name, sink.counts[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestExecutor_Limits(t *testing.T) {
+	sink := &countingSink{counts: make(map[string]int64)}
+	e := New(Policy{
+		Budget:  ratelimit.New(3, time.Hour),
+		Limits:  map[settings.Feature]*ratelimit.Bucket{settings.FeatureDeletion: ratelimit.New(1, time.Hour)},
+		Metrics: sink,
+	})
+	do := func(ctx context.Context, f settings.Feature) error {
+		return e.Do(ctx, Action{Kind: DeleteMessage, Feature: f, Do: func(...discordgo.RequestOption) error { return nil }})
+	}
+	expired, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := do(context.Background(), settings.FeatureDeletion); err != nil {
+		t.Fatalf("Do() error = %v within the limits", err)
+	}
+	if err := do(expired, settings.FeatureDeletion); err == nil {
+		t.Error("Do() should block once the feature limit is spent")
+	}
+	if err := do(context.Background(), settings.FeatureReactions); err != nil {
+		t.Errorf("Do() error = %v, want other features unaffected", err)
+	}
+	if err := do(context.Background(), ""); err != nil {
+		t.Errorf("Do() error = %v, want the budget left for one more", err)
+	}
+	if err := do(expired, ""); err == nil {
+		t.Error("Do() should block once the budget is spent")
+	}
+	feedback := Action{Kind: AddReaction, Feedback: true, Do: func(...discordgo.RequestOption) error { return nil }}
+	if err := e.Do(expired, feedback); err != nil {
+		t.Errorf("Do() error = %v, want feedback exempt from the budget", err)
+	}
+	if sink.counts[metrics.ActionsThrottled] != 2 {
+		t.Errorf("%s = %d, want 2", metrics.ActionsThrottled, sink.counts[metrics.ActionsThrottled])
+	}
+}
+
+func TestExecutor_RuleLimit(t *testing.T) {
+	sob := ratelimit.New(1, time.Hour)
+	e := New(Policy{RuleLimit: func(rule string) *ratelimit.Bucket {
+		if rule == "sob" {
+			return sob
+		}
+		return nil
+	}})
+	do := func(ctx context.Context, f settings.Feature, rule string) error {
+		return e.Do(ctx, Action{Kind: RemoveReaction, Feature: f, Rule: rule, Do: func(...discordgo.RequestOption) error { return nil }})
+	}
+	expired, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := do(context.Background(), settings.FeatureReactions, "sob"); err != nil {
+		t.Fatalf("Do() error = %v within the rule's limit", err)
+	}
+	if err := do(expired, settings.FeatureReactions, "sob"); err == nil {
+		t.Error("Do() should block once the rule's limit is spent")
+	}
+	if err := do(context.Background(), settings.FeatureReactions, "skull"); err != nil {
+		t.Errorf("Do() error = %v, want other rules unaffected", err)
+	}
+	if err := do(context.Background(), "", "sob"); err != nil {
+		t.Errorf("Do() error = %v, want later requests of a replacement unaffected", err)
+	}
+}
+
+func TestExecutor_Jitter(t *testing.T) {
+	const jitter = 30 * time.Millisecond
+	e := New(Policy{JitterMin: jitter, JitterMax: jitter})
+	tests := []struct {
+		name       string
+		feature    settings.Feature
+		wantJitter bool
+	}{
+		{"replacement", settings.FeatureReactions, true},
+		{"deletion", settings.FeatureDeletion, true},
+		{"other actions", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			err := e.Do(context.Background(), Action{Kind: DeleteMessage, Feature: tt.feature, Do: func(...discordgo.RequestOption) error { return nil }})
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if waited := time.Since(start) >= jitter; waited != tt.wantJitter {
+				t.Errorf("waited %v, want jitter %v", time.Since(start), tt.wantJitter)
+			}
+		})
+	}
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		called := false
+		err := e.Do(ctx, Action{Kind: DeleteMessage, Feature: settings.FeatureDeletion, Do: func(...discordgo.RequestOption) error {
+			called = true
+			return nil
+		}})
+		if !errors.Is(err, context.Canceled) || called {
+			t.Errorf("Do() = %v, called %v, want it canceled while waiting", err, called)
+		}
+	})
+}
+
+func TestExecutor_Reason(t *testing.T) {
+	e := New(Policy{Reason: func(a Action) string { return "jolly-okurb: " + a.Policy + " 💀" }})
+	tests := []struct {
+		name   string
+		policy string
+		want   string
+	}{
+		{"moderation", "skull reaction policy", "jolly-okurb: skull reaction policy 💀"},
+		{"no policy", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodDelete, "https://discord.com/api/v9/channels/1/messages/2", nil)
+			e.Do(context.Background(), Action{Kind: DeleteMessage, Policy: tt.policy, Do: func(opts ...discordgo.RequestOption) error {
+				cfg := &discordgo.RequestConfig{Request: req}
+				for _, opt := range opts {
+					opt(cfg)
+				}
+				return nil
+			}})
+
+			// Header values must be ASCII, so the reason is URL-encoded
+			got, err := url.PathUnescape(req.Header.Get("X-Audit-Log-Reason"))
+			if err != nil {
+				t.Fatalf("reason header is not URL-encoded: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("reason = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecutor_Comment(t *testing.T) {
+	var got string
+	e := New(Policy{Reason: func(a Action) string {
+		got = a.Comment
+		return a.Policy
+	}})
+	ctx := WithComment(context.Background(), "raid cleanup")
+	e.Do(ctx, Action{Kind: RemoveReaction, Policy: "p", Do: func(...discordgo.RequestOption) error { return nil }})
+	if got != "raid cleanup" {
+		t.Errorf("Comment = %q, want the one from the context", got)
+	}
+}
+
+func TestExecutor_Nil(t *testing.T) {
+	var e *Executor
+	called := false
+	err := e.Do(context.Background(), Action{Kind: Post, Do: func(...discordgo.RequestOption) error {
+		called = true
+		return nil
+	}})
+	if err != nil || !called {
+		t.Errorf("Do() = %v, called %v, want the action performed", err, called)
+	}
+}
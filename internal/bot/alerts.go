@@ -0,0 +1,259 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/metrics"
+)
+
+// Custom ID prefixes of the acknowledge buttons, followed by the alert ID.
+// Buttons on an alert's post update the post; buttons in /jolly alerts
+// update the list.
+const (
+	alertAckPrefix     = "jolly:alert:ack:"
+	alertListAckPrefix = "jolly:alerts:ack:"
+)
+
+const (
+	alertsKey = "alerts"
+
+	// maxAlerts bounds the queue in the store. Acknowledged alerts are
+	// dropped first, oldest first.
+	maxAlerts = 200
+
+	// maxListedAlerts is how many alerts /jolly alerts shows, one button each.
+	maxListedAlerts = 5
+)
+
+// alert is something a moderator should look at, such as an escalation.
+type alert struct {
+	ID       int       `json:"id"`
+	Text     string    `json:"text"`
+	RaisedAt time.Time `json:"raised_at"`
+	AckedBy  string    `json:"acked_by,omitempty"` // User ID of the moderator who handled it
+	AckedAt  time.Time `json:"acked_at,omitzero"`
+}
+
+// alertQueue is the stored list of alerts, oldest first.
+type alertQueue struct {
+	Next   int     `json:"next"` // ID of the next alert
+	Alerts []alert `json:"alerts,omitempty"`
+}
+
+// find returns the alert with id, or nil if it is gone.
+func (q *alertQueue) find(id int) *alert {
+	for i := range q.Alerts {
+		if q.Alerts[i].ID == id {
+			return &q.Alerts[i]
+		}
+	}
+	return nil
+}
+
+// pending returns the alerts no moderator has acknowledged, oldest first.
+func (q *alertQueue) pending() []alert {
+	var pending []alert
+	for _, a := range q.Alerts {
+		if a.AckedBy == "" {
+			pending = append(pending, a)
+		}
+	}
+	return pending
+}
+
+// trim drops alerts beyond maxAlerts, acknowledged ones first.
+func (q *alertQueue) trim() {
+	for len(q.Alerts) > maxAlerts {
+		i := slices.IndexFunc(q.Alerts, func(a alert) bool { return a.AckedBy != "" })
+		if i < 0 {
+			i = 0
+		}
+		q.Alerts = slices.Delete(q.Alerts, i, i+1)
+	}
+}
+
+// updateAlerts applies fn to the stored alert queue and saves the result.
+func (b *Bot) updateAlerts(fn func(*alertQueue) error) (alertQueue, error) {
+	b.alertsMu.Lock()
+	defer b.alertsMu.Unlock()
+
+	var q alertQueue
+	if _, err := b.store.Get(alertsKey, &q); err != nil {
+		return alertQueue{}, fmt.Errorf("failed to load alerts: %w", err)
+	}
+	if err := fn(&q); err != nil {
+		return alertQueue{}, err
+	}
+	if err := b.store.Put(alertsKey, q); err != nil {
+		return alertQueue{}, fmt.Errorf("failed to save alerts: %w", err)
+	}
+	return q, nil
+}
+
+// raiseAlert queues text for the moderators and posts it to the admin
+// channel, if there is one, with a button to acknowledge it. The queue keeps
+// the alert when the post fails or scrolls away.
+func (b *Bot) raiseAlert(s Session, text string) {
+	if raised, ok := b.queueAlert(text); ok {
+		b.postAlert(s, raised)
+	}
+}
+
+// queueAlert adds text to the alert queue and reports whether it did.
+func (b *Bot) queueAlert(text string) (alert, bool) {
+	var raised alert
+	_, err := b.updateAlerts(func(q *alertQueue) error {
+		q.Next++
+		raised = alert{ID: q.Next, Text: text, RaisedAt: time.Now()}
+		q.Alerts = append(q.Alerts, raised)
+		q.trim()
+		return nil
+	})
+	if err != nil {
+		logger.Error("failed to queue alert", "text", text, "error", err)
+		return alert{}, false
+	}
+	logger.Info("alert raised", "alert_id", raised.ID, "text", text)
+	metrics.Incr(b.sink(), metrics.AlertsRaised)
+	return raised, true
+}
+
+// postAlert posts a queued alert to the admin channel, if there is one, with
+// a button to acknowledge it followed by buttons.
+func (b *Bot) postAlert(s Session, raised alert, buttons ...discordgo.MessageComponent) {
+	if b.config.AdminChannelID == "" {
+		return
+	}
+	p := b.printer(b.config.GuildID)
+	buttons = append([]discordgo.MessageComponent{
+		discordgo.Button{Label: p.T("alerts.button.ack", raised.ID), Style: discordgo.SuccessButton, CustomID: alertAckPrefix + strconv.Itoa(raised.ID)},
+	}, buttons...)
+	err := b.executor.Do(context.Background(), actions.Action{
+		Kind:      actions.Post,
+		ChannelID: b.config.AdminChannelID,
+		Do: func(...discordgo.RequestOption) error {
+			_, err := s.ChannelMessageSendComplex(b.config.AdminChannelID, &discordgo.MessageSend{
+				Content:         raised.Text,
+				AllowedMentions: &discordgo.MessageAllowedMentions{}, // Name users without pinging them
+				Components:      []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}},
+			})
+			return err
+		},
+	})
+	if err != nil && !actions.Skipped(err) { // A skipped alert is still queued
+		logger.Error("failed to post alert", "alert_id", raised.ID, "channel_id", b.config.AdminChannelID, "error", err)
+	}
+}
+
+// handleAlerts lists the alerts waiting for a moderator, oldest first.
+func (b *Bot) handleAlerts(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	q, err := b.updateAlerts(func(*alertQueue) error { return nil })
+	if err != nil {
+		return nil, err
+	}
+	return b.alertList(i.GuildID, q.pending()), nil
+}
+
+// alertList renders pending alerts with a button to acknowledge each one.
+func (b *Bot) alertList(guildID string, pending []alert) *discordgo.InteractionResponseData {
+	p := b.printer(guildID)
+	if len(pending) == 0 {
+		return textReply(p.T("alerts.none"))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintln(&sb, p.T("alerts.pending", len(pending)))
+	var buttons []discordgo.MessageComponent
+	for _, a := range pending[:min(len(pending), maxListedAlerts)] {
+		fmt.Fprintf(&sb, "**#%d** <t:%d:R> %s\n", a.ID, a.RaisedAt.Unix(), a.Text)
+		buttons = append(buttons, discordgo.Button{
+			Label:    p.T("alerts.button.ack", a.ID),
+			Style:    discordgo.SuccessButton,
+			CustomID: alertListAckPrefix + strconv.Itoa(a.ID),
+		})
+	}
+	if more := len(pending) - maxListedAlerts; more > 0 {
+		sb.WriteString(p.T("alerts.more", more))
+	}
+	return &discordgo.InteractionResponseData{
+		Content:         strings.TrimSpace(sb.String()),
+		Components:      []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+}
+
+// handleAlertButton acknowledges an alert. The first moderator to press a
+// button claims the alert; later presses, on any of its buttons, are told
+// who already handled it.
+func (b *Bot) handleAlertButton(s Session, i *discordgo.InteractionCreate, customID string) {
+	p := b.printer(i.GuildID)
+	if i.Member == nil || i.Member.Permissions&panelPermissions == 0 {
+		b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, textReply(p.T("alerts.denied")))
+		return
+	}
+
+	fromList := strings.HasPrefix(customID, alertListAckPrefix)
+	id, err := strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(customID, alertListAckPrefix), alertAckPrefix))
+	if err != nil {
+		logger.Warn("invalid alert button", "custom_id", customID)
+		return
+	}
+
+	acked, already, q, err := b.acknowledgeAlert(id, interactionUserID(i))
+	if err != nil {
+		logger.Error("failed to acknowledge alert", "alert_id", id, "error", err)
+		b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, textReply(p.T("command.error", err)))
+		return
+	}
+
+	switch {
+	case acked.ID == 0:
+		b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, textReply(p.T("alerts.unknown", id)))
+		return
+	case already && !fromList:
+		// Someone acknowledged it from the list, so the post still has its button
+	case already:
+		b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource,
+			textReply(p.T("alerts.already_acked", acked.ID, acked.AckedBy, acked.AckedAt.Unix())))
+		return
+	}
+
+	reply := b.alertList(i.GuildID, q.pending())
+	if !fromList {
+		reply = textReply(acked.Text + "\n" + p.T("alerts.acked", acked.AckedBy, acked.AckedAt.Unix()))
+		reply.AllowedMentions = &discordgo.MessageAllowedMentions{}
+	}
+	b.respond(s, i, discordgo.InteractionResponseUpdateMessage, reply)
+}
+
+// acknowledgeAlert marks alert id as handled by userID, unless someone
+// already did, which it reports. The returned alert is zero if it is gone.
+func (b *Bot) acknowledgeAlert(id int, userID string) (acked alert, already bool, q alertQueue, err error) {
+	q, err = b.updateAlerts(func(q *alertQueue) error {
+		a := q.find(id)
+		if a == nil {
+			return nil
+		}
+		if already = a.AckedBy != ""; !already {
+			a.AckedBy, a.AckedAt = userID, time.Now()
+		}
+		acked = *a
+		return nil
+	})
+	if err != nil {
+		return alert{}, false, alertQueue{}, err
+	}
+	if acked.ID != 0 && !already {
+		logger.Info("alert acknowledged", "alert_id", acked.ID, "by", acked.AckedBy)
+		metrics.Incr(b.sink(), metrics.AlertsAcknowledged)
+	}
+	return acked, already, q, nil
+}
@@ -0,0 +1,187 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// newAlertPress builds a press of an alert button by admin1, on the alert's
+// post in the admin channel or on the ephemeral /jolly alerts list.
+func newAlertPress(customID string, perms int64) *discordgo.InteractionCreate {
+	i := newComponentInteraction("guild123", customID)
+	i.Member.Permissions = perms
+	i.Message = &discordgo.Message{ID: "sent1", ChannelID: "admin"}
+	if strings.HasPrefix(customID, alertListAckPrefix) {
+		i.Message.Flags = discordgo.MessageFlagsEphemeral
+	}
+	return i
+}
+
+func TestBot_RaiseAlert(t *testing.T) {
+	tests := []struct {
+		name         string
+		adminChannel string
+		wantPosted   bool
+	}{
+		{"posted to the admin channel", "admin", true},
+		{"queued without an admin channel", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(nil, "jollyskull:1")
+			cfg.AdminChannelID = tt.adminChannel
+			b := New(cfg)
+			mock := &mockSession{}
+
+			b.raiseAlert(mock, "something happened")
+
+			if posted := len(mock.sent) == 1 && mock.sent[0].channelID == "admin"; posted != tt.wantPosted {
+				t.Errorf("posted = %v, want %v (sent %+v)", posted, tt.wantPosted, mock.sent)
+			}
+			var q alertQueue
+			if _, err := b.store.Get(alertsKey, &q); err != nil {
+				t.Fatal(err)
+			}
+			if pending := q.pending(); len(pending) != 1 || pending[0].ID != 1 || pending[0].Text != "something happened" {
+				t.Errorf("pending = %+v, want the alert", pending)
+			}
+		})
+	}
+}
+
+func TestAlertQueue_Trim(t *testing.T) {
+	var q alertQueue
+	for id := 1; id <= maxAlerts+2; id++ {
+		a := alert{ID: id}
+		if id == 5 || id == 7 {
+			a.AckedBy = "admin1"
+		}
+		q.Alerts = append(q.Alerts, a)
+	}
+	q.trim()
+	if len(q.Alerts) != maxAlerts {
+		t.Fatalf("kept %d alerts, want %d", len(q.Alerts), maxAlerts)
+	}
+	if q.find(5) != nil || q.find(7) != nil || q.find(1) == nil {
+		t.Error("acknowledged alerts should be dropped before pending ones")
+	}
+}
+
+func TestBot_HandleAlerts(t *testing.T) {
+	b := New(newTestConfig(nil, "jollyskull:1"))
+	mock := &mockSession{}
+
+	b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"alerts"}))
+	if got := lastResponse(t, mock); got != "No alerts are waiting for a moderator." {
+		t.Errorf("response = %q", got)
+	}
+
+	for n := range maxListedAlerts + 2 {
+		b.raiseAlert(mock, fmt.Sprintf("alert text %d", n+1))
+	}
+	b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"alerts"}))
+	got := lastResponse(t, mock)
+	for _, want := range []string{"7 alert(s)", "**#1**", "alert text 5", "and 2 more"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("response %q should contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "alert text 6") {
+		t.Errorf("response %q should only list the oldest %d alerts", got, maxListedAlerts)
+	}
+	row := mock.responses[len(mock.responses)-1].Data.Components[0].(discordgo.ActionsRow)
+	if len(row.Components) != maxListedAlerts {
+		t.Errorf("got %d buttons, want one per listed alert", len(row.Components))
+	}
+}
+
+func TestBot_HandleAlertButton(t *testing.T) {
+	tests := []struct {
+		name     string
+		presses  []string
+		perms    int64
+		wantType discordgo.InteractionResponseType
+		want     string
+		wantAck  bool
+	}{
+		{
+			name:     "acknowledge from the post",
+			presses:  []string{alertAckPrefix + "1"},
+			perms:    discordgo.PermissionManageGuild,
+			wantType: discordgo.InteractionResponseUpdateMessage,
+			want:     "disk full\n✅ Acknowledged by <@admin1>",
+			wantAck:  true,
+		},
+		{
+			name:     "acknowledge from the list",
+			presses:  []string{alertListAckPrefix + "1"},
+			perms:    discordgo.PermissionManageGuild,
+			wantType: discordgo.InteractionResponseUpdateMessage,
+			want:     "No alerts are waiting",
+			wantAck:  true,
+		},
+		{
+			name:     "second acknowledgement is refused",
+			presses:  []string{alertAckPrefix + "1", alertListAckPrefix + "1"},
+			perms:    discordgo.PermissionManageGuild,
+			wantType: discordgo.InteractionResponseChannelMessageWithSource,
+			want:     "already acknowledged by <@admin1>",
+			wantAck:  true,
+		},
+		{
+			name:     "post updated after acknowledgement from the list",
+			presses:  []string{alertListAckPrefix + "1", alertAckPrefix + "1"},
+			perms:    discordgo.PermissionManageGuild,
+			wantType: discordgo.InteractionResponseUpdateMessage,
+			want:     "✅ Acknowledged by <@admin1>",
+			wantAck:  true,
+		},
+		{
+			name:     "unknown alert",
+			presses:  []string{alertAckPrefix + "9"},
+			perms:    discordgo.PermissionManageGuild,
+			wantType: discordgo.InteractionResponseChannelMessageWithSource,
+			want:     "Alert #9 is no longer in the queue.",
+		},
+		{
+			name:     "needs Manage Server",
+			presses:  []string{alertAckPrefix + "1"},
+			wantType: discordgo.InteractionResponseChannelMessageWithSource,
+			want:     "You need Manage Server",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(nil, "jollyskull:1")
+			cfg.AdminChannelID = "admin"
+			sink := newRecordingSink()
+			b := New(cfg, WithMetrics(sink))
+			mock := &mockSession{}
+			b.raiseAlert(mock, "disk full")
+
+			for _, customID := range tt.presses {
+				b.HandleInteraction(mock, newAlertPress(customID, tt.perms))
+			}
+
+			resp := mock.responses[len(mock.responses)-1]
+			if resp.Type != tt.wantType || !strings.Contains(resp.Data.Content, tt.want) {
+				t.Errorf("response = %v %q, want %v containing %q", resp.Type, resp.Data.Content, tt.wantType, tt.want)
+			}
+			var q alertQueue
+			if _, err := b.store.Get(alertsKey, &q); err != nil {
+				t.Fatal(err)
+			}
+			if acked := q.find(1).AckedBy == "admin1"; acked != tt.wantAck {
+				t.Errorf("acknowledged = %v, want %v", acked, tt.wantAck)
+			}
+			if want := map[bool]int64{true: 1}[tt.wantAck]; sink.counts["alerts.acknowledged"] != want {
+				t.Errorf("acknowledgements counted = %d, want %d", sink.counts["alerts.acknowledged"], want)
+			}
+		})
+	}
+}
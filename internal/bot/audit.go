@@ -0,0 +1,60 @@
+package bot
+
+import (
+	"strings"
+	"text/template"
+
+	"jolly-okurb/internal/actions"
+)
+
+// defaultAuditLogReason is used when AUDIT_LOG_REASON is unset or fails to render.
+const defaultAuditLogReason = "jolly-okurb: {{.Policy}}"
+
+var defaultReasonTemplate = template.Must(template.New("reason").Parse(defaultAuditLogReason))
+
+// maxAuditLogReason is the longest reason Discord accepts, in characters.
+const maxAuditLogReason = 512
+
+// Policies named in audit log reasons.
+const (
+	policySkullOnly     = "skull-only message policy"
+	policySkullReaction = "skull reaction policy"
+	policyMixed         = "mixed content policy"
+	policyEmojiCleanup  = "skull emoji cleanup"
+)
+
+// parseAuditLogReason compiles the configured reason template, falling back
+// to the default so a bad template never blocks moderation.
+func parseAuditLogReason(text string) *template.Template {
+	if text != "" {
+		tmpl, err := template.New("reason").Parse(text)
+		if err == nil {
+			return tmpl
+		}
+		logger.Warn("invalid audit log reason template, using default", "error", err)
+	}
+	return defaultReasonTemplate
+}
+
+// renderReason renders the audit log reason for a, followed by its comment
+// unless the template shows that itself.
+func (b *Bot) renderReason(a actions.Action) string {
+	tmpl := b.reasonTemplate
+	if tmpl == nil {
+		tmpl = defaultReasonTemplate
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, a); err != nil {
+		logger.Warn("failed to render audit log reason", "error", err)
+		sb.Reset()
+		sb.WriteString("jolly-okurb: " + a.Policy)
+	}
+	reason := strings.TrimSpace(sb.String())
+	if a.Comment != "" && !strings.Contains(reason, a.Comment) {
+		reason += " (" + a.Comment + ")"
+	}
+	if r := []rune(reason); len(r) > maxAuditLogReason {
+		reason = string(r[:maxAuditLogReason])
+	}
+	return reason
+}
@@ -0,0 +1,38 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/config"
+)
+
+func TestBot_AuditReason(t *testing.T) {
+	action := actions.Action{Policy: policySkullOnly, ChannelID: "c1", MessageID: "m1", UserID: "u1"}
+	tests := []struct {
+		name     string
+		template string
+		comment  string
+		expected string
+	}{
+		{"default", "", "", "jolly-okurb: skull-only message policy"},
+		{"custom", "💀 {{.Policy}} for <@{{.UserID}}> in {{.ChannelID}}", "", "💀 skull-only message policy for <@u1> in c1"},
+		{"invalid falls back to default", "{{.Policy", "", "jolly-okurb: skull-only message policy"},
+		{"unknown field", "{{.Nope}}", "", "jolly-okurb: skull-only message policy"},
+		{"truncated", strings.Repeat("x", 600), "", strings.Repeat("x", maxAuditLogReason)},
+		{"comment appended", "", "raid cleanup", "jolly-okurb: skull-only message policy (raid cleanup)"},
+		{"comment in template", "{{.Comment}}: {{.Policy}}", "raid cleanup", "raid cleanup: skull-only message policy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(&config.Config{AuditLogReason: tt.template})
+			a := action
+			a.Comment = tt.comment
+			if got := b.renderReason(a); got != tt.expected {
+				t.Errorf("reason = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/correlation"
+	"jolly-okurb/internal/snowflake"
+)
+
+// auditLogActionReactionRemove is the action type exported for skull reaction
+// removals. Discord does not audit reaction removals, so it uses a number well
+// outside Discord's range that tooling can map or filter.
+const auditLogActionReactionRemove discordgo.AuditLogAction = 10000
+
+// Outcomes of an exported action.
+const (
+	auditCompleted = "completed"
+	auditPartial   = "partial" // The skull was removed, but adding jollyskull failed
+	auditFailed    = "failed"  // Nothing changed on Discord
+	auditShadow    = "shadow"  // A shadow rule matched, so nothing changed on purpose
+)
+
+// auditLogEntry mirrors the payload of Discord's GUILD_AUDIT_LOG_ENTRY_CREATE
+// event: an audit log entry with the guild it belongs to. Status is not in
+// Discord's payload; it tells how far the action got.
+type auditLogEntry struct {
+	ID         string                   `json:"id"`
+	GuildID    string                   `json:"guild_id"`
+	ActionType discordgo.AuditLogAction `json:"action_type"`
+	UserID     string                   `json:"user_id"`   // The bot, which performed the action
+	TargetID   string                   `json:"target_id"` // The author of the message or reaction, or the deleted emoji
+	Reason     string                   `json:"reason,omitempty"`
+	Options    auditLogOptions          `json:"options"`
+	Status     string                   `json:"status"`
+	Rule       string                   `json:"rule,omitempty"` // Not in Discord's payload either
+
+	CorrelationID string `json:"correlation_id,omitempty"` // Not in Discord's payload either
+}
+
+type auditLogOptions struct {
+	ChannelID string `json:"channel_id"`
+	MessageID string `json:"message_id,omitempty"`
+	Count     string `json:"count,omitempty"`
+}
+
+// auditExporter writes audit log entries as JSON lines.
+type auditExporter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	seq uint64 // Increment for entry IDs
+}
+
+// WithAuditExport writes every action the bot performs to w as JSON lines in
+// the format of Discord audit log entries.
+func WithAuditExport(w io.Writer) Option {
+	return func(b *Bot) {
+		b.auditExport = &auditExporter{w: w}
+	}
+}
+
+// exportAction writes a with its outcome to the audit export, if one is
+// configured.
+func (b *Bot) exportAction(ctx context.Context, a actions.Action, status string) {
+	if b.auditExport == nil {
+		return
+	}
+	if a.Comment == "" {
+		a.Comment = actions.CommentFrom(ctx)
+	}
+	entry := auditLogEntry{
+		GuildID:  b.config.GuildID,
+		UserID:   b.selfID(),
+		TargetID: a.UserID,
+		Reason:   b.renderReason(a),
+		Options:  auditLogOptions{ChannelID: a.ChannelID},
+		Status:   status,
+		Rule:     a.Rule,
+
+		CorrelationID: correlation.ID(ctx),
+	}
+	switch a.Policy {
+	case policySkullOnly:
+		// Discord's own message deletion entries carry a count, not the message ID
+		entry.ActionType = discordgo.AuditLogActionMessageDelete
+		entry.Options.Count = "1"
+	case policyEmojiCleanup:
+		entry.ActionType = discordgo.AuditLogActionEmojiDelete
+		entry.TargetID = a.EmojiID
+	default:
+		entry.ActionType = auditLogActionReactionRemove
+		entry.Options.MessageID = a.MessageID
+	}
+
+	if err := b.auditExport.write(entry); err != nil {
+		logger.WarnContext(ctx, "failed to export audit log entry", "message_id", a.MessageID, "error", err)
+	}
+}
+
+// write assigns entry an ID and appends it to the export.
+func (e *auditExporter) write(entry auditLogEntry) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	entry.ID = snowflake.Generate(time.Now(), e.seq)
+	e.seq++
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode entry: %w", err)
+	}
+	if _, err := e.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write entry: %w", err)
+	}
+	return nil
+}
+
+// selfID returns the bot's own user ID, or "" before it has logged in.
+func (b *Bot) selfID() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.self
+}
@@ -0,0 +1,146 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/correlation"
+	"jolly-okurb/internal/events"
+)
+
+func TestBot_AuditExport(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := newTestConfig([]string{"target123"}, "jollyskull:123")
+	cfg.GuildID = "guild123"
+	b := New(cfg, WithAuditExport(&buf))
+	b.self = "bot1"
+	mock := &mockSession{}
+
+	b.DeleteMessage(context.Background(), mock, "channel123", "msg1", "target123")
+	b.ReplaceReaction(context.Background(), mock, "channel123", "msg2", "target123", &discordgo.Emoji{Name: "💀"})
+	b.config.DryRun = true
+	b.DeleteMessage(context.Background(), mock, "channel123", "msg3", "target123") // Not performed, so not exported
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("exported %d entries, want 2:\n%s", len(lines), buf.String())
+	}
+	var entries []map[string]any
+	for _, line := range lines {
+		var e map[string]any
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("entry %q is not JSON: %v", line, err)
+		}
+		entries = append(entries, e)
+	}
+
+	tests := []struct {
+		name     string
+		entry    map[string]any
+		field    string
+		expected any
+	}{
+		{"deletion type", entries[0], "action_type", float64(discordgo.AuditLogActionMessageDelete)},
+		{"deletion guild", entries[0], "guild_id", "guild123"},
+		{"deletion user", entries[0], "user_id", "bot1"},
+		{"deletion target", entries[0], "target_id", "target123"},
+		{"deletion reason", entries[0], "reason", "jolly-okurb: skull-only message policy"},
+		{"deletion options", entries[0], "options", map[string]any{"channel_id": "channel123", "count": "1"}},
+		{"deletion status", entries[0], "status", "completed"},
+		{"removal type", entries[1], "action_type", float64(auditLogActionReactionRemove)},
+		{"removal reason", entries[1], "reason", "jolly-okurb: skull reaction policy"},
+		{"removal options", entries[1], "options", map[string]any{"channel_id": "channel123", "message_id": "msg2"}},
+		{"removal status", entries[1], "status", "completed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := json.Marshal(tt.entry[tt.field])
+			want, _ := json.Marshal(tt.expected)
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s = %s, want %s", tt.field, got, want)
+			}
+		})
+	}
+
+	if entries[0]["id"] == entries[1]["id"] {
+		t.Errorf("entries share ID %v", entries[0]["id"])
+	}
+}
+
+func TestBot_AuditExport_Status(t *testing.T) {
+	unknownMessage := &discordgo.RESTError{Message: &discordgo.APIErrorMessage{Code: discordgo.ErrCodeUnknownMessage}}
+	tests := []struct {
+		name      string
+		mock      *mockSession
+		delete    bool
+		want      string // Empty means no entry
+		wantRetry bool
+	}{
+		{name: "removal failed", mock: &mockSession{removeErr: errors.New("HTTP 403 Forbidden")}, want: auditFailed},
+		{name: "add failed", mock: &mockSession{addErr: errors.New("HTTP 403 Forbidden")}, want: auditPartial, wantRetry: true},
+		{name: "message deleted before add", mock: &mockSession{addErr: unknownMessage}, want: auditPartial},
+		{name: "message deleted before removal", mock: &mockSession{removeErr: unknownMessage}},
+		{name: "deletion failed", mock: &mockSession{deleteErr: errors.New("HTTP 403 Forbidden")}, delete: true, want: auditFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			b := New(newTestConfig([]string{"target123"}, "jollyskull:123"), WithAuditExport(&buf))
+
+			if tt.delete {
+				b.DeleteMessage(context.Background(), tt.mock, "channel123", "msg1", "target123")
+			} else {
+				b.ReplaceReaction(context.Background(), tt.mock, "channel123", "msg1", "target123", &discordgo.Emoji{Name: "💀"})
+			}
+
+			var entry auditLogEntry
+			if line := strings.TrimSpace(buf.String()); line != "" {
+				if err := json.Unmarshal([]byte(line), &entry); err != nil {
+					t.Fatalf("entry %q is not JSON: %v", line, err)
+				}
+			}
+			if entry.Status != tt.want {
+				t.Errorf("status = %q, want %q", entry.Status, tt.want)
+			}
+			if retry := b.scheduler.Pending() > 0; retry != tt.wantRetry {
+				t.Errorf("retry scheduled = %v, want %v", retry, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestBot_AuditExportDisabled(t *testing.T) {
+	b := New(&config.Config{})
+	b.exportAction(context.Background(), actions.Action{Policy: policySkullOnly}, auditCompleted) // Must not panic
+}
+
+func TestBot_CorrelationID(t *testing.T) {
+	var buf bytes.Buffer
+	rec := &eventRecorder{}
+	bus := events.NewBus()
+	bus.Subscribe("test", rec)
+	b := New(newTestConfig([]string{"target123"}, "jollyskull:123"), WithAuditExport(&buf), WithEvents(bus))
+
+	ctx := correlation.With(context.Background(), "abc123")
+	b.ReplaceReaction(ctx, &mockSession{}, "channel123", "msg1", "target123", &discordgo.Emoji{Name: "💀"})
+	bus.Close()
+
+	var entry auditLogEntry
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry); err != nil {
+		t.Fatalf("entry %q is not JSON: %v", buf.String(), err)
+	}
+	if entry.CorrelationID != "abc123" {
+		t.Errorf("audit entry correlation ID = %q, want abc123", entry.CorrelationID)
+	}
+	if len(rec.events) != 1 || rec.events[0].CorrelationID != "abc123" {
+		t.Errorf("events = %+v, want one with correlation ID abc123", rec.events)
+	}
+}
@@ -0,0 +1,122 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/i18n"
+	"jolly-okurb/internal/settings"
+)
+
+// startupSummary is the effective configuration the bot came up with.
+type startupSummary struct {
+	Version  string
+	GuildID  string
+	Channels []string // Names of the monitored channels
+	Targets  int
+	Features map[settings.Feature]bool
+	DryRun   bool
+	Store    string // Path of the store file; empty means in memory
+}
+
+// startupSummary collects the effective configuration after initialization.
+func (b *Bot) startupSummary() startupSummary {
+	g := b.guildSettings()
+	sum := startupSummary{
+		Version:  b.version,
+		GuildID:  b.config.GuildID,
+		Targets:  len(g.TargetsWith(b.config.TargetUserIDs)),
+		Features: make(map[settings.Feature]bool),
+		DryRun:   g.DryRunFor("", b.config.DryRun),
+		Store:    b.config.StorePath,
+	}
+	for _, id := range b.monitoredChannelIDs() {
+		sum.Channels = append(sum.Channels, b.channelName(id))
+	}
+	for _, f := range settings.Features {
+		sum.Features[f] = g.Enabled(f)
+	}
+	return sum
+}
+
+// features lists the features as "name=on" pairs in display order.
+func (sum startupSummary) features(p i18n.Printer) string {
+	var parts []string
+	for _, f := range settings.Features {
+		parts = append(parts, string(f)+"="+formatToggle(p, sum.Features[f]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// embed renders the summary for the admin channel.
+func (sum startupSummary) embed(p i18n.Printer) *discordgo.MessageEmbed {
+	channels := p.T("startup.channels.none")
+	if len(sum.Channels) > 0 {
+		channels = "#" + strings.Join(sum.Channels, ", #")
+	}
+	store := p.T("startup.store.memory")
+	if sum.Store != "" {
+		store = p.T("startup.store.file", sum.Store)
+	}
+	color := colorSuccess
+	if len(sum.Channels) == 0 || sum.DryRun {
+		color = colorWarning
+	}
+
+	return &discordgo.MessageEmbed{
+		Title: p.T("startup.title", sum.Version),
+		Color: color,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: p.T("startup.channels"), Value: channels},
+			{Name: p.T("startup.targets"), Value: fmt.Sprint(sum.Targets), Inline: true},
+			{Name: p.T("startup.dryrun"), Value: formatToggle(p, sum.DryRun), Inline: true},
+			{Name: p.T("startup.features"), Value: sum.features(p)},
+			{Name: p.T("startup.store"), Value: store},
+		},
+	}
+}
+
+// announceStartup logs the effective configuration once per process and,
+// with STARTUP_REPORT set, posts it to the admin channel, so a
+// misconfiguration shows up right after boot.
+func (b *Bot) announceStartup(s Session) {
+	b.mu.Lock()
+	announced := b.startupAnnounced
+	b.startupAnnounced = true
+	b.mu.Unlock()
+	if announced {
+		return
+	}
+
+	sum := b.startupSummary()
+	store := "memory"
+	if sum.Store != "" {
+		store = "file:" + sum.Store
+	}
+	logger.Info("startup summary", "version", sum.Version, "guild_id", sum.GuildID, "channels", sum.Channels,
+		"targets", sum.Targets, "features", sum.features(i18n.For(i18n.DefaultLocale)), "dry_run", sum.DryRun, "read_only", b.config.ReadOnly, "store", store)
+	if len(sum.Channels) == 0 {
+		logger.Warn("startup summary: no channels are monitored yet", "channel", b.configuredChannel())
+	}
+	b.logConfigDrift()
+
+	if !b.config.StartupReport || b.config.AdminChannelID == "" {
+		return
+	}
+	if err := b.postEmbed(s, b.config.AdminChannelID, sum.embed(b.printer(b.config.GuildID))); err != nil && !actions.Skipped(err) {
+		logger.Error("failed to post startup summary", "channel_id", b.config.AdminChannelID, "error", err)
+	}
+}
+
+// channelName returns the name of a monitored channel, or its ID if unknown.
+func (b *Bot) channelName(channelID string) string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if name := b.channels[channelID]; name != "" {
+		return name
+	}
+	return channelID
+}
@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"jolly-okurb/internal/i18n"
+	"jolly-okurb/internal/settings"
+)
+
+func TestBot_StartupSummary(t *testing.T) {
+	cfg := newTestConfig([]string{"user1", "user2"}, "jollyskull:123")
+	cfg.GuildID = "guild123"
+	cfg.StorePath = "/data/jolly.json"
+	b := New(cfg, WithVersion("1.2.3"))
+	b.channels = map[string]string{"chan1": "jollyposting", "chan2": "jolly-memes"}
+	b.ready = true
+	if err := b.settings.SetFeature("guild123", settings.FeatureDeletion, false); err != nil {
+		t.Fatal(err)
+	}
+
+	sum := b.startupSummary()
+
+	if sum.Version != "1.2.3" || sum.GuildID != "guild123" || sum.Targets != 2 || sum.Store != "/data/jolly.json" {
+		t.Errorf("unexpected summary %+v", sum)
+	}
+	if got := strings.Join(sum.Channels, ","); got != "jollyposting,jolly-memes" {
+		t.Errorf("Channels = %q, want them in ID order", got)
+	}
+	if got := sum.features(i18n.For("en")); got != "reactions=on, deletion=off, historical=on, notice=on" {
+		t.Errorf("features = %q", got)
+	}
+}
+
+func TestBot_AnnounceStartup(t *testing.T) {
+	tests := []struct {
+		name          string
+		startupReport bool
+		adminChannel  string
+		wantPosts     int
+	}{
+		{name: "posts to the admin channel", startupReport: true, adminChannel: "admin", wantPosts: 1},
+		{name: "only logs without startup report", adminChannel: "admin"},
+		{name: "only logs without admin channel", startupReport: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig([]string{"user1"}, "jollyskull:123")
+			cfg.StartupReport = tt.startupReport
+			cfg.AdminChannelID = tt.adminChannel
+			b := New(cfg)
+			mock := &mockSession{}
+
+			b.announceStartup(mock)
+			b.announceStartup(mock) // Reconnects must not announce again
+
+			if len(mock.sent) != tt.wantPosts {
+				t.Fatalf("expected %d posts, got %d", tt.wantPosts, len(mock.sent))
+			}
+			if tt.wantPosts > 0 {
+				embed := mock.sent[0].embed
+				if embed == nil || embed.Title != "jolly-okurb dev started" {
+					t.Errorf("unexpected summary embed %+v", embed)
+				}
+				if embed.Color != colorWarning {
+					t.Error("expected a warning color when no channels are monitored")
+				}
+			}
+		})
+	}
+}
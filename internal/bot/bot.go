@@ -2,75 +2,534 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log/slog"
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 
+	"jolly-okurb/internal/actions"
 	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/correlation"
+	"jolly-okurb/internal/emoji"
+	"jolly-okurb/internal/events"
+	"jolly-okurb/internal/flags"
+	"jolly-okurb/internal/impersonate"
+	"jolly-okurb/internal/logx"
+	"jolly-okurb/internal/metrics"
+	"jolly-okurb/internal/ratelimit"
+	"jolly-okurb/internal/schedule"
+	"jolly-okurb/internal/sentry"
+	"jolly-okurb/internal/settings"
+	"jolly-okurb/internal/stats"
+	"jolly-okurb/internal/store"
 )
 
 const (
 	HistoricalCutoff = "2025-01-01T00:00:00Z"
 )
 
-// unicodeSkullEmojis lists skull emojis to match.
-// Order matters: ☠️ (with variant selector U+FE0F) must come before ☠ to avoid
-// leaving orphaned variant selectors when stripping.
+// webhookName is the name of the webhooks the bot creates to post as other
+// users. It is how the bot finds them again after a restart.
+const webhookName = "jolly-okurb"
+
+// Loggers of the subsystems in this package.
+var (
+	logger   = logx.For(logx.Bot)
+	scanLog  = logx.For(logx.Scan)
+	rulesLog = logx.For(logx.Rules)
+)
+
+// Backoff bounds for RetryInitialize. Variables so tests can shorten them.
+var (
+	initRetryBaseDelay = 5 * time.Second
+	initRetryMaxDelay  = 2 * time.Minute
+)
+
+// unicodeSkullEmojis lists the skull emojis the skull rule matches.
 var unicodeSkullEmojis = []string{"💀", "☠️", "☠"}
 
 type Bot struct {
-	config    *config.Config
-	channelID string
-	ready     bool
-	mu        sync.RWMutex
-	cancel    context.CancelFunc
+	config   *config.Config
+	channels map[string]string // Monitored channel IDs to names
+	// Newest message ID of each monitored channel when the channels were last
+	// resolved, so scans can skip channels without new messages
+	lastMessageIDs map[string]string
+	ready          bool
+	mu             sync.RWMutex
+	ctx            context.Context // Lifecycle context for background work
+	cancel         context.CancelFunc
+	metrics        metrics.Sink
+	sentry         *sentry.Client    // Receives recovered panics; nil means none
+	executor       *actions.Executor // Performs every mutation on Discord
+	store          store.Store
+	settings       *settings.Manager
+	flags          *flags.Set // Feature flags of risky features, per guild
+	version        string     // Build version, for the startup summary
+
+	reasonTemplate *template.Template    // Renders X-Audit-Log-Reason for deletions and removals
+	noticeTemplate *template.Template    // Renders DELETION_NOTICE; nil means none
+	notices        noticeCooldowns       // When each channel last got a deletion notice
+	auditExport    *auditExporter        // Receives performed actions; nil means none
+	events         *events.Bus           // Publishes performed actions to brokers; nil means none
+	self           string                // The bot's user ID, once logged in
+	webhooks       *impersonate.Webhooks // Posts as other users, such as to the jail channel
+	emojis         *emoji.Manager        // Caches, uploads, and deletes the guild's custom emojis
+
+	scheduler *schedule.Scheduler
+	stats     *stats.Recorder
+	users     map[string]userInfo // Cached names and avatars of target users, by ID
+
+	rateLimits    rateLimitCounter // 429 responses from Discord since startup
+	reacted       reactedSet       // Messages the bot has put a jollyskull on
+	jollyFallback atomic.Bool      // Reacting with JOLLYSKULL_FALLBACK, as jollyskull is unusable
+	roleTargets   roleMembers      // Members holding a DISCORD_TARGET_ROLE_IDS role
+	replaced      replacedEmojis   // Emojis the bot recently replaced, for learning from undos
+	ruleLimits    ruleLimits       // Token buckets of the emoji rules that set a limit
+	alertsMu      sync.Mutex       // Serializes read-modify-write cycles of the alert queue
+	undosMu       sync.Mutex       // Serializes read-modify-write cycles of the undo counts
+	selfTestMu    sync.Mutex       // Held while a self-test runs, so only one does at a time
+
+	skullKeywords atomic.Pointer[config.SkullKeywords] // SKULL_KEYWORDS and the emoji patterns, swapped by Reload
+	emojiRules    atomic.Pointer[[]config.EmojiRule]   // EMOJI_RULES, swapped by Reload
+	storedRules   atomic.Pointer[[]config.EmojiRule]   // Imported with /jolly rules import, winning over EMOJI_RULES
+
+	started      time.Time    // When the bot was created, for its uptime
+	replacements atomic.Int64 // Skulls replaced since started
+	deletions    atomic.Int64 // Skull-only messages deleted since started
+
+	historicalStarted bool
+	historicalRunning bool
+	historicalBeat    atomic.Int64 // Unix nanoseconds of the historical scan's last progress
+	resolverStarted   bool
+	schedulerStarted  bool
+	startupAnnounced  bool
+
+	background sync.WaitGroup // Work that Shutdown waits for, such as the historical scan
 }
 
-func New(cfg *config.Config) *Bot {
-	return &Bot{config: cfg}
+// Option configures optional Bot dependencies.
+type Option func(*Bot)
+
+// WithMetrics sets the sink that receives the bot's counters and timers.
+func WithMetrics(sink metrics.Sink) Option {
+	return func(b *Bot) {
+		b.metrics = sink
+	}
 }
 
-// Initialize resolves the channel ID before the bot starts processing events.
-func (b *Bot) Initialize(s Session) error {
-	channels, err := s.GuildChannels(b.config.GuildID)
+// WithEvents sets the bus that the bot publishes its actions to.
+func WithEvents(bus *events.Bus) Option {
+	return func(b *Bot) {
+		b.events = bus
+	}
+}
+
+// WithSentry sets where recovered panics are reported, in addition to the log.
+func WithSentry(c *sentry.Client) Option {
+	return func(b *Bot) {
+		b.sentry = c
+	}
+}
+
+// WithVersion sets the build version reported in the startup summary.
+func WithVersion(v string) Option {
+	return func(b *Bot) {
+		b.version = v
+	}
+}
+
+// WithStore sets where runtime-managed state such as guild settings is persisted.
+// Without it, state lives in memory and is lost on restart.
+func WithStore(st store.Store) Option {
+	return func(b *Bot) {
+		b.store = st
+	}
+}
+
+func New(cfg *config.Config, opts ...Option) *Bot {
+	b := &Bot{config: cfg, started: time.Now()}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.store == nil {
+		b.store = store.NewMemory()
+	}
+	if b.version == "" {
+		b.version = "dev"
+	}
+	b.skullKeywords.Store(&cfg.SkullKeywords)
+	b.emojiRules.Store(&cfg.EmojiRules)
+	b.reasonTemplate = parseAuditLogReason(cfg.AuditLogReason)
+	b.noticeTemplate = parseDeletionNotice(cfg.DeletionNotice)
+	b.executor = b.newExecutor()
+	b.webhooks = impersonate.New(webhookName)
+	b.emojis = emoji.New()
+	b.settings = settings.NewManager(b.store)
+	b.flags = flags.New(b.store, flagDefaults(cfg)...)
+	b.stats = stats.New(b.store)
+	b.scheduler = schedule.New(b.store)
+	if err := b.scheduler.Load(); err != nil {
+		logger.Error("failed to restore scheduled jobs", "error", err)
+	}
+	b.importEnvironment()
+	b.loadStoredRules()
+	b.warnSuppressedFeatures()
+	return b
+}
+
+// sink returns the configured metrics sink, or a no-op sink if none was set.
+func (b *Bot) sink() metrics.Sink {
+	if b.metrics == nil {
+		return metrics.Nop{}
+	}
+	return b.metrics
+}
+
+// metricTags returns the tags that place a metric: the guild, and the
+// channel, rule, and emoji where given. The sink caps how many distinct
+// values each takes.
+func (b *Bot) metricTags(channelID, rule, emoji string) []string {
+	tags := []string{metrics.LabelGuild + ":" + b.config.GuildID}
+	if channelID != "" {
+		tags = append(tags, metrics.LabelChannel+":"+channelID)
+	}
+	if rule != "" {
+		tags = append(tags, metrics.LabelRule+":"+rule)
+	}
+	if emoji != "" {
+		tags = append(tags, metrics.LabelEmoji+":"+emoji)
+	}
+	return tags
+}
+
+// guildSettings returns the configured guild's settings. If they cannot be
+// loaded the defaults are used, so the bot keeps working with everything on.
+func (b *Bot) guildSettings() settings.Guild {
+	if b.settings == nil {
+		return settings.Guild{}
+	}
+	g, err := b.settings.Guild(b.config.GuildID)
 	if err != nil {
-		return fmt.Errorf("failed to fetch guild channels: %w", err)
+		logger.Error("failed to load guild settings", "guild_id", b.config.GuildID, "error", err)
+		return settings.Guild{}
 	}
+	return g
+}
 
-	channelID := FindChannelByName(channels, b.config.ChannelName)
-	if channelID == "" {
-		return fmt.Errorf("channel '%s' not found in guild", b.config.ChannelName)
+// featureEnabled reports whether f is enabled for the configured guild and
+// the guild is not paused.
+func (b *Bot) featureEnabled(f settings.Feature) bool {
+	g := b.guildSettings()
+	return !g.Paused && g.Enabled(f)
+}
+
+// flagConfigured reports whether the feature f gates is configured, so that
+// deployments which set it up before the flag existed keep it.
+func flagConfigured(cfg *config.Config, f flags.Flag) bool {
+	switch f {
+	case flags.SoftDelete:
+		return cfg.SoftDeleteDelay > 0
+	case flags.Escalation:
+		return len(cfg.Escalation) > 0
+	case flags.AutoExclude:
+		return cfg.UndoExcludeAuto
 	}
+	return false
+}
 
-	b.mu.Lock()
-	b.channelID = channelID
-	b.ready = true
-	b.mu.Unlock()
+// flagDefaults returns the flags on in guilds that do not override them: those
+// FEATURE_FLAGS switches on, and those whose feature is configured unless
+// FEATURE_FLAGS switches them off.
+func flagDefaults(cfg *config.Config) []flags.Flag {
+	var on []flags.Flag
+	for _, f := range flags.All {
+		v, ok := cfg.FeatureFlags[f]
+		if !ok {
+			v = flagConfigured(cfg, f)
+		}
+		if v {
+			on = append(on, f)
+		}
+	}
+	return on
+}
 
-	slog.Info("monitoring channel", "channel", b.config.ChannelName, "id", b.channelID)
-	return nil
+// flagEnabled reports whether the feature flag f is on for the configured
+// guild. If the flags cannot be loaded, f follows its default, so a broken
+// store neither turns on what the environment leaves off nor falls back from
+// soft delete to deleting at once.
+func (b *Bot) flagEnabled(f flags.Flag) bool {
+	if b.flags == nil {
+		return false
+	}
+	on, err := b.flags.Enabled(b.config.GuildID, f)
+	if err != nil {
+		logger.Error("failed to load feature flags, using the default", "guild_id", b.config.GuildID, "flag", f, "default", b.flags.Default(f), "error", err)
+		return b.flags.Default(f)
+	}
+	return on
+}
+
+// warnSuppressedFeatures warns about every configured feature that a feature
+// flag keeps off in the configured guild, as nothing else would tell.
+func (b *Bot) warnSuppressedFeatures() {
+	for _, f := range flags.All {
+		if flagConfigured(b.config, f) && !b.flagEnabled(f) {
+			logger.Warn("configured feature is off by its feature flag", "guild_id", b.config.GuildID, "flag", f, "default", b.flags.Default(f))
+		}
+	}
+}
+
+// isDryRun reports whether actions in channelID should be logged instead of performed.
+func (b *Bot) isDryRun(channelID string) bool {
+	return b.guildSettings().DryRunFor(channelID, b.config.DryRun)
+}
+
+// isExempt reports whether an admin has exempted messageID from all actions.
+func (b *Bot) isExempt(messageID string) bool {
+	return b.guildSettings().IsExempt(messageID)
+}
+
+// newExecutor returns the executor of the bot's mutations, enforcing its
+// configuration.
+func (b *Bot) newExecutor() *actions.Executor {
+	p := actions.Policy{
+		ReadOnly:  b.config.ReadOnly,
+		DryRun:    b.isDryRun,
+		Limits:    make(map[settings.Feature]*ratelimit.Bucket),
+		RuleLimit: b.ruleLimit,
+		JitterMin: b.config.ActionJitterMin,
+		JitterMax: b.config.ActionJitterMax,
+		Reason:    b.renderReason,
+		Metrics:   b.sink(),
+	}
+	if b.config.ActionRateLimit > 0 {
+		p.Budget = ratelimit.New(b.config.ActionRateLimit, b.config.ActionRatePeriod)
+	}
+	if b.config.ReactionRateLimit > 0 {
+		p.Limits[settings.FeatureReactions] = ratelimit.New(b.config.ReactionRateLimit, b.config.ReactionRatePeriod)
+	}
+	if b.config.DeletionRateLimit > 0 {
+		p.Limits[settings.FeatureDeletion] = ratelimit.New(b.config.DeletionRateLimit, b.config.DeletionRatePeriod)
+	}
+	return actions.New(p)
+}
+
+// Initialize resolves the monitored channels before the bot starts processing events.
+// A plain channel name must exist; a glob pattern may match nothing yet, since
+// matching channels created later are picked up from ChannelCreate events. A
+// channel ID must be of a channel of the guild with a monitored type.
+func (b *Bot) Initialize(s Session) error {
+	_, err := b.RefreshChannels(s)
+	return err
 }
 
 func (b *Bot) OnReady(s *discordgo.Session, event *discordgo.Ready) {
-	slog.Info("logged in", "username", event.User.Username, "discriminator", event.User.Discriminator)
+	logger.Info("logged in", "username", event.User.Username, "discriminator", event.User.Discriminator)
+	b.mu.Lock()
+	b.self = event.User.ID
+	b.mu.Unlock()
+
+	if err := b.RegisterCommands(s, event.User.ID); err != nil {
+		logger.Error("command registration failed", "error", err)
+	}
 
+	ctx := b.lifecycleContext()
 	if err := b.Initialize(s); err != nil {
-		slog.Error("initialization failed", "error", err)
+		logger.Error("initialization failed, retrying", "error", err)
+		go b.RetryInitialize(ctx, s)
+	} else {
+		b.startHistorical(ctx, s)
+	}
+	b.startChannelResolver(ctx, s)
+	b.startScheduler(ctx, s)
+	b.requestTargetMembers(s)
+	b.announceStartup(s)
+}
+
+// RetryInitialize retries Initialize with exponential backoff, up to the
+// configured number of attempts. It stops early once the bot is ready, for
+// example because a channel event or the periodic resolver got there first.
+// When attempts run out it raises a not-ready alert; channel events and the
+// resolver can still bring the bot up afterwards.
+func (b *Bot) RetryInitialize(ctx context.Context, s Session) {
+	delay := initRetryBaseDelay
+	for attempt := 1; attempt <= b.config.InitRetryAttempts; attempt++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if b.isReady() {
+			return
+		}
+
+		if err := b.Initialize(s); err != nil {
+			logger.Warn("initialization retry failed", "attempt", attempt, "max_attempts", b.config.InitRetryAttempts, "error", err)
+			delay = min(delay*2, initRetryMaxDelay)
+			continue
+		}
+		logger.Info("initialization succeeded after retry", "attempt", attempt)
+		b.startHistorical(ctx, s)
+		return
+	}
+
+	if !b.isReady() {
+		logger.Error("bot is not ready: initialization retries exhausted, waiting for channel events or re-resolution",
+			"channel", b.configuredChannel(), "attempts", b.config.InitRetryAttempts)
+		metrics.Incr(b.sink(), metrics.NotReady)
+		b.raiseAlert(s, b.printer(b.config.GuildID).T("alert.not_ready", b.configuredChannel(), b.config.InitRetryAttempts))
+	}
+}
+
+// tryInitialize attempts initialization in response to a gateway event while
+// the bot is not ready yet.
+func (b *Bot) tryInitialize(s Session, reason string) {
+	if b.isReady() {
+		return
+	}
+	becameReady, err := b.RefreshChannels(s)
+	if err != nil {
+		logger.Debug("initialization attempt failed", "reason", reason, "error", err)
+		return
+	}
+	if becameReady {
+		logger.Info("initialization succeeded", "reason", reason)
+		b.startHistorical(b.lifecycleContext(), s)
+	}
+}
+
+func (b *Bot) isReady() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.ready
+}
+
+// lifecycleContext returns the context for background work, creating it on
+// first use. It is cancelled by Shutdown.
+func (b *Bot) lifecycleContext() context.Context {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ctx == nil {
+		b.ctx, b.cancel = context.WithCancel(context.Background())
+	}
+	return b.ctx
+}
+
+// startScheduler registers the scheduled job handlers and runs the
+// scheduler once per process.
+func (b *Bot) startScheduler(ctx context.Context, s Session) {
+	b.mu.Lock()
+	started := b.schedulerStarted
+	b.schedulerStarted = true
+	b.mu.Unlock()
+	if started {
 		return
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	b.scheduler.Handle(jobSoftDelete, func(ctx context.Context, job schedule.Job) error {
+		var j softDeleteJob
+		if err := job.Decode(&j); err != nil {
+			return err
+		}
+		return b.FinishSoftDelete(correlation.Continue(ctx, j.CorrelationID), s, j.ChannelID, j.MessageID)
+	})
+	b.scheduler.Handle(jobWeeklyDigest, func(ctx context.Context, job schedule.Job) error {
+		var j digestJob
+		if err := job.Decode(&j); err != nil {
+			return err
+		}
+		return b.PostWeeklyDigest(s, j.Start)
+	})
+	b.scheduler.Handle(jobWeeklyHighlight, func(ctx context.Context, job schedule.Job) error {
+		var j digestJob
+		if err := job.Decode(&j); err != nil {
+			return err
+		}
+		return b.PinWeeklyHighlight(s, j.Start)
+	})
+	b.scheduler.Handle(jobScrubDeleted, b.scrubContent)
+	b.scheduler.Handle(jobDeleteNotice, func(ctx context.Context, job schedule.Job) error {
+		var j noticeJob
+		if err := job.Decode(&j); err != nil {
+			return err
+		}
+		return b.deleteNotice(ctx, s, j.ChannelID, j.MessageID)
+	})
+	b.scheduler.Handle(jobJollySkullRetry, func(ctx context.Context, job schedule.Job) error {
+		var j jollySkullRetryJob
+		if err := job.Decode(&j); err != nil {
+			return err
+		}
+		return b.RetryJollySkull(correlation.Continue(ctx, j.CorrelationID), s, j.ChannelID, j.MessageID, j.Emojis...)
+	})
+
+	if b.config.WeeklyDigest {
+		if err := b.scheduleDigest(nextDigest(time.Now())); err != nil {
+			logger.Warn("failed to persist weekly digest", "error", err)
+		}
+	}
+	if b.config.WeeklyHighlight != "" {
+		if err := b.scheduleHighlight(nextDigest(time.Now())); err != nil {
+			logger.Warn("failed to persist weekly highlight", "error", err)
+		}
+	}
+	b.background.Go(func() { b.scheduler.Run(ctx) })
+}
+
+// startHistorical launches the historical scan once per process, if enabled.
+func (b *Bot) startHistorical(ctx context.Context, s Session) {
+	if !b.featureEnabled(settings.FeatureHistorical) {
+		logger.Info("historical processing disabled")
+		return
+	}
+
+	b.mu.Lock()
+	started := b.historicalStarted
+	b.historicalStarted = true
+	b.mu.Unlock()
+
+	if !started {
+		b.runHistorical(ctx, s, false)
+	}
+}
+
+// runHistorical starts a historical scan in the background unless one is
+// already running, and reports whether it did. A fresh scan discards the
+// checkpoints so every channel is scanned back to the cutoff again.
+func (b *Bot) runHistorical(ctx context.Context, s Session, fresh bool) bool {
 	b.mu.Lock()
-	b.cancel = cancel
+	running := b.historicalRunning
+	b.historicalRunning = true
 	b.mu.Unlock()
-	go b.ProcessHistoricalMessages(ctx, s)
+	if running {
+		return false
+	}
+
+	b.background.Go(func() {
+		defer func() {
+			b.mu.Lock()
+			b.historicalRunning = false
+			b.mu.Unlock()
+		}()
+		if fresh {
+			for _, channelID := range b.monitoredChannelIDs() {
+				b.deleteCheckpoint(channelID)
+			}
+		}
+		b.superviseHistorical(ctx, s)
+	})
+	return true
 }
 
+// Shutdown cancels background work and waits for the historical scan to
+// save its checkpoint and post its report.
 func (b *Bot) Shutdown() {
 	b.mu.RLock()
 	cancel := b.cancel
@@ -78,49 +537,150 @@ func (b *Bot) Shutdown() {
 	if cancel != nil {
 		cancel()
 	}
+	b.background.Wait()
 }
 
 func (b *Bot) OnReactionAdd(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	if r.UserID != b.selfID() && b.IsSkullEmoji(&r.Emoji) {
+		b.recordSkullUses(s, r.ChannelID, r.Emoji.MessageFormat())
+	}
+	b.evaluateShadowRules(r)
 	if !b.ShouldProcessReaction(r) {
 		return
 	}
+	b.rememberMember(r.Member)
 
-	slog.Debug("detected skull reaction from target user", "message_id", r.MessageID, "user_id", r.UserID, "emoji", r.Emoji.Name)
-	b.ReplaceReaction(s, r.MessageID, r.UserID, &r.Emoji)
+	ctx := correlation.Start(context.Background())
+	logger.DebugContext(ctx, "detected skull reaction from target user", "message_id", r.MessageID, "user_id", r.UserID, "emoji", r.Emoji.Name)
+	if !b.isMonitored(r.ChannelID) { // Only DMs get here otherwise
+		b.ReactInDM(ctx, s, r.ChannelID, r.MessageID)
+		return
+	}
+	b.ReplaceReaction(ctx, s, r.ChannelID, r.MessageID, r.UserID, &r.Emoji)
 }
 
 func (b *Bot) OnMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.Member != nil {
+		member := *m.Member // Message members carry no user
+		member.User = m.Author
+		member.GuildID = m.GuildID
+		b.rememberMember(&member)
+	} else {
+		b.rememberUser(m.Author)
+	}
+	if m.Author != nil && m.Author.ID != b.selfID() {
+		b.recordSkullUses(s, m.ChannelID, b.skullEmojisIn(m.Content)...)
+	}
+	ctx := correlation.Start(context.Background())
 	if !b.ShouldDeleteMessage(m) {
+		b.HandleMixedMessage(ctx, s, m)
 		return
 	}
 
-	slog.Debug("detected skull-only message from target user", "message_id", m.ID)
-	if err := s.ChannelMessageDelete(m.ChannelID, m.ID); err != nil {
-		slog.Error("failed to delete message", "message_id", m.ID, "error", err)
+	logger.DebugContext(ctx, "detected skull-only message from target user", "message_id", m.ID)
+	if !b.isMonitored(m.ChannelID) { // Only DMs get here otherwise
+		b.ReactInDM(ctx, s, m.ChannelID, m.ID)
 		return
 	}
-	slog.Info("deleted skull-only message", "message_id", m.ID)
+	if b.config.SoftDeleteDelay > 0 && b.flagEnabled(flags.SoftDelete) {
+		b.SoftDeleteMessage(ctx, s, m.ChannelID, m.ID)
+		return
+	}
+	b.removeMessage(ctx, s, m.Message)
 }
 
-func (b *Bot) ShouldDeleteMessage(m *discordgo.MessageCreate) bool {
-	b.mu.RLock()
-	ready := b.ready
-	channelID := b.channelID
-	b.mu.RUnlock()
+// removeMessage takes a skull-only message out of its channel: it moves it
+// to the jail channel if one is set, else deletes it. If the repost fails,
+// the message is kept rather than lost. A message that is gone is explained
+// with DELETION_NOTICE, if set. It reports whether the message is gone.
+func (b *Bot) removeMessage(ctx context.Context, s Session, m *discordgo.Message) bool {
+	if b.config.JailChannelID != "" {
+		if err := b.jail(ctx, s, m); err != nil && !actions.Skipped(err) {
+			logger.ErrorContext(ctx, "failed to move message to jail, keeping it", "message_id", m.ID, "error", err)
+			metrics.Incr(b.sink(), metrics.MessageMoveFailures)
+			return false
+		}
+	}
+	var authorID string
+	if m.Author != nil {
+		authorID = m.Author.ID
+	}
+	if !b.DeleteMessage(ctx, s, m.ChannelID, m.ID, authorID) {
+		return false
+	}
+	b.retainContent(m.ChannelID, m.ID, authorID, m.Content)
+	b.explainDeletion(ctx, s, m.ChannelID, authorID)
+	return true
+}
 
-	if !ready {
+// DeleteMessage deletes a skull-only message by authorID and reports whether
+// it did. In dry-run mode it only logs what it would do.
+func (b *Bot) DeleteMessage(ctx context.Context, s Session, channelID, messageID, authorID string) bool {
+	action := actions.Action{
+		Kind:      actions.DeleteMessage,
+		Policy:    policySkullOnly,
+		ChannelID: channelID,
+		MessageID: messageID,
+		UserID:    authorID,
+		Feature:   settings.FeatureDeletion,
+		Do: func(opts ...discordgo.RequestOption) error {
+			return s.ChannelMessageDelete(channelID, messageID, opts...)
+		},
+	}
+	err := b.executor.Do(ctx, action)
+	if errors.Is(err, actions.ErrReadOnly) {
+		// Still count it, so that stats show what the bot observed
+		if _, err := b.stats.RecordDeletion(time.Now(), authorID); err != nil {
+			logger.WarnContext(ctx, "failed to record deletion stats", "message_id", messageID, "error", err)
+		}
+	}
+	if actions.Skipped(err) {
+		return false
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to delete message", "message_id", messageID, "error", err)
+		metrics.Incr(b.sink(), metrics.MessageDeleteFailures, b.metricTags(channelID, "", "")...)
+		b.exportAction(ctx, action, auditFailed)
 		return false
 	}
-	if m.ChannelID != channelID {
+	logger.InfoContext(ctx, "deleted skull-only message", "message_id", messageID)
+	metrics.Incr(b.sink(), metrics.MessagesDeleted, b.metricTags(channelID, "", "")...)
+	b.deletions.Add(1)
+	b.exportAction(ctx, action, auditCompleted)
+	b.events.Publish(events.Event{
+		Type:      events.MessageDeleted,
+		Time:      time.Now().UTC(),
+		GuildID:   b.config.GuildID,
+		ChannelID: channelID,
+		MessageID: messageID,
+		UserID:    authorID,
+
+		CorrelationID: correlation.ID(ctx),
+	})
+	count, err := b.stats.RecordDeletion(time.Now(), authorID)
+	if err != nil {
+		logger.WarnContext(ctx, "failed to record deletion stats", "message_id", messageID, "error", err)
+		return true
+	}
+	b.escalate(ctx, s, authorID, count)
+	return true
+}
+
+func (b *Bot) ShouldDeleteMessage(m *discordgo.MessageCreate) bool {
+	if !b.isWatched(m.GuildID, m.ChannelID) {
 		return false
 	}
 	if m.Author == nil || !b.IsTargetUser(m.Author.ID) {
 		return false
 	}
-	return b.IsSkullOnlyMessage(m.Content)
+	if !b.featureEnabled(settings.FeatureDeletion) || b.isExempt(m.ID) {
+		return false
+	}
+	return b.IsSkullOnlyMessage(m.Content) && b.groupAllows(m.Author.ID, m.ChannelID, config.ActionDeletion, b.skullEmojisIn(m.Content)...)
 }
 
-// IsSkullOnlyMessage checks if a message contains only skull-related emojis and whitespace.
+// IsSkullOnlyMessage checks if a message contains only emojis a rule
+// replaces, such as skulls, and whitespace.
 func (b *Bot) IsSkullOnlyMessage(content string) bool {
 	// Remove whitespace
 	content = strings.ReplaceAll(content, " ", "")
@@ -130,13 +690,13 @@ func (b *Bot) IsSkullOnlyMessage(content string) bool {
 		return false
 	}
 
-	// Remove Unicode skull emojis
-	for _, skull := range unicodeSkullEmojis {
-		content = strings.ReplaceAll(content, skull, "")
+	// Remove Unicode emojis of the rules
+	for _, emoji := range b.unicodeEmojis() {
+		content = strings.ReplaceAll(content, emoji, "")
 	}
 
-	// Filter out skull custom emojis, keep everything else
-	remaining := filterCustomEmojis(content, isSkullCustomEmoji)
+	// Filter out custom emojis the rules match, keep everything else
+	remaining := filterCustomEmojis(content, b.isSkullCustomEmoji)
 
 	return remaining == ""
 }
@@ -173,194 +733,252 @@ func filterCustomEmojis(content string, shouldRemove func(emojiTag string) bool)
 	return result.String()
 }
 
-// isSkullCustomEmoji checks if a Discord custom emoji tag contains "skull" (but not "jollyskull").
+// isSkullCustomEmoji checks if a Discord custom emoji tag is named like an
+// emoji a rule replaces, such as a skull as SKULL_KEYWORDS decides.
 // Expects format: <:name:id> or <a:name:id> for animated emojis.
-func isSkullCustomEmoji(emojiTag string) bool {
+func (b *Bot) isSkullCustomEmoji(emojiTag string) bool {
 	parts := strings.Split(emojiTag, ":")
 	if len(parts) < 2 {
 		return false
 	}
-	name := strings.ToLower(parts[1])
-	return strings.Contains(name, "skull") && !strings.Contains(name, "jollyskull")
+	_, ok := b.ruleFor(parts[1])
+	return ok
 }
 
-func (b *Bot) ShouldProcessReaction(r *discordgo.MessageReactionAdd) bool {
-	b.mu.RLock()
-	ready := b.ready
-	channelID := b.channelID
-	b.mu.RUnlock()
+// keywords returns the skull keywords, or the defaults if the config has none.
+func (b *Bot) keywords() config.SkullKeywords {
+	if k := b.skullKeywords.Load(); k != nil && !k.IsZero() {
+		return *k
+	}
+	return config.DefaultSkullKeywords
+}
 
-	if !ready {
-		return false
+// Reload applies the settings of cfg that can change while the bot runs,
+// which are the skull keywords and the emoji rules. The rest of cfg is
+// ignored.
+func (b *Bot) Reload(cfg *config.Config) {
+	old, oldRules := b.keywords(), *b.emojiRules.Load()
+	b.skullKeywords.Store(&cfg.SkullKeywords)
+	b.emojiRules.Store(&cfg.EmojiRules)
+	k := b.keywords()
+	oldMatch, oldExclude := old.Patterns()
+	match, exclude := k.Patterns()
+	if k.String() != old.String() || match != oldMatch || exclude != oldExclude {
+		logger.Info("skull keywords reloaded", "from", old.String(), "to", k.String(), "match_pattern", match, "exclude_pattern", exclude)
 	}
-	if r.ChannelID != channelID {
+	if fmt.Sprint(cfg.EmojiRules) != fmt.Sprint(oldRules) {
+		logger.Info("emoji rules reloaded", "rules", len(cfg.EmojiRules))
+		if b.storedRules.Load() != nil {
+			logger.Warn("emoji rules imported with /jolly rules import override the reloaded EMOJI_RULES")
+		}
+	}
+}
+
+func (b *Bot) ShouldProcessReaction(r *discordgo.MessageReactionAdd) bool {
+	if !b.isWatched(r.GuildID, r.ChannelID) {
 		return false
 	}
 	if !b.IsTargetUser(r.UserID) {
 		return false
 	}
-	if !b.IsSkullEmoji(&r.Emoji) {
+	if !b.handlesEmoji(&r.Emoji) || !b.groupAllows(r.UserID, r.ChannelID, config.ActionReactions, r.Emoji.Name) {
 		return false
 	}
-	return true
+	return b.featureEnabled(settings.FeatureReactions) && !b.isExempt(r.MessageID)
 }
 
-func (b *Bot) ProcessHistoricalMessages(ctx context.Context, s Session) {
-	cutoff, err := time.Parse(time.RFC3339, HistoricalCutoff)
-	if err != nil {
-		slog.Error("invalid historical cutoff date", "error", err)
-		return
+// ReplaceReaction swaps userID's skull reaction on a message for jollyskull,
+// or takes the other steps the user's target group lists, and reports
+// whether it did. In dry-run mode it only
+// logs what it would do.
+func (b *Bot) ReplaceReaction(ctx context.Context, s Session, channelID, messageID, userID string, emoji *discordgo.Emoji) bool {
+	start := time.Now()
+	emojiStr := GetEmojiAPIString(emoji)
+	action := actions.Action{
+		Policy:    policySkullReaction,
+		ChannelID: channelID,
+		MessageID: messageID,
+		UserID:    userID,
+		Feature:   settings.FeatureReactions,
 	}
-	slog.Info("processing historical messages", "cutoff", cutoff.Format("2006-01-02"))
-
-	var beforeID string
-	processed := 0
-	replaced := 0
-
-	for {
-		select {
-		case <-ctx.Done():
-			slog.Info("historical processing cancelled", "processed", processed, "replaced", replaced)
-			return
-		default:
-		}
-
-		messages, err := s.ChannelMessages(b.channelID, 100, beforeID, "", "")
-		if err != nil {
-			slog.Error("failed to fetch messages", "error", err)
-			break
-		}
-
-		if len(messages) == 0 {
-			break
-		}
-
-		for _, msg := range messages {
-			if msg.Timestamp.Before(cutoff) {
-				slog.Info("reached messages before cutoff", "processed", processed, "replaced", replaced)
-				return
-			}
-
-			count := b.ProcessMessageReactions(s, msg)
-			replaced += count
-			processed++
-		}
-
-		beforeID = messages[len(messages)-1].ID
-
-		// Log progress periodically
-		if processed%500 == 0 {
-			slog.Info("historical processing progress", "processed", processed, "replaced", replaced)
-		}
-
-		time.Sleep(500 * time.Millisecond)
+	if rule, ok := b.ruleFor(emoji.Name); ok {
+		action.Rule = rule.Name
 	}
-
-	slog.Info("historical processing complete", "processed", processed, "replaced", replaced)
-}
-
-func (b *Bot) ProcessMessageReactions(s Session, msg *discordgo.Message) int {
-	replaced := 0
-
-	for _, reaction := range msg.Reactions {
-		if !b.IsSkullEmoji(reaction.Emoji) {
-			continue
-		}
-
-		targetUsers := b.findTargetUsersWithReaction(s, msg.ID, reaction.Emoji)
-		for _, userID := range targetUsers {
-			if b.ReplaceReaction(s, msg.ID, userID, reaction.Emoji) {
-				replaced++
-			}
+	tags := b.metricTags(channelID, action.Rule, emoji.Name)
+	defer func() {
+		b.sink().Timing(metrics.ReactionReplaceDuration, time.Since(start), tags...)
+	}()
+	steps := b.config.StepsFor(userID)
+	replacements := b.replacementsFor(userID, emoji)
+	stop := b.indicateProgress(ctx, s, channelID, messageID)
+	removed, err := b.swapSkull(ctx, s, action, emojiStr, steps, replacements)
+	stop()
+	if errors.Is(err, actions.ErrReadOnly) {
+		// Still count it, so that stats show what the bot observed
+		if _, _, err := b.stats.RecordReplacementOn(time.Now(), userID, emoji.MessageFormat(), channelID, messageID); err != nil {
+			logger.WarnContext(ctx, "failed to record replacement stats", "message_id", messageID, "error", err)
 		}
 	}
-
-	return replaced
-}
-
-// findTargetUsersWithReaction paginates through all reactions to find target users.
-// Returns the list of target user IDs that have reacted with the given emoji.
-func (b *Bot) findTargetUsersWithReaction(s Session, messageID string, emoji *discordgo.Emoji) []string {
-	var afterID string
-	var found []string
-	emojiStr := GetEmojiAPIString(emoji)
-
-	for {
-		users, err := s.MessageReactions(b.channelID, messageID, emojiStr, 100, "", afterID)
-		if err != nil {
-			slog.Error("failed to fetch reactions", "message_id", messageID, "emoji", emojiStr, "error", err)
-			return found
-		}
-
-		if len(users) == 0 {
-			return found
-		}
-
-		for _, user := range users {
-			if b.IsTargetUser(user.ID) {
-				found = append(found, user.ID)
-			}
-		}
-
-		// No more pages if we got fewer than requested
-		if len(users) < 100 {
-			return found
-		}
-
-		afterID = users[len(users)-1].ID
+	if actions.Skipped(err) {
+		return false
 	}
-}
-
-func (b *Bot) ReplaceReaction(s Session, messageID, userID string, emoji *discordgo.Emoji) bool {
-	emojiStr := GetEmojiAPIString(emoji)
-	err := s.MessageReactionRemove(b.channelID, messageID, emojiStr, userID)
-	if err != nil {
-		slog.Error("failed to remove skull reaction", "message_id", messageID, "user_id", userID, "emoji", emojiStr, "error", err)
+	if err != nil && !removed && ctx.Err() != nil {
+		logger.DebugContext(ctx, "skull replacement cancelled", "message_id", messageID, "error", err)
+		return false
+	}
+	switch {
+	case err == nil:
+		b.exportAction(ctx, action, auditCompleted)
+	case removed:
+		b.exportAction(ctx, action, auditPartial)
+	case !isUnknownMessage(err):
+		b.exportAction(ctx, action, auditFailed)
+	}
+	if isUnknownMessage(err) {
+		b.messageVanished(ctx, messageID)
 		return false
 	}
-
-	err = s.MessageReactionAdd(b.channelID, messageID, b.config.JollySkullID)
 	if err != nil {
-		slog.Error("failed to add jollyskull reaction", "message_id", messageID, "error", err)
+		logger.ErrorContext(ctx, "failed to replace skull reaction", "message_id", messageID, "user_id", userID, "emoji", emojiStr, "removed", removed, "error", err)
+		metrics.Incr(b.sink(), metrics.ReactionReplaceFailures, tags...)
+		if removed {
+			// The skull is gone with nothing in its place; keep trying to add jollyskull
+			b.scheduleJollySkullRetry(ctx, channelID, messageID, replacements...)
+		}
 		return false
 	}
+	if b.config.ReactionRecheck && removed {
+		b.recheckSkull(ctx, s, action, emojiStr)
+	}
 
-	slog.Debug("replaced skull with jollyskull", "message_id", messageID, "user_id", userID, "emoji", emojiStr)
+	logger.DebugContext(ctx, "replaced skull with jollyskull", "message_id", messageID, "user_id", userID, "emoji", emojiStr, "steps", steps)
+	metrics.Incr(b.sink(), metrics.ReactionsReplaced, tags...)
+	b.replacements.Add(1)
+	b.replaced.add(messageID, emoji.MessageFormat())
+	b.events.Publish(events.Event{
+		Type:      events.ReactionReplaced,
+		Time:      time.Now().UTC(),
+		GuildID:   b.config.GuildID,
+		ChannelID: channelID,
+		MessageID: messageID,
+		UserID:    userID,
+		Emoji:     emoji.MessageFormat(),
+
+		CorrelationID: correlation.ID(ctx),
+	})
+	count, repeat, err := b.stats.RecordReplacementOn(time.Now(), userID, emoji.MessageFormat(), channelID, messageID)
+	if err != nil {
+		logger.WarnContext(ctx, "failed to record replacement stats", "message_id", messageID, "error", err)
+		return true
+	}
+	if repeat {
+		metrics.Incr(b.sink(), metrics.ReactionRepeats, tags...)
+	} else {
+		metrics.Incr(b.sink(), metrics.MessagesJollified, tags...)
+	}
+	if slices.Contains(steps, config.StepEscalate) {
+		b.escalate(ctx, s, userID, count)
+	}
 	return true
 }
 
-func FindChannelByName(channels []*discordgo.Channel, name string) string {
-	for _, ch := range channels {
-		if ch.Name == name && ch.Type == discordgo.ChannelTypeGuildText {
-			return ch.ID
+// swapSkull takes the steps of handling the skull of a.UserID on a.MessageID
+// that act on Discord, in order and under a's policy: removing the skull,
+// adding jollyskull or replacements if any, logging, and a DM. A removal or add that fails stops it;
+// a failed DM is only logged, as the skull is handled by then. Steps that dry
+// run or read-only mode skip do not stop it, so each is logged, and it
+// returns their error at the end. It reports whether the skull was removed,
+// which holds even when a later step fails.
+func (b *Bot) swapSkull(ctx context.Context, s Session, a actions.Action, emojiStr string, steps, replacements []string) (removed bool, err error) {
+	var skipped error
+	for _, step := range steps {
+		switch step {
+		case config.StepRemove:
+			a.Kind = actions.RemoveReaction
+			a.Do = func(opts ...discordgo.RequestOption) error {
+				return s.MessageReactionRemove(a.ChannelID, a.MessageID, emojiStr, a.UserID, opts...)
+			}
+			err := b.executor.Do(ctx, a)
+			if actions.Skipped(err) {
+				skipped = err
+				continue
+			}
+			if err != nil {
+				return false, fmt.Errorf("failed to remove skull reaction: %w", err)
+			}
+			removed = true
+			// The skull is gone, so finish the replacement even if ctx is
+			// cancelled now, rather than leave nothing in its place
+			ctx = context.WithoutCancel(ctx)
+		case config.StepAdd:
+			_, err := b.addJollySkull(ctx, s, a.Policy, a.ChannelID, a.MessageID, replacements...)
+			if actions.Skipped(err) {
+				skipped = err
+				continue
+			}
+			if err != nil {
+				return removed, fmt.Errorf("failed to add jollyskull reaction: %w", err)
+			}
+		case config.StepLog:
+			rulesLog.InfoContext(ctx, "skull handled", "channel_id", a.ChannelID, "message_id", a.MessageID, "user_id", a.UserID, "emoji", emojiStr, "removed", removed)
+		case config.StepDM:
+			err := b.tellUser(ctx, s, a)
+			if actions.Skipped(err) {
+				skipped = err
+				continue
+			}
+			if err != nil {
+				rulesLog.WarnContext(ctx, "failed to tell user about their skull", "message_id", a.MessageID, "user_id", a.UserID, "error", err)
+			}
 		}
 	}
-	return ""
+	return removed, skipped
 }
 
-// IsTargetUser checks if the given user ID is in the target user set (O(1) lookup).
-func (b *Bot) IsTargetUser(userID string) bool {
-	_, ok := b.config.TargetUserIDSet[userID]
-	return ok
+// tellUser sends a.UserID a DM that its skull on a.MessageID was handled.
+func (b *Bot) tellUser(ctx context.Context, s Session, a actions.Action) error {
+	a.Kind = actions.SendDM
+	a.Feature = "" // Part of the replacement, which counted already
+	a.Do = func(...discordgo.RequestOption) error {
+		ch, err := s.UserChannelCreate(a.UserID)
+		if err != nil {
+			return err
+		}
+		_, err = s.ChannelMessageSendComplex(ch.ID, &discordgo.MessageSend{
+			Content: b.printer(b.config.GuildID).T("steps.dm", a.ChannelID),
+		})
+		return err
+	}
+	return b.executor.Do(ctx, a)
 }
 
-// IsSkullEmoji checks if an emoji is a skull-related emoji (but not jollyskull).
-// Matches skull emojis (💀, ☠️, ☠) and any custom emoji with "skull" in its name.
-func (b *Bot) IsSkullEmoji(emoji *discordgo.Emoji) bool {
-	// Standard Unicode skull emojis
-	if slices.Contains(unicodeSkullEmojis, emoji.Name) {
+// messageVanished records that a message was deleted before the bot could act
+// on it, which is expected during purges and not an error.
+func (b *Bot) messageVanished(ctx context.Context, messageID string) {
+	logger.DebugContext(ctx, "message was deleted before the bot could act on it", "message_id", messageID)
+	metrics.Incr(b.sink(), metrics.MessagesVanished)
+}
+
+// IsTargetUser checks if the given user ID is a target user: one holding a
+// target role, or a stored one once they are seeded, else one in the target
+// user set of the environment or added with /jolly targets.
+func (b *Bot) IsTargetUser(userID string) bool {
+	if b.roleTargets.contains(userID) {
 		return true
 	}
-	// Check for custom emojis with "skull" in the name (case-insensitive)
-	name := strings.ToLower(emoji.Name)
-	if !strings.Contains(name, "skull") {
-		return false
-	}
-	// Exclude jollyskull
-	if strings.Contains(name, "jollyskull") {
-		return false
+	g := b.guildSettings()
+	if _, ok := b.config.TargetUserIDSet[userID]; ok && !g.TargetsSeeded {
+		return true
 	}
-	return true
+	return slices.Contains(g.Targets, userID)
+}
+
+// IsSkullEmoji checks if an emoji is one a rule replaces (but not jollyskull):
+// a skull emoji (💀, ☠️, ☠) or custom emoji named like a skull, as
+// SKULL_KEYWORDS decides, or an emoji of EMOJI_RULES.
+func (b *Bot) IsSkullEmoji(emoji *discordgo.Emoji) bool {
+	_, ok := b.ruleFor(emoji.Name)
+	return ok
 }
 
 // GetEmojiAPIString returns the string format needed for Discord API calls.
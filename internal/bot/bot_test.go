@@ -3,26 +3,70 @@ package bot
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 
 	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/events"
+	"jolly-okurb/internal/flags"
+	"jolly-okurb/internal/metrics"
+	"jolly-okurb/internal/schedule"
+	"jolly-okurb/internal/settings"
+	"jolly-okurb/internal/snowflake"
+	"jolly-okurb/internal/store"
 )
 
 type mockSession struct {
+	mu               sync.Mutex // Guards recorded calls against concurrent historical workers
 	channels         []*discordgo.Channel
 	messages         []*discordgo.Message
 	messagePages     [][]*discordgo.Message // For paginated message fetching
 	messageCalls     int                    // Track ChannelMessages calls
+	beforeIDs        []string               // beforeID of every ChannelMessages call
 	reactions        map[string][]*discordgo.User
 	removedReactions []reactionCall
 	addedReactions   []reactionCall
 	removeErr        error
 	addErr           error
 	messagesErr      error
+	channelsErr      error
+	registered       []*discordgo.ApplicationCommand
+	responses        []*discordgo.InteractionResponse
+	edits            []*discordgo.WebhookEdit // Edits of deferred responses
+	sent             []sentMessage
+	sendErr          error
+	deleted          []string // IDs of deleted messages
+	deleteErr        error
+	members          map[string]*discordgo.Member // By user ID
+	pinned           []string                     // IDs of pinned messages
+	unpinned         []string                     // IDs of unpinned messages
+	typing           []string                     // Channel IDs the bot typed in
+	dmChannels       []string                     // Recipient IDs of opened DM channels
+	memberCalls      int
+	webhooks         []*discordgo.Webhook       // Webhooks in any channel, including created ones
+	executed         []*discordgo.WebhookParams // Webhook executions
+	executeErr       error
+	emojis           []*discordgo.Emoji // The guild's emojis, including created ones
+	createdEmojis    []string           // Names of created emojis
+	deletedEmojis    []string           // IDs of deleted emojis
+	emojiErr         error              // Returned when creating or deleting emojis
+}
+
+type sentMessage struct {
+	channelID string
+	content   string
+	embed     *discordgo.MessageEmbed
+	files     []*discordgo.File
+
+	components []discordgo.MessageComponent
 }
 
 // newTestConfig creates a config with TargetUserIDSet populated for testing.
@@ -38,6 +82,34 @@ func newTestConfig(targetUserIDs []string, jollySkullID string) *config.Config {
 	}
 }
 
+// recordingSink counts metrics emitted by the bot, and keeps the tags each
+// counter was last emitted with.
+type recordingSink struct {
+	mu      sync.Mutex
+	counts  map[string]int64
+	tags    map[string][]string
+	timings map[string]int
+}
+
+func newRecordingSink() *recordingSink {
+	return &recordingSink{counts: make(map[string]int64), tags: make(map[string][]string), timings: make(map[string]int)}
+}
+
+func (r *recordingSink) Count(name string, n int64, tags ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counts[name] += n
+	r.tags[name] = tags
+}
+
+func (r *recordingSink) Timing(name string, d time.Duration, tags ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.timings[name]++
+}
+
+func (r *recordingSink) Close() error { return nil }
+
 type reactionCall struct {
 	channelID string
 	messageID string
@@ -45,11 +117,37 @@ type reactionCall struct {
 	userID    string
 }
 
+func (m *mockSession) GuildMember(guildID, userID string, options ...discordgo.RequestOption) (*discordgo.Member, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.memberCalls++
+	if member, ok := m.members[userID]; ok {
+		return member, nil
+	}
+	return nil, &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusNotFound}}
+}
+
 func (m *mockSession) GuildChannels(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Channel, error) {
+	if m.channelsErr != nil {
+		return nil, m.channelsErr
+	}
 	return m.channels, nil
 }
 
+func (m *mockSession) Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	if m.channelsErr != nil {
+		return nil, m.channelsErr
+	}
+	if i := slices.IndexFunc(m.channels, func(ch *discordgo.Channel) bool { return ch.ID == channelID }); i >= 0 {
+		return m.channels[i], nil
+	}
+	return nil, fmt.Errorf("unknown channel %s", channelID)
+}
+
 func (m *mockSession) ChannelMessages(channelID string, limit int, beforeID, afterID, aroundID string, options ...discordgo.RequestOption) ([]*discordgo.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.beforeIDs = append(m.beforeIDs, beforeID)
 	if m.messagesErr != nil {
 		return nil, m.messagesErr
 	}
@@ -77,37 +175,184 @@ func (m *mockSession) MessageReactions(channelID, messageID, emojiID string, lim
 }
 
 func (m *mockSession) MessageReactionRemove(channelID, messageID, emojiID, userID string, options ...discordgo.RequestOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.removedReactions = append(m.removedReactions, reactionCall{channelID, messageID, emojiID, userID})
 	return m.removeErr
 }
 
 func (m *mockSession) MessageReactionAdd(channelID, messageID, emojiID string, options ...discordgo.RequestOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.addedReactions = append(m.addedReactions, reactionCall{channelID, messageID, emojiID, ""})
 	return m.addErr
 }
 
+func (m *mockSession) GuildEmojis(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Emoji, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.emojis), nil
+}
+
+func (m *mockSession) GuildEmojiCreate(guildID string, data *discordgo.EmojiParams, options ...discordgo.RequestOption) (*discordgo.Emoji, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.emojiErr != nil {
+		return nil, m.emojiErr
+	}
+	emoji := &discordgo.Emoji{ID: fmt.Sprintf("emoji%d", len(m.createdEmojis)+1), Name: data.Name, Available: true}
+	m.emojis = append(m.emojis, emoji)
+	m.createdEmojis = append(m.createdEmojis, data.Name)
+	return emoji, nil
+}
+
+func (m *mockSession) GuildEmojiDelete(guildID, emojiID string, options ...discordgo.RequestOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.emojiErr != nil {
+		return m.emojiErr
+	}
+	m.emojis = slices.DeleteFunc(m.emojis, func(e *discordgo.Emoji) bool { return e.ID == emojiID })
+	m.deletedEmojis = append(m.deletedEmojis, emojiID)
+	return nil
+}
+
+func (m *mockSession) UserChannelCreate(recipientID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dmChannels = append(m.dmChannels, recipientID)
+	return &discordgo.Channel{ID: "dm-" + recipientID, Type: discordgo.ChannelTypeDM}, nil
+}
+
+func (m *mockSession) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var embed *discordgo.MessageEmbed
+	if len(data.Embeds) > 0 {
+		embed = data.Embeds[0]
+	}
+	m.sent = append(m.sent, sentMessage{channelID: channelID, content: data.Content, embed: embed, files: data.Files, components: data.Components})
+	if m.sendErr != nil {
+		return nil, m.sendErr
+	}
+	return &discordgo.Message{ID: fmt.Sprintf("sent%d", len(m.sent)), ChannelID: channelID, Embeds: data.Embeds}, nil
+}
+
+func (m *mockSession) ChannelMessage(channelID, messageID string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, msg := range m.messages {
+		if msg.ID == messageID {
+			return msg, nil
+		}
+	}
+	return nil, &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusNotFound}}
+}
+
+func (m *mockSession) ChannelTyping(channelID string, options ...discordgo.RequestOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.typing = append(m.typing, channelID)
+	return nil
+}
+
+func (m *mockSession) ChannelMessagePin(channelID, messageID string, options ...discordgo.RequestOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pinned = append(m.pinned, messageID)
+	return nil
+}
+
+func (m *mockSession) ChannelMessageUnpin(channelID, messageID string, options ...discordgo.RequestOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unpinned = append(m.unpinned, messageID)
+	return nil
+}
+
+func (m *mockSession) ChannelMessageDelete(channelID, messageID string, options ...discordgo.RequestOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleted = append(m.deleted, messageID)
+	return m.deleteErr
+}
+
+func (m *mockSession) ChannelWebhooks(channelID string, options ...discordgo.RequestOption) ([]*discordgo.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var hooks []*discordgo.Webhook
+	for _, h := range m.webhooks {
+		if h.ChannelID == channelID {
+			hooks = append(hooks, h)
+		}
+	}
+	return hooks, nil
+}
+
+func (m *mockSession) WebhookCreate(channelID, name, avatar string, options ...discordgo.RequestOption) (*discordgo.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h := &discordgo.Webhook{ID: fmt.Sprintf("hook%d", len(m.webhooks)+1), ChannelID: channelID, Name: name, Token: "token"}
+	m.webhooks = append(m.webhooks, h)
+	return h, nil
+}
+
+func (m *mockSession) WebhookExecute(webhookID, token string, wait bool, data *discordgo.WebhookParams, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.executed = append(m.executed, data)
+	if m.executeErr != nil {
+		return nil, m.executeErr
+	}
+	return &discordgo.Message{ID: fmt.Sprintf("executed%d", len(m.executed)), WebhookID: webhookID}, nil
+}
+
+func (m *mockSession) ApplicationCommandBulkOverwrite(appID string, guildID string, commands []*discordgo.ApplicationCommand, options ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error) {
+	m.registered = commands
+	return commands, nil
+}
+
+func (m *mockSession) InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse, options ...discordgo.RequestOption) error {
+	m.responses = append(m.responses, resp)
+	return nil
+}
+
+func (m *mockSession) InteractionResponseEdit(interaction *discordgo.Interaction, newresp *discordgo.WebhookEdit, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	m.edits = append(m.edits, newresp)
+	return &discordgo.Message{}, nil
+}
+
 func TestFindChannelByName(t *testing.T) {
 	channels := []*discordgo.Channel{
 		{ID: "1", Name: "general", Type: discordgo.ChannelTypeGuildText},
+		{ID: "4", Name: "jollyposting", Type: discordgo.ChannelTypeGuildVoice},
 		{ID: "2", Name: "jollyposting", Type: discordgo.ChannelTypeGuildText},
 		{ID: "3", Name: "voice-chat", Type: discordgo.ChannelTypeGuildVoice},
-		{ID: "4", Name: "jollyposting", Type: discordgo.ChannelTypeGuildVoice},
+		{ID: "5", Name: "announcements", Type: discordgo.ChannelTypeGuildNews},
 	}
 
+	textOnly := []discordgo.ChannelType{discordgo.ChannelTypeGuildText}
+	all := []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews, discordgo.ChannelTypeGuildVoice}
+
 	tests := []struct {
 		name     string
 		search   string
+		types    []discordgo.ChannelType
 		expected string
 	}{
-		{"finds existing channel", "jollyposting", "2"},
-		{"finds general channel", "general", "1"},
-		{"returns empty for non-existent", "nonexistent", ""},
-		{"ignores voice channels", "voice-chat", ""},
+		{"finds existing channel", "jollyposting", textOnly, "2"},
+		{"finds general channel", "general", textOnly, "1"},
+		{"returns empty for non-existent", "nonexistent", textOnly, ""},
+		{"ignores voice channels when not allowed", "voice-chat", textOnly, ""},
+		{"ignores news channels when not allowed", "announcements", textOnly, ""},
+		{"finds voice channel when allowed", "voice-chat", all, "3"},
+		{"finds news channel when allowed", "announcements", all, "5"},
+		{"prefers earlier types on name clash", "jollyposting", all, "2"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := FindChannelByName(channels, tt.search)
+			result := FindChannelByName(channels, tt.search, tt.types)
 			if result != tt.expected {
 				t.Errorf("FindChannelByName(%q) = %q, want %q", tt.search, result, tt.expected)
 			}
@@ -138,7 +383,9 @@ func TestGetEmojiAPIString(t *testing.T) {
 	}
 }
 
-func TestIsSkullCustomEmoji(t *testing.T) {
+func TestBot_IsSkullCustomEmoji(t *testing.T) {
+	b := &Bot{config: &config.Config{}}
+
 	tests := []struct {
 		name     string
 		emojiTag string
@@ -159,7 +406,7 @@ func TestIsSkullCustomEmoji(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := isSkullCustomEmoji(tt.emojiTag)
+			result := b.isSkullCustomEmoji(tt.emojiTag)
 			if result != tt.expected {
 				t.Errorf("isSkullCustomEmoji(%q) = %v, want %v", tt.emojiTag, result, tt.expected)
 			}
@@ -230,6 +477,29 @@ func TestBot_IsSkullEmoji(t *testing.T) {
 	}
 }
 
+func TestBot_Reload(t *testing.T) {
+	b := New(newTestConfig(nil, "jollyskull:123"))
+	skelly := &discordgo.Emoji{Name: "Skelly", ID: "1"}
+	if b.IsSkullEmoji(skelly) {
+		t.Fatal("skelly should not be a skull before the reload")
+	}
+
+	b.Reload(&config.Config{SkullKeywords: config.SkullKeywords{Match: []string{"skull", "skelly"}, Exclude: []string{"jollyskull"}}})
+
+	if !b.IsSkullEmoji(skelly) || !b.IsSkullOnlyMessage("<:skelly:1> 💀") {
+		t.Error("skelly should be a skull after the reload")
+	}
+	if b.IsSkullEmoji(&discordgo.Emoji{Name: "jollyskull", ID: "123"}) {
+		t.Error("jollyskull should stay excluded")
+	}
+
+	b.Reload(&config.Config{SkullKeywords: config.SkullKeywords{Match: []string{"skull"}, ExcludePattern: regexp.MustCompile("(?i)^skullcandy$")}})
+
+	if b.IsSkullEmoji(&discordgo.Emoji{Name: "SkullCandy", ID: "2"}) || !b.IsSkullEmoji(&discordgo.Emoji{Name: "skull", ID: "3"}) {
+		t.Error("only skullcandy should be excluded by the reloaded pattern")
+	}
+}
+
 func TestBot_IsSkullOnlyMessage(t *testing.T) {
 	b := &Bot{config: &config.Config{}}
 
@@ -271,9 +541,9 @@ func TestBot_IsSkullOnlyMessage(t *testing.T) {
 
 func TestBot_ShouldProcessReaction(t *testing.T) {
 	b := &Bot{
-		config:    newTestConfig([]string{"user456"}, ""),
-		channelID: "chan123",
-		ready:     true,
+		config:   newTestConfig([]string{"user456"}, ""),
+		channels: map[string]string{"chan123": "jollyposting"},
+		ready:    true,
 	}
 
 	tests := []struct {
@@ -361,9 +631,9 @@ func TestBot_ShouldProcessReaction(t *testing.T) {
 
 func TestBot_ShouldProcessReaction_NotReady(t *testing.T) {
 	b := &Bot{
-		config:    newTestConfig([]string{"user456"}, ""),
-		channelID: "chan123",
-		ready:     false,
+		config:   newTestConfig([]string{"user456"}, ""),
+		channels: map[string]string{"chan123": "jollyposting"},
+		ready:    false,
 	}
 
 	reaction := &discordgo.MessageReactionAdd{
@@ -381,9 +651,9 @@ func TestBot_ShouldProcessReaction_NotReady(t *testing.T) {
 
 func TestBot_ShouldProcessReaction_MultipleTargetUsers(t *testing.T) {
 	b := &Bot{
-		config:    newTestConfig([]string{"user1", "user2", "user3"}, ""),
-		channelID: "chan123",
-		ready:     true,
+		config:   newTestConfig([]string{"user1", "user2", "user3"}, ""),
+		channels: map[string]string{"chan123": "jollyposting"},
+		ready:    true,
 	}
 
 	tests := []struct {
@@ -418,11 +688,11 @@ func TestBot_ReplaceReaction(t *testing.T) {
 	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
 
 	t.Run("successful replacement with unicode emoji", func(t *testing.T) {
-		b := &Bot{config: cfg, channelID: "test-channel"}
+		b := &Bot{config: cfg, channels: map[string]string{"test-channel": "jollyposting"}}
 		mock := &mockSession{}
 		emoji := &discordgo.Emoji{Name: "💀"}
 
-		result := b.ReplaceReaction(mock, "msg123", "target-user", emoji)
+		result := b.ReplaceReaction(context.Background(), mock, "test-channel", "msg123", "target-user", emoji)
 
 		if !result {
 			t.Error("ReplaceReaction() should return true on success")
@@ -448,11 +718,11 @@ func TestBot_ReplaceReaction(t *testing.T) {
 	})
 
 	t.Run("successful replacement with custom emoji", func(t *testing.T) {
-		b := &Bot{config: cfg, channelID: "test-channel"}
+		b := &Bot{config: cfg, channels: map[string]string{"test-channel": "jollyposting"}}
 		mock := &mockSession{}
 		emoji := &discordgo.Emoji{Name: "deadskull", ID: "456789"}
 
-		result := b.ReplaceReaction(mock, "msg123", "target-user", emoji)
+		result := b.ReplaceReaction(context.Background(), mock, "test-channel", "msg123", "target-user", emoji)
 
 		if !result {
 			t.Error("ReplaceReaction() should return true on success")
@@ -464,12 +734,30 @@ func TestBot_ReplaceReaction(t *testing.T) {
 		}
 	})
 
+	t.Run("unicode jollyskull", func(t *testing.T) {
+		b := New(newTestConfig([]string{"target-user"}, "🎅"))
+		mock := &mockSession{}
+
+		if !b.ReplaceReaction(context.Background(), mock, "test-channel", "msg123", "target-user", &discordgo.Emoji{Name: "💀"}) {
+			t.Fatal("ReplaceReaction() should return true on success")
+		}
+		if len(mock.addedReactions) != 1 || mock.addedReactions[0].emojiID != "🎅" {
+			t.Errorf("added %+v, want 🎅", mock.addedReactions)
+		}
+		if !b.isJollySkull(&discordgo.Emoji{Name: "🎅"}) {
+			t.Error("isJollySkull() should recognize a Unicode jollyskull")
+		}
+		if got := b.jollified("lol 💀"); got != "lol 🎅" {
+			t.Errorf("jollified() = %q, want the Unicode emoji in place", got)
+		}
+	})
+
 	t.Run("fails on remove error", func(t *testing.T) {
-		b := &Bot{config: cfg, channelID: "test-channel"}
+		b := &Bot{config: cfg, channels: map[string]string{"test-channel": "jollyposting"}}
 		mock := &mockSession{removeErr: errors.New("remove failed")}
 		emoji := &discordgo.Emoji{Name: "💀"}
 
-		result := b.ReplaceReaction(mock, "msg123", "target-user", emoji)
+		result := b.ReplaceReaction(context.Background(), mock, "test-channel", "msg123", "target-user", emoji)
 
 		if result {
 			t.Error("ReplaceReaction() should return false on remove error")
@@ -480,30 +768,295 @@ func TestBot_ReplaceReaction(t *testing.T) {
 	})
 
 	t.Run("fails on add error", func(t *testing.T) {
-		b := &Bot{config: cfg, channelID: "test-channel"}
+		b := New(cfg)
+		b.channels = map[string]string{"test-channel": "jollyposting"}
 		mock := &mockSession{addErr: errors.New("add failed")}
 		emoji := &discordgo.Emoji{Name: "💀"}
 
-		result := b.ReplaceReaction(mock, "msg123", "target-user", emoji)
+		result := b.ReplaceReaction(context.Background(), mock, "test-channel", "msg123", "target-user", emoji)
 
 		if result {
 			t.Error("ReplaceReaction() should return false on add error")
 		}
+		if b.scheduler.Pending() != 1 {
+			t.Errorf("expected the add to be scheduled for a retry, got %d jobs", b.scheduler.Pending())
+		}
+	})
+}
+
+func TestBot_ReplaceReaction_Mode(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        string
+		groups      []config.TargetGroup
+		wantRemoved int
+		wantAdded   int
+	}{
+		{"both", config.ReplaceBoth, nil, 1, 1},
+		{"remove only", config.ReplaceRemove, nil, 1, 0},
+		{"add only", config.ReplaceAdd, nil, 0, 1},
+		{"group overrides", config.ReplaceBoth, []config.TargetGroup{{Name: "g", Users: []string{"target-user"}, Replace: config.ReplaceAdd}}, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+			cfg.ReplaceMode = tt.mode
+			cfg.TargetGroups = tt.groups
+			b := New(cfg)
+			mock := &mockSession{}
+
+			if !b.ReplaceReaction(context.Background(), mock, "test-channel", "msg123", "target-user", &discordgo.Emoji{Name: "💀"}) {
+				t.Fatal("ReplaceReaction() should return true on success")
+			}
+			if len(mock.removedReactions) != tt.wantRemoved {
+				t.Errorf("removed %d reactions, want %d", len(mock.removedReactions), tt.wantRemoved)
+			}
+			if len(mock.addedReactions) != tt.wantAdded {
+				t.Errorf("added %d reactions, want %d", len(mock.addedReactions), tt.wantAdded)
+			}
+		})
+	}
+}
+
+func TestBot_ReplaceReaction_Steps(t *testing.T) {
+	tests := []struct {
+		name        string
+		steps       []string
+		dryRun      bool
+		dmErr       error
+		wantResult  bool
+		wantRemoved int
+		wantAdded   int
+		wantDMs     int
+		wantDryRun  int64
+	}{
+		{"remove, add, and dm", []string{config.StepRemove, config.StepAdd, config.StepLog, config.StepDM}, false, nil, true, 1, 1, 1, 0},
+		{"dm before the add", []string{config.StepDM, config.StepAdd}, false, nil, true, 0, 1, 1, 0},
+		{"failed dm keeps the replacement", []string{config.StepRemove, config.StepDM}, false, errors.New("dms closed"), true, 1, 0, 1, 0},
+		{"dry run logs every step", []string{config.StepRemove, config.StepAdd, config.StepDM}, true, nil, false, 0, 0, 0, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+			cfg.TargetGroups = []config.TargetGroup{{Name: "g", Users: []string{"target-user"}, Steps: tt.steps}}
+			cfg.DryRun = tt.dryRun
+			sink := newRecordingSink()
+			b := New(cfg, WithMetrics(sink))
+			mock := &mockSession{sendErr: tt.dmErr}
+
+			if got := b.ReplaceReaction(context.Background(), mock, "test-channel", "msg123", "target-user", &discordgo.Emoji{Name: "💀"}); got != tt.wantResult {
+				t.Errorf("ReplaceReaction() = %v, want %v", got, tt.wantResult)
+			}
+			if len(mock.removedReactions) != tt.wantRemoved || len(mock.addedReactions) != tt.wantAdded {
+				t.Errorf("removed %d and added %d reactions, want %d and %d", len(mock.removedReactions), len(mock.addedReactions), tt.wantRemoved, tt.wantAdded)
+			}
+			if len(mock.sent) != tt.wantDMs {
+				t.Errorf("tried to send %d DMs, want %d", len(mock.sent), tt.wantDMs)
+			}
+			if sink.counts[metrics.DryRunActions] != tt.wantDryRun {
+				t.Errorf("%s = %d, want %d", metrics.DryRunActions, sink.counts[metrics.DryRunActions], tt.wantDryRun)
+			}
+		})
+	}
+}
+
+func TestBot_ReplaceReaction_Replacements(t *testing.T) {
+	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	cfg.TargetGroups = []config.TargetGroup{{Name: "festive", Users: []string{"target-user"}, Replacements: []string{"🎅", "🎄", "jollyskull:123"}}}
+
+	t.Run("adds each in order", func(t *testing.T) {
+		b := New(cfg)
+		mock := &mockSession{}
+
+		if !b.ReplaceReaction(context.Background(), mock, "test-channel", "msg123", "target-user", &discordgo.Emoji{Name: "💀"}) {
+			t.Fatal("ReplaceReaction() should return true on success")
+		}
+		var got []string
+		for _, r := range mock.addedReactions {
+			got = append(got, r.emojiID)
+		}
+		if want := []string{"🎅", "🎄", "jollyskull:123"}; !slices.Equal(got, want) {
+			t.Errorf("added %v, want %v", got, want)
+		}
+		if !b.isJollySkull(&discordgo.Emoji{Name: "🎅"}) {
+			t.Error("isJollySkull() should recognize a group's replacement")
+		}
+	})
+
+	t.Run("retry keeps the set", func(t *testing.T) {
+		b := New(cfg)
+		mock := &mockSession{addErr: errors.New("add failed")}
+
+		b.ReplaceReaction(context.Background(), mock, "test-channel", "msg123", "target-user", &discordgo.Emoji{Name: "💀"})
+
+		var jobs map[string]schedule.Job
+		if _, err := b.store.Get("schedule/jobs", &jobs); err != nil {
+			t.Fatal(err)
+		}
+		var j jollySkullRetryJob
+		if err := jobs[jobJollySkullRetry+":msg123"].Decode(&j); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(j.Emojis, []string{"🎅", "🎄", "jollyskull:123"}) {
+			t.Errorf("retry job emojis = %v, want the group's replacements", j.Emojis)
+		}
 	})
 }
 
+func TestBot_ReplaceReaction_Metrics(t *testing.T) {
+	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	emoji := &discordgo.Emoji{Name: "💀"}
+
+	t.Run("counts successful replacement", func(t *testing.T) {
+		sink := newRecordingSink()
+		b := New(cfg, WithMetrics(sink))
+		b.channels = map[string]string{"test-channel": "jollyposting"}
+
+		b.ReplaceReaction(context.Background(), &mockSession{}, "test-channel", "msg123", "target-user", emoji)
+
+		if sink.counts[metrics.ReactionsReplaced] != 1 {
+			t.Errorf("%s = %d, want 1", metrics.ReactionsReplaced, sink.counts[metrics.ReactionsReplaced])
+		}
+		want := []string{"guild:" + cfg.GuildID, "channel:test-channel", "rule:skull", "emoji:💀"}
+		if got := sink.tags[metrics.ReactionsReplaced]; !slices.Equal(got, want) {
+			t.Errorf("%s tags = %q, want %q", metrics.ReactionsReplaced, got, want)
+		}
+		if sink.counts[metrics.ReactionReplaceFailures] != 0 {
+			t.Errorf("%s = %d, want 0", metrics.ReactionReplaceFailures, sink.counts[metrics.ReactionReplaceFailures])
+		}
+		if sink.timings[metrics.ReactionReplaceDuration] != 1 {
+			t.Errorf("expected 1 %s timing, got %d", metrics.ReactionReplaceDuration, sink.timings[metrics.ReactionReplaceDuration])
+		}
+	})
+
+	t.Run("counts repeats on a message", func(t *testing.T) {
+		sink := newRecordingSink()
+		b := New(cfg, WithMetrics(sink))
+		b.channels = map[string]string{"test-channel": "jollyposting"}
+
+		for _, messageID := range []string{"msg123", "msg123", "msg456"} {
+			b.ReplaceReaction(context.Background(), &mockSession{}, "test-channel", messageID, "target-user", emoji)
+		}
+
+		if sink.counts[metrics.MessagesJollified] != 2 || sink.counts[metrics.ReactionRepeats] != 1 {
+			t.Errorf("%s, %s = %d, %d, want 2, 1", metrics.MessagesJollified, metrics.ReactionRepeats, sink.counts[metrics.MessagesJollified], sink.counts[metrics.ReactionRepeats])
+		}
+	})
+
+	t.Run("counts failed replacement", func(t *testing.T) {
+		sink := newRecordingSink()
+		b := New(cfg, WithMetrics(sink))
+		b.channels = map[string]string{"test-channel": "jollyposting"}
+
+		b.ReplaceReaction(context.Background(), &mockSession{addErr: errors.New("add failed")}, "test-channel", "msg123", "target-user", emoji)
+
+		if sink.counts[metrics.ReactionsReplaced] != 0 {
+			t.Errorf("%s = %d, want 0", metrics.ReactionsReplaced, sink.counts[metrics.ReactionsReplaced])
+		}
+		if sink.counts[metrics.ReactionReplaceFailures] != 1 {
+			t.Errorf("%s = %d, want 1", metrics.ReactionReplaceFailures, sink.counts[metrics.ReactionReplaceFailures])
+		}
+	})
+
+	unknownMessage := &discordgo.RESTError{Message: &discordgo.APIErrorMessage{Code: discordgo.ErrCodeUnknownMessage, Message: "Unknown Message"}}
+	vanished := []struct {
+		name string
+		mock *mockSession
+	}{
+		{"message deleted before remove", &mockSession{removeErr: unknownMessage}},
+		{"message deleted before add", &mockSession{addErr: unknownMessage}},
+	}
+	for _, tt := range vanished {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := newRecordingSink()
+			b := New(cfg, WithMetrics(sink))
+			b.channels = map[string]string{"test-channel": "jollyposting"}
+
+			if b.ReplaceReaction(context.Background(), tt.mock, "test-channel", "msg123", "target-user", emoji) {
+				t.Error("ReplaceReaction() should return false for a deleted message")
+			}
+			if sink.counts[metrics.MessagesVanished] != 1 {
+				t.Errorf("%s = %d, want 1", metrics.MessagesVanished, sink.counts[metrics.MessagesVanished])
+			}
+			if sink.counts[metrics.ReactionReplaceFailures] != 0 {
+				t.Errorf("%s = %d, want 0", metrics.ReactionReplaceFailures, sink.counts[metrics.ReactionReplaceFailures])
+			}
+		})
+	}
+}
+
+// eventRecorder is an events.Publisher that records what it is given.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func (r *eventRecorder) Publish(ctx context.Context, e events.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+func (r *eventRecorder) Close() error { return nil }
+
+func TestBot_PublishesEvents(t *testing.T) {
+	cfg := newTestConfig([]string{"target123"}, "jollyskull:123")
+	cfg.GuildID = "guild123"
+	rec := &eventRecorder{}
+	bus := events.NewBus()
+	bus.Subscribe("test", rec)
+	b := New(cfg, WithEvents(bus))
+	b.channels = map[string]string{"channel123": "jollyposting"}
+
+	b.DeleteMessage(context.Background(), &mockSession{}, "channel123", "msg1", "target123")
+	b.ReplaceReaction(context.Background(), &mockSession{}, "channel123", "msg2", "target123", &discordgo.Emoji{Name: "💀"})
+	b.ReplaceReaction(context.Background(), &mockSession{addErr: errors.New("add failed")}, "channel123", "msg3", "target123", &discordgo.Emoji{Name: "💀"})
+	bus.Close()
+
+	tests := []struct {
+		name     string
+		typ      string
+		message  string
+		emoji    string
+		expected bool
+	}{
+		{"deletion", events.MessageDeleted, "msg1", "", true},
+		{"replacement", events.ReactionReplaced, "msg2", "💀", true},
+		{"failed replacement", events.ReactionReplaced, "msg3", "💀", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := false
+			for _, e := range rec.events {
+				if e.MessageID != tt.message {
+					continue
+				}
+				found = true
+				if e.Type != tt.typ || e.Emoji != tt.emoji || e.GuildID != "guild123" || e.ChannelID != "channel123" || e.UserID != "target123" {
+					t.Errorf("event = %+v, want a %s of %s by target123", e, tt.typ, tt.message)
+				}
+			}
+			if found != tt.expected {
+				t.Errorf("event published = %v, want %v", found, tt.expected)
+			}
+		})
+	}
+}
+
 func TestBot_ProcessMessageReactions(t *testing.T) {
 	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
 
 	t.Run("replaces skull reaction from target user", func(t *testing.T) {
-		b := &Bot{config: cfg, channelID: "test-channel"}
+		b := &Bot{config: cfg, channels: map[string]string{"test-channel": "jollyposting"}}
 		mock := &mockSession{
 			reactions: map[string][]*discordgo.User{
 				"msg1": {{ID: "other-user"}, {ID: "target-user"}},
 			},
 		}
 		msg := &discordgo.Message{
-			ID: "msg1",
+			ID:        "msg1",
+			ChannelID: "test-channel",
 			Reactions: []*discordgo.MessageReactions{
 				{Emoji: &discordgo.Emoji{Name: "💀"}},
 			},
@@ -517,14 +1070,15 @@ func TestBot_ProcessMessageReactions(t *testing.T) {
 	})
 
 	t.Run("ignores non-skull reactions", func(t *testing.T) {
-		b := &Bot{config: cfg, channelID: "test-channel"}
+		b := &Bot{config: cfg, channels: map[string]string{"test-channel": "jollyposting"}}
 		mock := &mockSession{
 			reactions: map[string][]*discordgo.User{
 				"msg1": {{ID: "target-user"}},
 			},
 		}
 		msg := &discordgo.Message{
-			ID: "msg1",
+			ID:        "msg1",
+			ChannelID: "test-channel",
 			Reactions: []*discordgo.MessageReactions{
 				{Emoji: &discordgo.Emoji{Name: "👍"}},
 			},
@@ -538,14 +1092,15 @@ func TestBot_ProcessMessageReactions(t *testing.T) {
 	})
 
 	t.Run("ignores skull reactions from other users", func(t *testing.T) {
-		b := &Bot{config: cfg, channelID: "test-channel"}
+		b := &Bot{config: cfg, channels: map[string]string{"test-channel": "jollyposting"}}
 		mock := &mockSession{
 			reactions: map[string][]*discordgo.User{
 				"msg1": {{ID: "other-user1"}, {ID: "other-user2"}},
 			},
 		}
 		msg := &discordgo.Message{
-			ID: "msg1",
+			ID:        "msg1",
+			ChannelID: "test-channel",
 			Reactions: []*discordgo.MessageReactions{
 				{Emoji: &discordgo.Emoji{Name: "💀"}},
 			},
@@ -558,8 +1113,33 @@ func TestBot_ProcessMessageReactions(t *testing.T) {
 		}
 	})
 
+	t.Run("add mode skips messages it already reacted to", func(t *testing.T) {
+		cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+		cfg.ReplaceMode = config.ReplaceAdd
+		b := &Bot{config: cfg, channels: map[string]string{"test-channel": "jollyposting"}}
+		mock := &mockSession{
+			reactions: map[string][]*discordgo.User{
+				"msg1": {{ID: "target-user"}},
+			},
+		}
+		msg := &discordgo.Message{
+			ID:        "msg1",
+			ChannelID: "test-channel",
+			Reactions: []*discordgo.MessageReactions{
+				{Emoji: &discordgo.Emoji{Name: "💀"}},
+				{Emoji: &discordgo.Emoji{Name: "jollyskull", ID: "123"}, Me: true},
+			},
+		}
+
+		count := b.ProcessMessageReactions(mock, msg)
+
+		if count != 0 || len(mock.addedReactions) != 0 {
+			t.Errorf("expected the skull left alone, got %d replacements and %d adds", count, len(mock.addedReactions))
+		}
+	})
+
 	t.Run("handles message with no reactions", func(t *testing.T) {
-		b := &Bot{config: cfg, channelID: "test-channel"}
+		b := &Bot{config: cfg, channels: map[string]string{"test-channel": "jollyposting"}}
 		mock := &mockSession{}
 		msg := &discordgo.Message{ID: "msg1", Reactions: nil}
 
@@ -590,14 +1170,74 @@ func TestBot_Initialize(t *testing.T) {
 		if err != nil {
 			t.Errorf("Initialize() unexpected error: %v", err)
 		}
-		if b.channelID != "chan2" {
-			t.Errorf("channelID = %q, want %q", b.channelID, "chan2")
+		if _, ok := b.channels["chan2"]; !ok || len(b.channels) != 1 {
+			t.Errorf("channels = %v, want only %q", b.channels, "chan2")
 		}
 		if !b.ready {
 			t.Error("bot should be ready after initialization")
 		}
 	})
 
+	t.Run("monitors news channel", func(t *testing.T) {
+		cfg := &config.Config{
+			GuildID:      "guild123",
+			ChannelName:  "jollyposting",
+			ChannelTypes: []string{"text", "news"},
+		}
+		b := New(cfg)
+		mock := &mockSession{
+			channels: []*discordgo.Channel{
+				{ID: "chan1", Name: "jollyposting", Type: discordgo.ChannelTypeGuildNews},
+			},
+		}
+
+		if err := b.Initialize(mock); err != nil {
+			t.Fatalf("Initialize() unexpected error: %v", err)
+		}
+		if _, ok := b.channels["chan1"]; !ok {
+			t.Errorf("channels = %v, want %q", b.channels, "chan1")
+		}
+	})
+
+	t.Run("channel by ID", func(t *testing.T) {
+		channels := []*discordgo.Channel{
+			{ID: "chan1", GuildID: "guild123", Name: "jollyposting", Type: discordgo.ChannelTypeGuildText},
+			{ID: "chan2", GuildID: "guild123", Name: "lounge", Type: discordgo.ChannelTypeGuildVoice},
+			{ID: "chan3", GuildID: "other", Name: "jollyposting", Type: discordgo.ChannelTypeGuildText},
+		}
+		tests := []struct {
+			channelID string
+			wantErr   string
+		}{
+			{"chan1", ""},
+			{"chan2", "not a text channel"},
+			{"chan3", "not in guild"},
+			{"chan4", "failed to fetch channel chan4"},
+		}
+		for _, tt := range tests {
+			b := New(&config.Config{GuildID: "guild123", ChannelID: tt.channelID})
+			mock := &mockSession{channels: channels}
+
+			err := b.Initialize(mock)
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Initialize(%s) unexpected error: %v", tt.channelID, err)
+				}
+				if _, ok := b.channels[tt.channelID]; !ok || len(b.channels) != 1 || !b.ready {
+					t.Errorf("Initialize(%s): channels = %v, ready = %v, want only it", tt.channelID, b.channels, b.ready)
+				}
+				continue
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Initialize(%s) error = %v, want it to contain %q", tt.channelID, err, tt.wantErr)
+			}
+			if b.ready {
+				t.Errorf("Initialize(%s) should leave the bot not ready", tt.channelID)
+			}
+		}
+	})
+
 	t.Run("channel not found", func(t *testing.T) {
 		cfg := &config.Config{
 			GuildID:     "guild123",
@@ -618,21 +1258,84 @@ func TestBot_Initialize(t *testing.T) {
 	})
 }
 
+func TestBot_RetryInitialize(t *testing.T) {
+	base, maxDelay := initRetryBaseDelay, initRetryMaxDelay
+	initRetryBaseDelay, initRetryMaxDelay = time.Millisecond, 2*time.Millisecond
+	t.Cleanup(func() { initRetryBaseDelay, initRetryMaxDelay = base, maxDelay })
+
+	newRetryBot := func(attempts int, sink metrics.Sink) *Bot {
+		cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+		cfg.GuildID = "guild123"
+		cfg.ChannelName = "jollyposting"
+		cfg.InitRetryAttempts = attempts
+		b := New(cfg, WithMetrics(sink))
+		b.settings.SetFeature("guild123", settings.FeatureHistorical, false)
+		return b
+	}
+
+	t.Run("succeeds once the channel exists", func(t *testing.T) {
+		sink := newRecordingSink()
+		b := newRetryBot(3, sink)
+		mock := &mockSession{
+			channels: []*discordgo.Channel{
+				{ID: "chan1", Name: "jollyposting", Type: discordgo.ChannelTypeGuildText},
+			},
+		}
+
+		b.RetryInitialize(context.Background(), mock)
+
+		if !b.isMonitored("chan1") {
+			t.Error("bot should be ready after a successful retry")
+		}
+		if sink.counts[metrics.NotReady] != 0 {
+			t.Errorf("%s = %d, want 0", metrics.NotReady, sink.counts[metrics.NotReady])
+		}
+	})
+
+	t.Run("alerts when retries are exhausted", func(t *testing.T) {
+		sink := newRecordingSink()
+		b := newRetryBot(2, sink)
+		mock := &mockSession{channelsErr: errors.New("API error")}
+
+		b.RetryInitialize(context.Background(), mock)
+
+		if b.isReady() {
+			t.Error("bot should not be ready when every retry failed")
+		}
+		if sink.counts[metrics.NotReady] != 1 {
+			t.Errorf("%s = %d, want 1", metrics.NotReady, sink.counts[metrics.NotReady])
+		}
+	})
+
+	t.Run("stops on context cancellation", func(t *testing.T) {
+		sink := newRecordingSink()
+		b := newRetryBot(3, sink)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		b.RetryInitialize(ctx, &mockSession{channelsErr: errors.New("API error")})
+
+		if sink.counts[metrics.NotReady] != 0 {
+			t.Error("cancelled retries should not raise the not-ready alert")
+		}
+	})
+}
+
 func TestBot_ProcessHistoricalMessages(t *testing.T) {
 	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	afterCutoff := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	beforeCutoff := time.Date(2024, 12, 15, 12, 0, 0, 0, time.UTC)
+	afterID, beforeID := snowflake.FromTime(afterCutoff), snowflake.FromTime(beforeCutoff)
 
 	t.Run("processes messages until cutoff", func(t *testing.T) {
-		b := &Bot{config: cfg, channelID: "test-channel"}
+		b := &Bot{config: cfg, channels: map[string]string{"test-channel": "jollyposting"}}
 
 		// Create messages: one after cutoff, one before
-		afterCutoff := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
-		beforeCutoff := time.Date(2024, 12, 15, 12, 0, 0, 0, time.UTC)
-
 		mock := &mockSession{
 			messagePages: [][]*discordgo.Message{
 				{
-					{ID: "msg1", Timestamp: afterCutoff, Reactions: nil},
-					{ID: "msg2", Timestamp: beforeCutoff, Reactions: nil},
+					{ID: afterID, Timestamp: afterCutoff, Reactions: nil},
+					{ID: beforeID, Timestamp: beforeCutoff, Reactions: nil},
 				},
 			},
 		}
@@ -646,14 +1349,14 @@ func TestBot_ProcessHistoricalMessages(t *testing.T) {
 	})
 
 	t.Run("stops on context cancellation", func(t *testing.T) {
-		b := &Bot{config: cfg, channelID: "test-channel"}
+		b := &Bot{config: cfg, channels: map[string]string{"test-channel": "jollyposting"}}
 
 		ctx, cancel := context.WithCancel(context.Background())
 		cancel() // Cancel immediately
 
 		mock := &mockSession{
 			messagePages: [][]*discordgo.Message{
-				{{ID: "msg1", Timestamp: time.Now()}},
+				{{ID: snowflake.FromTime(time.Now()), Timestamp: time.Now()}},
 			},
 		}
 
@@ -666,7 +1369,7 @@ func TestBot_ProcessHistoricalMessages(t *testing.T) {
 	})
 
 	t.Run("handles empty channel", func(t *testing.T) {
-		b := &Bot{config: cfg, channelID: "test-channel"}
+		b := &Bot{config: cfg, channels: map[string]string{"test-channel": "jollyposting"}}
 		mock := &mockSession{
 			messagePages: [][]*discordgo.Message{
 				{}, // Empty first page
@@ -682,7 +1385,7 @@ func TestBot_ProcessHistoricalMessages(t *testing.T) {
 	})
 
 	t.Run("handles fetch error", func(t *testing.T) {
-		b := &Bot{config: cfg, channelID: "test-channel"}
+		b := &Bot{config: cfg, channels: map[string]string{"test-channel": "jollyposting"}}
 		mock := &mockSession{
 			messagesErr: errors.New("API error"),
 		}
@@ -694,26 +1397,23 @@ func TestBot_ProcessHistoricalMessages(t *testing.T) {
 	})
 
 	t.Run("replaces reactions during historical processing", func(t *testing.T) {
-		b := &Bot{config: cfg, channelID: "test-channel"}
-
-		afterCutoff := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
-		beforeCutoff := time.Date(2024, 12, 15, 12, 0, 0, 0, time.UTC)
+		b := &Bot{config: cfg, channels: map[string]string{"test-channel": "jollyposting"}}
 
 		mock := &mockSession{
 			messagePages: [][]*discordgo.Message{
 				{
 					{
-						ID:        "msg1",
+						ID:        afterID,
 						Timestamp: afterCutoff,
 						Reactions: []*discordgo.MessageReactions{
 							{Emoji: &discordgo.Emoji{Name: "💀"}},
 						},
 					},
-					{ID: "msg2", Timestamp: beforeCutoff},
+					{ID: beforeID, Timestamp: beforeCutoff},
 				},
 			},
 			reactions: map[string][]*discordgo.User{
-				"msg1": {{ID: "target-user"}},
+				afterID: {{ID: "target-user"}},
 			},
 		}
 
@@ -731,9 +1431,9 @@ func TestBot_ProcessHistoricalMessages(t *testing.T) {
 
 func TestBot_ShouldDeleteMessage(t *testing.T) {
 	b := &Bot{
-		config:    newTestConfig([]string{"user456"}, ""),
-		channelID: "chan123",
-		ready:     true,
+		config:   newTestConfig([]string{"user456"}, ""),
+		channels: map[string]string{"chan123": "jollyposting"},
+		ready:    true,
 	}
 
 	tests := []struct {
@@ -907,11 +1607,209 @@ func TestBot_ShouldDeleteMessage(t *testing.T) {
 	}
 }
 
+func TestBot_Throttle(t *testing.T) {
+	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	cfg.DeletionRateLimit = 1
+	cfg.DeletionRatePeriod = time.Hour
+	sink := newRecordingSink()
+	b := New(cfg, WithMetrics(sink))
+	mock := &mockSession{}
+
+	if !b.DeleteMessage(context.Background(), mock, "chan1", "msg1", "target-user") {
+		t.Fatal("DeleteMessage() should delete within the limit")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if b.DeleteMessage(ctx, mock, "chan1", "msg2", "target-user") {
+		t.Error("DeleteMessage() should block once the deletion limit is spent")
+	}
+	if sink.counts[metrics.ActionsThrottled] != 1 {
+		t.Errorf("%s = %d, want 1", metrics.ActionsThrottled, sink.counts[metrics.ActionsThrottled])
+	}
+
+	// Other actions and the global budget are unaffected
+	if !b.ReplaceReaction(context.Background(), mock, "chan1", "msg1", "target-user", &discordgo.Emoji{Name: "💀"}) {
+		t.Error("ReplaceReaction() should not be limited by the deletion limit")
+	}
+}
+
+func TestBot_DryRun(t *testing.T) {
+	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	cfg.GuildID = "guild123"
+	cfg.DryRun = true
+	emoji := &discordgo.Emoji{Name: "💀"}
+
+	t.Run("replacement is only logged", func(t *testing.T) {
+		sink := newRecordingSink()
+		b := New(cfg, WithMetrics(sink))
+		mock := &mockSession{}
+
+		if b.ReplaceReaction(context.Background(), mock, "chan1", "msg1", "target-user", emoji) {
+			t.Error("ReplaceReaction() should report nothing replaced in dry-run mode")
+		}
+		if len(mock.removedReactions) != 0 || len(mock.addedReactions) != 0 {
+			t.Error("dry run should not touch reactions")
+		}
+		if sink.counts[metrics.DryRunActions] != 2 {
+			t.Errorf("expected the removal and the add logged as dry-run actions, got %d", sink.counts[metrics.DryRunActions])
+		}
+	})
+
+	t.Run("channel override enforces", func(t *testing.T) {
+		b := New(cfg)
+		off := false
+		b.settings.SetDryRun("guild123", "chan2", &off)
+		mock := &mockSession{}
+
+		if !b.ReplaceReaction(context.Background(), mock, "chan2", "msg1", "target-user", emoji) {
+			t.Error("ReplaceReaction() should act in a channel with dry run off")
+		}
+		if len(mock.removedReactions) != 1 {
+			t.Errorf("expected 1 removed reaction, got %d", len(mock.removedReactions))
+		}
+	})
+}
+
+func TestBot_ReadOnly(t *testing.T) {
+	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	cfg.GuildID = "guild123"
+	cfg.AdminChannelID = "admin"
+	cfg.ReadOnly = true
+	cfg.WeeklyDigest = true
+	sink := newRecordingSink()
+	b := New(cfg, WithMetrics(sink))
+	b.channels = map[string]string{"chan1": "jollyposting"}
+	mock := &mockSession{}
+	ctx := context.Background()
+
+	if b.ReplaceReaction(ctx, mock, "chan1", "msg1", "target-user", &discordgo.Emoji{Name: "💀"}) {
+		t.Error("ReplaceReaction() should report nothing replaced in read-only mode")
+	}
+	if b.DeleteMessage(ctx, mock, "chan1", "msg2", "target-user") {
+		t.Error("DeleteMessage() should report nothing deleted in read-only mode")
+	}
+	b.raiseAlert(mock, "something happened")
+	if err := b.PostWeeklyDigest(mock, time.Now().AddDate(0, 0, -1)); err != nil {
+		t.Errorf("PostWeeklyDigest() error = %v, want the post skipped", err)
+	}
+
+	if len(mock.removedReactions) != 0 || len(mock.addedReactions) != 0 || len(mock.deleted) != 0 || len(mock.sent) != 0 {
+		t.Errorf("read-only mode changed Discord: removed %v, added %v, deleted %v, sent %v",
+			mock.removedReactions, mock.addedReactions, mock.deleted, mock.sent)
+	}
+	if sink.counts[metrics.ReadOnlyBlocked] != 5 { // The replacement's removal and add are refused separately
+		t.Errorf("expected 5 refused mutations, got %d", sink.counts[metrics.ReadOnlyBlocked])
+	}
+	days, err := b.stats.Days(time.Now(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if days[0].Replaced["target-user"] != 1 || days[0].Deleted["target-user"] != 1 {
+		t.Errorf("stats = %+v, want the observed skulls counted", days[0])
+	}
+	var q alertQueue
+	if _, err := b.store.Get(alertsKey, &q); err != nil {
+		t.Fatal(err)
+	}
+	if len(q.pending()) != 1 {
+		t.Errorf("pending alerts = %+v, want the alert queued", q.pending())
+	}
+}
+
+func TestBot_FeatureToggles(t *testing.T) {
+	cfg := newTestConfig([]string{"user456"}, "")
+	cfg.GuildID = "guild123"
+
+	reaction := &discordgo.MessageReactionAdd{
+		MessageReaction: &discordgo.MessageReaction{
+			ChannelID: "chan123",
+			UserID:    "user456",
+			Emoji:     discordgo.Emoji{Name: "💀"},
+		},
+	}
+	message := &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ChannelID: "chan123",
+			Content:   "💀",
+			Author:    &discordgo.User{ID: "user456"},
+		},
+	}
+
+	t.Run("reactions disabled", func(t *testing.T) {
+		b := New(cfg)
+		b.channels = map[string]string{"chan123": "jollyposting"}
+		b.ready = true
+		b.settings.SetFeature("guild123", settings.FeatureReactions, false)
+
+		if b.ShouldProcessReaction(reaction) {
+			t.Error("ShouldProcessReaction() should return false when reactions are disabled")
+		}
+		if !b.ShouldDeleteMessage(message) {
+			t.Error("ShouldDeleteMessage() should be unaffected by the reactions toggle")
+		}
+	})
+
+	t.Run("deletion disabled", func(t *testing.T) {
+		b := New(cfg)
+		b.channels = map[string]string{"chan123": "jollyposting"}
+		b.ready = true
+		b.settings.SetFeature("guild123", settings.FeatureDeletion, false)
+
+		if b.ShouldDeleteMessage(message) {
+			t.Error("ShouldDeleteMessage() should return false when deletion is disabled")
+		}
+		if !b.ShouldProcessReaction(reaction) {
+			t.Error("ShouldProcessReaction() should be unaffected by the deletion toggle")
+		}
+	})
+}
+
+// brokenStore fails every read, as a corrupt or unreachable store would.
+type brokenStore struct{ store.Store }
+
+func (brokenStore) Get(key string, v any) (bool, error) {
+	return false, errors.New("store unavailable")
+}
+
+func TestBot_FlagEnabled(t *testing.T) {
+	tests := []struct {
+		name   string
+		delay  time.Duration
+		flags  map[flags.Flag]bool
+		broken bool
+		want   bool
+	}{
+		{"not configured", 0, nil, false, false},
+		{"configured before flags existed", 30 * time.Second, nil, false, true},
+		{"switched off by FEATURE_FLAGS", 30 * time.Second, map[flags.Flag]bool{flags.SoftDelete: false}, false, false},
+		{"switched on by FEATURE_FLAGS", 0, map[flags.Flag]bool{flags.SoftDelete: true}, false, true},
+		{"store failure keeps the default", 30 * time.Second, nil, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig([]string{"user456"}, "")
+			cfg.GuildID = "guild123"
+			cfg.SoftDeleteDelay = tt.delay
+			cfg.FeatureFlags = tt.flags
+			var s store.Store = store.NewMemory()
+			if tt.broken {
+				s = brokenStore{s}
+			}
+			b := New(cfg, WithStore(s))
+
+			if got := b.flagEnabled(flags.SoftDelete); got != tt.want {
+				t.Errorf("flagEnabled(soft_delete) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestBot_ShouldDeleteMessage_NotReady(t *testing.T) {
 	b := &Bot{
-		config:    newTestConfig([]string{"user456"}, ""),
-		channelID: "chan123",
-		ready:     false,
+		config:   newTestConfig([]string{"user456"}, ""),
+		channels: map[string]string{"chan123": "jollyposting"},
+		ready:    false,
 	}
 
 	message := &discordgo.MessageCreate{
@@ -950,4 +1848,22 @@ func TestBot_Shutdown(t *testing.T) {
 		// Should not panic
 		b.Shutdown()
 	})
+
+	t.Run("waits for the historical scan", func(t *testing.T) {
+		cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+		cfg.AdminChannelID = "admin"
+		b := New(cfg)
+		b.channels = map[string]string{"chan": "jollyposting"}
+		b.ready = true
+		h := skullHistory(time.Now(), 1000, time.Minute)
+
+		b.startHistorical(b.lifecycleContext(), h)
+		b.Shutdown()
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if len(h.sent) != 1 {
+			t.Errorf("expected the scan report to be posted before Shutdown returns, got %d", len(h.sent))
+		}
+	})
 }
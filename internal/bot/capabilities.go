@@ -0,0 +1,156 @@
+package bot
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/config"
+)
+
+// inviteScopes are the OAuth2 scopes the bot is installed with.
+var inviteScopes = []string{"bot", "applications.commands"}
+
+// capability is something the bot does, with the gateway intents and channel
+// permissions it needs. Intents and the invite link are both derived from the
+// capabilities a configuration enables, so they cannot drift apart.
+type capability struct {
+	name        string
+	enabled     func(cfg *config.Config) bool
+	intents     discordgo.Intent
+	permissions int64
+}
+
+func always(*config.Config) bool { return true }
+
+var capabilities = []capability{
+	{
+		name:        "channels",
+		enabled:     always,
+		intents:     discordgo.IntentsGuilds,
+		permissions: discordgo.PermissionViewChannel,
+	},
+	{
+		name:    "reactions",
+		enabled: always,
+		intents: discordgo.IntentsGuildMessageReactions,
+		// Adding a reaction needs the message history; removing others' needs Manage Messages
+		permissions: discordgo.PermissionAddReactions | discordgo.PermissionUseExternalEmojis |
+			discordgo.PermissionReadMessageHistory | discordgo.PermissionManageMessages,
+	},
+	{
+		name:    "emojis",
+		enabled: always,
+		intents: discordgo.IntentsGuildEmojis, // Keeps the emoji cache and the jollyskull check current
+	},
+	{
+		name:        "deletion",
+		enabled:     always,
+		intents:     discordgo.IntentsGuildMessages | discordgo.IntentMessageContent,
+		permissions: discordgo.PermissionManageMessages,
+	},
+	{
+		name:        "historical",
+		enabled:     always,
+		permissions: discordgo.PermissionReadMessageHistory,
+	},
+	{
+		name:    "members",
+		enabled: always,
+		intents: discordgo.IntentGuildMembers, // Names and avatars of target users, and who holds a target role
+	},
+	{
+		name:        "mixed",
+		enabled:     always, // The notify policy can be chosen at runtime
+		permissions: discordgo.PermissionSendMessages,
+	},
+	{
+		name: "posts",
+		enabled: func(cfg *config.Config) bool {
+			return cfg.AdminChannelID != "" || cfg.WeeklyDigest || cfg.WeeklyHighlight == config.HighlightPost
+		},
+		permissions: discordgo.PermissionSendMessages | discordgo.PermissionEmbedLinks | discordgo.PermissionAttachFiles,
+	},
+	{
+		name:        "jail",
+		enabled:     func(cfg *config.Config) bool { return cfg.JailChannelID != "" },
+		permissions: discordgo.PermissionManageWebhooks, // Reposts go through a webhook in the jail channel
+	},
+	{
+		name:        "theme emojis",
+		enabled:     func(cfg *config.Config) bool { return cfg.ThemeEmojiUpload },
+		permissions: discordgo.PermissionManageGuildExpressions, // Uploads and deletes a theme pack's custom emojis
+	},
+	{
+		name:        "emoji cleanup",
+		enabled:     func(cfg *config.Config) bool { return cfg.EmojiDeleteSuggest > 0 },
+		permissions: discordgo.PermissionManageGuildExpressions, // Deletes custom skull emojis an admin approves
+	},
+	{
+		name:    "direct messages",
+		enabled: func(cfg *config.Config) bool { return cfg.DirectMessages },
+		intents: discordgo.IntentsDirectMessages | discordgo.IntentsDirectMessageReactions,
+	},
+}
+
+// Intents returns the gateway intents cfg needs.
+func Intents(cfg *config.Config) discordgo.Intent {
+	var intents discordgo.Intent
+	for _, c := range capabilities {
+		if c.enabled(cfg) {
+			intents |= c.intents
+		}
+	}
+	return intents
+}
+
+// Permissions returns the channel permissions cfg needs.
+func Permissions(cfg *config.Config) int64 {
+	var perms int64
+	for _, c := range capabilities {
+		if c.enabled(cfg) {
+			perms |= c.permissions
+		}
+	}
+	return perms
+}
+
+// InviteURL returns the OAuth2 link that adds application appID to the
+// configured guild with the permissions cfg needs.
+func InviteURL(appID string, cfg *config.Config) string {
+	q := url.Values{}
+	q.Set("client_id", appID)
+	q.Set("permissions", strconv.FormatInt(Permissions(cfg), 10))
+	q.Set("scope", strings.Join(inviteScopes, " "))
+	if cfg.GuildID != "" {
+		q.Set("guild_id", cfg.GuildID)
+		q.Set("disable_guild_select", "true")
+	}
+	return "https://discord.com/oauth2/authorize?" + q.Encode()
+}
+
+// ApplicationID extracts the application ID from a bot token, whose first
+// part is the base64-encoded ID of the bot user, which shares its ID with
+// the application.
+func ApplicationID(token string) (string, error) {
+	first, _, _ := strings.Cut(strings.TrimPrefix(token, "Bot "), ".")
+	id, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(first, "="))
+	if err != nil || !isSnowflake(string(id)) {
+		return "", fmt.Errorf("failed to read application ID from DISCORD_TOKEN")
+	}
+	return string(id), nil
+}
+
+// handleInvite replies with the invite link for the current configuration.
+// Only administrators may see it, as it is how the bot is installed elsewhere.
+func (b *Bot) handleInvite(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	p := b.printer(i.GuildID)
+	if i.Member == nil || i.Member.Permissions&discordgo.PermissionAdministrator == 0 {
+		return textReply(p.T("invite.denied")), nil
+	}
+	return textReply(p.T("invite.link", InviteURL(i.AppID, b.config))), nil
+}
@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/config"
+)
+
+func TestIntents(t *testing.T) {
+	base := discordgo.IntentsGuilds | discordgo.IntentsGuildMessages | discordgo.IntentsGuildMessageReactions |
+		discordgo.IntentGuildMembers | discordgo.IntentMessageContent | discordgo.IntentsGuildEmojis
+	tests := []struct {
+		name     string
+		cfg      config.Config
+		expected discordgo.Intent
+	}{
+		{"default", config.Config{}, base},
+		{"direct messages", config.Config{DirectMessages: true}, base | discordgo.IntentsDirectMessages | discordgo.IntentsDirectMessageReactions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Intents(&tt.cfg); got != tt.expected {
+				t.Errorf("Intents() = %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	base := int64(discordgo.PermissionViewChannel | discordgo.PermissionAddReactions | discordgo.PermissionUseExternalEmojis |
+		discordgo.PermissionReadMessageHistory | discordgo.PermissionManageMessages | discordgo.PermissionSendMessages)
+	posts := int64(discordgo.PermissionEmbedLinks | discordgo.PermissionAttachFiles)
+	tests := []struct {
+		name     string
+		cfg      config.Config
+		expected int64
+	}{
+		{"default", config.Config{}, base},
+		{"admin channel", config.Config{AdminChannelID: "admin1"}, base | posts},
+		{"weekly digest", config.Config{WeeklyDigest: true}, base | posts},
+		{"theme emoji upload", config.Config{ThemeEmojiUpload: true}, base | discordgo.PermissionManageGuildExpressions},
+		{"emoji cleanup", config.Config{AdminChannelID: "admin", EmojiDeleteSuggest: 50}, base | posts | discordgo.PermissionManageGuildExpressions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Permissions(&tt.cfg); got != tt.expected {
+				t.Errorf("Permissions() = %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInviteURL(t *testing.T) {
+	cfg := &config.Config{GuildID: "guild123"}
+	u, err := url.Parse(InviteURL("app1", cfg))
+	if err != nil {
+		t.Fatalf("invalid URL: %v", err)
+	}
+	q := u.Query()
+	tests := []struct {
+		param    string
+		expected string
+	}{
+		{"client_id", "app1"},
+		{"scope", "bot applications.commands"},
+		{"permissions", "339008"},
+		{"guild_id", "guild123"},
+		{"disable_guild_select", "true"},
+	}
+	for _, tt := range tests {
+		if got := q.Get(tt.param); got != tt.expected {
+			t.Errorf("%s = %q, want %q", tt.param, got, tt.expected)
+		}
+	}
+}
+
+func TestApplicationID(t *testing.T) {
+	encoded := base64.RawStdEncoding.EncodeToString([]byte("123456789012345678"))
+	tests := []struct {
+		name     string
+		token    string
+		expected string
+		wantErr  bool
+	}{
+		{"bot token", encoded + ".GxYzAb.signature", "123456789012345678", false},
+		{"with prefix", "Bot " + encoded + ".GxYzAb.signature", "123456789012345678", false},
+		{"padded", base64.StdEncoding.EncodeToString([]byte("1234567")) + ".x.y", "1234567", false},
+		{"not base64", "!!!.x.y", "", true},
+		{"not a snowflake", base64.RawStdEncoding.EncodeToString([]byte("hello")) + ".x.y", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplicationID(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplicationID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("ApplicationID() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBot_HandleInvite(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions int64
+		contains    string
+	}{
+		{"administrator", discordgo.PermissionAdministrator, "https://discord.com/oauth2/authorize?client_id=app1"},
+		{"manage server only", discordgo.PermissionManageGuild, "You need Administrator"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(&config.Config{GuildID: "guild123"})
+			mock := &mockSession{}
+			i := newCommandInteraction("guild123", []string{"invite"})
+			i.AppID = "app1"
+			i.Member = &discordgo.Member{User: &discordgo.User{ID: "admin1"}, Permissions: tt.permissions}
+
+			b.HandleInteraction(mock, i)
+
+			if got := lastResponse(t, mock); !strings.Contains(got, tt.contains) {
+				t.Errorf("response %q should contain %q", got, tt.contains)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// channelTypesByName maps DISCORD_CHANNEL_TYPES entries to Discord channel types.
+// Voice channels are included for their built-in text chat.
+var channelTypesByName = map[string]discordgo.ChannelType{
+	"text":  discordgo.ChannelTypeGuildText,
+	"news":  discordgo.ChannelTypeGuildNews,
+	"voice": discordgo.ChannelTypeGuildVoice,
+}
+
+// FindChannelByName returns the ID of the channel called name whose type is one of types.
+// Types are tried in order, so earlier types win when several channels share a name.
+func FindChannelByName(channels []*discordgo.Channel, name string, types []discordgo.ChannelType) string {
+	for _, t := range types {
+		for _, ch := range channels {
+			if ch.Name == name && ch.Type == t {
+				return ch.ID
+			}
+		}
+	}
+	return ""
+}
+
+// MatchChannels returns every channel of an allowed type whose name matches the glob pattern.
+func MatchChannels(channels []*discordgo.Channel, pattern string, types []discordgo.ChannelType) []*discordgo.Channel {
+	var matched []*discordgo.Channel
+	for _, ch := range channels {
+		if !slices.Contains(types, ch.Type) {
+			continue
+		}
+		if ok, _ := path.Match(pattern, ch.Name); ok {
+			matched = append(matched, ch)
+		}
+	}
+	return matched
+}
+
+// resolveChannels looks up the channels to monitor, keyed by ID.
+func (b *Bot) resolveChannels(s Session) (map[string]*discordgo.Channel, error) {
+	if b.config.ChannelID != "" {
+		ch, err := b.channelByID(s)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]*discordgo.Channel{ch.ID: ch}, nil
+	}
+
+	channels, err := s.GuildChannels(b.config.GuildID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch guild channels: %w", err)
+	}
+
+	resolved := make(map[string]*discordgo.Channel)
+	if isChannelPattern(b.config.ChannelName) {
+		for _, ch := range MatchChannels(channels, b.config.ChannelName, b.channelTypes()) {
+			resolved[ch.ID] = ch
+		}
+		if len(resolved) == 0 {
+			logger.Warn("no channels match pattern yet", "pattern", b.config.ChannelName)
+		}
+		return resolved, nil
+	}
+
+	channelID := FindChannelByName(channels, b.config.ChannelName, b.channelTypes())
+	if channelID == "" {
+		return nil, fmt.Errorf("channel '%s' not found in guild", b.config.ChannelName)
+	}
+	for _, ch := range channels {
+		if ch.ID == channelID {
+			resolved[channelID] = ch
+		}
+	}
+	return resolved, nil
+}
+
+// channelByID fetches the channel set by DISCORD_CHANNEL_ID, skipping the
+// lookup by name, and checks that it is a channel of the guild the bot can
+// monitor.
+func (b *Bot) channelByID(s Session) (*discordgo.Channel, error) {
+	ch, err := s.Channel(b.config.ChannelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch channel %s: %w", b.config.ChannelID, err)
+	}
+	if ch.GuildID != b.config.GuildID {
+		return nil, fmt.Errorf("channel %s is not in guild %s", ch.ID, b.config.GuildID)
+	}
+	if !slices.Contains(b.channelTypes(), ch.Type) {
+		return nil, fmt.Errorf("channel %s (#%s) is not a text channel, or of another type in DISCORD_CHANNEL_TYPES", ch.ID, ch.Name)
+	}
+	return ch, nil
+}
+
+// configuredChannel returns the channel the bot is configured to monitor, by
+// ID or by name, for messages.
+func (b *Bot) configuredChannel() string {
+	if b.config.ChannelID != "" {
+		return b.config.ChannelID
+	}
+	return b.config.ChannelName
+}
+
+// RefreshChannels re-runs channel resolution and replaces the monitored set,
+// logging only what changed. It reports whether the bot was not ready before
+// and is now, so callers can start work that waits on readiness.
+// On error the current set is kept so a transient API failure never stops monitoring.
+func (b *Bot) RefreshChannels(s Session) (becameReady bool, err error) {
+	channels, err := b.resolveChannels(s)
+	if err != nil {
+		return false, err
+	}
+	resolved := make(map[string]string, len(channels))
+	lastMessageIDs := make(map[string]string, len(channels))
+	for id, ch := range channels {
+		resolved[id] = ch.Name
+		lastMessageIDs[id] = ch.LastMessageID
+	}
+
+	b.mu.Lock()
+	previous := b.channels
+	wasReady := b.ready
+	b.channels = resolved
+	b.lastMessageIDs = lastMessageIDs
+	b.ready = true
+	b.mu.Unlock()
+
+	for id, name := range resolved {
+		if _, ok := previous[id]; !ok {
+			logger.Info("monitoring channel", "channel_name", name, "channel_id", id)
+		}
+	}
+	for id, name := range previous {
+		if _, ok := resolved[id]; !ok {
+			logger.Info("stopped monitoring channel", "channel_name", name, "channel_id", id)
+		}
+	}
+	return !wasReady, nil
+}
+
+// startChannelResolver launches the periodic resolver once per process, if enabled.
+func (b *Bot) startChannelResolver(ctx context.Context, s Session) {
+	if b.config.ChannelResolveInterval <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	started := b.resolverStarted
+	b.resolverStarted = true
+	b.mu.Unlock()
+
+	if !started {
+		go b.RunChannelResolver(ctx, s, b.config.ChannelResolveInterval)
+	}
+}
+
+// RunChannelResolver periodically re-resolves the monitored channels until ctx
+// is done. This heals missed channel events and brings the bot up if its
+// channel did not exist, or the API failed, when it started.
+func (b *Bot) RunChannelResolver(ctx context.Context, s Session, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		becameReady, err := b.RefreshChannels(s)
+		if err != nil {
+			logger.Warn("channel re-resolution failed", "error", err)
+			continue
+		}
+		if becameReady {
+			logger.Info("channels resolved, bot is ready")
+			b.startHistorical(ctx, s)
+		}
+	}
+}
+
+// isChannelPattern reports whether the configured channel name is a glob pattern.
+func isChannelPattern(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// channelTypes returns the configured monitorable channel types, defaulting to text channels.
+func (b *Bot) channelTypes() []discordgo.ChannelType {
+	var types []discordgo.ChannelType
+	for _, name := range b.config.ChannelTypes {
+		if t, ok := channelTypesByName[name]; ok {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		return []discordgo.ChannelType{discordgo.ChannelTypeGuildText}
+	}
+	return types
+}
+
+// isMonitored reports whether the bot is ready and channelID is one of the monitored channels.
+func (b *Bot) isMonitored(channelID string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if !b.ready {
+		return false
+	}
+	_, ok := b.channels[channelID]
+	return ok
+}
+
+// lastMessageID returns the newest message in channelID as of the last time
+// the channels were resolved, or "" if it is not known.
+func (b *Bot) lastMessageID(channelID string) string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.lastMessageIDs[channelID]
+}
+
+// monitoredChannelIDs returns the monitored channel IDs in a stable order.
+func (b *Bot) monitoredChannelIDs() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return slices.Sorted(maps.Keys(b.channels))
+}
+
+func (b *Bot) OnChannelCreate(s *discordgo.Session, c *discordgo.ChannelCreate) {
+	b.handleChannelEvent(s, c.Channel)
+}
+
+func (b *Bot) OnChannelUpdate(s *discordgo.Session, c *discordgo.ChannelUpdate) {
+	b.handleChannelEvent(s, c.Channel)
+}
+
+// OnGuildCreate retries initialization when the guild becomes available,
+// e.g. after an outage made it unavailable while the bot was connecting.
+func (b *Bot) OnGuildCreate(s *discordgo.Session, g *discordgo.GuildCreate) {
+	if g.ID == b.config.GuildID {
+		b.emojis.Set(g.ID, g.Emojis)
+		b.checkJollyEmoji(s, g.Emojis)
+		b.tryInitialize(s, "guild available")
+	}
+}
+
+func (b *Bot) handleChannelEvent(s Session, ch *discordgo.Channel) {
+	if ch.GuildID != b.config.GuildID {
+		return
+	}
+	if !b.isReady() {
+		b.tryInitialize(s, "channel event")
+		return
+	}
+	b.TrackChannel(ch)
+}
+
+func (b *Bot) OnChannelDelete(s *discordgo.Session, c *discordgo.ChannelDelete) {
+	b.mu.Lock()
+	_, ok := b.channels[c.ID]
+	delete(b.channels, c.ID)
+	b.mu.Unlock()
+
+	if ok {
+		logger.Info("stopped monitoring deleted channel", "channel_name", c.Name, "channel_id", c.ID)
+	}
+}
+
+// TrackChannel starts or stops monitoring ch when it is created or changed so
+// that it matches, or no longer matches, a configured channel pattern.
+// Plain channel names are resolved once by Initialize and are left alone here.
+func (b *Bot) TrackChannel(ch *discordgo.Channel) {
+	if ch.GuildID != b.config.GuildID || !isChannelPattern(b.config.ChannelName) {
+		return
+	}
+	matches := len(MatchChannels([]*discordgo.Channel{ch}, b.config.ChannelName, b.channelTypes())) > 0
+
+	b.mu.Lock()
+	if !b.ready {
+		b.mu.Unlock()
+		return
+	}
+	_, monitored := b.channels[ch.ID]
+	if matches {
+		b.channels[ch.ID] = ch.Name // Also keeps the name current after a rename
+	} else {
+		delete(b.channels, ch.ID)
+	}
+	b.mu.Unlock()
+
+	switch {
+	case matches && !monitored:
+		logger.Info("monitoring new channel", "channel_name", ch.Name, "channel_id", ch.ID)
+	case !matches && monitored:
+		logger.Info("stopped monitoring channel", "channel_name", ch.Name, "channel_id", ch.ID)
+	}
+}
@@ -0,0 +1,287 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/settings"
+)
+
+func TestMatchChannels(t *testing.T) {
+	channels := []*discordgo.Channel{
+		{ID: "1", Name: "jollyposting", Type: discordgo.ChannelTypeGuildText},
+		{ID: "2", Name: "jolly-memes", Type: discordgo.ChannelTypeGuildText},
+		{ID: "3", Name: "jolly-voice", Type: discordgo.ChannelTypeGuildVoice},
+		{ID: "4", Name: "general", Type: discordgo.ChannelTypeGuildText},
+		{ID: "5", Name: "jolly-category", Type: discordgo.ChannelTypeGuildCategory},
+	}
+	textOnly := []discordgo.ChannelType{discordgo.ChannelTypeGuildText}
+
+	tests := []struct {
+		name     string
+		pattern  string
+		expected []string
+	}{
+		{"prefix wildcard", "jolly*", []string{"1", "2"}},
+		{"single character wildcard", "jolly?memes", []string{"2"}},
+		{"character class", "[gj]*", []string{"1", "2", "4"}},
+		{"no matches", "skull*", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			for _, ch := range MatchChannels(channels, tt.pattern, textOnly) {
+				ids = append(ids, ch.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("MatchChannels(%q) = %v, want %v", tt.pattern, ids, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBot_Initialize_Pattern(t *testing.T) {
+	t.Run("monitors all matching channels", func(t *testing.T) {
+		b := New(&config.Config{GuildID: "guild123", ChannelName: "jolly*"})
+		mock := &mockSession{
+			channels: []*discordgo.Channel{
+				{ID: "chan1", Name: "jollyposting", Type: discordgo.ChannelTypeGuildText},
+				{ID: "chan2", Name: "jolly-memes", Type: discordgo.ChannelTypeGuildText},
+				{ID: "chan3", Name: "general", Type: discordgo.ChannelTypeGuildText},
+			},
+		}
+
+		if err := b.Initialize(mock); err != nil {
+			t.Fatalf("Initialize() unexpected error: %v", err)
+		}
+		got := b.monitoredChannelIDs()
+		if strings.Join(got, ",") != "chan1,chan2" {
+			t.Errorf("monitored channels = %v, want [chan1 chan2]", got)
+		}
+	})
+
+	t.Run("pattern with no matches is not an error", func(t *testing.T) {
+		b := New(&config.Config{GuildID: "guild123", ChannelName: "jolly*"})
+		mock := &mockSession{
+			channels: []*discordgo.Channel{
+				{ID: "chan1", Name: "general", Type: discordgo.ChannelTypeGuildText},
+			},
+		}
+
+		if err := b.Initialize(mock); err != nil {
+			t.Fatalf("Initialize() unexpected error: %v", err)
+		}
+		if !b.ready {
+			t.Error("bot should be ready so future matching channels are picked up")
+		}
+	})
+}
+
+func TestBot_RefreshChannels(t *testing.T) {
+	t.Run("picks up added and removed channels", func(t *testing.T) {
+		b := New(&config.Config{GuildID: "guild123", ChannelName: "jolly*"})
+		b.channels = map[string]string{"chan1": "jollyposting", "chan2": "jolly-old"}
+		b.ready = true
+		mock := &mockSession{
+			channels: []*discordgo.Channel{
+				{ID: "chan1", Name: "jollyposting", Type: discordgo.ChannelTypeGuildText},
+				{ID: "chan3", Name: "jolly-new", Type: discordgo.ChannelTypeGuildText, LastMessageID: "msg9"},
+			},
+		}
+
+		becameReady, err := b.RefreshChannels(mock)
+		if err != nil {
+			t.Fatalf("RefreshChannels() unexpected error: %v", err)
+		}
+		if becameReady {
+			t.Error("RefreshChannels() should not report becoming ready when already ready")
+		}
+		if got := b.monitoredChannelIDs(); strings.Join(got, ",") != "chan1,chan3" {
+			t.Errorf("monitored channels = %v, want [chan1 chan3]", got)
+		}
+		if got := b.lastMessageID("chan3"); got != "msg9" {
+			t.Errorf("lastMessageID() = %q, want the channel's last message", got)
+		}
+	})
+
+	t.Run("keeps current channels on error", func(t *testing.T) {
+		b := New(&config.Config{GuildID: "guild123", ChannelName: "jollyposting"})
+		b.channels = map[string]string{"chan1": "jollyposting"}
+		b.ready = true
+		mock := &mockSession{channelsErr: errors.New("API error")}
+
+		if _, err := b.RefreshChannels(mock); err == nil {
+			t.Error("RefreshChannels() should return the API error")
+		}
+		if !b.isMonitored("chan1") {
+			t.Error("a failed refresh should not stop monitoring")
+		}
+	})
+
+	t.Run("reports becoming ready", func(t *testing.T) {
+		b := New(&config.Config{GuildID: "guild123", ChannelName: "jollyposting"})
+		mock := &mockSession{
+			channels: []*discordgo.Channel{
+				{ID: "chan1", Name: "jollyposting", Type: discordgo.ChannelTypeGuildText},
+			},
+		}
+
+		becameReady, err := b.RefreshChannels(mock)
+		if err != nil {
+			t.Fatalf("RefreshChannels() unexpected error: %v", err)
+		}
+		if !becameReady {
+			t.Error("RefreshChannels() should report becoming ready on first success")
+		}
+	})
+}
+
+func TestBot_RunChannelResolver(t *testing.T) {
+	// Simulate a bot whose channel did not exist at startup
+	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	cfg.GuildID = "guild123"
+	cfg.ChannelName = "jollyposting"
+	b := New(cfg)
+	mock := &mockSession{
+		channels: []*discordgo.Channel{
+			{ID: "chan1", Name: "jollyposting", Type: discordgo.ChannelTypeGuildText},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.RunChannelResolver(ctx, mock, 5*time.Millisecond)
+
+	deadline := time.After(time.Second)
+	for !b.isMonitored("chan1") {
+		select {
+		case <-deadline:
+			t.Fatal("resolver should bring the bot up once the channel exists")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func TestBot_HandleChannelEvent_NotReady(t *testing.T) {
+	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	cfg.GuildID = "guild123"
+	cfg.ChannelName = "jollyposting"
+	b := New(cfg)
+	b.settings.SetFeature("guild123", settings.FeatureHistorical, false)
+
+	created := &discordgo.Channel{ID: "chan1", GuildID: "guild123", Name: "jollyposting", Type: discordgo.ChannelTypeGuildText}
+	mock := &mockSession{channels: []*discordgo.Channel{created}}
+
+	b.handleChannelEvent(mock, created)
+
+	if !b.isMonitored("chan1") {
+		t.Error("creating the missing channel should bring the bot up")
+	}
+}
+
+func TestBot_TrackChannel(t *testing.T) {
+	newPatternBot := func() *Bot {
+		b := New(&config.Config{GuildID: "guild123", ChannelName: "jolly*"})
+		b.channels = map[string]string{"chan1": "jollyposting"}
+		b.ready = true
+		return b
+	}
+
+	t.Run("starts monitoring new matching channel", func(t *testing.T) {
+		b := newPatternBot()
+		b.TrackChannel(&discordgo.Channel{ID: "chan2", GuildID: "guild123", Name: "jolly-new", Type: discordgo.ChannelTypeGuildText})
+
+		if !b.isMonitored("chan2") {
+			t.Error("new matching channel should be monitored")
+		}
+	})
+
+	t.Run("ignores non-matching channel", func(t *testing.T) {
+		b := newPatternBot()
+		b.TrackChannel(&discordgo.Channel{ID: "chan2", GuildID: "guild123", Name: "general", Type: discordgo.ChannelTypeGuildText})
+
+		if b.isMonitored("chan2") {
+			t.Error("non-matching channel should not be monitored")
+		}
+	})
+
+	t.Run("ignores other guilds", func(t *testing.T) {
+		b := newPatternBot()
+		b.TrackChannel(&discordgo.Channel{ID: "chan2", GuildID: "other-guild", Name: "jolly-new", Type: discordgo.ChannelTypeGuildText})
+
+		if b.isMonitored("chan2") {
+			t.Error("channel in another guild should not be monitored")
+		}
+	})
+
+	t.Run("stops monitoring renamed channel", func(t *testing.T) {
+		b := newPatternBot()
+		b.TrackChannel(&discordgo.Channel{ID: "chan1", GuildID: "guild123", Name: "serious-posting", Type: discordgo.ChannelTypeGuildText})
+
+		if b.isMonitored("chan1") {
+			t.Error("channel renamed away from the pattern should no longer be monitored")
+		}
+	})
+
+	t.Run("stops monitoring deleted channel", func(t *testing.T) {
+		b := newPatternBot()
+		b.OnChannelDelete(nil, &discordgo.ChannelDelete{Channel: &discordgo.Channel{ID: "chan1", GuildID: "guild123"}})
+
+		if b.isMonitored("chan1") {
+			t.Error("deleted channel should no longer be monitored")
+		}
+	})
+
+	t.Run("plain channel names are not tracked", func(t *testing.T) {
+		b := New(&config.Config{GuildID: "guild123", ChannelName: "jollyposting"})
+		b.channels = map[string]string{"chan1": "jollyposting"}
+		b.ready = true
+		b.TrackChannel(&discordgo.Channel{ID: "chan2", GuildID: "guild123", Name: "jollyposting", Type: discordgo.ChannelTypeGuildText})
+
+		if b.isMonitored("chan2") {
+			t.Error("plain channel names should only be resolved by Initialize")
+		}
+	})
+}
+
+func TestBot_ProcessHistoricalMessages_MultipleChannels(t *testing.T) {
+	b := &Bot{
+		config:   newTestConfig([]string{"target-user"}, "jollyskull:123"),
+		channels: map[string]string{"chan1": "jolly-a", "chan2": "jolly-b"},
+	}
+	mock := &mockSession{
+		messagePages: [][]*discordgo.Message{{}, {}},
+	}
+
+	b.ProcessHistoricalMessages(context.Background(), mock)
+
+	if mock.messageCalls != 2 {
+		t.Errorf("expected one message fetch per channel, got %d", mock.messageCalls)
+	}
+}
+
+func TestBot_HandleInteraction_Status(t *testing.T) {
+	b := New(&config.Config{GuildID: "guild123", ChannelName: "jolly*"})
+	b.channels = map[string]string{"chan1": "jollyposting", "chan2": "jolly-memes"}
+	b.ready = true
+	b.replacements.Store(3)
+	b.deletions.Store(1)
+	b.historicalRunning = true
+	mock := &mockSession{}
+
+	b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"status"}))
+
+	got := lastResponse(t, mock)
+	for _, want := range []string{"Up for", "Target users: 0", "3 skull(s) replaced, 1 message(s) deleted", "in progress", "jolly*", "<#chan1>", "<#chan2>"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("status %q should contain %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,908 @@
+package bot
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/flags"
+	"jolly-okurb/internal/i18n"
+	"jolly-okurb/internal/settings"
+	"jolly-okurb/internal/themes"
+)
+
+// Keys of /jolly config set other than features.
+const (
+	outputKey = "output" // Embeds or plain text
+	mixedKey  = "mixed"  // Mixed content policy
+)
+
+// adminPermissions hides /jolly from members without Manage Server by default.
+var adminPermissions int64 = discordgo.PermissionManageGuild
+
+// commandOptions maps option names to the leaf options of an invoked subcommand.
+type commandOptions map[string]*discordgo.ApplicationCommandInteractionDataOption
+
+// commandHandler handles a /jolly subcommand and returns the reply.
+type commandHandler func(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error)
+
+// componentHandler handles a button press on a /jolly reply and returns the
+// content that replaces the message the button was on. A custom ID may end in
+// an argument after componentArgSep, which the handler reads itself.
+type componentHandler func(s Session, i *discordgo.InteractionCreate) (*discordgo.InteractionResponseData, error)
+
+func jollyCommand() *discordgo.ApplicationCommand {
+	keyChoices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(settings.Features)+2)
+	for _, f := range settings.Features {
+		keyChoices = append(keyChoices, &discordgo.ApplicationCommandOptionChoice{Name: string(f), Value: string(f)})
+	}
+	for _, key := range []string{outputKey, mixedKey} {
+		keyChoices = append(keyChoices, &discordgo.ApplicationCommandOptionChoice{Name: key, Value: key})
+	}
+	flagChoices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(flags.All))
+	for _, f := range flags.All {
+		flagChoices = append(flagChoices, &discordgo.ApplicationCommandOptionChoice{Name: string(f), Value: string(f)})
+	}
+	var localeChoices []*discordgo.ApplicationCommandOptionChoice
+	for _, locale := range i18n.Locales() {
+		localeChoices = append(localeChoices, &discordgo.ApplicationCommandOptionChoice{Name: i18n.For(locale).T("language.name"), Value: locale})
+	}
+
+	var themeChoices []*discordgo.ApplicationCommandOptionChoice
+	for _, id := range themes.IDs() {
+		pack, _ := themes.Get(id)
+		themeChoices = append(themeChoices, &discordgo.ApplicationCommandOptionChoice{Name: pack.Name, Value: id})
+	}
+
+	messageOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "message",
+		Description: "Message link or ID",
+		Required:    true,
+	}
+
+	return &discordgo.ApplicationCommand{
+		Name:                     "jolly",
+		Description:              "Manage jolly-okurb",
+		DefaultMemberPermissions: &adminPermissions,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "status",
+				Description: "Show uptime, targets, actions since startup, and what the bot is monitoring",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "panel",
+				Description: "Post a control panel with buttons to the admin channel",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "alerts",
+				Description: "List alerts waiting for a moderator and acknowledge them",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "invite",
+				Description: "Show the link that adds the bot with the permissions it needs",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "selftest",
+				Description: "Test reaction replacement end to end in the sandbox channel",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "rescan",
+				Description: "Rescan message history back to the cutoff",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "since",
+						Description: "Rescan back to this date (YYYY-MM-DD) instead of the cutoff",
+						MaxLength:   len(time.DateOnly),
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "reason",
+						Description: "Why, for the audit log of the changes the rescan makes",
+						MaxLength:   maxCommandReason,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "test",
+				Description: "Show what the bot would do with a message, without acting",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "text",
+						Description: "Message content, including emojis",
+						Required:    true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "user",
+						Description: "Target user whose group decides the steps (default: a target user in no group)",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "exempt",
+				Description: "Never act on a message, keeping its skulls",
+				Options:     []*discordgo.ApplicationCommandOption{messageOption},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "unexempt",
+				Description: "Stop exempting a message",
+				Options:     []*discordgo.ApplicationCommandOption{messageOption},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "deleted",
+				Description: "Show the retained content of a message the bot deleted",
+				Options:     []*discordgo.ApplicationCommandOption{messageOption},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+				Name:        "theme",
+				Description: "React with a themed set of emojis instead of jollyskull",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "set",
+						Description: "Switch to a theme pack",
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "pack",
+								Description: "Theme pack",
+								Required:    true,
+								Choices:     themeChoices,
+							},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "clear",
+						Description: "Go back to jollyskull",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+				Name:        "leaderboard",
+				Description: "Per-user counts of replaced and deleted skulls",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "export",
+						Description: "Download the leaderboard as CSV",
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Type:        discordgo.ApplicationCommandOptionInteger,
+								Name:        "days",
+								Description: fmt.Sprintf("Number of days up to today (default %d)", leaderboardDefaultDays),
+								MinValue:    &leaderboardMinDays,
+								MaxValue:    leaderboardMaxDays,
+							},
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "heatmap",
+				Description: "Download when skull-posting peaks, by weekday and hour, as CSV",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "days",
+						Description: fmt.Sprintf("Number of days up to today (default %d)", leaderboardDefaultDays),
+						MinValue:    &leaderboardMinDays,
+						MaxValue:    leaderboardMaxDays,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+				Name:        "rules",
+				Description: "Emoji rules of EMOJI_RULES",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "export",
+						Description: "Download the emoji rules as a JSON document",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "import",
+						Description: "Preview or import emoji rules that replace EMOJI_RULES",
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Type:        discordgo.ApplicationCommandOptionAttachment,
+								Name:        "file",
+								Description: "JSON document as /jolly rules export makes",
+							},
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "text",
+								Description: "JSON array of rules, instead of a file",
+							},
+							{
+								Type:        discordgo.ApplicationCommandOptionBoolean,
+								Name:        "apply",
+								Description: "Import the rules instead of previewing them (default false)",
+							},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "reset",
+						Description: "Forget the imported emoji rules and go back to EMOJI_RULES",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "emojis",
+				Description: "Show how often each skull emoji is used and replaced",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "days",
+						Description: fmt.Sprintf("Number of days up to today (default %d)", leaderboardDefaultDays),
+						MinValue:    &leaderboardMinDays,
+						MaxValue:    leaderboardMaxDays,
+					},
+				},
+			},
+			targetsCommand(),
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+				Name:        "config",
+				Description: "View or change settings for this server",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "show",
+						Description: "Show the current settings",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "diff",
+						Description: "Show server settings that override the environment",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "set",
+						Description: "Change a setting",
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "key",
+								Description: "Setting to change",
+								Required:    true,
+								Choices:     keyChoices,
+							},
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "value",
+								Description: "New value (on/off, embed/plain for output, ignore/react/notify for mixed)",
+								Required:    true,
+							},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "dryrun",
+						Description: "Only log actions, for the server or one channel",
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "value",
+								Description: "on, off, or inherit to remove the override",
+								Required:    true,
+								Choices: []*discordgo.ApplicationCommandOptionChoice{
+									{Name: "on", Value: "on"},
+									{Name: "off", Value: "off"},
+									{Name: "inherit", Value: "inherit"},
+								},
+							},
+							{
+								Type:        discordgo.ApplicationCommandOptionChannel,
+								Name:        "channel",
+								Description: "Channel to override (default: the whole server)",
+								ChannelTypes: []discordgo.ChannelType{
+									discordgo.ChannelTypeGuildText,
+									discordgo.ChannelTypeGuildNews,
+									discordgo.ChannelTypeGuildVoice,
+								},
+							},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "flag",
+						Description: "Roll a risky feature out to this server, or back",
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "flag",
+								Description: "Feature flag to change",
+								Required:    true,
+								Choices:     flagChoices,
+							},
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "value",
+								Description: "on, off, or inherit to follow the default",
+								Required:    true,
+								Choices: []*discordgo.ApplicationCommandOptionChoice{
+									{Name: "on", Value: "on"},
+									{Name: "off", Value: "off"},
+									{Name: "inherit", Value: "inherit"},
+								},
+							},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "publicstats",
+						Description: "Publish a stats page anyone with the link can view",
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "value",
+								Description: "on, off, or rotate to replace the link",
+								Required:    true,
+								Choices: []*discordgo.ApplicationCommandOptionChoice{
+									{Name: "on", Value: "on"},
+									{Name: "off", Value: "off"},
+									{Name: "rotate", Value: "rotate"},
+								},
+							},
+							{
+								Type:        discordgo.ApplicationCommandOptionBoolean,
+								Name:        "names",
+								Description: "Show usernames instead of anonymous ranks (default: keep)",
+							},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Name:        "locale",
+						Description: "Set the language of replies and posts",
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Type:        discordgo.ApplicationCommandOptionString,
+								Name:        "value",
+								Description: "Language",
+								Required:    true,
+								Choices:     localeChoices,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Names of the command and of the message menu entry with which a member
+// reports a false positive. Unlike /jolly, they need no permission, as the
+// moderators decide what to do with a report.
+const (
+	reportFPCommand = "report-fp"
+	reportFPMenu    = "Report false positive"
+)
+
+func reportFPCommands() []*discordgo.ApplicationCommand {
+	return []*discordgo.ApplicationCommand{
+		{
+			Name:        reportFPCommand,
+			Description: "Report a message whose skulls the bot should not have replaced",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "message",
+					Description: "Message link or ID",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name: reportFPMenu,
+			Type: discordgo.MessageApplicationCommand,
+		},
+	}
+}
+
+// RegisterCommands registers the /jolly command and the false positive
+// report commands in the configured guild.
+func (b *Bot) RegisterCommands(s Session, appID string) error {
+	commands := append([]*discordgo.ApplicationCommand{jollyCommand()}, reportFPCommands()...)
+	if _, err := s.ApplicationCommandBulkOverwrite(appID, b.config.GuildID, commands); err != nil {
+		return fmt.Errorf("failed to register commands: %w", err)
+	}
+	return nil
+}
+
+func (b *Bot) OnInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	b.HandleInteraction(s, i)
+}
+
+// HandleInteraction dispatches /jolly subcommands and false positive reports
+// and replies with an ephemeral message.
+// Presses of buttons on those replies are dispatched by their custom ID.
+func (b *Bot) HandleInteraction(s Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		b.handleCommand(s, i)
+	case discordgo.InteractionMessageComponent:
+		b.handleComponent(s, i)
+	}
+}
+
+func (b *Bot) handleCommand(s Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	var (
+		path    string
+		opts    commandOptions
+		handler commandHandler
+		ok      bool
+	)
+	switch data.Name {
+	case "jolly":
+		path, opts = commandPath(data.Options)
+		handler, ok = b.commandHandlers()[path]
+	case reportFPCommand:
+		_, opts = commandPath(data.Options)
+		path, handler, ok = reportFPCommand, b.handleReportFP, true
+	case reportFPMenu:
+		// The menu entry names the message it was opened on instead
+		opts = commandOptions{"message": {Name: "message", Type: discordgo.ApplicationCommandOptionString, Value: data.TargetID}}
+		path, handler, ok = reportFPCommand, b.handleReportFP, true
+	default:
+		return
+	}
+	if !ok {
+		b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, textReply(b.printer(i.GuildID).T("command.unknown", path)))
+		return
+	}
+
+	deferred := data.Name == "jolly" && deferredCommands[path]
+	if deferred {
+		b.respond(s, i, discordgo.InteractionResponseDeferredChannelMessageWithSource, &discordgo.InteractionResponseData{})
+	}
+	reply, err := b.runHandler("command "+path, func() (*discordgo.InteractionResponseData, error) {
+		return handler(s, i, opts)
+	})
+	if err != nil {
+		logger.Error("command failed", "command", path, "error", err)
+		reply = textReply(b.printer(i.GuildID).T("command.error", err))
+	}
+	if deferred {
+		b.editResponse(s, i, reply)
+		return
+	}
+	b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, reply)
+}
+
+// deferredCommands are the /jolly subcommands that may make more API calls
+// than Discord waits three seconds for. They are acknowledged right away and
+// their reply replaces the acknowledgement when they are done.
+var deferredCommands = map[string]bool{
+	"rescan":       true,
+	"rules import": true,
+	"theme set":    true,
+	"theme clear":  true,
+}
+
+func (b *Bot) handleComponent(s Session, i *discordgo.InteractionCreate) {
+	customID := i.MessageComponentData().CustomID
+	if strings.HasPrefix(customID, panelPrefix) {
+		b.handlePanelButton(s, i, customID)
+		return
+	}
+	if strings.HasPrefix(customID, alertAckPrefix) || strings.HasPrefix(customID, alertListAckPrefix) {
+		b.handleAlertButton(s, i, customID)
+		return
+	}
+	if strings.HasPrefix(customID, fpUndoPrefix) || strings.HasPrefix(customID, fpExemptPrefix) || strings.HasPrefix(customID, fpExcludePrefix) {
+		b.handleFalsePositiveButton(s, i, customID)
+		return
+	}
+	if strings.HasPrefix(customID, excludeAddPrefix) || strings.HasPrefix(customID, excludeRemovePrefix) {
+		b.handleExclusionButton(s, i, customID)
+		return
+	}
+	if strings.HasPrefix(customID, emojiDeletePrefix) {
+		b.handleEmojiDeleteButton(s, i, customID)
+		return
+	}
+	key, _, _ := strings.Cut(customID, componentArgSep)
+	handler, ok := b.componentHandlers()[key]
+	if !ok {
+		return
+	}
+
+	reply, err := b.runHandler("component "+customID, func() (*discordgo.InteractionResponseData, error) {
+		return handler(s, i)
+	})
+	if err != nil {
+		logger.Error("component failed", "custom_id", customID, "error", err)
+		reply = textReply(b.printer(i.GuildID).T("command.error", err))
+	}
+	b.respond(s, i, discordgo.InteractionResponseUpdateMessage, reply)
+}
+
+func (b *Bot) commandHandlers() map[string]commandHandler {
+	return map[string]commandHandler{
+		"status":             b.handleStatus,
+		"config show":        b.handleConfigShow,
+		"config diff":        b.handleConfigDiff,
+		"config set":         b.handleConfigSet,
+		"config dryrun":      b.handleConfigDryRun,
+		"config flag":        b.handleConfigFlag,
+		"config publicstats": b.handleConfigPublicStats,
+		"config locale":      b.handleConfigLocale,
+		"targets list":       b.handleTargetsList,
+		"targets add":        b.handleTargetsAdd,
+		"targets remove":     b.handleTargetsRemove,
+		"panel":              b.handlePanel,
+		"alerts":             b.handleAlerts,
+		"invite":             b.handleInvite,
+		"rescan":             b.handleRescan,
+		"test":               b.handleTest,
+		"exempt":             b.handleExempt,
+		"unexempt":           b.handleUnexempt,
+		"deleted":            b.handleDeleted,
+		"leaderboard export": b.handleLeaderboardExport,
+		"rules export":       b.handleRulesExport,
+		"rules import":       b.handleRulesImport,
+		"rules reset":        b.handleRulesReset,
+		"theme set":          b.handleThemeSet,
+		"theme clear":        b.handleThemeClear,
+		"heatmap":            b.handleHeatmap,
+		"emojis":             b.handleEmojis,
+		"selftest":           b.handleSelfTest,
+	}
+}
+
+func (b *Bot) componentHandlers() map[string]componentHandler {
+	return map[string]componentHandler{
+		rescanConfirmID: b.handleRescanConfirm,
+		rescanCancelID:  b.handleRescanCancel,
+	}
+}
+
+// textReply returns a reply with only text. Its empty component list removes
+// any buttons when it replaces a message.
+func textReply(content string) *discordgo.InteractionResponseData {
+	return &discordgo.InteractionResponseData{Content: content, Components: []discordgo.MessageComponent{}}
+}
+
+// respond answers an interaction. New messages are ephemeral; updates keep
+// the visibility of the message they replace.
+func (b *Bot) respond(s Session, i *discordgo.InteractionCreate, typ discordgo.InteractionResponseType, data *discordgo.InteractionResponseData) {
+	if typ != discordgo.InteractionResponseUpdateMessage || i.Message == nil || i.Message.Flags&discordgo.MessageFlagsEphemeral != 0 {
+		data.Flags |= discordgo.MessageFlagsEphemeral
+	}
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: typ, Data: data})
+	if err != nil {
+		logger.Error("failed to respond to interaction", "interaction_id", i.ID, "error", err)
+	}
+}
+
+// editResponse replaces the deferred response to i with data. The response
+// stays ephemeral, as the acknowledgement was.
+func (b *Bot) editResponse(s Session, i *discordgo.InteractionCreate, data *discordgo.InteractionResponseData) {
+	edit := &discordgo.WebhookEdit{Content: &data.Content, Files: data.Files, AllowedMentions: data.AllowedMentions}
+	if len(data.Components) > 0 {
+		edit.Components = &data.Components
+	}
+	if len(data.Embeds) > 0 {
+		edit.Embeds = &data.Embeds
+	}
+	if _, err := s.InteractionResponseEdit(i.Interaction, edit); err != nil {
+		logger.Error("failed to edit interaction response", "interaction_id", i.ID, "error", err)
+	}
+}
+
+// commandPath flattens nested subcommand groups into a path like "config set"
+// and returns the options of the innermost subcommand.
+func commandPath(options []*discordgo.ApplicationCommandInteractionDataOption) (string, commandOptions) {
+	var parts []string
+	for len(options) == 1 && (options[0].Type == discordgo.ApplicationCommandOptionSubCommandGroup ||
+		options[0].Type == discordgo.ApplicationCommandOptionSubCommand) {
+		parts = append(parts, options[0].Name)
+		options = options[0].Options
+	}
+
+	opts := make(commandOptions, len(options))
+	for _, o := range options {
+		opts[o.Name] = o
+	}
+	return strings.Join(parts, " "), opts
+}
+
+func (b *Bot) handleStatus(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	p := b.printer(i.GuildID)
+	channelIDs := b.monitoredChannelIDs()
+
+	var sb strings.Builder
+	fmt.Fprintln(&sb, p.T("status.uptime", time.Since(b.started).Round(time.Second)))
+	targets := len(b.targetUserIDs())
+	if len(b.config.TargetRoleIDs) > 0 {
+		fmt.Fprintln(&sb, p.T("status.targets.roles", targets, b.roleTargets.len()))
+	} else {
+		fmt.Fprintln(&sb, p.T("status.targets", targets))
+	}
+	fmt.Fprintln(&sb, p.T("status.actions", b.replacements.Load(), b.deletions.Load()))
+	if b.isHistoricalRunning() {
+		fmt.Fprintln(&sb, p.T("status.historical.running"))
+	} else {
+		fmt.Fprintln(&sb, p.T("status.historical.idle"))
+	}
+	if isChannelPattern(b.config.ChannelName) {
+		fmt.Fprintln(&sb, p.T("status.pattern", b.config.ChannelName))
+	}
+	if len(channelIDs) == 0 {
+		fmt.Fprintln(&sb, p.T("status.none"))
+	} else {
+		fmt.Fprintln(&sb, p.T("status.monitoring", len(channelIDs)))
+		for _, id := range channelIDs {
+			fmt.Fprintf(&sb, "- <#%s>\n", id)
+		}
+	}
+
+	total, routes, counts := b.rateLimits.top(statusTopRoutes)
+	if total == 0 {
+		fmt.Fprintln(&sb, p.T("status.ratelimits.none"))
+		return textReply(sb.String()), nil
+	}
+	fmt.Fprintln(&sb, p.T("status.ratelimits", total))
+	for _, route := range routes {
+		fmt.Fprintf(&sb, "- `%s`: %d\n", route, counts[route])
+	}
+	return textReply(sb.String()), nil
+}
+
+func (b *Bot) handleConfigShow(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	g, err := b.settings.Guild(i.GuildID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := i18n.For(g.Locale)
+
+	var sb strings.Builder
+	fmt.Fprintln(&sb, p.T("config.header"))
+	if b.config.ReadOnly {
+		fmt.Fprintln(&sb, p.T("config.readonly"))
+	}
+	if g.Paused {
+		fmt.Fprintln(&sb, p.T("config.paused"))
+	}
+	for _, f := range settings.Features {
+		fmt.Fprintln(&sb, p.T("config.feature", f, formatToggle(p, g.Enabled(f))))
+	}
+
+	overrides, err := b.flags.Overrides(i.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range flags.All {
+		on, overridden := overrides[f]
+		source := p.T("config.source.server")
+		if !overridden {
+			on, source = b.flags.Default(f), p.T("config.source.default")
+		}
+		fmt.Fprintln(&sb, p.T("config.flag", f, formatToggle(p, on), source))
+	}
+
+	source := p.T("config.source.default")
+	if g.DryRun != nil {
+		source = p.T("config.source.server")
+	}
+	fmt.Fprintln(&sb, p.T("config.dryrun", formatToggle(p, g.DryRunFor("", b.config.DryRun)), source))
+	for _, channelID := range slices.Sorted(maps.Keys(g.ChannelDryRun)) {
+		fmt.Fprintf(&sb, "  - <#%s>: %s\n", channelID, formatToggle(p, g.ChannelDryRun[channelID]))
+	}
+	if g.PublicStats != nil {
+		fmt.Fprintln(&sb, p.T("config.publicstats.on", b.publicStatsURL(g.PublicStats.Token), formatToggle(p, g.PublicStats.ShowNames)))
+	} else {
+		fmt.Fprintln(&sb, p.T("config.publicstats.off"))
+	}
+	fmt.Fprintln(&sb, p.T("config.locale", p.T("language.name")))
+	fmt.Fprintln(&sb, p.T("config.output", formatOutput(p, g.Output)))
+	fmt.Fprintln(&sb, p.T("config.mixed", p.T("mixed."+string(g.MixedPolicy()))))
+	if pack, ok := themes.Get(g.Theme); ok {
+		fmt.Fprintln(&sb, p.T("config.theme", pack.Name))
+	}
+	if len(g.Excluded) > 0 {
+		fmt.Fprintln(&sb, p.T("config.excluded", strings.Join(slices.Sorted(maps.Keys(g.Excluded)), ", ")))
+	}
+	return textReply(sb.String()), nil
+}
+
+func (b *Bot) handleConfigSet(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	switch opts["key"].StringValue() {
+	case outputKey:
+		return b.setOutput(i, opts["value"].StringValue())
+	case mixedKey:
+		return b.setMixedPolicy(i, opts["value"].StringValue())
+	}
+
+	f, err := settings.ParseFeature(opts["key"].StringValue())
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := parseToggle(opts["value"].StringValue())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.settings.SetFeature(i.GuildID, f, enabled); err != nil {
+		return nil, err
+	}
+	logger.Info("feature toggled", "guild_id", i.GuildID, "feature", f, "enabled", enabled, "by", interactionUserID(i))
+	p := b.printer(i.GuildID)
+	return textReply(p.T("config.set", f, formatToggle(p, enabled))), nil
+}
+
+// setOutput switches the guild between embeds and plain text output.
+func (b *Bot) setOutput(i *discordgo.InteractionCreate, value string) (*discordgo.InteractionResponseData, error) {
+	o, err := settings.ParseOutput(strings.ToLower(strings.TrimSpace(value)))
+	if err != nil {
+		return nil, err
+	}
+	if err := b.settings.SetOutput(i.GuildID, o); err != nil {
+		return nil, err
+	}
+	logger.Info("output changed", "guild_id", i.GuildID, "output", o, "by", interactionUserID(i))
+
+	p := b.printer(i.GuildID)
+	return textReply(p.T("config.set", outputKey, formatOutput(p, o))), nil
+}
+
+// setMixedPolicy sets what the bot does with messages mixing skulls with other content.
+func (b *Bot) setMixedPolicy(i *discordgo.InteractionCreate, value string) (*discordgo.InteractionResponseData, error) {
+	policy, err := settings.ParseMixedPolicy(strings.ToLower(strings.TrimSpace(value)))
+	if err != nil {
+		return nil, err
+	}
+	if err := b.settings.SetMixedPolicy(i.GuildID, policy); err != nil {
+		return nil, err
+	}
+	logger.Info("mixed content policy changed", "guild_id", i.GuildID, "policy", policy, "by", interactionUserID(i))
+
+	p := b.printer(i.GuildID)
+	return textReply(p.T("config.set", mixedKey, p.T("mixed."+string(policy)))), nil
+}
+
+func (b *Bot) handleConfigDryRun(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	var value *bool
+	if v := opts["value"].StringValue(); v != "inherit" {
+		enabled, err := parseToggle(v)
+		if err != nil {
+			return nil, err
+		}
+		value = &enabled
+	}
+
+	var channelID string
+	if o, ok := opts["channel"]; ok {
+		channelID = fmt.Sprint(o.Value)
+	}
+
+	if err := b.settings.SetDryRun(i.GuildID, channelID, value); err != nil {
+		return nil, err
+	}
+	logger.Info("dry run changed", "guild_id", i.GuildID, "channel_id", channelID, "dry_run", opts["value"].StringValue(), "by", interactionUserID(i))
+
+	p := b.printer(i.GuildID)
+	scope := p.T("dryrun.scope.server")
+	if channelID != "" {
+		scope = fmt.Sprintf("<#%s>", channelID)
+	}
+	if value == nil {
+		return textReply(p.T("dryrun.inherit", scope)), nil
+	}
+	return textReply(p.T("dryrun.set", scope, formatToggle(p, *value))), nil
+}
+
+func (b *Bot) handleConfigFlag(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	f, err := flags.Parse(opts["flag"].StringValue())
+	if err != nil {
+		return nil, err
+	}
+	var value *bool
+	if v := opts["value"].StringValue(); v != "inherit" {
+		on, err := parseToggle(v)
+		if err != nil {
+			return nil, err
+		}
+		value = &on
+	}
+
+	if err := b.flags.Override(i.GuildID, f, value); err != nil {
+		return nil, err
+	}
+	logger.Info("feature flag changed", "guild_id", i.GuildID, "flag", f, "value", opts["value"].StringValue(), "by", interactionUserID(i))
+
+	p := b.printer(i.GuildID)
+	if value == nil {
+		return textReply(p.T("flag.inherit", f, formatToggle(p, b.flags.Default(f)))), nil
+	}
+	return textReply(p.T("flag.set", f, formatToggle(p, *value))), nil
+}
+
+func (b *Bot) handleConfigLocale(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	locale := opts["value"].StringValue()
+	if !i18n.Supported(locale) {
+		return nil, fmt.Errorf("unsupported language %q (expected one of %s)", locale, strings.Join(i18n.Locales(), ", "))
+	}
+
+	if err := b.settings.SetLocale(i.GuildID, locale); err != nil {
+		return nil, err
+	}
+	logger.Info("locale changed", "guild_id", i.GuildID, "locale", locale, "by", interactionUserID(i))
+
+	p := i18n.For(locale)
+	return textReply(p.T("locale.set", p.T("language.name"))), nil
+}
+
+// parseToggle accepts the usual spellings of a boolean switch.
+func parseToggle(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "on", "true", "yes", "enable", "enabled", "1":
+		return true, nil
+	case "off", "false", "no", "disable", "disabled", "0":
+		return false, nil
+	default:
+		return false, fmt.Errorf("expected on or off, got %q", value)
+	}
+}
+
+func formatToggle(p i18n.Printer, enabled bool) string {
+	if enabled {
+		return p.T("toggle.on")
+	}
+	return p.T("toggle.off")
+}
+
+func formatOutput(p i18n.Printer, o settings.Output) string {
+	if o == settings.OutputPlain {
+		return p.T("output.plain")
+	}
+	return p.T("output.embed")
+}
+
+// printer returns the message printer for guildID's configured language.
+func (b *Bot) printer(guildID string) i18n.Printer {
+	g, err := b.settings.Guild(guildID)
+	if err != nil {
+		logger.Warn("failed to load guild settings, using the default language", "guild_id", guildID, "error", err)
+	}
+	return i18n.For(g.Locale)
+}
+
+// interactionUserID returns the invoking user's ID for guild and DM interactions.
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
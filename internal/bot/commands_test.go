@@ -0,0 +1,328 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/flags"
+	"jolly-okurb/internal/settings"
+)
+
+// newCommandInteraction builds a /jolly interaction for the given subcommand path and options.
+func newCommandInteraction(guildID string, path []string, opts ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	options := opts
+	for i := len(path) - 1; i >= 0; i-- {
+		optType := discordgo.ApplicationCommandOptionSubCommand
+		if i < len(path)-1 {
+			optType = discordgo.ApplicationCommandOptionSubCommandGroup
+		}
+		options = []*discordgo.ApplicationCommandInteractionDataOption{{
+			Name:    path[i],
+			Type:    optType,
+			Options: options,
+		}}
+	}
+	return &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:      "interaction1",
+			Type:    discordgo.InteractionApplicationCommand,
+			GuildID: guildID,
+			Member:  &discordgo.Member{User: &discordgo.User{ID: "admin1"}},
+			Data: discordgo.ApplicationCommandInteractionData{
+				Name:    "jolly",
+				Options: options,
+			},
+		},
+	}
+}
+
+func stringOption(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{
+		Name:  name,
+		Type:  discordgo.ApplicationCommandOptionString,
+		Value: value,
+	}
+}
+
+// lastResponse returns the content of the most recent interaction response.
+func lastResponse(t *testing.T, m *mockSession) string {
+	t.Helper()
+	return lastReply(t, m).Content
+}
+
+// lastReply returns the most recent interaction response, or what it was
+// edited to if it was deferred.
+func lastReply(t *testing.T, m *mockSession) *discordgo.InteractionResponseData {
+	t.Helper()
+	if len(m.responses) == 0 {
+		t.Fatal("expected an interaction response, got none")
+	}
+	resp := m.responses[len(m.responses)-1]
+	if resp.Data.Flags&discordgo.MessageFlagsEphemeral == 0 {
+		t.Error("command responses should be ephemeral")
+	}
+	if resp.Type != discordgo.InteractionResponseDeferredChannelMessageWithSource {
+		return resp.Data
+	}
+	if len(m.edits) == 0 {
+		t.Fatal("expected the deferred response to be edited, got no edits")
+	}
+	edit := m.edits[len(m.edits)-1]
+	data := &discordgo.InteractionResponseData{Flags: resp.Data.Flags, Files: edit.Files, AllowedMentions: edit.AllowedMentions}
+	if edit.Content != nil {
+		data.Content = *edit.Content
+	}
+	if edit.Components != nil {
+		data.Components = *edit.Components
+	}
+	if edit.Embeds != nil {
+		data.Embeds = *edit.Embeds
+	}
+	return data
+}
+
+func TestBot_RegisterCommands(t *testing.T) {
+	b := New(&config.Config{GuildID: "guild123"})
+	mock := &mockSession{}
+
+	if err := b.RegisterCommands(mock, "app1"); err != nil {
+		t.Fatalf("RegisterCommands() unexpected error: %v", err)
+	}
+	if len(mock.registered) != 3 || mock.registered[0].Name != "jolly" {
+		t.Fatalf("expected /jolly and the report commands to be registered, got %+v", mock.registered)
+	}
+	perms := mock.registered[0].DefaultMemberPermissions
+	if perms == nil || *perms != discordgo.PermissionManageGuild {
+		t.Error("/jolly should default to Manage Server permission")
+	}
+	for _, cmd := range mock.registered[1:] {
+		if cmd.DefaultMemberPermissions != nil {
+			t.Errorf("%q should be open to every member", cmd.Name)
+		}
+	}
+	if menu := mock.registered[2]; menu.Name != reportFPMenu || menu.Type != discordgo.MessageApplicationCommand {
+		t.Errorf("expected a message menu entry to report false positives, got %+v", menu)
+	}
+}
+
+func TestBot_HandleInteraction_Config(t *testing.T) {
+	t.Run("set disables a feature", func(t *testing.T) {
+		b := New(&config.Config{GuildID: "guild123"})
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"config", "set"},
+			stringOption("key", "deletion"), stringOption("value", "off")))
+
+		if got := lastResponse(t, mock); !strings.Contains(got, "deletion") {
+			t.Errorf("response %q should mention the feature", got)
+		}
+		if b.featureEnabled(settings.FeatureDeletion) {
+			t.Error("deletion should be disabled after /jolly config set")
+		}
+	})
+
+	t.Run("set rejects invalid value", func(t *testing.T) {
+		b := New(&config.Config{GuildID: "guild123"})
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"config", "set"},
+			stringOption("key", "deletion"), stringOption("value", "maybe")))
+
+		if got := lastResponse(t, mock); !strings.HasPrefix(got, "Error:") {
+			t.Errorf("response %q should report an error", got)
+		}
+		if !b.featureEnabled(settings.FeatureDeletion) {
+			t.Error("deletion should remain enabled after an invalid value")
+		}
+	})
+
+	t.Run("show lists every feature", func(t *testing.T) {
+		b := New(&config.Config{GuildID: "guild123"})
+		b.settings.SetFeature("guild123", settings.FeatureHistorical, false)
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"config", "show"}))
+
+		got := lastResponse(t, mock)
+		for _, f := range settings.Features {
+			if !strings.Contains(got, string(f)) {
+				t.Errorf("response %q should list %q", got, f)
+			}
+		}
+		if !strings.Contains(got, "historical: off") {
+			t.Errorf("response %q should show historical as off", got)
+		}
+	})
+
+	t.Run("dryrun overrides a channel", func(t *testing.T) {
+		b := New(&config.Config{GuildID: "guild123"})
+		mock := &mockSession{}
+		channel := &discordgo.ApplicationCommandInteractionDataOption{
+			Name:  "channel",
+			Type:  discordgo.ApplicationCommandOptionChannel,
+			Value: "chan1",
+		}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"config", "dryrun"}, stringOption("value", "on"), channel))
+
+		if got := lastResponse(t, mock); got != "Set dry run for <#chan1> to on." {
+			t.Errorf("response = %q", got)
+		}
+		if !b.isDryRun("chan1") || b.isDryRun("chan2") {
+			t.Error("only chan1 should be in dry-run mode")
+		}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"config", "show"}))
+		if got := lastResponse(t, mock); !strings.Contains(got, "<#chan1>: on") {
+			t.Errorf("response %q should list the channel override", got)
+		}
+	})
+
+	t.Run("dryrun inherit removes the server override", func(t *testing.T) {
+		b := New(&config.Config{GuildID: "guild123", DryRun: true})
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"config", "dryrun"}, stringOption("value", "off")))
+		if b.isDryRun("chan1") {
+			t.Error("the server override should turn dry run off")
+		}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"config", "dryrun"}, stringOption("value", "inherit")))
+		if !b.isDryRun("chan1") {
+			t.Error("inherit should fall back to DRY_RUN")
+		}
+	})
+
+	t.Run("flag rolls a feature out to the server", func(t *testing.T) {
+		b := New(&config.Config{GuildID: "guild123", FeatureFlags: map[flags.Flag]bool{flags.Escalation: true}})
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"config", "flag"}, stringOption("flag", "soft_delete"), stringOption("value", "on")))
+		if got := lastResponse(t, mock); got != "Set feature flag soft_delete to on for this server." {
+			t.Errorf("response = %q", got)
+		}
+		if !b.flagEnabled(flags.SoftDelete) {
+			t.Error("soft_delete should be on")
+		}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"config", "show"}))
+		got := lastResponse(t, mock)
+		for _, want := range []string{"flag soft_delete: on (server)", "flag escalation: on (default)", "flag auto_exclude: off (default)"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("response %q should contain %q", got, want)
+			}
+		}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"config", "flag"}, stringOption("flag", "soft_delete"), stringOption("value", "inherit")))
+		if b.flagEnabled(flags.SoftDelete) {
+			t.Error("inherit should fall back to FEATURE_FLAGS")
+		}
+	})
+
+	t.Run("set output", func(t *testing.T) {
+		b := New(&config.Config{GuildID: "guild123"})
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"config", "set"}, stringOption("key", "output"), stringOption("value", "plain")))
+		if got := lastResponse(t, mock); got != "Set output to plain text." {
+			t.Errorf("response = %q", got)
+		}
+		if !b.guildSettings().PlainOutput() {
+			t.Error("output should be plain")
+		}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"config", "set"}, stringOption("key", "output"), stringOption("value", "on")))
+		if got := lastResponse(t, mock); !strings.HasPrefix(got, "Error:") {
+			t.Errorf("response %q should reject a toggle value for output", got)
+		}
+	})
+
+	t.Run("set mixed content policy", func(t *testing.T) {
+		b := New(&config.Config{GuildID: "guild123"})
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"config", "set"}, stringOption("key", "mixed"), stringOption("value", "react")))
+		if got := lastResponse(t, mock); got != "Set mixed to react with jollyskull." {
+			t.Errorf("response = %q", got)
+		}
+		if b.guildSettings().MixedPolicy() != settings.MixedReact {
+			t.Error("mixed content policy should be react")
+		}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"config", "show"}))
+		if got := lastResponse(t, mock); !strings.Contains(got, "- mixed skulls: react with jollyskull") {
+			t.Errorf("response %q should show the mixed content policy", got)
+		}
+	})
+
+	t.Run("locale translates replies", func(t *testing.T) {
+		b := New(&config.Config{GuildID: "guild123"})
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"config", "locale"}, stringOption("value", "nl")))
+		if got := lastResponse(t, mock); got != "Taal ingesteld op Nederlands." {
+			t.Errorf("response = %q", got)
+		}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"config", "set"}, stringOption("key", "deletion"), stringOption("value", "off")))
+		if got := lastResponse(t, mock); got != "deletion staat nu uit." {
+			t.Errorf("response = %q", got)
+		}
+	})
+
+	t.Run("locale rejects unsupported languages", func(t *testing.T) {
+		b := New(&config.Config{GuildID: "guild123"})
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"config", "locale"}, stringOption("value", "xx")))
+		if got := lastResponse(t, mock); !strings.HasPrefix(got, "Error:") {
+			t.Errorf("response %q should report an error", got)
+		}
+	})
+
+	t.Run("ignores other commands", func(t *testing.T) {
+		b := New(&config.Config{GuildID: "guild123"})
+		mock := &mockSession{}
+		i := newCommandInteraction("guild123", []string{"config", "show"})
+		i.Data = discordgo.ApplicationCommandInteractionData{Name: "other"}
+
+		b.HandleInteraction(mock, i)
+
+		if len(mock.responses) != 0 {
+			t.Error("should not respond to commands other than /jolly")
+		}
+	})
+}
+
+func TestParseToggle(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    bool
+		wantErr bool
+	}{
+		{"on", true, false},
+		{"ON", true, false},
+		{"true", true, false},
+		{"enable", true, false},
+		{"off", false, false},
+		{"false", false, false},
+		{" disabled ", false, false},
+		{"maybe", false, true},
+		{"", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseToggle(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseToggle(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseToggle(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package bot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/i18n"
+	"jolly-okurb/internal/schedule"
+	"jolly-okurb/internal/stats"
+)
+
+// jobWeeklyDigest is the scheduled job kind that posts the weekly digest.
+const jobWeeklyDigest = "weekly_digest"
+
+// digestChartName is the file name of the chart attached to the digest.
+const digestChartName = "jolly-wrapped.png"
+
+// digestTopTargets is how many target users the digest lists.
+const digestTopTargets = 3
+
+// digestJob identifies the week a digest covers.
+type digestJob struct {
+	Start time.Time `json:"start"`
+}
+
+// nextDigest returns when the first digest after now is due: the next
+// Monday at midnight UTC.
+func nextDigest(now time.Time) time.Time {
+	now = now.UTC()
+	days := (8 - int(now.Weekday())) % 7
+	if days == 0 {
+		days = 7
+	}
+	return time.Date(now.Year(), now.Month(), now.Day()+days, 0, 0, 0, 0, time.UTC)
+}
+
+// scheduleDigest schedules the digest due at, covering the week before it.
+// Each week has its own job, so a digest that is being retried is never
+// replaced by the next one.
+func (b *Bot) scheduleDigest(at time.Time) error {
+	id := jobWeeklyDigest + ":" + at.Format(time.DateOnly)
+	job, err := schedule.NewJob(id, jobWeeklyDigest, at, digestJob{Start: at.AddDate(0, 0, -7)})
+	if err != nil {
+		return err
+	}
+	return b.scheduler.Schedule(job)
+}
+
+// PostWeeklyDigest posts the "Jolly Wrapped" digest for the week from start
+// to every monitored channel and schedules the next one. Quiet weeks are
+// skipped.
+func (b *Bot) PostWeeklyDigest(s Session, start time.Time) error {
+	if !b.config.WeeklyDigest {
+		return nil
+	}
+	if err := b.scheduleDigest(nextDigest(time.Now())); err != nil {
+		logger.Warn("failed to persist weekly digest", "error", err)
+	}
+
+	days, err := b.stats.Days(start, 7)
+	if err != nil {
+		return fmt.Errorf("failed to load weekly stats: %w", err)
+	}
+	summary := stats.Summarize(days)
+	if summary.Replaced+summary.Deleted == 0 {
+		logger.Info("no activity this week, skipping digest", "week_start", start.Format(time.DateOnly))
+		return nil
+	}
+
+	top := summary.TopTargets[:min(digestTopTargets, len(summary.TopTargets))]
+	users := make(map[string]userInfo, len(top))
+	for _, c := range top {
+		users[c.Key] = b.lookupUser(s, c.Key)
+	}
+
+	p := b.printer(b.config.GuildID)
+	embed := digestEmbed(p, start, days, summary, users)
+	var chart []byte
+	if b.guildSettings().PlainOutput() {
+		// Spell out what the chart shows
+		var lines []string
+		for _, d := range days {
+			if t, err := time.Parse(time.DateOnly, d.Date); err == nil {
+				lines = append(lines, fmt.Sprintf("%s: %d", weekdayName(p, t.Weekday()), d.Total()))
+			}
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: p.T("digest.per_day"), Value: strings.Join(lines, "\n")})
+	} else {
+		values := make([]int, len(days))
+		for i, d := range days {
+			values[i] = d.Total()
+		}
+		if chart, err = stats.BarChart(values); err != nil {
+			return err
+		}
+	}
+
+	var errs []error
+	for _, channelID := range b.monitoredChannelIDs() {
+		var files []*discordgo.File
+		if chart != nil {
+			files = append(files, &discordgo.File{Name: digestChartName, ContentType: "image/png", Reader: bytes.NewReader(chart)})
+		}
+		if err := b.postEmbed(s, channelID, embed, files...); actions.Skipped(err) {
+			return nil
+		} else if err != nil {
+			errs = append(errs, fmt.Errorf("failed to post weekly digest to %s: %w", channelID, err))
+			continue
+		}
+		logger.Info("posted weekly digest", "channel_id", channelID)
+	}
+	return errors.Join(errs...)
+}
+
+// digestEmbed renders the weekly summary. The chart attachment shows the
+// actions per day, Monday first. Top targets are named from users where
+// known and mentioned otherwise; the top one's avatar is the thumbnail.
+func digestEmbed(p i18n.Printer, start time.Time, days []stats.Day, summary stats.Summary, users map[string]userInfo) *discordgo.MessageEmbed {
+	end := start.AddDate(0, 0, len(days)-1)
+	fields := []*discordgo.MessageEmbedField{
+		{Name: p.T("digest.replaced"), Value: strconv.Itoa(summary.Replaced), Inline: true},
+	}
+	if summary.Messages > 0 {
+		fields = append(fields,
+			&discordgo.MessageEmbedField{Name: p.T("digest.messages"), Value: strconv.Itoa(summary.Messages), Inline: true},
+			&discordgo.MessageEmbedField{Name: p.T("digest.repeats"), Value: strconv.Itoa(summary.Repeats), Inline: true},
+		)
+	}
+	fields = append(fields, &discordgo.MessageEmbedField{Name: p.T("digest.deleted"), Value: strconv.Itoa(summary.Deleted), Inline: true})
+	if busiest, err := time.Parse(time.DateOnly, summary.Busiest.Date); err == nil {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   p.T("digest.busiest"),
+			Value:  fmt.Sprintf("%s (%d)", weekdayName(p, busiest.Weekday()), summary.Busiest.Total()),
+			Inline: true,
+		})
+	}
+	var thumbnail *discordgo.MessageEmbedThumbnail
+	if len(summary.TopTargets) > 0 {
+		var lines []string
+		for i, c := range summary.TopTargets[:min(digestTopTargets, len(summary.TopTargets))] {
+			label := "<@" + c.Key + ">"
+			if info := users[c.Key]; info.Name != "" {
+				label = info.Name
+			}
+			lines = append(lines, fmt.Sprintf("%d. %s — %d", i+1, label, c.N))
+		}
+		if avatar := users[summary.TopTargets[0].Key].AvatarURL; avatar != "" {
+			thumbnail = &discordgo.MessageEmbedThumbnail{URL: avatar}
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: p.T("digest.top_targets"), Value: strings.Join(lines, "\n")})
+	}
+	if len(summary.TopEmojis) > 0 {
+		top := summary.TopEmojis[0]
+		fields = append(fields, &discordgo.MessageEmbedField{Name: p.T("digest.top_emoji"), Value: fmt.Sprintf("%s × %d", top.Key, top.N)})
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       p.T("digest.title"),
+		Description: fmt.Sprintf("<t:%d:D> – <t:%d:D>", start.Unix(), end.Unix()), // Rendered in each reader's language
+		Color:       colorSuccess,
+		Fields:      fields,
+		Image:       &discordgo.MessageEmbedImage{URL: "attachment://" + digestChartName},
+		Thumbnail:   thumbnail,
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// weekdayName returns the localized name of d.
+func weekdayName(p i18n.Printer, d time.Weekday) string {
+	return p.T(fmt.Sprintf("weekday.%d", d))
+}
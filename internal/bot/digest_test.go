@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"jolly-okurb/internal/i18n"
+	"jolly-okurb/internal/settings"
+	"jolly-okurb/internal/stats"
+)
+
+func TestNextDigest(t *testing.T) {
+	monday := time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		now  time.Time
+	}{
+		{"sunday night", time.Date(2025, 1, 12, 23, 59, 0, 0, time.UTC)},
+		{"previous monday", time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)},
+		{"midweek", time.Date(2025, 1, 8, 15, 0, 0, 0, time.UTC)},
+		{"other time zone", time.Date(2025, 1, 13, 0, 30, 0, 0, time.FixedZone("CET", 3600))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextDigest(tt.now); !got.Equal(monday) {
+				t.Errorf("nextDigest(%v) = %v, want %v", tt.now, got, monday)
+			}
+		})
+	}
+}
+
+func TestDigestEmbed(t *testing.T) {
+	start := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	days := []stats.Day{
+		{Date: "2025-01-06", Replaced: map[string]int{"alice": 1}, Emojis: map[string]int{"💀": 1}, Messages: map[string]int{"c1/m1": 1}},
+		{Date: "2025-01-07", Replaced: map[string]int{"bob": 2}, Deleted: map[string]int{"alice": 2}, Emojis: map[string]int{"💀": 2}, Messages: map[string]int{"c1/m1": 2}},
+		{Date: "2025-01-08"}, {Date: "2025-01-09"}, {Date: "2025-01-10"}, {Date: "2025-01-11"}, {Date: "2025-01-12"},
+	}
+
+	users := map[string]userInfo{"alice": {Name: "Alice", AvatarURL: "https://cdn.example/alice.png"}}
+	e := digestEmbed(i18n.For("en"), start, days, stats.Summarize(days), users)
+
+	if e.Description != "<t:1736121600:D> – <t:1736640000:D>" {
+		t.Errorf("Description = %q", e.Description)
+	}
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{"Skulls replaced", "3"},
+		{"Messages jollified", "1"},
+		{"Repeat skulls", "2"},
+		{"Messages deleted", "2"},
+		{"Busiest day", "Tuesday (4)"},
+		{"Top targets", "1. Alice — 3\n2. <@bob> — 2"},
+		{"Most replaced emoji", "💀 × 3"},
+	}
+	for _, tt := range tests {
+		if got := embedField(e, tt.field); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.field, got, tt.want)
+		}
+	}
+	if e.Thumbnail == nil || e.Thumbnail.URL != "https://cdn.example/alice.png" {
+		t.Errorf("Thumbnail = %+v, want the top target's avatar", e.Thumbnail)
+	}
+	if !strings.HasSuffix(e.Image.URL, digestChartName) {
+		t.Errorf("Image.URL = %q, want the attached chart", e.Image.URL)
+	}
+}
+
+func TestBot_PostWeeklyDigest(t *testing.T) {
+	cfg := newTestConfig([]string{"alice"}, "jollyskull:123")
+	cfg.WeeklyDigest = true
+	start := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+
+	t.Run("posts to every monitored channel", func(t *testing.T) {
+		b := New(cfg)
+		b.channels = map[string]string{"chan1": "jollyposting", "chan2": "jollyposting-2"}
+		b.stats.RecordReplacement(start.Add(time.Hour), "alice", "💀")
+		mock := &mockSession{}
+
+		if err := b.PostWeeklyDigest(mock, start); err != nil {
+			t.Fatalf("PostWeeklyDigest() unexpected error: %v", err)
+		}
+		if len(mock.sent) != 2 {
+			t.Fatalf("expected 2 digests, got %d", len(mock.sent))
+		}
+		if files := mock.sent[0].files; len(files) != 1 || files[0].Name != digestChartName {
+			t.Errorf("expected the chart to be attached, got %v", files)
+		}
+		if b.scheduler.Pending() != 1 {
+			t.Errorf("expected the next digest to be scheduled, got %d jobs", b.scheduler.Pending())
+		}
+	})
+
+	t.Run("spells out the chart in plain output", func(t *testing.T) {
+		cfg := *cfg
+		cfg.GuildID = "guild123"
+		b := New(&cfg)
+		b.settings.SetOutput("guild123", settings.OutputPlain)
+		b.channels = map[string]string{"chan1": "jollyposting"}
+		b.stats.RecordReplacement(start.AddDate(0, 0, 1), "alice", "💀")
+		mock := &mockSession{}
+
+		if err := b.PostWeeklyDigest(mock, start); err != nil {
+			t.Fatalf("PostWeeklyDigest() unexpected error: %v", err)
+		}
+		msg := mock.sent[0]
+		if len(msg.files) != 0 || msg.embed != nil {
+			t.Errorf("plain output should have no chart or embed, got %+v", msg)
+		}
+		if !strings.Contains(msg.content, "Per day:\nMonday: 0\nTuesday: 1\n") {
+			t.Errorf("content should list the daily counts:\n%s", msg.content)
+		}
+	})
+
+	t.Run("skips a quiet week", func(t *testing.T) {
+		b := New(cfg)
+		b.channels = map[string]string{"chan1": "jollyposting"}
+		mock := &mockSession{}
+
+		if err := b.PostWeeklyDigest(mock, start); err != nil {
+			t.Fatalf("PostWeeklyDigest() unexpected error: %v", err)
+		}
+		if len(mock.sent) != 0 {
+			t.Errorf("expected no digest, got %d", len(mock.sent))
+		}
+	})
+
+	t.Run("reports failed posts for a retry", func(t *testing.T) {
+		b := New(cfg)
+		b.channels = map[string]string{"chan1": "jollyposting"}
+		b.stats.RecordDeletion(start, "alice")
+		mock := &mockSession{sendErr: errors.New("boom")}
+
+		if err := b.PostWeeklyDigest(mock, start); err == nil {
+			t.Error("PostWeeklyDigest() should report the failed post")
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package bot
+
+import (
+	"context"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/metrics"
+)
+
+// isDirectMessage reports whether an event with guildID happened outside a guild.
+func isDirectMessage(guildID string) bool {
+	return guildID == ""
+}
+
+// isWatched reports whether the bot handles skulls in channelID: a monitored
+// guild channel, or a DM with the bot when DIRECT_MESSAGES is on.
+func (b *Bot) isWatched(guildID, channelID string) bool {
+	if b.isMonitored(channelID) {
+		return true
+	}
+	return isDirectMessage(guildID) && b.config.DirectMessages && b.isReady()
+}
+
+// ReactInDM answers a skull in a DM with a jollyskull reaction and reports
+// whether it did. Bots cannot remove other users' reactions or delete their
+// messages in DMs, so the skull itself stays. In dry-run mode it only logs
+// what it would do.
+func (b *Bot) ReactInDM(ctx context.Context, s Session, channelID, messageID string) bool {
+	_, err := b.addJollySkull(ctx, s, policySkullReaction, channelID, messageID)
+	if actions.Skipped(err) {
+		return false
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to add jollyskull reaction in DM", "message_id", messageID, "channel_id", channelID, "error", err)
+		metrics.Incr(b.sink(), metrics.ReactionReplaceFailures)
+		return false
+	}
+	logger.DebugContext(ctx, "added jollyskull in DM", "message_id", messageID, "channel_id", channelID)
+	metrics.Incr(b.sink(), metrics.DirectMessageReactions)
+	return true
+}
@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/metrics"
+)
+
+func TestBot_IsWatched(t *testing.T) {
+	tests := []struct {
+		name           string
+		directMessages bool
+		ready          bool
+		guildID        string
+		channelID      string
+		expected       bool
+	}{
+		{name: "monitored channel", ready: true, guildID: "guild1", channelID: "chan123", expected: true},
+		{name: "other guild channel", directMessages: true, ready: true, guildID: "guild1", channelID: "other", expected: false},
+		{name: "DM with direct messages off", ready: true, channelID: "dm1", expected: false},
+		{name: "DM with direct messages on", directMessages: true, ready: true, channelID: "dm1", expected: true},
+		{name: "DM before ready", directMessages: true, channelID: "dm1", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig([]string{"user456"}, "jollyskull:123")
+			cfg.DirectMessages = tt.directMessages
+			b := &Bot{
+				config:   cfg,
+				channels: map[string]string{"chan123": "jollyposting"},
+				ready:    tt.ready,
+			}
+			if got := b.isWatched(tt.guildID, tt.channelID); got != tt.expected {
+				t.Errorf("isWatched(%q, %q) = %v, want %v", tt.guildID, tt.channelID, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBot_ReactInDM(t *testing.T) {
+	cfg := newTestConfig([]string{"user456"}, "jollyskull:123")
+	cfg.DirectMessages = true
+
+	t.Run("adds jollyskull without removing the skull", func(t *testing.T) {
+		sink := newRecordingSink()
+		b := New(cfg, WithMetrics(sink))
+		mock := &mockSession{}
+
+		if !b.ReactInDM(context.Background(), mock, "dm1", "msg1") {
+			t.Fatal("ReactInDM() = false, want true")
+		}
+		if len(mock.addedReactions) != 1 || mock.addedReactions[0].emojiID != "jollyskull:123" {
+			t.Errorf("expected a jollyskull reaction, got %v", mock.addedReactions)
+		}
+		if len(mock.removedReactions) != 0 {
+			t.Errorf("expected no removed reactions, got %v", mock.removedReactions)
+		}
+		if sink.counts[metrics.DirectMessageReactions] != 1 {
+			t.Errorf("expected 1 DM reaction, got %d", sink.counts[metrics.DirectMessageReactions])
+		}
+	})
+
+	t.Run("dry run only logs", func(t *testing.T) {
+		cfg := *cfg
+		cfg.DryRun = true
+		b := New(&cfg)
+		mock := &mockSession{}
+
+		if b.ReactInDM(context.Background(), mock, "dm1", "msg1") {
+			t.Error("ReactInDM() = true in dry run, want false")
+		}
+		if len(mock.addedReactions) != 0 {
+			t.Errorf("expected no reactions in dry run, got %v", mock.addedReactions)
+		}
+	})
+}
+
+func TestBot_ShouldDeleteMessage_DirectMessage(t *testing.T) {
+	cfg := newTestConfig([]string{"user456"}, "jollyskull:123")
+	cfg.DirectMessages = true
+	b := New(cfg)
+	b.ready = true
+
+	m := &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID:        "msg1",
+		ChannelID: "dm1",
+		Content:   "💀",
+		Author:    &discordgo.User{ID: "user456"},
+	}}
+	if !b.ShouldDeleteMessage(m) {
+		t.Error("ShouldDeleteMessage() = false for a skull-only DM, want true")
+	}
+}
@@ -0,0 +1,176 @@
+package bot
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// doctorProbeMessages is how many recent messages per channel the Message
+// Content check looks at.
+const doctorProbeMessages = 50
+
+// DoctorSession is the part of the Discord API the doctor checks use on top
+// of Session.
+type DoctorSession interface {
+	Session
+	User(userID string, options ...discordgo.RequestOption) (*discordgo.User, error)
+	GuildRoles(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Role, error)
+}
+
+// Check outcomes.
+const (
+	CheckPass = "pass"
+	CheckFail = "fail"
+	CheckSkip = "skip" // Could not be decided, e.g. without messages to probe
+)
+
+// DoctorCheck is one Discord-side prerequisite and whether it is met.
+type DoctorCheck struct {
+	Name   string
+	Status string
+	Detail string // What was found, and for failures how to fix it
+}
+
+// Doctor checks the prerequisites that live on Discord's side and cannot
+// be read from the configuration: the Message Content intent, the bot's
+// role position, and whether the jollyskull emoji is usable.
+func (b *Bot) Doctor(s DoctorSession) []DoctorCheck {
+	me, err := s.User("@me")
+	if err != nil {
+		return []DoctorCheck{{"Bot token", CheckFail, fmt.Sprintf("Discord rejected the token: %v", err)}}
+	}
+	checks := []DoctorCheck{{"Bot token", CheckPass, "logged in as " + me.Username}}
+
+	self, err := s.GuildMember(b.config.GuildID, me.ID)
+	if err != nil {
+		return append(checks, DoctorCheck{"Server membership", CheckFail, fmt.Sprintf("the bot is not in server %s; invite it with the link from `bot invite`: %v", b.config.GuildID, err)})
+	}
+	checks = append(checks, DoctorCheck{"Server membership", CheckPass, "the bot is in server " + b.config.GuildID})
+
+	checks = append(checks, b.checkMessageContent(s, me.ID))
+	roles, err := s.GuildRoles(b.config.GuildID)
+	if err != nil {
+		checks = append(checks, DoctorCheck{"Role position", CheckFail, fmt.Sprintf("failed to list roles: %v", err)})
+	} else {
+		checks = append(checks, b.checkRolePosition(s, self, roles))
+	}
+	return append(checks, b.checkJollySkull(s, self))
+}
+
+// checkMessageContent probes recent messages for content. Without the
+// privileged Message Content intent, Discord strips the content, embeds,
+// and attachments of other users' messages, so skull-only messages cannot
+// be recognized.
+func (b *Bot) checkMessageContent(s Session, selfID string) DoctorCheck {
+	const name = "Message Content intent"
+	channels, err := b.resolveChannels(s)
+	if err != nil {
+		return DoctorCheck{name, CheckFail, fmt.Sprintf("failed to find the watched channels: %v", err)}
+	}
+
+	probed := 0
+	for _, channelID := range slices.Sorted(maps.Keys(channels)) {
+		msgs, err := s.ChannelMessages(channelID, doctorProbeMessages, "", "", "")
+		if err != nil {
+			return DoctorCheck{name, CheckFail, fmt.Sprintf("failed to read #%s: %v", channels[channelID].Name, err)}
+		}
+		for _, m := range msgs {
+			if m.Author == nil || m.Author.ID == selfID || (m.Type != discordgo.MessageTypeDefault && m.Type != discordgo.MessageTypeReply) ||
+				slices.ContainsFunc(m.Mentions, func(u *discordgo.User) bool { return u.ID == selfID }) {
+				continue // Discord always includes the content of these
+			}
+			probed++
+			if m.Content != "" || len(m.Embeds) > 0 || len(m.Attachments) > 0 {
+				return DoctorCheck{name, CheckPass, "messages arrive with their content"}
+			}
+		}
+	}
+	if probed == 0 {
+		return DoctorCheck{name, CheckSkip, "no recent messages by others to probe"}
+	}
+	return DoctorCheck{name, CheckFail, fmt.Sprintf("%d recent messages arrived without content; enable Message Content Intent under Bot in the developer portal", probed)}
+}
+
+// checkRolePosition checks that the bot's highest role is above those of the
+// target users, so that role permission overwrites cannot shield them.
+func (b *Bot) checkRolePosition(s Session, self *discordgo.Member, roles []*discordgo.Role) DoctorCheck {
+	const name = "Role position"
+	positions := make(map[string]int, len(roles))
+	names := make(map[string]string, len(roles))
+	for _, r := range roles {
+		positions[r.ID], names[r.ID] = r.Position, r.Name
+	}
+	highest := func(m *discordgo.Member) (pos int, roleID string) {
+		for _, id := range m.Roles {
+			if p, ok := positions[id]; ok && p > pos {
+				pos, roleID = p, id
+			}
+		}
+		return pos, roleID
+	}
+
+	botPos, botRole := highest(self)
+	var above []string
+	for _, userID := range b.targetUserIDs() {
+		m, err := s.GuildMember(b.config.GuildID, userID)
+		if err != nil {
+			continue // Targets who left the server have no roles to compare
+		}
+		if pos, roleID := highest(m); pos >= botPos && roleID != "" {
+			above = append(above, fmt.Sprintf("%s (@%s)", userID, names[roleID]))
+		}
+	}
+	if len(above) > 0 {
+		return DoctorCheck{name, CheckFail, fmt.Sprintf("target users %s have a role at or above the bot's; move the bot's role up in Server Settings > Roles", strings.Join(above, ", "))}
+	}
+	if botRole == "" {
+		return DoctorCheck{name, CheckPass, "no target user has a role"}
+	}
+	return DoctorCheck{name, CheckPass, fmt.Sprintf("@%s is above every target user's roles", names[botRole])}
+}
+
+// checkJollySkull checks that the replacement emoji exists in the server,
+// is available, and is not restricted to roles the bot lacks. Unicode
+// emojis always are.
+func (b *Bot) checkJollySkull(s DoctorSession, self *discordgo.Member) DoctorCheck {
+	const name = "Jollyskull emoji"
+	emojiName, id, custom := strings.Cut(b.config.JollySkullID, ":")
+	if !custom {
+		return DoctorCheck{name, CheckPass, fmt.Sprintf("%s is a Unicode emoji, which every server can use", b.config.JollySkullID)}
+	}
+	e, err := b.emojis.ByID(s, b.config.GuildID, id)
+	if err != nil {
+		return DoctorCheck{name, CheckFail, err.Error()}
+	}
+	if e == nil {
+		return DoctorCheck{name, CheckFail, fmt.Sprintf("the server has no emoji with ID %s; check DISCORD_JOLLYSKULL_ID", id)}
+	}
+	if !e.Available {
+		return DoctorCheck{name, CheckFail, fmt.Sprintf(":%s: is unavailable, usually because the server lost the boost level it needs", e.Name)}
+	}
+	if len(e.Roles) > 0 && !slices.ContainsFunc(e.Roles, func(r string) bool { return slices.Contains(self.Roles, r) }) {
+		return DoctorCheck{name, CheckFail, fmt.Sprintf(":%s: is restricted to roles the bot does not have", e.Name)}
+	}
+	if e.Name != emojiName {
+		return DoctorCheck{name, CheckFail, fmt.Sprintf("the emoji is named :%s:, not :%s:; update DISCORD_JOLLYSKULL_ID", e.Name, emojiName)}
+	}
+	return DoctorCheck{name, CheckPass, fmt.Sprintf(":%s: is usable", e.Name)}
+}
+
+// WriteChecklist writes checks as a pass/fail list. It reports whether
+// every check passed or was skipped.
+func WriteChecklist(w io.Writer, checks []DoctorCheck) (bool, error) {
+	var sb strings.Builder
+	ok := true
+	for _, c := range checks {
+		fmt.Fprintf(&sb, "[%s] %s: %s\n", strings.ToUpper(c.Status), c.Name, c.Detail)
+		ok = ok && c.Status != CheckFail
+	}
+	_, err := io.WriteString(w, sb.String())
+	return ok, err
+}
@@ -0,0 +1,203 @@
+package bot
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// doctorSession adds the calls only the doctor makes to mockSession.
+type doctorSession struct {
+	*mockSession
+	userErr error
+	roles   []*discordgo.Role
+	emojis  []*discordgo.Emoji
+}
+
+func (d *doctorSession) User(userID string, options ...discordgo.RequestOption) (*discordgo.User, error) {
+	if d.userErr != nil {
+		return nil, d.userErr
+	}
+	return &discordgo.User{ID: "bot1", Username: "jolly"}, nil
+}
+
+func (d *doctorSession) GuildRoles(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Role, error) {
+	return d.roles, nil
+}
+
+func (d *doctorSession) GuildEmojis(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Emoji, error) {
+	return d.emojis, nil
+}
+
+// newDoctorSession returns a server where every check passes.
+func newDoctorSession() *doctorSession {
+	return &doctorSession{
+		mockSession: &mockSession{
+			channels: []*discordgo.Channel{{ID: "channel123", Name: "jollyposting", Type: discordgo.ChannelTypeGuildText}},
+			messages: []*discordgo.Message{
+				{Author: &discordgo.User{ID: "bot1"}, Content: "mine"},
+				{Author: &discordgo.User{ID: "user1"}, Content: "hello"},
+			},
+			members: map[string]*discordgo.Member{
+				"bot1":      {User: &discordgo.User{ID: "bot1"}, Roles: []string{"role-bot"}},
+				"target123": {User: &discordgo.User{ID: "target123"}, Roles: []string{"role-member"}},
+			},
+		},
+		roles: []*discordgo.Role{
+			{ID: "role-member", Name: "Member", Position: 1},
+			{ID: "role-bot", Name: "Jolly", Position: 2},
+			{ID: "role-mod", Name: "Mod", Position: 3},
+		},
+		emojis: []*discordgo.Emoji{{ID: "123", Name: "jollyskull", Available: true}},
+	}
+}
+
+func TestBot_Doctor(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(d *doctorSession)
+		check      string
+		wantStatus string
+		wantDetail string
+	}{
+		{"all pass: token", nil, "Bot token", CheckPass, "logged in as jolly"},
+		{"all pass: content", nil, "Message Content intent", CheckPass, "messages arrive with their content"},
+		{"all pass: roles", nil, "Role position", CheckPass, "@Jolly is above"},
+		{"all pass: emoji", nil, "Jollyskull emoji", CheckPass, ":jollyskull: is usable"},
+		{
+			name:       "bad token",
+			setup:      func(d *doctorSession) { d.userErr = errors.New("HTTP 401 Unauthorized") },
+			check:      "Bot token",
+			wantStatus: CheckFail,
+			wantDetail: "401",
+		},
+		{
+			name:       "bot not in server",
+			setup:      func(d *doctorSession) { delete(d.members, "bot1") },
+			check:      "Server membership",
+			wantStatus: CheckFail,
+			wantDetail: "bot invite",
+		},
+		{
+			name: "content stripped",
+			setup: func(d *doctorSession) {
+				d.messages = []*discordgo.Message{
+					{Author: &discordgo.User{ID: "user1"}},
+					{Author: &discordgo.User{ID: "user2"}},
+					{Author: &discordgo.User{ID: "user3"}, Content: "<@bot1> hi", Mentions: []*discordgo.User{{ID: "bot1"}}},
+				}
+			},
+			check:      "Message Content intent",
+			wantStatus: CheckFail,
+			wantDetail: "2 recent messages arrived without content",
+		},
+		{
+			name:       "nothing to probe",
+			setup:      func(d *doctorSession) { d.messages = nil },
+			check:      "Message Content intent",
+			wantStatus: CheckSkip,
+		},
+		{
+			name:       "target above bot",
+			setup:      func(d *doctorSession) { d.members["target123"].Roles = []string{"role-mod"} },
+			check:      "Role position",
+			wantStatus: CheckFail,
+			wantDetail: "target123 (@Mod)",
+		},
+		{
+			name:       "emoji missing",
+			setup:      func(d *doctorSession) { d.emojis = nil },
+			check:      "Jollyskull emoji",
+			wantStatus: CheckFail,
+			wantDetail: "no emoji with ID 123",
+		},
+		{
+			name:       "emoji unavailable",
+			setup:      func(d *doctorSession) { d.emojis[0].Available = false },
+			check:      "Jollyskull emoji",
+			wantStatus: CheckFail,
+			wantDetail: "unavailable",
+		},
+		{
+			name:       "emoji restricted",
+			setup:      func(d *doctorSession) { d.emojis[0].Roles = []string{"role-mod"} },
+			check:      "Jollyskull emoji",
+			wantStatus: CheckFail,
+			wantDetail: "restricted to roles",
+		},
+		{
+			name:       "emoji renamed",
+			setup:      func(d *doctorSession) { d.emojis[0].Name = "jolly" },
+			check:      "Jollyskull emoji",
+			wantStatus: CheckFail,
+			wantDetail: "update DISCORD_JOLLYSKULL_ID",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig([]string{"target123"}, "jollyskull:123")
+			cfg.GuildID = "guild123"
+			cfg.ChannelName = "jollyposting"
+			d := newDoctorSession()
+			if tt.setup != nil {
+				tt.setup(d)
+			}
+
+			var got *DoctorCheck
+			checks := New(cfg).Doctor(d)
+			for i := range checks {
+				if checks[i].Name == tt.check {
+					got = &checks[i]
+				}
+			}
+			if got == nil {
+				t.Fatalf("no %q check in %+v", tt.check, checks)
+			}
+			if got.Status != tt.wantStatus || !strings.Contains(got.Detail, tt.wantDetail) {
+				t.Errorf("%s = %s: %s, want %s containing %q", tt.check, got.Status, got.Detail, tt.wantStatus, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestBot_Doctor_UnicodeJollySkull(t *testing.T) {
+	cfg := newTestConfig([]string{"target123"}, "🎅")
+	cfg.GuildID = "guild123"
+	d := newDoctorSession()
+	d.emojis = nil
+
+	for _, c := range New(cfg).Doctor(d) {
+		if c.Name == "Jollyskull emoji" && (c.Status != CheckPass || !strings.Contains(c.Detail, "🎅 is a Unicode emoji")) {
+			t.Errorf("%s = %s: %s, want it to pass for a Unicode emoji", c.Name, c.Status, c.Detail)
+		}
+	}
+}
+
+func TestWriteChecklist(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []DoctorCheck
+		wantOK bool
+	}{
+		{"pass and skip", []DoctorCheck{{"A", CheckPass, "fine"}, {"B", CheckSkip, "unknown"}}, true},
+		{"a failure", []DoctorCheck{{"A", CheckPass, "fine"}, {"B", CheckFail, "broken"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			ok, err := WriteChecklist(&buf, tt.checks)
+			if err != nil {
+				t.Fatalf("WriteChecklist() error = %v", err)
+			}
+			if ok != tt.wantOK {
+				t.Errorf("WriteChecklist() = %v, want %v", ok, tt.wantOK)
+			}
+			if !strings.HasPrefix(buf.String(), "[PASS] A: fine\n") {
+				t.Errorf("checklist = %q", buf.String())
+			}
+		})
+	}
+}
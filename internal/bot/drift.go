@@ -0,0 +1,106 @@
+package bot
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/flags"
+	"jolly-okurb/internal/i18n"
+	"jolly-okurb/internal/settings"
+)
+
+// configDrift is a setting whose stored override disagrees with the
+// environment. The stored value wins.
+type configDrift struct {
+	EnvVar    string // Environment variable that is overridden
+	ChannelID string // Channel of the override; empty for the whole server
+	Env       any    // A bool, or a list of user IDs
+	Store     any
+}
+
+// configDrift lists the overrides of guildID, whose settings are g, that
+// disagree with the environment: dry run, feature flags, and the target users
+// once they are seeded.
+func (b *Bot) configDrift(guildID string, g settings.Guild) []configDrift {
+	var drift []configDrift
+	if g.TargetsSeeded && !sameMembers(g.Targets, b.config.TargetUserIDs) {
+		drift = append(drift, configDrift{EnvVar: "DISCORD_TARGET_USER_IDS", Env: b.config.TargetUserIDs, Store: g.Targets})
+	}
+	if g.DryRun != nil && *g.DryRun != b.config.DryRun {
+		drift = append(drift, configDrift{EnvVar: "DRY_RUN", Env: b.config.DryRun, Store: *g.DryRun})
+	}
+	for _, channelID := range slices.Sorted(maps.Keys(g.ChannelDryRun)) {
+		if v := g.ChannelDryRun[channelID]; v != b.config.DryRun {
+			drift = append(drift, configDrift{EnvVar: "DRY_RUN", ChannelID: channelID, Env: b.config.DryRun, Store: v})
+		}
+	}
+	overrides, err := b.flags.Overrides(guildID)
+	if err != nil {
+		logger.Warn("failed to load feature flags", "guild_id", guildID, "error", err)
+	}
+	for _, f := range flags.All {
+		if v, ok := overrides[f]; ok && v != b.flags.Default(f) {
+			drift = append(drift, configDrift{EnvVar: "FEATURE_FLAGS " + string(f), Env: b.flags.Default(f), Store: v})
+		}
+	}
+	return drift
+}
+
+// logConfigDrift warns about every stored override that disagrees with the environment.
+func (b *Bot) logConfigDrift() {
+	for _, d := range b.configDrift(b.config.GuildID, b.guildSettings()) {
+		logger.Warn("stored setting overrides the environment", "env_var", d.EnvVar, "channel_id", d.ChannelID, "env", d.Env, "store", d.Store)
+	}
+}
+
+func (b *Bot) handleConfigDiff(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	g, err := b.settings.Guild(i.GuildID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := b.printer(i.GuildID)
+	drift := b.configDrift(i.GuildID, g)
+	if len(drift) == 0 {
+		return textReply(p.T("diff.none")), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintln(&sb, p.T("diff.header"))
+	for _, d := range drift {
+		if d.ChannelID != "" {
+			fmt.Fprintln(&sb, p.T("diff.channel", d.ChannelID, d.EnvVar, formatDriftValue(p, d.Env), formatDriftValue(p, d.Store)))
+		} else {
+			fmt.Fprintln(&sb, p.T("diff.server", d.EnvVar, formatDriftValue(p, d.Env), formatDriftValue(p, d.Store)))
+		}
+	}
+	return textReply(sb.String()), nil
+}
+
+// formatDriftValue formats a configDrift value: a toggle, or users as mentions.
+func formatDriftValue(p i18n.Printer, v any) string {
+	switch v := v.(type) {
+	case bool:
+		return formatToggle(p, v)
+	case []string:
+		if len(v) == 0 {
+			return p.T("diff.nobody")
+		}
+		mentions := make([]string, len(v))
+		for i, id := range v {
+			mentions[i] = "<@" + id + ">"
+		}
+		return strings.Join(mentions, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// sameMembers reports whether a and b hold the same strings, in any order.
+func sameMembers(a, b []string) bool {
+	return slices.Equal(slices.Sorted(slices.Values(a)), slices.Sorted(slices.Values(b)))
+}
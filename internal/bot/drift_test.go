@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"testing"
+
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/flags"
+)
+
+func TestBot_ConfigDiff(t *testing.T) {
+	on, off := true, false
+
+	tests := []struct {
+		name      string
+		envDryRun bool
+		server    *bool
+		channels  map[string]bool
+		targets   []string // Stored targets, seeded when set
+		flags     map[flags.Flag]bool
+		want      string
+	}{
+		{
+			name: "no overrides",
+			want: "No server setting overrides the environment.",
+		},
+		{
+			name:      "overrides that agree are not drift",
+			envDryRun: true,
+			server:    &on,
+			channels:  map[string]bool{"chan1": true},
+			want:      "No server setting overrides the environment.",
+		},
+		{
+			name:     "server and channel overrides",
+			server:   &on,
+			channels: map[string]bool{"chan2": true, "chan1": false},
+			want: "Server settings that override the environment (the server setting wins):\n" +
+				"- DRY_RUN: off in the environment, on for this server\n" +
+				"- <#chan2>: DRY_RUN off in the environment, on for this channel\n",
+		},
+		{
+			name:      "server turns dry run off",
+			envDryRun: true,
+			server:    &off,
+			want: "Server settings that override the environment (the server setting wins):\n" +
+				"- DRY_RUN: on in the environment, off for this server\n",
+		},
+		{
+			name:    "stored targets that agree are not drift",
+			targets: []string{"user1"},
+			want:    "No server setting overrides the environment.",
+		},
+		{
+			name:    "stored targets",
+			targets: []string{"user2", "user3"},
+			want: "Server settings that override the environment (the server setting wins):\n" +
+				"- DISCORD_TARGET_USER_IDS: <@user1> in the environment, <@user2>, <@user3> for this server\n",
+		},
+		{
+			name:    "no stored targets",
+			targets: []string{},
+			want: "Server settings that override the environment (the server setting wins):\n" +
+				"- DISCORD_TARGET_USER_IDS: <@user1> in the environment, nobody for this server\n",
+		},
+		{
+			name:  "feature flags",
+			flags: map[flags.Flag]bool{flags.SoftDelete: true, flags.Escalation: true, flags.AutoExclude: false},
+			want: "Server settings that override the environment (the server setting wins):\n" +
+				"- FEATURE_FLAGS soft_delete: off in the environment, on for this server\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(&config.Config{GuildID: "guild123", DryRun: tt.envDryRun, TargetUserIDs: []string{"user1"}, FeatureFlags: map[flags.Flag]bool{flags.Escalation: true}})
+			if tt.targets != nil {
+				if _, err := b.settings.Import("guild123", tt.targets, tt.envDryRun); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.server != nil {
+				if err := b.settings.SetDryRun("guild123", "", tt.server); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for f, v := range tt.flags {
+				if err := b.flags.Override("guild123", f, &v); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for channelID, v := range tt.channels {
+				if err := b.settings.SetDryRun("guild123", channelID, &v); err != nil {
+					t.Fatal(err)
+				}
+			}
+			mock := &mockSession{}
+
+			b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"config", "diff"}))
+
+			if got := lastResponse(t, mock); got != tt.want {
+				t.Errorf("response = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
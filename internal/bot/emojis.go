@@ -0,0 +1,200 @@
+package bot
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/correlation"
+	"jolly-okurb/internal/stats"
+)
+
+// emojiDeletePrefix is the custom ID prefix of the button on an emoji deletion
+// suggestion, followed by the emoji ID.
+const emojiDeletePrefix = "jolly:emoji:delete:"
+
+// recordSkullUses counts emojis, skull emojis someone other than the bot used
+// in channelID, toward the usage shown by /jolly emojis. Only monitored
+// channels count. A custom emoji of the server reaching EMOJI_DELETE_SUGGEST
+// uses that day is suggested for deletion.
+func (b *Bot) recordSkullUses(s Session, channelID string, emojis ...string) {
+	if len(emojis) == 0 || !b.isMonitored(channelID) {
+		return
+	}
+	counts, err := b.stats.RecordSkullUses(time.Now(), emojis...)
+	if err != nil {
+		logger.Warn("failed to record skull emoji uses", "channel_id", channelID, "error", err)
+		return
+	}
+	if b.config.EmojiDeleteSuggest == 0 {
+		return
+	}
+	for i, emoji := range emojis {
+		if counts[i] == b.config.EmojiDeleteSuggest && strings.HasPrefix(emoji, "<") {
+			b.suggestEmojiDeletion(s, emoji, counts[i])
+		}
+	}
+}
+
+// suggestEmojiDeletion posts to the admin channel that tag, a custom skull
+// emoji used n times today, can be deleted, with a button that does so. Emojis
+// of other servers are left alone, as the bot cannot delete them.
+func (b *Bot) suggestEmojiDeletion(s Session, tag string, n int) {
+	id := strings.TrimSuffix(tag[strings.LastIndex(tag, ":")+1:], ">")
+	e, err := b.emojis.ByID(s, b.config.GuildID, id)
+	if err != nil {
+		logger.Warn("failed to look up emoji to suggest deleting", "emoji", tag, "error", err)
+		return
+	}
+	if e == nil {
+		return
+	}
+	logger.Info("suggesting emoji deletion", "emoji", tag, "uses", n)
+
+	p := b.printer(b.config.GuildID)
+	err = b.executor.Do(context.Background(), actions.Action{
+		Kind:      actions.Post,
+		ChannelID: b.config.AdminChannelID,
+		Do: func(...discordgo.RequestOption) error {
+			_, err := s.ChannelMessageSendComplex(b.config.AdminChannelID, &discordgo.MessageSend{
+				Content: p.T("emojis.suggest", tag, n),
+				Components: []discordgo.MessageComponent{
+					discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+						discordgo.Button{Label: p.T("emojis.button.delete", e.Name), Style: discordgo.DangerButton, CustomID: emojiDeletePrefix + id},
+					}},
+				},
+			})
+			return err
+		},
+	})
+	if err != nil && !actions.Skipped(err) {
+		logger.Error("failed to post emoji deletion suggestion", "emoji", tag, "channel_id", b.config.AdminChannelID, "error", err)
+	}
+}
+
+// handleEmojiDeleteButton deletes the emoji a suggestion offered to delete,
+// with the moderator who pressed the button in the audit log reason.
+func (b *Bot) handleEmojiDeleteButton(s Session, i *discordgo.InteractionCreate, customID string) {
+	p := b.printer(i.GuildID)
+	if i.Member == nil || i.Member.Permissions&panelPermissions == 0 {
+		b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, textReply(p.T("emojis.delete.denied")))
+		return
+	}
+	if i.AppPermissions&discordgo.PermissionManageGuildExpressions == 0 {
+		b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, textReply(p.T("emojis.delete.permission")))
+		return
+	}
+
+	id := strings.TrimPrefix(customID, emojiDeletePrefix)
+	e, err := b.emojis.ByID(s, b.config.GuildID, id)
+	if err != nil {
+		logger.Error("failed to look up emoji to delete", "emoji_id", id, "error", err)
+		b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, textReply(p.T("command.error", err)))
+		return
+	}
+	if e == nil {
+		b.respond(s, i, discordgo.InteractionResponseUpdateMessage, textReply(p.T("emojis.delete.gone", id)))
+		return
+	}
+
+	ctx := correlation.Start(context.Background())
+	action := actions.Action{
+		Kind:    actions.DeleteEmoji,
+		Policy:  policyEmojiCleanup,
+		EmojiID: id,
+		Comment: "approved by " + interactionUserID(i),
+		Do: func(opts ...discordgo.RequestOption) error {
+			return b.emojis.Delete(ctx, s, b.config.GuildID, id, opts...)
+		},
+	}
+	switch err := b.executor.Do(ctx, action); {
+	case actions.Skipped(err):
+		b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, textReply(p.T("emojis.delete.skipped", err)))
+		return
+	case err != nil:
+		logger.ErrorContext(ctx, "failed to delete emoji", "emoji_id", id, "error", err)
+		b.exportAction(ctx, action, auditFailed)
+		b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, textReply(p.T("command.error", err)))
+		return
+	}
+	b.exportAction(ctx, action, auditCompleted)
+	logger.InfoContext(ctx, "emoji deleted", "emoji", e.Name, "emoji_id", id, "by", interactionUserID(i))
+
+	reply := textReply(p.T("emojis.deleted", e.Name, interactionUserID(i)))
+	reply.AllowedMentions = &discordgo.MessageAllowedMentions{}
+	b.respond(s, i, discordgo.InteractionResponseUpdateMessage, reply)
+}
+
+// emojiUsage is how often a skull emoji was used and replaced.
+type emojiUsage struct {
+	emoji    string
+	uses     int
+	replaced int
+}
+
+// handleEmojis replies with how often each skull emoji was used and replaced
+// in the last days, ending today, and which of the server's custom skull
+// emojis nobody used, so admins can tell which are worth deleting.
+func (b *Bot) handleEmojis(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	days, err := b.optionDays(opts)
+	if err != nil {
+		return nil, err
+	}
+	p := b.printer(i.GuildID)
+	summary := stats.Summarize(days)
+
+	var usage []emojiUsage
+	index := make(map[string]int)
+	count := func(emoji string) *emojiUsage {
+		n, ok := index[emoji]
+		if !ok {
+			n = len(usage)
+			index[emoji] = n
+			usage = append(usage, emojiUsage{emoji: emoji})
+		}
+		return &usage[n]
+	}
+	for _, c := range summary.TopSkulls {
+		count(c.Key).uses = c.N
+	}
+	for _, c := range summary.TopEmojis {
+		count(c.Key).replaced = c.N
+	}
+	slices.SortStableFunc(usage, func(a, b emojiUsage) int {
+		return cmp.Or(cmp.Compare(b.uses, a.uses), cmp.Compare(b.replaced, a.replaced))
+	})
+
+	var unused []string
+	guildEmojis, err := b.emojis.List(s, b.config.GuildID)
+	if err != nil {
+		logger.Warn("failed to list server emojis, not reporting unused ones", "guild_id", b.config.GuildID, "error", err)
+	}
+	for _, e := range guildEmojis {
+		if b.IsSkullEmoji(e) {
+			if _, ok := index[e.MessageFormat()]; !ok {
+				unused = append(unused, e.MessageFormat())
+			}
+		}
+	}
+
+	if len(usage) == 0 && len(unused) == 0 {
+		return textReply(p.T("emojis.none", len(days))), nil
+	}
+	lines := []string{p.T("emojis.header", len(days))}
+	for _, u := range usage {
+		lines = append(lines, p.T("emojis.line", u.emoji, u.uses, u.replaced))
+	}
+	if len(usage) == 0 {
+		lines = []string{p.T("emojis.none", len(days))}
+	}
+	if len(unused) > 0 {
+		lines = append(lines, "", p.T("emojis.unused", strings.Join(unused, " ")))
+	}
+	logger.Info("emoji usage reported", "days", len(days), "emojis", len(usage), "unused", len(unused), "by", interactionUserID(i))
+	return textReply(strings.Join(lines, "\n")), nil
+}
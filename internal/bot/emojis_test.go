@@ -0,0 +1,182 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestBot_RecordSkullUses(t *testing.T) {
+	b := New(newTestConfig(nil, "jollyskull:123"))
+	b.channels = map[string]string{"chan": "jollyposting"}
+	b.ready = true
+
+	mock := &mockSession{}
+
+	b.recordSkullUses(mock, "chan", "💀", "<:deadskull:1>")
+	b.recordSkullUses(mock, "chan", "💀")
+	b.recordSkullUses(mock, "other", "💀")
+	b.recordSkullUses(mock, "chan")
+
+	days, err := b.stats.Days(time.Now().UTC(), 1)
+	if err != nil {
+		t.Fatalf("Days() error = %v", err)
+	}
+	if got := days[0].Skulls; len(got) != 2 || got["💀"] != 2 || got["<:deadskull:1>"] != 1 {
+		t.Errorf("Skulls = %v, want uses in the monitored channel only", got)
+	}
+	if len(mock.sent) != 0 {
+		t.Errorf("sent %+v, want no suggestions without EMOJI_DELETE_SUGGEST", mock.sent)
+	}
+}
+
+func TestBot_SuggestEmojiDeletion(t *testing.T) {
+	cfg := newTestConfig(nil, "jollyskull:123")
+	cfg.AdminChannelID = "admin"
+	cfg.EmojiDeleteSuggest = 2
+	b := New(cfg)
+	b.channels = map[string]string{"chan": "jollyposting"}
+	b.ready = true
+	mock := &mockSession{emojis: []*discordgo.Emoji{{ID: "1", Name: "deadskull"}}}
+
+	for range 3 {
+		b.recordSkullUses(mock, "chan", "💀", "<:deadskull:1>", "<:otherskull:2>")
+	}
+
+	if len(mock.sent) != 1 {
+		t.Fatalf("sent %+v, want one suggestion", mock.sent)
+	}
+	want := "<:deadskull:1> was used 2 times today. Delete it from the server so there are fewer skulls to replace?"
+	if got := mock.sent[0]; got.channelID != "admin" || got.content != want {
+		t.Errorf("sent %q to %s, want %q to admin", got.content, got.channelID, want)
+	}
+}
+
+func TestBot_HandleEmojiDeleteButton(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions int64
+		appPerms    int64
+		emojis      []*discordgo.Emoji
+		want        string
+		wantDeleted bool
+	}{
+		{
+			name:        "deletes",
+			permissions: discordgo.PermissionManageGuild,
+			appPerms:    discordgo.PermissionManageGuildExpressions,
+			emojis:      []*discordgo.Emoji{{ID: "1", Name: "deadskull"}},
+			want:        "🗑️ Deleted :deadskull:, approved by <@admin1>.",
+			wantDeleted: true,
+		},
+		{
+			name:        "already gone",
+			permissions: discordgo.PermissionManageGuild,
+			appPerms:    discordgo.PermissionManageGuildExpressions,
+			want:        "The emoji 1 is no longer on the server.",
+		},
+		{
+			name:     "member lacks permission",
+			appPerms: discordgo.PermissionManageGuildExpressions,
+			emojis:   []*discordgo.Emoji{{ID: "1", Name: "deadskull"}},
+			want:     "You need Manage Server to delete emojis.",
+		},
+		{
+			name:        "bot lacks permission",
+			permissions: discordgo.PermissionManageGuild,
+			emojis:      []*discordgo.Emoji{{ID: "1", Name: "deadskull"}},
+			want:        "The bot needs the Manage Expressions permission to delete emojis.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var export bytes.Buffer
+			b := New(newTestConfig(nil, "jollyskull:123"), WithAuditExport(&export))
+			mock := &mockSession{emojis: tt.emojis}
+			i := newComponentInteraction("guild123", emojiDeletePrefix+"1")
+			i.Member.Permissions = tt.permissions
+			i.AppPermissions = tt.appPerms
+
+			b.HandleInteraction(mock, i)
+
+			if got := lastResponse(t, mock); got != tt.want {
+				t.Errorf("reply = %q, want %q", got, tt.want)
+			}
+			if deleted := slices.Contains(mock.deletedEmojis, "1"); deleted != tt.wantDeleted {
+				t.Errorf("deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+			if !tt.wantDeleted {
+				return
+			}
+			var entry auditLogEntry
+			if err := json.Unmarshal(export.Bytes(), &entry); err != nil {
+				t.Fatalf("invalid audit export %q: %v", export.String(), err)
+			}
+			if entry.ActionType != discordgo.AuditLogActionEmojiDelete || entry.TargetID != "1" {
+				t.Errorf("exported %+v, want an emoji deletion of 1", entry)
+			}
+			if want := "jolly-okurb: skull emoji cleanup (approved by admin1)"; entry.Reason != want {
+				t.Errorf("reason = %q, want %q", entry.Reason, want)
+			}
+		})
+	}
+}
+
+func TestBot_HandleEmojis(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		uses   []string
+		emojis []*discordgo.Emoji
+		want   string
+	}{
+		{
+			name: "usage",
+			uses: []string{"💀", "💀", "☠️", "<:deadskull:1>"},
+			emojis: []*discordgo.Emoji{
+				{ID: "1", Name: "deadskull"},
+				{ID: "2", Name: "oldskull"},
+				{ID: "3", Name: "jollyskull"},
+				{ID: "4", Name: "party"},
+			},
+			want: "Skull emojis in the last 30 day(s):\n" +
+				"- 💀: used 2 time(s), 1 replaced\n" +
+				"- <:deadskull:1>: used 1 time(s), 1 replaced\n" +
+				"- ☠️: used 1 time(s), 0 replaced\n" +
+				"\n" +
+				"Custom skull emojis nobody used: <:oldskull:2>. Deleting them from the server leaves fewer skulls to replace.",
+		},
+		{
+			name:   "only unused",
+			emojis: []*discordgo.Emoji{{ID: "2", Name: "oldskull"}},
+			want: "Nobody used a skull emoji in the last 30 day(s).\n" +
+				"\n" +
+				"Custom skull emojis nobody used: <:oldskull:2>. Deleting them from the server leaves fewer skulls to replace.",
+		},
+		{
+			name: "nothing",
+			want: "Nobody used a skull emoji in the last 30 day(s).",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(newTestConfig([]string{"alice"}, "jollyskull:123"))
+			b.stats.RecordSkullUses(now, tt.uses...)
+			if len(tt.uses) > 0 {
+				b.stats.RecordReplacement(now, "alice", "💀")
+				b.stats.RecordReplacement(now, "alice", "<:deadskull:1>")
+			}
+			mock := &mockSession{emojis: tt.emojis}
+
+			b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"emojis"}))
+
+			if got := lastResponse(t, mock); got != tt.want {
+				t.Errorf("reply = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
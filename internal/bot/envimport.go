@@ -0,0 +1,27 @@
+package bot
+
+// importEnvironment copies the target users and dry-run setting from the
+// environment into the guild's stored settings on the first start with a
+// store file. From then on the stored values are authoritative, and the
+// environment only bootstraps new deployments.
+func (b *Bot) importEnvironment() {
+	if b.config.StorePath == "" {
+		return // An in-memory store would import the same values on every start
+	}
+	imported, err := b.settings.Import(b.config.GuildID, b.config.TargetUserIDs, b.config.DryRun)
+	if err != nil {
+		logger.Error("failed to import settings from the environment", "guild_id", b.config.GuildID, "error", err)
+		return
+	}
+	if imported {
+		logger.Info("imported settings from the environment into the store, which now takes precedence",
+			"guild_id", b.config.GuildID, "targets", len(b.config.TargetUserIDs), "dry_run", b.config.DryRun)
+	}
+}
+
+// targetUserIDs returns the target users: the stored ones once they are
+// seeded, else those of DISCORD_TARGET_USER_IDS and TARGET_GROUPS and those
+// added with /jolly targets.
+func (b *Bot) targetUserIDs() []string {
+	return b.guildSettings().TargetsWith(b.config.TargetUserIDs)
+}
@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"testing"
+
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/store"
+)
+
+func TestBot_ImportEnvironment(t *testing.T) {
+	envConfig := func(storePath string, dryRun bool, targets ...string) *config.Config {
+		cfg := &config.Config{GuildID: "guild123", StorePath: storePath, DryRun: dryRun, TargetUserIDs: targets, TargetUserIDSet: map[string]struct{}{}}
+		for _, id := range targets {
+			cfg.TargetUserIDSet[id] = struct{}{}
+		}
+		return cfg
+	}
+
+	tests := []struct {
+		name        string
+		storePath   string
+		wantDryRun  bool            // After a restart with dry run off
+		wantTargets map[string]bool // After a restart with user2 as the target
+	}{
+		{"store file", "/var/lib/jolly/store.json", true, map[string]bool{"user1": true, "user2": false}},
+		{"in-memory store", "", false, map[string]bool{"user1": false, "user2": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := store.NewMemory()
+			New(envConfig(tt.storePath, true, "user1"), WithStore(st))
+			b := New(envConfig(tt.storePath, false, "user2"), WithStore(st))
+
+			if got := b.isDryRun("chan1"); got != tt.wantDryRun {
+				t.Errorf("isDryRun() = %v, want %v", got, tt.wantDryRun)
+			}
+			for userID, want := range tt.wantTargets {
+				if got := b.IsTargetUser(userID); got != want {
+					t.Errorf("IsTargetUser(%s) = %v, want %v", userID, got, want)
+				}
+			}
+		})
+	}
+}
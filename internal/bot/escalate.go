@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/flags"
+	"jolly-okurb/internal/metrics"
+)
+
+// escalate takes the ESCALATION actions whose threshold userID reached with
+// its count-th action of the day, if the escalation flag is on. Each step
+// fires once a day, on the action that reaches it, and failures are logged so
+// they never undo the action.
+func (b *Bot) escalate(ctx context.Context, s Session, userID string, count int) {
+	if !b.flagEnabled(flags.Escalation) {
+		return
+	}
+	for _, step := range b.config.Escalation {
+		if step.Threshold != count {
+			continue
+		}
+		var err error
+		switch step.Action {
+		case config.EscalateDM:
+			err = b.warnUser(ctx, s, userID, count)
+		case config.EscalateNotify:
+			b.raiseAlert(s, b.printer(b.config.GuildID).T("escalation.notify", userID, count))
+		}
+		if actions.Skipped(err) {
+			return
+		}
+		if err != nil {
+			rulesLog.ErrorContext(ctx, "failed to escalate", "user_id", userID, "count", count, "action", step.Action, "error", err)
+			continue
+		}
+		rulesLog.InfoContext(ctx, "escalated", "user_id", userID, "count", count, "action", step.Action)
+		metrics.Incr(b.sink(), metrics.Escalations, "action:"+step.Action)
+	}
+}
+
+// warnUser sends userID a DM about how often its skulls were acted on today.
+func (b *Bot) warnUser(ctx context.Context, s Session, userID string, count int) error {
+	return b.executor.Do(ctx, actions.Action{
+		Kind:   actions.SendDM,
+		UserID: userID,
+		Do: func(...discordgo.RequestOption) error {
+			ch, err := s.UserChannelCreate(userID)
+			if err != nil {
+				return err
+			}
+			_, err = s.ChannelMessageSendComplex(ch.ID, &discordgo.MessageSend{
+				Content: b.printer(b.config.GuildID).T("escalation.dm", count),
+			})
+			return err
+		},
+	})
+}
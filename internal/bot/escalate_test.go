@@ -0,0 +1,106 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/flags"
+)
+
+func TestBot_Escalate(t *testing.T) {
+	steps := []config.EscalationStep{{Threshold: 2, Action: config.EscalateDM}, {Threshold: 3, Action: config.EscalateNotify}}
+
+	tests := []struct {
+		name         string
+		steps        []config.EscalationStep
+		flagOff      bool
+		adminChannel string
+		actions      int
+		wantDMs      []string
+		wantNotified bool
+		wantAlerts   int
+	}{
+		{"no escalation configured", nil, false, "admin", 5, nil, false, 0},
+		{"below every threshold", steps, false, "admin", 1, nil, false, 0},
+		{"dm at its threshold", steps, false, "admin", 2, []string{"target-user"}, false, 0},
+		{"each step fires once a day", steps, false, "admin", 5, []string{"target-user"}, true, 1},
+		{"notify without admin channel is only queued", steps, false, "", 3, []string{"target-user"}, false, 1},
+		{"flag off", steps, true, "admin", 5, nil, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+			cfg.Escalation = tt.steps
+			cfg.AdminChannelID = tt.adminChannel
+			if tt.flagOff {
+				cfg.FeatureFlags = map[flags.Flag]bool{flags.Escalation: false}
+			}
+			sink := newRecordingSink()
+			b := New(cfg, WithMetrics(sink))
+			mock := &mockSession{}
+
+			for n := range tt.actions {
+				if !b.ReplaceReaction(context.Background(), mock, "chan1", fmt.Sprintf("%d", 100+n), "target-user", &discordgo.Emoji{Name: "💀"}) {
+					t.Fatalf("ReplaceReaction() #%d did not replace", n+1)
+				}
+			}
+
+			if !slices.Equal(mock.dmChannels, tt.wantDMs) {
+				t.Errorf("DMs opened with %v, want %v", mock.dmChannels, tt.wantDMs)
+			}
+			var dms, notified int
+			for _, m := range mock.sent {
+				switch m.channelID {
+				case "dm-target-user":
+					dms++
+					if !strings.Contains(m.content, "2 times") {
+						t.Errorf("DM = %q, want the day's count", m.content)
+					}
+				case "admin":
+					notified++
+					if !strings.Contains(m.content, "<@target-user>") {
+						t.Errorf("notification = %q, want it to name the user", m.content)
+					}
+				}
+			}
+			if dms != len(tt.wantDMs) {
+				t.Errorf("sent %d DMs, want %d", dms, len(tt.wantDMs))
+			}
+			if got := notified == 1; got != tt.wantNotified || notified > 1 {
+				t.Errorf("sent %d notifications, want notified = %v", notified, tt.wantNotified)
+			}
+			var q alertQueue
+			if _, err := b.store.Get(alertsKey, &q); err != nil {
+				t.Fatal(err)
+			}
+			if len(q.pending()) != tt.wantAlerts {
+				t.Errorf("queued %d alerts, want %d", len(q.pending()), tt.wantAlerts)
+			}
+			if got, want := sink.counts["users.escalations"], int64(dms+tt.wantAlerts); got != want {
+				t.Errorf("escalations metric = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestBot_Escalate_Steps(t *testing.T) {
+	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	cfg.Escalation = []config.EscalationStep{{Threshold: 1, Action: config.EscalateDM}}
+	cfg.TargetGroups = []config.TargetGroup{{Name: "g", Users: []string{"target-user"}, Steps: []string{config.StepRemove, config.StepAdd}}}
+	b := New(cfg)
+	mock := &mockSession{}
+
+	if !b.ReplaceReaction(context.Background(), mock, "chan1", "100", "target-user", &discordgo.Emoji{Name: "💀"}) {
+		t.Fatal("ReplaceReaction() did not replace")
+	}
+	if len(mock.dmChannels) != 0 {
+		t.Errorf("DMs opened with %v, want none without an escalate step", mock.dmChannels)
+	}
+}
@@ -0,0 +1,161 @@
+package bot
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/i18n"
+	"jolly-okurb/internal/settings"
+)
+
+// customEmojiPattern matches custom emoji tags such as <:name:id> and <a:name:id>.
+var customEmojiPattern = regexp.MustCompile(`<a?:[\p{L}\p{N}_]+:\d+>`)
+
+// skullEmojisIn returns the emojis in content that a rule would replace if
+// used as reactions, such as skulls, in order of appearance and without
+// duplicates.
+func (b *Bot) skullEmojisIn(content string) []string {
+	return emojisIn(content, b.isSkullCustomEmoji, b.unicodeEmojis())
+}
+
+// emojisIn returns the custom emojis in content that match reports true for
+// and the emojis of unicode it contains, custom ones first, in order of
+// appearance and without duplicates. Longer Unicode emojis must come first.
+func emojisIn(content string, match func(tag string) bool, unicode []string) []string {
+	var found []string
+	add := func(e string) {
+		if !slices.Contains(found, e) {
+			found = append(found, e)
+		}
+	}
+
+	// Match custom emojis first so their names are not scanned for Unicode emojis
+	for _, tag := range customEmojiPattern.FindAllString(content, -1) {
+		if match(tag) {
+			add(tag)
+		}
+	}
+	content = customEmojiPattern.ReplaceAllString(content, "")
+	for _, emoji := range unicode {
+		if strings.Contains(content, emoji) {
+			add(emoji)
+			content = strings.ReplaceAll(content, emoji, "")
+		}
+	}
+	return found
+}
+
+// emojiEvaluation is what the rules would do with an emoji of a message a
+// target user reacted with.
+type emojiEvaluation struct {
+	Emoji  string            // As written in the message
+	Rule   *config.EmojiRule // The rule that replaces it; nil if none acts
+	Shadow *config.EmojiRule // The shadow rule that would replace it if it acted
+	Steps  []string          // What Rule has the bot do, replacements included
+}
+
+// evaluateEmojis returns what the rules would do with each emoji in content
+// that any rule matches, shadow rules included, were userID to react with it.
+func (b *Bot) evaluateEmojis(content, userID string) []emojiEvaluation {
+	var unicode []string
+	for _, r := range b.allRules() {
+		unicode = append(unicode, r.Unicode...)
+	}
+	slices.SortStableFunc(unicode, func(a, b string) int { return cmp.Compare(len(b), len(a)) })
+	matches := func(tag string) bool {
+		name := emojiName(tag)
+		_, ok := b.ruleFor(name)
+		_, shadow := b.shadowRuleFor(name)
+		return ok || shadow
+	}
+
+	var evals []emojiEvaluation
+	for _, e := range emojisIn(content, matches, unicode) {
+		eval := emojiEvaluation{Emoji: e}
+		emoji := &discordgo.Emoji{Name: emojiName(e)}
+		if parts := strings.Split(strings.Trim(e, "<>"), ":"); len(parts) == 3 {
+			emoji.ID = parts[2]
+		}
+		if rule, ok := b.shadowRuleFor(emoji.Name); ok {
+			eval.Shadow = &rule
+		}
+		if rule, ok := b.ruleFor(emoji.Name); ok {
+			eval.Rule = &rule
+			replacements := b.replacementsFor(userID, emoji)
+			if len(replacements) == 0 {
+				replacements = []string{b.jollyEmoji()}
+			}
+			for _, step := range b.config.StepsFor(userID) {
+				if step == config.StepAdd {
+					step += " " + strings.Join(replacements, " ")
+				}
+				eval.Steps = append(eval.Steps, step)
+			}
+		}
+		evals = append(evals, eval)
+	}
+	return evals
+}
+
+// evaluate describes what the bot would do with content from userID, a
+// target user, in a monitored channel, without doing it. Without a user, the
+// steps are those of a target user in no group.
+func (b *Bot) evaluate(content, userID string) string {
+	g := b.guildSettings()
+	p := i18n.For(g.Locale)
+	var sb strings.Builder
+
+	if b.IsSkullOnlyMessage(content) {
+		sb.WriteString(p.T("test.delete.match"))
+		if !g.Enabled(settings.FeatureDeletion) {
+			sb.WriteString(p.T("test.delete.disabled"))
+		}
+		sb.WriteString(".\n")
+	} else {
+		fmt.Fprintln(&sb, p.T("test.delete.none"))
+	}
+
+	if skulls := b.skullEmojisIn(content); len(skulls) > 0 {
+		sb.WriteString(p.T("test.reactions.match", strings.Join(skulls, ", ")))
+		if !g.Enabled(settings.FeatureReactions) {
+			sb.WriteString(p.T("test.reactions.disabled"))
+		}
+		sb.WriteString(".\n")
+	} else {
+		fmt.Fprintln(&sb, p.T("test.reactions.none"))
+	}
+	rules := b.allRules()
+	describe := func(r *config.EmojiRule) (string, int, int) {
+		i := slices.IndexFunc(rules, func(x config.EmojiRule) bool { return x.Name == r.Name })
+		return r.Name, i + 1, r.Priority
+	}
+	for _, eval := range b.evaluateEmojis(content, userID) {
+		if eval.Rule != nil {
+			name, n, priority := describe(eval.Rule)
+			fmt.Fprintln(&sb, p.T("test.rule", eval.Emoji, name, n, priority, strings.Join(eval.Steps, " → ")))
+		}
+		if eval.Shadow != nil {
+			name, n, priority := describe(eval.Shadow)
+			fmt.Fprintln(&sb, p.T("test.rule.shadow", eval.Emoji, name, n, priority))
+		}
+	}
+
+	if g.DryRunFor("", b.config.DryRun) {
+		fmt.Fprintln(&sb, p.T("test.dryrun"))
+	}
+	return sb.String()
+}
+
+func (b *Bot) handleTest(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	var userID string
+	if o, ok := opts["user"]; ok {
+		userID = o.UserValue(nil).ID
+	}
+	return textReply(b.evaluate(opts["text"].StringValue(), userID)), nil
+}
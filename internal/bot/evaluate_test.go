@@ -0,0 +1,133 @@
+package bot
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/settings"
+)
+
+func TestBot_SkullEmojisIn(t *testing.T) {
+	b := New(&config.Config{})
+
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{"no emojis", "hello", nil},
+		{"unicode skull", "lol 💀", []string{"💀"}},
+		{"duplicates", "💀💀 💀", []string{"💀"}},
+		{"crossbones with variant selector", "☠️", []string{"☠️"}},
+		{"custom skull", "<:bigskull:123>", []string{"<:bigskull:123>"}},
+		{"animated custom skull", "<a:skull_spin:456>", []string{"<a:skull_spin:456>"}},
+		{"custom skull named in another script", "<:ЧЕРЕП_skull:125> <:череп:126>", []string{"<:ЧЕРЕП_skull:125>"}},
+		{"jollyskull is ignored", "<:jollyskull:789>", nil},
+		{"other custom emoji", "<:smile:1>", nil},
+		{"mixed", "<:bigskull:123> 💀 <:smile:1>", []string{"<:bigskull:123>", "💀"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.skullEmojisIn(tt.content); !slices.Equal(got, tt.expected) {
+				t.Errorf("skullEmojisIn(%q) = %q, want %q", tt.content, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBot_HandleInteraction_Test(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		dryRun  bool
+		setup   func(*Bot)
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "skull-only message",
+			text: "💀 <:bigskull:123>",
+			want: []string{"Deletion: matches", "Reactions: <:bigskull:123>, 💀 would be replaced"},
+		},
+		{
+			name:    "ordinary message",
+			text:    "good morning",
+			want:    []string{"Deletion: no match", "Reactions: no skull emojis found"},
+			notWant: []string{"Dry run"},
+		},
+		{
+			name: "skull with text",
+			text: "that's hilarious 💀",
+			want: []string{"Deletion: no match", "💀 would be replaced"},
+		},
+		{
+			name:   "disabled features and dry run are mentioned",
+			text:   "💀",
+			dryRun: true,
+			setup:  func(b *Bot) { b.settings.SetFeature("guild123", settings.FeatureDeletion, false) },
+			want:   []string{"deletion is disabled", "Dry run is on"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{GuildID: "guild123", DryRun: tt.dryRun}
+			b := New(cfg)
+			if tt.setup != nil {
+				tt.setup(b)
+			}
+			mock := &mockSession{}
+
+			b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"test"}, stringOption("text", tt.text)))
+
+			got := lastResponse(t, mock)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("response %q should contain %q", got, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("response %q should not contain %q", got, notWant)
+				}
+			}
+			if len(mock.removedReactions) != 0 || len(mock.addedReactions) != 0 {
+				t.Error("testing a message should not act")
+			}
+		})
+	}
+}
+
+func TestBot_HandleInteraction_TestRules(t *testing.T) {
+	cfg := newTestConfig([]string{"target-user", "grouped-user"}, "jollyskull:123")
+	cfg.EmojiRules = []config.EmojiRule{
+		{Name: "sob", Unicode: []string{"😭"}, Keywords: config.SkullKeywords{Match: []string{"sob"}}, Replacement: "jollysob:456", Priority: 1},
+		{Name: "weep", Unicode: []string{"😭"}, Replacement: "jollyweep:789", Priority: 2, Shadow: true},
+	}
+	cfg.TargetGroups = []config.TargetGroup{{Name: "mods", Users: []string{"grouped-user"}, Steps: []string{config.StepRemove, config.StepLog, config.StepDM}}}
+	b := New(cfg)
+	text := stringOption("text", "😭 💀 <:bigsob:9>")
+
+	mock := &mockSession{}
+	b.HandleInteraction(mock, newCommandInteraction(cfg.GuildID, []string{"test"}, text))
+	got := lastResponse(t, mock)
+	for _, want := range []string{
+		"- 😭: rule sob (#2 in order, priority 1): remove → add jollysob:456 → escalate",
+		"- 😭: shadow rule weep (#1 in order, priority 2) would replace it too",
+		"- 💀: rule skull (#3 in order, priority 0): remove → add jollyskull:123 → escalate",
+		"- <:bigsob:9>: rule sob (#2 in order, priority 1)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("response %q should contain %q", got, want)
+		}
+	}
+
+	mock = &mockSession{}
+	b.HandleInteraction(mock, newCommandInteraction(cfg.GuildID, []string{"test"}, text, userOption("user", "grouped-user")))
+	if got, want := lastResponse(t, mock), "- 💀: rule skull (#3 in order, priority 0): remove → log → dm\n"; !strings.Contains(got, want) {
+		t.Errorf("response %q should contain the group's steps %q", got, want)
+	}
+}
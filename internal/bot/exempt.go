@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// messageRef identifies a message by a link or a bare ID.
+type messageRef struct {
+	GuildID   string // Empty for a bare ID
+	ChannelID string // Empty for a bare ID
+	MessageID string
+}
+
+// parseMessageRef accepts a message link as copied from the Discord client,
+// such as https://discord.com/channels/<guild>/<channel>/<message>, or a bare message ID.
+func parseMessageRef(ref string) (messageRef, error) {
+	ref = strings.TrimSpace(ref)
+	if isSnowflake(ref) {
+		return messageRef{MessageID: ref}, nil
+	}
+
+	u, err := url.Parse(ref)
+	if err == nil && u.Scheme == "https" && isDiscordHost(u.Hostname()) {
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) == 4 && parts[0] == "channels" && isSnowflake(parts[1]) && isSnowflake(parts[2]) && isSnowflake(parts[3]) {
+			return messageRef{GuildID: parts[1], ChannelID: parts[2], MessageID: parts[3]}, nil
+		}
+	}
+	return messageRef{}, fmt.Errorf("expected a message link or ID, got %q", ref)
+}
+
+// isDiscordHost reports whether host serves Discord message links, including
+// the PTB and Canary clients and the legacy discordapp.com domain.
+func isDiscordHost(host string) bool {
+	switch host {
+	case "discord.com", "ptb.discord.com", "canary.discord.com", "discordapp.com":
+		return true
+	}
+	return false
+}
+
+func isSnowflake(s string) bool {
+	if s == "" || len(s) > 20 {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *Bot) handleExempt(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	return b.setExempt(i, opts, true)
+}
+
+func (b *Bot) handleUnexempt(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	return b.setExempt(i, opts, false)
+}
+
+func (b *Bot) setExempt(i *discordgo.InteractionCreate, opts commandOptions, exempt bool) (*discordgo.InteractionResponseData, error) {
+	ref, err := parseMessageRef(opts["message"].StringValue())
+	if err != nil {
+		return nil, err
+	}
+	if ref.GuildID != "" && ref.GuildID != i.GuildID {
+		return nil, fmt.Errorf("that message is in another server")
+	}
+
+	if err := b.settings.SetExempt(i.GuildID, ref.MessageID, exempt); err != nil {
+		return nil, err
+	}
+	logger.Info("message exemption changed", "guild_id", i.GuildID, "message_id", ref.MessageID, "exempt", exempt, "by", interactionUserID(i))
+
+	p := b.printer(i.GuildID)
+	if exempt {
+		return textReply(p.T("exempt.added", ref.MessageID)), nil
+	}
+	return textReply(p.T("exempt.removed", ref.MessageID)), nil
+}
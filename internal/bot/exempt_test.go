@@ -0,0 +1,127 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/config"
+)
+
+func TestParseMessageRef(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    messageRef
+		wantErr bool
+	}{
+		{"bare ID", "1325376000000000000", messageRef{MessageID: "1325376000000000000"}, false},
+		{"link", "https://discord.com/channels/111/222/333", messageRef{"111", "222", "333"}, false},
+		{"ptb link", "https://ptb.discord.com/channels/111/222/333", messageRef{"111", "222", "333"}, false},
+		{"legacy domain", "https://discordapp.com/channels/111/222/333", messageRef{"111", "222", "333"}, false},
+		{"surrounding whitespace", "  333 ", messageRef{MessageID: "333"}, false},
+		{"channel link", "https://discord.com/channels/111/222", messageRef{}, true},
+		{"other host", "https://example.com/channels/111/222/333", messageRef{}, true},
+		{"plain http", "http://discord.com/channels/111/222/333", messageRef{}, true},
+		{"not an ID", "hello", messageRef{}, true},
+		{"empty", "", messageRef{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMessageRef(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMessageRef(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseMessageRef(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBot_HandleInteraction_Exempt(t *testing.T) {
+	newBot := func() *Bot {
+		cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+		cfg.GuildID = "111"
+		b := New(cfg)
+		b.channels = map[string]string{"222": "jollyposting"}
+		b.ready = true
+		return b
+	}
+	reaction := &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+		ChannelID: "222", MessageID: "333", UserID: "target-user", Emoji: discordgo.Emoji{Name: "💀"},
+	}}
+
+	t.Run("exempt message is left alone", func(t *testing.T) {
+		b := newBot()
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newCommandInteraction("111", []string{"exempt"},
+			stringOption("message", "https://discord.com/channels/111/222/333")))
+
+		if got := lastResponse(t, mock); !strings.Contains(got, "now exempt") {
+			t.Errorf("reply %q should confirm the exemption", got)
+		}
+		if b.ShouldProcessReaction(reaction) {
+			t.Error("reactions on an exempt message should not be processed")
+		}
+		deletion := &discordgo.MessageCreate{Message: &discordgo.Message{
+			ID: "333", ChannelID: "222", Content: "💀", Author: &discordgo.User{ID: "target-user"},
+		}}
+		if b.ShouldDeleteMessage(deletion) {
+			t.Error("an exempt message should not be deleted")
+		}
+	})
+
+	t.Run("unexempt restores processing", func(t *testing.T) {
+		b := newBot()
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newCommandInteraction("111", []string{"exempt"}, stringOption("message", "333")))
+		b.HandleInteraction(mock, newCommandInteraction("111", []string{"unexempt"}, stringOption("message", "333")))
+
+		if got := lastResponse(t, mock); !strings.Contains(got, "no longer exempt") {
+			t.Errorf("reply %q should confirm the exemption was removed", got)
+		}
+		if !b.ShouldProcessReaction(reaction) {
+			t.Error("reactions should be processed again after unexempting")
+		}
+	})
+
+	t.Run("rejects messages from other servers", func(t *testing.T) {
+		b := newBot()
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newCommandInteraction("111", []string{"exempt"},
+			stringOption("message", "https://discord.com/channels/999/222/333")))
+
+		if got := lastResponse(t, mock); !strings.HasPrefix(got, "Error:") {
+			t.Errorf("reply %q should be an error", got)
+		}
+		if !b.ShouldProcessReaction(reaction) {
+			t.Error("a rejected exemption should not be stored")
+		}
+	})
+
+	t.Run("historical scan skips exempt messages", func(t *testing.T) {
+		delay := historicalPageDelay
+		historicalPageDelay = 0
+		t.Cleanup(func() { historicalPageDelay = delay })
+
+		b := New(&config.Config{GuildID: "111", TargetUserIDSet: map[string]struct{}{"target-user": {}}})
+		b.channels = map[string]string{"222": "jollyposting"}
+		h := skullHistory(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC), 3, time.Hour)
+		b.settings.SetExempt("111", h.history[1].ID, true)
+
+		b.ProcessHistoricalMessages(context.Background(), h)
+
+		got := replacedIDs(h)
+		if len(got) != 2 || got[h.history[1].ID] != 0 {
+			t.Errorf("expected the two other messages to be processed, got %v", got)
+		}
+	})
+}
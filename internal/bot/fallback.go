@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/metrics"
+)
+
+// jollyEmoji returns the emoji the bot reacts with: jollyskull, or
+// JOLLYSKULL_FALLBACK while jollyskull is unusable.
+func (b *Bot) jollyEmoji() string {
+	if b.jollyFallback.Load() {
+		return b.config.JollySkullFallback
+	}
+	return b.config.JollySkullID
+}
+
+// isUnknownEmoji reports whether err is Discord's Unknown Emoji error,
+// returned when reacting with an emoji that was deleted or became
+// unavailable, such as after the server lost the boost level it needs.
+func isUnknownEmoji(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownEmoji
+}
+
+// Why jollyskull is unusable, as message keys.
+const (
+	fallbackUnknown     = "fallback.unknown"
+	fallbackDeleted     = "fallback.deleted"
+	fallbackUnavailable = "fallback.unavailable"
+)
+
+// fallBack switches the bot's reactions to JOLLYSKULL_FALLBACK and alerts
+// the moderators, so enforcement does not silently stop. why is one of the
+// fallback message keys. It reports whether it switched, which it does not
+// if the fallback was already in use.
+func (b *Bot) fallBack(ctx context.Context, s Session, why string) bool {
+	if !b.jollyFallback.CompareAndSwap(false, true) {
+		return false
+	}
+	logger.WarnContext(ctx, "jollyskull is unusable, reacting with the fallback", "emoji", b.config.JollySkullID, "fallback", b.config.JollySkullFallback, "reason", why)
+	metrics.Incr(b.sink(), metrics.ReactionFallbacks)
+	p := b.printer(b.config.GuildID)
+	b.raiseAlert(s, p.T("fallback.alert", p.T(why), emojiTag(b.config.JollySkullFallback)))
+	return true
+}
+
+// checkJollyEmoji falls back when emojis, the server's emojis, lack a usable
+// jollyskull, and returns to jollyskull once it is usable again.
+func (b *Bot) checkJollyEmoji(s Session, emojis []*discordgo.Emoji) {
+	_, id, custom := strings.Cut(b.config.JollySkullID, ":")
+	if !custom {
+		return
+	}
+	i := slices.IndexFunc(emojis, func(e *discordgo.Emoji) bool { return e.ID == id })
+	switch {
+	case i < 0:
+		b.fallBack(context.Background(), s, fallbackDeleted)
+	case !emojis[i].Available:
+		b.fallBack(context.Background(), s, fallbackUnavailable)
+	case b.jollyFallback.CompareAndSwap(true, false):
+		logger.Info("jollyskull is usable again, no longer reacting with the fallback", "emoji", b.config.JollySkullID)
+	}
+}
+
+// OnGuildEmojisUpdate refreshes the cached emojis and checks jollyskull when
+// the server's emojis change, which includes emojis becoming unavailable when
+// its boost level drops.
+func (b *Bot) OnGuildEmojisUpdate(s *discordgo.Session, e *discordgo.GuildEmojisUpdate) {
+	if e.GuildID == b.config.GuildID {
+		b.emojis.Set(e.GuildID, e.Emojis)
+		b.checkJollyEmoji(s, e.Emojis)
+	}
+}
+
+// emojiTag returns how emoji, as name:id or Unicode, is written in a message.
+func emojiTag(emoji string) string {
+	if strings.Contains(emoji, ":") {
+		return "<:" + emoji + ">"
+	}
+	return emoji
+}
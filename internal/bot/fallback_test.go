@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/metrics"
+)
+
+// unknownEmojiSession answers reactions with unknown as Discord's Unknown
+// Emoji error.
+type unknownEmojiSession struct {
+	*mockSession
+	unknown string
+}
+
+func (u *unknownEmojiSession) MessageReactionAdd(channelID, messageID, emojiID string, options ...discordgo.RequestOption) error {
+	u.mockSession.MessageReactionAdd(channelID, messageID, emojiID, options...)
+	if emojiID == u.unknown {
+		return &discordgo.RESTError{
+			Response: &http.Response{StatusCode: http.StatusBadRequest},
+			Message:  &discordgo.APIErrorMessage{Code: discordgo.ErrCodeUnknownEmoji, Message: "Unknown Emoji"},
+		}
+	}
+	return nil
+}
+
+func TestBot_AddJollySkull_Fallback(t *testing.T) {
+	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	cfg.JollySkullFallback = "🎄"
+	cfg.AdminChannelID = "admin"
+	sink := newRecordingSink()
+	b := New(cfg, WithMetrics(sink))
+	s := &unknownEmojiSession{mockSession: &mockSession{}, unknown: "jollyskull:123"}
+
+	for _, messageID := range []string{"msg1", "msg2"} {
+		if _, err := b.addJollySkull(context.Background(), s, policySkullReaction, "chan1", messageID); err != nil {
+			t.Fatalf("addJollySkull(%s) error = %v, want the fallback added", messageID, err)
+		}
+	}
+
+	var got []string
+	for _, r := range s.addedReactions {
+		got = append(got, r.messageID+" "+r.emojiID)
+	}
+	if want := "msg1 jollyskull:123,msg1 🎄,msg2 🎄"; strings.Join(got, ",") != want {
+		t.Errorf("added %v, want %s", got, want)
+	}
+	if sink.counts[metrics.ReactionFallbacks] != 1 {
+		t.Errorf("%s = %d, want 1", metrics.ReactionFallbacks, sink.counts[metrics.ReactionFallbacks])
+	}
+	if len(s.sent) != 1 || !strings.Contains(s.sent[0].content, "🎄") {
+		t.Errorf("sent %+v, want one alert naming the fallback", s.sent)
+	}
+	if !b.isJollySkull(&discordgo.Emoji{Name: "🎄"}) {
+		t.Error("isJollySkull() should recognize the fallback")
+	}
+}
+
+func TestBot_CheckJollyEmoji(t *testing.T) {
+	tests := []struct {
+		name         string
+		jollySkull   string
+		emojis       []*discordgo.Emoji
+		fallback     bool
+		wantFallback bool
+	}{
+		{"usable", "jollyskull:123", []*discordgo.Emoji{{ID: "123", Available: true}}, false, false},
+		{"deleted", "jollyskull:123", []*discordgo.Emoji{{ID: "456", Available: true}}, false, true},
+		{"unavailable", "jollyskull:123", []*discordgo.Emoji{{ID: "123"}}, false, true},
+		{"usable again", "jollyskull:123", []*discordgo.Emoji{{ID: "123", Available: true}}, true, false},
+		{"unicode jollyskull", "🎅", nil, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(newTestConfig(nil, tt.jollySkull))
+			b.jollyFallback.Store(tt.fallback)
+
+			b.checkJollyEmoji(&mockSession{}, tt.emojis)
+
+			if got := b.jollyFallback.Load(); got != tt.wantFallback {
+				t.Errorf("fallback = %v, want %v", got, tt.wantFallback)
+			}
+		})
+	}
+}
+
+func TestEmojiTag(t *testing.T) {
+	tests := []struct {
+		emoji string
+		want  string
+	}{
+		{"jollyskull:123", "<:jollyskull:123>"},
+		{"🎄", "🎄"},
+	}
+	for _, tt := range tests {
+		if got := emojiTag(tt.emoji); got != tt.want {
+			t.Errorf("emojiTag(%q) = %q, want %q", tt.emoji, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package bot
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/correlation"
+	"jolly-okurb/internal/metrics"
+)
+
+// Custom ID prefixes of the buttons on a false positive report, followed by
+// the alert ID, channel ID, and message ID, separated by colons. The exclude
+// button adds the name of the custom emoji to exclude.
+const (
+	fpUndoPrefix    = "jolly:fp:undo:"
+	fpExemptPrefix  = "jolly:fp:exempt:"
+	fpExcludePrefix = "jolly:fp:exclude:"
+)
+
+// maxFPExcludeButtons caps the exclude buttons on a report, leaving room in
+// its row for the others.
+const maxFPExcludeButtons = 2
+
+// handleReportFP lets any member flag a message whose skulls the bot should
+// not have replaced, with /report-fp or from the message's menu. The report
+// goes to the alert queue with buttons for the moderators to undo the
+// replacement, to exempt the message, and to exclude the custom emojis
+// replaced there from the rules.
+func (b *Bot) handleReportFP(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	ref, err := parseMessageRef(opts["message"].StringValue())
+	if err != nil {
+		return nil, err
+	}
+	if ref.GuildID != "" && ref.GuildID != i.GuildID {
+		return nil, fmt.Errorf("that message is in another server")
+	}
+	channelID := cmp.Or(ref.ChannelID, i.ChannelID)
+
+	p := b.printer(i.GuildID)
+	if b.isExempt(ref.MessageID) {
+		return textReply(p.T("falsepositive.exempt", ref.MessageID)), nil
+	}
+	msg, err := s.ChannelMessage(channelID, ref.MessageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch message: %w", err)
+	}
+	if len(b.ownReplacements(msg)) == 0 {
+		return textReply(p.T("falsepositive.none", ref.MessageID)), nil
+	}
+
+	reporter := interactionUserID(i)
+	raised, ok := b.queueAlert(b.printer(b.config.GuildID).T("falsepositive.alert", reporter, messageLink(i.GuildID, channelID, msg.ID)))
+	if !ok {
+		return nil, fmt.Errorf("failed to queue the report")
+	}
+	logger.Info("false positive reported", "alert_id", raised.ID, "channel_id", channelID, "message_id", msg.ID, "by", reporter)
+	metrics.Incr(b.sink(), metrics.FalsePositiveReports)
+	b.postAlert(s, raised, b.falsePositiveButtons(raised.ID, channelID, msg.ID, true)...)
+	return textReply(p.T("falsepositive.sent")), nil
+}
+
+// falsePositiveButtons returns the buttons of the report alertID on a
+// message: undo, exempt unless the message is already exempt, and exclude for
+// each custom emoji replaced there that is not excluded yet.
+func (b *Bot) falsePositiveButtons(alertID int, channelID, messageID string, undo bool) []discordgo.MessageComponent {
+	p := b.printer(b.config.GuildID)
+	args := strconv.Itoa(alertID) + ":" + channelID + ":" + messageID
+	var buttons []discordgo.MessageComponent
+	if undo {
+		buttons = append(buttons, discordgo.Button{Label: p.T("falsepositive.button.undo"), Style: discordgo.DangerButton, CustomID: fpUndoPrefix + args})
+	}
+	if !b.isExempt(messageID) {
+		buttons = append(buttons, discordgo.Button{Label: p.T("falsepositive.button.exempt"), Style: discordgo.SecondaryButton, CustomID: fpExemptPrefix + args})
+	}
+	excluding := 0
+	for _, tag := range b.replaced.get(messageID) {
+		parts := strings.Split(tag, ":")
+		if len(parts) != 3 || b.guildSettings().IsExcluded(parts[1]) || excluding == maxFPExcludeButtons {
+			continue
+		}
+		buttons = append(buttons, discordgo.Button{Label: p.T("exclusions.button.add", parts[1]), Style: discordgo.SecondaryButton, CustomID: fpExcludePrefix + args + ":" + parts[1]})
+		excluding++
+	}
+	return buttons
+}
+
+// ownReplacements returns the replacements the bot reacted to msg with, as
+// API strings.
+func (b *Bot) ownReplacements(msg *discordgo.Message) []string {
+	var emojis []string
+	for _, reaction := range msg.Reactions {
+		if reaction.Me && b.isJollySkull(reaction.Emoji) {
+			emojis = append(emojis, GetEmojiAPIString(reaction.Emoji))
+		}
+	}
+	return emojis
+}
+
+// handleFalsePositiveButton undoes the replacement on a reported message,
+// exempts it, or excludes a custom emoji replaced there, acknowledging the
+// report. The other buttons stay on the post, so a moderator can do several.
+func (b *Bot) handleFalsePositiveButton(s Session, i *discordgo.InteractionCreate, customID string) {
+	p := b.printer(i.GuildID)
+	if i.Member == nil || i.Member.Permissions&panelPermissions == 0 {
+		b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, textReply(p.T("alerts.denied")))
+		return
+	}
+
+	undo := strings.HasPrefix(customID, fpUndoPrefix)
+	exclude := strings.HasPrefix(customID, fpExcludePrefix)
+	args := customID
+	for _, prefix := range []string{fpUndoPrefix, fpExemptPrefix, fpExcludePrefix} {
+		args = strings.TrimPrefix(args, prefix)
+	}
+	parts := strings.Split(args, ":")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil || len(parts) != 3 && !(exclude && len(parts) == 4) {
+		logger.Warn("invalid false positive button", "custom_id", customID)
+		return
+	}
+	channelID, messageID, by := parts[1], parts[2], interactionUserID(i)
+
+	var done string
+	switch {
+	case exclude:
+		name := parts[3]
+		if err := b.settings.SetExcluded(i.GuildID, name, true); err != nil {
+			logger.Error("failed to exclude emoji", "emoji", name, "error", err)
+			b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, textReply(p.T("command.error", err)))
+			return
+		}
+		logger.Info("emoji exclusion changed", "emoji", name, "excluded", true, "by", by)
+		done = p.T("exclusions.added", name, by)
+	case undo:
+		n, err := b.undoReplacement(s, channelID, messageID, by)
+		switch {
+		case actions.Skipped(err):
+			b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, textReply(p.T("falsepositive.skipped", err)))
+			return
+		case err != nil:
+			logger.Error("failed to undo replacement", "channel_id", channelID, "message_id", messageID, "error", err)
+			b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, textReply(p.T("command.error", err)))
+			return
+		}
+		done = p.T("falsepositive.undone", by, n)
+		b.learnFromUndo(s, messageID)
+	default:
+		if err := b.settings.SetExempt(i.GuildID, messageID, true); err != nil {
+			logger.Error("failed to exempt reported message", "message_id", messageID, "error", err)
+			b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, textReply(p.T("command.error", err)))
+			return
+		}
+		logger.Info("message exemption changed", "guild_id", i.GuildID, "message_id", messageID, "exempt", true, "by", by)
+		done = p.T("falsepositive.exempted", by)
+	}
+
+	acked, _, _, err := b.acknowledgeAlert(id, by)
+	if err != nil {
+		// What the button does is done; the report just stays pending
+		logger.Warn("failed to acknowledge false positive report", "alert_id", id, "error", err)
+	}
+	content := acked.Text
+	if i.Message != nil {
+		content = i.Message.Content
+	}
+	reply := textReply(strings.TrimSpace(content + "\n" + done))
+	reply.AllowedMentions = &discordgo.MessageAllowedMentions{}
+	if buttons := b.falsePositiveButtons(id, channelID, messageID, !undo); len(buttons) > 0 {
+		reply.Components = []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+	}
+	b.respond(s, i, discordgo.InteractionResponseUpdateMessage, reply)
+}
+
+// undoReplacement removes the bot's replacements from a message, as approved
+// by userID, and returns how many it removed. A skull it took away cannot be
+// put back, as only its author can react with it.
+func (b *Bot) undoReplacement(s Session, channelID, messageID, userID string) (int, error) {
+	msg, err := s.ChannelMessage(channelID, messageID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch message: %w", err)
+	}
+	ctx := correlation.Start(context.Background())
+	removed := 0
+	for _, emoji := range b.ownReplacements(msg) {
+		err := b.executor.Do(ctx, actions.Action{
+			Kind:      actions.RemoveReaction,
+			ChannelID: channelID,
+			MessageID: messageID,
+			Do: func(...discordgo.RequestOption) error {
+				return s.MessageReactionRemove(channelID, messageID, emoji, "@me")
+			},
+		})
+		if err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	b.forgetReacted(messageID)
+	logger.InfoContext(ctx, "replacement undone", "channel_id", channelID, "message_id", messageID, "removed", removed, "by", userID)
+	return removed, nil
+}
@@ -0,0 +1,195 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/store"
+)
+
+func TestBot_HandleInteraction_ReportFP(t *testing.T) {
+	const link = "https://discord.com/channels/100/200/300"
+	newBot := func() *Bot {
+		cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+		cfg.GuildID = "100"
+		cfg.AdminChannelID = "admin"
+		return New(cfg, WithStore(store.NewMemory()))
+	}
+	replaced := func() *mockSession {
+		return &mockSession{messages: []*discordgo.Message{{
+			ID:        "300",
+			ChannelID: "200",
+			Reactions: []*discordgo.MessageReactions{
+				{Emoji: &discordgo.Emoji{Name: "jollyskull", ID: "123"}, Me: true},
+				{Emoji: &discordgo.Emoji{Name: "🎉"}, Me: true},
+			},
+		}}}
+	}
+	// report runs /report-fp as a member without any permissions.
+	report := func(b *Bot, mock *mockSession, ref string) string {
+		i := newCommandInteraction("100", nil, stringOption("message", ref))
+		i.Data = discordgo.ApplicationCommandInteractionData{Name: reportFPCommand, Options: i.ApplicationCommandData().Options}
+		i.Member = &discordgo.Member{User: &discordgo.User{ID: "member1"}}
+		b.HandleInteraction(mock, i)
+		return lastResponse(t, mock)
+	}
+	// press presses the report button with prefix on the alert post.
+	press := func(t *testing.T, b *Bot, mock *mockSession, prefix string) {
+		t.Helper()
+		post := mock.sent[len(mock.sent)-1]
+		for _, c := range post.components[0].(discordgo.ActionsRow).Components {
+			if id := c.(discordgo.Button).CustomID; strings.HasPrefix(id, prefix) {
+				i := newComponentInteraction("100", id)
+				i.Member.Permissions = discordgo.PermissionManageGuild
+				i.Message = &discordgo.Message{Content: post.content}
+				b.HandleInteraction(mock, i)
+				return
+			}
+		}
+		t.Fatalf("alert %+v has no %s button", post, prefix)
+	}
+
+	t.Run("raises an alert", func(t *testing.T) {
+		b := newBot()
+		mock := replaced()
+
+		if got := report(b, mock, link); !strings.Contains(got, "moderators") {
+			t.Errorf("reply %q should confirm the report", got)
+		}
+		if len(mock.sent) != 1 || mock.sent[0].channelID != "admin" || !strings.Contains(mock.sent[0].content, link) {
+			t.Fatalf("sent %+v, want an alert linking the message in the admin channel", mock.sent)
+		}
+		if got := len(mock.sent[0].components[0].(discordgo.ActionsRow).Components); got != 3 {
+			t.Errorf("alert has %d buttons, want acknowledge, undo, and exempt", got)
+		}
+	})
+
+	t.Run("from the message menu", func(t *testing.T) {
+		b := newBot()
+		mock := replaced()
+		i := newCommandInteraction("100", nil)
+		i.ChannelID = "200"
+		i.Data = discordgo.ApplicationCommandInteractionData{Name: reportFPMenu, CommandType: discordgo.MessageApplicationCommand, TargetID: "300"}
+		i.Member = &discordgo.Member{User: &discordgo.User{ID: "member1"}}
+
+		b.HandleInteraction(mock, i)
+
+		if got := lastResponse(t, mock); !strings.Contains(got, "moderators") {
+			t.Errorf("reply %q should confirm the report", got)
+		}
+		if len(mock.sent) != 1 || !strings.Contains(mock.sent[0].content, link) || !strings.Contains(mock.sent[0].content, "member1") {
+			t.Errorf("sent %+v, want an alert naming the reporter and linking the message", mock.sent)
+		}
+	})
+
+	t.Run("exclude stops replacing the emoji", func(t *testing.T) {
+		b := newBot()
+		mock := replaced()
+		b.replaced.add("300", "<:skull_custom:999>")
+		b.replaced.add("300", "💀")
+		report(b, mock, link)
+
+		press(t, b, mock, fpExcludePrefix)
+
+		if !b.guildSettings().IsExcluded("skull_custom") {
+			t.Error("the reported emoji should be excluded")
+		}
+		if len(mock.removedReactions) != 0 {
+			t.Error("excluding should not undo the replacement")
+		}
+		resp := mock.responses[len(mock.responses)-1]
+		if !strings.Contains(resp.Data.Content, "no longer replaced") {
+			t.Errorf("update %q should say the emoji was excluded", resp.Data.Content)
+		}
+		for _, c := range resp.Data.Components[0].(discordgo.ActionsRow).Components {
+			if strings.HasPrefix(c.(discordgo.Button).CustomID, fpExcludePrefix) {
+				t.Errorf("update should drop the exclude button, got %+v", c)
+			}
+		}
+	})
+
+	t.Run("undo removes the replacements", func(t *testing.T) {
+		b := newBot()
+		mock := replaced()
+		b.reacted.add("300")
+		report(b, mock, link)
+
+		press(t, b, mock, fpUndoPrefix)
+
+		if len(mock.removedReactions) != 1 || mock.removedReactions[0].emojiID != "jollyskull:123" || mock.removedReactions[0].userID != "@me" {
+			t.Errorf("removed %+v, want only the bot's jollyskull", mock.removedReactions)
+		}
+		if b.reacted.contains("300") {
+			t.Error("the bot should forget having reacted to the message")
+		}
+		resp := mock.responses[len(mock.responses)-1]
+		if !strings.Contains(resp.Data.Content, "Undone by <@admin1>") {
+			t.Errorf("update %q should say who undid it", resp.Data.Content)
+		}
+		row := resp.Data.Components[0].(discordgo.ActionsRow)
+		if len(row.Components) != 1 || !strings.HasPrefix(row.Components[0].(discordgo.Button).CustomID, fpExemptPrefix) {
+			t.Errorf("update should keep only the exempt button, got %+v", row.Components)
+		}
+		q, _ := b.updateAlerts(func(*alertQueue) error { return nil })
+		if len(q.pending()) != 0 {
+			t.Error("undoing should acknowledge the report")
+		}
+	})
+
+	t.Run("exempt adds an exemption", func(t *testing.T) {
+		b := newBot()
+		mock := replaced()
+		report(b, mock, link)
+
+		press(t, b, mock, fpExemptPrefix)
+
+		if !b.isExempt("300") {
+			t.Error("the reported message should be exempt")
+		}
+		if len(mock.removedReactions) != 0 {
+			t.Error("exempting should not undo the replacement")
+		}
+		if got := report(b, mock, link); !strings.Contains(got, "already exempt") {
+			t.Errorf("reply %q to reporting an exempt message should say so", got)
+		}
+	})
+
+	t.Run("buttons need Manage Server", func(t *testing.T) {
+		b := newBot()
+		mock := replaced()
+		report(b, mock, link)
+		id := mock.sent[0].components[0].(discordgo.ActionsRow).Components[1].(discordgo.Button).CustomID
+
+		b.HandleInteraction(mock, newComponentInteraction("100", id))
+
+		if got := lastResponse(t, mock); !strings.Contains(got, "Manage Server") {
+			t.Errorf("reply %q should name the missing permission", got)
+		}
+		if len(mock.removedReactions) != 0 {
+			t.Error("a member without permission should not undo anything")
+		}
+	})
+
+	t.Run("nothing replaced", func(t *testing.T) {
+		b := newBot()
+		mock := &mockSession{messages: []*discordgo.Message{{ID: "300", ChannelID: "200"}}}
+
+		if got := report(b, mock, link); !strings.Contains(got, "not replaced") {
+			t.Errorf("reply %q should say the bot did nothing there", got)
+		}
+		if len(mock.sent) != 0 {
+			t.Error("no alert should be raised")
+		}
+	})
+
+	t.Run("another server", func(t *testing.T) {
+		b := newBot()
+		mock := replaced()
+
+		if got := report(b, mock, "https://discord.com/channels/999/200/300"); !strings.Contains(got, "another server") {
+			t.Errorf("reply %q should refuse messages of other servers", got)
+		}
+	})
+}
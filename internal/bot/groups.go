@@ -0,0 +1,40 @@
+package bot
+
+import (
+	"strings"
+	"time"
+)
+
+// groupAllows reports whether action may be taken on userID's skulls in
+// channelID right now under the rules of the user's target group. Every emoji
+// must be one the group is acted on for. Users in no group are subject to
+// every action.
+func (b *Bot) groupAllows(userID, channelID, action string, emojis ...string) bool {
+	return b.groupAllowsAt(time.Now(), userID, channelID, action, emojis...)
+}
+
+// groupAllowsAt is groupAllows for skulls posted at t.
+func (b *Bot) groupAllowsAt(t time.Time, userID, channelID, action string, emojis ...string) bool {
+	g := b.config.Group(userID)
+	if g == nil {
+		return true
+	}
+	if !g.Allows(action) || !g.InChannel(channelID) || !g.ActiveAt(t) {
+		return false
+	}
+	for _, e := range emojis {
+		if !g.MatchesEmoji(emojiName(e)) {
+			return false
+		}
+	}
+	return true
+}
+
+// emojiName returns the name of a custom emoji tag such as <:skull:123>, or
+// a Unicode emoji as is.
+func emojiName(emoji string) string {
+	if parts := strings.Split(emoji, ":"); len(parts) == 3 && strings.HasPrefix(emoji, "<") {
+		return parts[1]
+	}
+	return emoji
+}
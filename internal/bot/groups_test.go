@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/config"
+)
+
+func TestBot_TargetGroups(t *testing.T) {
+	cfg := newTestConfig([]string{"plain", "casual", "strict"}, "jollyskull:123")
+	cfg.TargetGroups = []config.TargetGroup{
+		{Name: "casual", Users: []string{"casual"}, Actions: []string{config.ActionReactions}, Emojis: []string{"💀", "deadskull"}},
+		{Name: "strict", Users: []string{"strict"}},
+	}
+	b := &Bot{config: cfg, channels: map[string]string{"chan1": "jollyposting"}, ready: true}
+
+	reactions := []struct {
+		user     string
+		emoji    discordgo.Emoji
+		expected bool
+	}{
+		{"plain", discordgo.Emoji{Name: "☠️"}, true},
+		{"strict", discordgo.Emoji{Name: "☠️"}, true},
+		{"casual", discordgo.Emoji{Name: "💀"}, true},
+		{"casual", discordgo.Emoji{Name: "DeadSkull", ID: "1"}, true},
+		{"casual", discordgo.Emoji{Name: "☠️"}, false},
+	}
+	for _, tt := range reactions {
+		r := &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{ChannelID: "chan1", UserID: tt.user, Emoji: tt.emoji}}
+		if got := b.ShouldProcessReaction(r); got != tt.expected {
+			t.Errorf("ShouldProcessReaction(%s, %s) = %v, want %v", tt.user, tt.emoji.Name, got, tt.expected)
+		}
+	}
+
+	cfg.TargetGroups = append(cfg.TargetGroups, config.TargetGroup{Name: "local", Users: []string{"local"}, Channels: []string{"chan2"}})
+	cfg.TargetUserIDSet["local"] = struct{}{}
+	b.channels["chan2"] = "jollyposting-2"
+	for channelID, expected := range map[string]bool{"chan1": false, "chan2": true} {
+		r := &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{ChannelID: channelID, UserID: "local", Emoji: discordgo.Emoji{Name: "💀"}}}
+		if got := b.ShouldProcessReaction(r); got != expected {
+			t.Errorf("ShouldProcessReaction(local in %s) = %v, want %v", channelID, got, expected)
+		}
+	}
+
+	messages := []struct {
+		user     string
+		content  string
+		expected bool
+	}{
+		{"plain", "💀", true},
+		{"strict", "💀 ☠️", true},
+		{"casual", "💀", false}, // Casuals only get reactions replaced
+	}
+	for _, tt := range messages {
+		m := &discordgo.MessageCreate{Message: &discordgo.Message{ChannelID: "chan1", Content: tt.content, Author: &discordgo.User{ID: tt.user}}}
+		if got := b.ShouldDeleteMessage(m); got != tt.expected {
+			t.Errorf("ShouldDeleteMessage(%s, %q) = %v, want %v", tt.user, tt.content, got, tt.expected)
+		}
+	}
+}
+
+func TestBot_GroupAllowsDeletionEmojis(t *testing.T) {
+	cfg := newTestConfig([]string{"casual"}, "jollyskull:123")
+	cfg.TargetGroups = []config.TargetGroup{{Name: "casual", Users: []string{"casual"}, Emojis: []string{"deadskull"}}}
+	b := &Bot{config: cfg}
+
+	tests := []struct {
+		content  string
+		expected bool
+	}{
+		{"<:deadskull:1>", true},
+		{"<:deadskull:1> <a:deadskull:1>", true},
+		{"<:deadskull:1> 💀", false},
+	}
+	for _, tt := range tests {
+		if got := b.groupAllows("casual", "chan1", config.ActionDeletion, b.skullEmojisIn(tt.content)...); got != tt.expected {
+			t.Errorf("groupAllows(%q) = %v, want %v", tt.content, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/i18n"
+	"jolly-okurb/internal/stats"
+)
+
+// handleHeatmap replies with a CSV of actions by UTC weekday and hour for
+// the last days, ending today, ready to load into a spreadsheet as a heatmap.
+func (b *Bot) handleHeatmap(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	days, err := b.optionDays(opts)
+	if err != nil {
+		return nil, err
+	}
+	p := b.printer(i.GuildID)
+	h := stats.HeatmapOf(days)
+	day, hour, n := h.Peak()
+	if n == 0 {
+		return textReply(p.T("heatmap.none", len(days))), nil
+	}
+
+	csv, err := heatmapCSV(p, h)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("heatmap exported", "days", len(days), "by", interactionUserID(i))
+
+	reply := textReply(p.T("heatmap.export", len(days), weekdayName(p, day), hour, n))
+	reply.Files = []*discordgo.File{{
+		Name:        fmt.Sprintf("jolly-heatmap-%s-%s.csv", days[0].Date, days[len(days)-1].Date),
+		ContentType: "text/csv",
+		Reader:      bytes.NewReader(csv),
+	}}
+	return reply, nil
+}
+
+// heatmapCSV renders h with a row per weekday, Monday first, and a column per UTC hour.
+func heatmapCSV(p i18n.Printer, h stats.Heatmap) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	header := []string{"weekday"}
+	for hour := range 24 {
+		header = append(header, strconv.Itoa(hour))
+	}
+	w.Write(header)
+	for d := range 7 {
+		day := time.Weekday((d + 1) % 7)
+		row := []string{weekdayName(p, day)}
+		for _, n := range h[day] {
+			row = append(row, strconv.Itoa(n))
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
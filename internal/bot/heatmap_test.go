@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"encoding/csv"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBot_HandleHeatmap(t *testing.T) {
+	today := time.Now().UTC()
+	peak := time.Date(today.Year(), today.Month(), today.Day(), 0, 30, 0, 0, time.UTC)
+	yesterday := peak.AddDate(0, 0, -1).Add(5 * time.Hour)
+
+	tests := []struct {
+		name     string
+		record   bool
+		content  string
+		wantFile bool
+	}{
+		{
+			name:     "peak and attachment",
+			record:   true,
+			content:  fmt.Sprintf("Skull activity by weekday and UTC hour for the last 30 day(s). Busiest: %s at 00:00 UTC with 2 action(s).", peak.Weekday()),
+			wantFile: true,
+		},
+		{
+			name:    "no actions",
+			content: "No actions in the last 30 day(s), so there is no heatmap yet.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(newTestConfig([]string{"alice"}, "jollyskull:123"))
+			if tt.record {
+				b.stats.RecordReplacement(peak, "alice", "💀")
+				b.stats.RecordDeletion(peak.Add(10*time.Minute), "alice")
+				b.stats.RecordReplacement(yesterday, "alice", "💀")
+			}
+			mock := &mockSession{}
+
+			b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"heatmap"}))
+
+			if got := lastResponse(t, mock); got != tt.content {
+				t.Errorf("content = %q, want %q", got, tt.content)
+			}
+			files := mock.responses[len(mock.responses)-1].Data.Files
+			if !tt.wantFile {
+				if len(files) != 0 {
+					t.Errorf("expected no attachment, got %d", len(files))
+				}
+				return
+			}
+			if len(files) != 1 {
+				t.Fatalf("expected one CSV attachment, got %v", files)
+			}
+			rows, err := csv.NewReader(files[0].Reader).ReadAll()
+			if err != nil {
+				t.Fatalf("invalid CSV: %v", err)
+			}
+			if len(rows) != 8 || len(rows[0]) != 25 || rows[0][0] != "weekday" || rows[0][24] != "23" {
+				t.Fatalf("expected a header and 7 rows of 24 hours, got %v", rows)
+			}
+			if rows[1][0] != "Monday" || rows[7][0] != "Sunday" {
+				t.Errorf("rows should run Monday to Sunday, got %s to %s", rows[1][0], rows[7][0])
+			}
+			// Row of weekday d, with Monday in row 1
+			row := func(d time.Weekday) []string { return rows[(int(d)+6)%7+1] }
+			if got := row(peak.Weekday()); got[0] != peak.Weekday().String() || got[1] != "2" {
+				t.Errorf("peak row = %v, want 2 actions at hour 0", got)
+			}
+			if got := row(yesterday.Weekday()); got[6] != "1" {
+				t.Errorf("yesterday's row = %v, want 1 action at hour 5", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/schedule"
+	"jolly-okurb/internal/stats"
+)
+
+// jobWeeklyHighlight is the scheduled job kind that pins the weekly highlight.
+const jobWeeklyHighlight = "weekly_highlight"
+
+// highlightKey is the store key of the currently pinned highlight.
+const highlightKey = "highlight/pinned"
+
+// highlightCandidates is how many of the week's top messages are tried, in
+// case the most jollified ones have been deleted since.
+const highlightCandidates = 5
+
+// pinnedHighlight is the message pinned as the weekly highlight, which is
+// unpinned when the next week's is pinned.
+type pinnedHighlight struct {
+	ChannelID string `json:"channel_id"`
+	MessageID string `json:"message_id"`
+}
+
+// scheduleHighlight schedules the highlight due at, covering the week before
+// it, with one job per week like the digest.
+func (b *Bot) scheduleHighlight(at time.Time) error {
+	id := jobWeeklyHighlight + ":" + at.Format(time.DateOnly)
+	job, err := schedule.NewJob(id, jobWeeklyHighlight, at, digestJob{Start: at.AddDate(0, 0, -7)})
+	if err != nil {
+		return err
+	}
+	return b.scheduler.Schedule(job)
+}
+
+// PinWeeklyHighlight pins the message that had the most skulls replaced in
+// the week from start, or posts and pins a highlight of it, then unpins the
+// previous week's. It schedules the next one. Quiet weeks are skipped.
+func (b *Bot) PinWeeklyHighlight(s Session, start time.Time) error {
+	if b.config.WeeklyHighlight == "" {
+		return nil
+	}
+	if err := b.scheduleHighlight(nextDigest(time.Now())); err != nil {
+		logger.Warn("failed to persist weekly highlight", "error", err)
+	}
+
+	days, err := b.stats.Days(start, 7)
+	if err != nil {
+		return fmt.Errorf("failed to load weekly stats: %w", err)
+	}
+	top := stats.Summarize(days).TopMessages
+	for _, c := range top[:min(highlightCandidates, len(top))] {
+		channelID, messageID, _ := strings.Cut(c.Key, "/")
+		msg, err := s.ChannelMessage(channelID, messageID)
+		if isNotFound(err) {
+			logger.Debug("weekly highlight candidate is gone", "message_id", messageID)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch message: %w", err)
+		}
+		msg.ChannelID = channelID
+
+		pinned, err := b.pinHighlight(s, msg, c.N)
+		if actions.Skipped(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		b.unpinHighlight(s, pinned)
+		if err := b.store.Put(highlightKey, pinned); err != nil {
+			logger.Warn("failed to save weekly highlight", "message_id", pinned.MessageID, "error", err)
+		}
+		logger.Info("pinned weekly highlight", "channel_id", channelID, "message_id", messageID, "replaced", c.N)
+		return nil
+	}
+	logger.Info("no jollified messages this week, skipping highlight", "week_start", start.Format(time.DateOnly))
+	return nil
+}
+
+// pinHighlight pins msg, or posts a highlight of it in its channel and pins
+// that, and returns what it pinned.
+func (b *Bot) pinHighlight(s Session, msg *discordgo.Message, replaced int) (pinnedHighlight, error) {
+	pinned := pinnedHighlight{ChannelID: msg.ChannelID, MessageID: msg.ID}
+	if b.config.WeeklyHighlight == config.HighlightPost {
+		p := b.printer(b.config.GuildID)
+		embed := &discordgo.MessageEmbed{
+			Title:       p.T("highlight.title"),
+			Description: p.T("highlight.description", replaced, messageLink(b.config.GuildID, msg.ChannelID, msg.ID)),
+			Color:       colorSuccess,
+		}
+		if msg.Author != nil {
+			embed.Author = &discordgo.MessageEmbedAuthor{Name: msg.Author.DisplayName(), IconURL: msg.Author.AvatarURL("64")}
+		}
+		post, err := b.sendEmbed(s, msg.ChannelID, embed)
+		if err != nil {
+			return pinnedHighlight{}, fmt.Errorf("failed to post weekly highlight: %w", err)
+		}
+		pinned.MessageID = post.ID
+	}
+
+	err := b.executor.Do(context.Background(), actions.Action{
+		Kind:      actions.Pin,
+		ChannelID: pinned.ChannelID,
+		MessageID: pinned.MessageID,
+		Do: func(...discordgo.RequestOption) error {
+			return s.ChannelMessagePin(pinned.ChannelID, pinned.MessageID)
+		},
+	})
+	if err != nil {
+		return pinnedHighlight{}, fmt.Errorf("failed to pin weekly highlight: %w", err)
+	}
+	return pinned, nil
+}
+
+// unpinHighlight unpins the previous week's highlight, unless it is the
+// same message as the new one. A failure only leaves an extra pin behind.
+func (b *Bot) unpinHighlight(s Session, current pinnedHighlight) {
+	var prev pinnedHighlight
+	ok, err := b.store.Get(highlightKey, &prev)
+	if err != nil {
+		logger.Warn("failed to load previous weekly highlight", "error", err)
+		return
+	}
+	if !ok || prev == current {
+		return
+	}
+	err = b.executor.Do(context.Background(), actions.Action{
+		Kind:      actions.Unpin,
+		ChannelID: prev.ChannelID,
+		MessageID: prev.MessageID,
+		Do: func(...discordgo.RequestOption) error {
+			return s.ChannelMessageUnpin(prev.ChannelID, prev.MessageID)
+		},
+	})
+	if err != nil && !isNotFound(err) {
+		logger.Warn("failed to unpin previous weekly highlight", "message_id", prev.MessageID, "error", err)
+	}
+}
+
+// messageLink returns the URL that opens a message in Discord.
+func messageLink(guildID, channelID, messageID string) string {
+	return "https://discord.com/channels/" + guildID + "/" + channelID + "/" + messageID
+}
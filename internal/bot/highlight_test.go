@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/config"
+)
+
+func TestBot_PinWeeklyHighlight(t *testing.T) {
+	start := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	author := &discordgo.User{ID: "poster", Username: "poster"}
+	messages := []*discordgo.Message{
+		{ID: "top", ChannelID: "chan1", Author: author},
+		{ID: "runner-up", ChannelID: "chan1", Author: author},
+	}
+
+	tests := []struct {
+		name         string
+		mode         string
+		messages     []*discordgo.Message
+		previous     *pinnedHighlight
+		wantPinned   []string
+		wantUnpinned []string
+		wantPost     bool
+	}{
+		{name: "pins the top message", mode: config.HighlightPin, messages: messages, wantPinned: []string{"top"}},
+		{name: "posts a highlight", mode: config.HighlightPost, messages: messages, wantPinned: []string{"sent1"}, wantPost: true},
+		{name: "skips deleted messages", mode: config.HighlightPin, messages: messages[1:], wantPinned: []string{"runner-up"}},
+		{
+			name:         "unpins last week's",
+			mode:         config.HighlightPin,
+			messages:     messages,
+			previous:     &pinnedHighlight{ChannelID: "chan1", MessageID: "last-week"},
+			wantPinned:   []string{"top"},
+			wantUnpinned: []string{"last-week"},
+		},
+		{
+			name:       "keeps a repeat pinned",
+			mode:       config.HighlightPin,
+			messages:   messages,
+			previous:   &pinnedHighlight{ChannelID: "chan1", MessageID: "top"},
+			wantPinned: []string{"top"},
+		},
+		{name: "nothing left to pin", mode: config.HighlightPin},
+		{name: "off", messages: messages},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig([]string{"alice"}, "jollyskull:123")
+			cfg.GuildID = "guild123"
+			cfg.WeeklyHighlight = tt.mode
+			b := New(cfg)
+			for range 3 {
+				b.stats.RecordReplacementOn(start.Add(time.Hour), "alice", "💀", "chan1", "top")
+			}
+			b.stats.RecordReplacementOn(start.AddDate(0, 0, 2), "alice", "💀", "chan1", "runner-up")
+			b.stats.RecordReplacementOn(start.AddDate(0, 0, 7), "alice", "💀", "chan1", "next-week")
+			if tt.previous != nil {
+				b.store.Put(highlightKey, tt.previous)
+			}
+			mock := &mockSession{messages: tt.messages}
+
+			if err := b.PinWeeklyHighlight(mock, start); err != nil {
+				t.Fatalf("PinWeeklyHighlight() unexpected error: %v", err)
+			}
+			if !slices.Equal(mock.pinned, tt.wantPinned) {
+				t.Errorf("pinned = %v, want %v", mock.pinned, tt.wantPinned)
+			}
+			if !slices.Equal(mock.unpinned, tt.wantUnpinned) {
+				t.Errorf("unpinned = %v, want %v", mock.unpinned, tt.wantUnpinned)
+			}
+			if tt.wantPost {
+				if len(mock.sent) != 1 || mock.sent[0].channelID != "chan1" || mock.sent[0].embed == nil {
+					t.Fatalf("sent = %+v, want one highlight in chan1", mock.sent)
+				}
+				if desc := mock.sent[0].embed.Description; !strings.Contains(desc, "3 skulls") || !strings.Contains(desc, "https://discord.com/channels/guild123/chan1/top") {
+					t.Errorf("Description = %q, want the count and a link to the message", desc)
+				}
+			} else if len(mock.sent) != 0 {
+				t.Errorf("sent %d messages, want none", len(mock.sent))
+			}
+
+			var saved pinnedHighlight
+			ok, _ := b.store.Get(highlightKey, &saved)
+			if len(tt.wantPinned) > 0 && (!ok || saved.MessageID != tt.wantPinned[0]) {
+				t.Errorf("saved highlight = %+v, want %s", saved, tt.wantPinned[0])
+			}
+			wantJobs := 0 // The next week's highlight, when enabled
+			if tt.mode != "" {
+				wantJobs = 1
+			}
+			if b.scheduler.Pending() != wantJobs {
+				t.Errorf("pending jobs = %d, want %d", b.scheduler.Pending(), wantJobs)
+			}
+		})
+	}
+}
@@ -0,0 +1,403 @@
+package bot
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/correlation"
+	"jolly-okurb/internal/metrics"
+	"jolly-okurb/internal/settings"
+	"jolly-okurb/internal/snowflake"
+)
+
+// historicalPageDelay spaces out history fetches. A variable so tests can shorten it.
+var historicalPageDelay = 500 * time.Millisecond
+
+// checkpoint records how far the historical scan of a channel got, so a
+// restarted bot neither rescans messages it has already handled nor skips
+// the ones it never reached.
+type checkpoint struct {
+	NewestID string      `json:"newest_id,omitempty"` // Newest message covered by a completed scan
+	ScanTop  string      `json:"scan_top,omitempty"`  // Upper bound of the scan in progress
+	Ranges   []scanRange `json:"ranges,omitempty"`    // Partitions of the scan in progress, newest first
+}
+
+// scanRange is the share of a scan handled by one worker. Messages with IDs
+// between Low and Cursor, both exclusive, are still to be processed.
+type scanRange struct {
+	Cursor string `json:"cursor"`
+	Low    string `json:"low"`
+	Done   bool   `json:"done,omitempty"`
+}
+
+// plan starts a scan of the messages between the previous scan, or floor if
+// that is older, and top, split into up to n ranges.
+func (c *checkpoint) plan(top, floor string, n int) {
+	low := floor
+	if snowflake.Compare(c.NewestID, low) > 0 {
+		low = c.NewestID
+	}
+
+	c.ScanTop = top
+	c.Ranges = nil
+	bounds := snowflake.Split(low, top, n)
+	for i := 0; i+1 < len(bounds); i++ {
+		c.Ranges = append(c.Ranges, scanRange{Cursor: bounds[i], Low: bounds[i+1]})
+	}
+}
+
+// complete marks the scan in progress as finished.
+func (c *checkpoint) complete() {
+	if snowflake.Compare(c.ScanTop, c.NewestID) > 0 {
+		c.NewestID = c.ScanTop
+	}
+	c.ScanTop = ""
+	c.Ranges = nil
+}
+
+func checkpointKey(channelID string) string {
+	return "channels/" + channelID + "/history"
+}
+
+// loadCheckpoint returns the stored checkpoint for channelID. Load failures
+// are logged and treated as no checkpoint, which rescans the channel.
+func (b *Bot) loadCheckpoint(channelID string) checkpoint {
+	var cp checkpoint
+	if b.store == nil {
+		return cp
+	}
+	if _, err := b.store.Get(checkpointKey(channelID), &cp); err != nil {
+		scanLog.Error("failed to load history checkpoint", "channel_id", channelID, "error", err)
+		return checkpoint{}
+	}
+	return cp
+}
+
+func (b *Bot) saveCheckpoint(channelID string, cp checkpoint) {
+	if b.store == nil {
+		return
+	}
+	if err := b.store.Put(checkpointKey(channelID), cp); err != nil {
+		scanLog.Error("failed to save history checkpoint", "channel_id", channelID, "error", err)
+	}
+}
+
+func (b *Bot) deleteCheckpoint(channelID string) {
+	if b.store == nil {
+		return
+	}
+	if err := b.store.Delete(checkpointKey(channelID)); err != nil {
+		scanLog.Error("failed to delete history checkpoint", "channel_id", channelID, "error", err)
+	}
+}
+
+// historicalWorkers returns how many ranges each channel scan is split into.
+func (b *Bot) historicalWorkers() int {
+	return max(b.config.HistoricalWorkers, 1)
+}
+
+type cutoffKey struct{}
+
+// withCutoff returns a copy of ctx in which historical scans go back to
+// cutoff instead of HistoricalCutoff.
+func withCutoff(ctx context.Context, cutoff time.Time) context.Context {
+	return context.WithValue(ctx, cutoffKey{}, cutoff)
+}
+
+// scanCutoff returns how far back historical scans in ctx go.
+func scanCutoff(ctx context.Context) (time.Time, error) {
+	if cutoff, ok := ctx.Value(cutoffKey{}).(time.Time); ok {
+		return cutoff, nil
+	}
+	return time.Parse(time.RFC3339, HistoricalCutoff)
+}
+
+// ProcessHistoricalMessages replaces skull reactions on messages newer than
+// the cutoff, HistoricalCutoff unless ctx has another, in every monitored
+// channel, one channel at a time.
+func (b *Bot) ProcessHistoricalMessages(ctx context.Context, s Session) {
+	cutoff, err := scanCutoff(ctx)
+	if err != nil {
+		scanLog.Error("invalid historical cutoff date", "error", err)
+		return
+	}
+
+	start := time.Now()
+	report := historyReport{Complete: true}
+	defer func() {
+		if stalled(ctx) {
+			return // The watchdog restarted the scan; the new run reports instead
+		}
+		report.Duration = time.Since(start)
+		b.sink().Timing(metrics.HistoricalDuration, report.Duration)
+		b.postHistoryReport(s, report)
+	}()
+
+	for _, channelID := range b.monitoredChannelIDs() {
+		if ctx.Err() != nil {
+			scanLog.Info("historical processing cancelled")
+			report.Complete, report.Cancelled = false, true
+			return
+		}
+		if !b.scanAllowed() {
+			scanLog.Info("historical processing paused")
+			report.Complete, report.Paused = false, true
+			return
+		}
+		stats, complete := b.processChannelHistory(ctx, s, channelID, cutoff)
+		report.Channels++
+		report.add(stats)
+		if !complete {
+			report.Complete = false
+			report.Cancelled = ctx.Err() != nil
+		}
+	}
+}
+
+// scanAllowed reports whether a historical scan may go on replacing
+// reactions: the guild is not paused, and neither historical processing nor
+// reactions are disabled.
+func (b *Bot) scanAllowed() bool {
+	return b.featureEnabled(settings.FeatureHistorical) && b.featureEnabled(settings.FeatureReactions)
+}
+
+// processChannelHistory scans a channel back to the cutoff, or to the newest
+// message covered by a previous scan. A channel whose last message that scan
+// covered already is skipped without a request. The span is split into ranges scanned
+// concurrently, each checkpointed after every page. An interrupted scan is
+// first resumed where it stopped before the messages posted since are scanned.
+// It reports whether the scan reached its end.
+func (b *Bot) processChannelHistory(ctx context.Context, s Session, channelID string, cutoff time.Time) (scanStats, bool) {
+	h := &historyScan{bot: b, channelID: channelID, cp: b.loadCheckpoint(channelID)}
+	resuming := len(h.cp.Ranges) > 0
+	if resuming {
+		scanLog.Info("resuming interrupted historical scan", "channel_id", channelID, "ranges", len(h.cp.Ranges))
+	} else if last := b.lastMessageID(channelID); last != "" && snowflake.Compare(last, h.cp.NewestID) <= 0 {
+		// Quiet since the previous scan, so there is nothing to fetch
+		scanLog.Debug("no new messages since the last scan, skipping channel", "channel_id", channelID, "last_message_id", last)
+		metrics.Incr(b.sink(), metrics.HistoricalQuiet)
+		return h.stats, true
+	}
+
+	for {
+		if len(h.cp.Ranges) == 0 {
+			h.cp.plan(snowflake.FromTime(time.Now()), snowflake.FromTime(cutoff), b.historicalWorkers())
+			b.saveCheckpoint(channelID, h.cp)
+			scanLog.Info("processing historical messages", "channel_id", channelID, "cutoff", cutoff.Format("2006-01-02"),
+				"after_id", h.cp.NewestID, "workers", len(h.cp.Ranges))
+		}
+
+		if !h.run(ctx, s) {
+			// The checkpoint is left as is so the next run picks up from here
+			scanLog.Info("historical processing stopped", "channel_id", channelID, "processed", h.stats.Processed, "replaced", h.stats.Replaced)
+			return h.stats, false
+		}
+		h.cp.complete()
+		b.saveCheckpoint(channelID, h.cp)
+
+		if !resuming {
+			break
+		}
+		// Now scan whatever was posted since the interrupted scan started
+		resuming = false
+	}
+
+	scanLog.Info("historical processing complete", "channel_id", channelID, "processed", h.stats.Processed, "replaced", h.stats.Replaced)
+	return h.stats, true
+}
+
+// scanStats counts what a historical scan did.
+type scanStats struct {
+	Processed int // Messages scanned
+	Replaced  int // Reactions replaced
+	Failed    int // Failed fetches and replacements
+}
+
+func (st *scanStats) add(o scanStats) {
+	st.Processed += o.Processed
+	st.Replaced += o.Replaced
+	st.Failed += o.Failed
+}
+
+// historyScan coordinates the workers scanning the ranges of one channel.
+type historyScan struct {
+	bot       *Bot
+	channelID string
+
+	mu    sync.Mutex // Guards the fields below and serializes checkpoint writes
+	cp    checkpoint
+	stats scanStats
+}
+
+// run scans every unfinished range concurrently and reports whether all of them finished.
+func (h *historyScan) run(ctx context.Context, s Session) bool {
+	var wg sync.WaitGroup
+	for i, r := range slices.Clone(h.cp.Ranges) {
+		if !r.Done {
+			wg.Go(func() { h.scan(ctx, s, i, r) })
+		}
+	}
+	wg.Wait()
+
+	return !slices.ContainsFunc(h.cp.Ranges, func(r scanRange) bool { return !r.Done })
+}
+
+// scan processes range i from newest to oldest until it reaches its lower
+// bound, the context is cancelled, the scan is paused, or a fetch fails.
+func (h *historyScan) scan(ctx context.Context, s Session, i int, r scanRange) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		h.bot.beatHistorical()
+		messages, err := s.ChannelMessages(h.channelID, 100, r.Cursor, "", "")
+		if stalled(ctx) {
+			return // Do not overwrite the checkpoint of the run that replaced this one
+		}
+		if err != nil {
+			scanLog.Error("failed to fetch messages", "channel_id", h.channelID, "before_id", r.Cursor, "error", err)
+			h.record(i, r, scanStats{Failed: 1})
+			return
+		}
+
+		var page scanStats
+		r.Done = len(messages) == 0
+		paused := false
+		for _, msg := range messages {
+			if snowflake.Compare(msg.ID, r.Low) <= 0 {
+				r.Done = true
+				break
+			}
+			if ctx.Err() != nil {
+				break // The cursor keeps the messages left for the next scan
+			}
+			// Checked for every message so that pausing stops a scan at once;
+			// the next scan resumes from here
+			if !h.bot.scanAllowed() {
+				paused = true
+				break
+			}
+
+			// Fetched messages may omit the channel ID; replacements need it
+			if msg.ChannelID == "" {
+				msg.ChannelID = h.channelID
+			}
+			replaced, failed := h.bot.processMessageReactions(ctx, s, msg)
+			h.bot.beatHistorical()
+			page.Replaced += replaced
+			page.Failed += failed
+			page.Processed++
+			metrics.Incr(h.bot.sink(), metrics.HistoricalProcessed)
+			r.Cursor = msg.ID
+		}
+		if stalled(ctx) {
+			return
+		}
+		h.record(i, r, page)
+
+		if r.Done || paused {
+			return
+		}
+		time.Sleep(historicalPageDelay)
+	}
+}
+
+// record saves the progress of range i after a page.
+func (h *historyScan) record(i int, r scanRange, page scanStats) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.cp.Ranges[i] = r
+	h.bot.saveCheckpoint(h.channelID, h.cp)
+
+	// Log progress periodically
+	before := h.stats.Processed
+	h.stats.add(page)
+	if h.stats.Processed/500 > before/500 {
+		scanLog.Info("historical processing progress", "channel_id", h.channelID, "processed", h.stats.Processed, "replaced", h.stats.Replaced)
+	}
+}
+
+func (b *Bot) ProcessMessageReactions(s Session, msg *discordgo.Message) int {
+	replaced, _ := b.processMessageReactions(context.Background(), s, msg)
+	return replaced
+}
+
+// processMessageReactions replaces target users' skull reactions on msg and
+// returns how many replacements succeeded and failed. Replacements carry the
+// values of ctx, such as an operator's comment. Cancelling ctx interrupts
+// their waits for jitter and rate limits and stops the next one, but a
+// replacement that removed its skull is finished.
+func (b *Bot) processMessageReactions(ctx context.Context, s Session, msg *discordgo.Message) (replaced, failed int) {
+	if b.isExempt(msg.ID) {
+		return 0, 0
+	}
+	dryRun := b.isDryRun(msg.ChannelID) // Skipped replacements are not failures
+	b.noteReactions(msg)
+	for _, reaction := range msg.Reactions {
+		if !b.handlesEmoji(reaction.Emoji) {
+			continue
+		}
+
+		targetUsers := b.findTargetUsersWithReaction(s, msg.ChannelID, msg.ID, reaction.Emoji)
+		for _, userID := range targetUsers {
+			if !b.groupAllows(userID, msg.ChannelID, config.ActionReactions, reaction.Emoji.Name) {
+				continue
+			}
+			if !slices.Contains(b.config.StepsFor(userID), config.StepRemove) && b.hasReacted(msg.ID, b.replacementsFor(userID, reaction.Emoji)) {
+				continue // The skull stays without a remove step; it was handled when jollyskull was added
+			}
+			if ctx.Err() != nil {
+				return replaced, failed
+			}
+			if b.ReplaceReaction(correlation.Start(ctx), s, msg.ChannelID, msg.ID, userID, reaction.Emoji) {
+				replaced++
+			} else if !dryRun {
+				failed++
+			}
+		}
+	}
+
+	return replaced, failed
+}
+
+// findTargetUsersWithReaction paginates through all reactions to find target users.
+// Returns the list of target user IDs that have reacted with the given emoji.
+func (b *Bot) findTargetUsersWithReaction(s Session, channelID, messageID string, emoji *discordgo.Emoji) []string {
+	var afterID string
+	var found []string
+	emojiStr := GetEmojiAPIString(emoji)
+
+	for {
+		users, err := s.MessageReactions(channelID, messageID, emojiStr, 100, "", afterID)
+		if err != nil {
+			scanLog.Error("failed to fetch reactions", "message_id", messageID, "emoji", emojiStr, "error", err)
+			return found
+		}
+
+		if len(users) == 0 {
+			return found
+		}
+
+		for _, user := range users {
+			if b.IsTargetUser(user.ID) {
+				found = append(found, user.ID)
+			}
+		}
+
+		// No more pages if we got fewer than requested
+		if len(users) < 100 {
+			return found
+		}
+
+		afterID = users[len(users)-1].ID
+	}
+}
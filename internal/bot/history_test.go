@@ -0,0 +1,327 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/snowflake"
+	"jolly-okurb/internal/store"
+)
+
+// historySession serves a channel's history the way Discord pages it: newest
+// first, older than the requested message.
+type historySession struct {
+	*mockSession
+	history   []*discordgo.Message // Newest first
+	failAfter int                  // Fail fetches after this many calls (0 = never)
+}
+
+func (h *historySession) ChannelMessages(channelID string, limit int, beforeID, afterID, aroundID string, options ...discordgo.RequestOption) ([]*discordgo.Message, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.beforeIDs = append(h.beforeIDs, beforeID)
+	h.messageCalls++
+	if h.failAfter > 0 && h.messageCalls > h.failAfter {
+		return nil, errors.New("API error")
+	}
+
+	var page []*discordgo.Message
+	for _, m := range h.history {
+		if len(page) == limit {
+			break
+		}
+		if beforeID == "" || snowflake.Compare(m.ID, beforeID) < 0 {
+			page = append(page, m)
+		}
+	}
+	return page, nil
+}
+
+// skullHistory returns n messages, newest first, spaced step apart from
+// newest backwards. Every message has a skull reaction from target-user.
+// pausingSession calls pause, which pauses or cancels the scan, after each
+// removed reaction.
+type pausingSession struct {
+	*historySession
+	pause func()
+}
+
+func (p *pausingSession) MessageReactionRemove(channelID, messageID, emojiID, userID string, options ...discordgo.RequestOption) error {
+	err := p.historySession.MessageReactionRemove(channelID, messageID, emojiID, userID, options...)
+	p.pause()
+	return err
+}
+
+func skullHistory(newest time.Time, n int, step time.Duration) *historySession {
+	h := &historySession{mockSession: &mockSession{reactions: make(map[string][]*discordgo.User)}}
+	for i := range n {
+		ts := newest.Add(-time.Duration(i) * step)
+		id := snowflake.FromTime(ts)
+		h.history = append(h.history, &discordgo.Message{
+			ID:        id,
+			Timestamp: ts,
+			Reactions: []*discordgo.MessageReactions{{Emoji: &discordgo.Emoji{Name: "💀"}}},
+		})
+		h.reactions[id] = []*discordgo.User{{ID: "target-user"}}
+	}
+	return h
+}
+
+// replacedIDs returns the IDs of messages whose reactions were replaced, counting repeats.
+func replacedIDs(h *historySession) map[string]int {
+	ids := make(map[string]int)
+	for _, r := range h.removedReactions {
+		ids[r.messageID]++
+	}
+	return ids
+}
+
+func TestBot_ProcessHistoricalMessages_Checkpoint(t *testing.T) {
+	delay := historicalPageDelay
+	historicalPageDelay = 0
+	t.Cleanup(func() { historicalPageDelay = delay })
+
+	newest := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	newBot := func(st store.Store, workers int) *Bot {
+		cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+		cfg.HistoricalWorkers = workers
+		b := New(cfg, WithStore(st))
+		b.channels = map[string]string{"chan": "jollyposting"}
+		return b
+	}
+	loadCheckpoint := func(t *testing.T, st store.Store) checkpoint {
+		t.Helper()
+		var cp checkpoint
+		if _, err := st.Get(checkpointKey("chan"), &cp); err != nil {
+			t.Fatalf("Get() unexpected error: %v", err)
+		}
+		return cp
+	}
+
+	t.Run("completed scan records where it started", func(t *testing.T) {
+		st := store.NewMemory()
+		h := skullHistory(newest, 3, time.Hour)
+		started := snowflake.FromTime(time.Now())
+		newBot(st, 1).ProcessHistoricalMessages(context.Background(), h)
+
+		cp := loadCheckpoint(t, st)
+		if snowflake.Compare(cp.NewestID, started) < 0 {
+			t.Errorf("NewestID = %q, want at least %q", cp.NewestID, started)
+		}
+		if len(cp.Ranges) != 0 || cp.ScanTop != "" {
+			t.Errorf("checkpoint should have no scan in progress, got %+v", cp)
+		}
+		if got := len(h.removedReactions); got != 3 {
+			t.Errorf("expected 3 replaced reactions, got %d", got)
+		}
+	})
+
+	t.Run("next scan stops at the checkpoint", func(t *testing.T) {
+		st := store.NewMemory()
+		h := skullHistory(newest, 3, time.Hour)
+		st.Put(checkpointKey("chan"), checkpoint{NewestID: h.history[1].ID})
+		newBot(st, 1).ProcessHistoricalMessages(context.Background(), h)
+
+		got := replacedIDs(h)
+		if len(got) != 1 || got[h.history[0].ID] != 1 {
+			t.Errorf("expected only the newest message to be processed, got %v", got)
+		}
+	})
+
+	t.Run("quiet channel is skipped", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			last      int // Index of the channel's last message; -1 if not known
+			wantFetch bool
+		}{
+			{"no new messages", 1, false},
+			{"new messages", 0, true},
+			{"last message not known", -1, true},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				st := store.NewMemory()
+				h := skullHistory(newest, 3, time.Hour)
+				st.Put(checkpointKey("chan"), checkpoint{NewestID: h.history[1].ID})
+				b := newBot(st, 1)
+				if tt.last >= 0 {
+					b.lastMessageIDs = map[string]string{"chan": h.history[tt.last].ID}
+				}
+				b.ProcessHistoricalMessages(context.Background(), h)
+
+				if fetched := h.messageCalls > 0; fetched != tt.wantFetch {
+					t.Errorf("fetched %d pages, want fetches %v", h.messageCalls, tt.wantFetch)
+				}
+			})
+		}
+	})
+
+	t.Run("interrupted scan resumes from cursor", func(t *testing.T) {
+		st := store.NewMemory()
+		h := skullHistory(newest, 5, time.Hour)
+		// The interrupted scan started at message 1 and got down to message 2;
+		// message 4 was covered by the scan before that.
+		st.Put(checkpointKey("chan"), checkpoint{
+			NewestID: h.history[4].ID,
+			ScanTop:  h.history[1].ID,
+			Ranges:   []scanRange{{Cursor: h.history[2].ID, Low: h.history[4].ID}},
+		})
+		newBot(st, 1).ProcessHistoricalMessages(context.Background(), h)
+
+		got := replacedIDs(h)
+		for i, want := range []int{1, 0, 0, 1, 0} {
+			if got[h.history[i].ID] != want {
+				t.Errorf("message %d processed %d times, want %d", i, got[h.history[i].ID], want)
+			}
+		}
+		if cp := loadCheckpoint(t, st); len(cp.Ranges) != 0 || snowflake.Compare(cp.NewestID, h.history[0].ID) < 0 {
+			t.Errorf("checkpoint should cover the newest message with no scan in progress, got %+v", cp)
+		}
+	})
+
+	t.Run("pausing stops the scan at once", func(t *testing.T) {
+		st := store.NewMemory()
+		h := skullHistory(newest, 3, time.Hour)
+		b := newBot(st, 1)
+		// Pause as soon as the first message has been processed
+		p := &pausingSession{historySession: h, pause: func() { b.settings.SetPaused(b.config.GuildID, true) }}
+		b.ProcessHistoricalMessages(context.Background(), p)
+
+		if got := replacedIDs(h); len(got) != 1 || got[h.history[0].ID] != 1 {
+			t.Fatalf("expected only the newest message to be processed, got %v", got)
+		}
+		cp := loadCheckpoint(t, st)
+		if len(cp.Ranges) != 1 || cp.Ranges[0].Cursor != h.history[0].ID {
+			t.Fatalf("expected the range in progress to end at the processed message, got %+v", cp)
+		}
+
+		b.settings.SetPaused(b.config.GuildID, false)
+		b.ProcessHistoricalMessages(context.Background(), h)
+		for i, msg := range h.history {
+			if got := replacedIDs(h)[msg.ID]; got != 1 {
+				t.Errorf("message %d processed %d times after resuming, want 1", i, got)
+			}
+		}
+	})
+
+	t.Run("cancelling stops the scan at once", func(t *testing.T) {
+		st := store.NewMemory()
+		h := skullHistory(newest, 3, time.Hour)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		// Cancel as soon as the first skull has been removed
+		p := &pausingSession{historySession: h, pause: cancel}
+		newBot(st, 1).ProcessHistoricalMessages(ctx, p)
+
+		if got := replacedIDs(h); len(got) != 1 || got[h.history[0].ID] != 1 {
+			t.Fatalf("expected only the newest message to be processed, got %v", got)
+		}
+		if len(h.addedReactions) != 1 {
+			t.Errorf("added %d reactions, want the cancelled replacement finished", len(h.addedReactions))
+		}
+		if cp := loadCheckpoint(t, st); len(cp.Ranges) != 1 || cp.Ranges[0].Cursor != h.history[0].ID {
+			t.Errorf("expected the range in progress to end at the processed message, got %+v", cp)
+		}
+	})
+
+	t.Run("paused scan does not start", func(t *testing.T) {
+		st := store.NewMemory()
+		h := skullHistory(newest, 3, time.Hour)
+		b := newBot(st, 1)
+		b.settings.SetPaused(b.config.GuildID, true)
+		b.ProcessHistoricalMessages(context.Background(), h)
+
+		if h.messageCalls != 0 || len(h.removedReactions) != 0 {
+			t.Errorf("paused scan fetched %d pages and replaced %d reactions, want none", h.messageCalls, len(h.removedReactions))
+		}
+	})
+
+	t.Run("fetch error keeps cursor", func(t *testing.T) {
+		st := store.NewMemory()
+		h := skullHistory(newest, 150, time.Minute)
+		h.failAfter = 1
+		newBot(st, 1).ProcessHistoricalMessages(context.Background(), h)
+
+		cp := loadCheckpoint(t, st)
+		if len(cp.Ranges) != 1 {
+			t.Fatalf("expected 1 range in progress, got %+v", cp)
+		}
+		if r := cp.Ranges[0]; r.Done || r.Cursor != h.history[99].ID {
+			t.Errorf("range = %+v, want cursor at the last message of the first page", r)
+		}
+	})
+
+	t.Run("workers split the scan", func(t *testing.T) {
+		st := store.NewMemory()
+		h := skullHistory(newest, 300, 12*time.Hour)
+		newBot(st, 4).ProcessHistoricalMessages(context.Background(), h)
+
+		got := replacedIDs(h)
+		processed := 0
+		for _, msg := range h.history {
+			if msg.Timestamp.Before(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+				if got[msg.ID] != 0 {
+					t.Errorf("message %s before cutoff should not be processed", msg.ID)
+				}
+				continue
+			}
+			processed++
+			if got[msg.ID] != 1 {
+				t.Errorf("message %s processed %d times, want 1", msg.ID, got[msg.ID])
+			}
+		}
+		if processed == 0 {
+			t.Fatal("test history should include messages after the cutoff")
+		}
+		if len(h.beforeIDs) < 4 {
+			t.Errorf("expected at least one fetch per worker, got %d", len(h.beforeIDs))
+		}
+		if cp := loadCheckpoint(t, st); len(cp.Ranges) != 0 {
+			t.Errorf("checkpoint should have no scan in progress, got %+v", cp)
+		}
+	})
+}
+
+func TestCheckpoint_Plan(t *testing.T) {
+	floor := snowflake.FromTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	top := snowflake.FromTime(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	newest := snowflake.FromTime(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name      string
+		cp        checkpoint
+		workers   int
+		wantLow   string
+		wantCount int
+	}{
+		{"first scan goes back to the cutoff", checkpoint{}, 1, floor, 1},
+		{"later scan stops at the previous one", checkpoint{NewestID: newest}, 1, newest, 1},
+		{"split across workers", checkpoint{}, 3, floor, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cp := tt.cp
+			cp.plan(top, floor, tt.workers)
+
+			if len(cp.Ranges) != tt.wantCount {
+				t.Fatalf("got %d ranges, want %d", len(cp.Ranges), tt.wantCount)
+			}
+			if cp.ScanTop != top || cp.Ranges[0].Cursor != top {
+				t.Errorf("scan should start at %q, got %+v", top, cp)
+			}
+			if last := cp.Ranges[len(cp.Ranges)-1]; last.Low != tt.wantLow {
+				t.Errorf("scan should stop at %q, got %q", tt.wantLow, last.Low)
+			}
+			for i := 1; i < len(cp.Ranges); i++ {
+				if cp.Ranges[i].Cursor != cp.Ranges[i-1].Low {
+					t.Errorf("range %d should start where range %d ends, got %+v", i, i-1, cp.Ranges)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/impersonate"
+	"jolly-okurb/internal/metrics"
+)
+
+// jail reposts a skull-only message in the jail channel under its author's
+// name and avatar, so that its content survives the message's deletion.
+func (b *Bot) jail(ctx context.Context, s Session, m *discordgo.Message) error {
+	var as impersonate.Identity
+	var authorID string
+	if m.Author != nil {
+		authorID = m.Author.ID
+		info := b.lookupUser(s, m.Author.ID)
+		as = impersonate.Identity{Name: info.Name, AvatarURL: info.AvatarURL}
+		if as.Name == "" {
+			as = impersonate.Identity{Name: m.Author.DisplayName(), AvatarURL: m.Author.AvatarURL("64")}
+		}
+	}
+	err := b.executor.Do(ctx, actions.Action{
+		Kind:      actions.Post,
+		Policy:    policySkullOnly,
+		ChannelID: m.ChannelID, // Dry run follows the channel the message is taken from
+		MessageID: m.ID,
+		UserID:    authorID,
+		Do: func(...discordgo.RequestOption) error {
+			_, err := b.webhooks.Post(s, b.config.JailChannelID, as, &discordgo.WebhookParams{
+				Content:         m.Content,
+				AllowedMentions: &discordgo.MessageAllowedMentions{},
+			})
+			return err
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to repost message in jail: %w", err)
+	}
+	logger.InfoContext(ctx, "moved skull-only message to jail", "message_id", m.ID, "channel_id", m.ChannelID, "jail_channel_id", b.config.JailChannelID)
+	metrics.Incr(b.sink(), metrics.MessagesMoved)
+	return nil
+}
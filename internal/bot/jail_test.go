@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/metrics"
+)
+
+func TestBot_RemoveMessage_Jail(t *testing.T) {
+	tests := []struct {
+		name        string
+		jail        string
+		dryRun      bool
+		executeErr  error
+		wantRemoved bool
+		wantRepost  bool
+		wantMoved   int64
+		wantFailed  int64
+	}{
+		{name: "no jail deletes", wantRemoved: true},
+		{name: "moves", jail: "jail", wantRemoved: true, wantRepost: true, wantMoved: 1},
+		{name: "repost failure keeps message", jail: "jail", executeErr: errors.New("HTTP 403 Forbidden"), wantFailed: 1},
+		{name: "dry run", jail: "jail", dryRun: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig([]string{"target123"}, "jollyskull:123")
+			cfg.JailChannelID = tt.jail
+			cfg.DryRun = tt.dryRun
+			sink := newRecordingSink()
+			b := New(cfg, WithMetrics(sink))
+			s := &mockSession{executeErr: tt.executeErr}
+
+			m := &discordgo.Message{
+				ID:        "msg1",
+				ChannelID: "channel123",
+				Content:   "💀 💀",
+				Author:    &discordgo.User{ID: "target123", Username: "skully", GlobalName: "Skully"},
+			}
+			if got := b.removeMessage(context.Background(), s, m); got != tt.wantRemoved {
+				t.Errorf("removeMessage() = %v, want %v", got, tt.wantRemoved)
+			}
+			if deleted := slices.Contains(s.deleted, "msg1"); deleted != tt.wantRemoved {
+				t.Errorf("deleted = %v, want %v", deleted, tt.wantRemoved)
+			}
+
+			if tt.wantRepost {
+				if len(s.executed) != 1 || len(s.webhooks) != 1 || s.webhooks[0].ChannelID != "jail" {
+					t.Fatalf("reposted %d times through %+v, want once in jail", len(s.executed), s.webhooks)
+				}
+				p := s.executed[0]
+				if p.Content != m.Content || p.Username != "Skully" {
+					t.Errorf("reposted %q as %q, want %q as Skully", p.Content, p.Username, m.Content)
+				}
+				if p.AllowedMentions == nil || len(p.AllowedMentions.Parse) > 0 {
+					t.Errorf("repost allows mentions: %+v", p.AllowedMentions)
+				}
+			}
+			if got := sink.counts[metrics.MessagesMoved]; got != tt.wantMoved {
+				t.Errorf("moved = %d, want %d", got, tt.wantMoved)
+			}
+			if got := sink.counts[metrics.MessageMoveFailures]; got != tt.wantFailed {
+				t.Errorf("move failures = %d, want %d", got, tt.wantFailed)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/stats"
+)
+
+// Bounds of the days option of /jolly leaderboard export, /jolly heatmap, and
+// /jolly emojis.
+const (
+	leaderboardDefaultDays = 30
+	leaderboardMaxDays     = 366
+)
+
+// leaderboardMinDays is addressable for the option's MinValue.
+var leaderboardMinDays float64 = 1
+
+// handleLeaderboardExport replies with a CSV of per-user counts for the last
+// days, ending today.
+func (b *Bot) handleLeaderboardExport(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	days, err := b.optionDays(opts)
+	if err != nil {
+		return nil, err
+	}
+	n := len(days)
+	users := stats.PerUser(days)
+	csv, err := b.leaderboardCSV(s, users)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("leaderboard exported", "days", n, "users", len(users), "by", interactionUserID(i))
+
+	reply := textReply(b.printer(i.GuildID).T("leaderboard.export", n, len(users)))
+	reply.Files = []*discordgo.File{{
+		Name:        fmt.Sprintf("jolly-leaderboard-%s-%s.csv", days[0].Date, days[len(days)-1].Date),
+		ContentType: "text/csv",
+		Reader:      bytes.NewReader(csv),
+	}}
+	return reply, nil
+}
+
+// optionDays returns the stats for the number of days in the days option,
+// ending today.
+func (b *Bot) optionDays(opts commandOptions) ([]stats.Day, error) {
+	n := leaderboardDefaultDays
+	if opt, ok := opts["days"]; ok {
+		n = int(opt.IntValue())
+	}
+	if n < 1 || n > leaderboardMaxDays {
+		return nil, fmt.Errorf("days must be between 1 and %d", leaderboardMaxDays)
+	}
+
+	start := time.Now().UTC().AddDate(0, 0, 1-n)
+	days, err := b.stats.Days(start, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stats: %w", err)
+	}
+	return days, nil
+}
+
+// leaderboardCSV renders users as CSV with a header row.
+func (b *Bot) leaderboardCSV(s Session, users []stats.UserCounts) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"rank", "user_id", "name", "replaced", "deleted", "total"})
+	for i, u := range users {
+		w.Write([]string{
+			strconv.Itoa(i + 1),
+			u.UserID,
+			b.lookupUser(s, u.UserID).Name,
+			strconv.Itoa(u.Replaced),
+			strconv.Itoa(u.Deleted),
+			strconv.Itoa(u.Total()),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
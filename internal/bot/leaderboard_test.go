@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"encoding/csv"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func intOption(name string, value int) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{
+		Name:  name,
+		Type:  discordgo.ApplicationCommandOptionInteger,
+		Value: float64(value), // As decoded from JSON
+	}
+}
+
+func TestBot_HandleLeaderboardExport(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		opts     []*discordgo.ApplicationCommandInteractionDataOption
+		content  string
+		expected [][]string
+	}{
+		{
+			name:    "default period",
+			content: "Leaderboard for the last 30 day(s), 2 user(s).",
+			expected: [][]string{
+				{"rank", "user_id", "name", "replaced", "deleted", "total"},
+				{"1", "alice", "Alice", "2", "1", "3"},
+				{"2", "bob", "", "2", "0", "2"},
+			},
+		},
+		{
+			name:    "today only",
+			opts:    []*discordgo.ApplicationCommandInteractionDataOption{intOption("days", 1)},
+			content: "Leaderboard for the last 1 day(s), 1 user(s).",
+			expected: [][]string{
+				{"rank", "user_id", "name", "replaced", "deleted", "total"},
+				{"1", "alice", "Alice", "1", "1", "2"},
+			},
+		},
+		{
+			name:    "out of range",
+			opts:    []*discordgo.ApplicationCommandInteractionDataOption{intOption("days", 0)},
+			content: "Error: days must be between 1 and 366",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(newTestConfig([]string{"alice", "bob"}, "jollyskull:123"))
+			b.stats.RecordReplacement(now, "alice", "💀")
+			b.stats.RecordDeletion(now, "alice")
+			b.stats.RecordReplacement(now.AddDate(0, 0, -3), "alice", "💀")
+			b.stats.RecordReplacement(now.AddDate(0, 0, -3), "bob", "💀")
+			b.stats.RecordReplacement(now.AddDate(0, 0, -40), "bob", "💀") // Outside every period
+			b.stats.RecordReplacement(now.AddDate(0, 0, -10), "bob", "💀")
+			mock := &mockSession{members: map[string]*discordgo.Member{"alice": {User: &discordgo.User{ID: "alice", Username: "Alice"}}}}
+
+			b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"leaderboard", "export"}, tt.opts...))
+
+			if got := lastResponse(t, mock); got != tt.content {
+				t.Errorf("content = %q, want %q", got, tt.content)
+			}
+			files := mock.responses[len(mock.responses)-1].Data.Files
+			if tt.expected == nil {
+				if len(files) != 0 {
+					t.Errorf("expected no attachment, got %d", len(files))
+				}
+				return
+			}
+			if len(files) != 1 || !strings.HasSuffix(files[0].Name, ".csv") {
+				t.Fatalf("expected one CSV attachment, got %v", files)
+			}
+			rows, err := csv.NewReader(files[0].Reader).ReadAll()
+			if err != nil {
+				t.Fatalf("attachment is not CSV: %v", err)
+			}
+			if !slices.EqualFunc(rows, tt.expected, slices.Equal) {
+				t.Errorf("rows = %v, want %v", rows, tt.expected)
+			}
+		})
+	}
+}
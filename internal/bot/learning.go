@@ -0,0 +1,204 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/flags"
+)
+
+// Custom ID prefixes of the buttons that exclude a custom emoji from the
+// rules and that stop excluding it, followed by the emoji's name.
+const (
+	excludeAddPrefix    = "jolly:exclude:add:"
+	excludeRemovePrefix = "jolly:exclude:remove:"
+)
+
+// undoCountsKey stores how many replacements of each custom emoji, by
+// lower-case name, moderators undid.
+const undoCountsKey = "undos"
+
+// replacedEmojis remembers which emojis the bot replaced on the most recent
+// messages, so an undone replacement can be traced back to its emojis after
+// the skulls themselves are gone.
+type replacedEmojis struct {
+	mu     sync.Mutex
+	emojis map[string][]string // Message formats of the emojis, by message ID
+	order  []string            // Insertion order, for evicting the oldest
+}
+
+func (r *replacedEmojis) add(messageID, emoji string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.emojis == nil {
+		r.emojis = make(map[string][]string)
+	}
+	if _, ok := r.emojis[messageID]; !ok {
+		if len(r.order) >= reactedCapacity {
+			delete(r.emojis, r.order[0])
+			r.order = r.order[1:]
+		}
+		r.order = append(r.order, messageID)
+	}
+	r.emojis[messageID] = appendUnique(r.emojis[messageID], emoji)
+}
+
+func (r *replacedEmojis) get(messageID string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.emojis[messageID]
+}
+
+func appendUnique(s []string, v string) []string {
+	for _, e := range s {
+		if e == v {
+			return s
+		}
+	}
+	return append(s, v)
+}
+
+// learnFromUndo counts an undone replacement on messageID against the custom
+// emojis the bot replaced there. One reaching UNDO_EXCLUDE_SUGGEST undos is
+// offered for exclusion in the admin channel, or excluded right away with
+// UNDO_EXCLUDE_AUTO while the auto_exclude flag is on. Unicode emojis are left
+// alone, as the rules list them on purpose rather than matching them by name.
+func (b *Bot) learnFromUndo(s Session, messageID string) {
+	if b.config.UndoExcludeSuggest == 0 {
+		return
+	}
+	for _, tag := range b.replaced.get(messageID) {
+		parts := strings.Split(tag, ":")
+		if len(parts) != 3 {
+			continue
+		}
+		name := parts[1]
+		n, err := b.countUndo(name)
+		if err != nil {
+			logger.Warn("failed to count undone replacement", "emoji", tag, "error", err)
+			continue
+		}
+		if n != b.config.UndoExcludeSuggest {
+			continue
+		}
+		if b.config.UndoExcludeAuto && b.flagEnabled(flags.AutoExclude) {
+			b.excludeLearned(s, tag, name, n)
+		} else {
+			b.suggestExclusion(s, tag, name, n)
+		}
+	}
+}
+
+// countUndo adds an undone replacement of the custom emoji named name and
+// returns how many there were.
+func (b *Bot) countUndo(name string) (int, error) {
+	b.undosMu.Lock()
+	defer b.undosMu.Unlock()
+	counts := make(map[string]int)
+	if _, err := b.store.Get(undoCountsKey, &counts); err != nil {
+		return 0, fmt.Errorf("failed to load undo counts: %w", err)
+	}
+	counts[strings.ToLower(name)]++
+	if err := b.store.Put(undoCountsKey, counts); err != nil {
+		return 0, fmt.Errorf("failed to save undo counts: %w", err)
+	}
+	return counts[strings.ToLower(name)], nil
+}
+
+// resetUndos forgets the undone replacements of the custom emoji named name,
+// so it takes as many again to be offered for exclusion.
+func (b *Bot) resetUndos(name string) error {
+	b.undosMu.Lock()
+	defer b.undosMu.Unlock()
+	counts := make(map[string]int)
+	if _, err := b.store.Get(undoCountsKey, &counts); err != nil {
+		return fmt.Errorf("failed to load undo counts: %w", err)
+	}
+	delete(counts, strings.ToLower(name))
+	if err := b.store.Put(undoCountsKey, counts); err != nil {
+		return fmt.Errorf("failed to save undo counts: %w", err)
+	}
+	return nil
+}
+
+// suggestExclusion offers the admin channel to stop replacing the custom
+// emoji tag, named name, after n undone replacements.
+func (b *Bot) suggestExclusion(s Session, tag, name string, n int) {
+	logger.Info("suggesting emoji exclusion", "emoji", tag, "undos", n)
+	p := b.printer(b.config.GuildID)
+	b.postExclusion(s, p.T("exclusions.suggest", tag, n), discordgo.Button{
+		Label: p.T("exclusions.button.add", name), Style: discordgo.PrimaryButton, CustomID: excludeAddPrefix + name,
+	})
+}
+
+// excludeLearned stops replacing the custom emoji tag, named name, after n
+// undone replacements, and tells the admin channel with a button to take it
+// back.
+func (b *Bot) excludeLearned(s Session, tag, name string, n int) {
+	if err := b.settings.SetExcluded(b.config.GuildID, name, true); err != nil {
+		logger.Error("failed to exclude emoji", "emoji", tag, "error", err)
+		return
+	}
+	logger.Info("emoji excluded", "emoji", tag, "undos", n, "by", "undo learning")
+	p := b.printer(b.config.GuildID)
+	b.postExclusion(s, p.T("exclusions.auto", tag, n), discordgo.Button{
+		Label: p.T("exclusions.button.remove", name), Style: discordgo.SecondaryButton, CustomID: excludeRemovePrefix + name,
+	})
+}
+
+// postExclusion posts text with button to the admin channel.
+func (b *Bot) postExclusion(s Session, text string, button discordgo.Button) {
+	err := b.executor.Do(context.Background(), actions.Action{
+		Kind:      actions.Post,
+		ChannelID: b.config.AdminChannelID,
+		Do: func(...discordgo.RequestOption) error {
+			_, err := s.ChannelMessageSendComplex(b.config.AdminChannelID, &discordgo.MessageSend{
+				Content: text,
+				Components: []discordgo.MessageComponent{
+					discordgo.ActionsRow{Components: []discordgo.MessageComponent{button}},
+				},
+			})
+			return err
+		},
+	})
+	if err != nil && !actions.Skipped(err) {
+		logger.Error("failed to post emoji exclusion", "channel_id", b.config.AdminChannelID, "error", err)
+	}
+}
+
+// handleExclusionButton excludes the custom emoji a suggestion offered to
+// exclude, or stops excluding one the bot excluded itself.
+func (b *Bot) handleExclusionButton(s Session, i *discordgo.InteractionCreate, customID string) {
+	p := b.printer(i.GuildID)
+	if i.Member == nil || i.Member.Permissions&panelPermissions == 0 {
+		b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, textReply(p.T("exclusions.denied")))
+		return
+	}
+
+	exclude := strings.HasPrefix(customID, excludeAddPrefix)
+	name := strings.TrimPrefix(strings.TrimPrefix(customID, excludeAddPrefix), excludeRemovePrefix)
+	if err := b.settings.SetExcluded(i.GuildID, name, exclude); err != nil {
+		logger.Error("failed to change emoji exclusion", "emoji", name, "error", err)
+		b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, textReply(p.T("command.error", err)))
+		return
+	}
+	if !exclude {
+		if err := b.resetUndos(name); err != nil {
+			logger.Warn("failed to reset undo count", "emoji", name, "error", err)
+		}
+	}
+	by := interactionUserID(i)
+	logger.Info("emoji exclusion changed", "emoji", name, "excluded", exclude, "by", by)
+
+	reply := textReply(p.T("exclusions.added", name, by))
+	if !exclude {
+		reply = textReply(p.T("exclusions.removed", name, by))
+	}
+	reply.AllowedMentions = &discordgo.MessageAllowedMentions{}
+	b.respond(s, i, discordgo.InteractionResponseUpdateMessage, reply)
+}
@@ -0,0 +1,151 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/flags"
+	"jolly-okurb/internal/store"
+)
+
+func TestBot_LearnFromUndo(t *testing.T) {
+	const candy = "<:skullcandy:555>"
+	newBot := func(auto bool) *Bot {
+		cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+		cfg.GuildID = "100"
+		cfg.AdminChannelID = "admin"
+		cfg.UndoExcludeSuggest = 2
+		cfg.UndoExcludeAuto = auto
+		b := New(cfg, WithStore(store.NewMemory()))
+		b.replaced.add("300", candy)
+		b.replaced.add("300", "💀")
+		b.replaced.add("301", candy)
+		return b
+	}
+	// press presses the button of the last post in the admin channel.
+	press := func(t *testing.T, b *Bot, mock *mockSession) {
+		t.Helper()
+		post := mock.sent[len(mock.sent)-1]
+		i := newComponentInteraction("100", post.components[0].(discordgo.ActionsRow).Components[0].(discordgo.Button).CustomID)
+		i.Member.Permissions = discordgo.PermissionManageGuild
+		b.HandleInteraction(mock, i)
+	}
+
+	t.Run("suggests an exclusion", func(t *testing.T) {
+		b := newBot(false)
+		mock := &mockSession{}
+
+		b.learnFromUndo(mock, "300")
+		if len(mock.sent) != 0 {
+			t.Fatalf("sent %+v after one undo, want nothing below the threshold", mock.sent)
+		}
+		b.learnFromUndo(mock, "301")
+		if len(mock.sent) != 1 || mock.sent[0].channelID != "admin" || !strings.Contains(mock.sent[0].content, candy) {
+			t.Fatalf("sent %+v, want one suggestion naming the emoji in the admin channel", mock.sent)
+		}
+		if _, ok := b.ruleFor("skullcandy"); !ok {
+			t.Error("the emoji should still be replaced until the suggestion is approved")
+		}
+
+		press(t, b, mock)
+
+		if _, ok := b.ruleFor("SkullCandy"); ok {
+			t.Error("an excluded emoji should match no rule")
+		}
+		if _, ok := b.ruleFor("skull"); !ok {
+			t.Error("other emojis should still be replaced")
+		}
+		if got := lastResponse(t, mock); !strings.Contains(got, "<@admin1>") {
+			t.Errorf("update %q should say who approved it", got)
+		}
+	})
+
+	t.Run("excludes automatically", func(t *testing.T) {
+		b := newBot(true)
+		mock := &mockSession{}
+
+		b.learnFromUndo(mock, "300")
+		b.learnFromUndo(mock, "301")
+
+		if _, ok := b.ruleFor("skullcandy"); ok {
+			t.Error("the emoji should be excluded once it reaches the threshold")
+		}
+		if len(mock.sent) != 1 {
+			t.Fatalf("sent %+v, want one notice", mock.sent)
+		}
+
+		press(t, b, mock)
+
+		if _, ok := b.ruleFor("skullcandy"); !ok {
+			t.Error("replacing again should lift the exclusion")
+		}
+		b.learnFromUndo(mock, "300")
+		if len(mock.sent) != 1 {
+			t.Error("replacing again should reset the undo count")
+		}
+	})
+
+	t.Run("suggests while the auto_exclude flag is off", func(t *testing.T) {
+		b := newBot(true)
+		off := false
+		b.flags.Override("100", flags.AutoExclude, &off)
+		mock := &mockSession{}
+
+		b.learnFromUndo(mock, "300")
+		b.learnFromUndo(mock, "301")
+
+		if _, ok := b.ruleFor("skullcandy"); !ok {
+			t.Error("the emoji should not be excluded without the flag")
+		}
+		if len(mock.sent) != 1 || !strings.HasPrefix(mock.sent[0].components[0].(discordgo.ActionsRow).Components[0].(discordgo.Button).CustomID, excludeAddPrefix) {
+			t.Errorf("sent %+v, want a suggestion to exclude it", mock.sent)
+		}
+	})
+
+	t.Run("unicode emojis and unknown messages", func(t *testing.T) {
+		b := newBot(true)
+		b.config.UndoExcludeSuggest = 1
+		mock := &mockSession{}
+
+		b.learnFromUndo(mock, "302")
+		b.replaced = replacedEmojis{}
+		b.replaced.add("300", "💀")
+		b.learnFromUndo(mock, "300")
+
+		if len(mock.sent) != 0 || len(b.guildSettings().Excluded) != 0 {
+			t.Errorf("sent %+v, excluded %v, want nothing learned", mock.sent, b.guildSettings().Excluded)
+		}
+	})
+
+	t.Run("buttons need Manage Server", func(t *testing.T) {
+		b := newBot(false)
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newComponentInteraction("100", excludeAddPrefix+"skullcandy"))
+
+		if got := lastResponse(t, mock); !strings.Contains(got, "Manage Server") {
+			t.Errorf("reply %q should name the missing permission", got)
+		}
+		if _, ok := b.ruleFor("skullcandy"); !ok {
+			t.Error("a member without permission should not exclude anything")
+		}
+	})
+}
+
+func TestReplacedEmojis(t *testing.T) {
+	var r replacedEmojis
+	for i := range reactedCapacity + 1 {
+		r.add(string(rune('a'+i%26))+strings.Repeat("x", i/26), "💀")
+	}
+	r.add("b", "💀")
+	r.add("b", "☠️")
+
+	if got := r.get("a"); got != nil {
+		t.Errorf("get(oldest) = %v, want it evicted", got)
+	}
+	if got := strings.Join(r.get("b"), ","); got != "💀,☠️" {
+		t.Errorf("get(b) = %q, want each emoji once", got)
+	}
+}
@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/metrics"
+	"jolly-okurb/internal/settings"
+)
+
+// IsMixedMessage reports whether content has skull emojis alongside other
+// content, such as jollyskulls or text, so it is not skull-only.
+func (b *Bot) IsMixedMessage(content string) bool {
+	return len(b.skullEmojisIn(content)) > 0 && !b.IsSkullOnlyMessage(content)
+}
+
+// jollified returns content with every emoji a rule replaces swapped for its
+// replacement, such as skulls for jollyskull.
+func (b *Bot) jollified(content string) string {
+	replace := func(name, emoji string) string {
+		if rule, ok := b.ruleFor(name); ok {
+			return emojiTag(b.replacementOf(rule))
+		}
+		return emoji
+	}
+	content = customEmojiPattern.ReplaceAllStringFunc(content, func(tag string) string {
+		return replace(strings.Split(tag, ":")[1], tag)
+	})
+	for _, emoji := range b.unicodeEmojis() {
+		content = strings.ReplaceAll(content, emoji, replace(emoji, emoji))
+	}
+	return content
+}
+
+// mixedPolicyFor returns what to do with m under the guild's mixed content
+// policy, or MixedIgnore if m is not a target user's mixed message.
+func (b *Bot) mixedPolicyFor(m *discordgo.MessageCreate) settings.MixedPolicy {
+	g := b.guildSettings()
+	policy := g.MixedPolicy()
+	if policy == settings.MixedIgnore || g.Paused || !b.isWatched(m.GuildID, m.ChannelID) {
+		return settings.MixedIgnore
+	}
+	if m.Author == nil || !b.IsTargetUser(m.Author.ID) || g.IsExempt(m.ID) || !b.IsMixedMessage(m.Content) {
+		return settings.MixedIgnore
+	}
+	return policy
+}
+
+// HandleMixedMessage applies the mixed content policy to m and reports
+// whether it acted. In dry-run mode it only logs what it would do.
+func (b *Bot) HandleMixedMessage(ctx context.Context, s Session, m *discordgo.MessageCreate) bool {
+	policy := b.mixedPolicyFor(m)
+	if policy == settings.MixedIgnore {
+		return false
+	}
+	action := actions.Action{Policy: policyMixed, ChannelID: m.ChannelID, MessageID: m.ID, UserID: m.Author.ID}
+	switch policy {
+	case settings.MixedReact:
+		action.Kind = actions.AddReaction
+		action.Do = func(...discordgo.RequestOption) error {
+			return s.MessageReactionAdd(m.ChannelID, m.ID, b.jollyEmoji())
+		}
+	case settings.MixedNotify:
+		action.Kind = actions.Post
+		action.Do = func(...discordgo.RequestOption) error {
+			_, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+				Content:         b.printer(m.GuildID).T("mixed.suggest", b.jollified(m.Content)),
+				Reference:       m.Reference(),
+				AllowedMentions: &discordgo.MessageAllowedMentions{}, // Quote the message without pinging anyone
+			})
+			return err
+		}
+	}
+	err := b.executor.Do(ctx, action)
+	if actions.Skipped(err) {
+		return false
+	}
+	if err != nil {
+		rulesLog.ErrorContext(ctx, "failed to apply mixed content policy", "message_id", m.ID, "policy", policy, "error", err)
+		return false
+	}
+	rulesLog.DebugContext(ctx, "applied mixed content policy", "message_id", m.ID, "policy", policy)
+	metrics.Incr(b.sink(), metrics.MixedContentActions, "policy:"+string(policy))
+	return true
+}
@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/settings"
+)
+
+func TestBot_IsMixedMessage(t *testing.T) {
+	b := &Bot{config: newTestConfig(nil, "jollyskull:1")}
+
+	tests := []struct {
+		content  string
+		expected bool
+	}{
+		{"💀<:jollyskull:1>", true},
+		{"💀 that was funny", true},
+		{"<:deadskull:2> <:pog:3>", true},
+		{"💀💀", false},
+		{"<:jollyskull:1>", false},
+		{"no skulls here", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			if got := b.IsMixedMessage(tt.content); got != tt.expected {
+				t.Errorf("IsMixedMessage(%q) = %v, want %v", tt.content, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBot_Jollified(t *testing.T) {
+	b := &Bot{config: newTestConfig(nil, "jollyskull:1")}
+
+	got := b.jollified("☠️ lol 💀 <:deadskull:2> <:jollyskull:1> <:pog:3>")
+	want := "<:jollyskull:1> lol <:jollyskull:1> <:jollyskull:1> <:jollyskull:1> <:pog:3>"
+	if got != want {
+		t.Errorf("jollified() = %q, want %q", got, want)
+	}
+}
+
+func TestBot_HandleMixedMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    settings.MixedPolicy
+		dryRun    bool
+		author    string
+		content   string
+		wantAdded int
+		wantSent  string
+	}{
+		{name: "ignores by default", author: "target-user", content: "💀<:jollyskull:1>"},
+		{name: "reacts", policy: settings.MixedReact, author: "target-user", content: "💀<:jollyskull:1>", wantAdded: 1},
+		{name: "suggests an edit", policy: settings.MixedNotify, author: "target-user", content: "💀 lol", wantSent: "Jollier: <:jollyskull:1> lol"},
+		{name: "ignores other users", policy: settings.MixedReact, author: "other-user", content: "💀<:jollyskull:1>"},
+		{name: "ignores skull-only messages", policy: settings.MixedReact, author: "target-user", content: "💀"},
+		{name: "dry run only logs", policy: settings.MixedReact, dryRun: true, author: "target-user", content: "💀 lol"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig([]string{"target-user"}, "jollyskull:1")
+			cfg.GuildID = "guild123"
+			cfg.DryRun = tt.dryRun
+			b := New(cfg)
+			b.channels = map[string]string{"chan1": "jollyposting"}
+			b.ready = true
+			if tt.policy != "" {
+				if err := b.settings.SetMixedPolicy("guild123", tt.policy); err != nil {
+					t.Fatal(err)
+				}
+			}
+			mock := &mockSession{}
+
+			b.HandleMixedMessage(context.Background(), mock, &discordgo.MessageCreate{Message: &discordgo.Message{
+				ID:        "msg1",
+				ChannelID: "chan1",
+				GuildID:   "guild123",
+				Content:   tt.content,
+				Author:    &discordgo.User{ID: tt.author},
+			}})
+
+			if len(mock.addedReactions) != tt.wantAdded {
+				t.Errorf("expected %d reactions, got %v", tt.wantAdded, mock.addedReactions)
+			}
+			switch {
+			case tt.wantSent == "" && len(mock.sent) != 0:
+				t.Errorf("expected no reply, got %+v", mock.sent)
+			case tt.wantSent != "" && (len(mock.sent) != 1 || mock.sent[0].content != tt.wantSent):
+				t.Errorf("expected reply %q, got %+v", tt.wantSent, mock.sent)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/metrics"
+	"jolly-okurb/internal/schedule"
+	"jolly-okurb/internal/settings"
+)
+
+// jobDeleteNotice is the scheduled job kind that deletes a deletion notice
+// once it has been up for DELETION_NOTICE_TTL.
+const jobDeleteNotice = "delete_notice"
+
+// noticeJob identifies the deletion notice a job deletes.
+type noticeJob struct {
+	ChannelID string `json:"channel_id"`
+	MessageID string `json:"message_id"`
+}
+
+// noticeData holds the fields of the DELETION_NOTICE template.
+type noticeData struct {
+	User      string // Mention of the author of the deleted message
+	UserID    string
+	Channel   string // Mention of the channel
+	ChannelID string
+}
+
+// noticeCooldowns remembers when each channel last got a deletion notice.
+type noticeCooldowns struct {
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// allow reports whether channelID may get a notice at now, at least cooldown
+// after its last one, and if so counts now as its last one.
+func (c *noticeCooldowns) allow(channelID string, now time.Time, cooldown time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if last, ok := c.last[channelID]; ok && now.Sub(last) < cooldown {
+		return false
+	}
+	if c.last == nil {
+		c.last = make(map[string]time.Time)
+	}
+	c.last[channelID] = now
+	return true
+}
+
+// parseDeletionNotice compiles the DELETION_NOTICE template, or returns nil if
+// there is none. Config validates it, so an invalid one only disables the
+// notices.
+func parseDeletionNotice(text string) *template.Template {
+	if text == "" {
+		return nil
+	}
+	tmpl, err := template.New("notice").Parse(text)
+	if err != nil {
+		logger.Warn("invalid deletion notice template, not posting notices", "error", err)
+		return nil
+	}
+	return tmpl
+}
+
+// explainDeletion posts DELETION_NOTICE in the channel of m, a skull-only
+// message the bot just deleted, so its disappearance is not a mystery. The
+// notice deletes itself after DELETION_NOTICE_TTL. Channels get at most one
+// per DELETION_NOTICE_COOLDOWN, so a burst of deletions does not turn into a
+// burst of notices.
+func (b *Bot) explainDeletion(ctx context.Context, s Session, channelID, authorID string) {
+	if b.noticeTemplate == nil || !b.featureEnabled(settings.FeatureNotice) {
+		return
+	}
+	if !b.notices.allow(channelID, time.Now(), b.config.DeletionNoticeCooldown) {
+		logger.DebugContext(ctx, "deletion notice cooling down", "channel_id", channelID)
+		return
+	}
+
+	var sb strings.Builder
+	data := noticeData{User: "<@" + authorID + ">", UserID: authorID, Channel: "<#" + channelID + ">", ChannelID: channelID}
+	if err := b.noticeTemplate.Execute(&sb, data); err != nil {
+		logger.WarnContext(ctx, "failed to render deletion notice", "channel_id", channelID, "error", err)
+		return
+	}
+
+	var notice *discordgo.Message
+	err := b.executor.Do(ctx, actions.Action{
+		Kind:      actions.Post,
+		ChannelID: channelID,
+		Do: func(...discordgo.RequestOption) error {
+			var err error
+			notice, err = s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+				Content:         sb.String(),
+				AllowedMentions: &discordgo.MessageAllowedMentions{}, // Name the author without pinging them again
+			})
+			return err
+		},
+	})
+	if actions.Skipped(err) {
+		return
+	}
+	if err != nil {
+		logger.WarnContext(ctx, "failed to post deletion notice", "channel_id", channelID, "error", err)
+		return
+	}
+	metrics.Incr(b.sink(), metrics.DeletionNotices)
+
+	job, err := schedule.NewJob(jobDeleteNotice+":"+notice.ID, jobDeleteNotice, time.Now().Add(b.config.DeletionNoticeTTL),
+		noticeJob{ChannelID: channelID, MessageID: notice.ID})
+	if err == nil {
+		err = b.scheduler.Schedule(job)
+	}
+	if err != nil {
+		logger.WarnContext(ctx, "failed to schedule deleting the deletion notice", "message_id", notice.ID, "error", err)
+	}
+}
+
+// deleteNotice deletes a deletion notice whose time is up. One deleted by
+// hand already is fine.
+func (b *Bot) deleteNotice(ctx context.Context, s Session, channelID, messageID string) error {
+	err := b.executor.Do(ctx, actions.Action{
+		Kind:      actions.DeleteMessage,
+		ChannelID: channelID,
+		MessageID: messageID,
+		Do: func(...discordgo.RequestOption) error {
+			return s.ChannelMessageDelete(channelID, messageID)
+		},
+	})
+	if err != nil && !isNotFound(err) && !actions.Skipped(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/metrics"
+	"jolly-okurb/internal/settings"
+)
+
+func TestBot_ExplainDeletion(t *testing.T) {
+	newBot := func(notice string) (*Bot, *recordingSink) {
+		cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+		cfg.GuildID = "guild123"
+		cfg.DeletionNotice = notice
+		cfg.DeletionNoticeTTL = time.Hour
+		cfg.DeletionNoticeCooldown = time.Minute
+		sink := newRecordingSink()
+		return New(cfg, WithMetrics(sink)), sink
+	}
+	skullOnly := func(channelID, messageID string) *discordgo.Message {
+		return &discordgo.Message{ID: messageID, ChannelID: channelID, Content: "💀", Author: &discordgo.User{ID: "target-user"}}
+	}
+
+	t.Run("posts the notice and schedules its deletion", func(t *testing.T) {
+		b, sink := newBot("A skull-only post by {{.User}} in {{.Channel}} was jollified 🎄")
+		mock := &mockSession{}
+
+		if !b.removeMessage(context.Background(), mock, skullOnly("chan1", "msg1")) {
+			t.Fatal("removeMessage() did not delete the message")
+		}
+
+		if len(mock.sent) != 1 || mock.sent[0].channelID != "chan1" {
+			t.Fatalf("sent %+v, want one notice in chan1", mock.sent)
+		}
+		if want := "A skull-only post by <@target-user> in <#chan1> was jollified 🎄"; mock.sent[0].content != want {
+			t.Errorf("notice = %q, want %q", mock.sent[0].content, want)
+		}
+		if n := b.scheduler.Pending(); n != 1 {
+			t.Errorf("%d pending jobs, want the notice's deletion", n)
+		}
+		if sink.counts[metrics.DeletionNotices] != 1 {
+			t.Errorf("%s = %d, want 1", metrics.DeletionNotices, sink.counts[metrics.DeletionNotices])
+		}
+	})
+
+	t.Run("cooldown is per channel", func(t *testing.T) {
+		b, _ := newBot("jollified")
+		mock := &mockSession{}
+
+		b.removeMessage(context.Background(), mock, skullOnly("chan1", "msg1"))
+		b.removeMessage(context.Background(), mock, skullOnly("chan1", "msg2"))
+		b.removeMessage(context.Background(), mock, skullOnly("chan2", "msg3"))
+
+		if len(mock.deleted) != 3 {
+			t.Errorf("deleted %v, want every message deleted", mock.deleted)
+		}
+		if len(mock.sent) != 2 || mock.sent[0].channelID != "chan1" || mock.sent[1].channelID != "chan2" {
+			t.Errorf("sent %+v, want one notice in each channel", mock.sent)
+		}
+	})
+
+	t.Run("switched off for the server", func(t *testing.T) {
+		b, _ := newBot("jollified")
+		b.settings.SetFeature("guild123", settings.FeatureNotice, false)
+		mock := &mockSession{}
+
+		b.removeMessage(context.Background(), mock, skullOnly("chan1", "msg1"))
+
+		if len(mock.sent) != 0 {
+			t.Errorf("sent %+v, want no notice", mock.sent)
+		}
+	})
+
+	t.Run("no template", func(t *testing.T) {
+		b, _ := newBot("")
+		mock := &mockSession{}
+
+		b.removeMessage(context.Background(), mock, skullOnly("chan1", "msg1"))
+
+		if len(mock.sent) != 0 || b.scheduler.Pending() != 0 {
+			t.Errorf("sent %+v, want no notice", mock.sent)
+		}
+	})
+
+	t.Run("dry run posts nothing", func(t *testing.T) {
+		b, _ := newBot("jollified")
+		b.config.DryRun = true
+		mock := &mockSession{}
+
+		b.removeMessage(context.Background(), mock, skullOnly("chan1", "msg1"))
+
+		if len(mock.sent) != 0 {
+			t.Errorf("sent %+v, want no notice for a deletion that did not happen", mock.sent)
+		}
+	})
+}
+
+func TestBot_DeleteNotice(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{"deleted", nil, false},
+		{"already gone", &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusNotFound}}, false},
+		{"forbidden", &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusForbidden}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(newTestConfig(nil, "jollyskull:123"))
+			mock := &mockSession{deleteErr: tt.err}
+
+			err := b.deleteNotice(context.Background(), mock, "chan1", "sent1")
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("deleteNotice() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(mock.deleted) != 1 || mock.deleted[0] != "sent1" {
+				t.Errorf("deleted %v, want the notice", mock.deleted)
+			}
+		})
+	}
+}
+
+func TestBot_DeletionNoticeScheduled(t *testing.T) {
+	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	cfg.DeletionNotice = "jollified"
+	cfg.DeletionNoticeTTL = 10 * time.Millisecond
+	b := New(cfg)
+	mock := &mockSession{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.startScheduler(ctx, mock)
+	b.removeMessage(context.Background(), mock, &discordgo.Message{ID: "msg1", ChannelID: "chan1", Content: "💀"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for b.scheduler.Pending() > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	b.background.Wait()
+
+	if len(mock.deleted) != 2 || mock.deleted[1] != "sent1" {
+		t.Errorf("deleted %v, want the message and then the notice", mock.deleted)
+	}
+}
@@ -0,0 +1,188 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/i18n"
+	"jolly-okurb/internal/settings"
+)
+
+// Custom IDs of the control panel buttons. They share panelPrefix so
+// presses are routed to handlePanel.
+const (
+	panelPrefix   = "jolly:panel:"
+	panelPauseID  = panelPrefix + "pause"
+	panelDryRunID = panelPrefix + "dryrun"
+	panelRescanID = panelPrefix + "rescan"
+)
+
+// panelPermissions are the permissions, any of which lets a member press
+// the control panel buttons. The panel is a plain message, so unlike
+// /jolly it is not hidden from other members.
+const panelPermissions = discordgo.PermissionManageGuild | discordgo.PermissionAdministrator
+
+// panel renders the control panel with the guild's current state.
+func (b *Bot) panel() (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	g := b.guildSettings()
+	p := i18n.For(g.Locale)
+	dryRun := g.DryRunFor("", b.config.DryRun)
+
+	state, color, pauseLabel, pauseStyle := p.T("panel.running"), colorSuccess, p.T("panel.button.pause"), discordgo.DangerButton
+	if g.Paused {
+		state, color, pauseLabel, pauseStyle = p.T("panel.paused"), colorWarning, p.T("panel.button.resume"), discordgo.SuccessButton
+	}
+	scan := p.T("panel.scan.idle")
+	if b.isHistoricalRunning() {
+		scan = p.T("panel.scan.running")
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: p.T("panel.title"),
+		Color: color,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: p.T("panel.state"), Value: state, Inline: true},
+			{Name: p.T("startup.dryrun"), Value: formatToggle(p, dryRun), Inline: true},
+			{Name: p.T("startup.channels"), Value: fmt.Sprint(len(b.monitoredChannelIDs())), Inline: true},
+			{Name: p.T("panel.scan"), Value: scan, Inline: true},
+		},
+	}
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: pauseLabel, Style: pauseStyle, CustomID: panelPauseID},
+			discordgo.Button{Label: p.T("panel.button.dryrun", formatToggle(p, !dryRun)), Style: discordgo.SecondaryButton, CustomID: panelDryRunID},
+			discordgo.Button{
+				Label:    p.T("rescan.button.start"),
+				Style:    discordgo.PrimaryButton,
+				CustomID: panelRescanID,
+				Disabled: !g.Enabled(settings.FeatureHistorical),
+			},
+		}},
+	}
+	return embed, components
+}
+
+// panelReply renders the control panel as the content of an interaction
+// reply, as plain text if the guild prefers it.
+func (b *Bot) panelReply() *discordgo.InteractionResponseData {
+	embed, components := b.panel()
+	if b.guildSettings().PlainOutput() {
+		return &discordgo.InteractionResponseData{Content: plainText(embed), Components: components}
+	}
+	return &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}, Components: components}
+}
+
+// handlePanel posts the control panel to the admin channel and pins it.
+func (b *Bot) handlePanel(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	p := b.printer(i.GuildID)
+	if b.config.AdminChannelID == "" {
+		return textReply(p.T("panel.no_channel")), nil
+	}
+
+	data := b.panelReply()
+	var msg *discordgo.Message
+	err := b.executor.Do(context.Background(), actions.Action{
+		Kind:      actions.Post,
+		ChannelID: b.config.AdminChannelID,
+		Do: func(...discordgo.RequestOption) (err error) {
+			msg, err = s.ChannelMessageSendComplex(b.config.AdminChannelID, &discordgo.MessageSend{
+				Content:    data.Content,
+				Embeds:     data.Embeds,
+				Components: data.Components,
+			})
+			return err
+		},
+	})
+	if errors.Is(err, actions.ErrReadOnly) {
+		return textReply(p.T("read_only")), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to post control panel: %w", err)
+	}
+	err = b.executor.Do(context.Background(), actions.Action{
+		Kind:      actions.Pin,
+		ChannelID: b.config.AdminChannelID,
+		MessageID: msg.ID,
+		Do: func(...discordgo.RequestOption) error {
+			return s.ChannelMessagePin(b.config.AdminChannelID, msg.ID)
+		},
+	})
+	if err != nil {
+		// The panel works unpinned; it is only harder to find
+		logger.Warn("failed to pin control panel", "channel_id", b.config.AdminChannelID, "message_id", msg.ID, "error", err)
+	}
+	logger.Info("control panel posted", "channel_id", b.config.AdminChannelID, "message_id", msg.ID, "by", interactionUserID(i))
+	return textReply(p.T("panel.posted", b.config.AdminChannelID)), nil
+}
+
+// handlePanelButton handles a press on the control panel. Toggles update
+// the panel in place; everything else is answered privately so the panel
+// itself is never replaced.
+func (b *Bot) handlePanelButton(s Session, i *discordgo.InteractionCreate, customID string) {
+	p := b.printer(i.GuildID)
+	if i.Member == nil || i.Member.Permissions&panelPermissions == 0 {
+		b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, textReply(p.T("panel.denied")))
+		return
+	}
+
+	if customID == panelRescanID {
+		reply, err := b.runHandler("component "+customID, func() (*discordgo.InteractionResponseData, error) {
+			return b.handleRescan(s, i, nil)
+		})
+		if err != nil {
+			reply = textReply(p.T("command.error", err))
+		}
+		b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, reply)
+		return
+	}
+
+	reply, err := b.runHandler("component "+customID, func() (*discordgo.InteractionResponseData, error) {
+		if err := b.togglePanel(i, customID); err != nil {
+			return nil, err
+		}
+		return b.panelReply(), nil
+	})
+	if err != nil {
+		logger.Error("control panel action failed", "custom_id", customID, "error", err)
+		b.respond(s, i, discordgo.InteractionResponseChannelMessageWithSource, textReply(p.T("command.error", err)))
+		return
+	}
+	b.respond(s, i, discordgo.InteractionResponseUpdateMessage, reply)
+}
+
+// togglePanel flips the setting behind a control panel toggle button.
+func (b *Bot) togglePanel(i *discordgo.InteractionCreate, customID string) error {
+	g, err := b.settings.Guild(i.GuildID)
+	if err != nil {
+		return err
+	}
+
+	switch customID {
+	case panelPauseID:
+		if err := b.settings.SetPaused(i.GuildID, !g.Paused); err != nil {
+			return err
+		}
+		logger.Info("pause toggled from control panel", "guild_id", i.GuildID, "paused", !g.Paused, "by", interactionUserID(i))
+	case panelDryRunID:
+		dryRun := !g.DryRunFor("", b.config.DryRun)
+		if err := b.settings.SetDryRun(i.GuildID, "", &dryRun); err != nil {
+			return err
+		}
+		logger.Info("dry run toggled from control panel", "guild_id", i.GuildID, "dry_run", dryRun, "by", interactionUserID(i))
+	default:
+		return fmt.Errorf("unknown control panel button %q", strings.TrimPrefix(customID, panelPrefix))
+	}
+	return nil
+}
+
+// isHistoricalRunning reports whether a historical scan is in progress.
+func (b *Bot) isHistoricalRunning() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.historicalRunning
+}
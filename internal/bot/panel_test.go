@@ -0,0 +1,133 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/settings"
+)
+
+// newPanelPress builds a press of a control panel button by a member with perms.
+func newPanelPress(customID string, perms int64) *discordgo.InteractionCreate {
+	i := newComponentInteraction("guild123", customID)
+	i.Member.Permissions = perms
+	i.Message = &discordgo.Message{ID: "panel1", ChannelID: "admin"}
+	return i
+}
+
+func TestBot_HandlePanel(t *testing.T) {
+	t.Run("posts and pins the panel", func(t *testing.T) {
+		cfg := newTestConfig(nil, "jollyskull:1")
+		cfg.GuildID = "guild123"
+		cfg.AdminChannelID = "admin"
+		b := New(cfg)
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"panel"}))
+
+		if got := lastResponse(t, mock); got != "Control panel posted and pinned in <#admin>." {
+			t.Errorf("response = %q", got)
+		}
+		if len(mock.sent) != 1 || mock.sent[0].channelID != "admin" || mock.sent[0].embed == nil {
+			t.Fatalf("expected the panel in the admin channel, got %+v", mock.sent)
+		}
+		if len(mock.pinned) != 1 || mock.pinned[0] != "sent1" {
+			t.Errorf("expected the panel to be pinned, got %v", mock.pinned)
+		}
+	})
+
+	t.Run("needs an admin channel", func(t *testing.T) {
+		cfg := newTestConfig(nil, "jollyskull:1")
+		cfg.GuildID = "guild123"
+		b := New(cfg)
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"panel"}))
+
+		if got := lastResponse(t, mock); !strings.Contains(got, "DISCORD_ADMIN_CHANNEL_ID") {
+			t.Errorf("response %q should explain how to enable the panel", got)
+		}
+		if len(mock.sent) != 0 {
+			t.Error("nothing should be posted without an admin channel")
+		}
+	})
+}
+
+func TestBot_HandlePanelButton(t *testing.T) {
+	newBot := func() *Bot {
+		cfg := newTestConfig([]string{"user456"}, "jollyskull:1")
+		cfg.GuildID = "guild123"
+		b := New(cfg)
+		b.channels = map[string]string{"chan123": "jollyposting"}
+		b.ready = true
+		return b
+	}
+
+	t.Run("pause suspends every feature and updates the panel", func(t *testing.T) {
+		b := newBot()
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newPanelPress(panelPauseID, discordgo.PermissionManageGuild))
+
+		if b.featureEnabled(settings.FeatureReactions) || b.featureEnabled(settings.FeatureDeletion) {
+			t.Error("features should be suspended while paused")
+		}
+		resp := mock.responses[len(mock.responses)-1]
+		if resp.Type != discordgo.InteractionResponseUpdateMessage {
+			t.Errorf("response type = %v, want an update of the panel", resp.Type)
+		}
+		if resp.Data.Flags&discordgo.MessageFlagsEphemeral != 0 {
+			t.Error("the panel update must stay visible to everyone")
+		}
+		if len(resp.Data.Embeds) != 1 || resp.Data.Embeds[0].Fields[0].Value != "paused" {
+			t.Errorf("the panel should show the paused state, got %+v", resp.Data.Embeds)
+		}
+
+		b.HandleInteraction(mock, newPanelPress(panelPauseID, discordgo.PermissionManageGuild))
+		if !b.featureEnabled(settings.FeatureReactions) {
+			t.Error("a second press should resume")
+		}
+	})
+
+	t.Run("toggles dry run for the server", func(t *testing.T) {
+		b := newBot()
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newPanelPress(panelDryRunID, discordgo.PermissionAdministrator))
+
+		if !b.isDryRun("chan123") {
+			t.Error("dry run should be on after the press")
+		}
+	})
+
+	t.Run("rescan answers privately", func(t *testing.T) {
+		b := newBot()
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newPanelPress(panelRescanID, discordgo.PermissionManageGuild))
+
+		resp := mock.responses[len(mock.responses)-1]
+		if resp.Type != discordgo.InteractionResponseChannelMessageWithSource {
+			t.Errorf("response type = %v, want a new message so the panel stays", resp.Type)
+		}
+		if !strings.Contains(lastResponse(t, mock), "Rescanning 1 channel(s)") {
+			t.Errorf("response %q should show the rescan estimate", lastResponse(t, mock))
+		}
+	})
+
+	t.Run("rejects members without Manage Server", func(t *testing.T) {
+		b := newBot()
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newPanelPress(panelPauseID, discordgo.PermissionSendMessages))
+
+		if got := lastResponse(t, mock); got != "You need Manage Server to use the control panel." {
+			t.Errorf("response = %q", got)
+		}
+		if b.guildSettings().Paused {
+			t.Error("the press should not pause the bot")
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package bot
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/metrics"
+)
+
+// hourglassEmoji is the reaction PROGRESS_INDICATOR=reaction adds.
+const hourglassEmoji = "⏳"
+
+// progressDelay is how long a replacement may take before the bot shows it is
+// working on it. Replacements are quick unless rate limits slow them down, as
+// on heavily-reacted messages, so most never show anything.
+var progressDelay = 2 * time.Second
+
+// indicateProgress shows the configured progress indicator on a message if a
+// replacement on it is still under way after progressDelay. The returned stop
+// must be called once the replacement is done; it removes the hourglass if
+// one was added. Typing ends by itself once the bot acts.
+func (b *Bot) indicateProgress(ctx context.Context, s Session, channelID, messageID string) (stop func()) {
+	indicator := b.config.ProgressIndicator
+	if indicator == "" {
+		return func() {}
+	}
+
+	var shown atomic.Bool
+	fired := make(chan struct{})
+	timer := time.AfterFunc(progressDelay, func() {
+		defer close(fired)
+		a := actions.Action{Kind: actions.Typing, ChannelID: channelID, MessageID: messageID, Feedback: true}
+		a.Do = func(opts ...discordgo.RequestOption) error {
+			return s.ChannelTyping(channelID, opts...)
+		}
+		if indicator == config.IndicatorReaction {
+			a.Kind = actions.AddReaction
+			a.Do = func(opts ...discordgo.RequestOption) error {
+				return s.MessageReactionAdd(channelID, messageID, hourglassEmoji, opts...)
+			}
+		}
+		if err := b.executor.Do(ctx, a); err != nil {
+			logger.DebugContext(ctx, "failed to indicate progress", "message_id", messageID, "indicator", indicator, "error", err)
+			return
+		}
+		shown.Store(indicator == config.IndicatorReaction)
+		metrics.Incr(b.sink(), metrics.ProgressIndicated, "indicator:"+indicator)
+	})
+
+	return func() {
+		if timer.Stop() {
+			return
+		}
+		<-fired
+		if !shown.Load() {
+			return
+		}
+		err := b.executor.Do(ctx, actions.Action{
+			Kind:      actions.RemoveReaction,
+			ChannelID: channelID,
+			MessageID: messageID,
+			Feedback:  true,
+			Do: func(opts ...discordgo.RequestOption) error {
+				return s.MessageReactionRemove(channelID, messageID, hourglassEmoji, "@me", opts...)
+			},
+		})
+		if err != nil {
+			logger.WarnContext(ctx, "failed to remove progress indicator", "message_id", messageID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/metrics"
+)
+
+func TestBot_ReplaceReaction_Progress(t *testing.T) {
+	defer func(d time.Duration) { progressDelay = d }(progressDelay)
+	progressDelay = 5 * time.Millisecond
+
+	tests := []struct {
+		name          string
+		indicator     string
+		slow          bool
+		wantHourglass bool
+		wantTyping    bool
+	}{
+		{name: "off", slow: true},
+		{name: "quick replacement", indicator: config.IndicatorReaction},
+		{name: "hourglass", indicator: config.IndicatorReaction, slow: true, wantHourglass: true},
+		{name: "typing", indicator: config.IndicatorTyping, slow: true, wantTyping: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig([]string{"user123"}, "jollyskull:123")
+			cfg.ProgressIndicator = tt.indicator
+			sink := newRecordingSink()
+			b := New(cfg, WithMetrics(sink))
+			if tt.slow {
+				// The jitter holds up the replacement, not the indicator
+				b.executor = actions.New(actions.Policy{JitterMin: 50 * time.Millisecond, JitterMax: 50 * time.Millisecond})
+			}
+			mock := &mockSession{}
+
+			if !b.ReplaceReaction(context.Background(), mock, "chan1", "msg1", "user123", &discordgo.Emoji{Name: "💀"}) {
+				t.Fatal("ReplaceReaction() = false, want true")
+			}
+
+			added, removed := false, false
+			for _, r := range mock.addedReactions {
+				added = added || r.emojiID == hourglassEmoji
+			}
+			for _, r := range mock.removedReactions {
+				removed = removed || (r.emojiID == hourglassEmoji && r.userID == "@me")
+			}
+			if added != tt.wantHourglass || removed != tt.wantHourglass {
+				t.Errorf("hourglass added %v, removed %v, want %v", added, removed, tt.wantHourglass)
+			}
+			if typed := len(mock.typing) > 0; typed != tt.wantTyping {
+				t.Errorf("typed %v, want %v", typed, tt.wantTyping)
+			}
+			if want := tt.wantHourglass || tt.wantTyping; (sink.counts[metrics.ProgressIndicated] == 1) != want {
+				t.Errorf("%s = %d, want it counted %v", metrics.ProgressIndicated, sink.counts[metrics.ProgressIndicated], want)
+			}
+			if tt.wantHourglass && len(mock.addedReactions) != 2 {
+				t.Errorf("added %d reactions, want the hourglass and jollyskull", len(mock.addedReactions))
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/i18n"
+	"jolly-okurb/internal/settings"
+	"jolly-okurb/internal/stats"
+	"jolly-okurb/internal/web"
+)
+
+// Public stats page contents.
+const (
+	publicStatsDays        = 30
+	publicStatsLeaderboard = 10
+)
+
+// PublicStats returns the stats page the guild publishes under token, or nil
+// if it publishes none. Target users are anonymous unless the guild opted
+// into showing names, which come from the user cache so that page views
+// never call the Discord API.
+func (b *Bot) PublicStats(token string) (*web.StatsPage, error) {
+	g, err := b.settings.Guild(b.config.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	p := g.PublicStats
+	if p == nil || subtle.ConstantTimeCompare([]byte(p.Token), []byte(token)) != 1 {
+		return nil, nil
+	}
+
+	until := time.Now().UTC()
+	since := until.AddDate(0, 0, 1-publicStatsDays)
+	days, err := b.stats.Days(since, publicStatsDays)
+	if err != nil {
+		return nil, err
+	}
+	summary := stats.Summarize(days)
+
+	page := &web.StatsPage{
+		Since:    since,
+		Until:    until,
+		Replaced: summary.Replaced,
+		Messages: summary.Messages,
+		Repeats:  summary.Repeats,
+		Deleted:  summary.Deleted,
+		Heatmap:  stats.HeatmapOf(days),
+	}
+	for i, c := range summary.TopTargets[:min(publicStatsLeaderboard, len(summary.TopTargets))] {
+		entry := web.LeaderboardEntry{Name: fmt.Sprintf("Member #%d", i+1), Count: c.N}
+		if info, ok := b.cachedUser(c.Key); p.ShowNames && ok {
+			entry.Name, entry.AvatarURL = info.Name, info.AvatarURL
+		}
+		page.Leaderboard = append(page.Leaderboard, entry)
+	}
+	return page, nil
+}
+
+// publicStatsURL returns the link to a stats page, or only its path if
+// PUBLIC_URL is not set.
+func (b *Bot) publicStatsURL(token string) string {
+	return b.config.PublicURL + "/stats/" + token
+}
+
+func (b *Bot) handleConfigPublicStats(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	g, err := b.settings.Guild(i.GuildID)
+	if err != nil {
+		return nil, err
+	}
+
+	var p *settings.PublicStats
+	switch value := opts["value"].StringValue(); value {
+	case "off":
+	case "on", "rotate":
+		p = &settings.PublicStats{}
+		if g.PublicStats != nil {
+			*p = *g.PublicStats
+		}
+		if p.Token == "" || value == "rotate" {
+			p.Token = rand.Text()
+		}
+	default:
+		return nil, fmt.Errorf("expected on, off, or rotate, got %q", value)
+	}
+	if o, ok := opts["names"]; ok && p != nil {
+		p.ShowNames = o.BoolValue()
+	}
+
+	if err := b.settings.SetPublicStats(i.GuildID, p); err != nil {
+		return nil, err
+	}
+	logger.Info("public stats changed", "guild_id", i.GuildID, "public_stats", opts["value"].StringValue(), "by", interactionUserID(i))
+
+	pr := i18n.For(g.Locale)
+	if p == nil {
+		return textReply(pr.T("publicstats.off")), nil
+	}
+	reply := pr.T("publicstats.on", b.publicStatsURL(p.Token), formatToggle(pr, p.ShowNames))
+	if b.config.HTTPAddr == "" {
+		reply += "\n" + pr.T("publicstats.no_server")
+	}
+	return textReply(reply), nil
+}
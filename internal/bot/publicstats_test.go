@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/settings"
+)
+
+func TestBot_PublicStats(t *testing.T) {
+	cfg := newTestConfig([]string{"alice", "bob"}, "jollyskull:123")
+	cfg.GuildID = "guild123"
+
+	newBot := func(p *settings.PublicStats) *Bot {
+		b := New(cfg)
+		b.settings.SetPublicStats("guild123", p)
+		b.rememberUser(&discordgo.User{ID: "alice", Username: "alice_", GlobalName: "Alice"})
+		b.stats.RecordReplacement(time.Now(), "alice", "💀")
+		b.stats.RecordReplacement(time.Now(), "alice", "💀")
+		b.stats.RecordDeletion(time.Now(), "bob")
+		return b
+	}
+
+	tests := []struct {
+		name      string
+		published *settings.PublicStats
+		token     string
+		wantNames []string // nil if no page should be served
+	}{
+		{"not published", nil, "secret", nil},
+		{"wrong token", &settings.PublicStats{Token: "secret"}, "guess", nil},
+		{"anonymous by default", &settings.PublicStats{Token: "secret"}, "secret", []string{"Member #1", "Member #2"}},
+		{"names opted in", &settings.PublicStats{Token: "secret", ShowNames: true}, "secret", []string{"Alice", "Member #2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := newBot(tt.published).PublicStats(tt.token)
+			if err != nil {
+				t.Fatalf("PublicStats() unexpected error: %v", err)
+			}
+			if tt.wantNames == nil {
+				if page != nil {
+					t.Errorf("expected no page, got %+v", page)
+				}
+				return
+			}
+			if page == nil {
+				t.Fatal("expected a page, got none")
+			}
+			if page.Replaced != 2 || page.Deleted != 1 {
+				t.Errorf("Replaced, Deleted = %d, %d, want 2, 1", page.Replaced, page.Deleted)
+			}
+			var heatmap int
+			for _, hours := range page.Heatmap {
+				for _, n := range hours {
+					heatmap += n
+				}
+			}
+			if heatmap != 3 {
+				t.Errorf("heatmap holds %d actions, want 3", heatmap)
+			}
+			var names []string
+			for _, e := range page.Leaderboard {
+				names = append(names, e.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("leaderboard names = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestBot_ConfigPublicStats(t *testing.T) {
+	boolOption := func(name string, value bool) *discordgo.ApplicationCommandInteractionDataOption {
+		return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionBoolean, Value: value}
+	}
+	command := []string{"config", "publicstats"}
+	b := New(&config.Config{GuildID: "guild123", PublicURL: "https://jolly.example.com", HTTPAddr: ":8080"})
+	mock := &mockSession{}
+	publicStats := func() *settings.PublicStats {
+		g, _ := b.settings.Guild("guild123")
+		return g.PublicStats
+	}
+
+	b.HandleInteraction(mock, newCommandInteraction("guild123", command, stringOption("value", "on"), boolOption("names", true)))
+	first := publicStats()
+	if first == nil || first.Token == "" || !first.ShowNames {
+		t.Fatalf("PublicStats = %+v, want published with names", first)
+	}
+	if got := lastResponse(t, mock); !strings.Contains(got, "https://jolly.example.com/stats/"+first.Token) {
+		t.Errorf("response %q should link to the page", got)
+	}
+
+	b.HandleInteraction(mock, newCommandInteraction("guild123", command, stringOption("value", "on")))
+	if p := publicStats(); p.Token != first.Token || !p.ShowNames {
+		t.Error("turning on again should keep the link and names setting")
+	}
+
+	b.HandleInteraction(mock, newCommandInteraction("guild123", command, stringOption("value", "rotate")))
+	if p := publicStats(); p.Token == first.Token {
+		t.Error("rotate should replace the token")
+	}
+
+	b.HandleInteraction(mock, newCommandInteraction("guild123", command, stringOption("value", "off")))
+	if publicStats() != nil {
+		t.Error("off should unpublish the page")
+	}
+	if got := lastResponse(t, mock); got != "The public stats page is now off." {
+		t.Errorf("response = %q", got)
+	}
+}
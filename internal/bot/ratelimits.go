@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/metrics"
+)
+
+// statusTopRoutes is how many rate-limited routes /jolly status lists.
+const statusTopRoutes = 3
+
+// rateLimitCounter counts the 429 responses the bot hit since it started, per route.
+type rateLimitCounter struct {
+	mu     sync.Mutex
+	routes map[string]int
+}
+
+func (c *rateLimitCounter) add(route string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.routes == nil {
+		c.routes = make(map[string]int)
+	}
+	c.routes[route]++
+}
+
+// top returns the total count and up to n routes with the most hits, most first.
+func (c *rateLimitCounter) top(n int) (total int, routes []string, counts map[string]int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts = maps.Clone(c.routes)
+	for _, count := range counts {
+		total += count
+	}
+	routes = slices.SortedFunc(maps.Keys(counts), func(a, b string) int {
+		if counts[a] != counts[b] {
+			return counts[b] - counts[a]
+		}
+		return strings.Compare(a, b)
+	})
+	return total, routes[:min(n, len(routes))], counts
+}
+
+// OnRateLimit counts a 429 response. discordgo sends this event before it
+// waits out the limit and retries, when ShouldRetryOnRateLimit is set.
+func (b *Bot) OnRateLimit(s *discordgo.Session, r *discordgo.RateLimit) {
+	route := rateLimitRoute(r.URL)
+	b.rateLimits.add(route)
+	metrics.Incr(b.sink(), metrics.RateLimited, "route:"+route)
+
+	var retryAfter any
+	if r.TooManyRequests != nil {
+		retryAfter = r.RetryAfter
+	}
+	logger.Warn("hit Discord rate limit", "route", route, "retry_after", retryAfter)
+}
+
+// rateLimitRoute reduces a REST URL to its route, replacing IDs and emojis
+// with placeholders so that counts group by endpoint rather than by message.
+func rateLimitRoute(rawURL string) string {
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.Path
+	}
+	path = strings.TrimPrefix(path, "/api")
+	if rest, ok := strings.CutPrefix(path, "/v"); ok {
+		if _, after, ok := strings.Cut(rest, "/"); ok {
+			path = "/" + after
+		}
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, seg := range segments {
+		switch {
+		case i > 0 && segments[i-1] == "reactions":
+			segments[i] = ":emoji"
+		case isSnowflake(seg):
+			segments[i] = ":id"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
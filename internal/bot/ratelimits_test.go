@@ -0,0 +1,63 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/metrics"
+)
+
+func TestRateLimitRoute(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://discord.com/api/v9/channels/123/messages/456", "/channels/:id/messages/:id"},
+		{"https://discord.com/api/v9/channels/123/messages/456/reactions/%F0%9F%92%80/789", "/channels/:id/messages/:id/reactions/:emoji/:id"},
+		{"https://discord.com/api/v9/channels/123/messages/456/reactions/jollyskull:42/@me", "/channels/:id/messages/:id/reactions/:emoji/@me"},
+		{"https://discord.com/api/v9/guilds/123/channels", "/guilds/:id/channels"},
+		{"/channels/123/messages", "/channels/:id/messages"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := rateLimitRoute(tt.url); got != tt.expected {
+				t.Errorf("rateLimitRoute(%q) = %q, want %q", tt.url, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBot_OnRateLimit(t *testing.T) {
+	sink := newRecordingSink()
+	b := New(&config.Config{GuildID: "guild123"}, WithMetrics(sink))
+	hit := func(url string) {
+		b.OnRateLimit(nil, &discordgo.RateLimit{TooManyRequests: &discordgo.TooManyRequests{RetryAfter: time.Second}, URL: url})
+	}
+
+	mock := &mockSession{}
+	b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"status"}))
+	if got := lastResponse(t, mock); !strings.Contains(got, "No Discord rate limits hit since startup.") {
+		t.Errorf("response %q should report no rate limits", got)
+	}
+
+	for range 3 {
+		hit("https://discord.com/api/v9/channels/1/messages/2/reactions/x/@me")
+	}
+	hit("https://discord.com/api/v9/channels/1/messages/3")
+
+	if sink.counts[metrics.RateLimited] != 4 {
+		t.Errorf("expected 4 rate limits counted, got %d", sink.counts[metrics.RateLimited])
+	}
+	b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"status"}))
+	want := "Hit Discord rate limits 4 time(s) since startup, most on:\n" +
+		"- `/channels/:id/messages/:id/reactions/:emoji/@me`: 3\n" +
+		"- `/channels/:id/messages/:id`: 1\n"
+	if got := lastResponse(t, mock); !strings.HasSuffix(got, want) {
+		t.Errorf("response = %q, want it to end with %q", got, want)
+	}
+}
@@ -0,0 +1,147 @@
+package bot
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/metrics"
+)
+
+// reactedCapacity bounds how many messages the bot remembers having reacted to.
+const reactedCapacity = 4096
+
+// reactedSet remembers the most recent messages the bot has put a jollyskull
+// on, so repeated skulls and rescans do not send redundant add requests.
+type reactedSet struct {
+	mu    sync.Mutex
+	ids   map[string]struct{}
+	order []string // Insertion order, for evicting the oldest
+}
+
+func (r *reactedSet) add(messageID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ids == nil {
+		r.ids = make(map[string]struct{})
+	}
+	if _, ok := r.ids[messageID]; ok {
+		return
+	}
+	if len(r.order) >= reactedCapacity {
+		delete(r.ids, r.order[0])
+		r.order = r.order[1:]
+	}
+	r.ids[messageID] = struct{}{}
+	r.order = append(r.order, messageID)
+}
+
+func (r *reactedSet) contains(messageID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.ids[messageID]
+	return ok
+}
+
+// remove forgets messageID. Its slot in order is reclaimed on eviction.
+func (r *reactedSet) remove(messageID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.ids, messageID)
+}
+
+// isJollySkull reports whether emoji is one the bot replaces skulls with:
+// jollyskull, its fallback, a target group's replacement, a rule's, or one of
+// the theme pack's. Rules imported or reloaded since startup count too.
+func (b *Bot) isJollySkull(emoji *discordgo.Emoji) bool {
+	api := GetEmojiAPIString(emoji)
+	if slices.ContainsFunc(b.extraRules(), func(r config.EmojiRule) bool { return r.Replacement == api }) {
+		return true
+	}
+	return b.config.IsReplacement(api) || b.isThemeEmoji(emoji)
+}
+
+// noteReactions remembers msg as reacted for each of the bot's replacements
+// already among its reactions.
+func (b *Bot) noteReactions(msg *discordgo.Message) {
+	for _, reaction := range msg.Reactions {
+		if reaction.Me && b.isJollySkull(reaction.Emoji) {
+			b.reacted.add(b.reactedKey(msg.ID, GetEmojiAPIString(reaction.Emoji)))
+		}
+	}
+}
+
+// hasReacted reports whether the bot is known to have reacted to messageID
+// with replacements, as replacementsFor returns them.
+func (b *Bot) hasReacted(messageID string, replacements []string) bool {
+	if len(replacements) == 0 {
+		return b.reacted.contains(messageID)
+	}
+	return b.reacted.contains(b.reactedKey(messageID, replacements[0]))
+}
+
+// addJollySkull reacts to a message with jollyskull under policy, or with
+// emojis in order if there are any, unless the bot is known to have done so
+// already, and reports whether it skipped the request. If Discord no longer
+// knows jollyskull, it falls back and reacts with the fallback instead.
+func (b *Bot) addJollySkull(ctx context.Context, s Session, policy, channelID, messageID string, emojis ...string) (skipped bool, err error) {
+	if len(emojis) == 0 {
+		emojis = []string{b.config.JollySkullID}
+	}
+	key := b.reactedKey(messageID, emojis[0])
+	if b.reacted.contains(key) {
+		logger.DebugContext(ctx, "already reacted with jollyskull", "message_id", messageID)
+		metrics.Incr(b.sink(), metrics.ReactionAddsSkipped)
+		return true, nil
+	}
+	for _, emoji := range emojis {
+		if err := b.addReaction(ctx, s, policy, channelID, messageID, emoji); err != nil {
+			return false, err
+		}
+	}
+	b.reacted.add(key)
+	return false, nil
+}
+
+// addReaction reacts to a message with emoji under policy, or with the
+// fallback for jollyskull while it is unusable.
+func (b *Bot) addReaction(ctx context.Context, s Session, policy, channelID, messageID, emoji string) error {
+	add := func() error {
+		apiEmoji := emoji
+		if emoji == b.config.JollySkullID {
+			apiEmoji = b.jollyEmoji()
+		}
+		return b.executor.Do(ctx, actions.Action{
+			Kind:      actions.AddReaction,
+			Policy:    policy,
+			ChannelID: channelID,
+			MessageID: messageID,
+			Do: func(...discordgo.RequestOption) error {
+				return s.MessageReactionAdd(channelID, messageID, apiEmoji)
+			},
+		})
+	}
+	err := add()
+	if emoji == b.config.JollySkullID && isUnknownEmoji(err) && b.fallBack(ctx, s, fallbackUnknown) {
+		err = add()
+	}
+	return err
+}
+
+// OnReactionRemove forgets that the bot reacted to a message when its
+// jollyskull is removed, so the next skull puts it back.
+func (b *Bot) OnReactionRemove(s *discordgo.Session, r *discordgo.MessageReactionRemove) {
+	if r.UserID == b.selfID() && b.isJollySkull(&r.Emoji) {
+		b.reacted.remove(b.reactedKey(r.MessageID, GetEmojiAPIString(&r.Emoji)))
+	}
+}
+
+// OnReactionRemoveAll forgets that the bot reacted to a message when a
+// moderator clears all of its reactions.
+func (b *Bot) OnReactionRemoveAll(s *discordgo.Session, r *discordgo.MessageReactionRemoveAll) {
+	b.forgetReacted(r.MessageID)
+}
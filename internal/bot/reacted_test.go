@@ -0,0 +1,134 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/metrics"
+)
+
+func TestReactedSet_Evicts(t *testing.T) {
+	var r reactedSet
+	for i := range reactedCapacity + 1 {
+		r.add(fmt.Sprint(i))
+	}
+	if r.contains("0") {
+		t.Error("oldest message should have been evicted")
+	}
+	if !r.contains("1") || !r.contains(fmt.Sprint(reactedCapacity)) {
+		t.Error("newer messages should be remembered")
+	}
+}
+
+func TestBot_AlreadyReacted(t *testing.T) {
+	cfg := newTestConfig([]string{"user1", "user2"}, "jollyskull:123")
+	skull := &discordgo.Emoji{Name: "💀"}
+
+	tests := []struct {
+		name    string
+		setup   func(b *Bot, mock *mockSession)
+		adds    int
+		skipped int64
+	}{
+		{
+			name:  "first skull adds jollyskull",
+			setup: func(b *Bot, mock *mockSession) {},
+			adds:  1,
+		},
+		{
+			name: "second skull on the same message skips the add",
+			setup: func(b *Bot, mock *mockSession) {
+				b.ReplaceReaction(context.Background(), mock, "channel1", "msg1", "user2", skull)
+			},
+			adds:    1,
+			skipped: 1,
+		},
+		{
+			name: "removed jollyskull is added again",
+			setup: func(b *Bot, mock *mockSession) {
+				b.ReplaceReaction(context.Background(), mock, "channel1", "msg1", "user2", skull)
+				b.OnReactionRemove(nil, &discordgo.MessageReactionRemove{MessageReaction: &discordgo.MessageReaction{
+					UserID: "bot1", MessageID: "msg1", Emoji: discordgo.Emoji{Name: "jollyskull", ID: "123"},
+				}})
+			},
+			adds: 2,
+		},
+		{
+			name: "cleared reactions are added again",
+			setup: func(b *Bot, mock *mockSession) {
+				b.ReplaceReaction(context.Background(), mock, "channel1", "msg1", "user2", skull)
+				b.OnReactionRemoveAll(nil, &discordgo.MessageReactionRemoveAll{MessageReaction: &discordgo.MessageReaction{MessageID: "msg1"}})
+			},
+			adds: 2,
+		},
+		{
+			name: "another user's jollyskull removal is ignored",
+			setup: func(b *Bot, mock *mockSession) {
+				b.ReplaceReaction(context.Background(), mock, "channel1", "msg1", "user2", skull)
+				b.OnReactionRemove(nil, &discordgo.MessageReactionRemove{MessageReaction: &discordgo.MessageReaction{
+					UserID: "user2", MessageID: "msg1", Emoji: discordgo.Emoji{Name: "jollyskull", ID: "123"},
+				}})
+			},
+			adds:    1,
+			skipped: 1,
+		},
+		{
+			name: "jollyskull seen during a scan skips the add",
+			setup: func(b *Bot, mock *mockSession) {
+				b.noteReactions(&discordgo.Message{ID: "msg1", Reactions: []*discordgo.MessageReactions{
+					{Emoji: &discordgo.Emoji{Name: "jollyskull", ID: "123"}, Me: true},
+				}})
+			},
+			skipped: 1,
+		},
+		{
+			name: "someone else's jollyskull does not count",
+			setup: func(b *Bot, mock *mockSession) {
+				b.noteReactions(&discordgo.Message{ID: "msg1", Reactions: []*discordgo.MessageReactions{
+					{Emoji: &discordgo.Emoji{Name: "jollyskull", ID: "123"}},
+				}})
+			},
+			adds: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := newRecordingSink()
+			b := New(cfg, WithMetrics(sink))
+			b.self = "bot1"
+			b.channels = map[string]string{"channel1": "jollyposting"}
+			mock := &mockSession{}
+
+			tt.setup(b, mock)
+			if !b.ReplaceReaction(context.Background(), mock, "channel1", "msg1", "user1", skull) {
+				t.Fatal("ReplaceReaction() should succeed")
+			}
+
+			if len(mock.addedReactions) != tt.adds {
+				t.Errorf("added %d reactions, want %d", len(mock.addedReactions), tt.adds)
+			}
+			if sink.counts[metrics.ReactionAddsSkipped] != tt.skipped {
+				t.Errorf("%s = %d, want %d", metrics.ReactionAddsSkipped, sink.counts[metrics.ReactionAddsSkipped], tt.skipped)
+			}
+		})
+	}
+}
+
+func TestBot_IsJollySkull_ImportedRules(t *testing.T) {
+	b := New(newTestConfig([]string{"user1"}, "jollyskull:123"))
+	sob := &discordgo.Emoji{Name: "jollysob", ID: "456"}
+	if b.isJollySkull(sob) {
+		t.Fatal("jollysob is no replacement before a rule names it")
+	}
+
+	rules := []config.EmojiRule{{Name: "sob", Unicode: []string{"😭"}, Replacement: "jollysob:456"}}
+	b.storedRules.Store(&rules)
+	if !b.isJollySkull(sob) {
+		t.Error("an imported rule's replacement should count as the bot's")
+	}
+}
@@ -0,0 +1,39 @@
+package bot
+
+import (
+	"context"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/metrics"
+)
+
+// recheckSkull fetches who reacted to a message with emojiStr after the
+// skull of a.UserID was replaced, and removes it once more if it is back.
+// Between the removal and the jollyskull add, the user may have re-added it.
+// It retries once; a skull added after that arrives as a new event anyway.
+func (b *Bot) recheckSkull(ctx context.Context, s Session, a actions.Action, emojiStr string) {
+	channelID, messageID, userID := a.ChannelID, a.MessageID, a.UserID
+	users, err := s.MessageReactions(channelID, messageID, emojiStr, 100, "", "")
+	if err != nil {
+		logger.WarnContext(ctx, "failed to re-check reactions", "message_id", messageID, "error", err)
+		return
+	}
+	if !slices.ContainsFunc(users, func(u *discordgo.User) bool { return u.ID == userID }) {
+		return
+	}
+
+	a.Kind = actions.RemoveReaction
+	a.Feature = "" // Part of the replacement, which counted already
+	a.Do = func(opts ...discordgo.RequestOption) error {
+		return s.MessageReactionRemove(channelID, messageID, emojiStr, userID, opts...)
+	}
+	if err := b.executor.Do(ctx, a); err != nil {
+		logger.WarnContext(ctx, "failed to remove re-added skull reaction", "message_id", messageID, "user_id", userID, "error", err)
+		return
+	}
+	logger.DebugContext(ctx, "removed skull reaction re-added during replacement", "message_id", messageID, "user_id", userID, "emoji", emojiStr)
+	metrics.Incr(b.sink(), metrics.ReactionRecheckRemovals)
+}
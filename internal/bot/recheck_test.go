@@ -0,0 +1,52 @@
+package bot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/metrics"
+)
+
+func TestBot_ReplaceReaction_Recheck(t *testing.T) {
+	tests := []struct {
+		name         string
+		recheck      bool
+		reactedAfter []string // Who has the skull on the message after the replacement
+		wantRemovals int
+		wantRechecks int64
+	}{
+		{name: "off", reactedAfter: []string{"user123"}, wantRemovals: 1},
+		{name: "skull stays gone", recheck: true, wantRemovals: 1},
+		{name: "skull re-added", recheck: true, reactedAfter: []string{"other", "user123"}, wantRemovals: 2, wantRechecks: 1},
+		{name: "others' skulls left alone", recheck: true, reactedAfter: []string{"other"}, wantRemovals: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig([]string{"user123"}, "jollyskull:123")
+			cfg.ReactionRecheck = tt.recheck
+			sink := newRecordingSink()
+			b := New(cfg, WithMetrics(sink))
+			mock := &mockSession{reactions: map[string][]*discordgo.User{}}
+			for _, id := range tt.reactedAfter {
+				mock.reactions["msg1"] = append(mock.reactions["msg1"], &discordgo.User{ID: id})
+			}
+
+			if !b.ReplaceReaction(context.Background(), mock, "chan1", "msg1", "user123", &discordgo.Emoji{Name: "💀"}) {
+				t.Fatal("ReplaceReaction() = false, want true")
+			}
+			if len(mock.removedReactions) != tt.wantRemovals {
+				t.Errorf("removed %d reactions, want %d", len(mock.removedReactions), tt.wantRemovals)
+			}
+			for _, r := range mock.removedReactions {
+				if r.userID != "user123" || r.emojiID != "💀" {
+					t.Errorf("removed %+v, want only user123's 💀", r)
+				}
+			}
+			if got := sink.counts[metrics.ReactionRecheckRemovals]; got != tt.wantRechecks {
+				t.Errorf("%s = %d, want %d", metrics.ReactionRecheckRemovals, got, tt.wantRechecks)
+			}
+		})
+	}
+}
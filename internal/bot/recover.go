@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/metrics"
+)
+
+// errPanicked is returned to the user when a command handler panicked.
+var errPanicked = errors.New("internal error")
+
+// Recover wraps a gateway event handler so that a panic in it is logged and
+// reported instead of crashing the bot. discordgo runs handlers on their own
+// goroutines, where an unrecovered panic ends the process.
+func Recover[E any](b *Bot, handler func(*discordgo.Session, E)) func(*discordgo.Session, E) {
+	return func(s *discordgo.Session, event E) {
+		defer b.recoverPanic(fmt.Sprintf("%T", event))
+		handler(s, event)
+	}
+}
+
+// runHandler calls an interaction handler, turning a panic into an error so
+// the user still gets a reply.
+func (b *Bot) runHandler(source string, handler func() (*discordgo.InteractionResponseData, error)) (reply *discordgo.InteractionResponseData, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			b.reportPanic(source, v, debug.Stack())
+			reply, err = nil, errPanicked
+		}
+	}()
+	return handler()
+}
+
+// recoverPanic recovers a panic in the calling goroutine and reports it.
+// It must be deferred directly.
+func (b *Bot) recoverPanic(source string) {
+	if v := recover(); v != nil {
+		b.reportPanic(source, v, debug.Stack())
+	}
+}
+
+// reportPanic logs a recovered panic with its stack, counts it, and sends it
+// to Sentry if configured.
+func (b *Bot) reportPanic(source string, v any, stack []byte) {
+	logger.Error("recovered from panic", "source", source, "panic", v, "stack", string(stack))
+	metrics.Incr(b.sink(), metrics.PanicsRecovered)
+	if err := b.sentry.CapturePanic(context.Background(), v, stack, map[string]string{"source": source}); err != nil {
+		logger.Warn("failed to report panic", "source", source, "error", err)
+	}
+}
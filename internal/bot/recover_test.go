@@ -0,0 +1,71 @@
+package bot
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/metrics"
+)
+
+func TestRecover(t *testing.T) {
+	sink := newRecordingSink()
+	b := New(newTestConfig([]string{"target-user"}, "jollyskull:123"), WithMetrics(sink))
+
+	handler := Recover(b, func(s *discordgo.Session, m *discordgo.MessageCreate) {
+		panic("boom")
+	})
+	handler(nil, &discordgo.MessageCreate{})
+
+	if sink.counts[metrics.PanicsRecovered] != 1 {
+		t.Errorf("expected 1 recovered panic, got %d", sink.counts[metrics.PanicsRecovered])
+	}
+}
+
+func TestBot_RunHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    func() (*discordgo.InteractionResponseData, error)
+		wantReply  bool
+		wantErr    error
+		wantPanics int64
+	}{
+		{
+			name:      "passes the reply through",
+			handler:   func() (*discordgo.InteractionResponseData, error) { return textReply("ok"), nil },
+			wantReply: true,
+		},
+		{
+			name:    "passes errors through",
+			handler: func() (*discordgo.InteractionResponseData, error) { return nil, errTest },
+			wantErr: errTest,
+		},
+		{
+			name:       "turns a panic into an error",
+			handler:    func() (*discordgo.InteractionResponseData, error) { panic("boom") },
+			wantErr:    errPanicked,
+			wantPanics: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := newRecordingSink()
+			b := New(newTestConfig([]string{"target-user"}, "jollyskull:123"), WithMetrics(sink))
+
+			reply, err := b.runHandler("command test", tt.handler)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("runHandler() error = %v, want %v", err, tt.wantErr)
+			}
+			if (reply != nil) != tt.wantReply {
+				t.Errorf("runHandler() reply = %v, want reply %v", reply, tt.wantReply)
+			}
+			if sink.counts[metrics.PanicsRecovered] != tt.wantPanics {
+				t.Errorf("expected %d recovered panics, got %d", tt.wantPanics, sink.counts[metrics.PanicsRecovered])
+			}
+		})
+	}
+}
+
+var errTest = errors.New("test error")
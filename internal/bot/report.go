@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/i18n"
+)
+
+// Embed colors for reports.
+const (
+	colorSuccess = 0x2ecc71
+	colorWarning = 0xe67e22
+)
+
+// historyReport summarizes a historical scan across all monitored channels.
+type historyReport struct {
+	scanStats
+	Channels  int
+	Duration  time.Duration
+	Complete  bool // Every channel was scanned to the end
+	Cancelled bool // Stopped by shutdown rather than by an error
+	Paused    bool // Stopped because the server was paused or the feature disabled
+}
+
+// embed renders the report for the admin channel.
+func (r historyReport) embed(p i18n.Printer) *discordgo.MessageEmbed {
+	title, color := p.T("report.complete"), colorSuccess
+	switch {
+	case r.Cancelled:
+		title, color = p.T("report.cancelled"), colorWarning
+	case r.Paused:
+		title, color = p.T("report.paused"), colorWarning
+	case !r.Complete:
+		title, color = p.T("report.stopped"), colorWarning
+	}
+
+	field := func(name string, value int) *discordgo.MessageEmbedField {
+		return &discordgo.MessageEmbedField{Name: name, Value: strconv.Itoa(value), Inline: true}
+	}
+	return &discordgo.MessageEmbed{
+		Title: title,
+		Color: color,
+		Fields: []*discordgo.MessageEmbedField{
+			field(p.T("report.channels"), r.Channels),
+			field(p.T("report.scanned"), r.Processed),
+			field(p.T("report.replaced"), r.Replaced),
+			field(p.T("report.errors"), r.Failed),
+			{Name: p.T("report.duration"), Value: r.Duration.Round(time.Second).String(), Inline: true},
+		},
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// postHistoryReport sends the report to the admin channel, if one is configured.
+func (b *Bot) postHistoryReport(s Session, r historyReport) {
+	if b.config.AdminChannelID == "" {
+		return
+	}
+	if err := b.postEmbed(s, b.config.AdminChannelID, r.embed(b.printer(b.config.GuildID))); err != nil && !actions.Skipped(err) {
+		logger.Error("failed to post historical scan report", "channel_id", b.config.AdminChannelID, "error", err)
+	}
+}
+
+// postEmbed posts embed with files to channelID, or posts it as plain text
+// without the files if the guild prefers plain output.
+func (b *Bot) postEmbed(s Session, channelID string, embed *discordgo.MessageEmbed, files ...*discordgo.File) error {
+	_, err := b.sendEmbed(s, channelID, embed, files...)
+	return err
+}
+
+// sendEmbed is postEmbed that returns the posted message.
+func (b *Bot) sendEmbed(s Session, channelID string, embed *discordgo.MessageEmbed, files ...*discordgo.File) (*discordgo.Message, error) {
+	msg := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}, Files: files}
+	if b.guildSettings().PlainOutput() {
+		msg = &discordgo.MessageSend{Content: plainText(embed)}
+	}
+	var posted *discordgo.Message
+	err := b.executor.Do(context.Background(), actions.Action{
+		Kind:      actions.Post,
+		ChannelID: channelID,
+		Do: func(...discordgo.RequestOption) (err error) {
+			posted, err = s.ChannelMessageSendComplex(channelID, msg)
+			return err
+		},
+	})
+	return posted, err
+}
+
+// plainText renders the text of an embed as a screen reader friendly
+// message: the title, the description, then one field per line.
+func plainText(e *discordgo.MessageEmbed) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, e.Title)
+	if e.Description != "" {
+		fmt.Fprintln(&sb, e.Description)
+	}
+	for _, f := range e.Fields {
+		if strings.Contains(f.Value, "\n") {
+			fmt.Fprintf(&sb, "%s:\n%s\n", f.Name, f.Value)
+		} else {
+			fmt.Fprintf(&sb, "%s: %s\n", f.Name, f.Value)
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
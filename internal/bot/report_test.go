@@ -0,0 +1,163 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/i18n"
+	"jolly-okurb/internal/settings"
+)
+
+func TestHistoryReport_Embed(t *testing.T) {
+	tests := []struct {
+		name      string
+		report    historyReport
+		wantTitle string
+		wantColor int
+	}{
+		{"complete", historyReport{Complete: true}, "Historical scan complete", colorSuccess},
+		{"cancelled", historyReport{Cancelled: true}, "Historical scan cancelled", colorWarning},
+		{"stopped by errors", historyReport{}, "Historical scan stopped early", colorWarning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tt.report.embed(i18n.For("en"))
+			if e.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", e.Title, tt.wantTitle)
+			}
+			if e.Color != tt.wantColor {
+				t.Errorf("Color = %#x, want %#x", e.Color, tt.wantColor)
+			}
+		})
+	}
+}
+
+// embedField returns the value of the named field, or "" if it is missing.
+func embedField(e *discordgo.MessageEmbed, name string) string {
+	for _, f := range e.Fields {
+		if f.Name == name {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+func TestBot_ProcessHistoricalMessages_Report(t *testing.T) {
+	delay := historicalPageDelay
+	historicalPageDelay = 0
+	t.Cleanup(func() { historicalPageDelay = delay })
+
+	newBot := func(adminChannelID string) *Bot {
+		cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+		cfg.AdminChannelID = adminChannelID
+		b := New(cfg)
+		b.channels = map[string]string{"chan": "jollyposting"}
+		return b
+	}
+	newest := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	t.Run("posts summary to admin channel", func(t *testing.T) {
+		h := skullHistory(newest, 3, time.Hour)
+		newBot("admin").ProcessHistoricalMessages(context.Background(), h)
+
+		if len(h.sent) != 1 {
+			t.Fatalf("expected 1 report, got %d", len(h.sent))
+		}
+		msg := h.sent[0]
+		if msg.channelID != "admin" {
+			t.Errorf("report sent to %q, want %q", msg.channelID, "admin")
+		}
+		for name, want := range map[string]string{
+			"Channels":           "1",
+			"Messages scanned":   "3",
+			"Reactions replaced": "3",
+			"Errors":             "0",
+		} {
+			if got := embedField(msg.embed, name); got != want {
+				t.Errorf("field %q = %q, want %q", name, got, want)
+			}
+		}
+	})
+
+	t.Run("counts failed replacements", func(t *testing.T) {
+		h := skullHistory(newest, 2, time.Hour)
+		h.addErr = errors.New("add failed")
+		newBot("admin").ProcessHistoricalMessages(context.Background(), h)
+
+		if got := embedField(h.sent[0].embed, "Errors"); got != "2" {
+			t.Errorf("Errors = %q, want %q", got, "2")
+		}
+	})
+
+	t.Run("reports cancellation", func(t *testing.T) {
+		h := skullHistory(newest, 3, time.Hour)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		newBot("admin").ProcessHistoricalMessages(ctx, h)
+
+		if len(h.sent) != 1 || h.sent[0].embed.Title != "Historical scan cancelled" {
+			t.Errorf("expected a cancellation report, got %+v", h.sent)
+		}
+	})
+
+	t.Run("no admin channel", func(t *testing.T) {
+		h := skullHistory(newest, 3, time.Hour)
+		newBot("").ProcessHistoricalMessages(context.Background(), h)
+
+		if len(h.sent) != 0 {
+			t.Errorf("expected no report without an admin channel, got %d", len(h.sent))
+		}
+	})
+}
+
+func TestPlainText(t *testing.T) {
+	e := &discordgo.MessageEmbed{
+		Title:       "Jolly Wrapped",
+		Description: "This week",
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Skulls replaced", Value: "3"},
+			{Name: "Top targets", Value: "1. Alice — 2\n2. Bob — 1"},
+		},
+	}
+
+	want := "Jolly Wrapped\nThis week\nSkulls replaced: 3\nTop targets:\n1. Alice — 2\n2. Bob — 1"
+	if got := plainText(e); got != want {
+		t.Errorf("plainText() = %q, want %q", got, want)
+	}
+}
+
+func TestBot_PostEmbed(t *testing.T) {
+	embed := &discordgo.MessageEmbed{Title: "Report", Fields: []*discordgo.MessageEmbedField{{Name: "Errors", Value: "0"}}}
+	file := &discordgo.File{Name: "chart.png"}
+
+	t.Run("embed by default", func(t *testing.T) {
+		b := New(&config.Config{GuildID: "guild123"})
+		mock := &mockSession{}
+
+		if err := b.postEmbed(mock, "chan1", embed, file); err != nil {
+			t.Fatalf("postEmbed() unexpected error: %v", err)
+		}
+		if msg := mock.sent[0]; msg.embed != embed || len(msg.files) != 1 || msg.content != "" {
+			t.Errorf("expected the embed with its file, got %+v", msg)
+		}
+	})
+
+	t.Run("plain text when the guild prefers it", func(t *testing.T) {
+		b := New(&config.Config{GuildID: "guild123"})
+		b.settings.SetOutput("guild123", settings.OutputPlain)
+		mock := &mockSession{}
+
+		if err := b.postEmbed(mock, "chan1", embed, file); err != nil {
+			t.Fatalf("postEmbed() unexpected error: %v", err)
+		}
+		if msg := mock.sent[0]; msg.embed != nil || len(msg.files) != 0 || msg.content != "Report\nErrors: 0" {
+			t.Errorf("expected plain text only, got %+v", msg)
+		}
+	})
+}
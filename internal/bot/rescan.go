@@ -0,0 +1,212 @@
+package bot
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/settings"
+	"jolly-okurb/internal/snowflake"
+)
+
+// Custom IDs of the buttons on the rescan confirmation prompt. The confirm
+// button carries the options given to the command as its argument: the date
+// to rescan back to, if any, then the reason.
+const (
+	rescanConfirmID = "jolly:rescan:confirm"
+	rescanCancelID  = "jolly:rescan:cancel"
+)
+
+// componentArgSep separates a custom ID from its argument, and the parts of
+// an argument from each other.
+const componentArgSep = "|"
+
+// maxCommandReason is the longest reason a command accepts, so that it fits
+// in a custom ID, which Discord limits to 100 characters.
+const maxCommandReason = 100 - len(rescanConfirmID) - 2*len(componentArgSep) - len(time.DateOnly)
+
+// maxEstimateChannels caps the channels the rescan estimate samples, so
+// that it stays a handful of API calls in servers with many channels.
+const maxEstimateChannels = 10
+
+// backfillEstimate is a rough forecast of the work a full rescan will do.
+type backfillEstimate struct {
+	Channels int
+	Sampled  int // Channels whose newest page was sampled
+	Messages int
+	APICalls int
+	Duration time.Duration
+
+	// Extrapolated is set when a sample did not reach back to the cutoff,
+	// so the message count is projected from its rate rather than counted.
+	Extrapolated bool
+}
+
+// estimateBackfill samples the newest page of up to maxEstimateChannels
+// monitored channels, spread evenly over them, and extrapolates their message
+// rate back to the cutoff, scaling the total up to the unsampled channels. It
+// assumes every message with a skull reaction needs one reaction lookup and
+// one replacement, which makes API calls and time an upper bound for typical
+// channels.
+func (b *Bot) estimateBackfill(s Session, cutoff time.Time) (backfillEstimate, error) {
+	channelIDs := b.monitoredChannelIDs()
+	est := backfillEstimate{Channels: len(channelIDs), Sampled: min(len(channelIDs), maxEstimateChannels)}
+	var pages, skulls, messages float64
+	for n := range est.Sampled {
+		channelID := channelIDs[n*len(channelIDs)/est.Sampled]
+		sample, err := s.ChannelMessages(channelID, 100, "", "", "")
+		if err != nil {
+			return backfillEstimate{}, fmt.Errorf("failed to sample channel %s: %w", channelID, err)
+		}
+		count, skullRatio, extrapolated := b.extrapolateHistory(sample, cutoff)
+
+		messages += count
+		pages += math.Max(math.Ceil(count/100), 1)
+		skulls += count * skullRatio
+		est.Extrapolated = est.Extrapolated || extrapolated
+	}
+	if est.Sampled < est.Channels {
+		scale := float64(est.Channels) / float64(est.Sampled)
+		messages, pages, skulls = messages*scale, pages*scale, skulls*scale
+	}
+	est.Messages = int(messages)
+
+	// Each skull message costs a reaction lookup plus a remove and an add
+	est.APICalls = int(pages + 3*skulls)
+
+	est.Duration = time.Duration(pages) * historicalPageDelay / time.Duration(b.historicalWorkers())
+	if b.config.ActionRateLimit > 0 {
+		mutations := 2 * skulls
+		est.Duration += time.Duration(mutations / float64(b.config.ActionRateLimit) * float64(b.config.ActionRatePeriod))
+	}
+	return est, nil
+}
+
+// extrapolateHistory estimates how many messages a channel has after cutoff,
+// and what fraction of them have skull reactions, from its newest messages.
+// It reports whether it projected the count past the sample.
+func (b *Bot) extrapolateHistory(sample []*discordgo.Message, cutoff time.Time) (messages, skullRatio float64, extrapolated bool) {
+	var recent []*discordgo.Message
+	skulls := 0
+	for _, msg := range sample {
+		if snowflake.Before(msg.ID, cutoff) {
+			break
+		}
+		recent = append(recent, msg)
+		if slices.ContainsFunc(msg.Reactions, b.isSkullReaction) {
+			skulls++
+		}
+	}
+	if len(recent) == 0 {
+		return 0, 0, false
+	}
+	skullRatio = float64(skulls) / float64(len(recent))
+
+	// The sample already reaches the cutoff or the start of the channel
+	if len(recent) < len(sample) || len(sample) < 100 {
+		return float64(len(recent)), skullRatio, false
+	}
+
+	newest, errNewest := snowflake.Time(recent[0].ID)
+	oldest, errOldest := snowflake.Time(recent[len(recent)-1].ID)
+	span := newest.Sub(oldest)
+	if errNewest != nil || errOldest != nil || span <= 0 {
+		return float64(len(recent)), skullRatio, true
+	}
+	rate := float64(len(recent)-1) / span.Seconds() // Messages per second between the sampled ones
+	return float64(len(recent)) + rate*oldest.Sub(cutoff).Seconds(), skullRatio, true
+}
+
+func (b *Bot) isSkullReaction(r *discordgo.MessageReactions) bool {
+	return r.Emoji != nil && b.handlesEmoji(r.Emoji)
+}
+
+// handleRescan estimates a rescan and asks to confirm it. It is deferred, as
+// sampling the channels may take longer than Discord waits for a reply.
+func (b *Bot) handleRescan(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	p := b.printer(i.GuildID)
+	if !b.featureEnabled(settings.FeatureHistorical) {
+		return textReply(p.T("rescan.disabled")), nil
+	}
+	if !b.isReady() {
+		return textReply(p.T("rescan.not_ready")), nil
+	}
+
+	cutoff, err := time.Parse(time.RFC3339, HistoricalCutoff)
+	if err != nil {
+		return nil, fmt.Errorf("invalid historical cutoff date: %w", err)
+	}
+	var since string
+	if o, ok := opts["since"]; ok {
+		since = o.StringValue()
+		t, err := time.Parse(time.DateOnly, since)
+		if err != nil || t.Before(cutoff) || t.After(time.Now()) {
+			return textReply(p.T("rescan.invalid_since", cutoff.Format(time.DateOnly))), nil
+		}
+		cutoff = t
+	}
+	est, err := b.estimateBackfill(s, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintln(&sb, p.T("rescan.estimate", est.Channels, cutoff.Format("2006-01-02")))
+	fmt.Fprintln(&sb, p.T("rescan.estimate.messages", est.Messages))
+	fmt.Fprintln(&sb, p.T("rescan.estimate.calls", est.APICalls))
+	fmt.Fprintln(&sb, p.T("rescan.estimate.time", est.Duration.Round(time.Minute)))
+	switch {
+	case est.Sampled < est.Channels:
+		sb.WriteString(p.T("rescan.estimate.sampled", est.Sampled, est.Channels))
+	case est.Extrapolated:
+		sb.WriteString(p.T("rescan.estimate.note"))
+	default:
+		sb.WriteString(p.T("rescan.estimate.counted"))
+	}
+
+	var reason string
+	if o, ok := opts["reason"]; ok {
+		reason = o.StringValue()
+	}
+	confirmID := rescanConfirmID
+	if since != "" || reason != "" {
+		confirmID += componentArgSep + since + componentArgSep + reason
+	}
+	return &discordgo.InteractionResponseData{
+		Content: sb.String(),
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Label: p.T("rescan.button.start"), Style: discordgo.DangerButton, CustomID: confirmID},
+				discordgo.Button{Label: p.T("rescan.button.cancel"), Style: discordgo.SecondaryButton, CustomID: rescanCancelID},
+			}},
+		},
+	}, nil
+}
+
+func (b *Bot) handleRescanConfirm(s Session, i *discordgo.InteractionCreate) (*discordgo.InteractionResponseData, error) {
+	p := b.printer(i.GuildID)
+	_, arg, _ := strings.Cut(i.MessageComponentData().CustomID, componentArgSep)
+	since, reason, _ := strings.Cut(arg, componentArgSep)
+	ctx := actions.WithComment(b.lifecycleContext(), reason)
+	if cutoff, err := time.Parse(time.DateOnly, since); err == nil {
+		ctx = withCutoff(ctx, cutoff)
+	}
+	if !b.runHistorical(ctx, s, true) {
+		return textReply(p.T("rescan.running")), nil
+	}
+	scanLog.Info("rescan started", "by", interactionUserID(i), "since", since, "reason", reason)
+
+	if b.config.AdminChannelID != "" {
+		return textReply(p.T("rescan.started.report", b.config.AdminChannelID)), nil
+	}
+	return textReply(p.T("rescan.started")), nil
+}
+
+func (b *Bot) handleRescanCancel(s Session, i *discordgo.InteractionCreate) (*discordgo.InteractionResponseData, error) {
+	return textReply(b.printer(i.GuildID).T("rescan.cancelled")), nil
+}
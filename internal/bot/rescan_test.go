@@ -0,0 +1,257 @@
+package bot
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/settings"
+	"jolly-okurb/internal/snowflake"
+	"jolly-okurb/internal/store"
+)
+
+// newComponentInteraction builds a button press with the given custom ID.
+func newComponentInteraction(guildID, customID string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:      "interaction2",
+			Type:    discordgo.InteractionMessageComponent,
+			GuildID: guildID,
+			Member:  &discordgo.Member{User: &discordgo.User{ID: "admin1"}},
+			Data:    discordgo.MessageComponentInteractionData{CustomID: customID, ComponentType: discordgo.ButtonComponent},
+		},
+	}
+}
+
+func TestBot_ExtrapolateHistory(t *testing.T) {
+	b := New(newTestConfig(nil, ""))
+	cutoff := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// page returns n messages an hour apart, newest first, the newest at newest.
+	// Every skullEvery-th message has a skull reaction.
+	page := func(newest time.Time, n, skullEvery int) []*discordgo.Message {
+		var msgs []*discordgo.Message
+		for i := range n {
+			ts := newest.Add(-time.Duration(i) * time.Hour)
+			msg := &discordgo.Message{ID: snowflake.FromTime(ts), Timestamp: ts}
+			if i%skullEvery == 0 {
+				msg.Reactions = []*discordgo.MessageReactions{{Emoji: &discordgo.Emoji{Name: "💀"}}}
+			}
+			msgs = append(msgs, msg)
+		}
+		return msgs
+	}
+
+	tests := []struct {
+		name         string
+		sample       []*discordgo.Message
+		wantMessages float64
+		wantRatio    float64
+		wantExtra    bool
+	}{
+		{"empty channel", nil, 0, 0, false},
+		{"short channel", page(cutoff.Add(100*time.Hour), 10, 2), 10, 0.5, false},
+		{"sample reaches the cutoff", page(cutoff.Add(49*time.Hour+30*time.Minute), 100, 1), 50, 1, false},
+		{"extrapolates to the cutoff", page(cutoff.Add(299*time.Hour), 100, 4), 300, 0.25, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, ratio, extrapolated := b.extrapolateHistory(tt.sample, cutoff)
+			if int(messages+0.5) != int(tt.wantMessages) {
+				t.Errorf("messages = %.1f, want %.0f", messages, tt.wantMessages)
+			}
+			if ratio != tt.wantRatio {
+				t.Errorf("skull ratio = %.2f, want %.2f", ratio, tt.wantRatio)
+			}
+			if extrapolated != tt.wantExtra {
+				t.Errorf("extrapolated = %v, want %v", extrapolated, tt.wantExtra)
+			}
+		})
+	}
+}
+
+func TestBot_HandleInteraction_Rescan(t *testing.T) {
+	delay := historicalPageDelay
+	historicalPageDelay = 0
+	t.Cleanup(func() { historicalPageDelay = delay })
+
+	newBot := func() *Bot {
+		cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+		cfg.GuildID = "guild123"
+		b := New(cfg, WithStore(store.NewMemory()))
+		b.channels = map[string]string{"chan": "jollyposting"}
+		b.ready = true
+		return b
+	}
+
+	t.Run("shows estimate with confirmation buttons", func(t *testing.T) {
+		b := newBot()
+		h := skullHistory(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC), 3, time.Hour)
+
+		b.HandleInteraction(h, newCommandInteraction("guild123", []string{"rescan"}))
+
+		got := lastResponse(t, h.mockSession)
+		for _, want := range []string{"1 channel(s)", "Messages: ~3", "API calls: ~10"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("estimate %q should contain %q", got, want)
+			}
+		}
+		if resp := lastReply(t, h.mockSession); len(resp.Components) != 1 {
+			t.Fatalf("expected one row of buttons, got %+v", resp.Components)
+		}
+		if h.messageCalls != 1 {
+			t.Errorf("estimate should sample one page per channel, got %d fetches", h.messageCalls)
+		}
+		if h.responses[0].Type != discordgo.InteractionResponseDeferredChannelMessageWithSource {
+			t.Errorf("the reply should be deferred while channels are sampled, got response type %v", h.responses[0].Type)
+		}
+		if !strings.Contains(got, "counted") {
+			t.Errorf("estimate %q should say the messages were counted", got)
+		}
+	})
+
+	t.Run("samples a capped number of channels", func(t *testing.T) {
+		b := newBot()
+		b.channels = make(map[string]string)
+		for n := range 25 {
+			b.channels[fmt.Sprintf("chan%02d", n)] = "jollyposting"
+		}
+		h := skullHistory(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC), 3, time.Hour)
+
+		b.HandleInteraction(h, newCommandInteraction("guild123", []string{"rescan"}))
+
+		got := lastResponse(t, h.mockSession)
+		for _, want := range []string{"25 channel(s)", "Messages: ~75", "10 of the 25 channels"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("estimate %q should contain %q", got, want)
+			}
+		}
+		if h.messageCalls != maxEstimateChannels {
+			t.Errorf("estimate fetched %d pages, want %d", h.messageCalls, maxEstimateChannels)
+		}
+	})
+
+	t.Run("confirm starts a fresh scan", func(t *testing.T) {
+		b := newBot()
+		h := skullHistory(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC), 3, time.Hour)
+		// A previous scan already covered everything
+		b.store.Put(checkpointKey("chan"), checkpoint{NewestID: snowflake.FromTime(time.Now())})
+
+		b.HandleInteraction(h, newComponentInteraction("guild123", rescanConfirmID))
+		b.background.Wait()
+
+		resp := h.responses[len(h.responses)-1]
+		if resp.Type != discordgo.InteractionResponseUpdateMessage {
+			t.Errorf("button press should update the prompt, got response type %v", resp.Type)
+		}
+		if !strings.Contains(resp.Data.Content, "Rescan started") {
+			t.Errorf("reply %q should confirm the rescan", resp.Data.Content)
+		}
+		if len(resp.Data.Components) != 0 {
+			t.Error("buttons should be removed after confirming")
+		}
+		if len(h.removedReactions) != 3 {
+			t.Errorf("rescan should ignore the checkpoint and replace 3 reactions, got %d", len(h.removedReactions))
+		}
+	})
+
+	t.Run("reason reaches the audit log", func(t *testing.T) {
+		b := newBot()
+		var export bytes.Buffer
+		WithAuditExport(&export)(b)
+		h := skullHistory(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC), 1, time.Hour)
+
+		reason := &discordgo.ApplicationCommandInteractionDataOption{Name: "reason", Type: discordgo.ApplicationCommandOptionString, Value: "raid cleanup"}
+		b.HandleInteraction(h, newCommandInteraction("guild123", []string{"rescan"}, reason))
+		row := lastReply(t, h.mockSession).Components[0].(discordgo.ActionsRow)
+		confirmID := row.Components[0].(discordgo.Button).CustomID
+		if confirmID != rescanConfirmID+componentArgSep+componentArgSep+"raid cleanup" {
+			t.Fatalf("confirm button ID = %q, want the reason as its argument", confirmID)
+		}
+
+		b.HandleInteraction(h, newComponentInteraction("guild123", confirmID))
+		b.background.Wait()
+
+		if !strings.Contains(export.String(), `"reason":"jolly-okurb: skull reaction policy (raid cleanup)"`) {
+			t.Errorf("audit export %q should carry the reason", export.String())
+		}
+	})
+
+	t.Run("since limits the scan", func(t *testing.T) {
+		b := newBot()
+		h := skullHistory(time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC), 3, 24*time.Hour)
+
+		since := &discordgo.ApplicationCommandInteractionDataOption{Name: "since", Type: discordgo.ApplicationCommandOptionString, Value: "2025-06-14"}
+		b.HandleInteraction(h, newCommandInteraction("guild123", []string{"rescan"}, since))
+		row := lastReply(t, h.mockSession).Components[0].(discordgo.ActionsRow)
+		confirmID := row.Components[0].(discordgo.Button).CustomID
+		if confirmID != rescanConfirmID+componentArgSep+"2025-06-14"+componentArgSep {
+			t.Fatalf("confirm button ID = %q, want the date as its argument", confirmID)
+		}
+
+		b.HandleInteraction(h, newComponentInteraction("guild123", confirmID))
+		b.background.Wait()
+
+		if len(h.removedReactions) != 2 {
+			t.Errorf("rescan since 2025-06-14 should replace 2 reactions, got %d", len(h.removedReactions))
+		}
+	})
+
+	t.Run("invalid since", func(t *testing.T) {
+		for _, value := range []string{"yesterday", "2024-12-31", time.Now().AddDate(0, 0, 2).Format(time.DateOnly)} {
+			b := newBot()
+			mock := &mockSession{}
+			since := &discordgo.ApplicationCommandInteractionDataOption{Name: "since", Type: discordgo.ApplicationCommandOptionString, Value: value}
+
+			b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"rescan"}, since))
+
+			if got := lastResponse(t, mock); !strings.Contains(got, "2025-01-01") {
+				t.Errorf("reply %q to since %q should name the earliest date", got, value)
+			}
+		}
+	})
+
+	t.Run("confirm while a scan is running", func(t *testing.T) {
+		b := newBot()
+		b.historicalRunning = true
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newComponentInteraction("guild123", rescanConfirmID))
+
+		if got := lastResponse(t, mock); !strings.Contains(got, "already running") {
+			t.Errorf("reply %q should say a scan is already running", got)
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		b := newBot()
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newComponentInteraction("guild123", rescanCancelID))
+		b.Shutdown()
+
+		if got := lastResponse(t, mock); got != "Rescan cancelled." {
+			t.Errorf("reply = %q, want %q", got, "Rescan cancelled.")
+		}
+		if mock.messageCalls != 0 {
+			t.Error("cancelling should not scan anything")
+		}
+	})
+
+	t.Run("refused when historical processing is disabled", func(t *testing.T) {
+		b := newBot()
+		b.settings.SetFeature("guild123", settings.FeatureHistorical, false)
+		mock := &mockSession{}
+
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"rescan"}))
+
+		if got := lastResponse(t, mock); !strings.Contains(got, "disabled") {
+			t.Errorf("reply %q should say historical processing is disabled", got)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/schedule"
+)
+
+// jobScrubDeleted is the scheduled job kind that scrubs retained content.
+const jobScrubDeleted = "scrub_deleted"
+
+// deletedMessage is the retained content of a message the bot deleted.
+type deletedMessage struct {
+	ChannelID string    `json:"channel_id"`
+	MessageID string    `json:"message_id"`
+	AuthorID  string    `json:"author_id"`
+	Content   string    `json:"content"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// scrubDeletedJob identifies the retained message a scrub job removes.
+type scrubDeletedJob struct {
+	MessageID string `json:"message_id"`
+}
+
+func deletedKey(messageID string) string {
+	return "deleted/" + messageID
+}
+
+// retainContent keeps the content of a message the bot deleted, if retention
+// is enabled, and schedules it to be scrubbed once the retention period is
+// over. Deletion counts are kept separately and are not affected.
+func (b *Bot) retainContent(channelID, messageID, authorID, content string) {
+	if b.config.DeletedRetention <= 0 {
+		return
+	}
+	now := time.Now()
+	msg := deletedMessage{ChannelID: channelID, MessageID: messageID, AuthorID: authorID, Content: content, DeletedAt: now}
+	if err := b.store.Put(deletedKey(messageID), msg); err != nil {
+		logger.Warn("failed to retain deleted message content", "message_id", messageID, "error", err)
+		return
+	}
+	job, err := schedule.NewJob(jobScrubDeleted+":"+messageID, jobScrubDeleted, now.Add(b.config.DeletedRetention),
+		scrubDeletedJob{MessageID: messageID})
+	if err == nil {
+		err = b.scheduler.Schedule(job)
+	}
+	if err != nil {
+		logger.Warn("failed to schedule scrubbing deleted message content", "message_id", messageID, "error", err)
+	}
+}
+
+// scrubContent removes the retained content of a deleted message.
+func (b *Bot) scrubContent(ctx context.Context, job schedule.Job) error {
+	var j scrubDeletedJob
+	if err := job.Decode(&j); err != nil {
+		return err
+	}
+	if err := b.store.Delete(deletedKey(j.MessageID)); err != nil {
+		return fmt.Errorf("failed to scrub deleted message content: %w", err)
+	}
+	logger.Debug("scrubbed deleted message content", "message_id", j.MessageID)
+	return nil
+}
+
+// handleDeleted shows the retained content of a message the bot deleted.
+func (b *Bot) handleDeleted(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	p := b.printer(i.GuildID)
+	if b.config.DeletedRetention <= 0 {
+		return textReply(p.T("deleted.disabled")), nil
+	}
+	ref, err := parseMessageRef(opts["message"].StringValue())
+	if err != nil {
+		return nil, err
+	}
+
+	var msg deletedMessage
+	found, err := b.store.Get(deletedKey(ref.MessageID), &msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load deleted message: %w", err)
+	}
+	if !found {
+		return textReply(p.T("deleted.none", ref.MessageID)), nil
+	}
+	return textReply(p.T("deleted.content", msg.AuthorID, msg.ChannelID, msg.DeletedAt.Unix(), msg.Content)), nil
+}
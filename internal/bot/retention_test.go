@@ -0,0 +1,106 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/stats"
+)
+
+func TestBot_RetainContent(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention time.Duration
+		wantKept  bool
+	}{
+		{"disabled by default", 0, false},
+		{"enabled", time.Hour, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+			cfg.DeletedRetention = tt.retention
+			b := New(cfg)
+			mock := &mockSession{messages: []*discordgo.Message{{ID: "msg1", Content: "💀 💀", Author: &discordgo.User{ID: "target-user"}}}}
+
+			if err := b.FinishSoftDelete(context.Background(), mock, "chan1", "msg1"); err != nil {
+				t.Fatalf("FinishSoftDelete() unexpected error: %v", err)
+			}
+
+			var msg deletedMessage
+			kept, _ := b.store.Get(deletedKey("msg1"), &msg)
+			if kept != tt.wantKept {
+				t.Fatalf("content kept = %v, want %v", kept, tt.wantKept)
+			}
+			if kept && (msg.Content != "💀 💀" || msg.AuthorID != "target-user" || msg.ChannelID != "chan1") {
+				t.Errorf("kept %+v", msg)
+			}
+			if wantJobs := map[bool]int{true: 1}[tt.wantKept]; b.scheduler.Pending() != wantJobs {
+				t.Errorf("pending jobs = %d, want %d", b.scheduler.Pending(), wantJobs)
+			}
+		})
+	}
+}
+
+func TestBot_ScrubContent(t *testing.T) {
+	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	cfg.DeletedRetention = 10 * time.Millisecond
+	b := New(cfg)
+	mock := &mockSession{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.startScheduler(ctx, mock)
+	if _, err := b.stats.RecordDeletion(time.Now(), "target-user"); err != nil {
+		t.Fatal(err)
+	}
+	b.retainContent("chan1", "msg1", "target-user", "💀")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for b.scheduler.Pending() > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	b.background.Wait()
+
+	if kept, _ := b.store.Get(deletedKey("msg1"), &deletedMessage{}); kept {
+		t.Error("content should be scrubbed after the retention period")
+	}
+	days, _ := b.stats.Days(time.Now(), 1)
+	if got := stats.Summarize(days).Deleted; got != 1 {
+		t.Errorf("deletion count = %d, want it kept at 1", got)
+	}
+}
+
+func TestBot_HandleDeleted(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention time.Duration
+		message   string
+		contains  string
+	}{
+		{"retained", time.Hour, "100", "Deleted message by <@target-user> in <#chan1>"},
+		{"not retained", time.Hour, "https://discord.com/channels/1/2/111", "No content is retained for message 111."},
+		{"retention disabled", 0, "100", "Set RETAIN_DELETED_CONTENT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+			cfg.DeletedRetention = tt.retention
+			b := New(cfg)
+			b.store.Put(deletedKey("100"), deletedMessage{ChannelID: "chan1", MessageID: "100", AuthorID: "target-user", Content: "💀", DeletedAt: time.Now()})
+			mock := &mockSession{}
+
+			b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"deleted"}, stringOption("message", tt.message)))
+
+			if got := lastResponse(t, mock); !strings.Contains(got, tt.contains) {
+				t.Errorf("response %q should contain %q", got, tt.contains)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/correlation"
+	"jolly-okurb/internal/schedule"
+	"jolly-okurb/internal/settings"
+)
+
+// jobJollySkullRetry is the scheduled job kind that adds a jollyskull that
+// failed to be added after the skull it replaces was removed.
+const jobJollySkullRetry = "jollyskull_retry"
+
+// jollySkullRetryDelay is how long after a failed add the first retry runs.
+// Later retries back off as the scheduler does for every job.
+const jollySkullRetryDelay = 5 * time.Second
+
+// jollySkullRetryJob identifies the message a jollyskull retry reacts to.
+type jollySkullRetryJob struct {
+	ChannelID string   `json:"channel_id"`
+	MessageID string   `json:"message_id"`
+	Emojis    []string `json:"emojis,omitempty"` // The replacements it reacts with instead of jollyskull
+
+	CorrelationID string `json:"correlation_id,omitempty"` // Of the replacement that lost its jollyskull
+}
+
+// scheduleJollySkullRetry schedules adding jollyskull, or emojis if there
+// are any, to a message once more. A message has at most one retry pending,
+// however many skulls it lost.
+func (b *Bot) scheduleJollySkullRetry(ctx context.Context, channelID, messageID string, emojis ...string) {
+	job, err := schedule.NewJob(jobJollySkullRetry+":"+messageID, jobJollySkullRetry, time.Now().Add(jollySkullRetryDelay),
+		jollySkullRetryJob{ChannelID: channelID, MessageID: messageID, Emojis: emojis, CorrelationID: correlation.ID(ctx)})
+	if err == nil {
+		err = b.scheduler.Schedule(job)
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to schedule jollyskull retry", "message_id", messageID, "error", err)
+		return
+	}
+	logger.InfoContext(ctx, "scheduled jollyskull retry", "message_id", messageID)
+}
+
+// RetryJollySkull adds jollyskull, or emojis if there are any, to a message
+// whose skull was removed without one taking its place. A message deleted
+// since needs nothing. While reactions are paused or disabled it postpones
+// the job instead.
+func (b *Bot) RetryJollySkull(ctx context.Context, s Session, channelID, messageID string, emojis ...string) error {
+	if !b.featureEnabled(settings.FeatureReactions) {
+		return schedule.Postpone(pausedJobDelay, "reactions are paused or disabled")
+	}
+	_, err := b.addJollySkull(ctx, s, policySkullReaction, channelID, messageID, emojis...)
+	if actions.Skipped(err) {
+		return nil
+	}
+	if isUnknownMessage(err) || isNotFound(err) {
+		b.messageVanished(ctx, messageID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add jollyskull reaction: %w", err)
+	}
+	logger.InfoContext(ctx, "added jollyskull on retry", "message_id", messageID)
+	return nil
+}
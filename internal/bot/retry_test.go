@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/metrics"
+)
+
+func TestBot_RetryJollySkull(t *testing.T) {
+	tests := []struct {
+		name         string
+		addErr       error
+		reacted      bool // The bot already put a jollyskull on the message
+		wantErr      bool
+		wantAdds     int
+		wantVanished int64
+	}{
+		{name: "adds jollyskull", wantAdds: 1},
+		{name: "already reacted", reacted: true},
+		{name: "fails for a retry", addErr: errors.New("HTTP 500"), wantErr: true, wantAdds: 1},
+		{
+			name:         "message deleted",
+			addErr:       &discordgo.RESTError{Message: &discordgo.APIErrorMessage{Code: discordgo.ErrCodeUnknownMessage}},
+			wantAdds:     1,
+			wantVanished: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := newRecordingSink()
+			b := New(newTestConfig([]string{"target123"}, "jollyskull:123"), WithMetrics(sink))
+			if tt.reacted {
+				b.reacted.add("msg1")
+			}
+			mock := &mockSession{addErr: tt.addErr}
+
+			err := b.RetryJollySkull(context.Background(), mock, "channel123", "msg1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RetryJollySkull() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(mock.addedReactions) != tt.wantAdds {
+				t.Errorf("added %d reactions, want %d", len(mock.addedReactions), tt.wantAdds)
+			}
+			if got := sink.counts[metrics.MessagesVanished]; got != tt.wantVanished {
+				t.Errorf("%s = %d, want %d", metrics.MessagesVanished, got, tt.wantVanished)
+			}
+		})
+	}
+}
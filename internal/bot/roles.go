@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// roleMembers tracks which members hold a DISCORD_TARGET_ROLE_IDS role, so
+// IsTargetUser can tell without a request per event. It is filled from the
+// member list requested at startup and kept current by member events.
+type roleMembers struct {
+	mu    sync.RWMutex
+	users map[string]struct{}
+}
+
+// set records whether userID holds a target role and reports whether that
+// changed.
+func (r *roleMembers) set(userID string, held bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, had := r.users[userID]
+	switch {
+	case held && !had:
+		if r.users == nil {
+			r.users = make(map[string]struct{})
+		}
+		r.users[userID] = struct{}{}
+	case !held && had:
+		delete(r.users, userID)
+	}
+	return held != had
+}
+
+func (r *roleMembers) contains(userID string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.users[userID]
+	return ok
+}
+
+func (r *roleMembers) len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.users)
+}
+
+// trackRoles records whether m holds a target role, as seen in an event or
+// member chunk.
+func (b *Bot) trackRoles(m *discordgo.Member) {
+	if len(b.config.TargetRoleIDs) == 0 || m == nil || m.User == nil {
+		return
+	}
+	held := slices.ContainsFunc(m.Roles, func(id string) bool { return slices.Contains(b.config.TargetRoleIDs, id) })
+	if b.roleTargets.set(m.User.ID, held) {
+		logger.Debug("target role changed", "user_id", m.User.ID, "held", held)
+	}
+}
+
+func (b *Bot) OnGuildMemberAdd(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+	if m.GuildID == b.config.GuildID {
+		b.rememberMember(m.Member)
+	}
+}
+
+// OnGuildMemberUpdate keeps the role targets current as roles are given and
+// taken away.
+func (b *Bot) OnGuildMemberUpdate(s *discordgo.Session, m *discordgo.GuildMemberUpdate) {
+	if m.GuildID == b.config.GuildID {
+		b.rememberMember(m.Member)
+	}
+}
+
+func (b *Bot) OnGuildMemberRemove(s *discordgo.Session, m *discordgo.GuildMemberRemove) {
+	if m.GuildID == b.config.GuildID && m.User != nil {
+		b.roleTargets.set(m.User.ID, false)
+	}
+}
@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestBot_TargetRoles(t *testing.T) {
+	cfg := newTestConfig([]string{"listed"}, "")
+	cfg.GuildID = "guild123"
+	cfg.TargetRoleIDs = []string{"role1"}
+	b := New(cfg)
+	member := func(userID string, roles ...string) *discordgo.Member {
+		return &discordgo.Member{GuildID: "guild123", User: &discordgo.User{ID: userID}, Roles: roles}
+	}
+
+	b.OnGuildMembersChunk(nil, &discordgo.GuildMembersChunk{GuildID: "guild123", Members: []*discordgo.Member{
+		member("holder", "role1"),
+		member("other", "role2"),
+	}})
+	b.OnGuildMemberAdd(nil, &discordgo.GuildMemberAdd{Member: member("joined", "role2", "role1")})
+	b.OnGuildMemberAdd(nil, &discordgo.GuildMemberAdd{Member: &discordgo.Member{GuildID: "elsewhere", User: &discordgo.User{ID: "stranger"}, Roles: []string{"role1"}}})
+
+	tests := []struct {
+		userID string
+		want   bool
+	}{
+		{"listed", true},
+		{"holder", true},
+		{"joined", true},
+		{"other", false},
+		{"stranger", false},
+	}
+	for _, tt := range tests {
+		if got := b.IsTargetUser(tt.userID); got != tt.want {
+			t.Errorf("IsTargetUser(%s) = %v, want %v", tt.userID, got, tt.want)
+		}
+	}
+
+	t.Run("role removed", func(t *testing.T) {
+		b.OnGuildMemberUpdate(nil, &discordgo.GuildMemberUpdate{Member: member("holder", "role2")})
+		if b.IsTargetUser("holder") {
+			t.Error("a member whose target role was removed should no longer be a target")
+		}
+	})
+
+	t.Run("role added", func(t *testing.T) {
+		b.OnGuildMemberUpdate(nil, &discordgo.GuildMemberUpdate{Member: member("other", "role2", "role1")})
+		if !b.IsTargetUser("other") {
+			t.Error("a member given a target role should be a target")
+		}
+	})
+
+	t.Run("member left", func(t *testing.T) {
+		b.OnGuildMemberRemove(nil, &discordgo.GuildMemberRemove{Member: member("joined")})
+		if b.IsTargetUser("joined") {
+			t.Error("a member who left should no longer be a target")
+		}
+	})
+}
@@ -0,0 +1,324 @@
+package bot
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/i18n"
+	"jolly-okurb/internal/ratelimit"
+)
+
+// allRules returns the emoji rules in the order they are tried, shadow rules
+// included: highest priority first, and among rules of the same priority the
+// skull rule, replacing skulls with jollyskull, then those of EMOJI_RULES in
+// the order they are listed.
+func (b *Bot) allRules() []config.EmojiRule {
+	skull := config.EmojiRule{
+		Name:        config.SkullRule,
+		Unicode:     unicodeSkullEmojis,
+		Keywords:    b.keywords(),
+		Replacement: b.config.JollySkullID,
+	}
+	rules := append([]config.EmojiRule{skull}, b.extraRules()...)
+	slices.SortStableFunc(rules, func(x, y config.EmojiRule) int { return cmp.Compare(y.Priority, x.Priority) })
+	return rules
+}
+
+// rules returns the emoji rules that act, in the order they are tried.
+func (b *Bot) rules() []config.EmojiRule {
+	return slices.DeleteFunc(b.allRules(), func(r config.EmojiRule) bool { return r.Shadow })
+}
+
+// extraRules returns the rules imported with /jolly rules import, or else
+// those of EMOJI_RULES.
+func (b *Bot) extraRules() []config.EmojiRule {
+	if r := b.storedRules.Load(); r != nil {
+		return *r
+	}
+	if r := b.emojiRules.Load(); r != nil {
+		return *r
+	}
+	return nil
+}
+
+// ruleFor returns the rule that replaces the emoji named name, the first
+// that matches it, and whether there is one. Custom emojis the guild excluded have none.
+func (b *Bot) ruleFor(name string) (config.EmojiRule, bool) {
+	if b.guildSettings().IsExcluded(name) {
+		return config.EmojiRule{}, false
+	}
+	rules := b.rules()
+	i := slices.IndexFunc(rules, func(r config.EmojiRule) bool { return r.Matches(name) })
+	if i < 0 {
+		return config.EmojiRule{}, false
+	}
+	return rules[i], true
+}
+
+// shadowRuleFor returns the shadow rule that would replace the emoji named
+// name if shadow rules acted, and whether there is one.
+func (b *Bot) shadowRuleFor(name string) (config.EmojiRule, bool) {
+	if b.guildSettings().IsExcluded(name) {
+		return config.EmojiRule{}, false
+	}
+	rules := b.allRules()
+	i := slices.IndexFunc(rules, func(r config.EmojiRule) bool { return r.Matches(name) })
+	if i < 0 || !rules[i].Shadow {
+		return config.EmojiRule{}, false
+	}
+	return rules[i], true
+}
+
+// ruleLimits holds the token buckets of the emoji rules that set a limit.
+type ruleLimits struct {
+	mu      sync.Mutex
+	buckets map[string]ruleBucket // By rule name
+}
+
+// ruleBucket is the token bucket of a rule's limit, and the limit it was
+// made for, so that a rule imported or reloaded with another limit gets a
+// new one.
+type ruleBucket struct {
+	limit  string
+	bucket *ratelimit.Bucket
+}
+
+// ruleLimit returns the token bucket of the limit of the rule named name,
+// which replacements under it wait for on top of REACTION_RATE_LIMIT, or nil
+// if it has none.
+func (b *Bot) ruleLimit(name string) *ratelimit.Bucket {
+	rules := b.rules()
+	i := slices.IndexFunc(rules, func(r config.EmojiRule) bool { return r.Name == name })
+	if i < 0 {
+		return nil
+	}
+	n, period, ok := rules[i].Rate()
+	if !ok {
+		return nil
+	}
+
+	b.ruleLimits.mu.Lock()
+	defer b.ruleLimits.mu.Unlock()
+	if rb, ok := b.ruleLimits.buckets[name]; ok && rb.limit == rules[i].Limit {
+		return rb.bucket
+	}
+	if b.ruleLimits.buckets == nil {
+		b.ruleLimits.buckets = make(map[string]ruleBucket)
+	}
+	rb := ruleBucket{limit: rules[i].Limit, bucket: ratelimit.New(n, period)}
+	b.ruleLimits.buckets[name] = rb
+	return rb.bucket
+}
+
+// unicodeEmojis returns the Unicode emojis of every rule, longest first, so
+// that ☠️ is stripped before ☠ rather than leaving its variant selector.
+func (b *Bot) unicodeEmojis() []string {
+	var emojis []string
+	for _, r := range b.rules() {
+		emojis = append(emojis, r.Unicode...)
+	}
+	slices.SortStableFunc(emojis, func(a, b string) int { return cmp.Compare(len(b), len(a)) })
+	return emojis
+}
+
+// replacementOf returns the emoji the bot reacts with under rule: its
+// replacement, or for the skull rule jollyskull or its fallback.
+func (b *Bot) replacementOf(rule config.EmojiRule) string {
+	if rule.Name == config.SkullRule {
+		return b.jollyEmoji()
+	}
+	return rule.Replacement
+}
+
+// reactedKey returns the key under which the bot remembers reacting to
+// messageID with emoji. Skull replacements stand in for each other and share
+// the message ID; those of EMOJI_RULES get a key of their own, so a jollysob
+// does not stop the bot from adding jollyskull.
+func (b *Bot) reactedKey(messageID, emoji string) string {
+	if slices.ContainsFunc(b.extraRules(), func(r config.EmojiRule) bool { return r.Replacement == emoji }) {
+		return messageID + "/" + emoji
+	}
+	return messageID
+}
+
+// forgetReacted forgets every reaction the bot is known to have on messageID.
+func (b *Bot) forgetReacted(messageID string) {
+	b.reacted.remove(messageID)
+	for _, r := range b.extraRules() {
+		b.reacted.remove(messageID + "/" + r.Replacement)
+	}
+}
+
+// rulesExportName is the file /jolly rules export replies with.
+const rulesExportName = "jolly-rules.json"
+
+// handleRulesExport replies with the rules of EMOJI_RULES as a JSON document,
+// which EMOJI_RULES and the emoji_rules of a config file take as is, so rules
+// can be copied to another server or kept under version control.
+func (b *Bot) handleRulesExport(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	p := b.printer(i.GuildID)
+	rules := b.extraRules()
+	if len(rules) == 0 {
+		return textReply(p.T("rules.none")), nil
+	}
+	doc, err := json.MarshalIndent(rules, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("emoji rules exported", "rules", len(rules), "by", interactionUserID(i))
+
+	reply := textReply(p.T("rules.export", len(rules)))
+	reply.Files = []*discordgo.File{{
+		Name:        rulesExportName,
+		ContentType: "application/json",
+		Reader:      bytes.NewReader(append(doc, '\n')),
+	}}
+	return reply, nil
+}
+
+// storedRulesKey stores the emoji rules imported with /jolly rules import.
+const storedRulesKey = "rules"
+
+// maxRulesDocument is the largest rules file /jolly rules import reads.
+const maxRulesDocument = 1 << 20
+
+// attachmentClient fetches the files attached to commands.
+var attachmentClient = &http.Client{Timeout: 10 * time.Second}
+
+// loadStoredRules restores the emoji rules imported before.
+func (b *Bot) loadStoredRules() {
+	var rules []config.EmojiRule
+	ok, err := b.store.Get(storedRulesKey, &rules)
+	if err != nil {
+		logger.Error("failed to load imported emoji rules", "error", err)
+		return
+	}
+	if ok {
+		b.storedRules.Store(&rules)
+		logger.Info("emoji rules imported with /jolly rules import override EMOJI_RULES", "rules", len(rules))
+	}
+}
+
+// handleRulesImport validates emoji rules from an attached file or pasted
+// text the way EMOJI_RULES is validated and previews how they differ from the
+// current ones. With apply, it stores them, and they replace EMOJI_RULES
+// until /jolly rules reset.
+func (b *Bot) handleRulesImport(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	p := b.printer(i.GuildID)
+	file, text := opts["file"], opts["text"]
+	if (file == nil) == (text == nil) {
+		return textReply(p.T("rules.import.source")), nil
+	}
+	var spec string
+	if text != nil {
+		spec = text.StringValue()
+	} else {
+		doc, err := fetchAttachment(i, file)
+		if err != nil {
+			return nil, err
+		}
+		spec = string(doc)
+	}
+	rules, err := config.ParseEmojiRules(spec, b.config.JollySkullID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rules: %w", err)
+	}
+
+	var sb strings.Builder
+	apply := opts["apply"] != nil && opts["apply"].BoolValue()
+	if apply {
+		if err := b.store.Put(storedRulesKey, rules); err != nil {
+			return nil, fmt.Errorf("failed to save emoji rules: %w", err)
+		}
+		old := b.extraRules()
+		b.storedRules.Store(&rules)
+		logger.Info("emoji rules imported", "rules", len(rules), "by", interactionUserID(i))
+		fmt.Fprintln(&sb, p.T("rules.import.done", len(rules)))
+		writeRulesDiff(&sb, p, old, rules)
+		return textReply(sb.String()), nil
+	}
+	fmt.Fprintln(&sb, p.T("rules.import.preview", len(rules)))
+	writeRulesDiff(&sb, p, b.extraRules(), rules)
+	sb.WriteString(p.T("rules.import.apply"))
+	return textReply(sb.String()), nil
+}
+
+// handleRulesReset forgets the imported emoji rules, so those of EMOJI_RULES
+// apply again.
+func (b *Bot) handleRulesReset(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	p := b.printer(i.GuildID)
+	if b.storedRules.Load() == nil {
+		return textReply(p.T("rules.reset.none")), nil
+	}
+	if err := b.store.Delete(storedRulesKey); err != nil {
+		return nil, fmt.Errorf("failed to delete emoji rules: %w", err)
+	}
+	b.storedRules.Store(nil)
+	logger.Info("imported emoji rules reset", "by", interactionUserID(i))
+	return textReply(p.T("rules.reset", len(b.extraRules()))), nil
+}
+
+// writeRulesDiff writes a line to sb for each rule that replacing old with
+// rules adds, changes, or removes, by name.
+func writeRulesDiff(sb *strings.Builder, p i18n.Printer, old, rules []config.EmojiRule) {
+	before := make(map[string]string, len(old))
+	for _, r := range old {
+		before[r.Name] = fmt.Sprint(r)
+	}
+	changes := 0
+	for _, r := range rules {
+		was, ok := before[r.Name]
+		delete(before, r.Name)
+		switch {
+		case !ok:
+			fmt.Fprintln(sb, p.T("rules.diff.added", r.Name))
+		case was != fmt.Sprint(r):
+			fmt.Fprintln(sb, p.T("rules.diff.changed", r.Name))
+		default:
+			continue
+		}
+		changes++
+	}
+	for _, r := range old {
+		if _, ok := before[r.Name]; ok {
+			fmt.Fprintln(sb, p.T("rules.diff.removed", r.Name))
+			changes++
+		}
+	}
+	if changes == 0 {
+		fmt.Fprintln(sb, p.T("rules.diff.none"))
+	}
+}
+
+// fetchAttachment downloads the file of an attachment option of i.
+func fetchAttachment(i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) ([]byte, error) {
+	data := i.ApplicationCommandData()
+	id, _ := opt.Value.(string)
+	if data.Resolved == nil || data.Resolved.Attachments[id] == nil {
+		return nil, fmt.Errorf("attachment %s not found", id)
+	}
+	attachment := data.Resolved.Attachments[id]
+	if attachment.Size > maxRulesDocument {
+		return nil, fmt.Errorf("%s is larger than %d bytes", attachment.Filename, maxRulesDocument)
+	}
+	resp, err := attachmentClient.Get(attachment.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", attachment.Filename, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", attachment.Filename, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxRulesDocument))
+}
@@ -0,0 +1,271 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/store"
+)
+
+func TestBot_EmojiRules(t *testing.T) {
+	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	cfg.EmojiRules = []config.EmojiRule{{
+		Name:        "sob",
+		Unicode:     []string{"😭"},
+		Keywords:    config.SkullKeywords{Match: []string{"sob"}, Exclude: []string{"jollysob"}},
+		Replacement: "jollysob:456",
+	}}
+	b := New(cfg)
+
+	t.Run("matching", func(t *testing.T) {
+		tests := []struct {
+			emoji *discordgo.Emoji
+			want  bool
+		}{
+			{&discordgo.Emoji{Name: "😭"}, true},
+			{&discordgo.Emoji{Name: "SobbingCat", ID: "7"}, true},
+			{&discordgo.Emoji{Name: "💀"}, true},
+			{&discordgo.Emoji{Name: "jollysob", ID: "456"}, false},
+			{&discordgo.Emoji{Name: "😂"}, false},
+		}
+		for _, tt := range tests {
+			if got := b.IsSkullEmoji(tt.emoji); got != tt.want {
+				t.Errorf("IsSkullEmoji(%s) = %v, want %v", tt.emoji.Name, got, tt.want)
+			}
+		}
+		if !b.IsSkullOnlyMessage("😭 💀 <:sobbingcat:7>") {
+			t.Error("a message of sobs and skulls should count as skull-only")
+		}
+		if !b.isJollySkull(&discordgo.Emoji{Name: "jollysob", ID: "456"}) {
+			t.Error("a rule's replacement should count as the bot's own")
+		}
+	})
+
+	t.Run("message content", func(t *testing.T) {
+		content := "so sad 😭 <:sobbingcat:7> ☠️"
+		if got, want := b.skullEmojisIn(content), []string{"<:sobbingcat:7>", "☠️", "😭"}; !slices.Equal(got, want) {
+			t.Errorf("skullEmojisIn() = %v, want %v", got, want)
+		}
+		if got, want := b.jollified(content), "so sad <:jollysob:456> <:jollysob:456> <:jollyskull:123>"; got != want {
+			t.Errorf("jollified() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("replacement", func(t *testing.T) {
+		b := New(cfg)
+		mock := &mockSession{}
+
+		b.ReplaceReaction(context.Background(), mock, "chan1", "msg1", "target-user", &discordgo.Emoji{Name: "😭"})
+		b.ReplaceReaction(context.Background(), mock, "chan1", "msg1", "target-user", &discordgo.Emoji{Name: "💀"})
+		b.ReplaceReaction(context.Background(), mock, "chan1", "msg1", "target-user", &discordgo.Emoji{Name: "😭"})
+
+		var added []string
+		for _, r := range mock.addedReactions {
+			added = append(added, r.emojiID)
+		}
+		if want := []string{"jollysob:456", "jollyskull:123"}; !slices.Equal(added, want) {
+			t.Errorf("added %v, want %v: each rule's replacement once", added, want)
+		}
+		if len(mock.removedReactions) != 3 {
+			t.Errorf("removed %d reactions, want every sob and skull", len(mock.removedReactions))
+		}
+	})
+
+	t.Run("priority", func(t *testing.T) {
+		cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+		cfg.EmojiRules = []config.EmojiRule{
+			{Name: "tie", Unicode: []string{"💀", "😭"}, Replacement: "🎉"},
+			{Name: "first", Unicode: []string{"😭"}, Replacement: "🥳", Priority: 1},
+			{Name: "party", Unicode: []string{"☠"}, Replacement: "🎊", Priority: 2},
+		}
+		var buf bytes.Buffer
+		b := New(cfg, WithAuditExport(&buf))
+
+		for name, want := range map[string]string{"💀": config.SkullRule, "😭": "first", "☠": "party"} {
+			if rule, _ := b.ruleFor(name); rule.Name != want {
+				t.Errorf("ruleFor(%s) = %q, want %q", name, rule.Name, want)
+			}
+		}
+
+		b.ReplaceReaction(context.Background(), &mockSession{}, "chan1", "msg1", "target-user", &discordgo.Emoji{Name: "😭"})
+		var entry auditLogEntry
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("expected an audit log entry, got %q: %v", buf.String(), err)
+		}
+		if entry.Rule != "first" {
+			t.Errorf("audit log entry rule = %q, want %q", entry.Rule, "first")
+		}
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		b := New(newTestConfig(nil, "jollyskull:123"))
+		if b.IsSkullEmoji(&discordgo.Emoji{Name: "😭"}) {
+			t.Fatal("😭 should not be replaced before the reload")
+		}
+		b.Reload(cfg)
+		if !b.IsSkullEmoji(&discordgo.Emoji{Name: "😭"}) {
+			t.Error("😭 should be replaced after the reload")
+		}
+	})
+}
+
+func TestBot_RuleLimit(t *testing.T) {
+	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	cfg.EmojiRules = []config.EmojiRule{{Name: "sob", Unicode: []string{"😭"}, Replacement: "jollysob:456", Limit: "1/1h"}}
+	b := New(cfg)
+	mock := &mockSession{}
+	expired, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if !b.ReplaceReaction(context.Background(), mock, "chan1", "msg1", "target-user", &discordgo.Emoji{Name: "😭"}) {
+		t.Fatal("the first sob should be replaced within the rule's limit")
+	}
+	if b.ReplaceReaction(expired, mock, "chan1", "msg2", "target-user", &discordgo.Emoji{Name: "😭"}) {
+		t.Error("a second sob should wait for the rule's limit")
+	}
+	if !b.ReplaceReaction(context.Background(), mock, "chan1", "msg3", "target-user", &discordgo.Emoji{Name: "💀"}) {
+		t.Error("the skull rule should not share the sob rule's limit")
+	}
+	if len(mock.removedReactions) != 2 {
+		t.Errorf("removed %d reactions, want 2", len(mock.removedReactions))
+	}
+
+	rules := []config.EmojiRule{{Name: "sob", Unicode: []string{"😭"}, Replacement: "jollysob:456", Limit: "2/1h"}}
+	b.storedRules.Store(&rules)
+	if !b.ReplaceReaction(expired, mock, "chan1", "msg2", "target-user", &discordgo.Emoji{Name: "😭"}) {
+		t.Error("an imported rule with another limit should get a bucket of its own")
+	}
+}
+
+func TestBot_HandleInteraction_RulesExport(t *testing.T) {
+	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	cfg.GuildID = "guild123"
+	mock := &mockSession{}
+
+	New(cfg).HandleInteraction(mock, newCommandInteraction("guild123", []string{"rules", "export"}))
+	if got, want := lastResponse(t, mock), "There are no emoji rules besides the built-in skull rule."; got != want {
+		t.Errorf("without rules = %q, want %q", got, want)
+	}
+
+	cfg.EmojiRules = []config.EmojiRule{{
+		Name:        "sob",
+		Unicode:     []string{"😭"},
+		Keywords:    config.SkullKeywords{Match: []string{"sob"}, Exclude: []string{"jollysob"}},
+		Replacement: "jollysob:456",
+		Priority:    1,
+		Shadow:      true,
+	}}
+	New(cfg).HandleInteraction(mock, newCommandInteraction("guild123", []string{"rules", "export"}))
+	lastResponse(t, mock)
+	files := mock.responses[len(mock.responses)-1].Data.Files
+	if len(files) != 1 || files[0].Name != rulesExportName {
+		t.Fatalf("expected %s attached, got %v", rulesExportName, files)
+	}
+	var doc []map[string]any
+	if err := json.NewDecoder(files[0].Reader).Decode(&doc); err != nil {
+		t.Fatalf("export is not JSON: %v", err)
+	}
+	want := []map[string]any{{
+		"name":        "sob",
+		"unicode":     []any{"😭"},
+		"keywords":    "sob,!jollysob",
+		"replacement": "jollysob:456",
+		"priority":    float64(1),
+		"shadow":      true,
+	}}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("export = %v, want %v", doc, want)
+	}
+}
+
+func TestBot_HandleInteraction_RulesImport(t *testing.T) {
+	const sob = `[{"name":"sob","unicode":["😭"],"keywords":"sob,!jollysob","replacement":"jollysob:456"}]`
+	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	cfg.GuildID = "guild123"
+	cfg.EmojiRules = []config.EmojiRule{{Name: "cry", Unicode: []string{"😢"}, Replacement: "jollycry:789"}}
+	st := store.NewMemory()
+	b := New(cfg, WithStore(st))
+	mock := &mockSession{}
+	run := func(path []string, opts ...*discordgo.ApplicationCommandInteractionDataOption) string {
+		b.HandleInteraction(mock, newCommandInteraction("guild123", path, opts...))
+		return lastResponse(t, mock)
+	}
+	apply := &discordgo.ApplicationCommandInteractionDataOption{Name: "apply", Type: discordgo.ApplicationCommandOptionBoolean, Value: true}
+
+	t.Run("preview", func(t *testing.T) {
+		got := run([]string{"rules", "import"}, stringOption("text", sob))
+		for _, want := range []string{"would set 1 emoji rule(s)", "- sob: added", "- cry: removed", "Nothing was imported"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("preview %q should contain %q", got, want)
+			}
+		}
+		if b.IsSkullEmoji(&discordgo.Emoji{Name: "😭"}) {
+			t.Error("a preview should not change the rules")
+		}
+	})
+
+	t.Run("invalid rules", func(t *testing.T) {
+		got := run([]string{"rules", "import"}, stringOption("text", `[{"name":"sob","unicode":["😭"],"replacement":"jollysob:456","colour":"blue"}]`), apply)
+		if !strings.HasPrefix(got, "Error:") || !strings.Contains(got, "colour") {
+			t.Errorf("reply %q should reject the unknown field", got)
+		}
+		if got := run([]string{"rules", "import"}, stringOption("text", `[{"name":"skull","unicode":["😭"],"replacement":"jollysob:456"}]`), apply); !strings.Contains(got, "built in") {
+			t.Errorf("reply %q should refuse to redefine the skull rule", got)
+		}
+	})
+
+	t.Run("file and text", func(t *testing.T) {
+		if got := run([]string{"rules", "import"}); !strings.Contains(got, "not both") {
+			t.Errorf("reply %q should ask for rules", got)
+		}
+	})
+
+	t.Run("apply from a file", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(sob))
+		}))
+		defer srv.Close()
+		i := newCommandInteraction("guild123", []string{"rules", "import"},
+			&discordgo.ApplicationCommandInteractionDataOption{Name: "file", Type: discordgo.ApplicationCommandOptionAttachment, Value: "att1"}, apply)
+		data := i.ApplicationCommandData()
+		data.Resolved = &discordgo.ApplicationCommandInteractionDataResolved{Attachments: map[string]*discordgo.MessageAttachment{
+			"att1": {ID: "att1", Filename: "jolly-rules.json", URL: srv.URL + "/jolly-rules.json", Size: len(sob)},
+		}}
+		i.Data = data
+
+		b.HandleInteraction(mock, i)
+
+		if got := lastResponse(t, mock); !strings.Contains(got, "Imported 1 emoji rule(s)") {
+			t.Errorf("reply %q should confirm the import", got)
+		}
+		if !b.IsSkullEmoji(&discordgo.Emoji{Name: "😭"}) || b.IsSkullEmoji(&discordgo.Emoji{Name: "😢"}) {
+			t.Error("the imported rules should replace EMOJI_RULES")
+		}
+		if restarted := New(cfg, WithStore(st)); !restarted.IsSkullEmoji(&discordgo.Emoji{Name: "😭"}) {
+			t.Error("the imported rules should survive a restart")
+		}
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		if got := run([]string{"rules", "reset"}); !strings.Contains(got, "the 1 of EMOJI_RULES apply again") {
+			t.Errorf("reply %q should confirm the reset", got)
+		}
+		if b.IsSkullEmoji(&discordgo.Emoji{Name: "😭"}) || !b.IsSkullEmoji(&discordgo.Emoji{Name: "😢"}) {
+			t.Error("EMOJI_RULES should apply again after a reset")
+		}
+		if got := run([]string{"rules", "reset"}); !strings.Contains(got, "No emoji rules were imported") {
+			t.Errorf("second reset = %q, want nothing to reset", got)
+		}
+	})
+}
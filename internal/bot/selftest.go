@@ -0,0 +1,164 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/i18n"
+)
+
+// selfTestSkull is the skull the self-test reacts with.
+const selfTestSkull = "💀"
+
+// selfTestStep is one stage of the self-test and how it went.
+type selfTestStep struct {
+	key string // i18n key of the step name
+	err error
+}
+
+// handleSelfTest starts an end-to-end test of reaction replacement in the
+// sandbox channel. It runs in the background, since it takes more API calls
+// than an interaction reply may wait for, and posts its result there.
+func (b *Bot) handleSelfTest(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	p := b.printer(i.GuildID)
+	if i.Member == nil || i.Member.Permissions&panelPermissions == 0 {
+		return textReply(p.T("selftest.denied")), nil
+	}
+	channelID := b.config.SelfTestChannelID
+	if channelID == "" {
+		return textReply(p.T("selftest.no_channel")), nil
+	}
+	if b.config.ReadOnly {
+		return textReply(p.T("read_only")), nil
+	}
+	if !b.selfTestMu.TryLock() {
+		return textReply(p.T("selftest.running")), nil
+	}
+
+	logger.Info("self-test started", "channel_id", channelID, "by", interactionUserID(i))
+	b.background.Go(func() {
+		defer b.selfTestMu.Unlock()
+		b.runSelfTest(s, p, channelID)
+	})
+	return textReply(p.T("selftest.started", channelID)), nil
+}
+
+// runSelfTest posts a test message, reacts to it with a skull as the bot,
+// replaces the skull the way target users' skulls are replaced, checks the
+// reactions through the API, deletes the message, and posts the outcome.
+// Dry run does not apply, as the sandbox is for exactly this, so its actions
+// enforce no policy. Stats, events, and the audit export are left untouched.
+func (b *Bot) runSelfTest(s Session, p i18n.Printer, channelID string) {
+	ctx := context.Background()
+	start := time.Now()
+	var steps []selfTestStep
+	step := func(key string, fn func() error) bool {
+		err := fn()
+		steps = append(steps, selfTestStep{key, err})
+		return err == nil
+	}
+	act := func(kind actions.Kind, messageID string, do func() error) error {
+		return b.executor.Do(ctx, actions.Action{
+			Kind:      kind,
+			ChannelID: channelID,
+			MessageID: messageID,
+			Do:        func(...discordgo.RequestOption) error { return do() },
+		})
+	}
+
+	var msg *discordgo.Message
+	self := b.selfID()
+	ok := step("selftest.step.post", func() error {
+		return act(actions.Post, "", func() (err error) {
+			msg, err = s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+				Content:         p.T("selftest.message"),
+				AllowedMentions: &discordgo.MessageAllowedMentions{},
+			})
+			return err
+		})
+	})
+	ok = ok && step("selftest.step.react", func() error {
+		return act(actions.AddReaction, msg.ID, func() error {
+			return s.MessageReactionAdd(channelID, msg.ID, selfTestSkull)
+		})
+	})
+	ok = ok && step("selftest.step.replace", func() error {
+		_, err := b.swapSkull(ctx, s, actions.Action{ChannelID: channelID, MessageID: msg.ID, UserID: self}, selfTestSkull, []string{config.StepRemove, config.StepAdd}, nil)
+		return err
+	})
+	ok = ok && step("selftest.step.verify", func() error {
+		return b.verifySelfTest(s, p, channelID, msg.ID, self)
+	})
+	if msg != nil {
+		step("selftest.step.cleanup", func() error {
+			b.forgetReacted(msg.ID)
+			return act(actions.DeleteMessage, msg.ID, func() error {
+				return s.ChannelMessageDelete(channelID, msg.ID)
+			})
+		})
+	}
+
+	passed := ok && steps[len(steps)-1].err == nil // Cleanup must succeed too
+	var sb strings.Builder
+	for _, st := range steps {
+		if st.err != nil {
+			fmt.Fprintf(&sb, "❌ %s: %v\n", p.T(st.key), st.err)
+		} else {
+			fmt.Fprintf(&sb, "✅ %s\n", p.T(st.key))
+		}
+	}
+	if passed {
+		sb.WriteString(p.T("selftest.passed", time.Since(start).Round(time.Millisecond)))
+		logger.Info("self-test passed", "channel_id", channelID, "duration", time.Since(start))
+	} else {
+		sb.WriteString(p.T("selftest.failed"))
+		logger.Warn("self-test failed", "channel_id", channelID)
+	}
+
+	err := act(actions.Post, "", func() error {
+		_, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+			Content:         sb.String(),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+		return err
+	})
+	if err != nil {
+		logger.Error("failed to post self-test result", "channel_id", channelID, "error", err)
+	}
+}
+
+// verifySelfTest checks through the API that the bot's skull is gone from
+// the message and its jollyskull is there.
+func (b *Bot) verifySelfTest(s Session, p i18n.Printer, channelID, messageID, self string) error {
+	reactedBySelf := func(emojiID string) (bool, error) {
+		users, err := s.MessageReactions(channelID, messageID, emojiID, 100, "", "")
+		if err != nil {
+			return false, err
+		}
+		return slices.ContainsFunc(users, func(u *discordgo.User) bool { return u.ID == self }), nil
+	}
+
+	skull, err := reactedBySelf(selfTestSkull)
+	if err != nil {
+		return err
+	}
+	if skull {
+		return errors.New(p.T("selftest.verify.skull_left"))
+	}
+	jolly, err := reactedBySelf(b.jollyEmoji())
+	if err != nil {
+		return err
+	}
+	if !jolly {
+		return errors.New(p.T("selftest.verify.no_jolly"))
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package bot
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// reactionSession tracks reactions so that MessageReactions reflects the
+// adds and removes before it, as Discord does. Reactions are added as bot1.
+type reactionSession struct {
+	*mockSession
+	mu        sync.Mutex
+	reactions map[string][]string // User IDs by message ID and emoji
+	keepSkull bool                // Ignore removals, as if Discord dropped them
+}
+
+func newReactionSession() *reactionSession {
+	return &reactionSession{mockSession: &mockSession{}, reactions: make(map[string][]string)}
+}
+
+func (r *reactionSession) MessageReactionAdd(channelID, messageID, emojiID string, options ...discordgo.RequestOption) error {
+	if err := r.mockSession.MessageReactionAdd(channelID, messageID, emojiID, options...); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reactions[messageID+"/"+emojiID] = append(r.reactions[messageID+"/"+emojiID], "bot1")
+	return nil
+}
+
+func (r *reactionSession) MessageReactionRemove(channelID, messageID, emojiID, userID string, options ...discordgo.RequestOption) error {
+	if err := r.mockSession.MessageReactionRemove(channelID, messageID, emojiID, userID, options...); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.keepSkull {
+		key := messageID + "/" + emojiID
+		r.reactions[key] = slices.DeleteFunc(r.reactions[key], func(id string) bool { return id == userID })
+	}
+	return nil
+}
+
+func (r *reactionSession) MessageReactions(channelID, messageID, emojiID string, limit int, beforeID, afterID string, options ...discordgo.RequestOption) ([]*discordgo.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var users []*discordgo.User
+	for _, id := range r.reactions[messageID+"/"+emojiID] {
+		users = append(users, &discordgo.User{ID: id})
+	}
+	return users, nil
+}
+
+func TestBot_SelfTest(t *testing.T) {
+	tests := []struct {
+		name        string
+		channel     string
+		perms       int64
+		setup       func(r *reactionSession)
+		wantReply   string
+		wantResult  []string
+		wantDeleted bool
+	}{
+		{
+			name:        "passes",
+			channel:     "sandbox",
+			perms:       discordgo.PermissionManageGuild,
+			wantReply:   "Self-test started in <#sandbox>",
+			wantResult:  []string{"✅ Post a test message", "✅ Replace 💀 with jollyskull", "✅ Check the reactions", "✅ Delete the test message", "Self-test passed"},
+			wantDeleted: true,
+		},
+		{
+			name:        "skull not removed",
+			channel:     "sandbox",
+			perms:       discordgo.PermissionManageGuild,
+			setup:       func(r *reactionSession) { r.keepSkull = true },
+			wantReply:   "Self-test started",
+			wantResult:  []string{"❌ Check the reactions: the 💀 reaction is still there", "✅ Delete the test message", "Self-test failed"},
+			wantDeleted: true,
+		},
+		{
+			name:        "jollyskull not added",
+			channel:     "sandbox",
+			perms:       discordgo.PermissionManageGuild,
+			setup:       func(r *reactionSession) { r.addErr = errors.New("HTTP 403 Forbidden") },
+			wantReply:   "Self-test started",
+			wantResult:  []string{"❌ React with 💀: HTTP 403 Forbidden", "✅ Delete the test message", "Self-test failed"},
+			wantDeleted: true,
+		},
+		{
+			name:        "cleanup fails",
+			channel:     "sandbox",
+			perms:       discordgo.PermissionManageGuild,
+			setup:       func(r *reactionSession) { r.deleteErr = errors.New("HTTP 403 Forbidden") },
+			wantReply:   "Self-test started",
+			wantResult:  []string{"✅ Check the reactions", "❌ Delete the test message", "Self-test failed"},
+			wantDeleted: true,
+		},
+		{name: "no sandbox channel", perms: discordgo.PermissionManageGuild, wantReply: "SELFTEST_CHANNEL_ID"},
+		{name: "denied", channel: "sandbox", wantReply: "You need Manage Server"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig([]string{"target123"}, "jollyskull:123")
+			cfg.SelfTestChannelID = tt.channel
+			b := New(cfg)
+			b.self = "bot1"
+			r := newReactionSession()
+			if tt.setup != nil {
+				tt.setup(r)
+			}
+
+			i := newCommandInteraction("guild123", []string{"selftest"})
+			i.Member.Permissions = tt.perms
+			b.HandleInteraction(r, i)
+			b.background.Wait()
+
+			if got := lastResponse(t, r.mockSession); !strings.Contains(got, tt.wantReply) {
+				t.Errorf("reply = %q, want it to contain %q", got, tt.wantReply)
+			}
+			if tt.wantResult == nil {
+				if len(r.sent) > 0 {
+					t.Errorf("posted %d messages, want none", len(r.sent))
+				}
+				return
+			}
+
+			if len(r.sent) != 2 {
+				t.Fatalf("posted %d messages, want the test message and the result", len(r.sent))
+			}
+			result := r.sent[1]
+			if result.channelID != "sandbox" {
+				t.Errorf("result posted to %q, want sandbox", result.channelID)
+			}
+			for _, want := range tt.wantResult {
+				if !strings.Contains(result.content, want) {
+					t.Errorf("result does not contain %q:\n%s", want, result.content)
+				}
+			}
+			if got := slices.Contains(r.deleted, "sent1"); got != tt.wantDeleted {
+				t.Errorf("test message deleted = %v, want %v", got, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestBot_SelfTest_NoSideEffects(t *testing.T) {
+	sink := newRecordingSink()
+	cfg := newTestConfig([]string{"target123"}, "jollyskull:123")
+	cfg.SelfTestChannelID = "sandbox"
+	cfg.DryRun = true // The sandbox is tested regardless
+	b := New(cfg, WithMetrics(sink))
+	b.self = "bot1"
+	r := newReactionSession()
+
+	i := newCommandInteraction("guild123", []string{"selftest"})
+	i.Member.Permissions = discordgo.PermissionAdministrator
+	b.HandleInteraction(r, i)
+	b.background.Wait()
+
+	if len(r.sent) != 2 || !strings.Contains(r.sent[1].content, "Self-test passed") {
+		t.Fatalf("self-test did not pass: %+v", r.sent)
+	}
+	if n := sink.counts["reactions.replaced"]; n != 0 {
+		t.Errorf("counted %d replacements, want none for the self-test", n)
+	}
+	days, err := b.stats.Days(time.Now(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if days[0].Total() != 0 {
+		t.Errorf("recorded stats %+v, want none for the self-test", days[0])
+	}
+}
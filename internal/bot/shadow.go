@@ -0,0 +1,38 @@
+package bot
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/correlation"
+	"jolly-okurb/internal/metrics"
+	"jolly-okurb/internal/settings"
+)
+
+// evaluateShadowRules records that a shadow rule would have replaced r, if
+// one would and the bot would otherwise consider r: a target user's
+// reaction in a watched channel, with reactions on, to a message that is not
+// exempt. It logs the match, counts it, and exports it to the audit log with
+// the rule, but changes nothing on Discord.
+func (b *Bot) evaluateShadowRules(r *discordgo.MessageReactionAdd) {
+	rule, ok := b.shadowRuleFor(r.Emoji.Name)
+	if !ok || !b.isWatched(r.GuildID, r.ChannelID) || !b.IsTargetUser(r.UserID) {
+		return
+	}
+	if !b.featureEnabled(settings.FeatureReactions) || b.isExempt(r.MessageID) {
+		return
+	}
+
+	ctx := correlation.Start(context.Background())
+	logger.InfoContext(ctx, "shadow rule would replace reaction", "rule", rule.Name, "message_id", r.MessageID, "user_id", r.UserID, "emoji", r.Emoji.Name)
+	metrics.Incr(b.sink(), metrics.ShadowMatches, b.metricTags(r.ChannelID, rule.Name, r.Emoji.Name)...)
+	b.exportAction(ctx, actions.Action{
+		Policy:    policySkullReaction,
+		ChannelID: r.ChannelID,
+		MessageID: r.MessageID,
+		UserID:    r.UserID,
+		Rule:      rule.Name,
+	}, auditShadow)
+}
@@ -0,0 +1,59 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/metrics"
+)
+
+func TestBot_ShadowRules(t *testing.T) {
+	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	cfg.EmojiRules = []config.EmojiRule{{
+		Name:        "sob",
+		Unicode:     []string{"😭"},
+		Replacement: "jollysob:456",
+		Shadow:      true,
+	}}
+	reaction := func(userID, emoji string) *discordgo.MessageReactionAdd {
+		return &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+			ChannelID: "chan1",
+			MessageID: "msg1",
+			UserID:    userID,
+			Emoji:     discordgo.Emoji{Name: emoji},
+		}}
+	}
+
+	var buf bytes.Buffer
+	sink := newRecordingSink()
+	b := New(cfg, WithMetrics(sink), WithAuditExport(&buf))
+	b.channels = map[string]string{"chan1": "jollyposting"}
+	b.ready = true
+
+	if b.IsSkullEmoji(&discordgo.Emoji{Name: "😭"}) || b.ShouldProcessReaction(reaction("target-user", "😭")) {
+		t.Error("a shadow rule should never act")
+	}
+	if b.IsSkullOnlyMessage("😭") {
+		t.Error("a shadow rule's emojis should not make a message skull-only")
+	}
+
+	b.evaluateShadowRules(reaction("target-user", "😭"))
+	b.evaluateShadowRules(reaction("other-user", "😭"))  // Not a target, so not counted
+	b.evaluateShadowRules(reaction("target-user", "💀")) // The skull rule acts on it
+
+	if got := sink.counts[metrics.ShadowMatches]; got != 1 {
+		t.Errorf("counted %d shadow matches, want 1", got)
+	}
+	var entry auditLogEntry
+	if err := json.Unmarshal([]byte(strings.TrimSpace(buf.String())), &entry); err != nil {
+		t.Fatalf("expected one audit log entry, got %q: %v", buf.String(), err)
+	}
+	if entry.Status != auditShadow || entry.Rule != "sob" || entry.Options.MessageID != "msg1" {
+		t.Errorf("entry = %+v, want a shadow entry for rule sob on msg1", entry)
+	}
+}
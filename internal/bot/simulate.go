@@ -0,0 +1,163 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/stats"
+)
+
+// chatExport is the subset of a DiscordChatExporter JSON export the
+// simulation reads.
+type chatExport struct {
+	Channel struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"channel"`
+	Messages []exportedMessage `json:"messages"`
+}
+
+type exportedMessage struct {
+	ID           string             `json:"id"`
+	Timestamp    time.Time          `json:"timestamp"`
+	Content      string             `json:"content"`
+	Author       exportedUser       `json:"author"`
+	InlineEmojis []exportedEmoji    `json:"inlineEmojis"`
+	Reactions    []exportedReaction `json:"reactions"`
+}
+
+type exportedUser struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type exportedEmoji struct {
+	ID         string `json:"id"` // Empty for Unicode emojis
+	Name       string `json:"name"`
+	IsAnimated bool   `json:"isAnimated"`
+}
+
+type exportedReaction struct {
+	Emoji exportedEmoji  `json:"emoji"`
+	Count int            `json:"count"`
+	Users []exportedUser `json:"users"` // Only in exports that include reaction users
+}
+
+// SimulationReport is what the bot would have done with an exported channel history.
+type SimulationReport struct {
+	Channel      string
+	Messages     int
+	From, Until  time.Time
+	Deleted      int                // Skull-only messages by target users
+	Replaced     int                // Skull reactions by target users
+	Unattributed int                // Skull reactions the export does not list the users of
+	Users        []stats.UserCounts // Per target user, most actions first
+	Names        map[string]string  // Names of target users as exported, by ID
+}
+
+// Simulate runs the target user, skull, and target group rules over a
+// DiscordChatExporter JSON export and reports what the bot would have done,
+// without calling Discord. Guild settings such as feature toggles and exempt
+// messages live in the bot's store and are not applied.
+func (b *Bot) Simulate(r io.Reader) (*SimulationReport, error) {
+	var export chatExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("failed to read export: %w", err)
+	}
+
+	report := &SimulationReport{Channel: export.Channel.Name, Names: make(map[string]string)}
+	var counts stats.Day
+	for _, msg := range export.Messages {
+		report.Messages++
+		if report.From.IsZero() || msg.Timestamp.Before(report.From) {
+			report.From = msg.Timestamp
+		}
+		if msg.Timestamp.After(report.Until) {
+			report.Until = msg.Timestamp
+		}
+
+		content := exportedContent(msg)
+		if b.IsTargetUser(msg.Author.ID) && b.IsSkullOnlyMessage(content) &&
+			b.groupAllowsAt(msg.Timestamp, msg.Author.ID, export.Channel.ID, config.ActionDeletion, b.skullEmojisIn(content)...) {
+			report.Deleted++
+			counts.Deleted = incrCount(counts.Deleted, msg.Author.ID)
+			report.Names[msg.Author.ID] = msg.Author.Name
+			continue // A deleted message keeps none of its reactions
+		}
+
+		for _, reaction := range msg.Reactions {
+			emoji := &discordgo.Emoji{ID: reaction.Emoji.ID, Name: reaction.Emoji.Name}
+			if !b.handlesEmoji(emoji) {
+				continue
+			}
+			if reaction.Users == nil {
+				report.Unattributed += reaction.Count
+				continue
+			}
+			for _, u := range reaction.Users {
+				if !b.IsTargetUser(u.ID) || !b.groupAllowsAt(msg.Timestamp, u.ID, export.Channel.ID, config.ActionReactions, emoji.Name) {
+					continue
+				}
+				report.Replaced++
+				counts.Replaced = incrCount(counts.Replaced, u.ID)
+				report.Names[u.ID] = u.Name
+			}
+		}
+	}
+	report.Users = stats.PerUser([]stats.Day{counts})
+	return report, nil
+}
+
+// exportedContent returns msg's content with custom emojis restored to the
+// tags Discord uses, since exports write them as :name:.
+func exportedContent(msg exportedMessage) string {
+	content := msg.Content
+	for _, e := range msg.InlineEmojis {
+		if e.ID == "" {
+			continue
+		}
+		tag := "<:" + e.Name + ":" + e.ID + ">"
+		if e.IsAnimated {
+			tag = "<a:" + e.Name + ":" + e.ID + ">"
+		}
+		content = strings.ReplaceAll(content, ":"+e.Name+":", tag)
+	}
+	return content
+}
+
+func incrCount(m map[string]int, k string) map[string]int {
+	if m == nil {
+		m = make(map[string]int)
+	}
+	m[k]++
+	return m
+}
+
+// WriteText writes the report for people to read.
+func (r *SimulationReport) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Simulated %d messages in #%s", r.Messages, r.Channel)
+	if r.Messages > 0 {
+		fmt.Fprintf(&sb, " from %s to %s", r.From.UTC().Format(time.DateOnly), r.Until.UTC().Format(time.DateOnly))
+	}
+	sb.WriteString("\n")
+	fmt.Fprintf(&sb, "Would delete %d skull-only messages\n", r.Deleted)
+	fmt.Fprintf(&sb, "Would replace %d skull reactions\n", r.Replaced)
+	if r.Unattributed > 0 {
+		fmt.Fprintf(&sb, "Skipped %d skull reactions the export does not list the users of; export with reaction users to include them\n", r.Unattributed)
+	}
+	if len(r.Users) > 0 {
+		sb.WriteString("\nPer target user:\n")
+		for _, u := range r.Users {
+			fmt.Fprintf(&sb, "  %s (%s): %d replaced, %d deleted\n", r.Names[u.UserID], u.UserID, u.Replaced, u.Deleted)
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"jolly-okurb/internal/config"
+)
+
+// chatExportJSON is a trimmed DiscordChatExporter export of #jollyposting.
+const chatExportJSON = `{
+  "guild": {"id": "1", "name": "Jolly"},
+  "channel": {"id": "2", "type": "GuildTextChat", "name": "jollyposting"},
+  "messages": [
+    {
+      "id": "10", "type": "Default", "timestamp": "2025-01-06T09:15:00+00:00", "content": "💀💀",
+      "author": {"id": "alice", "name": "alice"}
+    },
+    {
+      "id": "11", "type": "Default", "timestamp": "2025-01-06T22:00:00+01:00", "content": ":skull_cat:",
+      "author": {"id": "alice", "name": "alice"},
+      "inlineEmojis": [{"id": "99", "name": "skull_cat", "isAnimated": false}]
+    },
+    {
+      "id": "12", "type": "Default", "timestamp": "2025-01-07T12:00:00+00:00", "content": "look at this",
+      "author": {"id": "carol", "name": "carol"},
+      "reactions": [
+        {"emoji": {"id": "", "name": "💀"}, "count": 3, "users": [{"id": "alice", "name": "alice"}, {"id": "bob", "name": "bob"}, {"id": "carol", "name": "carol"}]},
+        {"emoji": {"id": "5", "name": "jollyskull"}, "count": 1, "users": [{"id": "bob", "name": "bob"}]},
+        {"emoji": {"id": "", "name": "☠️"}, "count": 2}
+      ]
+    },
+    {
+      "id": "13", "type": "Default", "timestamp": "2025-01-07T13:00:00+00:00", "content": "💀 lol",
+      "author": {"id": "bob", "name": "bob"}
+    }
+  ]
+}`
+
+func TestBot_Simulate(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups []config.TargetGroup
+		want   []string
+	}{
+		{
+			name: "every rule applies",
+			want: []string{
+				"Simulated 4 messages in #jollyposting from 2025-01-06 to 2025-01-07",
+				"Would delete 2 skull-only messages",
+				"Would replace 2 skull reactions",
+				"Skipped 2 skull reactions",
+				"alice (alice): 1 replaced, 2 deleted\n  bob (bob): 1 replaced, 0 deleted",
+			},
+		},
+		{
+			name:   "target groups limit actions",
+			groups: []config.TargetGroup{{Name: "casual", Users: []string{"alice"}, Actions: []string{config.ActionReactions}}},
+			want: []string{
+				"Would delete 0 skull-only messages",
+				"Would replace 2 skull reactions",
+				"alice (alice): 1 replaced, 0 deleted",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig([]string{"alice", "bob"}, "jollyskull:5")
+			cfg.TargetGroups = tt.groups
+			report, err := New(cfg).Simulate(strings.NewReader(chatExportJSON))
+			if err != nil {
+				t.Fatalf("Simulate() unexpected error: %v", err)
+			}
+			var out strings.Builder
+			if err := report.WriteText(&out); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("report does not contain %q:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+
+	if _, err := New(newTestConfig(nil, "")).Simulate(strings.NewReader("not json")); err == nil {
+		t.Error("Simulate() should fail on an invalid export")
+	}
+}
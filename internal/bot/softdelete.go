@@ -0,0 +1,125 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/correlation"
+	"jolly-okurb/internal/metrics"
+	"jolly-okurb/internal/schedule"
+	"jolly-okurb/internal/settings"
+)
+
+// softDeleteWarningEmoji marks a skull-only message that will be deleted
+// unless it is edited before the grace period ends.
+const softDeleteWarningEmoji = "⚠️"
+
+// jobSoftDelete is the scheduled job kind that finishes a soft delete.
+const jobSoftDelete = "soft_delete"
+
+// softDeleteJob identifies the message a soft delete job acts on.
+type softDeleteJob struct {
+	ChannelID string `json:"channel_id"`
+	MessageID string `json:"message_id"`
+
+	CorrelationID string `json:"correlation_id,omitempty"` // Of the message's creation event
+}
+
+// SoftDeleteMessage marks a skull-only message with a warning reaction and
+// schedules its deletion after the soft delete delay, giving the author time
+// to edit it into something else.
+func (b *Bot) SoftDeleteMessage(ctx context.Context, s Session, channelID, messageID string) {
+	job, err := schedule.NewJob(jobSoftDelete+":"+messageID, jobSoftDelete, time.Now().Add(b.config.SoftDeleteDelay),
+		softDeleteJob{ChannelID: channelID, MessageID: messageID, CorrelationID: correlation.ID(ctx)})
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to schedule soft delete", "message_id", messageID, "error", err)
+		return
+	}
+
+	err = b.executor.Do(ctx, actions.Action{
+		Kind:      actions.AddReaction,
+		Policy:    policySkullOnly,
+		ChannelID: channelID,
+		MessageID: messageID,
+		Do: func(...discordgo.RequestOption) error {
+			return s.MessageReactionAdd(channelID, messageID, softDeleteWarningEmoji)
+		},
+	})
+	if actions.Skipped(err) {
+		return
+	}
+	if err != nil {
+		// Still delete on schedule; the warning is a courtesy
+		logger.WarnContext(ctx, "failed to add soft delete warning", "message_id", messageID, "error", err)
+	}
+	if err := b.scheduler.Schedule(job); err != nil {
+		// The job still runs unless the bot restarts first
+		logger.WarnContext(ctx, "failed to persist soft delete", "message_id", messageID, "error", err)
+	}
+	logger.InfoContext(ctx, "scheduled skull-only message for deletion", "message_id", messageID, "delay", b.config.SoftDeleteDelay)
+	metrics.Incr(b.sink(), metrics.SoftDeleteWarnings)
+}
+
+// pausedJobDelay is how long a job that needs a paused or disabled feature
+// waits before checking again.
+const pausedJobDelay = time.Minute
+
+// FinishSoftDelete deletes a soft-deleted message once its grace period is
+// over, unless it has been edited so it is no longer skull-only, exempted,
+// or deleted already. While deletion is paused or disabled it postpones the
+// job instead. It is safe to call again after a failure.
+func (b *Bot) FinishSoftDelete(ctx context.Context, s Session, channelID, messageID string) error {
+	if !b.featureEnabled(settings.FeatureDeletion) {
+		return schedule.Postpone(pausedJobDelay, "deletion is paused or disabled")
+	}
+	msg, err := s.ChannelMessage(channelID, messageID)
+	if isNotFound(err) {
+		logger.DebugContext(ctx, "soft-deleted message is already gone", "message_id", messageID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch message: %w", err)
+	}
+
+	if b.IsSkullOnlyMessage(msg.Content) && !b.isExempt(messageID) {
+		msg.ChannelID = channelID // Not always set on fetched messages
+		if !b.removeMessage(ctx, s, msg) && !b.isDryRun(channelID) {
+			return fmt.Errorf("failed to delete message %s", messageID)
+		}
+		return nil
+	}
+
+	logger.InfoContext(ctx, "skull-only message was edited in time, keeping it", "message_id", messageID)
+	metrics.Incr(b.sink(), metrics.SoftDeleteReprieves)
+	err = b.executor.Do(ctx, actions.Action{
+		Kind:      actions.RemoveReaction,
+		ChannelID: channelID,
+		MessageID: messageID,
+		Do: func(...discordgo.RequestOption) error {
+			return s.MessageReactionRemove(channelID, messageID, softDeleteWarningEmoji, "@me")
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove soft delete warning: %w", err)
+	}
+	return nil
+}
+
+// isNotFound reports whether err is a Discord 404, such as for a deleted message.
+func isNotFound(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound
+}
+
+// isUnknownMessage reports whether err is Discord's Unknown Message error,
+// returned when the message was deleted before the request reached it.
+func isUnknownMessage(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownMessage
+}
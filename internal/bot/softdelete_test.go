@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/metrics"
+)
+
+func TestBot_SoftDeleteMessage(t *testing.T) {
+	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	cfg.SoftDeleteDelay = time.Hour
+
+	t.Run("warns and schedules deletion", func(t *testing.T) {
+		sink := newRecordingSink()
+		b := New(cfg, WithMetrics(sink))
+		mock := &mockSession{}
+
+		b.SoftDeleteMessage(context.Background(), mock, "chan1", "msg1")
+
+		if len(mock.addedReactions) != 1 || mock.addedReactions[0].emojiID != softDeleteWarningEmoji {
+			t.Errorf("expected the warning reaction, got %v", mock.addedReactions)
+		}
+		if len(mock.deleted) != 0 {
+			t.Error("message should not be deleted before the delay")
+		}
+		if n := b.scheduler.Pending(); n != 1 {
+			t.Errorf("expected 1 pending job, got %d", n)
+		}
+		if sink.counts[metrics.SoftDeleteWarnings] != 1 {
+			t.Errorf("expected 1 soft delete warning, got %d", sink.counts[metrics.SoftDeleteWarnings])
+		}
+	})
+
+	t.Run("dry run only logs", func(t *testing.T) {
+		cfg := *cfg
+		cfg.DryRun = true
+		b := New(&cfg)
+		mock := &mockSession{}
+
+		b.SoftDeleteMessage(context.Background(), mock, "chan1", "msg1")
+
+		if len(mock.addedReactions) != 0 || b.scheduler.Pending() != 0 {
+			t.Error("dry run should neither warn nor schedule")
+		}
+	})
+}
+
+func TestBot_FinishSoftDelete(t *testing.T) {
+	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	cfg.GuildID = "guild123"
+
+	tests := []struct {
+		name        string
+		messages    []*discordgo.Message
+		exempt      bool
+		paused      bool
+		deleteErr   error
+		wantErr     bool
+		wantDeleted bool
+		wantRemoved bool
+	}{
+		{
+			name:        "deletes message that is still skull-only",
+			messages:    []*discordgo.Message{{ID: "msg1", Content: "💀"}},
+			wantDeleted: true,
+		},
+		{
+			name:        "keeps edited message and removes the warning",
+			messages:    []*discordgo.Message{{ID: "msg1", Content: "💀 that was funny"}},
+			wantRemoved: true,
+		},
+		{
+			name:        "keeps exempted message",
+			messages:    []*discordgo.Message{{ID: "msg1", Content: "💀"}},
+			exempt:      true,
+			wantRemoved: true,
+		},
+		{
+			name: "ignores message deleted in the meantime",
+		},
+		{
+			name:        "reports failed deletion for a retry",
+			messages:    []*discordgo.Message{{ID: "msg1", Content: "💀"}},
+			deleteErr:   errors.New("boom"),
+			wantErr:     true,
+			wantDeleted: true,
+		},
+		{
+			name:     "postpones while paused",
+			messages: []*discordgo.Message{{ID: "msg1", Content: "💀"}},
+			paused:   true,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(cfg)
+			if tt.exempt {
+				b.settings.SetExempt("guild123", "msg1", true)
+			}
+			if tt.paused {
+				b.settings.SetPaused("guild123", true)
+			}
+			mock := &mockSession{messages: tt.messages, deleteErr: tt.deleteErr}
+
+			if err := b.FinishSoftDelete(context.Background(), mock, "chan1", "msg1"); (err != nil) != tt.wantErr {
+				t.Fatalf("FinishSoftDelete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := len(mock.deleted) == 1; got != tt.wantDeleted {
+				t.Errorf("deleted = %v, want %v", got, tt.wantDeleted)
+			}
+			if got := len(mock.removedReactions) == 1; got != tt.wantRemoved {
+				t.Errorf("warning removed = %v, want %v", got, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestBot_SoftDeleteScheduled(t *testing.T) {
+	cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+	cfg.SoftDeleteDelay = 10 * time.Millisecond
+	b := New(cfg)
+	mock := &mockSession{messages: []*discordgo.Message{{ID: "msg1", Content: "💀"}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.startScheduler(ctx, mock)
+	b.SoftDeleteMessage(context.Background(), mock, "chan1", "msg1")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for b.scheduler.Pending() > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	b.background.Wait()
+
+	if len(mock.deleted) != 1 {
+		t.Errorf("expected the message to be deleted after the delay, got %v", mock.deleted)
+	}
+}
@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// targetsCommand is the /jolly targets group, which edits the server's
+// target users. The first change seeds them from the environment, which no
+// longer counts afterwards.
+func targetsCommand() *discordgo.ApplicationCommandOption {
+	userOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionUser,
+		Name:        "user",
+		Description: "Member",
+		Required:    true,
+	}
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		Name:        "targets",
+		Description: "Target users, seeded from DISCORD_TARGET_USER_IDS and TARGET_GROUPS",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "List the target users and where each comes from",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Target a member on this server",
+				Options:     []*discordgo.ApplicationCommandOption{userOption},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Stop targeting a member on this server",
+				Options:     []*discordgo.ApplicationCommandOption{userOption},
+			},
+		},
+	}
+}
+
+func (b *Bot) handleTargetsList(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	g, err := b.settings.Guild(i.GuildID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := b.printer(i.GuildID)
+	targets := g.TargetsWith(b.config.TargetUserIDs)
+	if len(targets) == 0 {
+		return textReply(p.T("targets.none")), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintln(&sb, p.T("targets.header", len(targets)))
+	for _, id := range targets {
+		source := p.T("targets.source.server")
+		if b.isEnvTarget(id) {
+			source = p.T("targets.source.env")
+		}
+		fmt.Fprintf(&sb, "- <@%s> (%s)\n", id, source)
+	}
+	return textReply(sb.String()), nil
+}
+
+func (b *Bot) handleTargetsAdd(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	return b.setTarget(i, opts, true)
+}
+
+func (b *Bot) handleTargetsRemove(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	return b.setTarget(i, opts, false)
+}
+
+func (b *Bot) setTarget(i *discordgo.InteractionCreate, opts commandOptions, target bool) (*discordgo.InteractionResponseData, error) {
+	userID := opts["user"].UserValue(nil).ID
+	p := b.printer(i.GuildID)
+	if err := b.settings.SetTarget(i.GuildID, userID, target, b.config.TargetUserIDs); err != nil {
+		return nil, err
+	}
+	logger.Info("target user changed", "guild_id", i.GuildID, "user_id", userID, "target", target, "by", interactionUserID(i))
+
+	if target {
+		return textReply(p.T("targets.added", userID)), nil
+	}
+	return textReply(p.T("targets.removed", userID)), nil
+}
+
+// isEnvTarget reports whether userID is a target user of
+// DISCORD_TARGET_USER_IDS or TARGET_GROUPS.
+func (b *Bot) isEnvTarget(userID string) bool {
+	return slices.Contains(b.config.TargetUserIDs, userID)
+}
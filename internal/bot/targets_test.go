@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/store"
+)
+
+// userOption returns a user option of an invoked subcommand.
+func userOption(name, userID string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionUser, Value: userID}
+}
+
+func TestBot_HandleInteraction_Targets(t *testing.T) {
+	cfg := newTestConfig([]string{"env-user"}, "jollyskull:123")
+	cfg.GuildID = "guild123"
+	st := store.NewMemory()
+	b := New(cfg, WithStore(st))
+	mock := &mockSession{}
+	run := func(path []string, opts ...*discordgo.ApplicationCommandInteractionDataOption) string {
+		b.HandleInteraction(mock, newCommandInteraction("guild123", path, opts...))
+		return lastResponse(t, mock)
+	}
+
+	if got, want := run([]string{"targets", "add"}, userOption("user", "new-user")), "<@new-user> is now a target user on this server."; got != want {
+		t.Errorf("add = %q, want %q", got, want)
+	}
+	if !b.IsTargetUser("new-user") || !b.IsTargetUser("env-user") {
+		t.Error("both the added and the environment's target users should be targeted")
+	}
+
+	if got, want := run([]string{"targets", "list"}), "2 target user(s):\n- <@env-user> (environment)\n- <@new-user> (added on this server)\n"; got != want {
+		t.Errorf("list = %q, want %q", got, want)
+	}
+
+	// A target user of the environment stays removed, as the store is
+	// authoritative once seeded
+	run([]string{"targets", "remove"}, userOption("user", "env-user"))
+	if b.IsTargetUser("env-user") {
+		t.Error("a removed target user of the environment should no longer be targeted")
+	}
+	if got, want := run([]string{"targets", "list"}), "1 target user(s):\n- <@new-user> (added on this server)\n"; got != want {
+		t.Errorf("list = %q, want %q", got, want)
+	}
+	if restarted := New(cfg, WithStore(st)); restarted.IsTargetUser("env-user") {
+		t.Error("a removed target user of the environment should stay removed after a restart")
+	}
+
+	run([]string{"targets", "remove"}, userOption("user", "new-user"))
+	if b.IsTargetUser("new-user") {
+		t.Error("removed target user should no longer be targeted")
+	}
+}
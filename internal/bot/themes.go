@@ -0,0 +1,276 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/actions"
+	"jolly-okurb/internal/config"
+	"jolly-okurb/internal/i18n"
+	"jolly-okurb/internal/settings"
+	"jolly-okurb/internal/themes"
+)
+
+// theme returns the guild's reaction theme pack, if it has one, and the
+// settings its custom emojis resolve against.
+func (b *Bot) theme() (themes.Pack, settings.Guild, bool) {
+	g := b.guildSettings()
+	pack, ok := themes.Get(g.Theme)
+	return pack, g, ok
+}
+
+// handlesEmoji reports whether the bot replaces emoji: a skull, or another
+// emoji the theme pack maps.
+func (b *Bot) handlesEmoji(emoji *discordgo.Emoji) bool {
+	if b.IsSkullEmoji(emoji) {
+		return true
+	}
+	pack, _, ok := b.theme()
+	return ok && emoji.ID == "" && pack.Maps(emoji.Name)
+}
+
+// replacementsFor returns the emojis userID's emoji is replaced with, or nil
+// for jollyskull. An emoji of EMOJI_RULES gets its rule's replacement; for
+// skulls, a target group's replacements win over the theme pack.
+func (b *Bot) replacementsFor(userID string, emoji *discordgo.Emoji) []string {
+	if rule, ok := b.ruleFor(emoji.Name); ok && rule.Name != config.SkullRule {
+		return []string{rule.Replacement}
+	}
+	if r := b.config.ReplacementsFor(userID); len(r) > 0 {
+		return r
+	}
+	if pack, g, ok := b.theme(); ok {
+		return resolveThemeEmojis(g, pack.Replacements(emoji.Name, b.IsSkullEmoji(emoji)))
+	}
+	return nil
+}
+
+// isThemeEmoji reports whether emoji is one the theme pack reacts with.
+func (b *Bot) isThemeEmoji(emoji *discordgo.Emoji) bool {
+	pack, g, ok := b.theme()
+	if !ok {
+		return false
+	}
+	if emoji.ID == "" {
+		return pack.Contains(emoji.Name)
+	}
+	return pack.Contains(":"+emoji.Name+":") && g.ThemeEmojis[emoji.Name].ID == emoji.ID
+}
+
+// resolveThemeEmojis returns emojis with the custom ones resolved to name:id
+// in the guild, leaving out those it lacks.
+func resolveThemeEmojis(g settings.Guild, emojis []string) []string {
+	var resolved []string
+	for _, emoji := range emojis {
+		name, custom := themes.CustomName(emoji)
+		if !custom {
+			resolved = append(resolved, emoji)
+		} else if e, ok := g.ThemeEmojis[name]; ok {
+			resolved = append(resolved, name+":"+e.ID)
+		}
+	}
+	return resolved
+}
+
+// themeEmojiSync is what syncing a guild's custom theme emojis did, by emoji
+// name.
+type themeEmojiSync struct {
+	uploaded []string // Uploaded for the pack
+	deleted  []string // Uploaded earlier and no longer needed
+	missing  []string // Needed by the pack but lacking in the guild
+	failed   []string // Uploaded earlier and no longer needed, but not deleted
+}
+
+// syncThemeEmojis makes the custom emojis of guildID fit pack: it records
+// the ones the guild has, uploads the ones it lacks if upload is set, and
+// deletes the ones uploaded earlier that pack does not react with. An empty
+// pack deletes every uploaded one.
+func (b *Bot) syncThemeEmojis(ctx context.Context, s Session, guildID string, pack themes.Pack, upload bool) (themeEmojiSync, error) {
+	var sync themeEmojiSync
+	g, err := b.settings.Guild(guildID)
+	if err != nil {
+		return sync, err
+	}
+	existing, err := b.emojis.List(s, guildID)
+	if err != nil {
+		return sync, err
+	}
+	ids := make(map[string]string, len(existing))
+	for _, e := range existing {
+		ids[e.Name] = e.ID
+	}
+
+	tracked := maps.Clone(g.ThemeEmojis)
+	if tracked == nil {
+		tracked = make(map[string]settings.ThemeEmoji)
+	}
+	for _, name := range slices.Sorted(maps.Keys(tracked)) {
+		e := tracked[name]
+		if _, ok := pack.Images[name]; ok && ids[name] == e.ID {
+			continue
+		}
+		if e.Uploaded && ids[name] == e.ID {
+			err := b.executor.Do(ctx, actions.Action{Kind: actions.DeleteEmoji, Do: func(opts ...discordgo.RequestOption) error {
+				return b.emojis.Delete(ctx, s, guildID, e.ID, opts...)
+			}})
+			if err != nil {
+				logger.WarnContext(ctx, "failed to delete theme emoji", "guild_id", guildID, "emoji", name, "error", err)
+				sync.failed = append(sync.failed, name)
+				continue
+			}
+			sync.deleted = append(sync.deleted, name)
+		}
+		delete(tracked, name)
+	}
+
+	for _, name := range pack.CustomEmojis() {
+		if _, ok := tracked[name]; ok {
+			continue
+		}
+		if id, ok := ids[name]; ok {
+			tracked[name] = settings.ThemeEmoji{ID: id}
+			continue
+		}
+		if !upload {
+			sync.missing = append(sync.missing, name)
+			continue
+		}
+		id, err := b.uploadThemeEmoji(ctx, s, guildID, pack, name)
+		if err != nil {
+			logger.WarnContext(ctx, "failed to upload theme emoji", "guild_id", guildID, "theme", pack.ID, "emoji", name, "error", err)
+			sync.missing = append(sync.missing, name)
+			continue
+		}
+		tracked[name] = settings.ThemeEmoji{ID: id, Uploaded: true}
+		sync.uploaded = append(sync.uploaded, name)
+	}
+
+	if err := b.settings.SetThemeEmojis(guildID, tracked); err != nil {
+		return sync, err
+	}
+	if len(sync.uploaded) > 0 || len(sync.deleted) > 0 {
+		logger.InfoContext(ctx, "theme emojis synced", "guild_id", guildID, "theme", pack.ID, "uploaded", sync.uploaded, "deleted", sync.deleted)
+	}
+	return sync, nil
+}
+
+// uploadThemeEmoji uploads pack's custom emoji name to guildID and returns
+// its ID.
+func (b *Bot) uploadThemeEmoji(ctx context.Context, s Session, guildID string, pack themes.Pack, name string) (string, error) {
+	image, err := pack.Image(name)
+	if err != nil {
+		return "", err
+	}
+	var id string
+	err = b.executor.Do(ctx, actions.Action{Kind: actions.CreateEmoji, Do: func(opts ...discordgo.RequestOption) error {
+		e, err := b.emojis.Create(ctx, s, guildID, name, image, opts...)
+		if err != nil {
+			return err
+		}
+		id = e.ID
+		return nil
+	}})
+	return id, err
+}
+
+// canUploadEmojis reports whether the bot may upload theme emojis when
+// handling i.
+func (b *Bot) canUploadEmojis(i *discordgo.InteractionCreate) bool {
+	return b.config.ThemeEmojiUpload && i.AppPermissions&discordgo.PermissionManageGuildExpressions != 0
+}
+
+// handleThemeSet switches the guild to a theme pack, uploading its custom
+// emojis first. It is deferred, as the uploads may take longer than Discord
+// waits for a reply.
+func (b *Bot) handleThemeSet(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	id := opts["pack"].StringValue()
+	pack, ok := themes.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("unknown theme %q (expected one of %s)", id, strings.Join(themes.IDs(), ", "))
+	}
+
+	sync, err := b.syncThemeEmojis(context.Background(), s, i.GuildID, pack, b.canUploadEmojis(i))
+	if err != nil {
+		return nil, err
+	}
+	if err := b.settings.SetTheme(i.GuildID, id); err != nil {
+		return nil, err
+	}
+	logger.Info("theme changed", "guild_id", i.GuildID, "theme", id, "by", interactionUserID(i))
+
+	g, err := b.settings.Guild(i.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	p := b.printer(i.GuildID)
+	var sb strings.Builder
+	fmt.Fprintln(&sb, p.T("theme.set", pack.Name, strings.Join(themeEmojiTags(g, pack.Skulls), " ")))
+	b.describeThemeEmojiSync(&sb, p, sync)
+	if len(sync.missing) > 0 {
+		switch {
+		case !b.config.ThemeEmojiUpload:
+			fmt.Fprintln(&sb, p.T("theme.missing.upload"))
+		case !b.canUploadEmojis(i):
+			fmt.Fprintln(&sb, p.T("theme.missing.permission"))
+		}
+	}
+	return textReply(sb.String()), nil
+}
+
+func (b *Bot) handleThemeClear(s Session, i *discordgo.InteractionCreate, opts commandOptions) (*discordgo.InteractionResponseData, error) {
+	sync, err := b.syncThemeEmojis(context.Background(), s, i.GuildID, themes.Pack{}, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.settings.SetTheme(i.GuildID, ""); err != nil {
+		return nil, err
+	}
+	logger.Info("theme changed", "guild_id", i.GuildID, "theme", "", "by", interactionUserID(i))
+
+	p := b.printer(i.GuildID)
+	var sb strings.Builder
+	fmt.Fprintln(&sb, p.T("theme.cleared"))
+	b.describeThemeEmojiSync(&sb, p, sync)
+	return textReply(sb.String()), nil
+}
+
+// describeThemeEmojiSync writes a line for each thing sync did to sb.
+func (b *Bot) describeThemeEmojiSync(sb *strings.Builder, p i18n.Printer, sync themeEmojiSync) {
+	for _, line := range []struct {
+		key   string
+		names []string
+	}{
+		{"theme.uploaded", sync.uploaded},
+		{"theme.deleted", sync.deleted},
+		{"theme.missing", sync.missing},
+		{"theme.delete_failed", sync.failed},
+	} {
+		if len(line.names) > 0 {
+			fmt.Fprintln(sb, p.T(line.key, formatEmojiNames(line.names)))
+		}
+	}
+}
+
+// themeEmojiTags returns how emojis of a pack are written in a message,
+// leaving out the custom ones the guild lacks.
+func themeEmojiTags(g settings.Guild, emojis []string) []string {
+	var tags []string
+	for _, emoji := range resolveThemeEmojis(g, emojis) {
+		tags = append(tags, emojiTag(emoji))
+	}
+	return tags
+}
+
+// formatEmojiNames lists custom emoji names as they are typed in Discord.
+func formatEmojiNames(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = ":" + name + ":"
+	}
+	return strings.Join(quoted, ", ")
+}
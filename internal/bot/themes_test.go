@@ -0,0 +1,228 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/config"
+)
+
+func TestBot_Theme(t *testing.T) {
+	newBot := func(theme string) *Bot {
+		cfg := newTestConfig([]string{"target-user", "festive-user"}, "jollyskull:123")
+		cfg.GuildID = "guild123"
+		cfg.TargetGroups = []config.TargetGroup{{Name: "festive", Users: []string{"festive-user"}, Replacements: []string{"🌟"}}}
+		b := New(cfg)
+		b.channels = map[string]string{"test-channel": "jollyposting"}
+		b.ready = true
+		if theme != "" {
+			b.settings.SetTheme("guild123", theme)
+		}
+		return b
+	}
+
+	tests := []struct {
+		name      string
+		theme     string
+		userID    string
+		emoji     string
+		wantAdded []string // nil means the emoji is left alone
+	}{
+		{"skull without a theme", "", "target-user", "💀", []string{"jollyskull:123"}},
+		{"negative emoji without a theme", "", "target-user", "👎", nil},
+		{"skull", "christmas", "target-user", "💀", []string{"🎅", "🎄"}},
+		{"negative emoji", "christmas", "target-user", "👎", []string{"🎁"}},
+		{"unmapped emoji", "christmas", "target-user", "👍", nil},
+		{"group replacements win", "christmas", "festive-user", "💀", []string{"🌟"}},
+		{"other pack", "halloween", "target-user", "👻", []string{"😇"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBot(tt.theme)
+			mock := &mockSession{}
+			emoji := discordgo.Emoji{Name: tt.emoji}
+			r := &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+				UserID: tt.userID, MessageID: "msg123", ChannelID: "test-channel", GuildID: "guild123", Emoji: emoji,
+			}}
+
+			if got := b.ShouldProcessReaction(r); got != (tt.wantAdded != nil) {
+				t.Fatalf("ShouldProcessReaction() = %v, want %v", got, tt.wantAdded != nil)
+			}
+			if tt.wantAdded == nil {
+				return
+			}
+			if !b.ReplaceReaction(context.Background(), mock, "test-channel", "msg123", tt.userID, &emoji) {
+				t.Fatal("ReplaceReaction() should return true on success")
+			}
+			if got := addedEmojis(mock); !slices.Equal(got, tt.wantAdded) {
+				t.Errorf("added %v, want %v", got, tt.wantAdded)
+			}
+			for _, emoji := range tt.wantAdded {
+				if !b.isJollySkull(&discordgo.Emoji{Name: emoji}) {
+					t.Errorf("isJollySkull(%s) should recognize the replacement", emoji)
+				}
+			}
+		})
+	}
+}
+
+func TestBot_HandleInteraction_Theme(t *testing.T) {
+	cfg := newTestConfig(nil, "jollyskull:123")
+	cfg.GuildID = "guild123"
+	b := New(cfg)
+
+	t.Run("set", func(t *testing.T) {
+		mock := &mockSession{}
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"theme", "set"}, stringOption("pack", "birthday")))
+
+		if len(mock.responses) != 1 || mock.responses[0].Type != discordgo.InteractionResponseDeferredChannelMessageWithSource {
+			t.Fatalf("responses %+v, want the reply deferred while emojis sync", mock.responses)
+		}
+		if got := lastResponse(t, mock); !strings.Contains(got, "Birthday") || !strings.Contains(got, "🎂") {
+			t.Errorf("reply %q should name the pack and its skull replacements", got)
+		}
+		if g, _ := b.settings.Guild("guild123"); g.Theme != "birthday" {
+			t.Errorf("Theme = %q, want %q", g.Theme, "birthday")
+		}
+	})
+
+	t.Run("unknown pack", func(t *testing.T) {
+		mock := &mockSession{}
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"theme", "set"}, stringOption("pack", "easter")))
+
+		if got := lastResponse(t, mock); !strings.Contains(got, "christmas") {
+			t.Errorf("reply %q should list the packs", got)
+		}
+		if g, _ := b.settings.Guild("guild123"); g.Theme != "birthday" {
+			t.Errorf("Theme = %q, want it unchanged", g.Theme)
+		}
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		mock := &mockSession{}
+		b.HandleInteraction(mock, newCommandInteraction("guild123", []string{"theme", "clear"}))
+
+		if got := lastResponse(t, mock); !strings.Contains(got, "jollyskull") {
+			t.Errorf("reply %q should say skulls turn into jollyskull", got)
+		}
+		if g, _ := b.settings.Guild("guild123"); g.Theme != "" {
+			t.Errorf("Theme = %q, want it cleared", g.Theme)
+		}
+	})
+}
+
+func TestBot_HandleInteraction_ThemeEmojis(t *testing.T) {
+	newBot := func(upload bool) *Bot {
+		cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+		cfg.GuildID = "guild123"
+		cfg.ThemeEmojiUpload = upload
+		return New(cfg)
+	}
+	setTheme := func(b *Bot, guild *mockSession, pack string, perms int64) string {
+		in := newCommandInteraction("guild123", []string{"theme", "set"}, stringOption("pack", pack))
+		in.AppPermissions = perms
+		b.HandleInteraction(guild, in)
+		return lastResponse(t, guild)
+	}
+	const manage = discordgo.PermissionManageGuildExpressions
+
+	t.Run("uploads, switches, and cleans up", func(t *testing.T) {
+		b := newBot(true)
+		guild := &mockSession{}
+
+		if got := setTheme(b, guild, "christmas", manage); !strings.Contains(got, ":jollysanta:") || !strings.Contains(got, "<:jollysanta:emoji1>") {
+			t.Errorf("reply %q should name the uploaded emoji and react with it", got)
+		}
+		mock := &mockSession{}
+		b.ReplaceReaction(context.Background(), mock, "test-channel", "msg123", "target-user", &discordgo.Emoji{Name: "💀"})
+		if got := addedEmojis(mock); !slices.Equal(got, []string{"jollysanta:emoji1", "🎅", "🎄"}) {
+			t.Errorf("added %v, want the uploaded emoji first", got)
+		}
+		if !b.isJollySkull(&discordgo.Emoji{Name: "jollysanta", ID: "emoji1"}) || b.isJollySkull(&discordgo.Emoji{Name: "jollysanta", ID: "other"}) {
+			t.Error("isJollySkull() should recognize the uploaded emoji by its ID")
+		}
+
+		setTheme(b, guild, "birthday", manage)
+		if !slices.Equal(guild.createdEmojis, []string{"jollysanta", "jollycake"}) || !slices.Equal(guild.deletedEmojis, []string{"emoji1"}) {
+			t.Errorf("created %v and deleted %v, want jollysanta swapped for jollycake", guild.createdEmojis, guild.deletedEmojis)
+		}
+
+		b.HandleInteraction(guild, newCommandInteraction("guild123", []string{"theme", "clear"}))
+		if !slices.Equal(guild.deletedEmojis, []string{"emoji1", "emoji2"}) {
+			t.Errorf("deleted %v, want both uploads gone", guild.deletedEmojis)
+		}
+		if g, _ := b.settings.Guild("guild123"); len(g.ThemeEmojis) != 0 {
+			t.Errorf("ThemeEmojis = %v, want none left", g.ThemeEmojis)
+		}
+	})
+
+	t.Run("uses the server's own emoji", func(t *testing.T) {
+		b := newBot(true)
+		guild := &mockSession{emojis: []*discordgo.Emoji{{ID: "own", Name: "jollysanta"}}}
+
+		setTheme(b, guild, "christmas", manage)
+		b.HandleInteraction(guild, newCommandInteraction("guild123", []string{"theme", "clear"}))
+
+		if len(guild.createdEmojis) != 0 || len(guild.deletedEmojis) != 0 {
+			t.Errorf("created %v and deleted %v, want the server's emoji left alone", guild.createdEmojis, guild.deletedEmojis)
+		}
+	})
+
+	t.Run("missing emojis", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			upload bool
+			perms  int64
+			want   string
+		}{
+			{"upload disabled", false, manage, "THEME_EMOJI_UPLOAD"},
+			{"no permission", true, 0, "Manage Expressions"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				b := newBot(tt.upload)
+				guild := &mockSession{}
+
+				got := setTheme(b, guild, "christmas", tt.perms)
+				if !strings.Contains(got, "lacks :jollysanta:") || !strings.Contains(got, tt.want) {
+					t.Errorf("reply %q should name the missing emoji and mention %s", got, tt.want)
+				}
+				if len(guild.createdEmojis) != 0 {
+					t.Errorf("created %v, want nothing uploaded", guild.createdEmojis)
+				}
+			})
+		}
+	})
+
+	t.Run("failed delete is retried", func(t *testing.T) {
+		b := newBot(true)
+		guild := &mockSession{}
+		setTheme(b, guild, "christmas", manage)
+
+		guild.emojiErr = errors.New("forbidden")
+		b.HandleInteraction(guild, newCommandInteraction("guild123", []string{"theme", "clear"}))
+		if got := lastResponse(t, guild); !strings.Contains(got, "Could not delete :jollysanta:") {
+			t.Errorf("reply %q should say the emoji was not deleted", got)
+		}
+
+		guild.emojiErr = nil
+		b.HandleInteraction(guild, newCommandInteraction("guild123", []string{"theme", "clear"}))
+		if !slices.Equal(guild.deletedEmojis, []string{"emoji1"}) {
+			t.Errorf("deleted %v, want the upload deleted on the next clear", guild.deletedEmojis)
+		}
+	})
+}
+
+// addedEmojis returns the emojis mock reacted with, in order.
+func addedEmojis(mock *mockSession) []string {
+	var got []string
+	for _, r := range mock.addedReactions {
+		got = append(got, r.emojiID)
+	}
+	return got
+}
@@ -0,0 +1,111 @@
+package bot
+
+import (
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// userCacheTTL is how long cached user info is used before it is refetched.
+const userCacheTTL = 6 * time.Hour
+
+// memberRequestBatch is the most user IDs a member chunk request may name.
+const memberRequestBatch = 100
+
+// userInfo is how reports show a target user.
+type userInfo struct {
+	Name      string // Server nickname, else display name, else username
+	AvatarURL string
+	Fetched   time.Time
+}
+
+// memberRequester asks the gateway for guild member chunks.
+type memberRequester interface {
+	RequestGuildMembers(guildID, query string, limit int, nonce string, presences bool) error
+	RequestGuildMembersList(guildID string, userIDs []string, limit int, nonce string, presences bool) error
+}
+
+// requestTargetMembers asks the gateway for the target users' member info,
+// which arrives as member chunks and warms the user cache. With target roles
+// it asks for every member, as anyone may hold one.
+func (b *Bot) requestTargetMembers(s memberRequester) {
+	if len(b.config.TargetRoleIDs) > 0 {
+		if err := s.RequestGuildMembers(b.config.GuildID, "", 0, "", false); err != nil {
+			logger.Warn("failed to request members for target roles", "error", err)
+		}
+	}
+	for ids := range slices.Chunk(b.targetUserIDs(), memberRequestBatch) {
+		if err := s.RequestGuildMembersList(b.config.GuildID, ids, 0, "", false); err != nil {
+			logger.Warn("failed to request target members", "error", err)
+			return
+		}
+	}
+}
+
+func (b *Bot) OnGuildMembersChunk(s *discordgo.Session, c *discordgo.GuildMembersChunk) {
+	if c.GuildID != b.config.GuildID {
+		return
+	}
+	for _, m := range c.Members {
+		b.rememberMember(m)
+	}
+}
+
+// rememberMember tracks the roles of a member seen in an event or member
+// chunk, and caches its name and avatar if it is a target.
+func (b *Bot) rememberMember(m *discordgo.Member) {
+	if m == nil || m.User == nil {
+		return
+	}
+	b.trackRoles(m)
+	b.cacheUser(m.User.ID, userInfo{Name: m.DisplayName(), AvatarURL: m.AvatarURL("64"), Fetched: time.Now()})
+}
+
+// rememberUser caches a target user seen in an event that has no member
+// info, such as a message without a member attached.
+func (b *Bot) rememberUser(u *discordgo.User) {
+	if u == nil {
+		return
+	}
+	b.cacheUser(u.ID, userInfo{Name: u.DisplayName(), AvatarURL: u.AvatarURL("64"), Fetched: time.Now()})
+}
+
+func (b *Bot) cacheUser(userID string, info userInfo) {
+	if !b.IsTargetUser(userID) || info.Name == "" {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.users == nil {
+		b.users = make(map[string]userInfo)
+	}
+	b.users[userID] = info
+}
+
+// cachedUser returns what is known about userID, however old.
+func (b *Bot) cachedUser(userID string) (userInfo, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	info, ok := b.users[userID]
+	return info, ok
+}
+
+// lookupUser returns info about userID, fetching the member over REST when
+// the cache has nothing fresh. If the fetch fails, stale info is better than
+// none; with nothing at all the name is empty.
+func (b *Bot) lookupUser(s Session, userID string) userInfo {
+	cached, ok := b.cachedUser(userID)
+	if ok && time.Since(cached.Fetched) < userCacheTTL {
+		return cached
+	}
+
+	m, err := s.GuildMember(b.config.GuildID, userID)
+	if err != nil {
+		logger.Debug("failed to fetch member", "user_id", userID, "error", err)
+		return cached
+	}
+	b.rememberMember(m)
+	info, _ := b.cachedUser(userID)
+	return info
+}
@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestBot_RememberUser(t *testing.T) {
+	tests := []struct {
+		name   string
+		member *discordgo.Member
+		user   *discordgo.User
+		want   string
+	}{
+		{
+			name:   "prefers the server nickname",
+			member: &discordgo.Member{Nick: "Skull King", User: &discordgo.User{ID: "target", Username: "skull_fan", GlobalName: "Skull Fan"}},
+			want:   "Skull King",
+		},
+		{
+			name: "falls back to the display name",
+			user: &discordgo.User{ID: "target", Username: "skull_fan", GlobalName: "Skull Fan"},
+			want: "Skull Fan",
+		},
+		{
+			name: "falls back to the username",
+			user: &discordgo.User{ID: "target", Username: "skull_fan"},
+			want: "skull_fan",
+		},
+		{
+			name: "ignores other users",
+			user: &discordgo.User{ID: "other", Username: "bystander"},
+		},
+		{
+			name:   "ignores members without a user",
+			member: &discordgo.Member{Nick: "ghost"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(newTestConfig([]string{"target"}, ""))
+			b.rememberMember(tt.member)
+			b.rememberUser(tt.user)
+
+			id := "target"
+			if tt.user != nil {
+				id = tt.user.ID
+			}
+			info, _ := b.cachedUser(id)
+			if info.Name != tt.want {
+				t.Errorf("Name = %q, want %q", info.Name, tt.want)
+			}
+			if tt.want != "" && info.AvatarURL == "" {
+				t.Error("AvatarURL should fall back to the default avatar")
+			}
+		})
+	}
+}
+
+func TestBot_LookupUser(t *testing.T) {
+	cfg := newTestConfig([]string{"target"}, "")
+	member := &discordgo.Member{Nick: "Fetched", User: &discordgo.User{ID: "target", Username: "skull_fan"}}
+
+	t.Run("serves fresh entries from the cache", func(t *testing.T) {
+		b := New(cfg)
+		b.rememberUser(&discordgo.User{ID: "target", Username: "cached"})
+		mock := &mockSession{members: map[string]*discordgo.Member{"target": member}}
+
+		if got := b.lookupUser(mock, "target").Name; got != "cached" {
+			t.Errorf("Name = %q, want %q", got, "cached")
+		}
+		if mock.memberCalls != 0 {
+			t.Errorf("expected no API calls, got %d", mock.memberCalls)
+		}
+	})
+
+	t.Run("refetches expired entries", func(t *testing.T) {
+		b := New(cfg)
+		b.cacheUser("target", userInfo{Name: "stale", Fetched: time.Now().Add(-userCacheTTL)})
+		mock := &mockSession{members: map[string]*discordgo.Member{"target": member}}
+
+		if got := b.lookupUser(mock, "target").Name; got != "Fetched" {
+			t.Errorf("Name = %q, want %q", got, "Fetched")
+		}
+		if got, _ := b.cachedUser("target"); got.Name != "Fetched" {
+			t.Error("the fetched member should be cached")
+		}
+	})
+
+	t.Run("keeps stale entries when the fetch fails", func(t *testing.T) {
+		b := New(cfg)
+		b.cacheUser("target", userInfo{Name: "stale", Fetched: time.Now().Add(-userCacheTTL)})
+
+		if got := b.lookupUser(&mockSession{}, "target").Name; got != "stale" {
+			t.Errorf("Name = %q, want %q", got, "stale")
+		}
+	})
+}
+
+// fakeMemberRequester records gateway member requests.
+type fakeMemberRequester struct {
+	batches [][]string
+	all     int // Requests for every member
+}
+
+func (f *fakeMemberRequester) RequestGuildMembers(guildID, query string, limit int, nonce string, presences bool) error {
+	f.all++
+	return nil
+}
+
+func (f *fakeMemberRequester) RequestGuildMembersList(guildID string, userIDs []string, limit int, nonce string, presences bool) error {
+	f.batches = append(f.batches, userIDs)
+	return nil
+}
+
+func TestBot_RequestTargetMembers(t *testing.T) {
+	ids := make([]string, memberRequestBatch+1)
+	for i := range ids {
+		ids[i] = string(rune('a' + i%26))
+	}
+	b := New(newTestConfig(ids, ""))
+	f := &fakeMemberRequester{}
+
+	b.requestTargetMembers(f)
+
+	if len(f.batches) != 2 || len(f.batches[0]) != memberRequestBatch || len(f.batches[1]) != 1 {
+		t.Errorf("expected batches of %d and 1, got %d batches", memberRequestBatch, len(f.batches))
+	}
+
+	t.Run("target roles", func(t *testing.T) {
+		cfg := newTestConfig(nil, "")
+		cfg.TargetRoleIDs = []string{"role1"}
+		b := New(cfg)
+		f := &fakeMemberRequester{}
+
+		b.requestTargetMembers(f)
+
+		if f.all != 1 {
+			t.Errorf("expected one request for every member, got %d", f.all)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"jolly-okurb/internal/metrics"
+)
+
+// maxHistoricalRestarts bounds how often the watchdog restarts a stalled
+// historical scan, since every restart may leave a hung goroutine behind.
+const maxHistoricalRestarts = 3
+
+// errScanStalled is the cancellation cause of a historical scan the watchdog gave up on.
+var errScanStalled = errors.New("historical scan stalled")
+
+// stalled reports whether ctx belongs to a scan the watchdog replaced.
+func stalled(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errScanStalled)
+}
+
+// beatHistorical records that the historical scan made progress.
+func (b *Bot) beatHistorical() {
+	b.historicalBeat.Store(time.Now().UnixNano())
+}
+
+// sinceHistoricalBeat returns how long ago the historical scan last made progress.
+func (b *Bot) sinceHistoricalBeat() time.Duration {
+	return time.Since(time.Unix(0, b.historicalBeat.Load()))
+}
+
+// superviseHistorical runs the historical scan and restarts it from its
+// checkpoints when it makes no progress for HISTORICAL_STALL_TIMEOUT. A hung
+// run cannot be stopped, only cancelled; once replaced it no longer saves
+// checkpoints or posts its report, should it ever wake up.
+func (b *Bot) superviseHistorical(ctx context.Context, s Session) {
+	timeout := b.config.HistoricalStallTimeout
+	if timeout <= 0 {
+		b.ProcessHistoricalMessages(ctx, s)
+		return
+	}
+
+	for restarts := 0; ; restarts++ {
+		runCtx, cancel := context.WithCancelCause(ctx)
+		done := make(chan struct{})
+		b.beatHistorical()
+		go func() {
+			defer close(done)
+			b.ProcessHistoricalMessages(runCtx, s)
+		}()
+
+		if !b.watchHistorical(done, timeout) {
+			cancel(nil)
+			return
+		}
+		cancel(errScanStalled)
+		metrics.Incr(b.sink(), metrics.HistoricalStalls)
+		if ctx.Err() != nil {
+			logger.Error("historical scan stalled during shutdown", "timeout", timeout)
+			return
+		}
+		if restarts == maxHistoricalRestarts {
+			logger.Error("historical scan stalled, giving up", "timeout", timeout, "restarts", restarts)
+			b.raiseAlert(s, b.printer(b.config.GuildID).T("alert.scan_stalled", restarts))
+			return
+		}
+		logger.Error("historical scan stalled, restarting from checkpoint", "timeout", timeout, "restart", restarts+1)
+	}
+}
+
+// watchHistorical waits for the scan to finish and reports whether it
+// stalled first. Cancellation is left to the scan, which saves its
+// checkpoints before it returns.
+func (b *Bot) watchHistorical(done <-chan struct{}, timeout time.Duration) bool {
+	ticker := time.NewTicker(max(timeout/10, 10*time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return false
+		case <-ticker.C:
+			if b.sinceHistoricalBeat() > timeout {
+				return true
+			}
+		}
+	}
+}
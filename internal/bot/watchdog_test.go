@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/metrics"
+)
+
+// hangingSession hangs the first history fetch until release is closed.
+type hangingSession struct {
+	*historySession
+	release chan struct{}
+	hung    chan struct{}
+}
+
+func (h *hangingSession) ChannelMessages(channelID string, limit int, beforeID, afterID, aroundID string, options ...discordgo.RequestOption) ([]*discordgo.Message, error) {
+	select {
+	case <-h.hung:
+	default:
+		close(h.hung)
+		<-h.release
+	}
+	return h.historySession.ChannelMessages(channelID, limit, beforeID, afterID, aroundID, options...)
+}
+
+func TestBot_SuperviseHistorical(t *testing.T) {
+	delay := historicalPageDelay
+	historicalPageDelay = 0
+	t.Cleanup(func() { historicalPageDelay = delay })
+
+	newBot := func(timeout time.Duration) (*Bot, *recordingSink) {
+		cfg := newTestConfig([]string{"target-user"}, "jollyskull:123")
+		cfg.AdminChannelID = "admin"
+		cfg.HistoricalStallTimeout = timeout
+		sink := newRecordingSink()
+		b := New(cfg, WithMetrics(sink))
+		b.channels = map[string]string{"chan": "jollyposting"}
+		b.ready = true
+		return b, sink
+	}
+
+	t.Run("restarts a stalled scan from its checkpoint", func(t *testing.T) {
+		b, sink := newBot(50 * time.Millisecond)
+		h := &hangingSession{
+			historySession: skullHistory(time.Now().Add(-time.Minute), 250, time.Minute),
+			release:        make(chan struct{}),
+			hung:           make(chan struct{}),
+		}
+
+		b.superviseHistorical(t.Context(), h)
+
+		if got := len(replacedIDs(h.historySession)); got != 250 {
+			t.Errorf("expected all 250 messages replaced after the restart, got %d", got)
+		}
+		if sink.counts[metrics.HistoricalStalls] != 1 {
+			t.Errorf("expected 1 stall, got %d", sink.counts[metrics.HistoricalStalls])
+		}
+
+		// The replaced run wakes up but must neither report nor save its checkpoint
+		close(h.release)
+		time.Sleep(50 * time.Millisecond)
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if len(h.sent) != 1 {
+			t.Errorf("expected only the restarted run's report, got %d", len(h.sent))
+		}
+		if cp := b.loadCheckpoint("chan"); len(cp.Ranges) != 0 || cp.NewestID == "" {
+			t.Errorf("expected a completed checkpoint, got %+v", cp)
+		}
+	})
+
+	t.Run("runs unsupervised when disabled", func(t *testing.T) {
+		b, sink := newBot(0)
+		h := skullHistory(time.Now().Add(-time.Minute), 10, time.Minute)
+
+		b.superviseHistorical(t.Context(), h)
+
+		if got := len(replacedIDs(h)); got != 10 {
+			t.Errorf("expected 10 messages replaced, got %d", got)
+		}
+		if sink.counts[metrics.HistoricalStalls] != 0 {
+			t.Errorf("expected no stalls, got %d", sink.counts[metrics.HistoricalStalls])
+		}
+	})
+}
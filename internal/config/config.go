@@ -1,33 +1,229 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
+	"log/slog"
+	"maps"
+	"net/url"
 	"os"
+	"path"
+	"slices"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"jolly-okurb/internal/flags"
+)
+
+// Values of WEEKLY_HIGHLIGHT.
+const (
+	HighlightPin  = "pin"  // Pin the message itself
+	HighlightPost = "post" // Post a highlight linking to the message, and pin that
+)
+
+// Values of PROGRESS_INDICATOR.
+const (
+	IndicatorReaction = "reaction" // React with an hourglass, removed afterward
+	IndicatorTyping   = "typing"   // Show the bot typing in the channel
+)
+
+// Values of REPLACE_MODE and of a target group's replace rule: the steps of
+// a skull replacement.
+const (
+	ReplaceBoth   = "both"   // Remove the skull and add jollyskull
+	ReplaceRemove = "remove" // Only remove the skull
+	ReplaceAdd    = "add"    // Only add jollyskull, next to the skull
+)
+
+// Values of LOG_SHIPPER.
+const (
+	LogShipperLoki       = "loki"
+	LogShipperCloudWatch = "cloudwatch"
 )
 
 type Config struct {
-	Token           string              // Discord bot token
-	GuildID         string              // Server ID to operate in
-	ChannelName     string              // Channel name to monitor
-	TargetUserIDs   []string            // User IDs whose reactions to replace
-	TargetUserIDSet map[string]struct{} // Set for O(1) lookup
-	JollySkullID    string              // Custom emoji ID for jollyskull
+	Token                  string              // Discord bot token
+	GuildID                string              // Server ID to operate in
+	ChannelName            string              // Channel name or glob pattern (e.g. "jolly*") to monitor
+	ChannelID              string              // Channel ID to monitor instead of looking up ChannelName (empty = by name)
+	ChannelTypes           []string            // Channel types that may be monitored: text, news, voice
+	ChannelResolveInterval time.Duration       // How often to re-resolve monitored channels (0 = never)
+	InitRetryAttempts      int                 // Startup initialization retries before alerting
+	HistoricalWorkers      int                 // Concurrent workers per channel for the historical scan
+	HistoricalStallTimeout time.Duration       // Restart the historical scan after this long without progress (0 = never)
+	TargetUserIDs          []string            // User IDs whose reactions to replace
+	TargetUserIDSet        map[string]struct{} // Set for O(1) lookup
+	TargetRoleIDs          []string            // Role IDs whose holders are target users too
+	TargetGroups           []TargetGroup       // Targets with their own rules; other targets get every action
+	JollySkullID           string              // Emoji that replaces skulls, as name:id for a custom emoji or Unicode
+	JollySkullFallback     string              // Emoji reacted with while jollyskull is unusable, as name:id or Unicode
+	ThemeEmojiUpload       bool                // Upload a theme pack's custom emojis the guild lacks, and delete them with the theme
+	EmojiDeleteSuggest     int                 // Daily uses of a custom skull emoji at which the admin channel is offered to delete it (0 = never)
+	UndoExcludeSuggest     int                 // Undone replacements of a custom emoji at which the admin channel is offered to exclude it (0 = never)
+	UndoExcludeAuto        bool                // Exclude such an emoji right away, telling the admin channel, instead of offering to
+	AdminChannelID         string              // Channel for reports to server admins (empty = none)
+	SelfTestChannelID      string              // Sandbox channel for /jolly selftest (empty = disabled)
+	JailChannelID          string              // Channel skull-only messages are moved to instead of deleted (empty = delete them)
+	StartupReport          bool                // Post the startup summary to the admin channel
+	MetricsBackend         string              // Metrics sink: none, statsd, or dogstatsd
+	MetricsAddr            string              // UDP address of the statsd agent
+	MetricsPrefix          string              // Prefix prepended to every metric name
+	MetricsLabelLimit      int                 // Distinct values each guild, channel, rule, or emoji label takes before "other"
+	SentryDSN              string              // Sentry project to report panics to (empty = none)
+	LogLevel               slog.Level          // Least level logged
+	LogDebugSample         int                 // Log one in this many debug records with the same message (1 = all)
+	LogShipper             string              // Service to ship logs to as well as stderr: LogShipperLoki or LogShipperCloudWatch (empty = none)
+	LokiURL                string              // Loki server, with basic auth credentials if needed
+	CloudWatchLogGroup     string              // CloudWatch Logs group, which must exist
+	CloudWatchLogStream    string              // CloudWatch Logs stream, created if needed
+	AWSRegion              string              // AWS region of CloudWatch Logs
+	AWSAccessKeyID         string              // AWS credentials for CloudWatch Logs
+	AWSSecretAccessKey     string              // AWS credentials for CloudWatch Logs
+	AWSSessionToken        string              // AWS session token, only for temporary credentials
+	ActionRateLimit        int                 // Max mutations per ActionRatePeriod across all features (0 = unlimited)
+	ActionRatePeriod       time.Duration       // Window for ActionRateLimit
+	ReactionRateLimit      int                 // Max reaction replacements per ReactionRatePeriod (0 = unlimited)
+	ReactionRatePeriod     time.Duration       // Window for ReactionRateLimit
+	DeletionRateLimit      int                 // Max message deletions per DeletionRatePeriod (0 = unlimited)
+	DeletionRatePeriod     time.Duration       // Window for DeletionRateLimit
+	ActionJitterMin        time.Duration       // Least random delay before a replacement or deletion
+	ActionJitterMax        time.Duration       // Most random delay before a replacement or deletion (0 = act at once)
+	StorePath              string              // JSON file for runtime-managed state (empty = in-memory)
+	StoreKey               []byte              // AES key that encrypts the store file at rest (empty = unencrypted)
+	HTTPAddr               string              // Listen address of the admin HTTP server (empty = disabled)
+	PublicURL              string              // Base URL the HTTP server is reachable at, for links in replies
+	DryRun                 bool                // Log actions instead of performing them, unless overridden per guild or channel
+	ReadOnly               bool                // Refuse every change on Discord while still observing, for analytics-only deployments
+	FeatureFlags           map[flags.Flag]bool // Feature flag defaults FEATURE_FLAGS sets; the others follow whether their feature is configured
+	SoftDeleteDelay        time.Duration       // Grace period to edit a skull-only message before deletion (0 = delete at once)
+	DeletionNotice         string              // text/template posted in the channel after deleting a skull-only message (empty = none)
+	DeletionNoticeTTL      time.Duration       // How long the notice stays before the bot deletes it
+	DeletionNoticeCooldown time.Duration       // Least time between notices in a channel
+	DeletedRetention       time.Duration       // How long to keep the content of deleted messages (0 = never keep it)
+	WeeklyDigest           bool                // Post a "Jolly Wrapped" summary to the monitored channels every Monday
+	WeeklyHighlight        string              // Every Monday, pin or post last week's most jollified message: HighlightPin or HighlightPost (empty = off)
+	DirectMessages         bool                // Also handle skulls from target users in DMs with the bot
+	ReplaceMode            string              // Steps of a skull replacement: ReplaceBoth, ReplaceRemove, or ReplaceAdd; target groups may override it
+	ReactionRecheck        bool                // Re-check reactions after a replacement and remove a skull re-added meanwhile
+	ProgressIndicator      string              // Feedback while a slow replacement is under way: IndicatorReaction or IndicatorTyping (empty = none)
+	AuditLogReason         string              // text/template for the audit log reason of deletions and reaction removals (empty = default)
+	AuditExportPath        string              // JSON lines file the bot appends its actions to as audit log entries (empty = none)
+	Escalation             []EscalationStep    // Extra actions once a user reaches a number of actions in a day
+	SkullKeywords          SkullKeywords       // Which custom emojis are skulls by name, with the emoji patterns; reloaded on SIGHUP
+	EmojiRules             []EmojiRule         // Other emojis to replace, after the skull rule; reloaded on SIGHUP
+	NATSURL                string              // NATS server to publish action events to (empty = none)
+	NATSSubject            string              // Subject of published action events
+	MQTTURL                string              // MQTT broker to publish action events and daily counts to (empty = none)
+	MQTTTopic              string              // Topic prefix of published MQTT messages
+	Webhooks               []Webhook           // Endpoints that receive action events
+
+	Migrations []string // Changes made upgrading CONFIG_FILE to the current version, for the startup log
+}
+
+// reloadable are the settings that the bot applies again on SIGHUP.
+var reloadable = []string{"SKULL_KEYWORDS", "EMOJI_MATCH_PATTERN", "EMOJI_EXCLUDE_PATTERN", "EMOJI_RULES"}
+
+// EnvReloadable returns the settings that SIGHUP reloads but that are set in
+// the environment. The environment wins over CONFIG_FILE and cannot change
+// while the bot runs, so reloading never changes them.
+func EnvReloadable() []string {
+	var names []string
+	for _, name := range reloadable {
+		if os.Getenv(name) != "" {
+			names = append(names, name)
+		}
+	}
+	return names
 }
 
+// Load reads the configuration from the environment, and from the config
+// file at CONFIG_FILE if set. Environment variables that are set win over
+// the file, which may only set the settings the bot reads.
 func Load() (*Config, error) {
+	getenv := os.Getenv
+	var f *File
+	var migrations []string
+	read := make(map[string]bool) // Settings asked for
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		var err error
+		if f, migrations, err = ReadFile(path); err != nil {
+			return nil, err
+		}
+		getenv = func(name string) string {
+			read[name] = true
+			if v := os.Getenv(name); v != "" {
+				return v
+			}
+			v, _ := f.Lookup(name)
+			return v
+		}
+	}
+
+	cfg, err := load(getenv)
+	if err != nil {
+		return nil, err
+	}
+	if f != nil {
+		for _, name := range slices.Sorted(maps.Keys(f.Settings)) {
+			if !read[name] {
+				return nil, fmt.Errorf("invalid config file %s: unknown setting %s", os.Getenv("CONFIG_FILE"), name)
+			}
+		}
+	}
+	cfg.Migrations = migrations
+	return cfg, nil
+}
+
+// load reads the configuration through getenv.
+func load(getenv func(string) string) (*Config, error) {
 	cfg := &Config{
-		Token:        os.Getenv("DISCORD_TOKEN"),
-		GuildID:      os.Getenv("DISCORD_GUILD_ID"),
-		ChannelName:  os.Getenv("DISCORD_CHANNEL_NAME"),
-		JollySkullID: os.Getenv("DISCORD_JOLLYSKULL_ID"),
+		Token:        getenv("DISCORD_TOKEN"),
+		GuildID:      getenv("DISCORD_GUILD_ID"),
+		ChannelName:  getenv("DISCORD_CHANNEL_NAME"),
+		ChannelID:    getenv("DISCORD_CHANNEL_ID"),
+		JollySkullID: getenv("DISCORD_JOLLYSKULL_ID"),
+
+		AdminChannelID:    getenv("DISCORD_ADMIN_CHANNEL_ID"),
+		SelfTestChannelID: getenv("SELFTEST_CHANNEL_ID"),
+		JailChannelID:     getenv("JAIL_CHANNEL_ID"),
+
+		MetricsBackend: getenv("METRICS_BACKEND"),
+		MetricsAddr:    getenv("METRICS_ADDR"),
+		MetricsPrefix:  getenv("METRICS_PREFIX"),
+
+		SentryDSN: getenv("SENTRY_DSN"),
+
+		LokiURL:             getenv("LOKI_URL"),
+		CloudWatchLogGroup:  getenv("CLOUDWATCH_LOG_GROUP"),
+		CloudWatchLogStream: getenv("CLOUDWATCH_LOG_STREAM"),
+		AWSRegion:           getenv("AWS_REGION"),
+		AWSAccessKeyID:      getenv("AWS_ACCESS_KEY_ID"),
+		AWSSecretAccessKey:  getenv("AWS_SECRET_ACCESS_KEY"),
+		AWSSessionToken:     getenv("AWS_SESSION_TOKEN"),
+
+		StorePath: getenv("STORE_PATH"),
+
+		HTTPAddr:  getenv("HTTP_ADDR"),
+		PublicURL: strings.TrimSuffix(getenv("PUBLIC_URL"), "/"),
+
+		AuditLogReason:  getenv("AUDIT_LOG_REASON"),
+		AuditExportPath: getenv("AUDIT_EXPORT_PATH"),
+
+		NATSURL:     getenv("NATS_URL"),
+		NATSSubject: getenv("NATS_SUBJECT"),
+		MQTTURL:     getenv("MQTT_URL"),
+		MQTTTopic:   getenv("MQTT_TOPIC"),
 	}
 
 	// Parse comma-separated user IDs
-	targetUserIDs := os.Getenv("DISCORD_TARGET_USER_IDS")
+	targetUserIDs := getenv("DISCORD_TARGET_USER_IDS")
 	if targetUserIDs == "" {
 		// Fall back to singular for backwards compatibility
-		targetUserIDs = os.Getenv("DISCORD_TARGET_USER_ID")
+		targetUserIDs = getenv("DISCORD_TARGET_USER_ID")
 	}
 	cfg.TargetUserIDSet = make(map[string]struct{})
 	if targetUserIDs != "" {
@@ -46,15 +242,507 @@ func Load() (*Config, error) {
 	if cfg.GuildID == "" {
 		return nil, fmt.Errorf("DISCORD_GUILD_ID is required")
 	}
-	if cfg.ChannelName == "" {
+	switch {
+	case cfg.ChannelID != "" && cfg.ChannelName != "":
+		return nil, fmt.Errorf("set either DISCORD_CHANNEL_ID or DISCORD_CHANNEL_NAME, not both")
+	case cfg.ChannelID != "":
+		if _, err := strconv.ParseUint(cfg.ChannelID, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid DISCORD_CHANNEL_ID %q", cfg.ChannelID)
+		}
+	case cfg.ChannelName == "":
 		cfg.ChannelName = "jollyposting"
 	}
-	if len(cfg.TargetUserIDs) == 0 {
-		return nil, fmt.Errorf("DISCORD_TARGET_USER_IDS is required")
+	if _, err := path.Match(cfg.ChannelName, ""); err != nil {
+		return nil, fmt.Errorf("invalid DISCORD_CHANNEL_NAME pattern %q: %w", cfg.ChannelName, err)
+	}
+
+	cfg.ChannelResolveInterval = 5 * time.Minute
+	if interval := getenv("CHANNEL_RESOLVE_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid CHANNEL_RESOLVE_INTERVAL %q", interval)
+		}
+		cfg.ChannelResolveInterval = d
+	}
+
+	cfg.InitRetryAttempts = 5
+	if attempts := getenv("INIT_RETRY_ATTEMPTS"); attempts != "" {
+		n, err := strconv.Atoi(attempts)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid INIT_RETRY_ATTEMPTS %q", attempts)
+		}
+		cfg.InitRetryAttempts = n
+	}
+
+	cfg.HistoricalWorkers = 1
+	if workers := getenv("HISTORICAL_WORKERS"); workers != "" {
+		n, err := strconv.Atoi(workers)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid HISTORICAL_WORKERS %q", workers)
+		}
+		cfg.HistoricalWorkers = n
+	}
+
+	cfg.HistoricalStallTimeout = 10 * time.Minute
+	if timeout := getenv("HISTORICAL_STALL_TIMEOUT"); timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid HISTORICAL_STALL_TIMEOUT %q", timeout)
+		}
+		cfg.HistoricalStallTimeout = d
+	}
+
+	channelTypes := getenv("DISCORD_CHANNEL_TYPES")
+	if channelTypes == "" {
+		channelTypes = "text,news" // Voice channels are opt-in
+	}
+	for t := range strings.SplitSeq(channelTypes, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+		if t != "text" && t != "news" && t != "voice" {
+			return nil, fmt.Errorf("invalid DISCORD_CHANNEL_TYPES entry %q (expected text, news, or voice)", t)
+		}
+		cfg.ChannelTypes = append(cfg.ChannelTypes, t)
+	}
+	if groups := getenv("TARGET_GROUPS"); groups != "" {
+		if err := cfg.parseTargetGroups(groups); err != nil {
+			return nil, fmt.Errorf("invalid TARGET_GROUPS: %w", err)
+		}
+	}
+	if roles := getenv("DISCORD_TARGET_ROLE_IDS"); roles != "" {
+		for id := range strings.SplitSeq(roles, ",") {
+			id = strings.TrimSpace(id)
+			if id == "" {
+				continue
+			}
+			if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid DISCORD_TARGET_ROLE_IDS entry %q (expected a role ID)", id)
+			}
+			cfg.TargetRoleIDs = append(cfg.TargetRoleIDs, id)
+		}
+	}
+	if len(cfg.TargetUserIDs) == 0 && len(cfg.TargetRoleIDs) == 0 {
+		return nil, fmt.Errorf("DISCORD_TARGET_USER_IDS or DISCORD_TARGET_ROLE_IDS is required")
 	}
 	if cfg.JollySkullID == "" {
 		return nil, fmt.Errorf("DISCORD_JOLLYSKULL_ID is required")
 	}
+	jolly, err := parseEmoji(cfg.JollySkullID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DISCORD_JOLLYSKULL_ID: %w", err)
+	}
+	cfg.JollySkullID = jolly
+
+	cfg.JollySkullFallback = "🎄"
+	if fallback := getenv("JOLLYSKULL_FALLBACK"); fallback != "" {
+		if cfg.JollySkullFallback, err = parseEmoji(fallback); err != nil {
+			return nil, fmt.Errorf("invalid JOLLYSKULL_FALLBACK: %w", err)
+		}
+	}
+	if upload := getenv("THEME_EMOJI_UPLOAD"); upload != "" {
+		v, err := strconv.ParseBool(upload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid THEME_EMOJI_UPLOAD %q", upload)
+		}
+		cfg.ThemeEmojiUpload = v
+	}
+	if uses := getenv("EMOJI_DELETE_SUGGEST"); uses != "" {
+		n, err := strconv.Atoi(uses)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid EMOJI_DELETE_SUGGEST %q", uses)
+		}
+		if n > 0 && cfg.AdminChannelID == "" {
+			return nil, fmt.Errorf("EMOJI_DELETE_SUGGEST requires DISCORD_ADMIN_CHANNEL_ID")
+		}
+		cfg.EmojiDeleteSuggest = n
+	}
+	if undos := getenv("UNDO_EXCLUDE_SUGGEST"); undos != "" {
+		n, err := strconv.Atoi(undos)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid UNDO_EXCLUDE_SUGGEST %q", undos)
+		}
+		if n > 0 && cfg.AdminChannelID == "" {
+			return nil, fmt.Errorf("UNDO_EXCLUDE_SUGGEST requires DISCORD_ADMIN_CHANNEL_ID")
+		}
+		cfg.UndoExcludeSuggest = n
+	}
+	if auto := getenv("UNDO_EXCLUDE_AUTO"); auto != "" {
+		v, err := strconv.ParseBool(auto)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UNDO_EXCLUDE_AUTO %q", auto)
+		}
+		if v && cfg.UndoExcludeSuggest == 0 {
+			return nil, fmt.Errorf("UNDO_EXCLUDE_AUTO requires UNDO_EXCLUDE_SUGGEST")
+		}
+		cfg.UndoExcludeAuto = v
+	}
+
+	if rate := getenv("ACTION_RATE_LIMIT"); rate != "" {
+		n, period, err := parseRate(rate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ACTION_RATE_LIMIT: %w", err)
+		}
+		cfg.ActionRateLimit = n
+		cfg.ActionRatePeriod = period
+	}
+	if rate := getenv("REACTION_RATE_LIMIT"); rate != "" {
+		n, period, err := parseRate(rate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REACTION_RATE_LIMIT: %w", err)
+		}
+		cfg.ReactionRateLimit = n
+		cfg.ReactionRatePeriod = period
+	}
+	if rate := getenv("DELETION_RATE_LIMIT"); rate != "" {
+		n, period, err := parseRate(rate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DELETION_RATE_LIMIT: %w", err)
+		}
+		cfg.DeletionRateLimit = n
+		cfg.DeletionRatePeriod = period
+	}
+	if jitter := getenv("ACTION_JITTER"); jitter != "" {
+		lo, hi, err := parseJitter(jitter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ACTION_JITTER: %w", err)
+		}
+		cfg.ActionJitterMin = lo
+		cfg.ActionJitterMax = hi
+	}
+
+	if dryRun := getenv("DRY_RUN"); dryRun != "" {
+		v, err := strconv.ParseBool(dryRun)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DRY_RUN %q", dryRun)
+		}
+		cfg.DryRun = v
+	}
+
+	if readOnly := getenv("READ_ONLY"); readOnly != "" {
+		v, err := strconv.ParseBool(readOnly)
+		if err != nil {
+			return nil, fmt.Errorf("invalid READ_ONLY %q", readOnly)
+		}
+		cfg.ReadOnly = v
+	}
+
+	if list := getenv("FEATURE_FLAGS"); list != "" {
+		cfg.FeatureFlags = make(map[flags.Flag]bool)
+		for name := range strings.SplitSeq(list, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			name, off := strings.CutPrefix(name, "-")
+			f, err := flags.Parse(name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid FEATURE_FLAGS: %w", err)
+			}
+			cfg.FeatureFlags[f] = !off
+		}
+	}
+
+	if report := getenv("STARTUP_REPORT"); report != "" {
+		v, err := strconv.ParseBool(report)
+		if err != nil {
+			return nil, fmt.Errorf("invalid STARTUP_REPORT %q", report)
+		}
+		cfg.StartupReport = v
+	}
+
+	if digest := getenv("WEEKLY_DIGEST"); digest != "" {
+		v, err := strconv.ParseBool(digest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid WEEKLY_DIGEST %q", digest)
+		}
+		cfg.WeeklyDigest = v
+	}
+	switch highlight := getenv("WEEKLY_HIGHLIGHT"); highlight {
+	case "", HighlightPin, HighlightPost:
+		cfg.WeeklyHighlight = highlight
+	default:
+		return nil, fmt.Errorf("invalid WEEKLY_HIGHLIGHT %q (expected pin or post)", highlight)
+	}
+
+	if dms := getenv("DIRECT_MESSAGES"); dms != "" {
+		v, err := strconv.ParseBool(dms)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DIRECT_MESSAGES %q", dms)
+		}
+		cfg.DirectMessages = v
+	}
+
+	if recheck := getenv("REACTION_RECHECK"); recheck != "" {
+		v, err := strconv.ParseBool(recheck)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REACTION_RECHECK %q", recheck)
+		}
+		cfg.ReactionRecheck = v
+	}
+
+	switch mode := getenv("REPLACE_MODE"); mode {
+	case "":
+		cfg.ReplaceMode = ReplaceBoth
+	case ReplaceBoth, ReplaceRemove, ReplaceAdd:
+		cfg.ReplaceMode = mode
+	default:
+		return nil, fmt.Errorf("invalid REPLACE_MODE %q (expected both, remove, or add)", mode)
+	}
+
+	switch indicator := getenv("PROGRESS_INDICATOR"); indicator {
+	case "", IndicatorReaction, IndicatorTyping:
+		cfg.ProgressIndicator = indicator
+	default:
+		return nil, fmt.Errorf("invalid PROGRESS_INDICATOR %q (expected reaction or typing)", indicator)
+	}
+
+	if delay := getenv("SOFT_DELETE_DELAY"); delay != "" {
+		d, err := time.ParseDuration(delay)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid SOFT_DELETE_DELAY %q", delay)
+		}
+		cfg.SoftDeleteDelay = d
+	}
+
+	if notice := getenv("DELETION_NOTICE"); notice != "" {
+		if _, err := template.New("notice").Parse(notice); err != nil {
+			return nil, fmt.Errorf("invalid DELETION_NOTICE template: %w", err)
+		}
+		cfg.DeletionNotice = notice
+	}
+	cfg.DeletionNoticeTTL = 10 * time.Second
+	if ttl := getenv("DELETION_NOTICE_TTL"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid DELETION_NOTICE_TTL %q", ttl)
+		}
+		cfg.DeletionNoticeTTL = d
+	}
+	cfg.DeletionNoticeCooldown = time.Minute
+	if cooldown := getenv("DELETION_NOTICE_COOLDOWN"); cooldown != "" {
+		d, err := time.ParseDuration(cooldown)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid DELETION_NOTICE_COOLDOWN %q", cooldown)
+		}
+		cfg.DeletionNoticeCooldown = d
+	}
+
+	if key := getenv("STORE_KEY"); key != "" {
+		if cfg.StorePath == "" {
+			return nil, fmt.Errorf("STORE_KEY requires STORE_PATH")
+		}
+		k, err := base64.StdEncoding.DecodeString(key)
+		if err != nil || (len(k) != 16 && len(k) != 24 && len(k) != 32) {
+			return nil, fmt.Errorf("invalid STORE_KEY (expected a base64-encoded 16, 24, or 32 byte key)")
+		}
+		cfg.StoreKey = k
+	}
+
+	if cfg.AuditLogReason != "" {
+		if _, err := template.New("reason").Parse(cfg.AuditLogReason); err != nil {
+			return nil, fmt.Errorf("invalid AUDIT_LOG_REASON template: %w", err)
+		}
+	}
+
+	if retention := getenv("RETAIN_DELETED_CONTENT"); retention != "" {
+		d, err := time.ParseDuration(retention)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid RETAIN_DELETED_CONTENT %q", retention)
+		}
+		cfg.DeletedRetention = d
+	}
+
+	cfg.SkullKeywords = DefaultSkullKeywords
+	if spec := getenv("SKULL_KEYWORDS"); spec != "" {
+		keywords, err := parseSkullKeywords(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SKULL_KEYWORDS: %w", err)
+		}
+		cfg.SkullKeywords = keywords
+	}
+	if pattern := getenv("EMOJI_MATCH_PATTERN"); pattern != "" {
+		re, err := compilePattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EMOJI_MATCH_PATTERN: %w", err)
+		}
+		if getenv("SKULL_KEYWORDS") == "" {
+			// The pattern replaces the default keywords, keeping their exclusions
+			cfg.SkullKeywords.Match = nil
+		}
+		cfg.SkullKeywords.Pattern = re
+	}
+	if pattern := getenv("EMOJI_EXCLUDE_PATTERN"); pattern != "" {
+		re, err := compilePattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EMOJI_EXCLUDE_PATTERN: %w", err)
+		}
+		cfg.SkullKeywords.ExcludePattern = re
+	}
+	if rules := getenv("EMOJI_RULES"); rules != "" {
+		if err := cfg.parseEmojiRules(rules); err != nil {
+			return nil, fmt.Errorf("invalid EMOJI_RULES: %w", err)
+		}
+	}
+
+	if spec := getenv("ESCALATION"); spec != "" {
+		steps, err := parseEscalation(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ESCALATION: %w", err)
+		}
+		cfg.Escalation = steps
+	}
+	if spec := getenv("WEBHOOKS"); spec != "" {
+		hooks, err := parseWebhooks(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid WEBHOOKS: %w", err)
+		}
+		cfg.Webhooks = hooks
+	}
+	if path := getenv("WEBHOOKS_FILE"); path != "" {
+		if cfg.Webhooks != nil {
+			return nil, fmt.Errorf("WEBHOOKS and WEBHOOKS_FILE cannot both be set")
+		}
+		spec, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read WEBHOOKS_FILE: %w", err)
+		}
+		hooks, err := parseWebhooks(string(spec))
+		if err != nil {
+			return nil, fmt.Errorf("invalid WEBHOOKS_FILE %s: %w", path, err)
+		}
+		cfg.Webhooks = hooks
+	}
+
+	if cfg.PublicURL != "" {
+		u, err := url.Parse(cfg.PublicURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid PUBLIC_URL %q (expected an http or https URL)", cfg.PublicURL)
+		}
+	}
+
+	if level := getenv("LOG_LEVEL"); level != "" {
+		if err := cfg.LogLevel.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid LOG_LEVEL %q (expected debug, info, warn, or error)", level)
+		}
+	}
+
+	cfg.LogDebugSample = 1
+	if sample := getenv("LOG_DEBUG_SAMPLE"); sample != "" {
+		n, err := strconv.Atoi(sample)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid LOG_DEBUG_SAMPLE %q (expected a positive number)", sample)
+		}
+		cfg.LogDebugSample = n
+	}
+
+	switch shipper := getenv("LOG_SHIPPER"); shipper {
+	case "", "none":
+	case LogShipperLoki:
+		u, err := url.Parse(cfg.LokiURL)
+		if cfg.LokiURL == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid LOKI_URL %q (expected an http or https URL, required by LOG_SHIPPER=loki)", cfg.LokiURL)
+		}
+		cfg.LogShipper = shipper
+	case LogShipperCloudWatch:
+		for _, required := range []struct{ name, value string }{
+			{"CLOUDWATCH_LOG_GROUP", cfg.CloudWatchLogGroup},
+			{"AWS_REGION", cfg.AWSRegion},
+			{"AWS_ACCESS_KEY_ID", cfg.AWSAccessKeyID},
+			{"AWS_SECRET_ACCESS_KEY", cfg.AWSSecretAccessKey},
+		} {
+			if required.value == "" {
+				return nil, fmt.Errorf("%s is required by LOG_SHIPPER=cloudwatch", required.name)
+			}
+		}
+		if cfg.CloudWatchLogStream == "" {
+			cfg.CloudWatchLogStream = "jolly-okurb"
+		}
+		cfg.LogShipper = shipper
+	default:
+		return nil, fmt.Errorf("invalid LOG_SHIPPER %q (expected none, loki, or cloudwatch)", shipper)
+	}
+
+	if cfg.MetricsBackend == "" {
+		cfg.MetricsBackend = "none"
+	}
+	if cfg.MetricsAddr == "" {
+		cfg.MetricsAddr = "127.0.0.1:8125"
+	}
+	if cfg.MetricsPrefix == "" {
+		cfg.MetricsPrefix = "jolly_okurb"
+	}
+	cfg.MetricsLabelLimit = 100
+	if limit := getenv("METRICS_LABEL_LIMIT"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid METRICS_LABEL_LIMIT %q (expected a positive number)", limit)
+		}
+		cfg.MetricsLabelLimit = n
+	}
+	if cfg.NATSSubject == "" {
+		cfg.NATSSubject = "jolly.events"
+	}
+	if cfg.MQTTTopic == "" {
+		cfg.MQTTTopic = "jolly"
+	}
 
 	return cfg, nil
 }
+
+// parseRate parses a rate such as "20/10s" into a count and a period.
+func parseRate(spec string) (int, time.Duration, error) {
+	count, period, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected format <count>/<duration>, got %q", spec)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || n <= 0 {
+		return 0, 0, fmt.Errorf("count must be a positive integer, got %q", count)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || d <= 0 {
+		return 0, 0, fmt.Errorf("period must be a positive duration, got %q", period)
+	}
+	return n, d, nil
+}
+
+// parseJitter parses a jitter range such as "1s-5s", or "5s" for up to 5s.
+func parseJitter(spec string) (lo, hi time.Duration, err error) {
+	loSpec, hiSpec, isRange := strings.Cut(spec, "-")
+	if !isRange {
+		loSpec, hiSpec = "0s", spec
+	}
+	lo, err = time.ParseDuration(strings.TrimSpace(loSpec))
+	if err != nil || lo < 0 {
+		return 0, 0, fmt.Errorf("expected format <duration> or <min>-<max>, got %q", spec)
+	}
+	hi, err = time.ParseDuration(strings.TrimSpace(hiSpec))
+	if err != nil || hi < lo {
+		return 0, 0, fmt.Errorf("expected format <duration> or <min>-<max> with min <= max, got %q", spec)
+	}
+	return lo, hi, nil
+}
+
+// parseEmoji parses an emoji into the form reactions take: name:id for a
+// custom emoji, given as such or as a message tag like <:name:id> or
+// <a:name:id>, or a Unicode emoji such as 🎅 as is.
+func parseEmoji(spec string) (string, error) {
+	tag := strings.TrimSuffix(strings.TrimPrefix(spec, "<"), ">")
+	if prefix, rest, _ := strings.Cut(tag, ":"); strings.Count(tag, ":") == 2 && (prefix == "" || prefix == "a") {
+		tag = rest // Animated emojis react by name:id too
+	}
+	name, id, custom := strings.Cut(tag, ":")
+	if custom {
+		if _, err := strconv.ParseUint(id, 10, 64); err != nil || name == "" {
+			return "", fmt.Errorf("expected name:id, <:name:id>, or a Unicode emoji, got %q", spec)
+		}
+		return name + ":" + id, nil
+	}
+	// Names and :shortcodes: are not emojis to Discord's API
+	if spec == "" || strings.ContainsFunc(spec, func(r rune) bool { return r == ' ' || r < utf8.RuneSelf && unicode.IsLetter(r) }) {
+		return "", fmt.Errorf("expected name:id, <:name:id>, or a Unicode emoji, got %q", spec)
+	}
+	return spec, nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Escalation actions, taken on top of the usual reaction replacement or deletion.
+const (
+	EscalateDM     = "dm"     // Send the user a warning in a DM
+	EscalateNotify = "notify" // Tell the mods in the admin channel
+)
+
+// EscalationStep is an action taken once a user's skulls have been acted on
+// Threshold times in a UTC day.
+type EscalationStep struct {
+	Threshold int
+	Action    string
+}
+
+// parseEscalation parses a list such as "5:dm,20:notify" into steps ordered by threshold.
+func parseEscalation(spec string) ([]EscalationStep, error) {
+	var steps []EscalationStep
+	for entry := range strings.SplitSeq(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		count, action, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("expected format <count>:<action>, got %q", entry)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("count must be a positive integer, got %q", count)
+		}
+		action = strings.ToLower(strings.TrimSpace(action))
+		if action != EscalateDM && action != EscalateNotify {
+			return nil, fmt.Errorf("unknown action %q (expected dm or notify)", action)
+		}
+		steps = append(steps, EscalationStep{Threshold: n, Action: action})
+	}
+	slices.SortStableFunc(steps, func(a, b EscalationStep) int {
+		return a.Threshold - b.Threshold
+	})
+	return steps, nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoad_Escalation(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      string
+		wantErr   string
+		wantSteps []EscalationStep
+	}{
+		{
+			name:      "steps are ordered by threshold",
+			spec:      "20:notify, 5:DM",
+			wantSteps: []EscalationStep{{5, EscalateDM}, {20, EscalateNotify}},
+		},
+		{
+			name:      "several actions at one threshold",
+			spec:      "10:dm,10:notify,",
+			wantSteps: []EscalationStep{{10, EscalateDM}, {10, EscalateNotify}},
+		},
+		{name: "missing action", spec: "5", wantErr: "expected format <count>:<action>"},
+		{name: "zero count", spec: "0:dm", wantErr: "count must be a positive integer"},
+		{name: "unknown action", spec: "5:ban", wantErr: `unknown action "ban"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnvVars()
+			defer clearEnvVars()
+			os.Setenv("DISCORD_TOKEN", "test-token")
+			os.Setenv("DISCORD_GUILD_ID", "guild-123")
+			os.Setenv("DISCORD_TARGET_USER_IDS", "1")
+			os.Setenv("DISCORD_JOLLYSKULL_ID", "jollyskull:789")
+			os.Setenv("ESCALATION", tt.spec)
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "ESCALATION") {
+					t.Fatalf("Load() error = %v, want it to name ESCALATION and contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() unexpected error: %v", err)
+			}
+			if !slices.Equal(cfg.Escalation, tt.wantSteps) {
+				t.Errorf("Escalation = %v, want %v", cfg.Escalation, tt.wantSteps)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FileVersion is the version of the config file format this release reads
+// natively. Files of earlier versions are upgraded when they are loaded.
+//
+//   - Version 1 is a flat JSON object of environment variables and their
+//     values, rules included as JSON strings.
+//   - Version 2 keeps the settings under "settings" and holds the rules,
+//     target groups, escalation, skull keywords, and webhooks, as JSON
+//     under "rules".
+const FileVersion = 2
+
+// File is a config file, an alternative to setting every environment
+// variable. Environment variables that are set win over the file. It is
+// JSON, or YAML or TOML by its extension (.yaml, .yml, or .toml), holding
+// the same fields.
+type File struct {
+	Version  int               `json:"version"`
+	Settings map[string]string `json:"settings,omitempty"` // Values keyed by environment variable
+	Rules    Rules             `json:"rules,omitzero"`
+}
+
+// Rules are the structured settings of a config file, in the formats of
+// their environment variables.
+type Rules struct {
+	TargetGroups   json.RawMessage `json:"target_groups,omitempty"`   // TARGET_GROUPS
+	Escalation     string          `json:"escalation,omitempty"`      // ESCALATION
+	SkullKeywords  string          `json:"skull_keywords,omitempty"`  // SKULL_KEYWORDS
+	MatchPattern   string          `json:"match_pattern,omitempty"`   // EMOJI_MATCH_PATTERN
+	ExcludePattern string          `json:"exclude_pattern,omitempty"` // EMOJI_EXCLUDE_PATTERN
+	EmojiRules     json.RawMessage `json:"emoji_rules,omitempty"`     // EMOJI_RULES
+	Webhooks       json.RawMessage `json:"webhooks,omitempty"`        // WEBHOOKS
+}
+
+// upgrades[v] upgrades a file from version v to version v+1, returning what
+// it changed.
+var upgrades = map[int]func(raw []byte) (*File, []string, error){
+	1: upgradeV1,
+}
+
+// ReadFile reads the config file at path, upgrading it to FileVersion. It
+// returns the upgraded file and a description of every change the upgrade
+// made, for the deployment's logs.
+func ReadFile(path string) (*File, []string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if raw, err = fileJSON(path, raw); err != nil {
+		return nil, nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	var probe struct {
+		Version *int `json:"version"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return nil, nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	version := 1 // Version 1 files have no version
+	if probe.Version != nil {
+		version = *probe.Version
+	}
+	if version < 1 || version > FileVersion {
+		return nil, nil, fmt.Errorf("config file %s has version %d, but this release reads versions 1 to %d", path, version, FileVersion)
+	}
+
+	var migrations []string
+	for ; version < FileVersion; version++ {
+		f, changes, err := upgrades[version](raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to upgrade config file %s from version %d: %w", path, version, err)
+		}
+		migrations = append(migrations, fmt.Sprintf("upgraded config file from version %d to %d", version, version+1))
+		migrations = append(migrations, changes...)
+		if raw, err = json.Marshal(f); err != nil {
+			return nil, nil, fmt.Errorf("failed to upgrade config file %s: %w", path, err)
+		}
+	}
+
+	f := &File{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(f); err != nil {
+		return nil, nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if err := f.checkSettings(); err != nil {
+		return nil, nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return f, migrations, nil
+}
+
+// fileJSON returns the JSON of the config file at path, whose content is
+// raw, converting YAML and TOML by the extension of path. Those formats
+// arrived with version 2, so a file without a version is read as version 2,
+// and they may write settings as numbers or booleans, which become strings.
+func fileJSON(path string, raw []byte) ([]byte, error) {
+	var parse func([]byte) (any, error)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		parse = parseYAML
+	case ".toml":
+		parse = parseTOML
+	default:
+		return raw, nil
+	}
+	v, err := parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a mapping of version, settings, and rules")
+	}
+	switch version, ok := doc["version"]; {
+	case !ok:
+		doc["version"] = FileVersion
+	case version == json.Number("1"):
+		return nil, fmt.Errorf("version 1 config files are JSON")
+	}
+	if settings, ok := doc["settings"].(map[string]any); ok {
+		for name, v := range settings {
+			switch v := v.(type) {
+			case string:
+			case json.Number:
+				settings[name] = string(v)
+			case bool:
+				settings[name] = strconv.FormatBool(v)
+			default:
+				return nil, fmt.Errorf("setting %s must be a string, number, or boolean", name)
+			}
+		}
+	}
+	return json.Marshal(doc)
+}
+
+// upgradeV1 moves the rules of a flat version 1 file out of their JSON
+// strings and into the rules section.
+func upgradeV1(raw []byte) (*File, []string, error) {
+	var settings map[string]string
+	if err := json.Unmarshal(raw, &settings); err != nil {
+		return nil, nil, fmt.Errorf("expected an object of environment variables and string values: %w", err)
+	}
+	f := &File{Version: 2, Settings: settings}
+	var changes []string
+	move := func(envVar string, set func(string) error) error {
+		v, ok := settings[envVar]
+		if !ok {
+			return nil
+		}
+		if err := set(v); err != nil {
+			return fmt.Errorf("invalid %s: %w", envVar, err)
+		}
+		delete(settings, envVar)
+		changes = append(changes, fmt.Sprintf("moved %s to rules.%s", envVar, ruleFields[envVar]))
+		return nil
+	}
+	rawJSON := func(dst *json.RawMessage) func(string) error {
+		return func(v string) error {
+			if !json.Valid([]byte(v)) {
+				return fmt.Errorf("expected JSON")
+			}
+			*dst = json.RawMessage(v)
+			return nil
+		}
+	}
+	if err := move("TARGET_GROUPS", rawJSON(&f.Rules.TargetGroups)); err != nil {
+		return nil, nil, err
+	}
+	if err := move("ESCALATION", func(v string) error { f.Rules.Escalation = v; return nil }); err != nil {
+		return nil, nil, err
+	}
+	if err := move("SKULL_KEYWORDS", func(v string) error { f.Rules.SkullKeywords = v; return nil }); err != nil {
+		return nil, nil, err
+	}
+	if err := move("EMOJI_MATCH_PATTERN", func(v string) error { f.Rules.MatchPattern = v; return nil }); err != nil {
+		return nil, nil, err
+	}
+	if err := move("EMOJI_EXCLUDE_PATTERN", func(v string) error { f.Rules.ExcludePattern = v; return nil }); err != nil {
+		return nil, nil, err
+	}
+	if err := move("EMOJI_RULES", rawJSON(&f.Rules.EmojiRules)); err != nil {
+		return nil, nil, err
+	}
+	if err := move("WEBHOOKS", rawJSON(&f.Rules.Webhooks)); err != nil {
+		return nil, nil, err
+	}
+	return f, changes, nil
+}
+
+// Write writes f as indented JSON.
+func (f *File) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(f); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// Lookup returns the value of the environment variable name as the file sets
+// it, and whether it does.
+func (f *File) Lookup(name string) (string, bool) {
+	switch name {
+	case "TARGET_GROUPS":
+		return string(f.Rules.TargetGroups), f.Rules.TargetGroups != nil
+	case "ESCALATION":
+		return f.Rules.Escalation, f.Rules.Escalation != ""
+	case "SKULL_KEYWORDS":
+		return f.Rules.SkullKeywords, f.Rules.SkullKeywords != ""
+	case "EMOJI_MATCH_PATTERN":
+		return f.Rules.MatchPattern, f.Rules.MatchPattern != ""
+	case "EMOJI_EXCLUDE_PATTERN":
+		return f.Rules.ExcludePattern, f.Rules.ExcludePattern != ""
+	case "EMOJI_RULES":
+		return string(f.Rules.EmojiRules), f.Rules.EmojiRules != nil
+	case "WEBHOOKS":
+		return string(f.Rules.Webhooks), f.Rules.Webhooks != nil
+	}
+	v, ok := f.Settings[name]
+	return v, ok
+}
+
+// ruleFields maps the environment variables of rules to their field in the
+// rules section.
+var ruleFields = map[string]string{
+	"TARGET_GROUPS":         "target_groups",
+	"ESCALATION":            "escalation",
+	"SKULL_KEYWORDS":        "skull_keywords",
+	"EMOJI_MATCH_PATTERN":   "match_pattern",
+	"EMOJI_EXCLUDE_PATTERN": "exclude_pattern",
+	"EMOJI_RULES":           "emoji_rules",
+	"WEBHOOKS":              "webhooks",
+}
+
+// checkSettings rejects rules set as plain settings in a version 2 file,
+// where they would be ignored.
+func (f *File) checkSettings() error {
+	for _, name := range slices.Sorted(maps.Keys(f.Settings)) {
+		if field, ok := ruleFields[name]; ok {
+			return fmt.Errorf("%s belongs in the rules section, as rules.%s", name, field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadFile(t *testing.T) {
+	tests := []struct {
+		name           string
+		content        string
+		wantErr        bool
+		wantMigrations []string
+		lookup         map[string]string // Expected values of the upgraded file
+	}{
+		{
+			name:    "version 1",
+			content: `{"DISCORD_GUILD_ID": "guild-123", "TARGET_GROUPS": "[{\"name\":\"casual\",\"users\":[\"u1\"]}]", "ESCALATION": "5:dm"}`,
+			wantMigrations: []string{
+				"upgraded config file from version 1 to 2",
+				"moved TARGET_GROUPS to rules.target_groups",
+				"moved ESCALATION to rules.escalation",
+			},
+			lookup: map[string]string{
+				"DISCORD_GUILD_ID": "guild-123",
+				"TARGET_GROUPS":    `[{"name":"casual","users":["u1"]}]`,
+				"ESCALATION":       "5:dm",
+				"WEBHOOKS":         "",
+			},
+		},
+		{
+			name:    "version 2",
+			content: `{"version": 2, "settings": {"DISCORD_GUILD_ID": "guild-123"}, "rules": {"webhooks": [{"url": "https://example.com/hook"}], "skull_keywords": "skull,calavera", "exclude_pattern": "^skullcandy$"}}`,
+			lookup: map[string]string{
+				"DISCORD_GUILD_ID":      "guild-123",
+				"WEBHOOKS":              `[{"url": "https://example.com/hook"}]`,
+				"SKULL_KEYWORDS":        "skull,calavera",
+				"EMOJI_EXCLUDE_PATTERN": "^skullcandy$",
+			},
+		},
+		{
+			name:    "version 1 with invalid rules",
+			content: `{"TARGET_GROUPS": "[{"}`,
+			wantErr: true,
+		},
+		{
+			name:    "version 1 with a non-string value",
+			content: `{"HISTORICAL_WORKERS": 2}`,
+			wantErr: true,
+		},
+		{
+			name:    "rules among version 2 settings",
+			content: `{"version": 2, "settings": {"ESCALATION": "5:dm"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown version 2 field",
+			content: `{"version": 2, "rule": {}}`,
+			wantErr: true,
+		},
+		{
+			name:    "newer version",
+			content: `{"version": 3}`,
+			wantErr: true,
+		},
+		{
+			name:    "not JSON",
+			content: `DISCORD_GUILD_ID=guild-123`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			f, migrations, err := ReadFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if f.Version != FileVersion {
+				t.Errorf("Version = %d, want %d", f.Version, FileVersion)
+			}
+			if strings.Join(migrations, "\n") != strings.Join(tt.wantMigrations, "\n") {
+				t.Errorf("migrations = %q, want %q", migrations, tt.wantMigrations)
+			}
+			for name, want := range tt.lookup {
+				if got, _ := f.Lookup(name); got != want {
+					t.Errorf("Lookup(%s) = %q, want %q", name, got, want)
+				}
+			}
+
+			// The upgraded file reads back without further migrations
+			var buf bytes.Buffer
+			if err := f.Write(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, migrations, err := ReadFile(path); err != nil || len(migrations) != 0 {
+				t.Errorf("rereading the written file = %q, %v, want no migrations", migrations, err)
+			}
+		})
+	}
+}
+
+func TestLoad_ConfigFile(t *testing.T) {
+	clearEnvVars()
+	defer clearEnvVars()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{
+		"DISCORD_TOKEN": "file-token",
+		"DISCORD_GUILD_ID": "file-guild",
+		"DISCORD_TARGET_USER_IDS": "user-456",
+		"DISCORD_JOLLYSKULL_ID": "jollyskull:789",
+		"TARGET_GROUPS": "[{\"name\":\"casual\",\"users\":[\"user-789\"]}]"
+	}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("CONFIG_FILE", path)
+	os.Setenv("DISCORD_GUILD_ID", "env-guild")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if cfg.Token != "file-token" || cfg.GuildID != "env-guild" {
+		t.Errorf("Token, GuildID = %q, %q, want the file's token and the environment's guild", cfg.Token, cfg.GuildID)
+	}
+	if len(cfg.TargetGroups) != 1 || len(cfg.TargetUserIDs) != 2 {
+		t.Errorf("TargetGroups, TargetUserIDs = %v, %v, want the file's group added to the targets", cfg.TargetGroups, cfg.TargetUserIDs)
+	}
+	if len(cfg.Migrations) != 2 {
+		t.Errorf("Migrations = %q, want the upgrade from version 1", cfg.Migrations)
+	}
+}
+
+func TestLoad_ConfigFileRules(t *testing.T) {
+	clearEnvVars()
+	defer clearEnvVars()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{
+		"version": 2,
+		"settings": {
+			"DISCORD_TOKEN": "file-token",
+			"DISCORD_GUILD_ID": "file-guild",
+			"DISCORD_JOLLYSKULL_ID": "jollyskull:789"
+		},
+		"rules": {
+			"target_groups": [
+				{"name": "lounge", "users": ["1"], "channels": ["100"], "replace": "add"},
+				{"name": "memes", "users": ["2"], "channels": ["200", "300"], "actions": ["reactions"]}
+			]
+		}
+	}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("CONFIG_FILE", path)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if len(cfg.TargetGroups) != 2 || !slices.Equal(cfg.TargetGroups[1].Channels, []string{"200", "300"}) {
+		t.Errorf("TargetGroups = %+v, want the file's per-channel groups", cfg.TargetGroups)
+	}
+	if len(cfg.Migrations) != 0 {
+		t.Errorf("Migrations = %q, want none for a current file", cfg.Migrations)
+	}
+}
+
+func TestLoad_ConfigFileUnknownSetting(t *testing.T) {
+	clearEnvVars()
+	defer clearEnvVars()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `settings:
+  DISCORD_TOKEN: file-token
+  DISCORD_GUILD_ID: file-guild
+  DISCORD_TARGET_USER_IDS: user-456
+  DISCORD_JOLLYSKULL_ID: jollyskull:789
+  HISTORICAL_WORKER: 4
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("CONFIG_FILE", path)
+
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "unknown setting HISTORICAL_WORKER") {
+		t.Errorf("Load() error = %v, want it to name the misspelled setting", err)
+	}
+}
+
+func TestReadFile_Formats(t *testing.T) {
+	want := map[string]string{
+		"DISCORD_GUILD_ID":   "guild-123",
+		"DRY_RUN":            "true",
+		"HISTORICAL_WORKERS": "4",
+		"TARGET_GROUPS":      `[{"channels":["100"],"name":"lounge","users":["1"]}]`,
+		"ESCALATION":         "5:dm",
+	}
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr bool
+	}{
+		{
+			name: "yaml",
+			file: "config.yaml",
+			content: `settings:
+  DISCORD_GUILD_ID: guild-123
+  DRY_RUN: true
+  HISTORICAL_WORKERS: 4
+rules:
+  target_groups:
+    - name: lounge
+      users: ["1"]
+      channels: ["100"]
+  escalation: 5:dm
+`,
+		},
+		{
+			name: "toml",
+			file: "config.TOML",
+			content: `version = 2
+
+[settings]
+DISCORD_GUILD_ID = "guild-123"
+DRY_RUN = true
+HISTORICAL_WORKERS = 4
+
+[rules]
+escalation = "5:dm"
+
+[[rules.target_groups]]
+name = "lounge"
+users = ["1"]
+channels = ["100"]
+`,
+		},
+		{
+			name:    "yaml setting that is a list",
+			file:    "config.yml",
+			content: "settings:\n  DISCORD_TARGET_USER_IDS: [1, 2]\n",
+			wantErr: true,
+		},
+		{
+			name:    "yaml unknown section",
+			file:    "config.yaml",
+			content: "setings:\n  DISCORD_GUILD_ID: guild-123\n",
+			wantErr: true,
+		},
+		{
+			name:    "toml unknown rule",
+			file:    "config.toml",
+			content: "[rules]\nescalations = \"5:dm\"\n",
+			wantErr: true,
+		},
+		{
+			name:    "version 1 yaml",
+			file:    "config.yml",
+			content: "version: 1\nDISCORD_GUILD_ID: guild-123\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			f, migrations, err := ReadFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if f.Version != FileVersion || len(migrations) != 0 {
+				t.Errorf("Version = %d, migrations = %q, want version %d without migrations", f.Version, migrations, FileVersion)
+			}
+			for name, want := range want {
+				if got, _ := f.Lookup(name); got != want {
+					t.Errorf("Lookup(%s) = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
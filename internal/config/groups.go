@@ -0,0 +1,249 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Actions a target group can be limited to. They match the feature names.
+const (
+	ActionReactions = "reactions"
+	ActionDeletion  = "deletion"
+)
+
+// Steps of handling a target user's skull, as listed in a target group's
+// steps rule.
+const (
+	StepRemove   = "remove"   // Remove the skull
+	StepAdd      = "add"      // Add jollyskull
+	StepLog      = "log"      // Log the skull and the steps so far
+	StepDM       = "dm"       // Tell the user in a DM
+	StepEscalate = "escalate" // Count it towards ESCALATION; always last
+)
+
+// maxReplacements bounds the emojis a group's skulls are replaced with, as
+// each one is another request to Discord.
+const maxReplacements = 5
+
+// TargetGroup is a set of target users with their own rules, so enforcement
+// can differ between, say, repeat offenders and casual skull posters.
+type TargetGroup struct {
+	Name     string   `json:"name"`
+	Users    []string `json:"users"`
+	Actions  []string `json:"actions,omitempty"`  // Empty means every action
+	Emojis   []string `json:"emojis,omitempty"`   // Skull emoji names acted on; empty means all
+	Hours    string   `json:"hours,omitempty"`    // UTC hours the group is enforced, e.g. "9-17"; empty means always
+	Channels []string `json:"channels,omitempty"` // IDs of the channels the group is enforced in; empty means all
+	Replace  string   `json:"replace,omitempty"`  // Steps of its skull replacements, as in REPLACE_MODE; empty means REPLACE_MODE
+	Steps    []string `json:"steps,omitempty"`    // Ordered steps of handling its skulls, instead of Replace
+
+	// Emojis the add step reacts with, in order, as in DISCORD_JOLLYSKULL_ID;
+	// empty means jollyskull
+	Replacements []string `json:"replacements,omitempty"`
+
+	from, until int // Parsed Hours; equal means always
+}
+
+// Allows reports whether the group is subject to action.
+func (g *TargetGroup) Allows(action string) bool {
+	return len(g.Actions) == 0 || slices.Contains(g.Actions, action)
+}
+
+// MatchesEmoji reports whether the group's skulls named name are acted on.
+func (g *TargetGroup) MatchesEmoji(name string) bool {
+	return len(g.Emojis) == 0 || slices.ContainsFunc(g.Emojis, func(e string) bool {
+		return strings.EqualFold(e, name)
+	})
+}
+
+// InChannel reports whether the group is enforced in channelID.
+func (g *TargetGroup) InChannel(channelID string) bool {
+	return len(g.Channels) == 0 || slices.Contains(g.Channels, channelID)
+}
+
+// ActiveAt reports whether the group is enforced at t.
+func (g *TargetGroup) ActiveAt(t time.Time) bool {
+	if g.from == g.until {
+		return true
+	}
+	h := t.UTC().Hour()
+	if g.from < g.until {
+		return h >= g.from && h < g.until
+	}
+	return h >= g.from || h < g.until // The window wraps past midnight
+}
+
+// ReplaceModeFor returns the steps of replacing userID's skulls: those of
+// the user's group if it sets them, else ReplaceMode.
+func (c *Config) ReplaceModeFor(userID string) string {
+	if g := c.Group(userID); g != nil && g.Replace != "" {
+		return g.Replace
+	}
+	return c.ReplaceMode
+}
+
+// StepsFor returns the ordered steps of handling userID's skulls: those of
+// the user's group if it lists them, else the ones its replace mode implies.
+func (c *Config) StepsFor(userID string) []string {
+	if g := c.Group(userID); g != nil && len(g.Steps) > 0 {
+		return g.Steps
+	}
+	switch c.ReplaceModeFor(userID) {
+	case ReplaceRemove:
+		return []string{StepRemove, StepEscalate}
+	case ReplaceAdd:
+		return []string{StepAdd, StepEscalate}
+	default:
+		return []string{StepRemove, StepAdd, StepEscalate}
+	}
+}
+
+// ReplacementsFor returns the emojis userID's skulls are replaced with, or
+// nil for jollyskull.
+func (c *Config) ReplacementsFor(userID string) []string {
+	if g := c.Group(userID); g != nil {
+		return g.Replacements
+	}
+	return nil
+}
+
+// IsReplacement reports whether emoji, as name:id or Unicode, is one the bot
+// replaces skulls with: jollyskull, its fallback, or one of a group's
+// replacements. The replacements of EMOJI_RULES count too.
+func (c *Config) IsReplacement(emoji string) bool {
+	if emoji == c.JollySkullID || emoji == c.JollySkullFallback {
+		return true
+	}
+	if slices.ContainsFunc(c.EmojiRules, func(r EmojiRule) bool { return r.Replacement == emoji }) {
+		return true
+	}
+	return slices.ContainsFunc(c.TargetGroups, func(g TargetGroup) bool {
+		return slices.Contains(g.Replacements, emoji)
+	})
+}
+
+// Group returns the group userID belongs to, or nil if it is in none.
+func (c *Config) Group(userID string) *TargetGroup {
+	for i := range c.TargetGroups {
+		if slices.Contains(c.TargetGroups[i].Users, userID) {
+			return &c.TargetGroups[i]
+		}
+	}
+	return nil
+}
+
+// parseTargetGroups decodes a JSON array of groups and adds their users to the targets.
+func (c *Config) parseTargetGroups(spec string) error {
+	dec := json.NewDecoder(strings.NewReader(spec))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c.TargetGroups); err != nil {
+		return fmt.Errorf("expected a JSON array of groups: %w", err)
+	}
+
+	seen := make(map[string]string)
+	for i := range c.TargetGroups {
+		g := &c.TargetGroups[i]
+		if g.Name == "" {
+			return fmt.Errorf("group %d has no name", i+1)
+		}
+		if len(g.Users) == 0 {
+			return fmt.Errorf("group %q has no users", g.Name)
+		}
+		for _, a := range g.Actions {
+			if a != ActionReactions && a != ActionDeletion {
+				return fmt.Errorf("group %q has unknown action %q (expected reactions or deletion)", g.Name, a)
+			}
+		}
+		switch g.Replace {
+		case "", ReplaceBoth, ReplaceRemove, ReplaceAdd:
+		default:
+			return fmt.Errorf("group %q has unknown replace %q (expected both, remove, or add)", g.Name, g.Replace)
+		}
+		if err := validateSteps(g); err != nil {
+			return fmt.Errorf("group %q: %w", g.Name, err)
+		}
+		if len(g.Replacements) > maxReplacements {
+			return fmt.Errorf("group %q has %d replacements (at most %d)", g.Name, len(g.Replacements), maxReplacements)
+		}
+		for j, spec := range g.Replacements {
+			emoji, err := parseEmoji(spec)
+			if err != nil {
+				return fmt.Errorf("group %q has an invalid replacement: %w", g.Name, err)
+			}
+			if slices.Contains(g.Replacements[:j], emoji) {
+				return fmt.Errorf("group %q lists replacement %s twice", g.Name, emoji)
+			}
+			g.Replacements[j] = emoji
+		}
+		for _, id := range g.Channels {
+			if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+				return fmt.Errorf("group %q has invalid channel %q (expected a channel ID)", g.Name, id)
+			}
+		}
+		if g.Hours != "" {
+			from, until, err := parseHours(g.Hours)
+			if err != nil {
+				return fmt.Errorf("group %q: %w", g.Name, err)
+			}
+			g.from, g.until = from, until
+		}
+		for _, id := range g.Users {
+			if other, ok := seen[id]; ok {
+				return fmt.Errorf("user %s is in both %q and %q", id, other, g.Name)
+			}
+			seen[id] = g.Name
+			if _, ok := c.TargetUserIDSet[id]; !ok {
+				c.TargetUserIDs = append(c.TargetUserIDs, id)
+				c.TargetUserIDSet[id] = struct{}{}
+			}
+		}
+	}
+	return nil
+}
+
+// validateSteps checks that g's steps are known, listed once, with escalate
+// last, and that they do something about the skull.
+func validateSteps(g *TargetGroup) error {
+	if len(g.Steps) == 0 {
+		return nil
+	}
+	if g.Replace != "" {
+		return fmt.Errorf("set either replace or steps, not both")
+	}
+	for i, step := range g.Steps {
+		switch step {
+		case StepRemove, StepAdd, StepLog, StepDM, StepEscalate:
+		default:
+			return fmt.Errorf("unknown step %q (expected remove, add, log, dm, or escalate)", step)
+		}
+		if slices.Contains(g.Steps[:i], step) {
+			return fmt.Errorf("step %q is listed twice", step)
+		}
+		if step == StepEscalate && i != len(g.Steps)-1 {
+			return fmt.Errorf("escalate must be the last step")
+		}
+	}
+	if !slices.Contains(g.Steps, StepRemove) && !slices.Contains(g.Steps, StepAdd) {
+		return fmt.Errorf("steps must remove the skull or add jollyskull")
+	}
+	return nil
+}
+
+// parseHours parses a range of UTC hours such as "9-17" or "22-6".
+func parseHours(spec string) (from, until int, err error) {
+	a, b, ok := strings.Cut(spec, "-")
+	if ok {
+		from, err = strconv.Atoi(strings.TrimSpace(a))
+	}
+	if ok && err == nil {
+		until, err = strconv.Atoi(strings.TrimSpace(b))
+	}
+	if !ok || err != nil || from < 0 || from > 23 || until < 0 || until > 24 || from == until%24 {
+		return 0, 0, fmt.Errorf("invalid hours %q (expected <from>-<until> in UTC, e.g. 9-17)", spec)
+	}
+	return from, until % 24, nil
+}
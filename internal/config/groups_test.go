@@ -0,0 +1,192 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad_TargetGroups(t *testing.T) {
+	tests := []struct {
+		name        string
+		groups      string
+		wantErr     string
+		wantTargets []string
+	}{
+		{
+			name:        "groups add their users to the targets",
+			groups:      `[{"name":"repeat","users":["2","3"]},{"name":"casual","users":["4"],"actions":["reactions"],"emojis":["💀"],"hours":"9-17","replace":"add"}]`,
+			wantTargets: []string{"1", "2", "3", "4"},
+		},
+		{
+			name:        "user in a group and the plain targets",
+			groups:      `[{"name":"repeat","users":["1"]}]`,
+			wantTargets: []string{"1"},
+		},
+		{name: "not JSON", groups: `repeat=1,2`, wantErr: "expected a JSON array"},
+		{name: "unknown field", groups: `[{"name":"a","users":["2"],"severity":3}]`, wantErr: "severity"},
+		{name: "no name", groups: `[{"users":["2"]}]`, wantErr: "group 1 has no name"},
+		{name: "no users", groups: `[{"name":"a"}]`, wantErr: `group "a" has no users`},
+		{name: "unknown action", groups: `[{"name":"a","users":["2"],"actions":["ban"]}]`, wantErr: `unknown action "ban"`},
+		{name: "unknown replace", groups: `[{"name":"a","users":["2"],"replace":"swap"}]`, wantErr: `unknown replace "swap"`},
+		{name: "steps", groups: `[{"name":"a","users":["1"],"steps":["add","log","dm","escalate"]}]`, wantTargets: []string{"1"}},
+		{name: "unknown step", groups: `[{"name":"a","users":["2"],"steps":["remove","ban"]}]`, wantErr: `unknown step "ban"`},
+		{name: "step twice", groups: `[{"name":"a","users":["2"],"steps":["remove","add","remove"]}]`, wantErr: `step "remove" is listed twice`},
+		{name: "escalate before the end", groups: `[{"name":"a","users":["2"],"steps":["remove","escalate","dm"]}]`, wantErr: "escalate must be the last step"},
+		{name: "steps leave the skull alone", groups: `[{"name":"a","users":["2"],"steps":["dm"]}]`, wantErr: "remove the skull or add jollyskull"},
+		{name: "replace and steps", groups: `[{"name":"a","users":["2"],"replace":"add","steps":["add"]}]`, wantErr: "either replace or steps"},
+		{name: "replacements", groups: `[{"name":"a","users":["1"],"replacements":["🎅","🎄","<:jollyskull:789>"]}]`, wantTargets: []string{"1"}},
+		{name: "invalid replacement", groups: `[{"name":"a","users":["2"],"replacements":[":santa:"]}]`, wantErr: "invalid replacement"},
+		{name: "replacement twice", groups: `[{"name":"a","users":["2"],"replacements":["jollyskull:789","<:jollyskull:789>"]}]`, wantErr: "lists replacement jollyskull:789 twice"},
+		{name: "too many replacements", groups: `[{"name":"a","users":["2"],"replacements":["1️⃣","2️⃣","3️⃣","4️⃣","5️⃣","6️⃣"]}]`, wantErr: "at most 5"},
+		{name: "channels", groups: `[{"name":"a","users":["1"],"channels":["100","200"]}]`, wantTargets: []string{"1"}},
+		{name: "invalid channel", groups: `[{"name":"a","users":["2"],"channels":["#jollyposting"]}]`, wantErr: `invalid channel "#jollyposting"`},
+		{name: "invalid hours", groups: `[{"name":"a","users":["2"],"hours":"9"}]`, wantErr: `invalid hours "9"`},
+		{name: "empty hours range", groups: `[{"name":"a","users":["2"],"hours":"0-24"}]`, wantErr: "invalid hours"},
+		{name: "user in two groups", groups: `[{"name":"a","users":["2"]},{"name":"b","users":["2"]}]`, wantErr: `user 2 is in both "a" and "b"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnvVars()
+			defer clearEnvVars()
+			os.Setenv("DISCORD_TOKEN", "test-token")
+			os.Setenv("DISCORD_GUILD_ID", "guild-123")
+			os.Setenv("DISCORD_TARGET_USER_IDS", "1")
+			os.Setenv("DISCORD_JOLLYSKULL_ID", "jollyskull:789")
+			os.Setenv("TARGET_GROUPS", tt.groups)
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "TARGET_GROUPS") {
+					t.Fatalf("Load() error = %v, want it to name TARGET_GROUPS and contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() unexpected error: %v", err)
+			}
+			if !slices.Equal(cfg.TargetUserIDs, tt.wantTargets) {
+				t.Errorf("TargetUserIDs = %v, want %v", cfg.TargetUserIDs, tt.wantTargets)
+			}
+			for _, id := range tt.wantTargets {
+				if _, ok := cfg.TargetUserIDSet[id]; !ok {
+					t.Errorf("TargetUserIDSet is missing %s", id)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_ReplaceModeFor(t *testing.T) {
+	cfg := &Config{
+		ReplaceMode: ReplaceRemove,
+		TargetGroups: []TargetGroup{
+			{Name: "gentle", Users: []string{"1"}, Replace: ReplaceAdd},
+			{Name: "plain", Users: []string{"2"}},
+		},
+	}
+	tests := []struct {
+		userID string
+		want   string
+	}{
+		{"1", ReplaceAdd},
+		{"2", ReplaceRemove},
+		{"3", ReplaceRemove},
+	}
+	for _, tt := range tests {
+		if got := cfg.ReplaceModeFor(tt.userID); got != tt.want {
+			t.Errorf("ReplaceModeFor(%q) = %q, want %q", tt.userID, got, tt.want)
+		}
+	}
+}
+
+func TestConfig_StepsFor(t *testing.T) {
+	cfg := &Config{
+		ReplaceMode: ReplaceBoth,
+		TargetGroups: []TargetGroup{
+			{Name: "pipeline", Users: []string{"1"}, Steps: []string{StepAdd, StepDM}},
+			{Name: "remove", Users: []string{"2"}, Replace: ReplaceRemove},
+		},
+	}
+	tests := []struct {
+		userID string
+		want   []string
+	}{
+		{"1", []string{StepAdd, StepDM}},
+		{"2", []string{StepRemove, StepEscalate}},
+		{"3", []string{StepRemove, StepAdd, StepEscalate}},
+	}
+	for _, tt := range tests {
+		if got := cfg.StepsFor(tt.userID); !slices.Equal(got, tt.want) {
+			t.Errorf("StepsFor(%q) = %v, want %v", tt.userID, got, tt.want)
+		}
+	}
+}
+
+func TestConfig_Replacements(t *testing.T) {
+	cfg := &Config{
+		JollySkullID:       "jollyskull:789",
+		JollySkullFallback: "🎄",
+		TargetGroups:       []TargetGroup{{Name: "festive", Users: []string{"1"}, Replacements: []string{"🎅", "jollyskull:789"}}},
+	}
+	if got := cfg.ReplacementsFor("1"); !slices.Equal(got, []string{"🎅", "jollyskull:789"}) {
+		t.Errorf("ReplacementsFor(1) = %v, want the group's", got)
+	}
+	if got := cfg.ReplacementsFor("2"); got != nil {
+		t.Errorf("ReplacementsFor(2) = %v, want nil for jollyskull", got)
+	}
+	for emoji, want := range map[string]bool{"jollyskull:789": true, "🎄": true, "🎅": true, "👍": false} {
+		if got := cfg.IsReplacement(emoji); got != want {
+			t.Errorf("IsReplacement(%q) = %v, want %v", emoji, got, want)
+		}
+	}
+}
+
+func TestTargetGroup(t *testing.T) {
+	g := TargetGroup{Actions: []string{ActionReactions}, Emojis: []string{"💀", "DeadSkull"}}
+	if !g.Allows(ActionReactions) || g.Allows(ActionDeletion) {
+		t.Error("Allows() should only allow the listed actions")
+	}
+	if !g.MatchesEmoji("💀") || !g.MatchesEmoji("deadskull") || g.MatchesEmoji("☠️") {
+		t.Error("MatchesEmoji() should match listed emojis, ignoring case")
+	}
+	if local := (TargetGroup{Channels: []string{"100"}}); !local.InChannel("100") || local.InChannel("200") {
+		t.Error("InChannel() should only match the listed channels")
+	}
+	if all := (TargetGroup{}); !all.Allows(ActionDeletion) || !all.MatchesEmoji("☠️") || !all.InChannel("200") {
+		t.Error("a group without actions, emojis, or channels should allow everything")
+	}
+
+	hours := []struct {
+		spec   string
+		hour   int
+		active bool
+	}{
+		{"", 3, true},
+		{"9-17", 9, true},
+		{"9-17", 16, true},
+		{"9-17", 17, false},
+		{"9-17", 3, false},
+		{"22-6", 23, true},
+		{"22-6", 5, true},
+		{"22-6", 6, false},
+		{"18-24", 23, true},
+		{"18-24", 0, false},
+	}
+	for _, tt := range hours {
+		g := TargetGroup{Hours: tt.spec}
+		if tt.spec != "" {
+			var err error
+			if g.from, g.until, err = parseHours(tt.spec); err != nil {
+				t.Fatalf("parseHours(%q) unexpected error: %v", tt.spec, err)
+			}
+		}
+		at := time.Date(2025, 1, 6, tt.hour, 30, 0, 0, time.UTC)
+		if got := g.ActiveAt(at); got != tt.active {
+			t.Errorf("hours %q: ActiveAt(%02d:30) = %v, want %v", tt.spec, tt.hour, got, tt.active)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultSkullKeywords are the skull keywords when SKULL_KEYWORDS is unset:
+// custom emojis named like a skull count, jollyskull does not.
+var DefaultSkullKeywords = SkullKeywords{
+	Match:   []string{"skull"},
+	Exclude: []string{"jollyskull"},
+}
+
+// skullKeywordPresets are lists of keywords SKULL_KEYWORDS can include by
+// name after an @, such as "@multilingual,!jollyskull".
+//
+// The multilingual preset adds the word for skull in Spanish, German,
+// French, Italian, Portuguese, Dutch, Polish, Turkish, Russian and Ukrainian,
+// Chinese, Japanese, and Korean, in that order. Accented words are listed
+// without their accents too, as emoji names often drop them, except the
+// French "crâne", since "crane" is a bird. Keywords match anywhere in a name,
+// so words that merely start with one are excluded, such as the Russian for
+// turtle, "черепаха". It is opt-in, as any such list matches some names that
+// are not skulls.
+var skullKeywordPresets = map[string]SkullKeywords{
+	"multilingual": {
+		Match: []string{
+			"skull",
+			"calavera", "cráneo", "craneo",
+			"totenkopf", "schädel", "schaedel",
+			"crâne",
+			"teschio",
+			"caveira", "crânio", "cranio",
+			"doodskop", "schedel",
+			"czaszka",
+			"kafatası",
+			"череп",
+			"骷髅",
+			"ドクロ", "どくろ", "頭蓋骨",
+			"해골",
+		},
+		Exclude: []string{"черепах", "черепаш"},
+	},
+}
+
+// SkullKeywords decide by name which custom emojis are skulls.
+type SkullKeywords struct {
+	Match          []string       // A name containing one of these, ignoring case, is a skull
+	Exclude        []string       // Unless it contains one of these
+	Pattern        *regexp.Regexp // A name it matches is a skull too; EMOJI_MATCH_PATTERN
+	ExcludePattern *regexp.Regexp // Unless it matches this; EMOJI_EXCLUDE_PATTERN
+}
+
+// Matches reports whether a custom emoji named name is a skull. Case is
+// ignored in every script, so "ЧЕРЕП" matches "череп".
+func (k SkullKeywords) Matches(name string) bool {
+	folded := foldCase(name)
+	contains := func(keyword string) bool { return strings.Contains(folded, foldCase(keyword)) }
+	match := slices.ContainsFunc(k.Match, contains) || k.Pattern != nil && k.Pattern.MatchString(name)
+	excluded := slices.ContainsFunc(k.Exclude, contains) || k.ExcludePattern != nil && k.ExcludePattern.MatchString(name)
+	return match && !excluded
+}
+
+// IsZero reports whether k has neither keywords nor a pattern to match, so
+// it matches no name at all.
+func (k SkullKeywords) IsZero() bool {
+	return len(k.Match) == 0 && k.Pattern == nil
+}
+
+// Patterns returns the match and exclude patterns of k as configured, empty
+// if unset.
+func (k SkullKeywords) Patterns() (match, exclude string) {
+	source := func(re *regexp.Regexp) string {
+		if re == nil {
+			return ""
+		}
+		return strings.TrimPrefix(re.String(), caseless)
+	}
+	return source(k.Pattern), source(k.ExcludePattern)
+}
+
+// caseless makes a pattern ignore case, as keywords do.
+const caseless = "(?i)"
+
+// compilePattern compiles an emoji name pattern such as "^(dead)?skull$",
+// ignoring case. A pattern matching every name, including the empty one, is
+// rejected, as it would make every custom emoji a skull or none.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(caseless + pattern)
+	if err != nil {
+		return nil, err
+	}
+	if re.MatchString("") {
+		return nil, fmt.Errorf("pattern %q matches every name", pattern)
+	}
+	return re, nil
+}
+
+// foldCase maps s to a single case for comparison. Going through upper case
+// first also folds letters with several lower case forms, such as Greek
+// final sigma and the long s.
+func foldCase(s string) string {
+	return strings.ToLower(strings.ToUpper(s))
+}
+
+// String returns k in the format of SKULL_KEYWORDS.
+func (k SkullKeywords) String() string {
+	entries := slices.Clone(k.Match)
+	for _, keyword := range k.Exclude {
+		entries = append(entries, "!"+keyword)
+	}
+	return strings.Join(entries, ",")
+}
+
+// parseSkullKeywords parses a list such as "skull,calavera,!jollyskull", in
+// which keywords starting with ! exclude names instead, and ones starting
+// with @ name a preset whose keywords are added.
+func parseSkullKeywords(spec string) (SkullKeywords, error) {
+	var k SkullKeywords
+	for entry := range strings.SplitSeq(spec, ",") {
+		entry = foldCase(strings.TrimSpace(entry))
+		keyword, exclude := strings.CutPrefix(entry, "!")
+		switch {
+		case entry == "":
+			continue
+		case strings.HasPrefix(entry, "@"):
+			preset, ok := skullKeywordPresets[entry[1:]]
+			if !ok {
+				return SkullKeywords{}, fmt.Errorf("unknown preset %q (expected one of %s)", entry, strings.Join(slices.Sorted(maps.Keys(skullKeywordPresets)), ", "))
+			}
+			k.Match = append(k.Match, preset.Match...)
+			k.Exclude = append(k.Exclude, preset.Exclude...)
+		case keyword == "":
+			return SkullKeywords{}, fmt.Errorf("expected a keyword after !")
+		case exclude:
+			k.Exclude = append(k.Exclude, keyword)
+		default:
+			k.Match = append(k.Match, keyword)
+		}
+	}
+	if len(k.Match) == 0 {
+		return SkullKeywords{}, fmt.Errorf("expected at least one keyword that is not excluded, got %q", spec)
+	}
+	return k, nil
+}
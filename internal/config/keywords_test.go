@@ -0,0 +1,208 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoad_SkullKeywords(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		wantErr  string
+		want     SkullKeywords
+		wantText string
+	}{
+		{
+			name: "default",
+			want: DefaultSkullKeywords,
+		},
+		{
+			name:     "keywords and exclusions",
+			spec:     "Skull, calavera,SKELLY,!jollyskull, ",
+			want:     SkullKeywords{Match: []string{"skull", "calavera", "skelly"}, Exclude: []string{"jollyskull"}},
+			wantText: "skull,calavera,skelly,!jollyskull",
+		},
+		{name: "only exclusions", spec: "!jollyskull", wantErr: "at least one keyword"},
+		{name: "bare exclusion", spec: "skull,!", wantErr: "expected a keyword after !"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnvVars()
+			defer clearEnvVars()
+			os.Setenv("DISCORD_TOKEN", "test-token")
+			os.Setenv("DISCORD_GUILD_ID", "guild-123")
+			os.Setenv("DISCORD_TARGET_USER_IDS", "1")
+			os.Setenv("DISCORD_JOLLYSKULL_ID", "jollyskull:789")
+			os.Setenv("SKULL_KEYWORDS", tt.spec)
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "SKULL_KEYWORDS") {
+					t.Fatalf("Load() error = %v, want it to name SKULL_KEYWORDS and contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() unexpected error: %v", err)
+			}
+			if !slices.Equal(cfg.SkullKeywords.Match, tt.want.Match) || !slices.Equal(cfg.SkullKeywords.Exclude, tt.want.Exclude) {
+				t.Errorf("SkullKeywords = %+v, want %+v", cfg.SkullKeywords, tt.want)
+			}
+			if got := cfg.SkullKeywords.String(); tt.wantText != "" && got != tt.wantText {
+				t.Errorf("String() = %q, want %q", got, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestSkullKeywords_Matches(t *testing.T) {
+	k := SkullKeywords{Match: []string{"skull", "calavera"}, Exclude: []string{"jollyskull"}}
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"deadskull", true},
+		{"SKULL", true},
+		{"Calavera_Roja", true},
+		{"jollyskull", false},
+		{"JollySkull2", false},
+		{"party", false},
+	}
+	for _, tt := range tests {
+		if got := k.Matches(tt.name); got != tt.want {
+			t.Errorf("Matches(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultSkullKeywords(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"deadskull", true},
+		{"SKULL", true},
+		{"calavera_roja", false},
+		{"череп", false},
+		{"jollyskull", false},
+		{"party", false},
+	}
+	for _, tt := range tests {
+		if got := DefaultSkullKeywords.Matches(tt.name); got != tt.want {
+			t.Errorf("Matches(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSkullKeywordPresets(t *testing.T) {
+	k, err := parseSkullKeywords("@multilingual,!jollyskull")
+	if err != nil {
+		t.Fatalf("parseSkullKeywords() unexpected error: %v", err)
+	}
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"deadskull", true},
+		{"calavera_roja", true},
+		{"SCHÄDEL", true},
+		{"schaedel", true},
+		{"Crâne", true},
+		{"crane", false},
+		{"KAFATASI", true},
+		{"kafatası", true},
+		{"ЧЕРЕП", true},
+		{"Череп2", true},
+		{"черепаха", false},
+		{"Черепашка", false},
+		{"骷髅", true},
+		{"jollyskull", false},
+		{"party", false},
+	}
+	for _, tt := range tests {
+		if got := k.Matches(tt.name); got != tt.want {
+			t.Errorf("Matches(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := parseSkullKeywords("@klingon"); err == nil || !strings.Contains(err.Error(), "multilingual") {
+		t.Errorf("parseSkullKeywords(@klingon) error = %v, want it to list the presets", err)
+	}
+}
+
+func TestLoad_EmojiPatterns(t *testing.T) {
+	tests := []struct {
+		name        string
+		keywords    string
+		match       string
+		exclude     string
+		wantErr     string
+		wantSkull   []string
+		wantNoSkull []string
+	}{
+		{
+			name:        "exclude pattern",
+			exclude:     "^skull(candy|kid)",
+			wantSkull:   []string{"skull", "deadskull"},
+			wantNoSkull: []string{"skullcandy", "SkullKid2", "jollyskull"},
+		},
+		{
+			name:        "match pattern replaces the default keywords",
+			match:       "^(dead|rip)_?skull$",
+			wantSkull:   []string{"deadskull", "RIP_Skull"},
+			wantNoSkull: []string{"skull", "calavera", "deadskull2"},
+		},
+		{
+			name:        "match pattern adds to keywords",
+			keywords:    "calavera",
+			match:       "^skelly$",
+			wantSkull:   []string{"calavera", "Skelly"},
+			wantNoSkull: []string{"skull", "skellyton"},
+		},
+		{name: "invalid match pattern", match: "skull(", wantErr: "EMOJI_MATCH_PATTERN"},
+		{name: "match pattern matching every name", match: "skull|", wantErr: "EMOJI_MATCH_PATTERN"},
+		{name: "invalid exclude pattern", exclude: "[", wantErr: "EMOJI_EXCLUDE_PATTERN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnvVars()
+			defer clearEnvVars()
+			os.Setenv("DISCORD_TOKEN", "test-token")
+			os.Setenv("DISCORD_GUILD_ID", "guild-123")
+			os.Setenv("DISCORD_TARGET_USER_IDS", "1")
+			os.Setenv("DISCORD_JOLLYSKULL_ID", "jollyskull:789")
+			os.Setenv("SKULL_KEYWORDS", tt.keywords)
+			os.Setenv("EMOJI_MATCH_PATTERN", tt.match)
+			os.Setenv("EMOJI_EXCLUDE_PATTERN", tt.exclude)
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want it to name %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() unexpected error: %v", err)
+			}
+			for _, name := range tt.wantSkull {
+				if !cfg.SkullKeywords.Matches(name) {
+					t.Errorf("Matches(%q) = false, want true", name)
+				}
+			}
+			for _, name := range tt.wantNoSkull {
+				if cfg.SkullKeywords.Matches(name) {
+					t.Errorf("Matches(%q) = true, want false", name)
+				}
+			}
+			if match, exclude := cfg.SkullKeywords.Patterns(); match != tt.match || exclude != tt.exclude {
+				t.Errorf("Patterns() = %q, %q, want %q, %q", match, exclude, tt.match, tt.exclude)
+			}
+		})
+	}
+}
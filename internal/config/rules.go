@@ -0,0 +1,119 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SkullRule is the name of the built-in rule, which replaces the skulls
+// SKULL_KEYWORDS decides on with jollyskull.
+const SkullRule = "skull"
+
+// EmojiRule replaces the emojis it matches with Replacement, as the skull
+// rule built from SKULL_KEYWORDS replaces skulls with jollyskull, so the bot
+// can map, say, 😭 to a jollysob too. A shadow rule never acts, so a new
+// rule can be tried against live traffic before it is switched on.
+//
+// Only the first rule that matches an emoji, by priority, replaces it. Rules
+// of the same priority are tried in the order they are listed, after the
+// skull rule.
+type EmojiRule struct {
+	Name        string        `json:"name"`
+	Unicode     []string      `json:"unicode,omitempty"`  // Unicode emojis it matches
+	Keywords    SkullKeywords `json:"keywords,omitzero"`  // Custom emojis it matches by name, as in SKULL_KEYWORDS
+	Replacement string        `json:"replacement"`        // As in DISCORD_JOLLYSKULL_ID
+	Priority    int           `json:"priority,omitempty"` // Rules are tried highest first; the skull rule has 0
+	Shadow      bool          `json:"shadow,omitempty"`   // Only log, count, and audit what it would replace
+	Limit       string        `json:"limit,omitempty"`    // Most replacements, e.g. "5/1m", on top of REACTION_RATE_LIMIT; empty means unlimited
+}
+
+// Rate returns the most replacements the rule makes per period, as Limit
+// sets, and whether it has a limit.
+func (r EmojiRule) Rate() (n int, period time.Duration, ok bool) {
+	if r.Limit == "" {
+		return 0, 0, false
+	}
+	n, period, err := parseRate(r.Limit)
+	return n, period, err == nil
+}
+
+// Matches reports whether the rule replaces the emoji named name: a Unicode
+// emoji it lists, or a custom emoji named like one of its keywords.
+func (r EmojiRule) Matches(name string) bool {
+	return slices.Contains(r.Unicode, name) || !r.Keywords.IsZero() && r.Keywords.Matches(name)
+}
+
+// MarshalText returns the keywords in the format of SKULL_KEYWORDS. Patterns
+// are left out, as rules have none.
+func (k SkullKeywords) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText parses keywords in the format of SKULL_KEYWORDS.
+func (k *SkullKeywords) UnmarshalText(text []byte) error {
+	keywords, err := parseSkullKeywords(string(text))
+	if err != nil {
+		return err
+	}
+	*k = keywords
+	return nil
+}
+
+// ParseEmojiRules decodes and validates a JSON array of rules as EMOJI_RULES
+// takes them, for a bot whose skulls are replaced with jollySkullID.
+func ParseEmojiRules(spec, jollySkullID string) ([]EmojiRule, error) {
+	c := &Config{JollySkullID: jollySkullID}
+	if err := c.parseEmojiRules(spec); err != nil {
+		return nil, err
+	}
+	return c.EmojiRules, nil
+}
+
+// parseEmojiRules decodes a JSON array of rules into c.EmojiRules.
+func (c *Config) parseEmojiRules(spec string) error {
+	dec := json.NewDecoder(strings.NewReader(spec))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c.EmojiRules); err != nil {
+		return fmt.Errorf("expected a JSON array of rules: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i := range c.EmojiRules {
+		r := &c.EmojiRules[i]
+		switch {
+		case r.Name == "":
+			return fmt.Errorf("rule %d has no name", i+1)
+		case r.Name == SkullRule:
+			return fmt.Errorf("rule %q is built in; set SKULL_KEYWORDS and DISCORD_JOLLYSKULL_ID instead", r.Name)
+		case seen[r.Name]:
+			return fmt.Errorf("rule %q is listed twice", r.Name)
+		case len(r.Unicode) == 0 && r.Keywords.IsZero():
+			return fmt.Errorf("rule %q matches nothing (expected unicode or keywords)", r.Name)
+		case slices.Contains(r.Unicode, ""):
+			return fmt.Errorf("rule %q has an empty unicode emoji", r.Name)
+		}
+		if r.Limit != "" {
+			if _, _, err := parseRate(r.Limit); err != nil {
+				return fmt.Errorf("rule %q has an invalid limit: %w", r.Name, err)
+			}
+		}
+		seen[r.Name] = true
+
+		emoji, err := parseEmoji(r.Replacement)
+		if err != nil {
+			return fmt.Errorf("rule %q has an invalid replacement: %w", r.Name, err)
+		}
+		if emoji == c.JollySkullID {
+			return fmt.Errorf("rule %q replaces with jollyskull, which the skull rule does", r.Name)
+		}
+		name, _, _ := strings.Cut(emoji, ":")
+		if r.Matches(name) {
+			return fmt.Errorf("rule %q would replace its own replacement %s (exclude it with !%s)", r.Name, emoji, name)
+		}
+		r.Replacement = emoji
+	}
+	return nil
+}
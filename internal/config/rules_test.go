@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad_EmojiRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr string
+	}{
+		{name: "unset"},
+		{name: "sob", spec: `[{"name":"sob","unicode":["😭"],"keywords":"sob,!jollysob","replacement":"<:jollysob:456>","limit":"5/1m"}]`},
+		{name: "not JSON", spec: `sob=jollysob`, wantErr: "JSON array"},
+		{name: "no name", spec: `[{"unicode":["😭"],"replacement":"🎉"}]`, wantErr: "rule 1 has no name"},
+		{name: "skull", spec: `[{"name":"skull","unicode":["💀"],"replacement":"🎉"}]`, wantErr: "built in"},
+		{name: "twice", spec: `[{"name":"sob","unicode":["😭"],"replacement":"🎉"},{"name":"sob","unicode":["😢"],"replacement":"🎉"}]`, wantErr: "listed twice"},
+		{name: "matches nothing", spec: `[{"name":"sob","replacement":"🎉"}]`, wantErr: "matches nothing"},
+		{name: "invalid keywords", spec: `[{"name":"sob","keywords":"!sob","replacement":"🎉"}]`, wantErr: "at least one keyword"},
+		{name: "invalid replacement", spec: `[{"name":"sob","unicode":["😭"],"replacement":"jollysob"}]`, wantErr: "invalid replacement"},
+		{name: "jollyskull", spec: `[{"name":"sob","unicode":["😭"],"replacement":"jollyskull:789"}]`, wantErr: "skull rule"},
+		{name: "invalid limit", spec: `[{"name":"sob","unicode":["😭"],"replacement":"🎉","limit":"5"}]`, wantErr: "invalid limit"},
+		{name: "own replacement", spec: `[{"name":"sob","keywords":"sob","replacement":"jollysob:456"}]`, wantErr: "!jollysob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnvVars()
+			defer clearEnvVars()
+			os.Setenv("DISCORD_TOKEN", "test-token")
+			os.Setenv("DISCORD_GUILD_ID", "guild-123")
+			os.Setenv("DISCORD_TARGET_USER_IDS", "1")
+			os.Setenv("DISCORD_JOLLYSKULL_ID", "jollyskull:789")
+			os.Setenv("EMOJI_RULES", tt.spec)
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "EMOJI_RULES") {
+					t.Fatalf("Load() error = %v, want it to name EMOJI_RULES and contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() unexpected error: %v", err)
+			}
+			if tt.spec == "" {
+				if len(cfg.EmojiRules) != 0 {
+					t.Errorf("EmojiRules = %+v, want none", cfg.EmojiRules)
+				}
+				return
+			}
+			r := cfg.EmojiRules[0]
+			if r.Replacement != "jollysob:456" {
+				t.Errorf("Replacement = %q, want it normalized to jollysob:456", r.Replacement)
+			}
+			if n, period, ok := r.Rate(); !ok || n != 5 || period != time.Minute {
+				t.Errorf("Rate() = %d, %v, %v, want 5 per minute", n, period, ok)
+			}
+			if !cfg.IsReplacement("jollysob:456") {
+				t.Error("IsReplacement() should recognize a rule's replacement")
+			}
+			for name, want := range map[string]bool{"😭": true, "SobbingCat": true, "jollysob": false, "💀": false} {
+				if got := r.Matches(name); got != want {
+					t.Errorf("Matches(%q) = %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,388 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// parseTOML parses the subset of TOML config files need into maps, slices,
+// strings, json.Numbers, and bools: tables, arrays of tables, dotted keys,
+// basic and literal strings, decimal integers and floats, booleans, arrays,
+// which may span lines, and inline tables, which later lines cannot extend.
+// Multi-line strings and dates are not supported.
+func parseTOML(data []byte) (any, error) {
+	root := map[string]any{}
+	table := root
+	defined := map[string]bool{} // Headers of tables seen so far
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		n := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			array := strings.HasPrefix(line, "[[")
+			open, end := "[", "]"
+			if array {
+				open, end = "[[", "]]"
+			}
+			header, tail, ok := strings.Cut(line[len(open):], end)
+			if tail = strings.TrimSpace(tail); !ok || (tail != "" && tail[0] != '#') {
+				return nil, fmt.Errorf("line %d: invalid table header %q", n, line)
+			}
+			path, err := tomlKey(header)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			if table, err = tomlTable(root, path, array); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			if !array {
+				name := strings.Join(path, ".")
+				if defined[name] {
+					return nil, fmt.Errorf("line %d: table %s defined twice", n, name)
+				}
+				defined[name] = true
+			}
+			continue
+		}
+
+		rawKey, rest, ok := tomlCutUnquoted(line, '=')
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key = value\", got %q", n, line)
+		}
+		path, err := tomlKey(rawKey)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		// An array may continue on the following lines until it is closed
+		rest = strings.TrimSpace(rest)
+		if strings.HasPrefix(rest, "[") && !tomlBalanced(rest) {
+			rest = stripTOMLComment(rest)
+			for !tomlBalanced(rest) && i+1 < len(lines) {
+				i++
+				rest += "\n" + stripTOMLComment(lines[i])
+			}
+		}
+		v, tail, err := tomlValue(rest)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if tail = strings.TrimSpace(tail); tail != "" && tail[0] != '#' {
+			return nil, fmt.Errorf("line %d: unexpected %q after the value", n, tail)
+		}
+		if err := tomlSet(table, path, v); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	return resolveTOML(root), nil
+}
+
+// tomlInline is an inline table, which is complete as written: neither table
+// headers nor dotted keys may add to it.
+type tomlInline map[string]any
+
+// resolveTOML turns the inline tables in v into plain tables.
+func resolveTOML(v any) any {
+	switch v := v.(type) {
+	case tomlInline:
+		return resolveTOML(map[string]any(v))
+	case []any:
+		for i, e := range v {
+			v[i] = resolveTOML(e)
+		}
+	case map[string]any:
+		for k, e := range v {
+			v[k] = resolveTOML(e)
+		}
+	}
+	return v
+}
+
+// tomlKey splits a bare, quoted, or dotted key into its parts.
+func tomlKey(s string) ([]string, error) {
+	var path []string
+	s = strings.TrimSpace(s)
+	for {
+		var part string
+		switch {
+		case s == "":
+			return nil, fmt.Errorf("empty key")
+		case s[0] == '"' || s[0] == '\'':
+			v, rest, err := tomlString(s)
+			if err != nil {
+				return nil, err
+			}
+			part, s = v, strings.TrimSpace(rest)
+		default:
+			end := strings.IndexFunc(s, func(r rune) bool { return !isBareKeyRune(r) })
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid key %q", s)
+			}
+			part, s = s[:end], strings.TrimSpace(s[end:])
+		}
+		path = append(path, part)
+		if s == "" {
+			return path, nil
+		}
+		if s[0] != '.' {
+			return nil, fmt.Errorf("invalid key: unexpected %q", s)
+		}
+		s = strings.TrimSpace(s[1:])
+	}
+}
+
+func isBareKeyRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-'
+}
+
+// tomlTable returns the table at path under root, creating it if need be. If
+// array is set, it appends a new table to the array of tables at path
+// instead.
+func tomlTable(root map[string]any, path []string, array bool) (map[string]any, error) {
+	t := root
+	for i, key := range path {
+		last := i == len(path)-1
+		switch v := t[key].(type) {
+		case nil:
+			child := map[string]any{}
+			if last && array {
+				t[key] = []any{child}
+			} else {
+				t[key] = child
+			}
+			t = child
+		case map[string]any:
+			if last && array {
+				return nil, fmt.Errorf("%s is a table, not an array of tables", strings.Join(path, "."))
+			}
+			t = v
+		case tomlInline:
+			return nil, fmt.Errorf("%s is an inline table, which cannot be extended", strings.Join(path[:i+1], "."))
+		case []any:
+			if len(v) == 0 {
+				return nil, fmt.Errorf("%s is an array, not a table", strings.Join(path[:i+1], "."))
+			}
+			tables, ok := v[len(v)-1].(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s is an array, not a table", strings.Join(path[:i+1], "."))
+			}
+			if last {
+				if !array {
+					return nil, fmt.Errorf("%s is an array of tables", strings.Join(path, "."))
+				}
+				tables = map[string]any{}
+				t[key] = append(v, tables)
+			}
+			t = tables
+		default:
+			return nil, fmt.Errorf("%s is already a value", strings.Join(path[:i+1], "."))
+		}
+	}
+	return t, nil
+}
+
+// tomlSet sets the dotted key path in t to v.
+func tomlSet(t map[string]any, path []string, v any) error {
+	for _, key := range path[:len(path)-1] {
+		switch child := t[key].(type) {
+		case nil:
+			m := map[string]any{}
+			t[key] = m
+			t = m
+		case map[string]any:
+			t = child
+		case tomlInline:
+			return fmt.Errorf("%s is an inline table, which cannot be extended", key)
+		default:
+			return fmt.Errorf("%s is already a value", key)
+		}
+	}
+	key := path[len(path)-1]
+	if _, ok := t[key]; ok {
+		return fmt.Errorf("duplicate key %q", strings.Join(path, "."))
+	}
+	t[key] = v
+	return nil
+}
+
+var tomlNumber = regexp.MustCompile(`^[-+]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][-+]?[0-9](_?[0-9])*)?$`)
+
+// tomlValue parses the value s starts with, returning it and the rest of s.
+func tomlValue(s string) (any, string, error) {
+	s = strings.TrimLeft(s, " \t\n")
+	if s == "" {
+		return nil, "", fmt.Errorf("missing value")
+	}
+	switch s[0] {
+	case '"', '\'':
+		if strings.HasPrefix(s, `"""`) || strings.HasPrefix(s, "'''") {
+			return nil, "", fmt.Errorf("multi-line strings are not supported")
+		}
+		return tomlString(s)
+	case '[':
+		return tomlArray(s)
+	case '{':
+		return tomlInlineTable(s)
+	}
+	end := strings.IndexAny(s, ",]} \t\n#")
+	if end < 0 {
+		end = len(s)
+	}
+	word, rest := s[:end], s[end:]
+	switch {
+	case word == "true":
+		return true, rest, nil
+	case word == "false":
+		return false, rest, nil
+	case tomlNumber.MatchString(word):
+		return json.Number(strings.TrimPrefix(strings.ReplaceAll(word, "_", ""), "+")), rest, nil
+	}
+	return nil, "", fmt.Errorf("unsupported value %q; quote strings", word)
+}
+
+// tomlString parses the basic ("...") or literal ('...') string s starts
+// with, returning it and the rest of s.
+func tomlString(s string) (string, string, error) {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\n':
+			return "", "", fmt.Errorf("unterminated string %s", s[:i])
+		case quote == '"' && s[i] == '\\':
+			i++
+		case s[i] == quote:
+			if quote == '\'' {
+				return s[1:i], s[i+1:], nil
+			}
+			v, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", fmt.Errorf("invalid string %s: %w", s[:i+1], err)
+			}
+			return v, s[i+1:], nil
+		}
+	}
+	return "", "", fmt.Errorf("unterminated string %s", s)
+}
+
+func tomlArray(s string) (any, string, error) {
+	array := []any{}
+	s = s[1:]
+	for {
+		s = strings.TrimLeft(s, " \t\n")
+		if s == "" {
+			return nil, "", fmt.Errorf("unterminated array")
+		}
+		if s[0] == ']' {
+			return array, s[1:], nil
+		}
+		v, rest, err := tomlValue(s)
+		if err != nil {
+			return nil, "", err
+		}
+		array = append(array, v)
+		s = strings.TrimLeft(rest, " \t\n")
+		if strings.HasPrefix(s, ",") {
+			s = s[1:]
+		} else if !strings.HasPrefix(s, "]") {
+			return nil, "", fmt.Errorf("expected a comma or ] in array")
+		}
+	}
+}
+
+func tomlInlineTable(s string) (any, string, error) {
+	table := tomlInline{}
+	s = strings.TrimLeft(s[1:], " \t")
+	if strings.HasPrefix(s, "}") {
+		return table, s[1:], nil
+	}
+	for {
+		rawKey, rest, ok := tomlCutUnquoted(s, '=')
+		if !ok {
+			return nil, "", fmt.Errorf("expected \"key = value\" in inline table")
+		}
+		path, err := tomlKey(rawKey)
+		if err != nil {
+			return nil, "", err
+		}
+		v, rest, err := tomlValue(rest)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := tomlSet(table, path, v); err != nil {
+			return nil, "", err
+		}
+		s = strings.TrimLeft(rest, " \t")
+		switch {
+		case strings.HasPrefix(s, ","):
+			s = s[1:]
+		case strings.HasPrefix(s, "}"):
+			return table, s[1:], nil
+		default:
+			return nil, "", fmt.Errorf("expected a comma or } in inline table")
+		}
+	}
+}
+
+// tomlCutUnquoted cuts s around the first sep outside quotes.
+func tomlCutUnquoted(s string, sep byte) (before, after string, found bool) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == sep:
+			return s[:i], s[i+1:], true
+		}
+	}
+	return s, "", false
+}
+
+// tomlBalanced reports whether the brackets of s, outside strings and
+// comments, are closed.
+func tomlBalanced(s string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote || c == '\n' {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			// The comment runs to the end of the line
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// stripTOMLComment removes the comment from a line of an array.
+func stripTOMLComment(line string) string {
+	before, _, _ := tomlCutUnquoted(line, '#')
+	return before
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string // As JSON
+		wantErr bool
+	}{
+		{
+			name: "tables and scalars",
+			input: `version = 2 # current
+
+[settings]
+DISCORD_GUILD_ID = "123"
+DRY_RUN = true
+HISTORICAL_WORKERS = 1_000
+JITTER = -0.5
+
+[rules]
+escalation = "5:dm,20:notify"
+exclude_pattern = '^skull(candy|kid)\b'
+`,
+			want: `{"rules":{"escalation":"5:dm,20:notify","exclude_pattern":"^skull(candy|kid)\\b"},"settings":{"DISCORD_GUILD_ID":"123","DRY_RUN":true,"HISTORICAL_WORKERS":1000,"JITTER":-0.5},"version":2}`,
+		},
+		{
+			name: "arrays of tables",
+			input: `[[rules.target_groups]]
+name = "lounge"
+users = [
+  "1", # first
+  "2",
+]
+channels = ["100"]
+
+[[rules.target_groups]]
+name = "memes"
+replace = { mode = "add", emoji = "🎄" }
+`,
+			want: `{"rules":{"target_groups":[{"channels":["100"],"name":"lounge","users":["1","2"]},{"name":"memes","replace":{"emoji":"🎄","mode":"add"}}]}}`,
+		},
+		{
+			name:  "dotted and quoted keys",
+			input: `a.b = 1` + "\n" + `"c.d" = "x"` + "\n" + `a.e = []`,
+			want:  `{"a":{"b":1,"e":[]},"c.d":"x"}`,
+		},
+		{name: "duplicate key", input: "a = 1\na = 2", wantErr: true},
+		{name: "table defined twice", input: "[a]\n[a]", wantErr: true},
+		{name: "bare string", input: "a = skull", wantErr: true},
+		{name: "multi-line string", input: `a = """x"""`, wantErr: true},
+		{name: "unterminated array", input: "a = [1,\n2", wantErr: true},
+		{name: "missing value", input: "a =", wantErr: true},
+		{name: "table over a value", input: "a = 1\n[a]", wantErr: true},
+		{name: "trailing garbage", input: `a = "x" y`, wantErr: true},
+		{name: "inline table extended by a header", input: "a = {b = 1}\n[a]\nc = 2", wantErr: true},
+		{name: "inline table extended by a dotted key", input: "a = {b = 1}\na.c = 2", wantErr: true},
+		{name: "array of inline tables extended", input: "a = [{b = 1}]\n[[a]]", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := parseTOML([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTOML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got, err := json.Marshal(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("parseTOML() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Webhook payload formats.
+const (
+	WebhookFull   = "full"   // Nested JSON describing the guild, channel, message, and user
+	WebhookSimple = "simple" // Flat JSON with string values, for IFTTT, Zapier, and the like
+)
+
+// WebhookEvents are the event types a webhook can subscribe to, matching
+// those of package events.
+var WebhookEvents = []string{"reaction.replaced", "message.deleted"}
+
+// defaultWebhookBackoff is the wait before the first retry of a webhook.
+const defaultWebhookBackoff = time.Second
+
+// maxWebhookRetries bounds retries, so that a dead endpoint gives up within minutes.
+const maxWebhookRetries = 10
+
+// Webhook is an endpoint that receives the bot's actions as HTTP POSTs.
+type Webhook struct {
+	URL     string            `json:"url"`
+	Format  string            `json:"format,omitempty"`  // WebhookFull or WebhookSimple; empty means full
+	Events  []string          `json:"events,omitempty"`  // Event types sent; empty means all
+	Secret  string            `json:"secret,omitempty"`  // Key to sign payloads with HMAC-SHA256; empty means unsigned
+	Headers map[string]string `json:"headers,omitempty"` // Sent with every request
+	Retries int               `json:"retries,omitempty"` // Further attempts after a failed delivery
+	Backoff string            `json:"backoff,omitempty"` // Wait before the first retry, doubling after each, e.g. "2s"; default 1s
+
+	backoff time.Duration // Parsed Backoff
+}
+
+// Wants reports whether the webhook receives events of type typ.
+func (h *Webhook) Wants(typ string) bool {
+	return len(h.Events) == 0 || slices.Contains(h.Events, typ)
+}
+
+// RetryDelay returns how long to wait before retry n, counting from zero.
+func (h *Webhook) RetryDelay(n int) time.Duration {
+	return h.backoff << n
+}
+
+// parseWebhooks decodes a JSON array of webhooks.
+func parseWebhooks(spec string) ([]Webhook, error) {
+	var hooks []Webhook
+	dec := json.NewDecoder(strings.NewReader(spec))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&hooks); err != nil {
+		return nil, fmt.Errorf("expected a JSON array of webhooks: %w", err)
+	}
+
+	for i := range hooks {
+		h := &hooks[i]
+		u, err := url.Parse(h.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("webhook %d has invalid URL %q (expected http or https)", i+1, h.URL)
+		}
+		switch h.Format {
+		case "":
+			h.Format = WebhookFull
+		case WebhookFull, WebhookSimple:
+		default:
+			return nil, fmt.Errorf("webhook %d has unknown format %q (expected full or simple)", i+1, h.Format)
+		}
+		for _, e := range h.Events {
+			if !slices.Contains(WebhookEvents, e) {
+				return nil, fmt.Errorf("webhook %d has unknown event %q (expected one of %s)", i+1, e, strings.Join(WebhookEvents, ", "))
+			}
+		}
+		for name, value := range h.Headers {
+			if name == "" || strings.ContainsAny(name, " :\r\n") || strings.ContainsAny(value, "\r\n") {
+				return nil, fmt.Errorf("webhook %d has invalid header %q", i+1, name)
+			}
+		}
+		if h.Retries < 0 || h.Retries > maxWebhookRetries {
+			return nil, fmt.Errorf("webhook %d has invalid retries %d (expected 0 to %d)", i+1, h.Retries, maxWebhookRetries)
+		}
+		h.backoff = defaultWebhookBackoff
+		if h.Backoff != "" {
+			h.backoff, err = time.ParseDuration(h.Backoff)
+			if err != nil || h.backoff <= 0 {
+				return nil, fmt.Errorf("webhook %d has invalid backoff %q (expected a duration such as 2s)", i+1, h.Backoff)
+			}
+		}
+	}
+	return hooks, nil
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad_Webhooks(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      string
+		wantErr   string
+		wantHooks []Webhook
+	}{
+		{
+			name: "format defaults to full",
+			spec: `[{"url":"https://example.com/hook"},{"url":"https://hooks.zapier.com/1","format":"simple"}]`,
+			wantHooks: []Webhook{
+				{URL: "https://example.com/hook", Format: WebhookFull, backoff: time.Second},
+				{URL: "https://hooks.zapier.com/1", Format: WebhookSimple, backoff: time.Second},
+			},
+		},
+		{
+			name: "filtering, signing, headers, and retries",
+			spec: `[{"url":"https://example.com/hook","events":["message.deleted"],"secret":"s3cr3t","headers":{"Authorization":"Bearer x"},"retries":3,"backoff":"500ms"}]`,
+			wantHooks: []Webhook{{
+				URL:     "https://example.com/hook",
+				Format:  WebhookFull,
+				Events:  []string{"message.deleted"},
+				Secret:  "s3cr3t",
+				Headers: map[string]string{"Authorization": "Bearer x"},
+				Retries: 3,
+				Backoff: "500ms",
+				backoff: 500 * time.Millisecond,
+			}},
+		},
+		{name: "not JSON", spec: "https://example.com/hook", wantErr: "expected a JSON array of webhooks"},
+		{name: "unknown field", spec: `[{"url":"https://example.com","color":"red"}]`, wantErr: "unknown field"},
+		{name: "missing URL", spec: `[{"format":"simple"}]`, wantErr: "webhook 1 has invalid URL"},
+		{name: "unsupported scheme", spec: `[{"url":"ftp://example.com"}]`, wantErr: "webhook 1 has invalid URL"},
+		{name: "unknown format", spec: `[{"url":"https://example.com","format":"xml"}]`, wantErr: `unknown format "xml"`},
+		{name: "unknown event", spec: `[{"url":"https://example.com","events":["message.created"]}]`, wantErr: `unknown event "message.created"`},
+		{name: "invalid header", spec: `[{"url":"https://example.com","headers":{"X Key":"1"}}]`, wantErr: `invalid header "X Key"`},
+		{name: "header injection", spec: `[{"url":"https://example.com","headers":{"X-Key":"1\r\nHost: evil"}}]`, wantErr: `invalid header "X-Key"`},
+		{name: "negative retries", spec: `[{"url":"https://example.com","retries":-1}]`, wantErr: "invalid retries -1"},
+		{name: "too many retries", spec: `[{"url":"https://example.com","retries":50}]`, wantErr: "invalid retries 50"},
+		{name: "invalid backoff", spec: `[{"url":"https://example.com","backoff":"soon"}]`, wantErr: `invalid backoff "soon"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnvVars()
+			defer clearEnvVars()
+			os.Setenv("DISCORD_TOKEN", "test-token")
+			os.Setenv("DISCORD_GUILD_ID", "guild-123")
+			os.Setenv("DISCORD_TARGET_USER_IDS", "1")
+			os.Setenv("DISCORD_JOLLYSKULL_ID", "jollyskull:789")
+			os.Setenv("WEBHOOKS", tt.spec)
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "WEBHOOKS") {
+					t.Fatalf("Load() error = %v, want it to name WEBHOOKS and contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.Webhooks, tt.wantHooks) {
+				t.Errorf("Webhooks = %+v, want %+v", cfg.Webhooks, tt.wantHooks)
+			}
+		})
+	}
+}
+
+func TestLoad_WebhooksFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+	os.WriteFile(path, []byte(`[{"url":"https://example.com/hook","format":"simple"}]`), 0o600)
+
+	tests := []struct {
+		name      string
+		env       map[string]string
+		wantErr   string
+		wantCount int
+	}{
+		{name: "from file", env: map[string]string{"WEBHOOKS_FILE": path}, wantCount: 1},
+		{name: "missing file", env: map[string]string{"WEBHOOKS_FILE": path + ".missing"}, wantErr: "failed to read WEBHOOKS_FILE"},
+		{
+			name:    "both set",
+			env:     map[string]string{"WEBHOOKS_FILE": path, "WEBHOOKS": `[{"url":"https://example.com"}]`},
+			wantErr: "WEBHOOKS and WEBHOOKS_FILE cannot both be set",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnvVars()
+			defer clearEnvVars()
+			os.Setenv("DISCORD_TOKEN", "test-token")
+			os.Setenv("DISCORD_GUILD_ID", "guild-123")
+			os.Setenv("DISCORD_TARGET_USER_IDS", "1")
+			os.Setenv("DISCORD_JOLLYSKULL_ID", "jollyskull:789")
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+			}
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() unexpected error: %v", err)
+			}
+			if len(cfg.Webhooks) != tt.wantCount || cfg.Webhooks[0].Format != WebhookSimple {
+				t.Errorf("Webhooks = %+v, want the file's webhook", cfg.Webhooks)
+			}
+		})
+	}
+}
+
+func TestWebhook_RetryDelay(t *testing.T) {
+	h := Webhook{backoff: time.Second}
+	tests := []struct {
+		retry    int
+		expected time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{3, 8 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := h.RetryDelay(tt.retry); got != tt.expected {
+			t.Errorf("RetryDelay(%d) = %v, want %v", tt.retry, got, tt.expected)
+		}
+	}
+}
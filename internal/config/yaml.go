@@ -0,0 +1,451 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// parseYAML parses the subset of YAML config files need into maps, slices,
+// strings, json.Numbers, bools, and nils: block mappings and sequences,
+// single-line flow collections, quoted and plain scalars, literal (|) and
+// folded (>) block scalars, and comments. Anchors, tags, and multiple
+// documents are not supported. Like a full parser it rejects what YAML does
+// not allow, such as a plain scalar containing ": " or a flow collection
+// missing a comma, rather than guess at it.
+func parseYAML(data []byte) (any, error) {
+	var lines []yamlLine
+	for i, text := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		content := strings.TrimLeft(text, " ")
+		if strings.HasPrefix(content, "\t") && strings.TrimSpace(content) != "" {
+			return nil, fmt.Errorf("line %d: tabs cannot indent YAML", i+1)
+		}
+		lines = append(lines, yamlLine{n: i + 1, indent: len(text) - len(content), text: content, raw: text})
+	}
+	p := &yamlParser{lines: lines}
+	p.skip()
+	if p.i < len(p.lines) && p.lines[p.i].text == "---" {
+		p.i++
+		p.skip()
+	}
+	if p.i == len(p.lines) {
+		return map[string]any{}, nil
+	}
+	v, err := p.block(p.lines[p.i].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.skip(); p.i < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.i].n)
+	}
+	return resolveYAML(v), nil
+}
+
+type yamlLine struct {
+	n      int    // Line number
+	indent int    // Column of text
+	text   string // Without indentation; comments are stripped as the line is parsed
+	raw    string // As in the file, for block scalars
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	i     int // Next line
+}
+
+// skip moves past blank and comment-only lines.
+func (p *yamlParser) skip() {
+	for p.i < len(p.lines) {
+		if t := p.lines[p.i].text; t != "" && !strings.HasPrefix(t, "#") {
+			return
+		}
+		p.i++
+	}
+}
+
+// next returns the next line with content if it is indented by indent, and
+// whether it is.
+func (p *yamlParser) next(indent int) (*yamlLine, bool) {
+	p.skip()
+	if p.i == len(p.lines) || p.lines[p.i].indent != indent {
+		return nil, false
+	}
+	return &p.lines[p.i], true
+}
+
+// block parses the mapping or sequence whose entries are indented by indent.
+func (p *yamlParser) block(indent int) (any, error) {
+	line, _ := p.next(indent)
+	if isSeqEntry(line.text) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func isSeqEntry(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func (p *yamlParser) sequence(indent int) ([]any, error) {
+	seq := []any{}
+	for {
+		line, ok := p.next(indent)
+		if !ok || !isSeqEntry(line.text) {
+			return seq, nil
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		var v any
+		var err error
+		switch {
+		case rest == "" || strings.HasPrefix(rest, "#"):
+			p.i++
+			v, err = p.nested(indent)
+		case isSeqEntry(rest) || isMappingEntry(rest):
+			// The entry is a collection starting on this line: parse it as
+			// if it started on a line of its own
+			line.indent += len(line.text) - len(rest)
+			line.text = rest
+			v, err = p.block(line.indent)
+		default:
+			p.i++
+			v, err = p.scalar(line, rest, indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, v)
+	}
+}
+
+func (p *yamlParser) mapping(indent int) (map[string]any, error) {
+	m := map[string]any{}
+	for {
+		line, ok := p.next(indent)
+		if !ok {
+			return m, nil
+		}
+		if isSeqEntry(line.text) {
+			return nil, fmt.Errorf("line %d: expected a key, not a sequence entry", line.n)
+		}
+		key, rest, err := splitMappingEntry(line.text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.n, err)
+		}
+		if key == "" {
+			return nil, fmt.Errorf("line %d: empty key", line.n)
+		}
+		if _, ok := m[key]; ok {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.n, key)
+		}
+		p.i++
+		var v any
+		if rest == "" || strings.HasPrefix(rest, "#") {
+			// A sequence may be indented as far as its key
+			if next, ok := p.next(indent); ok && isSeqEntry(next.text) {
+				v, err = p.sequence(indent)
+			} else {
+				v, err = p.nested(indent)
+			}
+		} else {
+			v, err = p.scalar(line, rest, indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+}
+
+// nested parses the block indented deeper than indent that follows, or
+// returns nil if there is none.
+func (p *yamlParser) nested(indent int) (any, error) {
+	p.skip()
+	if p.i == len(p.lines) || p.lines[p.i].indent <= indent {
+		return nil, nil
+	}
+	return p.block(p.lines[p.i].indent)
+}
+
+// isMappingEntry reports whether text starts a "key: value" entry.
+func isMappingEntry(text string) bool {
+	_, _, err := splitMappingEntry(text)
+	return err == nil
+}
+
+// splitMappingEntry splits a "key: value" entry into its key and the rest of
+// the line.
+func splitMappingEntry(text string) (key, rest string, err error) {
+	if text[0] == '"' || text[0] == '\'' {
+		s, n, err := yamlQuoted(text)
+		if err != nil {
+			return "", "", err
+		}
+		after := text[n:]
+		if after != ":" && !strings.HasPrefix(after, ": ") {
+			return "", "", fmt.Errorf("expected a colon after key %q", s)
+		}
+		return s, strings.TrimSpace(after[1:]), nil
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), nil
+		}
+		if text[i] == '#' && i > 0 && text[i-1] == ' ' {
+			break
+		}
+	}
+	return "", "", fmt.Errorf("expected \"key: value\", got %q", text)
+}
+
+// scalar parses rest, the value of line, which may continue as a block
+// scalar on the lines indented deeper than indent.
+func (p *yamlParser) scalar(line *yamlLine, rest string, indent int) (any, error) {
+	switch rest[0] {
+	case '|', '>':
+		return p.blockScalar(line, rest, indent)
+	case '[', '{':
+		v, n, err := parseYAMLFlow(rest)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.n, err)
+		}
+		if tail := strings.TrimSpace(rest[n:]); tail != "" && !strings.HasPrefix(tail, "#") {
+			return nil, fmt.Errorf("line %d: unexpected %q after the value", line.n, tail)
+		}
+		return v, nil
+	case '"', '\'':
+		s, n, err := yamlQuoted(rest)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line.n, err)
+		}
+		if tail := strings.TrimSpace(rest[n:]); tail != "" && !strings.HasPrefix(tail, "#") {
+			return nil, fmt.Errorf("line %d: unexpected %q after the string", line.n, tail)
+		}
+		return s, nil
+	case '&', '*', '!':
+		return nil, fmt.Errorf("line %d: anchors, aliases, and tags are not supported", line.n)
+	}
+	if i := strings.Index(rest, " #"); i >= 0 {
+		rest = rest[:i]
+	}
+	rest = strings.TrimSpace(rest)
+	if strings.Contains(rest, ": ") || strings.HasSuffix(rest, ":") {
+		return nil, fmt.Errorf("line %d: unexpected colon in %q; quote the value", line.n, rest)
+	}
+	return yamlPlain(rest), nil
+}
+
+// blockScalar parses a literal (|) or folded (>) block scalar with the clip
+// (default), strip (-), or keep (+) chomping indicator.
+func (p *yamlParser) blockScalar(line *yamlLine, header string, indent int) (string, error) {
+	if i := strings.Index(header, " #"); i >= 0 {
+		header = header[:i]
+	}
+	style, chomp := header[0], strings.TrimSpace(header[1:])
+	if chomp != "" && chomp != "-" && chomp != "+" {
+		return "", fmt.Errorf("line %d: unsupported block scalar header %q", line.n, header)
+	}
+
+	var body []string
+	blockIndent := -1
+	for ; p.i < len(p.lines); p.i++ {
+		l := p.lines[p.i]
+		if l.text == "" {
+			body = append(body, "")
+			continue
+		}
+		if l.indent <= indent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = l.indent
+		}
+		if l.indent < blockIndent {
+			return "", fmt.Errorf("line %d: block scalar is less indented than its first line", l.n)
+		}
+		body = append(body, l.raw[blockIndent:])
+	}
+	// Trailing blank lines belong to the chomping, not the content
+	trailing := 0
+	for len(body) > 0 && body[len(body)-1] == "" {
+		body = body[:len(body)-1]
+		trailing++
+	}
+
+	var s string
+	if style == '|' {
+		s = strings.Join(body, "\n")
+	} else {
+		var sb strings.Builder
+		for i, l := range body {
+			// Blank lines become line breaks, and the others join with spaces
+			switch {
+			case l == "":
+				sb.WriteByte('\n')
+			case i > 0 && body[i-1] != "":
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(l)
+		}
+		s = sb.String()
+	}
+	switch {
+	case len(body) == 0 || chomp == "-":
+	case chomp == "+":
+		s += strings.Repeat("\n", trailing+1)
+	default:
+		s += "\n"
+	}
+	return s, nil
+}
+
+// yamlQuoted parses the single- or double-quoted string s starts with,
+// returning it and its length in s.
+func yamlQuoted(s string) (string, int, error) {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case s[i] == quote && quote == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++ // An escaped single quote
+		case s[i] == quote:
+			if quote == '\'' {
+				return strings.ReplaceAll(s[1:i], "''", "'"), i + 1, nil
+			}
+			v, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid string %s: %w", s[:i+1], err)
+			}
+			return v, i + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string %s", s)
+}
+
+// parseYAMLFlow parses the flow sequence or mapping s starts with, returning
+// it and its length in s.
+func parseYAMLFlow(s string) (any, int, error) {
+	end := byte(']')
+	if s[0] == '{' {
+		end = '}'
+	}
+	seq, m := []any{}, map[string]any{}
+	i := 1
+	for {
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		if i == len(s) {
+			return nil, 0, fmt.Errorf("unterminated %c", s[0])
+		}
+		if s[i] == end {
+			if end == ']' {
+				return seq, i + 1, nil
+			}
+			return m, i + 1, nil
+		}
+
+		if s[i] == ',' {
+			return nil, 0, fmt.Errorf("empty entry in %s", s)
+		}
+		v, n, err := yamlFlowItem(s[i:], end)
+		if err != nil {
+			return nil, 0, err
+		}
+		i += n
+		if end == '}' {
+			key, ok := v.(yamlPlain)
+			if str, quoted := v.(string); quoted {
+				key, ok = yamlPlain(str), true
+			}
+			if !ok || i == len(s) || s[i] != ':' {
+				return nil, 0, fmt.Errorf("expected \"key: value\" in %s", s)
+			}
+			i++
+			for i < len(s) && s[i] == ' ' {
+				i++
+			}
+			if v, n, err = yamlFlowItem(s[i:], end); err != nil {
+				return nil, 0, err
+			}
+			i += n
+			if _, ok := m[string(key)]; ok {
+				return nil, 0, fmt.Errorf("duplicate key %q in %s", key, s)
+			}
+			m[string(key)] = resolveYAML(v)
+		} else {
+			seq = append(seq, resolveYAML(v))
+		}
+
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		switch {
+		case i < len(s) && s[i] == ',':
+			i++
+		case i < len(s) && s[i] != end:
+			return nil, 0, fmt.Errorf("expected a comma or %c in %s", end, s)
+		}
+	}
+}
+
+// yamlFlowItem parses the value s starts with inside a flow collection
+// closed by end.
+func yamlFlowItem(s string, end byte) (any, int, error) {
+	switch s[0] {
+	case '[', '{':
+		return parseYAMLFlow(s)
+	case '"', '\'':
+		return yamlQuoted(s)
+	}
+	n := strings.IndexFunc(s, func(r rune) bool { return r == ',' || r == rune(end) })
+	if n < 0 {
+		return nil, 0, fmt.Errorf("unterminated %c", end)
+	}
+	// A colon followed by a space ends a key
+	if i := strings.Index(s[:n], ": "); i >= 0 {
+		n = i
+	} else if end == '}' && strings.HasSuffix(s[:n], ":") {
+		n--
+	}
+	return yamlPlain(strings.TrimSpace(s[:n])), n, nil
+}
+
+// yamlPlain is an unquoted scalar, whose type depends on how it reads.
+type yamlPlain string
+
+// yamlNumber matches the plain scalars read as numbers: those JSON reads as
+// numbers, so that IDs with leading zeros stay strings.
+var yamlNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// resolveYAML turns the plain scalars in v into nulls, bools, numbers, or
+// strings.
+func resolveYAML(v any) any {
+	switch v := v.(type) {
+	case yamlPlain:
+		switch s := string(v); s {
+		case "", "~", "null", "Null", "NULL":
+			return nil
+		case "true", "True", "TRUE":
+			return true
+		case "false", "False", "FALSE":
+			return false
+		default:
+			if yamlNumber.MatchString(s) {
+				return json.Number(s)
+			}
+			return s
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = resolveYAML(e)
+		}
+	case map[string]any:
+		for k, e := range v {
+			v[k] = resolveYAML(e)
+		}
+	}
+	return v
+}
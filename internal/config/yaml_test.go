@@ -0,0 +1,103 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string // As JSON
+		wantErr bool
+	}{
+		{
+			name:  "empty",
+			input: "# nothing\n",
+			want:  `{}`,
+		},
+		{
+			name: "nested mappings and scalars",
+			input: `---
+version: 2  # current
+settings:
+  DISCORD_GUILD_ID: "123"
+  DRY_RUN: true
+  HISTORICAL_WORKERS: 4
+  STORE_PATH: /var/lib/jolly/store.json
+  EMPTY:
+  LEADING_ZERO: 0123
+rules:
+  escalation: 5:dm,20:notify
+  exclude_pattern: '^skull(candy|kid)'
+`,
+			want: `{"rules":{"escalation":"5:dm,20:notify","exclude_pattern":"^skull(candy|kid)"},"settings":{"DISCORD_GUILD_ID":"123","DRY_RUN":true,"EMPTY":null,"HISTORICAL_WORKERS":4,"LEADING_ZERO":"0123","STORE_PATH":"/var/lib/jolly/store.json"},"version":2}`,
+		},
+		{
+			name: "sequences of mappings",
+			input: `target_groups:
+- name: lounge
+  users: ["1", "2"]
+  channels:
+    - "100"
+- name: memes
+  users: []
+  replace: {mode: add, emoji: "🎄"}
+`,
+			want: `{"target_groups":[{"channels":["100"],"name":"lounge","users":["1","2"]},{"name":"memes","replace":{"emoji":"🎄","mode":"add"},"users":[]}]}`,
+		},
+		{
+			name: "block scalars",
+			input: `literal: |
+  line one
+    indented
+
+folded: >-
+  one
+  two
+
+  three
+keep: |+
+  kept
+
+end: x
+`,
+			want: `{"end":"x","folded":"one two\nthree","keep":"kept\n\n","literal":"line one\n  indented\n"}`,
+		},
+		{
+			name:  "quoted strings",
+			input: `a: "tab\tnewline\n"` + "\n" + `b: 'it''s # not a comment'` + "\n" + `"quoted key": 1`,
+			want:  `{"a":"tab\tnewline\n","b":"it's # not a comment","quoted key":1}`,
+		},
+		{name: "tab indentation", input: "a:\n\tb: 1", wantErr: true},
+		{name: "duplicate key", input: "a: 1\na: 2", wantErr: true},
+		{name: "unterminated string", input: `a: "open`, wantErr: true},
+		{name: "unterminated flow", input: `a: [1, 2`, wantErr: true},
+		{name: "alias", input: "a: *ref", wantErr: true},
+		{name: "bad indentation", input: "a:\n    b: 1\n  c: 2", wantErr: true},
+		{name: "not a mapping entry", input: "a: 1\njust text", wantErr: true},
+		{name: "colon in plain value", input: "a: b: c", wantErr: true},
+		{name: "empty flow entry", input: "a: [1, , 2]", wantErr: true},
+		{name: "missing comma in flow", input: `a: ["x" "y"]`, wantErr: true},
+		{name: "duplicate flow key", input: "a: {b: 1, b: 2}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := parseYAML([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseYAML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got, err := json.Marshal(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("parseYAML() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Package correlation ties together everything that stems from one gateway
+// event, such as its log lines, audit entries, published events, and
+// retries, with a correlation ID carried in a context.
+package correlation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+)
+
+// Key is the log attribute and field name of correlation IDs.
+const Key = "correlation_id"
+
+type contextKey struct{}
+
+// New returns a random correlation ID.
+func New() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// With returns a copy of ctx that carries id.
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// Start returns a copy of ctx with a new correlation ID, for work that does
+// not continue an earlier event.
+func Start(ctx context.Context) context.Context {
+	return With(ctx, New())
+}
+
+// Continue returns a copy of ctx that carries id, such as one saved with a
+// scheduled job, or a new ID if id is empty.
+func Continue(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return Start(ctx)
+	}
+	return With(ctx, id)
+}
+
+// ID returns the correlation ID carried by ctx, or "" if there is none.
+func ID(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Handler adds the correlation ID of the context passed to the *Context
+// logging functions, such as slog.InfoContext, to every record.
+type Handler struct {
+	slog.Handler
+}
+
+// NewHandler wraps h so records carry their correlation ID.
+func NewHandler(h slog.Handler) *Handler {
+	return &Handler{h}
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if id := ID(ctx); id != "" {
+		r.AddAttrs(slog.String(Key, id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{h.Handler.WithGroup(name)}
+}
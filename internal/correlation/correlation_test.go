@@ -0,0 +1,63 @@
+package correlation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestID(t *testing.T) {
+	if id := ID(context.Background()); id != "" {
+		t.Errorf("ID() without one = %q, want empty", id)
+	}
+	ctx := With(context.Background(), "abc")
+	if id := ID(ctx); id != "abc" {
+		t.Errorf("ID() = %q, want abc", id)
+	}
+	if id := ID(Continue(context.Background(), "abc")); id != "abc" {
+		t.Errorf("Continue() = %q, want abc", id)
+	}
+	if id := ID(Continue(context.Background(), "")); id == "" {
+		t.Error("Continue() without an ID should start a new one")
+	}
+	a, b := ID(Start(ctx)), ID(Start(ctx))
+	if len(a) != 16 || a == b || a == "abc" {
+		t.Errorf("Start() IDs = %q, %q, want distinct new 16-character IDs", a, b)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want any
+	}{
+		{"with ID", With(context.Background(), "abc"), "abc"},
+		{"without ID", context.Background(), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(NewHandler(slog.NewJSONHandler(&buf, nil))).With("component", "bot").WithGroup("g")
+			logger.InfoContext(tt.ctx, "replaced", "message_id", "m1")
+
+			var record map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatal(err)
+			}
+			// Attributes added by the handler land in the open group
+			var got any
+			if g, ok := record["g"].(map[string]any); ok {
+				got = g[Key]
+			}
+			if got != tt.want {
+				t.Errorf("%s = %v, want %v in %s", Key, got, tt.want, buf.String())
+			}
+			if record["component"] != "bot" {
+				t.Errorf("component = %v, want the logger's attributes kept", record["component"])
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Package emoji manages a guild's custom emojis: it lists them once and keeps
+// the list current from gateway events, and uploads and deletes them within
+// the strict limits Discord puts on its emoji routes.
+package emoji
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/ratelimit"
+)
+
+// Discord allows few emoji uploads and deletions per guild and answers more
+// with waits of up to an hour, so writes are spaced out before they get
+// there.
+const (
+	writeLimit  = 5
+	writePeriod = time.Minute
+)
+
+// API is the part of the Discord API that emojis are managed through.
+type API interface {
+	GuildEmojis(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Emoji, error)
+	GuildEmojiCreate(guildID string, data *discordgo.EmojiParams, options ...discordgo.RequestOption) (*discordgo.Emoji, error)
+	GuildEmojiDelete(guildID, emojiID string, options ...discordgo.RequestOption) error
+}
+
+// Manager lists, uploads, and deletes the custom emojis of guilds, keeping
+// the emojis of each guild it has seen cached.
+type Manager struct {
+	writes *ratelimit.Bucket // Shared by uploads and deletions
+
+	mu     sync.Mutex // Guards guilds
+	guilds map[string][]*discordgo.Emoji
+}
+
+// New returns a Manager with an empty cache.
+func New() *Manager {
+	return &Manager{writes: ratelimit.New(writeLimit, writePeriod), guilds: make(map[string][]*discordgo.Emoji)}
+}
+
+// List returns the emojis of guildID, listing them the first time.
+func (m *Manager) List(api API, guildID string) ([]*discordgo.Emoji, error) {
+	m.mu.Lock()
+	emojis, ok := m.guilds[guildID]
+	m.mu.Unlock()
+	if ok {
+		return slices.Clone(emojis), nil
+	}
+
+	emojis, err := api.GuildEmojis(guildID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list emojis: %w", err)
+	}
+	m.Set(guildID, emojis)
+	return emojis, nil
+}
+
+// Set replaces the cached emojis of guildID with emojis, as the gateway
+// sends them when the bot joins a guild and whenever they change.
+func (m *Manager) Set(guildID string, emojis []*discordgo.Emoji) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.guilds[guildID] = slices.Clone(emojis)
+}
+
+// ByName returns the emoji of guildID named name, or nil if there is none.
+func (m *Manager) ByName(api API, guildID, name string) (*discordgo.Emoji, error) {
+	emojis, err := m.List(api, guildID)
+	if err != nil {
+		return nil, err
+	}
+	if i := slices.IndexFunc(emojis, func(e *discordgo.Emoji) bool { return e.Name == name }); i >= 0 {
+		return emojis[i], nil
+	}
+	return nil, nil
+}
+
+// ByID returns the emoji of guildID with id, or nil if there is none.
+func (m *Manager) ByID(api API, guildID, id string) (*discordgo.Emoji, error) {
+	emojis, err := m.List(api, guildID)
+	if err != nil {
+		return nil, err
+	}
+	if i := slices.IndexFunc(emojis, func(e *discordgo.Emoji) bool { return e.ID == id }); i >= 0 {
+		return emojis[i], nil
+	}
+	return nil, nil
+}
+
+// Create uploads image as the emoji name in guildID, waiting for the write
+// limit first, and returns it. opts are passed on to the request.
+func (m *Manager) Create(ctx context.Context, api API, guildID, name string, image []byte, opts ...discordgo.RequestOption) (*discordgo.Emoji, error) {
+	if err := m.writes.Wait(ctx); err != nil {
+		return nil, err
+	}
+	params := &discordgo.EmojiParams{
+		Name:  name,
+		Image: "data:" + http.DetectContentType(image) + ";base64," + base64.StdEncoding.EncodeToString(image),
+	}
+	e, err := api.GuildEmojiCreate(guildID, params, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload emoji %s: %w", name, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if emojis, ok := m.guilds[guildID]; ok {
+		m.guilds[guildID] = append(emojis, e)
+	}
+	return e, nil
+}
+
+// Delete deletes the emoji id from guildID, waiting for the write limit
+// first. opts are passed on to the request.
+func (m *Manager) Delete(ctx context.Context, api API, guildID, id string, opts ...discordgo.RequestOption) error {
+	if err := m.writes.Wait(ctx); err != nil {
+		return err
+	}
+	if err := api.GuildEmojiDelete(guildID, id, opts...); err != nil {
+		return fmt.Errorf("failed to delete emoji %s: %w", id, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if emojis, ok := m.guilds[guildID]; ok {
+		m.guilds[guildID] = slices.DeleteFunc(emojis, func(e *discordgo.Emoji) bool { return e.ID == id })
+	}
+	return nil
+}
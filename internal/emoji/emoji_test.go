@@ -0,0 +1,136 @@
+package emoji
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"jolly-okurb/internal/ratelimit"
+)
+
+// fakeAPI keeps the emojis of one guild and counts the listings.
+type fakeAPI struct {
+	mu      sync.Mutex
+	emojis  []*discordgo.Emoji
+	lists   int
+	created []*discordgo.EmojiParams
+	err     error
+}
+
+func (f *fakeAPI) GuildEmojis(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Emoji, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lists++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return append([]*discordgo.Emoji(nil), f.emojis...), nil
+}
+
+func (f *fakeAPI) GuildEmojiCreate(guildID string, data *discordgo.EmojiParams, options ...discordgo.RequestOption) (*discordgo.Emoji, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.created = append(f.created, data)
+	e := &discordgo.Emoji{ID: fmt.Sprintf("emoji%d", len(f.created)), Name: data.Name}
+	f.emojis = append(f.emojis, e)
+	return e, nil
+}
+
+func (f *fakeAPI) GuildEmojiDelete(guildID, emojiID string, options ...discordgo.RequestOption) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	for i, e := range f.emojis {
+		if e.ID == emojiID {
+			f.emojis = append(f.emojis[:i], f.emojis[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("unknown emoji")
+}
+
+// png is the start of a PNG file, enough to detect its type.
+var png = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestManager(t *testing.T) {
+	api := &fakeAPI{emojis: []*discordgo.Emoji{{ID: "1", Name: "deadskull"}}}
+	m := New()
+	ctx := context.Background()
+
+	if e, err := m.ByName(api, "guild1", "deadskull"); err != nil || e == nil || e.ID != "1" {
+		t.Fatalf("ByName() = %v, %v, want the guild's emoji", e, err)
+	}
+	if e, _ := m.ByID(api, "guild1", "2"); e != nil {
+		t.Errorf("ByID() = %v, want nil for an unknown emoji", e)
+	}
+
+	e, err := m.Create(ctx, api, "guild1", "jollysanta", png)
+	if err != nil {
+		t.Fatalf("Create() unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(api.created[0].Image, "data:image/png;base64,") {
+		t.Errorf("Image = %q, want a PNG data URI", api.created[0].Image)
+	}
+	if found, _ := m.ByName(api, "guild1", "jollysanta"); found == nil || found.ID != e.ID {
+		t.Errorf("ByName() = %v, want the uploaded emoji from the cache", found)
+	}
+
+	if err := m.Delete(ctx, api, "guild1", "1"); err != nil {
+		t.Fatalf("Delete() unexpected error: %v", err)
+	}
+	if found, _ := m.ByName(api, "guild1", "deadskull"); found != nil {
+		t.Errorf("ByName() = %v, want the deleted emoji gone from the cache", found)
+	}
+	if api.lists != 1 {
+		t.Errorf("listed %d times, want once", api.lists)
+	}
+
+	m.Set("guild1", []*discordgo.Emoji{{ID: "3", Name: "skull2"}})
+	if emojis, _ := m.List(api, "guild1"); len(emojis) != 1 || emojis[0].ID != "3" {
+		t.Errorf("List() = %v, want the emojis set from the gateway", emojis)
+	}
+}
+
+func TestManager_Errors(t *testing.T) {
+	api := &fakeAPI{err: errors.New("forbidden")}
+	m := New()
+
+	if _, err := m.List(api, "guild1"); err == nil || !strings.Contains(err.Error(), "failed to list emojis") {
+		t.Errorf("List() error = %v, want the failure wrapped", err)
+	}
+	if _, err := m.List(api, "guild1"); api.lists != 2 || err == nil {
+		t.Error("a failed listing should not be cached")
+	}
+	if _, err := m.Create(context.Background(), api, "guild1", "jollysanta", png); err == nil || !strings.Contains(err.Error(), "jollysanta") {
+		t.Errorf("Create() error = %v, want it to name the emoji", err)
+	}
+}
+
+func TestManager_WriteLimit(t *testing.T) {
+	api := &fakeAPI{}
+	m := New()
+	m.writes = ratelimit.New(1, time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := m.Create(ctx, api, "guild1", "first", png); err != nil {
+		t.Fatalf("Create() unexpected error: %v", err)
+	}
+	if err := m.Delete(ctx, api, "guild1", "emoji1"); err == nil {
+		t.Error("Delete() should wait for the write limit shared with uploads")
+	}
+	if len(api.emojis) != 1 {
+		t.Errorf("emojis = %v, want the upload only", api.emojis)
+	}
+}
@@ -0,0 +1,149 @@
+// Package events publishes the bot's actions to message brokers and
+// webhooks, for servers that pipe moderation events into other systems.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"jolly-okurb/internal/logx"
+)
+
+// Event types.
+const (
+	ReactionReplaced = "reaction.replaced"
+	MessageDeleted   = "message.deleted"
+)
+
+// logger is the logger of this package.
+var logger = logx.For(logx.Events)
+
+// queueSize bounds the events waiting for a slow subscriber. Further events
+// are dropped so that a broker outage never holds up moderation.
+const queueSize = 256
+
+// publishTimeout bounds how long one subscriber may take to publish an event,
+// unless it sets its own timeout.
+const publishTimeout = 10 * time.Second
+
+// timeoutPublisher is implemented by publishers that need another timeout,
+// such as ones that retry.
+type timeoutPublisher interface {
+	PublishTimeout() time.Duration
+}
+
+// Event is an action the bot took.
+type Event struct {
+	Type      string    `json:"type"`
+	Time      time.Time `json:"time"`
+	GuildID   string    `json:"guild_id,omitempty"` // Empty in DMs
+	ChannelID string    `json:"channel_id"`
+	MessageID string    `json:"message_id"`
+	UserID    string    `json:"user_id"`         // Whose skull was acted on
+	Emoji     string    `json:"emoji,omitempty"` // The replaced skull, for reaction events
+
+	CorrelationID string `json:"correlation_id,omitempty"` // Shared with the logs and audit entries of the same action
+}
+
+// Encode returns e as JSON, the payload every publisher sends.
+func (e Event) Encode() []byte {
+	data, _ := json.Marshal(e) // Cannot fail for these field types
+	return data
+}
+
+// Publisher sends events to one destination, such as a broker subject.
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+	Close() error
+}
+
+// Bus fans events out to its subscribers. Each subscriber has its own queue
+// and goroutine, so a slow or failing one does not affect the others.
+// A nil Bus discards events.
+type Bus struct {
+	mu     sync.RWMutex
+	subs   []*subscriber
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type subscriber struct {
+	name  string
+	p     Publisher
+	queue chan Event
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe starts delivering published events to p.
+func (b *Bus) Subscribe(name string, p Publisher) {
+	sub := &subscriber{name: name, p: p, queue: make(chan Event, queueSize)}
+	b.mu.Lock()
+	b.subs = append(b.subs, sub)
+	b.mu.Unlock()
+
+	timeout := publishTimeout
+	if t, ok := p.(timeoutPublisher); ok {
+		timeout = t.PublishTimeout()
+	}
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for e := range sub.queue {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			if err := sub.p.Publish(ctx, e); err != nil {
+				logger.Warn("failed to publish event", "subscriber", sub.name, "type", e.Type, "message_id", e.MessageID, "error", err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// Publish queues e for every subscriber without waiting for delivery.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+	for _, sub := range b.subs {
+		select {
+		case sub.queue <- e:
+		default:
+			logger.Warn("event queue full, dropping event", "subscriber", sub.name, "type", e.Type, "message_id", e.MessageID)
+		}
+	}
+}
+
+// Close delivers the queued events, then closes every subscriber.
+func (b *Bus) Close() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	for _, sub := range b.subs {
+		close(sub.queue)
+	}
+	b.mu.Unlock()
+
+	b.wg.Wait()
+	var firstErr error
+	for _, sub := range b.subs {
+		if err := sub.p.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
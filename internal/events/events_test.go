@@ -0,0 +1,137 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingPublisher records the events it is given.
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []Event
+	closed bool
+	block  chan struct{} // When set, Publish waits for it to close
+	err    error
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, e Event) error {
+	if p.block != nil {
+		<-p.block
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, e)
+	return p.err
+}
+
+func (p *recordingPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+func TestEvent_Encode(t *testing.T) {
+	e := Event{
+		Type:      ReactionReplaced,
+		Time:      time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		ChannelID: "c1",
+		MessageID: "m1",
+		UserID:    "u1",
+		Emoji:     "💀",
+	}
+	var got map[string]any
+	if err := json.Unmarshal(e.Encode(), &got); err != nil {
+		t.Fatalf("Encode() is not JSON: %v", err)
+	}
+
+	tests := []struct {
+		field    string
+		expected any
+	}{
+		{"type", "reaction.replaced"},
+		{"time", "2026-03-01T12:00:00Z"},
+		{"channel_id", "c1"},
+		{"message_id", "m1"},
+		{"user_id", "u1"},
+		{"emoji", "💀"},
+		{"guild_id", nil}, // Omitted when empty
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			if got[tt.field] != tt.expected {
+				t.Errorf("%s = %v, want %v", tt.field, got[tt.field], tt.expected)
+			}
+		})
+	}
+}
+
+func TestBus(t *testing.T) {
+	tests := []struct {
+		name    string
+		publish int
+		err     error
+	}{
+		{"fans out every event", 3, nil},
+		{"keeps going after errors", 2, errors.New("broker down")},
+		{"nothing published", 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := &recordingPublisher{err: tt.err}, &recordingPublisher{}
+			bus := NewBus()
+			bus.Subscribe("a", a)
+			bus.Subscribe("b", b)
+			for i := range tt.publish {
+				bus.Publish(Event{Type: MessageDeleted, MessageID: string(rune('0' + i))})
+			}
+			if err := bus.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			for _, p := range []*recordingPublisher{a, b} {
+				if len(p.events) != tt.publish {
+					t.Errorf("subscriber got %d events, want %d", len(p.events), tt.publish)
+				}
+				for i, e := range p.events {
+					if want := string(rune('0' + i)); e.MessageID != want {
+						t.Errorf("event %d has message ID %q, want %q", i, e.MessageID, want)
+					}
+				}
+				if !p.closed {
+					t.Error("subscriber was not closed")
+				}
+			}
+			bus.Publish(Event{Type: MessageDeleted}) // Ignored once closed
+		})
+	}
+}
+
+func TestBus_DropsWhenFull(t *testing.T) {
+	slow := &recordingPublisher{block: make(chan struct{})}
+	bus := NewBus()
+	bus.Subscribe("slow", slow)
+
+	// One event is taken off the queue and blocks; the queue holds the next queueSize
+	for range queueSize + 10 {
+		bus.Publish(Event{Type: MessageDeleted})
+	}
+	close(slow.block)
+	bus.Close()
+
+	if n := len(slow.events); n < queueSize || n > queueSize+1 {
+		t.Errorf("subscriber got %d events, want %d or %d", n, queueSize, queueSize+1)
+	}
+}
+
+func TestBus_Nil(t *testing.T) {
+	var bus *Bus
+	bus.Publish(Event{Type: MessageDeleted})
+	if err := bus.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mqttPacket is a control packet a fake broker received.
+type mqttPacket struct {
+	typ  byte // With flags
+	body []byte
+}
+
+// fakeBroker is an MQTT broker that answers CONNECT with returnCode and
+// reports the packets clients send.
+type fakeBroker struct {
+	ln         net.Listener
+	returnCode byte
+	packets    chan mqttPacket
+	conns      chan net.Conn
+}
+
+func newFakeBroker(t *testing.T, returnCode byte) *fakeBroker {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeBroker{ln: ln, returnCode: returnCode, packets: make(chan mqttPacket, 20), conns: make(chan net.Conn, 10)}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			f.conns <- conn
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeBroker) url(userinfo string) string {
+	if userinfo != "" {
+		userinfo += "@"
+	}
+	return "mqtt://" + userinfo + f.ln.Addr().String()
+}
+
+func (f *fakeBroker) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		first, err := r.Peek(1)
+		if err != nil {
+			return
+		}
+		flags := first[0]
+		typ, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		f.packets <- mqttPacket{typ: flags, body: body}
+		if typ == mqttConnect {
+			writePacket(w, mqttConnAck, []byte{0, f.returnCode})
+			w.Flush()
+		}
+	}
+}
+
+func (f *fakeBroker) receive(t *testing.T) mqttPacket {
+	t.Helper()
+	select {
+	case p := <-f.packets:
+		return p
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the client")
+		return mqttPacket{}
+	}
+}
+
+// publishOf splits a PUBLISH body into its topic and payload.
+func publishOf(t *testing.T, p mqttPacket) (topic string, payload []byte, retain bool) {
+	t.Helper()
+	if p.typ&0xf0 != mqttPublish {
+		t.Fatalf("packet type %#x, want PUBLISH", p.typ)
+	}
+	n := int(p.body[0])<<8 | int(p.body[1])
+	return string(p.body[2 : 2+n]), p.body[2+n:], p.typ&0x01 != 0
+}
+
+func TestDialMQTT(t *testing.T) {
+	tests := []struct {
+		name      string
+		userinfo  string
+		wantFlags byte
+		wantAuth  []string
+	}{
+		{"no auth", "", 0x02, nil},
+		{"user only", "ha", 0x82, []string{"ha"}},
+		{"user and password", "ha:secret", 0xc2, []string{"ha", "secret"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker := newFakeBroker(t, 0)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			m, err := DialMQTT(ctx, broker.url(tt.userinfo), "home/jolly/")
+			if err != nil {
+				t.Fatalf("DialMQTT() error = %v", err)
+			}
+			defer m.Close()
+
+			connect := broker.receive(t)
+			if connect.typ != mqttConnect {
+				t.Fatalf("first packet type %#x, want CONNECT", connect.typ)
+			}
+			if !strings.HasPrefix(string(connect.body), "\x00\x04MQTT\x04") {
+				t.Errorf("CONNECT does not ask for MQTT 3.1.1: %q", connect.body[:7])
+			}
+			if flags := connect.body[7]; flags != tt.wantFlags {
+				t.Errorf("connect flags = %#x, want %#x", flags, tt.wantFlags)
+			}
+			for _, s := range tt.wantAuth {
+				if !strings.Contains(string(connect.body), string(mqttString(s))) {
+					t.Errorf("CONNECT does not contain %q", s)
+				}
+			}
+		})
+	}
+}
+
+func TestDialMQTT_Errors(t *testing.T) {
+	refusing := newFakeBroker(t, 4)
+	tests := []struct {
+		name    string
+		url     string
+		topic   string
+		wantErr string
+	}{
+		{"unsupported scheme", "tcp://localhost:1883", "jolly", "unsupported scheme"},
+		{"missing host", "mqtt://", "jolly", "missing host"},
+		{"empty topic", "mqtt://localhost", "", "invalid MQTT topic"},
+		{"wildcard topic", "mqtt://localhost", "jolly/#", "invalid MQTT topic"},
+		{"refused", refusing.url("ha:wrong"), "jolly", "bad user name or password"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_, err := DialMQTT(ctx, tt.url, tt.topic)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("DialMQTT() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMQTT_Publish(t *testing.T) {
+	broker := newFakeBroker(t, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	m, err := DialMQTT(ctx, broker.url(""), "jolly")
+	if err != nil {
+		t.Fatalf("DialMQTT() error = %v", err)
+	}
+	defer m.Close()
+	broker.receive(t) // CONNECT
+
+	day := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	m.SetCounts(DayCounts{Date: "2026-03-01", Replaced: 4, Deleted: 1})
+	published := []Event{
+		{Type: ReactionReplaced, Time: day, MessageID: "m1"},
+		{Type: MessageDeleted, Time: day.Add(time.Hour), MessageID: "m2"},
+		{Type: ReactionReplaced, Time: day.AddDate(0, 0, 1), MessageID: "m3"}, // A new day starts from zero
+	}
+	wantCounts := []DayCounts{
+		{Date: "2026-03-01", Replaced: 5, Deleted: 1},
+		{Date: "2026-03-01", Replaced: 5, Deleted: 2},
+		{Date: "2026-03-02", Replaced: 1, Deleted: 0},
+	}
+	for i, e := range published {
+		if err := m.Publish(ctx, e); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+
+		topic, payload, retain := publishOf(t, broker.receive(t))
+		if topic != "jolly/events" || retain || string(payload) != string(e.Encode()) {
+			t.Errorf("event %d published to %q (retain %v) as %s", i, topic, retain, payload)
+		}
+
+		topic, payload, retain = publishOf(t, broker.receive(t))
+		var counts DayCounts
+		if err := json.Unmarshal(payload, &counts); err != nil {
+			t.Fatalf("counts %s are not JSON: %v", payload, err)
+		}
+		if topic != "jolly/counts" || !retain || counts != wantCounts[i] {
+			t.Errorf("counts after event %d = %+v on %q (retain %v), want %+v on jolly/counts retained", i, counts, topic, retain, wantCounts[i])
+		}
+	}
+}
+
+func TestMQTT_PublishAfterDeadline(t *testing.T) {
+	broker := newFakeBroker(t, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	m, err := DialMQTT(ctx, broker.url(""), "jolly")
+	if err != nil {
+		t.Fatalf("DialMQTT() error = %v", err)
+	}
+	defer m.Close()
+	broker.receive(t) // CONNECT
+
+	short, cancelShort := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelShort()
+	if err := m.Publish(short, Event{Type: MessageDeleted, Time: time.Now(), MessageID: "m1"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	broker.receive(t) // Event
+	broker.receive(t) // Counts
+	<-short.Done()
+
+	// The first publish's deadline has passed; it must not fail this one
+	if err := m.Publish(context.Background(), Event{Type: MessageDeleted, Time: time.Now(), MessageID: "m2"}); err != nil {
+		t.Fatalf("Publish() after an earlier deadline passed error = %v", err)
+	}
+	if p := broker.receive(t); p.typ == mqttConnect {
+		t.Error("client reconnected, want the publish on the same connection")
+	}
+}
+
+func TestMQTT_Reconnect(t *testing.T) {
+	broker := newFakeBroker(t, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	m, err := DialMQTT(ctx, broker.url(""), "jolly")
+	if err != nil {
+		t.Fatalf("DialMQTT() error = %v", err)
+	}
+	defer m.Close()
+	broker.receive(t) // CONNECT
+
+	(<-broker.conns).Close()
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		m.mu.Lock()
+		dropped := m.conn == nil
+		m.mu.Unlock()
+		if dropped {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("client did not notice the dropped connection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := m.Publish(ctx, Event{Type: MessageDeleted, Time: time.Now()}); err != nil {
+		t.Fatalf("Publish() after reconnect error = %v", err)
+	}
+	if p := broker.receive(t); p.typ != mqttConnect {
+		t.Errorf("packet type %#x after reconnect, want CONNECT", p.typ)
+	}
+	if topic, _, _ := publishOf(t, broker.receive(t)); topic != "jolly/events" {
+		t.Errorf("published to %q, want jolly/events", topic)
+	}
+}
+
+func TestMQTT_Close(t *testing.T) {
+	broker := newFakeBroker(t, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	m, err := DialMQTT(ctx, broker.url(""), "jolly")
+	if err != nil {
+		t.Fatalf("DialMQTT() error = %v", err)
+	}
+	broker.receive(t) // CONNECT
+
+	m.Close()
+	if p := broker.receive(t); p.typ != mqttDisconnect {
+		t.Errorf("packet type %#x on close, want DISCONNECT", p.typ)
+	}
+	if err := m.Publish(ctx, Event{Type: MessageDeleted}); err == nil {
+		t.Error("Publish() after Close() succeeded, want an error")
+	}
+}
@@ -0,0 +1,243 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeNATS is a NATS server that accepts connections and reports what
+// clients send.
+type fakeNATS struct {
+	ln       net.Listener
+	refuse   string        // When set, answers CONNECT with -ERR and this text
+	connects chan string   // CONNECT options of each client
+	pubs     chan string   // "subject payload" of each PUB
+	conns    chan net.Conn // Accepted connections
+}
+
+func newFakeNATS(t *testing.T, refuse string) *fakeNATS {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeNATS{
+		ln:       ln,
+		refuse:   refuse,
+		connects: make(chan string, 10),
+		pubs:     make(chan string, 10),
+		conns:    make(chan net.Conn, 10),
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			f.conns <- conn
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeNATS) url(userinfo string) string {
+	if userinfo != "" {
+		userinfo += "@"
+	}
+	return "nats://" + userinfo + f.ln.Addr().String()
+}
+
+func (f *fakeNATS) serve(conn net.Conn) {
+	defer conn.Close()
+	fmt.Fprint(conn, "INFO {\"server_id\":\"fake\"}\r\n")
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "CONNECT":
+			f.connects <- strings.TrimSpace(strings.TrimPrefix(line, "CONNECT"))
+			if f.refuse != "" {
+				fmt.Fprintf(conn, "-ERR '%s'\r\n", f.refuse)
+				return
+			}
+		case "PING":
+			fmt.Fprint(conn, "PONG\r\n")
+		case "PUB":
+			size, _ := strconv.Atoi(fields[len(fields)-1])
+			payload := make([]byte, size+2) // With the trailing CRLF
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+			f.pubs <- fields[1] + " " + string(payload[:size])
+		}
+	}
+}
+
+func receive(t *testing.T, ch chan string) string {
+	t.Helper()
+	select {
+	case s := <-ch:
+		return s
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the client")
+		return ""
+	}
+}
+
+func TestDialNATS(t *testing.T) {
+	tests := []struct {
+		name        string
+		userinfo    string
+		wantConnect []string
+	}{
+		{"no auth", "", []string{`"user":""`, `"auth_token":""`}},
+		{"user and password", "alice:secret", []string{`"user":"alice"`, `"pass":"secret"`}},
+		{"token", "s3cr3t", []string{`"auth_token":"s3cr3t"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeNATS(t, "")
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			n, err := DialNATS(ctx, srv.url(tt.userinfo), "jolly.events")
+			if err != nil {
+				t.Fatalf("DialNATS() error = %v", err)
+			}
+			defer n.Close()
+
+			connect := receive(t, srv.connects)
+			for _, want := range tt.wantConnect {
+				if !strings.Contains(connect, want) {
+					t.Errorf("CONNECT %s does not contain %s", connect, want)
+				}
+			}
+
+			e := Event{Type: MessageDeleted, MessageID: "m1"}
+			if err := n.Publish(ctx, e); err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+			if got, want := receive(t, srv.pubs), "jolly.events "+string(e.Encode()); got != want {
+				t.Errorf("published %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestDialNATS_Errors(t *testing.T) {
+	refusing := newFakeNATS(t, "Authorization Violation")
+	tests := []struct {
+		name    string
+		url     string
+		subject string
+		wantErr string
+	}{
+		{"unsupported scheme", "http://localhost:4222", "jolly.events", "unsupported scheme"},
+		{"missing host", "nats://", "jolly.events", "missing host"},
+		{"invalid subject", "nats://localhost", "jolly events", "invalid NATS subject"},
+		{"empty subject", "nats://localhost", "", "invalid NATS subject"},
+		{"refused", refusing.url("alice:wrong"), "jolly.events", "Authorization Violation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_, err := DialNATS(ctx, tt.url, tt.subject)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("DialNATS() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNATS_Reconnect(t *testing.T) {
+	srv := newFakeNATS(t, "")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	n, err := DialNATS(ctx, srv.url(""), "jolly.events")
+	if err != nil {
+		t.Fatalf("DialNATS() error = %v", err)
+	}
+	defer n.Close()
+
+	// The server drops the connection; the read loop notices
+	(<-srv.conns).Close()
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		n.mu.Lock()
+		dropped := n.conn == nil
+		n.mu.Unlock()
+		if dropped {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("client did not notice the dropped connection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := n.Publish(ctx, Event{Type: MessageDeleted, MessageID: "m2"}); err != nil {
+		t.Fatalf("Publish() after reconnect error = %v", err)
+	}
+	if got := receive(t, srv.pubs); !strings.Contains(got, `"message_id":"m2"`) {
+		t.Errorf("published %q, want message m2", got)
+	}
+	if got := len(srv.connects); got != 2 {
+		t.Errorf("client sent %d CONNECTs, want 2", got)
+	}
+}
+
+func TestNATS_PublishAfterDeadline(t *testing.T) {
+	srv := newFakeNATS(t, "")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	n, err := DialNATS(ctx, srv.url(""), "jolly.events")
+	if err != nil {
+		t.Fatalf("DialNATS() error = %v", err)
+	}
+	defer n.Close()
+
+	short, cancelShort := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelShort()
+	if err := n.Publish(short, Event{Type: MessageDeleted, MessageID: "m1"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	receive(t, srv.pubs)
+	<-short.Done()
+
+	// The first publish's deadline has passed; it must not fail this one
+	if err := n.Publish(context.Background(), Event{Type: MessageDeleted, MessageID: "m2"}); err != nil {
+		t.Fatalf("Publish() after an earlier deadline passed error = %v", err)
+	}
+	if got := receive(t, srv.pubs); !strings.Contains(got, `"message_id":"m2"`) {
+		t.Errorf("published %q, want message m2", got)
+	}
+}
+
+func TestNATS_PublishAfterClose(t *testing.T) {
+	srv := newFakeNATS(t, "")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	n, err := DialNATS(ctx, srv.url(""), "jolly.events")
+	if err != nil {
+		t.Fatalf("DialNATS() error = %v", err)
+	}
+	n.Close()
+	if err := n.Publish(ctx, Event{Type: MessageDeleted}); err == nil {
+		t.Error("Publish() after Close() succeeded, want an error")
+	}
+}
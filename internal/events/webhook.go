@@ -0,0 +1,221 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"jolly-okurb/internal/config"
+)
+
+// Signature headers of webhooks with a secret. The signature is
+// "sha256=" and the hex HMAC-SHA256 of the timestamp, a dot, and the body,
+// so that receivers can reject replayed requests.
+const (
+	signatureHeader = "X-Jolly-Signature"
+	timestampHeader = "X-Jolly-Timestamp"
+)
+
+// Webhook POSTs events as JSON to an HTTP endpoint, retrying failed
+// deliveries as configured.
+type Webhook struct {
+	config config.Webhook
+	http   *http.Client
+}
+
+func NewWebhook(hook config.Webhook) *Webhook {
+	return &Webhook{config: hook, http: &http.Client{Timeout: publishTimeout}}
+}
+
+// PublishTimeout allows every attempt its own publishTimeout, plus the waits between them.
+func (w *Webhook) PublishTimeout() time.Duration {
+	d := time.Duration(w.config.Retries+1) * publishTimeout
+	for n := range w.config.Retries {
+		d += w.config.RetryDelay(n)
+	}
+	return d
+}
+
+// fullPayload is the default webhook body.
+type fullPayload struct {
+	Event   string        `json:"event"`
+	Time    time.Time     `json:"time"`
+	Guild   *idRef        `json:"guild,omitempty"` // Absent in DMs
+	Channel idRef         `json:"channel"`
+	Message messageRef    `json:"message"`
+	User    idRef         `json:"user"`
+	Emoji   *emojiPayload `json:"emoji,omitempty"` // Only for reaction events
+
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+type idRef struct {
+	ID string `json:"id"`
+}
+
+type messageRef struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+type emojiPayload struct {
+	Name string `json:"name"` // As written in messages, e.g. <:skull:123> for custom emojis
+}
+
+// simplePayload is a flat body with string values only, which low-code
+// automation platforms map without a transformation step. value1 to value3
+// are the fields IFTTT's webhook trigger passes on.
+type simplePayload struct {
+	Event      string `json:"event"`
+	Time       string `json:"time"`
+	GuildID    string `json:"guild_id"`
+	ChannelID  string `json:"channel_id"`
+	MessageID  string `json:"message_id"`
+	MessageURL string `json:"message_url"`
+	UserID     string `json:"user_id"`
+	Emoji      string `json:"emoji"`
+	Text       string `json:"text"`
+	Value1     string `json:"value1"` // Event
+	Value2     string `json:"value2"` // User ID
+	Value3     string `json:"value3"` // Message URL
+
+	CorrelationID string `json:"correlation_id"`
+}
+
+// payload renders e in the webhook's format.
+func (w *Webhook) payload(e Event) []byte {
+	var body any
+	if w.config.Format == config.WebhookSimple {
+		body = simplePayload{
+			Event:      e.Type,
+			Time:       e.Time.UTC().Format(time.RFC3339),
+			GuildID:    e.GuildID,
+			ChannelID:  e.ChannelID,
+			MessageID:  e.MessageID,
+			MessageURL: messageURL(e),
+			UserID:     e.UserID,
+			Emoji:      e.Emoji,
+			Text:       describe(e),
+			Value1:     e.Type,
+			Value2:     e.UserID,
+			Value3:     messageURL(e),
+
+			CorrelationID: e.CorrelationID,
+		}
+	} else {
+		p := fullPayload{
+			Event:   e.Type,
+			Time:    e.Time.UTC(),
+			Channel: idRef{e.ChannelID},
+			Message: messageRef{ID: e.MessageID, URL: messageURL(e)},
+			User:    idRef{e.UserID},
+
+			CorrelationID: e.CorrelationID,
+		}
+		if e.GuildID != "" {
+			p.Guild = &idRef{e.GuildID}
+		}
+		if e.Emoji != "" {
+			p.Emoji = &emojiPayload{Name: e.Emoji}
+		}
+		body = p
+	}
+	data, _ := json.Marshal(body) // Cannot fail for these field types
+	return data
+}
+
+// errPermanent marks delivery failures that a retry would not fix.
+var errPermanent = errors.New("permanent failure")
+
+// Publish POSTs e to the endpoint if it subscribes to e's type. Network
+// errors, rate limiting, and server errors are retried.
+func (w *Webhook) Publish(ctx context.Context, e Event) error {
+	if !w.config.Wants(e.Type) {
+		return nil
+	}
+	body := w.payload(e)
+	for n := 0; ; n++ {
+		err := w.send(ctx, body)
+		if err == nil || errors.Is(err, errPermanent) || n == w.config.Retries {
+			return err
+		}
+		select {
+		case <-time.After(w.config.RetryDelay(n)):
+		case <-ctx.Done():
+			return fmt.Errorf("%w (gave up retrying: %w)", err, ctx.Err())
+		}
+	}
+}
+
+// send makes one delivery attempt.
+func (w *Webhook) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w: %w", errPermanent, err)
+	}
+	for name, value := range w.config.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "jolly-okurb")
+	if w.config.Secret != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(timestampHeader, ts)
+		req.Header.Set(signatureHeader, sign(w.config.Secret, ts, body))
+	}
+
+	resp, err := w.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("failed to send webhook: %s", resp.Status)
+	default:
+		return fmt.Errorf("failed to send webhook: %w: %s", errPermanent, resp.Status)
+	}
+}
+
+// sign returns the signature header value of body sent at timestamp ts.
+func sign(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (w *Webhook) Close() error {
+	w.http.CloseIdleConnections()
+	return nil
+}
+
+// messageURL links to the message e is about.
+func messageURL(e Event) string {
+	guild := e.GuildID
+	if guild == "" {
+		guild = "@me"
+	}
+	return "https://discord.com/channels/" + guild + "/" + e.ChannelID + "/" + e.MessageID
+}
+
+// describe summarizes e in a sentence.
+func describe(e Event) string {
+	switch e.Type {
+	case ReactionReplaced:
+		return fmt.Sprintf("Replaced a %s reaction by %s", e.Emoji, e.UserID)
+	case MessageDeleted:
+		return fmt.Sprintf("Deleted a skull-only message by %s", e.UserID)
+	}
+	return e.Type
+}
@@ -0,0 +1,235 @@
+package events
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"jolly-okurb/internal/config"
+)
+
+func TestWebhook_Publish(t *testing.T) {
+	e := Event{
+		Type:      ReactionReplaced,
+		Time:      time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		GuildID:   "g1",
+		ChannelID: "c1",
+		MessageID: "m1",
+		UserID:    "u1",
+		Emoji:     "💀",
+
+		CorrelationID: "abc",
+	}
+	tests := []struct {
+		name   string
+		format string
+		want   map[string]any
+	}{
+		{
+			name:   "full",
+			format: config.WebhookFull,
+			want: map[string]any{
+				"event":   "reaction.replaced",
+				"time":    "2026-03-01T12:00:00Z",
+				"guild":   map[string]any{"id": "g1"},
+				"channel": map[string]any{"id": "c1"},
+				"message": map[string]any{"id": "m1", "url": "https://discord.com/channels/g1/c1/m1"},
+				"user":    map[string]any{"id": "u1"},
+				"emoji":   map[string]any{"name": "💀"},
+
+				"correlation_id": "abc",
+			},
+		},
+		{
+			name:   "simple",
+			format: config.WebhookSimple,
+			want: map[string]any{
+				"event":       "reaction.replaced",
+				"time":        "2026-03-01T12:00:00Z",
+				"guild_id":    "g1",
+				"channel_id":  "c1",
+				"message_id":  "m1",
+				"message_url": "https://discord.com/channels/g1/c1/m1",
+				"user_id":     "u1",
+				"emoji":       "💀",
+				"text":        "Replaced a 💀 reaction by u1",
+				"value1":      "reaction.replaced",
+				"value2":      "u1",
+				"value3":      "https://discord.com/channels/g1/c1/m1",
+
+				"correlation_id": "abc",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]any
+			var contentType string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contentType = r.Header.Get("Content-Type")
+				body, _ := io.ReadAll(r.Body)
+				json.Unmarshal(body, &got)
+			}))
+			defer srv.Close()
+
+			hook := NewWebhook(config.Webhook{URL: srv.URL, Format: tt.format})
+			defer hook.Close()
+			if err := hook.Publish(context.Background(), e); err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+			if contentType != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", contentType)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("payload = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhook_PublishFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	hook := NewWebhook(config.Webhook{URL: srv.URL, Format: config.WebhookFull})
+	err := hook.Publish(context.Background(), Event{Type: MessageDeleted})
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("Publish() error = %v, want the 502 status", err)
+	}
+}
+
+func TestMessageURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    Event
+		expected string
+	}{
+		{"guild", Event{GuildID: "g", ChannelID: "c", MessageID: "m"}, "https://discord.com/channels/g/c/m"},
+		{"direct message", Event{ChannelID: "c", MessageID: "m"}, "https://discord.com/channels/@me/c/m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := messageURL(tt.event); got != tt.expected {
+				t.Errorf("messageURL() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWebhook_Filtering(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer srv.Close()
+
+	hook := NewWebhook(config.Webhook{URL: srv.URL, Events: []string{MessageDeleted}})
+	hook.Publish(context.Background(), Event{Type: ReactionReplaced})
+	hook.Publish(context.Background(), Event{Type: MessageDeleted})
+	if got := calls.Load(); got != 1 {
+		t.Errorf("endpoint called %d times, want 1 for the subscribed event only", got)
+	}
+}
+
+func TestWebhook_SigningAndHeaders(t *testing.T) {
+	var req *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	hook := NewWebhook(config.Webhook{
+		URL:     srv.URL,
+		Secret:  "s3cr3t",
+		Headers: map[string]string{"Authorization": "Bearer x", "Content-Type": "text/plain"},
+	})
+	if err := hook.Publish(context.Background(), Event{Type: MessageDeleted}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte("s3cr3t"))
+	mac.Write([]byte(req.Header.Get(timestampHeader) + "."))
+	mac.Write(body)
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{signatureHeader, "sha256=" + hex.EncodeToString(mac.Sum(nil))},
+		{"Authorization", "Bearer x"},
+		{"Content-Type", "application/json"}, // Not overridable, since the body is always JSON
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := req.Header.Get(tt.header); got != tt.expected {
+				t.Errorf("%s = %q, want %q", tt.header, got, tt.expected)
+			}
+		})
+	}
+	if ts := req.Header.Get(timestampHeader); ts == "" {
+		t.Errorf("%s is missing", timestampHeader)
+	}
+}
+
+func TestWebhook_Retries(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int // Responses in order; the last repeats
+		retries   int
+		wantCalls int
+		wantErr   bool
+	}{
+		{"succeeds first time", []int{200}, 3, 1, false},
+		{"retries server errors", []int{500, 503, 204}, 3, 3, false},
+		{"retries rate limiting", []int{429, 200}, 3, 2, false},
+		{"gives up after retries", []int{500}, 2, 3, true},
+		{"no retries by default", []int{500}, 0, 1, true},
+		{"client errors are permanent", []int{400}, 3, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(calls.Add(1)) - 1
+				w.WriteHeader(tt.statuses[min(n, len(tt.statuses)-1)])
+			}))
+			defer srv.Close()
+
+			hook := NewWebhook(config.Webhook{URL: srv.URL, Retries: tt.retries}) // No backoff outside config, so retries are immediate
+			err := hook.Publish(context.Background(), Event{Type: MessageDeleted})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Publish() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := int(calls.Load()); got != tt.wantCalls {
+				t.Errorf("endpoint called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWebhook_PublishTimeout(t *testing.T) {
+	hook := NewWebhook(config.Webhook{URL: "https://example.com", Retries: 2})
+	if got, want := hook.PublishTimeout(), 3*publishTimeout; got != want {
+		t.Errorf("PublishTimeout() = %v, want %v for three attempts", got, want)
+	}
+}
+
+func TestWebhookEvents(t *testing.T) {
+	if want := []string{ReactionReplaced, MessageDeleted}; !slices.Equal(config.WebhookEvents, want) {
+		t.Errorf("config.WebhookEvents = %v, want the event types %v", config.WebhookEvents, want)
+	}
+}
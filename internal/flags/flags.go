@@ -0,0 +1,127 @@
+// Package flags gates risky features per guild, so they can be rolled out to
+// one server at a time. Each flag has a default, and a guild can override it
+// either way. Overrides are kept in the store and cached in memory.
+package flags
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"jolly-okurb/internal/store"
+)
+
+// Flag names a feature that can be rolled out one guild at a time.
+type Flag string
+
+const (
+	SoftDelete  Flag = "soft_delete"  // Warn before deleting skull-only messages, per SOFT_DELETE_DELAY
+	Escalation  Flag = "escalation"   // Take the ESCALATION actions
+	AutoExclude Flag = "auto_exclude" // Exclude emojis learned from undos without asking, per UNDO_EXCLUDE_AUTO
+)
+
+// All lists every flag in display order.
+var All = []Flag{SoftDelete, Escalation, AutoExclude}
+
+// Parse validates a flag name.
+func Parse(name string) (Flag, error) {
+	f := Flag(name)
+	if !slices.Contains(All, f) {
+		return "", fmt.Errorf("unknown feature flag %q", name)
+	}
+	return f, nil
+}
+
+// Set holds the flags of every guild.
+type Set struct {
+	store    store.Store
+	defaults map[Flag]bool
+	mu       sync.RWMutex
+	cache    map[string]map[Flag]bool // Overrides by guild ID
+	updateMu sync.Mutex               // Serializes read-modify-write cycles
+}
+
+// New returns a Set that keeps overrides in s and has the flags in defaults
+// on in guilds without an override.
+func New(s store.Store, defaults ...Flag) *Set {
+	set := &Set{store: s, defaults: make(map[Flag]bool), cache: make(map[string]map[Flag]bool)}
+	for _, f := range defaults {
+		set.defaults[f] = true
+	}
+	return set
+}
+
+// Default reports whether f is on in guilds that do not override it.
+func (s *Set) Default(f Flag) bool {
+	return s.defaults[f]
+}
+
+// Enabled reports whether f is on in guildID: its override if it has one,
+// else the default.
+func (s *Set) Enabled(guildID string, f Flag) (bool, error) {
+	overrides, err := s.Overrides(guildID)
+	if err != nil {
+		return false, err
+	}
+	if on, ok := overrides[f]; ok {
+		return on, nil
+	}
+	return s.Default(f), nil
+}
+
+// Overrides returns the flags guildID overrides. The map must not be
+// modified.
+func (s *Set) Overrides(guildID string) (map[Flag]bool, error) {
+	s.mu.RLock()
+	overrides, ok := s.cache[guildID]
+	s.mu.RUnlock()
+	if ok {
+		return overrides, nil
+	}
+
+	if _, err := s.store.Get(key(guildID), &overrides); err != nil {
+		return nil, fmt.Errorf("failed to load feature flags: %w", err)
+	}
+
+	s.mu.Lock()
+	s.cache[guildID] = overrides
+	s.mu.Unlock()
+	return overrides, nil
+}
+
+// Override switches f on or off in guildID, or makes it follow the default
+// again if value is nil, and persists the change.
+func (s *Set) Override(guildID string, f Flag, value *bool) error {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
+	overrides, err := s.Overrides(guildID)
+	if err != nil {
+		return err
+	}
+
+	// Copy so readers holding the previous map never see a partial update
+	overrides = maps.Clone(overrides)
+	if value == nil {
+		delete(overrides, f)
+	} else {
+		if overrides == nil {
+			overrides = make(map[Flag]bool)
+		}
+		overrides[f] = *value
+	}
+
+	if err := s.store.Put(key(guildID), overrides); err != nil {
+		return fmt.Errorf("failed to save feature flags: %w", err)
+	}
+
+	s.mu.Lock()
+	s.cache[guildID] = overrides
+	s.mu.Unlock()
+	return nil
+}
+
+func key(guildID string) string {
+	return "guilds/" + guildID + "/flags"
+}
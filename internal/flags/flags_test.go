@@ -0,0 +1,96 @@
+package flags
+
+import (
+	"testing"
+
+	"jolly-okurb/internal/store"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Flag
+		wantErr bool
+	}{
+		{"soft_delete", SoftDelete, false},
+		{"escalation", Escalation, false},
+		{"auto_exclude", AutoExclude, false},
+		{"reactions", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	on, off := true, false
+	enabled := func(t *testing.T, s *Set, guildID string, f Flag) bool {
+		t.Helper()
+		got, err := s.Enabled(guildID, f)
+		if err != nil {
+			t.Fatalf("Enabled() unexpected error: %v", err)
+		}
+		return got
+	}
+
+	t.Run("off unless on by default", func(t *testing.T) {
+		s := New(store.NewMemory(), Escalation)
+		if enabled(t, s, "guild-1", SoftDelete) {
+			t.Error("soft_delete should be off without a default")
+		}
+		if !enabled(t, s, "guild-1", Escalation) {
+			t.Error("escalation should follow its default")
+		}
+	})
+
+	t.Run("overrides are per guild", func(t *testing.T) {
+		s := New(store.NewMemory(), Escalation)
+		if err := s.Override("guild-1", SoftDelete, &on); err != nil {
+			t.Fatalf("Override() unexpected error: %v", err)
+		}
+		if err := s.Override("guild-1", Escalation, &off); err != nil {
+			t.Fatalf("Override() unexpected error: %v", err)
+		}
+
+		if !enabled(t, s, "guild-1", SoftDelete) || enabled(t, s, "guild-1", Escalation) {
+			t.Error("guild-1 should have its overrides")
+		}
+		if enabled(t, s, "guild-2", SoftDelete) || !enabled(t, s, "guild-2", Escalation) {
+			t.Error("guild-2 should follow the defaults")
+		}
+	})
+
+	t.Run("inherit removes the override", func(t *testing.T) {
+		s := New(store.NewMemory())
+		s.Override("guild-1", SoftDelete, &on)
+		before, _ := s.Overrides("guild-1")
+
+		if err := s.Override("guild-1", SoftDelete, nil); err != nil {
+			t.Fatalf("Override() unexpected error: %v", err)
+		}
+
+		if enabled(t, s, "guild-1", SoftDelete) {
+			t.Error("soft_delete should follow the default again")
+		}
+		if !before[SoftDelete] {
+			t.Error("a map returned earlier should not change")
+		}
+	})
+
+	t.Run("persists through the store", func(t *testing.T) {
+		st := store.NewMemory()
+		if err := New(st).Override("guild-1", AutoExclude, &on); err != nil {
+			t.Fatalf("Override() unexpected error: %v", err)
+		}
+		if !enabled(t, New(st), "guild-1", AutoExclude) {
+			t.Error("the override should survive a restart")
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+)
+
+// DefaultLocale is used for guilds without a locale and for any message a
+// catalog does not translate.
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var catalogFiles embed.FS
+
+// catalogs maps locales to their messages by key.
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+	files, err := catalogFiles.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("failed to read message catalogs: %v", err))
+	}
+
+	catalogs := make(map[string]map[string]string, len(files))
+	for _, f := range files {
+		data, err := catalogFiles.ReadFile(path.Join("locales", f.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("failed to read message catalog %s: %v", f.Name(), err))
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("failed to parse message catalog %s: %v", f.Name(), err))
+		}
+		catalogs[strings.TrimSuffix(f.Name(), ".json")] = messages
+	}
+	return catalogs
+}
+
+// Locales returns the supported locales in a stable order.
+func Locales() []string {
+	return slices.Sorted(maps.Keys(catalogs))
+}
+
+// Supported reports whether there is a catalog for locale.
+func Supported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// Printer formats messages in one locale.
+type Printer struct {
+	locale string
+}
+
+// For returns a Printer for locale, or for DefaultLocale if it is unsupported.
+func For(locale string) Printer {
+	if !Supported(locale) {
+		locale = DefaultLocale
+	}
+	return Printer{locale: locale}
+}
+
+// Locale returns the printer's locale.
+func (p Printer) Locale() string {
+	if p.locale == "" {
+		return DefaultLocale
+	}
+	return p.locale
+}
+
+// T formats the message for key with args, as fmt.Sprintf does. Messages
+// missing from the locale's catalog fall back to English, and unknown keys
+// are returned as is so they stand out.
+func (p Printer) T(key string, args ...any) string {
+	msg, ok := catalogs[p.Locale()][key]
+	if !ok {
+		msg, ok = catalogs[DefaultLocale][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
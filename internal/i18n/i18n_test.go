@@ -0,0 +1,68 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestPrinter_T(t *testing.T) {
+	tests := []struct {
+		name   string
+		locale string
+		key    string
+		args   []any
+		want   string
+	}{
+		{"english", "en", "status.monitoring", []any{2}, "Monitoring 2 channel(s):"},
+		{"translated", "nl", "toggle.on", nil, "aan"},
+		{"unsupported locale falls back to english", "xx", "toggle.on", nil, "on"},
+		{"empty locale is english", "", "toggle.off", nil, "off"},
+		{"unknown key is returned as is", "nl", "no.such.key", nil, "no.such.key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := For(tt.locale).T(tt.key, tt.args...); got != tt.want {
+				t.Errorf("T(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocales(t *testing.T) {
+	if !slices.Contains(Locales(), DefaultLocale) {
+		t.Fatalf("Locales() = %v, should include %q", Locales(), DefaultLocale)
+	}
+	if For("xx").Locale() != DefaultLocale {
+		t.Error("unsupported locales should use the default")
+	}
+}
+
+// verbPattern matches fmt verbs so translations can be checked against English.
+var verbPattern = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+
+func TestCatalogsMatchEnglish(t *testing.T) {
+	english := catalogs[DefaultLocale]
+	for _, locale := range Locales() {
+		messages := catalogs[locale]
+		if messages["language.name"] == "" {
+			t.Errorf("%s: missing language.name", locale)
+		}
+		for key, msg := range messages {
+			want, ok := english[key]
+			if !ok {
+				t.Errorf("%s: %q is not in the English catalog", locale, key)
+				continue
+			}
+			if got, want := verbPattern.FindAllString(msg, -1), verbPattern.FindAllString(want, -1); !slices.Equal(got, want) {
+				t.Errorf("%s: %q uses verbs %v, English uses %v", locale, key, got, want)
+			}
+		}
+		for key := range english {
+			if _, ok := messages[key]; !ok {
+				t.Errorf("%s: missing translation for %q", locale, key)
+			}
+		}
+	}
+}
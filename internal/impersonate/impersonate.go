@@ -0,0 +1,123 @@
+// Package impersonate posts messages under other users' names and avatars,
+// through a webhook per channel that it creates once and reuses.
+package impersonate
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxUsername is the longest name Discord accepts for a webhook message.
+const maxUsername = 80
+
+// forbiddenNames are substrings Discord rejects in webhook message names.
+var forbiddenNames = []string{"discord", "clyde"}
+
+// API is the part of the Discord API that webhooks are managed through.
+type API interface {
+	ChannelWebhooks(channelID string, options ...discordgo.RequestOption) ([]*discordgo.Webhook, error)
+	WebhookCreate(channelID, name, avatar string, options ...discordgo.RequestOption) (*discordgo.Webhook, error)
+	WebhookExecute(webhookID, token string, wait bool, data *discordgo.WebhookParams, options ...discordgo.RequestOption) (*discordgo.Message, error)
+}
+
+// Identity is who a message is posted as.
+type Identity struct {
+	Name      string
+	AvatarURL string
+}
+
+// Webhooks posts through webhooks it owns, one per channel. Webhooks are
+// found again by name, so the name should be the same across restarts.
+type Webhooks struct {
+	name string
+
+	mu    sync.Mutex // Guards hooks, and serializes finding or creating them
+	hooks map[string]*discordgo.Webhook
+}
+
+// New returns Webhooks that creates its webhooks with name.
+func New(name string) *Webhooks {
+	return &Webhooks{name: name, hooks: make(map[string]*discordgo.Webhook)}
+}
+
+// Post posts params to channelID as the given identity and returns the
+// message. Names Discord would reject are replaced with the webhook's own
+// name. If the webhook was deleted, the next post creates another.
+func (w *Webhooks) Post(api API, channelID string, as Identity, params *discordgo.WebhookParams) (*discordgo.Message, error) {
+	hook, err := w.webhook(api, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := *params
+	p.Username, p.AvatarURL = username(as.Name), as.AvatarURL
+	msg, err := api.WebhookExecute(hook.ID, hook.Token, true, &p)
+	if err != nil {
+		if isNotFound(err) {
+			w.mu.Lock()
+			if w.hooks[channelID] == hook {
+				delete(w.hooks, channelID)
+			}
+			w.mu.Unlock()
+		}
+		return nil, fmt.Errorf("failed to post through webhook: %w", err)
+	}
+	return msg, nil
+}
+
+// webhook returns the webhook in channelID, finding or creating it the first
+// time. Only webhooks created by the bot come with the token needed to
+// execute them, which tells them apart from others' of the same name.
+func (w *Webhooks) webhook(api API, channelID string) (*discordgo.Webhook, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if hook, ok := w.hooks[channelID]; ok {
+		return hook, nil
+	}
+
+	hooks, err := api.ChannelWebhooks(channelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	var hook *discordgo.Webhook
+	for _, h := range hooks {
+		if h.Name == w.name && h.Token != "" {
+			hook = h
+			break
+		}
+	}
+	if hook == nil {
+		if hook, err = api.WebhookCreate(channelID, w.name, ""); err != nil {
+			return nil, fmt.Errorf("failed to create webhook: %w", err)
+		}
+	}
+	w.hooks[channelID] = hook
+	return hook, nil
+}
+
+// username returns name as Discord accepts it for a webhook message, or
+// empty for the webhook's own name.
+func username(name string) string {
+	name = strings.TrimSpace(name)
+	lower := strings.ToLower(name)
+	for _, f := range forbiddenNames {
+		if strings.Contains(lower, f) {
+			return ""
+		}
+	}
+	if r := []rune(name); len(r) > maxUsername {
+		name = string(r[:maxUsername])
+	}
+	return name
+}
+
+// isNotFound reports whether err is a Discord 404, such as for a deleted webhook.
+func isNotFound(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,160 @@
+package impersonate
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fakeAPI keeps webhooks by channel and records executions.
+type fakeAPI struct {
+	mu         sync.Mutex
+	hooks      []*discordgo.Webhook
+	created    int
+	executed   []*discordgo.WebhookParams
+	executeErr error
+}
+
+func (f *fakeAPI) ChannelWebhooks(channelID string, options ...discordgo.RequestOption) ([]*discordgo.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var hooks []*discordgo.Webhook
+	for _, h := range f.hooks {
+		if h.ChannelID == channelID {
+			hooks = append(hooks, h)
+		}
+	}
+	return hooks, nil
+}
+
+func (f *fakeAPI) WebhookCreate(channelID, name, avatar string, options ...discordgo.RequestOption) (*discordgo.Webhook, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.created++
+	h := &discordgo.Webhook{ID: fmt.Sprintf("hook%d", f.created), ChannelID: channelID, Name: name, Token: "token"}
+	f.hooks = append(f.hooks, h)
+	return h, nil
+}
+
+func (f *fakeAPI) WebhookExecute(webhookID, token string, wait bool, data *discordgo.WebhookParams, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.executeErr != nil {
+		return nil, f.executeErr
+	}
+	f.executed = append(f.executed, data)
+	return &discordgo.Message{ID: "msg", WebhookID: webhookID, Content: data.Content}, nil
+}
+
+func TestWebhooks_Post(t *testing.T) {
+	tests := []struct {
+		name     string
+		hooks    []*discordgo.Webhook
+		channels []string
+		wantHook string // Webhook the last post went through
+		wantNew  int
+	}{
+		{name: "creates webhook", channels: []string{"c1"}, wantHook: "hook1", wantNew: 1},
+		{name: "reuses webhook", channels: []string{"c1", "c1"}, wantHook: "hook1", wantNew: 1},
+		{name: "one webhook per channel", channels: []string{"c1", "c2"}, wantHook: "hook2", wantNew: 2},
+		{
+			name:     "finds own webhook",
+			hooks:    []*discordgo.Webhook{{ID: "old", ChannelID: "c1", Name: "jolly", Token: "t"}},
+			channels: []string{"c1"},
+			wantHook: "old",
+		},
+		{
+			name: "ignores others' webhooks",
+			hooks: []*discordgo.Webhook{
+				{ID: "theirs", ChannelID: "c1", Name: "jolly"}, // Without the token
+				{ID: "other", ChannelID: "c1", Name: "other", Token: "t"},
+			},
+			channels: []string{"c1"},
+			wantHook: "hook1",
+			wantNew:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeAPI{hooks: tt.hooks}
+			w := New("jolly")
+			var msg *discordgo.Message
+			for _, c := range tt.channels {
+				var err error
+				msg, err = w.Post(api, c, Identity{Name: "Skully", AvatarURL: "https://cdn/a.png"}, &discordgo.WebhookParams{Content: "💀"})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if msg.WebhookID != tt.wantHook {
+				t.Errorf("posted through %q, want %q", msg.WebhookID, tt.wantHook)
+			}
+			if api.created != tt.wantNew {
+				t.Errorf("created %d webhooks, want %d", api.created, tt.wantNew)
+			}
+			p := api.executed[len(api.executed)-1]
+			if p.Content != "💀" || p.Username != "Skully" || p.AvatarURL != "https://cdn/a.png" {
+				t.Errorf("posted %+v, want 💀 as Skully with avatar", p)
+			}
+		})
+	}
+}
+
+func TestWebhooks_Post_DeletedWebhook(t *testing.T) {
+	api := &fakeAPI{}
+	w := New("jolly")
+	if _, err := w.Post(api, "c1", Identity{}, &discordgo.WebhookParams{}); err != nil {
+		t.Fatal(err)
+	}
+
+	api.hooks = nil
+	api.executeErr = &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusNotFound}}
+	if _, err := w.Post(api, "c1", Identity{}, &discordgo.WebhookParams{}); err == nil {
+		t.Fatal("Post() through a deleted webhook succeeded")
+	}
+	api.executeErr = nil
+	msg, err := w.Post(api, "c1", Identity{}, &discordgo.WebhookParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.WebhookID != "hook2" {
+		t.Errorf("posted through %q, want a new webhook", msg.WebhookID)
+	}
+}
+
+func TestWebhooks_Post_Error(t *testing.T) {
+	api := &fakeAPI{executeErr: errors.New("HTTP 403 Forbidden")}
+	w := New("jolly")
+	for range 2 {
+		if _, err := w.Post(api, "c1", Identity{}, &discordgo.WebhookParams{}); err == nil {
+			t.Fatal("Post() succeeded")
+		}
+	}
+	if api.created != 1 {
+		t.Errorf("created %d webhooks, want the first kept after an error other than 404", api.created)
+	}
+}
+
+func TestUsername(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Skully", "Skully"},
+		{"  Skully ", "Skully"},
+		{"DiscordFan", ""},
+		{"clyde", ""},
+		{strings.Repeat("💀", 100), strings.Repeat("💀", 80)},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := username(tt.name); got != tt.want {
+			t.Errorf("username(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
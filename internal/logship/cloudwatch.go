@@ -0,0 +1,239 @@
+package logship
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credentials are the AWS access keys requests are signed with.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Only for temporary credentials
+}
+
+// CloudWatch puts entries into a CloudWatch Logs stream, creating the stream
+// on first use. The log group must exist.
+type CloudWatch struct {
+	endpoint string
+	region   string
+	group    string
+	stream   string
+	creds    Credentials
+	http     *http.Client
+	now      func() time.Time
+
+	mu      sync.Mutex
+	created bool // Whether the stream is known to exist
+}
+
+// NewCloudWatch returns a client for the given log group and stream.
+func NewCloudWatch(region, group, stream string, creds Credentials) *CloudWatch {
+	return &CloudWatch{
+		endpoint: "https://logs." + region + ".amazonaws.com/",
+		region:   region,
+		group:    group,
+		stream:   stream,
+		creds:    creds,
+		http:     &http.Client{Timeout: requestTimeout},
+		now:      time.Now,
+	}
+}
+
+type cloudWatchEvent struct {
+	Timestamp int64  `json:"timestamp"` // Unix milliseconds
+	Message   string `json:"message"`
+}
+
+type cloudWatchError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+
+	permanent bool
+}
+
+func (c *CloudWatch) Push(ctx context.Context, batch []Entry) error {
+	if err := c.createStream(ctx); err != nil {
+		return err
+	}
+	events := make([]cloudWatchEvent, len(batch))
+	for i, e := range batch {
+		events[i] = cloudWatchEvent{Timestamp: e.Time.UnixMilli(), Message: e.Line}
+	}
+	err := c.call(ctx, "PutLogEvents", map[string]any{
+		"logGroupName":  c.group,
+		"logStreamName": c.stream,
+		"logEvents":     events,
+	})
+	if isCloudWatchError(err, "ResourceNotFoundException") {
+		// The stream was deleted; create it again on the retry
+		c.mu.Lock()
+		c.created = false
+		c.mu.Unlock()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to put log events: %w", err)
+	}
+	return nil
+}
+
+// createStream creates the log stream unless it is known to exist.
+func (c *CloudWatch) createStream(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.created {
+		return nil
+	}
+	err := c.call(ctx, "CreateLogStream", map[string]any{
+		"logGroupName":  c.group,
+		"logStreamName": c.stream,
+	})
+	if isCloudWatchError(err, "ResourceAlreadyExistsException") {
+		err = nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create log stream: %w", err)
+	}
+	c.created = true
+	return nil
+}
+
+// call makes a signed request to the CloudWatch Logs action. Service errors
+// are returned as a *cloudWatchError, wrapping errPermanent unless a retry
+// may succeed.
+func (c *CloudWatch) call(ctx context.Context, action string, params any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errPermanent, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %w", errPermanent, err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Logs_20140328."+action)
+	signV4(req, body, c.creds, c.region, "logs", c.now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+
+	apiErr := &cloudWatchError{}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, apiErr) != nil || apiErr.Type == "" {
+		apiErr.Type = resp.Status
+	}
+	// Types may be prefixed with a namespace, as in "com.amazonaws...#Type"
+	if _, t, ok := strings.Cut(apiErr.Type, "#"); ok {
+		apiErr.Type = t
+	}
+	apiErr.permanent = resp.StatusCode < 500 && !slices.Contains(retryableErrors, apiErr.Type)
+	return apiErr
+}
+
+// isCloudWatchError reports whether err is a CloudWatch Logs error of type typ.
+func isCloudWatchError(err error, typ string) bool {
+	var apiErr *cloudWatchError
+	return errors.As(err, &apiErr) && apiErr.Type == typ
+}
+
+// retryableErrors are the CloudWatch Logs client errors a retry may fix.
+var retryableErrors = []string{
+	"ThrottlingException",
+	"ServiceUnavailableException",
+	"ResourceNotFoundException", // After the stream was deleted, as Push creates it again
+	"429 Too Many Requests",
+}
+
+func (e *cloudWatchError) Error() string {
+	if e.Message == "" {
+		return e.Type
+	}
+	return e.Type + ": " + e.Message
+}
+
+func (e *cloudWatchError) Unwrap() error {
+	if e.permanent {
+		return errPermanent
+	}
+	return nil
+}
+
+// signV4 signs req, whose body is body, with AWS Signature Version 4. Every
+// header set on req is signed, along with Host.
+func signV4(req *http.Request, body []byte, creds Credentials, region, service string, t time.Time) {
+	amzDate := t.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hexSHA256(body),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	for _, part := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,96 @@
+package logship
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignV4(t *testing.T) {
+	// The example request from the AWS Signature Version 4 documentation
+	req, _ := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	creds := Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signV4(req, nil, creds, "us-east-1", "iam", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-date, " +
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Errorf("X-Amz-Date = %q, want 20150830T123600Z", got)
+	}
+}
+
+func TestCloudWatch_Push(t *testing.T) {
+	tests := []struct {
+		name          string
+		create        string // Error type of CreateLogStream (empty = success)
+		put           string // Error type of PutLogEvents (empty = success)
+		putStatus     int
+		wantErr       bool
+		wantPermanent bool
+		wantCreated   bool
+	}{
+		{name: "new stream", wantCreated: true},
+		{name: "existing stream", create: "ResourceAlreadyExistsException", wantCreated: true},
+		{name: "throttled", put: "ThrottlingException", putStatus: http.StatusBadRequest, wantErr: true, wantCreated: true},
+		{name: "stream deleted", put: "ResourceNotFoundException", putStatus: http.StatusBadRequest, wantErr: true},
+		{name: "access denied", put: "AccessDeniedException", putStatus: http.StatusBadRequest, wantErr: true, wantPermanent: true, wantCreated: true},
+		{name: "server error", put: "InternalFailure", putStatus: http.StatusInternalServerError, wantErr: true, wantCreated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var actions []string
+			var put struct {
+				LogGroupName  string            `json:"logGroupName"`
+				LogStreamName string            `json:"logStreamName"`
+				LogEvents     []cloudWatchEvent `json:"logEvents"`
+			}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") ||
+					r.Header.Get("X-Amz-Security-Token") != "token" {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				action := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "Logs_20140328.")
+				actions = append(actions, action)
+				errType, status := tt.create, http.StatusBadRequest
+				if action == "PutLogEvents" {
+					json.NewDecoder(r.Body).Decode(&put)
+					errType, status = tt.put, tt.putStatus
+				}
+				if errType != "" {
+					w.WriteHeader(status)
+					json.NewEncoder(w).Encode(cloudWatchError{Type: "com.amazonaws.logs#" + errType, Message: "nope"})
+				}
+			}))
+			defer srv.Close()
+
+			c := NewCloudWatch("eu-west-1", "bots", "jolly-okurb", Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "token"})
+			c.endpoint = srv.URL + "/"
+			err := c.Push(context.Background(), []Entry{{Time: time.UnixMilli(1700000000123), Line: "replaced"}})
+
+			if (err != nil) != tt.wantErr || errors.Is(err, errPermanent) != tt.wantPermanent {
+				t.Fatalf("Push() error = %v, want error %v (permanent %v)", err, tt.wantErr, tt.wantPermanent)
+			}
+			if strings.Join(actions, ",") != "CreateLogStream,PutLogEvents" {
+				t.Errorf("actions = %v, want the stream created before the put", actions)
+			}
+			if put.LogGroupName != "bots" || put.LogStreamName != "jolly-okurb" ||
+				len(put.LogEvents) != 1 || put.LogEvents[0] != (cloudWatchEvent{Timestamp: 1700000000123, Message: "replaced"}) {
+				t.Errorf("put = %+v, want the entry in bots/jolly-okurb", put)
+			}
+			if c.created != tt.wantCreated {
+				t.Errorf("created = %v, want %v", c.created, tt.wantCreated)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+// Package logship ships log records straight to a log service, such as Loki
+// or CloudWatch Logs, for deployments without a log collector next to the
+// bot. Records are batched and failed batches are retried.
+package logship
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"jolly-okurb/internal/logx"
+)
+
+// Batching: a batch is sent every flushInterval, or sooner once it holds
+// batchSize records or batchBytes of them.
+const (
+	flushInterval = 5 * time.Second
+	batchSize     = 500
+	batchBytes    = 512 << 10
+)
+
+// maxPending bounds the records waiting while the service is unreachable.
+// Further records are dropped so that an outage cannot exhaust memory.
+const maxPending = 10000
+
+// Retry policy for failed batches: exponential backoff from retryDelay,
+// giving up after retries more attempts.
+const (
+	retries    = 4
+	retryDelay = time.Second
+)
+
+// closeTimeout bounds how long Close spends sending the last records.
+const closeTimeout = 10 * time.Second
+
+// requestTimeout bounds one request to a log service.
+const requestTimeout = 10 * time.Second
+
+// errPermanent marks push failures that a retry would not fix.
+var errPermanent = errors.New("permanent failure")
+
+// fallback reports shipping failures. It writes to stderr only, since failures
+// logged through the shipper would be shipped, and fail, in turn.
+var fallback = slog.New(slog.NewTextHandler(os.Stderr, nil)).With(logx.KeyComponent, logx.Logs)
+
+// Entry is one log record.
+type Entry struct {
+	Time time.Time
+	Line string
+}
+
+// Client pushes a batch of entries, in chronological order, to a log service.
+type Client interface {
+	Push(ctx context.Context, batch []Entry) error
+}
+
+// Shipper is an io.Writer that ships every write, such as a record from a
+// slog handler, to a Client as one entry.
+type Shipper struct {
+	client Client
+
+	mu      sync.Mutex
+	pending []Entry
+	size    int // Bytes in pending
+	dropped int // Entries dropped since the last report
+
+	wake    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+
+	interval   time.Duration
+	retryDelay time.Duration
+}
+
+// New starts a Shipper that pushes to client until it is closed.
+func New(client Client) *Shipper {
+	return newShipper(client, flushInterval, retryDelay)
+}
+
+func newShipper(client Client, interval, retryDelay time.Duration) *Shipper {
+	s := &Shipper{
+		client:     client,
+		wake:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+		interval:   interval,
+		retryDelay: retryDelay,
+	}
+	go s.run()
+	return s
+}
+
+// Write queues p, without its trailing newline, as one entry. It never fails;
+// entries beyond maxPending are dropped and reported once shipping recovers.
+func (s *Shipper) Write(p []byte) (int, error) {
+	line := strings.TrimSuffix(string(p), "\n")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) >= maxPending {
+		s.dropped++
+		return len(p), nil
+	}
+	s.pending = append(s.pending, Entry{Time: time.Now(), Line: line})
+	s.size += len(line)
+	if len(s.pending) >= batchSize || s.size >= batchBytes {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Close sends the queued entries and stops the shipper.
+func (s *Shipper) Close() error {
+	close(s.done)
+	<-s.stopped
+	return nil
+}
+
+func (s *Shipper) run() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.done
+		// Let the last flush finish, but not forever
+		time.AfterFunc(closeTimeout, cancel)
+	}()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-s.done:
+			s.flush(ctx)
+			return
+		}
+		s.flush(ctx)
+	}
+}
+
+// flush pushes the queued entries in batches.
+func (s *Shipper) flush(ctx context.Context) {
+	for {
+		batch, dropped := s.take()
+		if dropped > 0 {
+			fallback.Warn("log shipping fell behind, dropped records", "count", dropped)
+		}
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.push(ctx, batch); err != nil {
+			fallback.Error("failed to ship logs", "count", len(batch), "error", err)
+		}
+	}
+}
+
+// take removes the next batch from the queue, along with the count of
+// entries dropped since the last call.
+func (s *Shipper) take() ([]Entry, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, size := 0, 0
+	for n < len(s.pending) && n < batchSize && size < batchBytes {
+		size += len(s.pending[n].Line)
+		n++
+	}
+	batch := s.pending[:n:n]
+	s.pending = s.pending[n:]
+	s.size -= size
+	dropped := s.dropped
+	s.dropped = 0
+	return batch, dropped
+}
+
+// push sends batch, retrying failures that are not permanent.
+func (s *Shipper) push(ctx context.Context, batch []Entry) error {
+	for n := 0; ; n++ {
+		err := s.client.Push(ctx, batch)
+		if err == nil || errors.Is(err, errPermanent) || n == retries {
+			return err
+		}
+		select {
+		case <-time.After(s.retryDelay << n):
+		case <-ctx.Done():
+			return fmt.Errorf("%w (gave up retrying: %w)", err, ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package logship
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClient records the batches pushed to it, failing the first fails pushes.
+type fakeClient struct {
+	mu      sync.Mutex
+	batches [][]Entry
+	pushes  int
+	fails   int
+	err     error
+}
+
+func (c *fakeClient) Push(ctx context.Context, batch []Entry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pushes++
+	if c.pushes <= c.fails {
+		return c.err
+	}
+	c.batches = append(c.batches, batch)
+	return nil
+}
+
+func TestShipper(t *testing.T) {
+	tests := []struct {
+		name        string
+		client      *fakeClient
+		writes      int
+		wantPushes  int
+		wantBatches []int // Sizes of the delivered batches
+	}{
+		{"one batch", &fakeClient{}, 3, 1, []int{3}},
+		{"split at batch size", &fakeClient{}, batchSize + 1, 2, []int{batchSize, 1}},
+		{"retries failures", &fakeClient{fails: 2, err: errors.New("503 Service Unavailable")}, 2, 3, []int{2}},
+		{"gives up after retries", &fakeClient{fails: retries + 1, err: errors.New("503 Service Unavailable")}, 2, retries + 1, nil},
+		{"permanent failure", &fakeClient{fails: 1, err: fmt.Errorf("%w: 401 Unauthorized", errPermanent)}, 2, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newShipper(tt.client, time.Hour, time.Millisecond)
+			for i := range tt.writes {
+				fmt.Fprintf(s, "record %d\n", i)
+			}
+			s.Close()
+
+			if tt.client.pushes != tt.wantPushes {
+				t.Errorf("pushes = %d, want %d", tt.client.pushes, tt.wantPushes)
+			}
+			var sizes []int
+			for _, b := range tt.client.batches {
+				sizes = append(sizes, len(b))
+			}
+			if fmt.Sprint(sizes) != fmt.Sprint(tt.wantBatches) {
+				t.Errorf("batch sizes = %v, want %v", sizes, tt.wantBatches)
+			}
+			if len(tt.client.batches) > 0 && tt.client.batches[0][0].Line != "record 0" {
+				t.Errorf("first line = %q, want record 0 without its newline", tt.client.batches[0][0].Line)
+			}
+		})
+	}
+}
+
+func TestShipper_FlushesFullBatch(t *testing.T) {
+	client := &fakeClient{}
+	s := newShipper(client, time.Hour, time.Millisecond)
+	defer s.Close()
+
+	for i := range batchSize {
+		fmt.Fprintf(s, "record %d\n", i)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		client.mu.Lock()
+		n := len(client.batches)
+		client.mu.Unlock()
+		if n == 1 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("a full batch was not sent before the flush interval")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestShipper_DropsBeyondMaxPending(t *testing.T) {
+	s := &Shipper{wake: make(chan struct{}, 1)} // Not running, so nothing is sent
+	for range maxPending + 3 {
+		fmt.Fprintln(s, "record")
+	}
+	batch, dropped := s.take()
+	if len(batch) != batchSize || dropped != 3 {
+		t.Errorf("take() = %d entries, %d dropped, want %d, 3", len(batch), dropped, batchSize)
+	}
+	if _, dropped := s.take(); dropped != 0 {
+		t.Errorf("dropped = %d after it was reported, want 0", dropped)
+	}
+}
@@ -0,0 +1,88 @@
+package logship
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// lokiPushPath is the push API path used when the Loki URL has no path.
+const lokiPushPath = "/loki/api/v1/push"
+
+// Loki pushes entries to a Loki server as one stream labelled
+// service="jolly-okurb". Records are JSON, so they can be queried with
+// LogQL's json parser.
+type Loki struct {
+	url      string
+	username string
+	password string
+	http     *http.Client
+}
+
+// NewLoki returns a client for the Loki server at rawURL. Credentials in the
+// URL are sent with basic authentication, as Grafana Cloud expects.
+func NewLoki(rawURL string) (*Loki, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Loki URL: %w", err)
+	}
+	l := &Loki{http: &http.Client{Timeout: requestTimeout}}
+	if u.User != nil {
+		l.username = u.User.Username()
+		l.password, _ = u.User.Password()
+		u.User = nil
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = lokiPushPath
+	}
+	l.url = u.String()
+	return l, nil
+}
+
+type lokiPush struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"` // Unix nanosecond timestamp and line
+}
+
+func (l *Loki) Push(ctx context.Context, batch []Entry) error {
+	stream := lokiStream{Stream: map[string]string{"service": "jolly-okurb"}}
+	for _, e := range batch {
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(e.Time.UnixNano(), 10), e.Line})
+	}
+	body, err := json.Marshal(lokiPush{Streams: []lokiStream{stream}})
+	if err != nil {
+		return fmt.Errorf("failed to encode Loki push: %w: %w", errPermanent, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Loki request: %w: %w", errPermanent, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "jolly-okurb")
+	if l.username != "" || l.password != "" {
+		req.SetBasicAuth(l.username, l.password)
+	}
+
+	resp, err := l.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push to Loki: %w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("failed to push to Loki: %s", resp.Status)
+	default:
+		return fmt.Errorf("failed to push to Loki: %w: %s", errPermanent, resp.Status)
+	}
+}
@@ -0,0 +1,66 @@
+package logship
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoki_Push(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		status        int
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{"default path", "", http.StatusNoContent, false, false},
+		{"custom path", "/api/prom/push", http.StatusNoContent, false, false},
+		{"server error", "", http.StatusServiceUnavailable, true, false},
+		{"rate limited", "", http.StatusTooManyRequests, true, false},
+		{"rejected", "", http.StatusBadRequest, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got lokiPush
+			var path, user, pass string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				user, pass, _ = r.BasicAuth()
+				json.NewDecoder(r.Body).Decode(&got)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			l, err := NewLoki(strings.Replace(srv.URL, "://", "://1234:secret@", 1) + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			at := time.Unix(1700000000, 5)
+			err = l.Push(context.Background(), []Entry{{Time: at, Line: `{"msg":"replaced"}`}})
+
+			if (err != nil) != tt.wantErr || errors.Is(err, errPermanent) != tt.wantPermanent {
+				t.Fatalf("Push() error = %v, want error %v (permanent %v)", err, tt.wantErr, tt.wantPermanent)
+			}
+			wantPath := tt.path
+			if wantPath == "" {
+				wantPath = lokiPushPath
+			}
+			if path != wantPath {
+				t.Errorf("path = %q, want %q", path, wantPath)
+			}
+			if user != "1234" || pass != "secret" {
+				t.Errorf("basic auth = %q:%q, want the URL's credentials", user, pass)
+			}
+			if len(got.Streams) != 1 || got.Streams[0].Stream["service"] != "jolly-okurb" ||
+				len(got.Streams[0].Values) != 1 || got.Streams[0].Values[0] != [2]string{"1700000000000000005", `{"msg":"replaced"}`} {
+				t.Errorf("push = %+v, want one jolly-okurb stream with the entry", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Package logx gives each subsystem a logger that tags its records with a
+// component attribute, and names the keys that log lines share, so logs can
+// be queried by subsystem, guild, channel, or message.
+package logx
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+)
+
+// Components that log under their own name.
+const (
+	Bot      = "bot"      // Gateway events, commands, and reports
+	Scan     = "scan"     // Historical and on-demand message scans
+	Rules    = "rules"    // Mixed content and escalation policies
+	Store    = "store"    // Persistence
+	HTTP     = "http"     // The web server
+	Events   = "events"   // Event publishing
+	Schedule = "schedule" // Scheduled jobs
+	Metrics  = "metrics"  // Metric sinks
+	Logs     = "logs"     // Log shipping
+	Actions  = "actions"  // Changes made on Discord
+)
+
+// Keys shared by log lines across components. Discord IDs are always logged
+// under these keys, never as "id" or "channel".
+const (
+	KeyComponent = "component"
+	KeyGuild     = "guild_id"
+	KeyChannel   = "channel_id"
+	KeyMessage   = "message_id"
+	KeyUser      = "user_id"
+	KeyError     = "error"
+)
+
+// For returns the logger of component. It writes through whatever handler
+// slog.Default has when a record is logged, so loggers created at package
+// initialisation still use the handler main installs later.
+func For(component string) *slog.Logger {
+	return slog.New(&deferred{}).With(KeyComponent, component)
+}
+
+// Tee returns a handler that passes each record to every one of handlers
+// that is enabled for its level.
+func Tee(handlers ...slog.Handler) slog.Handler {
+	return tee(handlers)
+}
+
+type tee []slog.Handler
+
+func (t tee) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t tee) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range t {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t tee) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return t.each(func(h slog.Handler) slog.Handler { return h.WithAttrs(attrs) })
+}
+
+func (t tee) WithGroup(name string) slog.Handler {
+	return t.each(func(h slog.Handler) slog.Handler { return h.WithGroup(name) })
+}
+
+func (t tee) each(f func(slog.Handler) slog.Handler) tee {
+	out := make(tee, len(t))
+	for i, h := range t {
+		out[i] = f(h)
+	}
+	return out
+}
+
+// deferred is a slog.Handler that resolves the default handler per record and
+// replays the attributes and groups added to it.
+type deferred struct {
+	wrap []func(slog.Handler) slog.Handler
+}
+
+func (d *deferred) handler() slog.Handler {
+	h := slog.Default().Handler()
+	for _, w := range d.wrap {
+		h = w(h)
+	}
+	return h
+}
+
+func (d *deferred) Enabled(ctx context.Context, level slog.Level) bool {
+	return slog.Default().Handler().Enabled(ctx, level)
+}
+
+func (d *deferred) Handle(ctx context.Context, r slog.Record) error {
+	return d.handler().Handle(ctx, r)
+}
+
+func (d *deferred) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return d.with(func(h slog.Handler) slog.Handler { return h.WithAttrs(attrs) })
+}
+
+func (d *deferred) WithGroup(name string) slog.Handler {
+	return d.with(func(h slog.Handler) slog.Handler { return h.WithGroup(name) })
+}
+
+func (d *deferred) with(w func(slog.Handler) slog.Handler) *deferred {
+	return &deferred{wrap: append(slices.Clip(d.wrap), w)}
+}
@@ -0,0 +1,108 @@
+package logx
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestFor(t *testing.T) {
+	// Created before the default handler is set, like package-level loggers
+	logger := For(Scan)
+	scoped := logger.With(KeyChannel, "c1").WithGroup("progress")
+
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn})))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	tests := []struct {
+		name   string
+		log    func()
+		want   map[string]any
+		silent bool
+	}{
+		{
+			name: "component",
+			log:  func() { logger.Warn("scan stopped", KeyMessage, "m1") },
+			want: map[string]any{KeyComponent: Scan, KeyMessage: "m1"},
+		},
+		{
+			name: "attributes and groups",
+			log:  func() { scoped.Warn("scan progress", "processed", 3) },
+			want: map[string]any{KeyComponent: Scan, KeyChannel: "c1", "progress": map[string]any{"processed": float64(3)}},
+		},
+		{
+			name:   "default level",
+			log:    func() { logger.Info("scan started") },
+			silent: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			tt.log()
+			if tt.silent {
+				if buf.Len() != 0 {
+					t.Errorf("logged %s, want nothing below the default handler's level", buf.String())
+				}
+				return
+			}
+
+			var record map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("record %q is not JSON: %v", buf.String(), err)
+			}
+			for k, v := range tt.want {
+				if got, _ := json.Marshal(record[k]); string(got) != mustJSON(t, v) {
+					t.Errorf("%s = %s, want %s", k, got, mustJSON(t, v))
+				}
+			}
+		})
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestTee(t *testing.T) {
+	var debug, warn bytes.Buffer
+	logger := slog.New(Tee(
+		slog.NewJSONHandler(&debug, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		slog.NewJSONHandler(&warn, &slog.HandlerOptions{Level: slog.LevelWarn}),
+	)).With(KeyComponent, Bot)
+
+	logger.Debug("checked message", KeyMessage, "m1")
+	logger.Warn("failed to replace reaction", KeyMessage, "m2")
+
+	tests := []struct {
+		name string
+		buf  *bytes.Buffer
+		want int
+	}{
+		{"debug handler", &debug, 2},
+		{"warn handler", &warn, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := bytes.Split(bytes.TrimSpace(tt.buf.Bytes()), []byte("\n"))
+			if len(lines) != tt.want {
+				t.Fatalf("got %d records, want %d: %s", len(lines), tt.want, tt.buf.String())
+			}
+			var record map[string]any
+			if err := json.Unmarshal(lines[len(lines)-1], &record); err != nil {
+				t.Fatal(err)
+			}
+			if record[KeyComponent] != Bot || record[KeyMessage] != "m2" {
+				t.Errorf("record = %v, want the bot's m2 warning", record)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package logx
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// KeySampled is the key under which a sampled record notes how many records
+// it stands for.
+const KeySampled = "sampled"
+
+// Sample returns a handler that passes one in every n debug records with the
+// same message to h, starting with the first, and every record of a higher
+// level. During a backfill the bot logs the same debug lines for thousands
+// of messages, so sampling keeps debug level affordable in production. An n
+// of 1 or less passes everything.
+func Sample(h slog.Handler, n int) slog.Handler {
+	if n <= 1 {
+		return h
+	}
+	return &sampler{h: h, n: uint64(n), seen: &sync.Map{}}
+}
+
+type sampler struct {
+	h    slog.Handler
+	n    uint64
+	seen *sync.Map // Message to *atomic.Uint64 of its debug records, shared by derived handlers
+}
+
+func (s *sampler) Enabled(ctx context.Context, level slog.Level) bool {
+	return s.h.Enabled(ctx, level)
+}
+
+func (s *sampler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelInfo {
+		return s.h.Handle(ctx, r)
+	}
+	c, _ := s.seen.LoadOrStore(r.Message, new(atomic.Uint64))
+	if c.(*atomic.Uint64).Add(1)%s.n != 1 {
+		return nil
+	}
+	r = r.Clone()
+	r.AddAttrs(slog.Uint64(KeySampled, s.n))
+	return s.h.Handle(ctx, r)
+}
+
+func (s *sampler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &sampler{h: s.h.WithAttrs(attrs), n: s.n, seen: s.seen}
+}
+
+func (s *sampler) WithGroup(name string) slog.Handler {
+	return &sampler{h: s.h.WithGroup(name), n: s.n, seen: s.seen}
+}
@@ -0,0 +1,80 @@
+package logx
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestSample(t *testing.T) {
+	tests := []struct {
+		name        string
+		n           int
+		log         func(*slog.Logger)
+		wantRecords int
+		wantSampled bool
+	}{
+		{
+			name: "one in n debug records",
+			n:    10,
+			log: func(l *slog.Logger) {
+				for range 25 {
+					l.Debug("skipped message", KeyMessage, "m1")
+				}
+			},
+			wantRecords: 3,
+			wantSampled: true,
+		},
+		{
+			name: "counted per message",
+			n:    10,
+			log: func(l *slog.Logger) {
+				l.Debug("skipped message")
+				l.Debug("checked message")
+				l.With(KeyChannel, "c1").Debug("skipped message")
+			},
+			wantRecords: 2,
+			wantSampled: true,
+		},
+		{
+			name: "higher levels pass",
+			n:    10,
+			log: func(l *slog.Logger) {
+				for range 5 {
+					l.Info("replaced skull")
+				}
+			},
+			wantRecords: 5,
+		},
+		{
+			name: "off",
+			n:    1,
+			log: func(l *slog.Logger) {
+				for range 5 {
+					l.Debug("skipped message")
+				}
+			},
+			wantRecords: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+			tt.log(slog.New(Sample(h, tt.n)))
+
+			lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+			if len(lines) != tt.wantRecords {
+				t.Fatalf("got %d records, want %d: %s", len(lines), tt.wantRecords, buf.String())
+			}
+			var record map[string]any
+			if err := json.Unmarshal(lines[0], &record); err != nil {
+				t.Fatal(err)
+			}
+			if _, sampled := record[KeySampled]; sampled != tt.wantSampled {
+				t.Errorf("record = %v, want %s noted %v", record, KeySampled, tt.wantSampled)
+			}
+		})
+	}
+}
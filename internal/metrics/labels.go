@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Label keys of the tags that place a metric. Their values come from the
+// guild's data, so Bound caps how many distinct ones each takes.
+const (
+	LabelGuild   = "guild"
+	LabelChannel = "channel"
+	LabelRule    = "rule"
+	LabelEmoji   = "emoji"
+)
+
+// Other is the value a bounded label takes once it has seen its limit of
+// distinct values.
+const Other = "other"
+
+// bounded is a Sink that passes at most limit distinct values of each bounded
+// label on to its Sink, and reports later ones as Other. Values seen first
+// keep their own series for as long as the bot runs.
+type bounded struct {
+	Sink
+	limit int
+
+	mu   sync.Mutex
+	seen map[string]map[string]struct{} // Label key to the values passed on
+}
+
+// Bound wraps s so each of the guild, channel, rule, and emoji labels takes
+// at most limit distinct values, keeping their series from growing without
+// end. A limit of 0 or less leaves s as is.
+func Bound(s Sink, limit int) Sink {
+	if limit <= 0 {
+		return s
+	}
+	return &bounded{Sink: s, limit: limit, seen: map[string]map[string]struct{}{}}
+}
+
+func (b *bounded) Count(name string, n int64, tags ...string) {
+	b.Sink.Count(name, n, b.bound(tags)...)
+}
+
+func (b *bounded) Timing(name string, d time.Duration, tags ...string) {
+	b.Sink.Timing(name, d, b.bound(tags)...)
+}
+
+// bound returns tags with the values of bounded labels beyond the limit
+// replaced by Other.
+func (b *bounded) bound(tags []string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []string
+	for i, tag := range tags {
+		key, value, ok := strings.Cut(tag, ":")
+		if !ok || !isBoundedLabel(key) {
+			continue
+		}
+		values := b.seen[key]
+		if values == nil {
+			values = map[string]struct{}{}
+			b.seen[key] = values
+		}
+		if _, ok := values[value]; ok {
+			continue
+		}
+		if len(values) < b.limit {
+			values[value] = struct{}{}
+			continue
+		}
+		if out == nil {
+			out = append([]string(nil), tags...)
+		}
+		out[i] = key + ":" + Other
+	}
+	if out == nil {
+		return tags
+	}
+	return out
+}
+
+func isBoundedLabel(key string) bool {
+	switch key {
+	case LabelGuild, LabelChannel, LabelRule, LabelEmoji:
+		return true
+	}
+	return false
+}
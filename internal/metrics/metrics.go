@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"fmt"
+	"time"
+)
+
+// Metric names emitted by the bot. Every Sink receives the same names.
+const (
+	ReactionsReplaced       = "reactions.replaced"
+	ReactionReplaceFailures = "reactions.replace_failures"
+	ReactionReplaceDuration = "reactions.replace_duration"
+	ReactionAddsSkipped     = "reactions.adds_skipped"
+	ReactionRecheckRemovals = "reactions.recheck_removals"
+	ProgressIndicated       = "reactions.progress_indicated"
+	ReactionRepeats         = "reactions.repeats"
+	ReactionFallbacks       = "reactions.fallbacks"
+	ShadowMatches           = "reactions.shadow_matches"
+	MessagesJollified       = "messages.jollified"
+	MessagesDeleted         = "messages.deleted"
+	MessageDeleteFailures   = "messages.delete_failures"
+	MessagesMoved           = "messages.moved"
+	MessageMoveFailures     = "messages.move_failures"
+	MessagesVanished        = "messages.vanished"
+	SoftDeleteWarnings      = "messages.soft_delete_warnings"
+	SoftDeleteReprieves     = "messages.soft_delete_reprieves"
+	DeletionNotices         = "messages.deletion_notices"
+	DirectMessageReactions  = "dms.reactions"
+	MixedContentActions     = "messages.mixed_content"
+	Escalations             = "users.escalations"
+	AlertsRaised            = "alerts.raised"
+	AlertsAcknowledged      = "alerts.acknowledged"
+	FalsePositiveReports    = "alerts.false_positives"
+	HistoricalProcessed     = "historical.processed"
+	HistoricalDuration      = "historical.duration"
+	HistoricalStalls        = "historical.stalls"
+	HistoricalQuiet         = "historical.quiet_channels"
+	ActionsThrottled        = "actions.throttled"
+	ActionsPerformed        = "actions.performed"
+	ActionsFailed           = "actions.failed"
+	ActionsRetried          = "actions.retried"
+	RateLimited             = "api.rate_limited"
+	DryRunActions           = "actions.dry_run"
+	ReadOnlyBlocked         = "actions.read_only"
+	NotReady                = "bot.not_ready"
+	PanicsRecovered         = "bot.panics_recovered"
+)
+
+// Supported values for METRICS_BACKEND.
+const (
+	BackendNone      = "none"
+	BackendStatsd    = "statsd"
+	BackendDogStatsd = "dogstatsd"
+)
+
+// Sink receives counters and timers. Tags are "key:value" pairs and may be
+// ignored by backends that do not support them.
+type Sink interface {
+	Count(name string, n int64, tags ...string)
+	Timing(name string, d time.Duration, tags ...string)
+	Close() error
+}
+
+// Incr increments the named counter by one.
+func Incr(s Sink, name string, tags ...string) {
+	s.Count(name, 1, tags...)
+}
+
+// Nop discards all metrics.
+type Nop struct{}
+
+func (Nop) Count(string, int64, ...string)          {}
+func (Nop) Timing(string, time.Duration, ...string) {}
+func (Nop) Close() error                            { return nil }
+
+// New creates the sink for the given backend name.
+func New(backend, addr, prefix string) (Sink, error) {
+	switch backend {
+	case "", BackendNone:
+		return Nop{}, nil
+	case BackendStatsd:
+		return NewStatsd(addr, prefix, false)
+	case BackendDogStatsd:
+		return NewStatsd(addr, prefix, true)
+	default:
+		return nil, fmt.Errorf("unknown metrics backend %q", backend)
+	}
+}
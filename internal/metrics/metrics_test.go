@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend string
+		wantNop bool
+		wantErr bool
+	}{
+		{"empty backend is nop", "", true, false},
+		{"none backend is nop", "none", true, false},
+		{"statsd backend", "statsd", false, false},
+		{"dogstatsd backend", "dogstatsd", false, false},
+		{"unknown backend", "prometheus", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink, err := New(tt.backend, "127.0.0.1:8125", "jolly")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("New() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() unexpected error: %v", err)
+			}
+			defer sink.Close()
+			if _, ok := sink.(Nop); ok != tt.wantNop {
+				t.Errorf("New(%q) nop = %v, want %v", tt.backend, ok, tt.wantNop)
+			}
+		})
+	}
+}
+
+func TestStatsd(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     bool
+		emit     func(Sink)
+		expected string
+	}{
+		{
+			name:     "counter",
+			emit:     func(s Sink) { Incr(s, ReactionsReplaced) },
+			expected: "jolly.reactions.replaced:1|c",
+		},
+		{
+			name:     "timer",
+			emit:     func(s Sink) { s.Timing(ReactionReplaceDuration, 250*time.Millisecond) },
+			expected: "jolly.reactions.replace_duration:250|ms",
+		},
+		{
+			name:     "plain statsd drops tags",
+			emit:     func(s Sink) { s.Count(MessagesDeleted, 3, "channel:general") },
+			expected: "jolly.messages.deleted:3|c",
+		},
+		{
+			name:     "dogstatsd keeps tags",
+			tags:     true,
+			emit:     func(s Sink) { s.Count(MessagesDeleted, 3, "channel:general", "emoji:skull") },
+			expected: "jolly.messages.deleted:3|c|#channel:general,emoji:skull",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("failed to listen: %v", err)
+			}
+			defer conn.Close()
+
+			sink, err := NewStatsd(conn.LocalAddr().String(), "jolly", tt.tags)
+			if err != nil {
+				t.Fatalf("NewStatsd() unexpected error: %v", err)
+			}
+			defer sink.Close()
+
+			tt.emit(sink)
+
+			buf := make([]byte, 512)
+			conn.SetReadDeadline(time.Now().Add(time.Second))
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				t.Fatalf("failed to read metric: %v", err)
+			}
+			if got := string(buf[:n]); got != tt.expected {
+				t.Errorf("metric line = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// recordingSink keeps the tags of every metric it receives.
+type recordingSink struct {
+	Nop
+	tags [][]string
+}
+
+func (r *recordingSink) Count(_ string, _ int64, tags ...string) {
+	r.tags = append(r.tags, tags)
+}
+
+func TestBound(t *testing.T) {
+	rec := &recordingSink{}
+	sink := Bound(rec, 2)
+
+	for _, channel := range []string{"a", "b", "c", "a"} {
+		Incr(sink, ReactionsReplaced, "guild:g1", "channel:"+channel, "policy:strict")
+	}
+
+	want := []string{"channel:a", "channel:b", "channel:other", "channel:a"}
+	for i, tags := range rec.tags {
+		if tags[0] != "guild:g1" || tags[1] != want[i] || tags[2] != "policy:strict" {
+			t.Errorf("metric %d tags = %q, want guild:g1, %s, policy:strict", i, tags, want[i])
+		}
+	}
+	if got := Bound(rec, 0); got != Sink(rec) {
+		t.Error("a limit of 0 should leave the sink unbounded")
+	}
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"jolly-okurb/internal/logx"
+)
+
+// logger is the logger of this package.
+var logger = logx.For(logx.Metrics)
+
+// Statsd sends metrics over UDP using the statsd line protocol.
+// With tags enabled it emits the DogStatsD "|#k:v" extension.
+type Statsd struct {
+	conn   net.Conn
+	prefix string
+	tags   bool
+}
+
+// NewStatsd dials the statsd agent at addr. Metric names are prefixed with
+// prefix followed by a dot when prefix is non-empty.
+func NewStatsd(addr, prefix string, tags bool) (*Statsd, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial statsd at %s: %w", addr, err)
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+	return &Statsd{conn: conn, prefix: prefix, tags: tags}, nil
+}
+
+func (s *Statsd) Count(name string, n int64, tags ...string) {
+	s.send(name, fmt.Sprintf("%d|c", n), tags)
+}
+
+func (s *Statsd) Timing(name string, d time.Duration, tags ...string) {
+	s.send(name, fmt.Sprintf("%d|ms", d.Milliseconds()), tags)
+}
+
+func (s *Statsd) Close() error {
+	return s.conn.Close()
+}
+
+func (s *Statsd) send(name, value string, tags []string) {
+	line := s.prefix + name + ":" + value
+	if s.tags && len(tags) > 0 {
+		line += "|#" + strings.Join(tags, ",")
+	}
+	// UDP is fire-and-forget; a dropped metric must never affect the bot.
+	if _, err := s.conn.Write([]byte(line)); err != nil {
+		logger.Debug("failed to send metric", "metric", name, "error", err)
+	}
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket allowing n actions per period with bursts up to n.
+// A nil *Bucket imposes no limit.
+type Bucket struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	rate     float64 // tokens per second
+	last     time.Time
+	now      func() time.Time
+}
+
+// New creates a full bucket that refills n tokens every period.
+func New(n int, period time.Duration) *Bucket {
+	return &Bucket{
+		capacity: float64(n),
+		tokens:   float64(n),
+		rate:     float64(n) / period.Seconds(),
+		last:     time.Now(),
+		now:      time.Now,
+	}
+}
+
+// Allow takes a token if one is available without waiting.
+func (b *Bucket) Allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	if b.tokens >= 1 {
+		b.tokens--
+		return true
+	}
+	return false
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (b *Bucket) Wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	for {
+		b.mu.Lock()
+		b.refill()
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// refill adds tokens for the time elapsed since the last refill. Callers must hold mu.
+func (b *Bucket) refill() {
+	now := b.now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+}
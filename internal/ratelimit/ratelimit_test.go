@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBucket_Allow(t *testing.T) {
+	now := time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC)
+	b := New(2, 10*time.Second)
+	b.now = func() time.Time { return now }
+	b.last = now
+
+	if !b.Allow() || !b.Allow() {
+		t.Fatal("Allow() should permit a full burst")
+	}
+	if b.Allow() {
+		t.Fatal("Allow() should reject once the burst is spent")
+	}
+
+	// One token refills every 5 seconds
+	now = now.Add(5 * time.Second)
+	if !b.Allow() {
+		t.Error("Allow() should permit after refill")
+	}
+	if b.Allow() {
+		t.Error("Allow() should only refill one token after 5 seconds")
+	}
+
+	// Refill is capped at capacity
+	now = now.Add(time.Hour)
+	allowed := 0
+	for b.Allow() {
+		allowed++
+	}
+	if allowed != 2 {
+		t.Errorf("allowed %d after long idle, want capacity 2", allowed)
+	}
+}
+
+func TestBucket_Wait(t *testing.T) {
+	t.Run("waits for refill", func(t *testing.T) {
+		b := New(1, 50*time.Millisecond)
+		b.Allow()
+
+		start := time.Now()
+		if err := b.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() unexpected error: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 25*time.Millisecond {
+			t.Errorf("Wait() returned after %v, expected to block for refill", elapsed)
+		}
+	})
+
+	t.Run("stops on context cancellation", func(t *testing.T) {
+		b := New(1, time.Hour)
+		b.Allow()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if err := b.Wait(ctx); err == nil {
+			t.Error("Wait() should return an error when the context is cancelled")
+		}
+	})
+
+	t.Run("nil bucket is unlimited", func(t *testing.T) {
+		var b *Bucket
+		if !b.Allow() {
+			t.Error("nil bucket Allow() should return true")
+		}
+		if err := b.Wait(context.Background()); err != nil {
+			t.Errorf("nil bucket Wait() unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,303 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"jolly-okurb/internal/logx"
+	"jolly-okurb/internal/store"
+)
+
+// logger is the logger of this package.
+var logger = logx.For(logx.Schedule)
+
+// jobsKey is the store key holding every pending job.
+const jobsKey = "schedule/jobs"
+
+// Retry policy for failed jobs: exponential backoff from retryDelay, capped
+// at maxRetryDelay, giving up after maxAttempts runs.
+var (
+	retryDelay    = 5 * time.Second
+	maxRetryDelay = 10 * time.Minute
+	maxAttempts   = 5
+)
+
+// Job is an action to run at a later time. Jobs are identified by ID, so
+// scheduling a job with an existing ID replaces it.
+type Job struct {
+	ID       string          `json:"id"`
+	Kind     string          `json:"kind"` // Selects the Handler
+	RunAt    time.Time       `json:"run_at"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	Attempts int             `json:"attempts,omitempty"` // Failed runs so far
+}
+
+// NewJob creates a job whose data is v encoded as JSON.
+func NewJob(id, kind string, runAt time.Time, v any) (Job, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Job{}, fmt.Errorf("failed to encode %s job data: %w", kind, err)
+	}
+	return Job{ID: id, Kind: kind, RunAt: runAt, Data: data}, nil
+}
+
+// Decode decodes the job's data into v.
+func (j Job) Decode(v any) error {
+	if err := json.Unmarshal(j.Data, v); err != nil {
+		return fmt.Errorf("failed to decode %s job data: %w", j.Kind, err)
+	}
+	return nil
+}
+
+// postponed is the error of a handler that cannot run its job yet.
+type postponed struct {
+	after time.Duration
+	why   string
+}
+
+func (p *postponed) Error() string {
+	return "postponed: " + p.why
+}
+
+// Postpone returns the error a Handler returns to run its job again after d,
+// because of why, without counting it as a failed attempt. A job can be
+// postponed indefinitely, such as while the features it needs are paused.
+func Postpone(d time.Duration, why string) error {
+	return &postponed{after: d, why: why}
+}
+
+// Handler performs a job of one kind. Jobs run at least once: a job that
+// fails, or is interrupted by a restart, runs again, so handlers must be
+// safe to repeat.
+type Handler func(ctx context.Context, job Job) error
+
+// Scheduler runs jobs once they are due. Pending jobs are persisted to the
+// store so they survive a restart.
+type Scheduler struct {
+	store    store.Store
+	jitter   time.Duration
+	mu       sync.Mutex
+	jobs     map[string]Job
+	handlers map[string]Handler
+	wake     chan struct{} // Signals Run that the earliest job may have changed
+	now      func() time.Time
+}
+
+// Option configures a Scheduler.
+type Option func(*Scheduler)
+
+// WithJitter delays every scheduled job by a random duration up to d, so
+// jobs scheduled for the same moment do not all hit the API at once.
+func WithJitter(d time.Duration) Option {
+	return func(s *Scheduler) {
+		s.jitter = d
+	}
+}
+
+func New(st store.Store, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		store:    st,
+		jobs:     make(map[string]Job),
+		handlers: make(map[string]Handler),
+		wake:     make(chan struct{}, 1),
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Load restores the jobs persisted by a previous run. Jobs that became due
+// while the bot was down run as soon as Run starts.
+func (s *Scheduler) Load() error {
+	var jobs map[string]Job
+	if _, err := s.store.Get(jobsKey, &jobs); err != nil {
+		return fmt.Errorf("failed to load scheduled jobs: %w", err)
+	}
+
+	s.mu.Lock()
+	for id, job := range jobs {
+		if _, ok := s.jobs[id]; !ok {
+			s.jobs[id] = job
+		}
+	}
+	s.mu.Unlock()
+	s.notify()
+	return nil
+}
+
+// Handle registers the handler for jobs of the given kind.
+func (s *Scheduler) Handle(kind string, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[kind] = h
+}
+
+// Schedule adds job, replacing any job with the same ID.
+func (s *Scheduler) Schedule(job Job) error {
+	if s.jitter > 0 {
+		job.RunAt = job.RunAt.Add(rand.N(s.jitter))
+	}
+
+	s.mu.Lock()
+	s.jobs[job.ID] = job
+	err := s.save()
+	s.mu.Unlock()
+	s.notify()
+	return err
+}
+
+// Cancel removes the job with the given ID, if it has not run yet.
+func (s *Scheduler) Cancel(id string) error {
+	s.mu.Lock()
+	delete(s.jobs, id)
+	err := s.save()
+	s.mu.Unlock()
+	s.notify()
+	return err
+}
+
+// Pending returns the number of jobs waiting to run or being run.
+func (s *Scheduler) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.jobs)
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// save persists the pending jobs. The caller must hold s.mu.
+func (s *Scheduler) save() error {
+	if err := s.store.Put(jobsKey, s.jobs); err != nil {
+		return fmt.Errorf("failed to save scheduled jobs: %w", err)
+	}
+	return nil
+}
+
+// Run executes jobs as they become due until ctx is done. Jobs run one at a
+// time, in order of their run time.
+func (s *Scheduler) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		for _, job := range s.due() {
+			if ctx.Err() != nil {
+				return
+			}
+			s.run(ctx, job)
+		}
+
+		timer.Reset(s.untilNext())
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// due returns the jobs whose run time has passed, earliest first. They stay
+// pending until they have run, so a restart mid-run does not lose them.
+func (s *Scheduler) due() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var due []Job
+	for _, job := range s.jobs {
+		if !job.RunAt.After(now) {
+			due = append(due, job)
+		}
+	}
+	slices.SortFunc(due, func(a, b Job) int { return a.RunAt.Compare(b.RunAt) })
+	return due
+}
+
+// untilNext returns how long to sleep before the earliest job is due.
+func (s *Scheduler) untilNext() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := time.Hour // Wake up occasionally even with nothing scheduled
+	for _, job := range s.jobs {
+		next = min(next, job.RunAt.Sub(s.now()))
+	}
+	return max(next, 0)
+}
+
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	s.mu.Lock()
+	h, ok := s.handlers[job.Kind]
+	s.mu.Unlock()
+	if !ok {
+		logger.Error("no handler for scheduled job", "job_id", job.ID, "kind", job.Kind)
+		s.finish(job, nil)
+		return
+	}
+
+	err := h(ctx, job)
+	if err == nil {
+		s.finish(job, nil)
+		return
+	}
+	var p *postponed
+	if errors.As(err, &p) {
+		later := job
+		later.RunAt = s.now().Add(p.after)
+		logger.Debug("scheduled job postponed", "job_id", job.ID, "kind", job.Kind, "run_at", later.RunAt, "reason", p.why)
+		s.finish(job, &later)
+		return
+	}
+
+	retry := job
+	retry.Attempts++
+	if retry.Attempts >= maxAttempts {
+		logger.Error("giving up on scheduled job", "job_id", job.ID, "kind", job.Kind, "attempts", retry.Attempts, "error", err)
+		s.finish(job, nil)
+		return
+	}
+	retry.RunAt = s.now().Add(backoff(retry.Attempts))
+	logger.Warn("scheduled job failed, will retry", "job_id", job.ID, "kind", job.Kind, "attempts", retry.Attempts, "retry_at", retry.RunAt, "error", err)
+	s.finish(job, &retry)
+}
+
+// finish removes a job that has run, or replaces it with its retry. A job
+// that was rescheduled or cancelled while it ran is left alone.
+func (s *Scheduler) finish(job Job, retry *Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.jobs[job.ID]
+	if !ok || !current.RunAt.Equal(job.RunAt) || current.Attempts != job.Attempts {
+		return
+	}
+	if retry != nil {
+		s.jobs[job.ID] = *retry
+	} else {
+		delete(s.jobs, job.ID)
+	}
+	if err := s.save(); err != nil {
+		logger.Error("failed to save scheduled jobs", "error", err)
+	}
+}
+
+// backoff returns the delay before retry number attempt. Half of it is
+// random so that jobs failing together do not retry in lockstep.
+func backoff(attempt int) time.Duration {
+	d := min(retryDelay<<(attempt-1), maxRetryDelay)
+	return d/2 + rand.N(d/2+1)
+}
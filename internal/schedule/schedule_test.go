@@ -0,0 +1,281 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"jolly-okurb/internal/store"
+)
+
+// recorder collects the IDs of jobs it handles.
+type recorder struct {
+	mu  sync.Mutex
+	ids []string
+	ran chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{ran: make(chan struct{}, 10)}
+}
+
+func (r *recorder) handle(ctx context.Context, job Job) error {
+	r.mu.Lock()
+	r.ids = append(r.ids, job.ID)
+	r.mu.Unlock()
+	r.ran <- struct{}{}
+	return nil
+}
+
+func (r *recorder) wait(t *testing.T, n int) []string {
+	t.Helper()
+	for range n {
+		select {
+		case <-r.ran:
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %d jobs", n)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.ids)
+}
+
+func TestNewJob(t *testing.T) {
+	type payload struct {
+		MessageID string `json:"message_id"`
+	}
+
+	job, err := NewJob("job-1", "test", time.Now(), payload{MessageID: "123"})
+	if err != nil {
+		t.Fatalf("NewJob() unexpected error: %v", err)
+	}
+	var got payload
+	if err := job.Decode(&got); err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+	if got.MessageID != "123" {
+		t.Errorf("MessageID = %q, want %q", got.MessageID, "123")
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	run := func(t *testing.T, s *Scheduler) {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			s.Run(ctx)
+			close(done)
+		}()
+		t.Cleanup(func() {
+			cancel()
+			<-done
+		})
+	}
+
+	t.Run("runs jobs in order once due", func(t *testing.T) {
+		s := New(store.NewMemory())
+		r := newRecorder()
+		s.Handle("test", r.handle)
+		now := time.Now()
+		s.Schedule(Job{ID: "later", Kind: "test", RunAt: now.Add(30 * time.Millisecond)})
+		s.Schedule(Job{ID: "sooner", Kind: "test", RunAt: now.Add(10 * time.Millisecond)})
+		run(t, s)
+
+		if got := r.wait(t, 2); !slices.Equal(got, []string{"sooner", "later"}) {
+			t.Errorf("ran %q, want [sooner later]", got)
+		}
+		if s.Pending() != 0 {
+			t.Errorf("Pending() = %d, want 0", s.Pending())
+		}
+	})
+
+	t.Run("jobs scheduled while running are picked up", func(t *testing.T) {
+		s := New(store.NewMemory())
+		r := newRecorder()
+		s.Handle("test", r.handle)
+		run(t, s)
+
+		s.Schedule(Job{ID: "new", Kind: "test", RunAt: time.Now()})
+
+		if got := r.wait(t, 1); !slices.Equal(got, []string{"new"}) {
+			t.Errorf("ran %q, want [new]", got)
+		}
+	})
+
+	t.Run("same ID replaces the job", func(t *testing.T) {
+		s := New(store.NewMemory())
+		r := newRecorder()
+		s.Handle("test", r.handle)
+		s.Schedule(Job{ID: "job", Kind: "test", RunAt: time.Now().Add(time.Hour)})
+		s.Schedule(Job{ID: "job", Kind: "test", RunAt: time.Now()})
+		run(t, s)
+
+		if got := r.wait(t, 1); !slices.Equal(got, []string{"job"}) {
+			t.Errorf("ran %q, want [job]", got)
+		}
+		if s.Pending() != 0 {
+			t.Errorf("replaced job should not remain pending, got %d", s.Pending())
+		}
+	})
+
+	t.Run("cancelled jobs do not run", func(t *testing.T) {
+		s := New(store.NewMemory())
+		r := newRecorder()
+		s.Handle("test", r.handle)
+		s.Schedule(Job{ID: "cancelled", Kind: "test", RunAt: time.Now().Add(10 * time.Millisecond)})
+		s.Schedule(Job{ID: "kept", Kind: "test", RunAt: time.Now().Add(20 * time.Millisecond)})
+		s.Cancel("cancelled")
+		run(t, s)
+
+		if got := r.wait(t, 1); !slices.Equal(got, []string{"kept"}) {
+			t.Errorf("ran %q, want [kept]", got)
+		}
+	})
+
+	t.Run("unhandled jobs are dropped", func(t *testing.T) {
+		s := New(store.NewMemory())
+		r := newRecorder()
+		s.Handle("test", r.handle)
+		s.Schedule(Job{ID: "unknown", Kind: "missing", RunAt: time.Now()})
+		s.Schedule(Job{ID: "known", Kind: "test", RunAt: time.Now().Add(10 * time.Millisecond)})
+		run(t, s)
+
+		r.wait(t, 1)
+		if s.Pending() != 0 {
+			t.Errorf("Pending() = %d, want 0", s.Pending())
+		}
+	})
+
+	t.Run("failed jobs are retried until they succeed", func(t *testing.T) {
+		setRetryDelay(t, time.Millisecond)
+		s := New(store.NewMemory())
+		r := newRecorder()
+		s.Handle("flaky", func(ctx context.Context, job Job) error {
+			r.handle(ctx, job)
+			if job.Attempts < 2 {
+				return errors.New("boom")
+			}
+			return nil
+		})
+		s.Schedule(Job{ID: "flaky", Kind: "flaky", RunAt: time.Now()})
+		run(t, s)
+
+		r.wait(t, 3)
+		waitPending(t, s, 0)
+	})
+
+	t.Run("postponed jobs run again without counting attempts", func(t *testing.T) {
+		s := New(store.NewMemory())
+		r := newRecorder()
+		runs := 0
+		s.Handle("paused", func(ctx context.Context, job Job) error {
+			r.handle(ctx, job)
+			if runs++; runs <= maxAttempts {
+				return Postpone(time.Millisecond, "paused")
+			}
+			if job.Attempts != 0 {
+				t.Errorf("Attempts = %d, want postponements not counted", job.Attempts)
+			}
+			return nil
+		})
+		s.Schedule(Job{ID: "paused", Kind: "paused", RunAt: time.Now()})
+		run(t, s)
+
+		r.wait(t, maxAttempts+1)
+		waitPending(t, s, 0)
+	})
+
+	t.Run("jobs are dropped after too many failures", func(t *testing.T) {
+		setRetryDelay(t, time.Millisecond)
+		s := New(store.NewMemory())
+		r := newRecorder()
+		s.Handle("failing", func(ctx context.Context, job Job) error {
+			r.handle(ctx, job)
+			return errors.New("boom")
+		})
+		s.Schedule(Job{ID: "failing", Kind: "failing", RunAt: time.Now()})
+		run(t, s)
+
+		r.wait(t, maxAttempts)
+		waitPending(t, s, 0)
+	})
+
+	t.Run("jobs survive a restart", func(t *testing.T) {
+		st := store.NewMemory()
+		before := New(st)
+		before.Schedule(Job{ID: "job", Kind: "test", RunAt: time.Now()})
+
+		s := New(st)
+		if err := s.Load(); err != nil {
+			t.Fatalf("Load() unexpected error: %v", err)
+		}
+		r := newRecorder()
+		s.Handle("test", r.handle)
+		run(t, s)
+
+		if got := r.wait(t, 1); !slices.Equal(got, []string{"job"}) {
+			t.Errorf("ran %q, want [job]", got)
+		}
+		waitPending(t, s, 0)
+
+		var saved map[string]Job
+		st.Get(jobsKey, &saved)
+		if len(saved) != 0 {
+			t.Errorf("finished job should be removed from the store, got %v", saved)
+		}
+	})
+}
+
+func TestScheduler_Jitter(t *testing.T) {
+	s := New(store.NewMemory(), WithJitter(time.Minute))
+	runAt := time.Now()
+	s.Schedule(Job{ID: "job", Kind: "test", RunAt: runAt})
+
+	got := s.jobs["job"].RunAt
+	if got.Before(runAt) || !got.Before(runAt.Add(time.Minute)) {
+		t.Errorf("RunAt = %v, want within a minute after %v", got, runAt)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration // Upper bound; at least half of it
+	}{
+		{attempt: 1, want: retryDelay},
+		{attempt: 2, want: 2 * retryDelay},
+		{attempt: 3, want: 4 * retryDelay},
+		{attempt: 30, want: maxRetryDelay},
+	}
+
+	for _, tt := range tests {
+		for range 20 {
+			if got := backoff(tt.attempt); got < tt.want/2 || got > tt.want {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", tt.attempt, got, tt.want/2, tt.want)
+			}
+		}
+	}
+}
+
+// setRetryDelay shortens the retry backoff for the duration of the test.
+func setRetryDelay(t *testing.T, d time.Duration) {
+	old := retryDelay
+	retryDelay = d
+	t.Cleanup(func() { retryDelay = old })
+}
+
+// waitPending waits until the scheduler has n jobs left.
+func waitPending(t *testing.T, s *Scheduler, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for s.Pending() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Pending() = %d, want %d", s.Pending(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,120 @@
+// Package sentry reports panics to Sentry through its HTTP store API.
+package sentry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// sendTimeout bounds how long reporting a panic may hold up its handler.
+const sendTimeout = 5 * time.Second
+
+// Client sends events to one Sentry project. A nil Client discards them.
+type Client struct {
+	endpoint string // Store API URL of the project
+	auth     string // X-Sentry-Auth header value
+	http     *http.Client
+}
+
+// New creates a client for a DSN such as "https://key@o1.ingest.sentry.io/42".
+func New(dsn string) (*Client, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Sentry DSN: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid Sentry DSN: unsupported scheme %q", u.Scheme)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN: missing public key")
+	}
+	path, project, ok := cutLast(strings.TrimSuffix(u.Path, "/"), "/")
+	if !ok || project == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN: missing project ID")
+	}
+
+	endpoint := url.URL{Scheme: u.Scheme, Host: u.Host, Path: path + "/api/" + project + "/store/"}
+	return &Client{
+		endpoint: endpoint.String(),
+		auth:     "Sentry sentry_version=7, sentry_client=jolly-okurb/1.0, sentry_key=" + u.User.Username(),
+		http:     &http.Client{Timeout: sendTimeout},
+	}, nil
+}
+
+// event is the subset of the Sentry event payload the bot fills in.
+type event struct {
+	EventID   string            `json:"event_id"`
+	Timestamp string            `json:"timestamp"`
+	Level     string            `json:"level"`
+	Platform  string            `json:"platform"`
+	Message   string            `json:"message"`
+	Exception exceptions        `json:"exception"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Extra     map[string]string `json:"extra,omitempty"`
+}
+
+type exceptions struct {
+	Values []exception `json:"values"`
+}
+
+type exception struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// CapturePanic reports a recovered panic value with the stack it was raised on.
+func (c *Client) CapturePanic(ctx context.Context, value any, stack []byte, tags map[string]string) error {
+	if c == nil {
+		return nil
+	}
+
+	id := make([]byte, 16)
+	rand.Read(id)
+	msg := fmt.Sprint(value)
+	body, err := json.Marshal(event{
+		EventID:   hex.EncodeToString(id),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Level:     "fatal",
+		Platform:  "go",
+		Message:   msg,
+		Exception: exceptions{Values: []exception{{Type: "panic", Value: msg}}},
+		Tags:      tags,
+		Extra:     map[string]string{"stack": string(stack)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode Sentry event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Sentry request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", c.auth)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send Sentry event: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to send Sentry event: %s", resp.Status)
+	}
+	return nil
+}
+
+// cutLast slices s around the last instance of sep.
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,111 @@
+package sentry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name         string
+		dsn          string
+		wantEndpoint string
+		wantErr      string
+	}{
+		{
+			name:         "hosted DSN",
+			dsn:          "https://abc123@o1.ingest.sentry.io/42",
+			wantEndpoint: "https://o1.ingest.sentry.io/api/42/store/",
+		},
+		{
+			name:         "self-hosted under a path",
+			dsn:          "http://abc123@sentry.example.com/sentry/7",
+			wantEndpoint: "http://sentry.example.com/sentry/api/7/store/",
+		},
+		{name: "missing key", dsn: "https://sentry.example.com/42", wantErr: "public key"},
+		{name: "missing project", dsn: "https://abc123@sentry.example.com/", wantErr: "project ID"},
+		{name: "unsupported scheme", dsn: "udp://abc123@sentry.example.com/42", wantErr: "scheme"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(tt.dsn)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("New(%q) error = %v, want it to mention %q", tt.dsn, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New(%q) error = %v", tt.dsn, err)
+			}
+			if c.endpoint != tt.wantEndpoint {
+				t.Errorf("endpoint = %q, want %q", c.endpoint, tt.wantEndpoint)
+			}
+		})
+	}
+}
+
+func TestClient_CapturePanic(t *testing.T) {
+	t.Run("sends the panic and its stack", func(t *testing.T) {
+		var got event
+		var auth string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth = r.Header.Get("X-Sentry-Auth")
+			if r.URL.Path != "/api/42/store/" {
+				t.Errorf("path = %q, want the store API", r.URL.Path)
+			}
+			if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+				t.Errorf("failed to decode event: %v", err)
+			}
+		}))
+		defer srv.Close()
+
+		c, err := New(strings.Replace(srv.URL, "://", "://abc123@", 1) + "/42")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.CapturePanic(t.Context(), "boom", []byte("goroutine 1"), map[string]string{"handler": "test"})
+		if err != nil {
+			t.Fatalf("CapturePanic() error = %v", err)
+		}
+
+		if !strings.Contains(auth, "sentry_key=abc123") {
+			t.Errorf("X-Sentry-Auth = %q, want the public key", auth)
+		}
+		if got.Message != "boom" || len(got.Exception.Values) != 1 || got.Exception.Values[0].Value != "boom" {
+			t.Errorf("unexpected event %+v", got)
+		}
+		if got.Extra["stack"] != "goroutine 1" || got.Tags["handler"] != "test" {
+			t.Errorf("expected stack and tags, got %+v", got)
+		}
+		if len(got.EventID) != 32 {
+			t.Errorf("event_id = %q, want 32 hex digits", got.EventID)
+		}
+	})
+
+	t.Run("reports rejected events", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer srv.Close()
+
+		c, err := New(strings.Replace(srv.URL, "://", "://abc123@", 1) + "/42")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.CapturePanic(t.Context(), "boom", nil, nil); err == nil {
+			t.Error("CapturePanic() error = nil, want the rejection")
+		}
+	})
+
+	t.Run("nil client discards events", func(t *testing.T) {
+		var c *Client
+		if err := c.CapturePanic(t.Context(), "boom", nil, nil); err != nil {
+			t.Errorf("CapturePanic() error = %v, want nil", err)
+		}
+	})
+}
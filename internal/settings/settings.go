@@ -0,0 +1,389 @@
+package settings
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"jolly-okurb/internal/store"
+)
+
+// Feature names a behaviour that can be switched off per guild.
+type Feature string
+
+const (
+	FeatureReactions  Feature = "reactions"  // Replace skull reactions with jollyskull
+	FeatureDeletion   Feature = "deletion"   // Delete skull-only messages
+	FeatureHistorical Feature = "historical" // Scan message history on startup
+	FeatureNotice     Feature = "notice"     // Explain deletions in the channel, if DELETION_NOTICE is set
+)
+
+// Features lists every toggleable feature in display order.
+var Features = []Feature{FeatureReactions, FeatureDeletion, FeatureHistorical, FeatureNotice}
+
+// ParseFeature validates a feature name.
+func ParseFeature(name string) (Feature, error) {
+	f := Feature(name)
+	if !slices.Contains(Features, f) {
+		return "", fmt.Errorf("unknown feature %q", name)
+	}
+	return f, nil
+}
+
+// Output is how the bot formats reports and summaries it posts.
+type Output string
+
+const (
+	OutputEmbed Output = "embed" // Rich embeds with charts
+	OutputPlain Output = "plain" // Plain text that reads well with a screen reader
+)
+
+// ParseOutput validates an output format name.
+func ParseOutput(name string) (Output, error) {
+	switch o := Output(name); o {
+	case OutputEmbed, OutputPlain:
+		return o, nil
+	default:
+		return "", fmt.Errorf("unknown output %q (expected embed or plain)", name)
+	}
+}
+
+// MixedPolicy is what the bot does with a target user's message that has
+// skull emojis alongside other content, so it is not deleted as skull-only.
+type MixedPolicy string
+
+const (
+	MixedIgnore MixedPolicy = "ignore" // Leave the message alone
+	MixedReact  MixedPolicy = "react"  // React with jollyskull
+	MixedNotify MixedPolicy = "notify" // Reply with the message as it reads with jollyskulls
+)
+
+// ParseMixedPolicy validates a mixed content policy name.
+func ParseMixedPolicy(name string) (MixedPolicy, error) {
+	switch p := MixedPolicy(name); p {
+	case MixedIgnore, MixedReact, MixedNotify:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown mixed content policy %q (expected ignore, react, or notify)", name)
+	}
+}
+
+// Guild holds the runtime-managed settings for a single guild.
+type Guild struct {
+	Features map[Feature]bool `json:"features,omitempty"`
+	Paused   bool             `json:"paused,omitempty"` // Suspends every feature without changing the toggles
+	Exempt   map[string]bool  `json:"exempt,omitempty"` // Message IDs the bot must never act on
+
+	// Custom emoji names, in lower case, that no rule replaces, such as ones
+	// learned from undone replacements
+	Excluded map[string]bool `json:"excluded,omitempty"`
+
+	// Dry-run overrides; unset values fall back to the next level up and finally to DRY_RUN
+	DryRun        *bool           `json:"dry_run,omitempty"`
+	ChannelDryRun map[string]bool `json:"channel_dry_run,omitempty"`
+
+	PublicStats *PublicStats `json:"public_stats,omitempty"` // nil keeps the stats page private
+	Locale      string       `json:"locale,omitempty"`       // Language of replies and posts; empty means English
+	Output      Output       `json:"output,omitempty"`       // Empty means OutputEmbed
+	Mixed       MixedPolicy  `json:"mixed,omitempty"`        // Empty means MixedIgnore
+	Theme       string       `json:"theme,omitempty"`        // Reaction theme pack; empty means jollyskull
+
+	// Custom emojis of theme packs by name, found in the guild or uploaded.
+	// Uploaded ones stay here until deleted, even after the theme changes
+	ThemeEmojis map[string]ThemeEmoji `json:"theme_emojis,omitempty"`
+
+	// Imported the dry-run setting from the environment on the first start
+	// with a store
+	Imported bool `json:"imported,omitempty"`
+
+	// Target users. Once seeded from DISCORD_TARGET_USER_IDS and
+	// TARGET_GROUPS, on the first start with a store or the first change with
+	// /jolly targets, they are every target user and the environment's no
+	// longer count. Until then they are added on top of the environment's
+	Targets       []string `json:"targets,omitempty"`
+	TargetsSeeded bool     `json:"targets_seeded,omitempty"`
+}
+
+// PublicStats configures a guild's public stats page.
+type PublicStats struct {
+	Token     string `json:"token"`                // Unguessable part of the page URL
+	ShowNames bool   `json:"show_names,omitempty"` // Show usernames instead of anonymous ranks
+}
+
+// ThemeEmoji is a custom emoji of a theme pack in a guild.
+type ThemeEmoji struct {
+	ID       string `json:"id"`
+	Uploaded bool   `json:"uploaded,omitempty"` // Uploaded by the bot, which deletes it with the theme
+}
+
+// PlainOutput reports whether reports should be posted as plain text.
+func (g Guild) PlainOutput() bool {
+	return g.Output == OutputPlain
+}
+
+// MixedPolicy returns the policy for messages mixing skulls with other content.
+func (g Guild) MixedPolicy() MixedPolicy {
+	if g.Mixed == "" {
+		return MixedIgnore
+	}
+	return g.Mixed
+}
+
+// Enabled reports whether f is on. Features are enabled unless explicitly disabled.
+func (g Guild) Enabled(f Feature) bool {
+	enabled, ok := g.Features[f]
+	return !ok || enabled
+}
+
+// TargetsWith returns the stored target users once they are seeded. Until
+// then it returns env, the target users of the environment, followed by those
+// added on this server that env lacks.
+func (g Guild) TargetsWith(env []string) []string {
+	if g.TargetsSeeded {
+		return g.Targets
+	}
+	targets := slices.Clone(env)
+	for _, id := range g.Targets {
+		if !slices.Contains(env, id) {
+			targets = append(targets, id)
+		}
+	}
+	return targets
+}
+
+// IsExempt reports whether messageID has been exempted from all actions.
+func (g Guild) IsExempt(messageID string) bool {
+	return g.Exempt[messageID]
+}
+
+// IsExcluded reports whether the custom emoji named name is excluded from
+// the rules. Case is ignored.
+func (g Guild) IsExcluded(name string) bool {
+	return g.Excluded[strings.ToLower(name)]
+}
+
+// DryRunFor reports whether actions in channelID should only be logged.
+// A channel override wins over the guild setting, which wins over def.
+func (g Guild) DryRunFor(channelID string, def bool) bool {
+	if v, ok := g.ChannelDryRun[channelID]; ok {
+		return v
+	}
+	if g.DryRun != nil {
+		return *g.DryRun
+	}
+	return def
+}
+
+// Manager loads and saves guild settings through the store, caching them in memory.
+type Manager struct {
+	store    store.Store
+	mu       sync.RWMutex
+	cache    map[string]Guild
+	updateMu sync.Mutex // Serializes read-modify-write cycles
+}
+
+func NewManager(s store.Store) *Manager {
+	return &Manager{store: s, cache: make(map[string]Guild)}
+}
+
+// Guild returns the settings for guildID, or defaults if none are stored.
+func (m *Manager) Guild(guildID string) (Guild, error) {
+	m.mu.RLock()
+	g, ok := m.cache[guildID]
+	m.mu.RUnlock()
+	if ok {
+		return g, nil
+	}
+
+	if _, err := m.store.Get(key(guildID), &g); err != nil {
+		return Guild{}, fmt.Errorf("failed to load guild settings: %w", err)
+	}
+
+	m.mu.Lock()
+	m.cache[guildID] = g
+	m.mu.Unlock()
+	return g, nil
+}
+
+// SetFeature enables or disables f for guildID and persists the change.
+func (m *Manager) SetFeature(guildID string, f Feature, enabled bool) error {
+	return m.update(guildID, func(g *Guild) {
+		if g.Features == nil {
+			g.Features = make(map[Feature]bool)
+		}
+		g.Features[f] = enabled
+	})
+}
+
+// SetExempt adds messageID to, or removes it from, the guild's exempt messages.
+func (m *Manager) SetExempt(guildID, messageID string, exempt bool) error {
+	return m.update(guildID, func(g *Guild) {
+		if !exempt {
+			delete(g.Exempt, messageID)
+			return
+		}
+		if g.Exempt == nil {
+			g.Exempt = make(map[string]bool)
+		}
+		g.Exempt[messageID] = true
+	})
+}
+
+// SetTarget adds userID to, or removes it from, the guild's target users,
+// first seeding them from env unless they were seeded before.
+func (m *Manager) SetTarget(guildID, userID string, target bool, env []string) error {
+	return m.update(guildID, func(g *Guild) {
+		seedTargets(g, env)
+		g.Targets = slices.DeleteFunc(slices.Clone(g.Targets), func(id string) bool { return id == userID })
+		if target {
+			g.Targets = append(g.Targets, userID)
+		}
+	})
+}
+
+// SetExcluded excludes the custom emoji named name from the rules, or stops
+// excluding it.
+func (m *Manager) SetExcluded(guildID, name string, excluded bool) error {
+	name = strings.ToLower(name)
+	return m.update(guildID, func(g *Guild) {
+		if !excluded {
+			delete(g.Excluded, name)
+			return
+		}
+		if g.Excluded == nil {
+			g.Excluded = make(map[string]bool)
+		}
+		g.Excluded[name] = true
+	})
+}
+
+// SetDryRun sets the dry-run override for a channel, or for the whole guild
+// if channelID is empty. A nil value removes the override.
+func (m *Manager) SetDryRun(guildID, channelID string, value *bool) error {
+	return m.update(guildID, func(g *Guild) {
+		if channelID == "" {
+			g.DryRun = value
+			return
+		}
+		if value == nil {
+			delete(g.ChannelDryRun, channelID)
+			return
+		}
+		if g.ChannelDryRun == nil {
+			g.ChannelDryRun = make(map[string]bool)
+		}
+		g.ChannelDryRun[channelID] = *value
+	})
+}
+
+// SetPublicStats publishes the guild's stats page with p, or unpublishes it if p is nil.
+func (m *Manager) SetPublicStats(guildID string, p *PublicStats) error {
+	return m.update(guildID, func(g *Guild) {
+		g.PublicStats = p
+	})
+}
+
+// SetLocale sets the language the bot uses in the guild.
+func (m *Manager) SetLocale(guildID, locale string) error {
+	return m.update(guildID, func(g *Guild) {
+		g.Locale = locale
+	})
+}
+
+// SetTheme sets the reaction theme pack of the guild, or clears it if theme
+// is empty.
+func (m *Manager) SetTheme(guildID, theme string) error {
+	return m.update(guildID, func(g *Guild) {
+		g.Theme = theme
+	})
+}
+
+// SetThemeEmojis replaces the custom emojis of theme packs in the guild.
+func (m *Manager) SetThemeEmojis(guildID string, emojis map[string]ThemeEmoji) error {
+	return m.update(guildID, func(g *Guild) {
+		g.ThemeEmojis = emojis
+	})
+}
+
+// SetOutput sets how the bot formats what it posts in the guild.
+func (m *Manager) SetOutput(guildID string, o Output) error {
+	return m.update(guildID, func(g *Guild) {
+		g.Output = o
+	})
+}
+
+// SetMixedPolicy sets what the bot does with messages mixing skulls with other content.
+func (m *Manager) SetMixedPolicy(guildID string, p MixedPolicy) error {
+	return m.update(guildID, func(g *Guild) {
+		g.Mixed = p
+	})
+}
+
+// SetPaused suspends or resumes every feature in the guild.
+func (m *Manager) SetPaused(guildID string, paused bool) error {
+	return m.update(guildID, func(g *Guild) {
+		g.Paused = paused
+	})
+}
+
+// Import stores the dry-run setting as the guild's own, unless it was
+// imported before or the guild already overrides dry run, and seeds the
+// guild's target users from targets unless they were seeded before. It
+// reports whether anything was imported; afterwards the stored values win
+// over the environment they came from.
+func (m *Manager) Import(guildID string, targets []string, dryRun bool) (bool, error) {
+	g, err := m.Guild(guildID)
+	if err != nil || g.Imported && g.TargetsSeeded {
+		return false, err
+	}
+	err = m.update(guildID, func(g *Guild) {
+		seedTargets(g, targets)
+		if !g.Imported && g.DryRun == nil {
+			g.DryRun = &dryRun
+		}
+		g.Imported = true
+	})
+	return err == nil, err
+}
+
+// seedTargets makes env and the target users added to g on top of it the
+// stored target users of g, unless they were seeded before.
+func seedTargets(g *Guild, env []string) {
+	if !g.TargetsSeeded {
+		g.Targets = g.TargetsWith(env)
+		g.TargetsSeeded = true
+	}
+}
+
+// update applies fn to a copy of the guild's settings and stores the result.
+func (m *Manager) update(guildID string, fn func(*Guild)) error {
+	m.updateMu.Lock()
+	defer m.updateMu.Unlock()
+
+	g, err := m.Guild(guildID)
+	if err != nil {
+		return err
+	}
+
+	// Copy maps so readers holding the previous value never see a partial update
+	g.Features = maps.Clone(g.Features)
+	g.Exempt = maps.Clone(g.Exempt)
+	g.Excluded = maps.Clone(g.Excluded)
+	g.ChannelDryRun = maps.Clone(g.ChannelDryRun)
+	fn(&g)
+
+	if err := m.store.Put(key(guildID), g); err != nil {
+		return fmt.Errorf("failed to save guild settings: %w", err)
+	}
+
+	m.mu.Lock()
+	m.cache[guildID] = g
+	m.mu.Unlock()
+	return nil
+}
+
+func key(guildID string) string {
+	return "guilds/" + guildID + "/settings"
+}
@@ -0,0 +1,368 @@
+package settings
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"jolly-okurb/internal/store"
+)
+
+func TestParseFeature(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Feature
+		wantErr bool
+	}{
+		{"reactions", "reactions", FeatureReactions, false},
+		{"deletion", "deletion", FeatureDeletion, false},
+		{"historical", "historical", FeatureHistorical, false},
+		{"notice", "notice", FeatureNotice, false},
+		{"unknown", "summaries", "", true},
+		{"empty", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFeature(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFeature(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFeature(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager(t *testing.T) {
+	t.Run("features default to enabled", func(t *testing.T) {
+		m := NewManager(store.NewMemory())
+		g, err := m.Guild("guild-1")
+		if err != nil {
+			t.Fatalf("Guild() unexpected error: %v", err)
+		}
+		for _, f := range Features {
+			if !g.Enabled(f) {
+				t.Errorf("feature %q should default to enabled", f)
+			}
+		}
+	})
+
+	t.Run("disabling is per guild", func(t *testing.T) {
+		m := NewManager(store.NewMemory())
+		if err := m.SetFeature("guild-1", FeatureDeletion, false); err != nil {
+			t.Fatalf("SetFeature() unexpected error: %v", err)
+		}
+
+		g1, _ := m.Guild("guild-1")
+		if g1.Enabled(FeatureDeletion) {
+			t.Error("deletion should be disabled for guild-1")
+		}
+		if !g1.Enabled(FeatureReactions) {
+			t.Error("reactions should remain enabled for guild-1")
+		}
+
+		g2, _ := m.Guild("guild-2")
+		if !g2.Enabled(FeatureDeletion) {
+			t.Error("deletion should remain enabled for guild-2")
+		}
+	})
+
+	t.Run("persists through the store", func(t *testing.T) {
+		s := store.NewMemory()
+		if err := NewManager(s).SetFeature("guild-1", FeatureHistorical, false); err != nil {
+			t.Fatalf("SetFeature() unexpected error: %v", err)
+		}
+
+		g, err := NewManager(s).Guild("guild-1")
+		if err != nil {
+			t.Fatalf("Guild() unexpected error: %v", err)
+		}
+		if g.Enabled(FeatureHistorical) {
+			t.Error("historical should stay disabled after reloading from the store")
+		}
+	})
+	t.Run("exempt messages", func(t *testing.T) {
+		m := NewManager(store.NewMemory())
+		if err := m.SetExempt("guild-1", "msg-1", true); err != nil {
+			t.Fatalf("SetExempt() unexpected error: %v", err)
+		}
+
+		g, _ := m.Guild("guild-1")
+		if !g.IsExempt("msg-1") {
+			t.Error("msg-1 should be exempt")
+		}
+		if g.IsExempt("msg-2") {
+			t.Error("msg-2 should not be exempt")
+		}
+
+		if err := m.SetExempt("guild-1", "msg-1", false); err != nil {
+			t.Fatalf("SetExempt() unexpected error: %v", err)
+		}
+		if g, _ := m.Guild("guild-1"); g.IsExempt("msg-1") {
+			t.Error("msg-1 should no longer be exempt")
+		}
+	})
+	t.Run("excluded emojis", func(t *testing.T) {
+		m := NewManager(store.NewMemory())
+		if err := m.SetExcluded("guild-1", "SkullCandy", true); err != nil {
+			t.Fatalf("SetExcluded() unexpected error: %v", err)
+		}
+
+		g, _ := m.Guild("guild-1")
+		if !g.IsExcluded("skullcandy") || !g.IsExcluded("SKULLCANDY") {
+			t.Error("skullcandy should be excluded, ignoring case")
+		}
+		if g.IsExcluded("skull") {
+			t.Error("skull should not be excluded")
+		}
+
+		if err := m.SetExcluded("guild-1", "skullcandy", false); err != nil {
+			t.Fatalf("SetExcluded() unexpected error: %v", err)
+		}
+		if g, _ := m.Guild("guild-1"); g.IsExcluded("SkullCandy") {
+			t.Error("skullcandy should no longer be excluded")
+		}
+	})
+	t.Run("dry run overrides", func(t *testing.T) {
+		m := NewManager(store.NewMemory())
+		on, off := true, false
+
+		g, _ := m.Guild("guild-1")
+		if g.DryRunFor("chan-1", false) || !g.DryRunFor("chan-1", true) {
+			t.Error("without overrides the default should apply")
+		}
+
+		if err := m.SetDryRun("guild-1", "", &on); err != nil {
+			t.Fatalf("SetDryRun() unexpected error: %v", err)
+		}
+		if err := m.SetDryRun("guild-1", "chan-2", &off); err != nil {
+			t.Fatalf("SetDryRun() unexpected error: %v", err)
+		}
+		g, _ = m.Guild("guild-1")
+		if !g.DryRunFor("chan-1", false) {
+			t.Error("the guild override should beat the default")
+		}
+		if g.DryRunFor("chan-2", true) {
+			t.Error("the channel override should beat the guild override")
+		}
+
+		if err := m.SetDryRun("guild-1", "chan-2", nil); err != nil {
+			t.Fatalf("SetDryRun() unexpected error: %v", err)
+		}
+		g, _ = m.Guild("guild-1")
+		if !g.DryRunFor("chan-2", false) {
+			t.Error("clearing the channel override should fall back to the guild override")
+		}
+	})
+	t.Run("public stats", func(t *testing.T) {
+		st := store.NewMemory()
+		m := NewManager(st)
+		if g, _ := m.Guild("guild-1"); g.PublicStats != nil {
+			t.Error("stats should be private by default")
+		}
+
+		want := PublicStats{Token: "secret", ShowNames: true}
+		if err := m.SetPublicStats("guild-1", &want); err != nil {
+			t.Fatalf("SetPublicStats() unexpected error: %v", err)
+		}
+		if g, _ := NewManager(st).Guild("guild-1"); g.PublicStats == nil || *g.PublicStats != want {
+			t.Errorf("PublicStats = %+v, want %+v", g.PublicStats, want)
+		}
+
+		if err := m.SetPublicStats("guild-1", nil); err != nil {
+			t.Fatalf("SetPublicStats() unexpected error: %v", err)
+		}
+		if g, _ := m.Guild("guild-1"); g.PublicStats != nil {
+			t.Error("stats should be private again")
+		}
+	})
+	t.Run("locale", func(t *testing.T) {
+		m := NewManager(store.NewMemory())
+		if err := m.SetLocale("guild-1", "nl"); err != nil {
+			t.Fatalf("SetLocale() unexpected error: %v", err)
+		}
+		if g, _ := m.Guild("guild-1"); g.Locale != "nl" {
+			t.Errorf("Locale = %q, want %q", g.Locale, "nl")
+		}
+		if g, _ := m.Guild("guild-2"); g.Locale != "" {
+			t.Errorf("Locale = %q, want the default", g.Locale)
+		}
+	})
+	t.Run("theme", func(t *testing.T) {
+		m := NewManager(store.NewMemory())
+		if err := m.SetTheme("guild-1", "christmas"); err != nil {
+			t.Fatalf("SetTheme() unexpected error: %v", err)
+		}
+		if g, _ := m.Guild("guild-1"); g.Theme != "christmas" {
+			t.Errorf("Theme = %q, want %q", g.Theme, "christmas")
+		}
+		if err := m.SetTheme("guild-1", ""); err != nil {
+			t.Fatalf("SetTheme() unexpected error: %v", err)
+		}
+		if g, _ := m.Guild("guild-1"); g.Theme != "" {
+			t.Errorf("Theme = %q, want it cleared", g.Theme)
+		}
+		emojis := map[string]ThemeEmoji{"jollysanta": {ID: "1", Uploaded: true}}
+		if err := m.SetThemeEmojis("guild-1", emojis); err != nil {
+			t.Fatalf("SetThemeEmojis() unexpected error: %v", err)
+		}
+		if g, _ := m.Guild("guild-1"); !maps.Equal(g.ThemeEmojis, emojis) {
+			t.Errorf("ThemeEmojis = %v, want %v", g.ThemeEmojis, emojis)
+		}
+	})
+	t.Run("output", func(t *testing.T) {
+		m := NewManager(store.NewMemory())
+		if g, _ := m.Guild("guild-1"); g.PlainOutput() {
+			t.Error("output should default to embeds")
+		}
+		if err := m.SetOutput("guild-1", OutputPlain); err != nil {
+			t.Fatalf("SetOutput() unexpected error: %v", err)
+		}
+		if g, _ := m.Guild("guild-1"); !g.PlainOutput() {
+			t.Error("output should be plain")
+		}
+	})
+	t.Run("paused", func(t *testing.T) {
+		m := NewManager(store.NewMemory())
+		if err := m.SetPaused("guild-1", true); err != nil {
+			t.Fatalf("SetPaused() unexpected error: %v", err)
+		}
+		g, _ := m.Guild("guild-1")
+		if !g.Paused {
+			t.Error("guild should be paused")
+		}
+		if !g.Enabled(FeatureReactions) {
+			t.Error("pausing should leave the feature toggles alone")
+		}
+	})
+	t.Run("mixed policy", func(t *testing.T) {
+		m := NewManager(store.NewMemory())
+		if g, _ := m.Guild("guild-1"); g.MixedPolicy() != MixedIgnore {
+			t.Errorf("MixedPolicy() = %q, want %q by default", g.MixedPolicy(), MixedIgnore)
+		}
+		if err := m.SetMixedPolicy("guild-1", MixedNotify); err != nil {
+			t.Fatalf("SetMixedPolicy() unexpected error: %v", err)
+		}
+		if g, _ := m.Guild("guild-1"); g.MixedPolicy() != MixedNotify {
+			t.Errorf("MixedPolicy() = %q, want %q", g.MixedPolicy(), MixedNotify)
+		}
+	})
+	t.Run("targets", func(t *testing.T) {
+		m := NewManager(store.NewMemory())
+		if err := m.SetTarget("guild-1", "u3", true, []string{"u1", "u2"}); err != nil {
+			t.Fatalf("SetTarget() unexpected error: %v", err)
+		}
+		// Once seeded, the environment passed in no longer matters
+		if err := m.SetTarget("guild-1", "u1", false, []string{"u1", "u4"}); err != nil {
+			t.Fatalf("SetTarget() unexpected error: %v", err)
+		}
+
+		g, _ := m.Guild("guild-1")
+		if !g.TargetsSeeded {
+			t.Error("changing a target user should seed the stored ones")
+		}
+		if got := g.TargetsWith([]string{"u1", "u4"}); !slices.Equal(got, []string{"u2", "u3"}) {
+			t.Errorf("TargetsWith() = %v, want the stored target users only", got)
+		}
+	})
+	t.Run("targets before seeding", func(t *testing.T) {
+		g := Guild{Targets: []string{"u2", "u3"}}
+		if got := g.TargetsWith([]string{"u1", "u2"}); !slices.Equal(got, []string{"u1", "u2", "u3"}) {
+			t.Errorf("TargetsWith() = %v, want the environment's, followed by the added ones", got)
+		}
+	})
+	t.Run("import", func(t *testing.T) {
+		m := NewManager(store.NewMemory())
+		on := true
+		if err := m.SetDryRun("guild-1", "", &on); err != nil {
+			t.Fatal(err)
+		}
+
+		if imported, err := m.Import("guild-1", []string{"u1"}, false); err != nil || !imported {
+			t.Fatalf("Import() = %v, %v, want an import", imported, err)
+		}
+		if imported, err := m.Import("guild-1", []string{"u2"}, false); err != nil || imported {
+			t.Fatalf("second Import() = %v, %v, want nothing imported", imported, err)
+		}
+
+		g, _ := m.Guild("guild-1")
+		if !g.DryRunFor("", false) {
+			t.Error("an existing dry-run override should be kept")
+		}
+		if !slices.Equal(g.Targets, []string{"u1"}) {
+			t.Errorf("Targets = %v, want the first import's", g.Targets)
+		}
+	})
+	t.Run("import seeds targets of an older store", func(t *testing.T) {
+		m := NewManager(store.NewMemory())
+		if _, err := m.Import("guild-1", nil, true); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.update("guild-1", func(g *Guild) { g.TargetsSeeded = false; g.Targets = []string{"u2"} }); err != nil {
+			t.Fatal(err)
+		}
+
+		if imported, err := m.Import("guild-1", []string{"u1"}, false); err != nil || !imported {
+			t.Fatalf("Import() = %v, %v, want the targets imported", imported, err)
+		}
+		g, _ := m.Guild("guild-1")
+		if !slices.Equal(g.Targets, []string{"u1", "u2"}) {
+			t.Errorf("Targets = %v, want the environment's and the added one", g.Targets)
+		}
+		if !g.DryRunFor("", false) {
+			t.Error("the dry-run setting imported before should be kept")
+		}
+	})
+}
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Output
+		wantErr bool
+	}{
+		{"embed", OutputEmbed, false},
+		{"plain", OutputPlain, false},
+		{"fancy", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOutput(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseOutput(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseOutput(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMixedPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    MixedPolicy
+		wantErr bool
+	}{
+		{"ignore", MixedIgnore, false},
+		{"react", MixedReact, false},
+		{"notify", MixedNotify, false},
+		{"delete", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMixedPolicy(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMixedPolicy(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMixedPolicy(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
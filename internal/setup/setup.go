@@ -0,0 +1,226 @@
+// Package setup walks a first-time self-hoster through the required
+// settings, checking each answer against the Discord API, and writes them
+// as an .envrc file.
+package setup
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// API is the part of the Discord API the answers are checked against.
+type API interface {
+	User(userID string, options ...discordgo.RequestOption) (*discordgo.User, error)
+	UserGuilds(limit int, beforeID, afterID string, withCounts bool, options ...discordgo.RequestOption) ([]*discordgo.UserGuild, error)
+	Guild(guildID string, options ...discordgo.RequestOption) (*discordgo.Guild, error)
+	GuildChannels(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Channel, error)
+	GuildMember(guildID, userID string, options ...discordgo.RequestOption) (*discordgo.Member, error)
+	GuildEmojis(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Emoji, error)
+}
+
+// Connect returns an API client authenticated with a bot token.
+type Connect func(token string) (API, error)
+
+// Answers are the settings the bot needs to start.
+type Answers struct {
+	Token         string
+	GuildID       string
+	ChannelName   string
+	TargetUserIDs []string
+	JollySkullID  string // As name:id
+}
+
+// errEOF is returned when the input ends before every question is answered.
+var errEOF = errors.New("input ended before setup finished")
+
+// wizard asks questions on out and reads answers from in.
+type wizard struct {
+	in  *bufio.Scanner
+	out io.Writer
+}
+
+// Run asks for each setting until the answer checks out against Discord.
+func Run(in io.Reader, out io.Writer, connect Connect) (*Answers, error) {
+	w := &wizard{in: bufio.NewScanner(in), out: out}
+	var a Answers
+	var api API
+
+	fmt.Fprintln(out, "This sets up jolly-okurb. Create a bot at https://discord.com/developers/applications first,")
+	fmt.Fprintln(out, "enable its Server Members and Message Content intents, and invite it to your server.")
+	fmt.Fprintln(out)
+
+	err := w.ask("Bot token", "", func(token string) error {
+		c, err := connect(token)
+		if err != nil {
+			return err
+		}
+		me, err := c.User("@me")
+		if err != nil {
+			return fmt.Errorf("Discord rejected the token: %w", err)
+		}
+		fmt.Fprintf(out, "  Logged in as %s\n", me.Username)
+		a.Token, api = token, c
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	guilds, err := api.UserGuilds(200, "", "", false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the bot's servers: %w", err)
+	}
+	if len(guilds) == 0 {
+		return nil, errors.New("the bot is not in any server yet; invite it first, e.g. with the link from `bot invite`")
+	}
+	fmt.Fprintln(out, "The bot is in these servers:")
+	for _, g := range guilds {
+		fmt.Fprintf(out, "  %s  %s\n", g.ID, g.Name)
+	}
+	err = w.ask("Server ID", guilds[0].ID, func(id string) error {
+		g, err := api.Guild(id)
+		if err != nil {
+			return fmt.Errorf("the bot cannot see server %s: %w", id, err)
+		}
+		fmt.Fprintf(out, "  Using %s\n", g.Name)
+		a.GuildID = id
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	channels, err := api.GuildChannels(a.GuildID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list channels: %w", err)
+	}
+	err = w.ask("Channel name or pattern such as jolly*", "jollyposting", func(pattern string) error {
+		var names []string
+		for _, c := range channels {
+			if ok, err := path.Match(pattern, c.Name); err != nil {
+				return fmt.Errorf("invalid pattern: %w", err)
+			} else if ok && c.Type != discordgo.ChannelTypeGuildCategory {
+				names = append(names, "#"+c.Name)
+			}
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("no channel matches %q", pattern)
+		}
+		fmt.Fprintf(out, "  Watching %s\n", strings.Join(names, ", "))
+		a.ChannelName = pattern
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.ask("User IDs to act on, comma-separated", "", func(list string) error {
+		var ids, names []string
+		for id := range strings.SplitSeq(list, ",") {
+			if id = strings.TrimSpace(id); id == "" || slices.Contains(ids, id) {
+				continue
+			}
+			m, err := api.GuildMember(a.GuildID, id)
+			if err != nil {
+				return fmt.Errorf("user %s is not a member of the server: %w", id, err)
+			}
+			ids, names = append(ids, id), append(names, m.User.Username)
+		}
+		if len(ids) == 0 {
+			return errors.New("enter at least one user ID")
+		}
+		fmt.Fprintf(out, "  Targeting %s\n", strings.Join(names, ", "))
+		a.TargetUserIDs = ids
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	emojis, err := api.GuildEmojis(a.GuildID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list emojis: %w", err)
+	}
+	err = w.ask("Name of the emoji that replaces skulls, or a Unicode emoji", "jollyskull", func(name string) error {
+		name = strings.Trim(name, ":")
+		for _, e := range emojis {
+			if strings.EqualFold(e.Name, name) {
+				a.JollySkullID = e.Name + ":" + e.ID
+				fmt.Fprintf(out, "  Using :%s:\n", e.Name)
+				return nil
+			}
+		}
+		if !strings.ContainsFunc(name, func(r rune) bool { return r < utf8.RuneSelf && unicode.IsLetter(r) }) {
+			a.JollySkullID = name
+			fmt.Fprintf(out, "  Using %s\n", name)
+			return nil
+		}
+		return fmt.Errorf("the server has no custom emoji named %q", name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// ask prompts until check accepts an answer. An empty answer means def.
+func (w *wizard) ask(question, def string, check func(string) error) error {
+	for {
+		if def != "" {
+			fmt.Fprintf(w.out, "%s [%s]: ", question, def)
+		} else {
+			fmt.Fprintf(w.out, "%s: ", question)
+		}
+		if !w.in.Scan() {
+			fmt.Fprintln(w.out)
+			if err := w.in.Err(); err != nil {
+				return err
+			}
+			return errEOF
+		}
+		answer := strings.TrimSpace(w.in.Text())
+		if answer == "" {
+			answer = def
+		}
+		if answer == "" {
+			continue
+		}
+		if err := check(answer); err != nil {
+			fmt.Fprintf(w.out, "  %v\n", err)
+			continue
+		}
+		return nil
+	}
+}
+
+// WriteEnvrc writes the answers as shell exports, in the format of
+// .envrc.example.
+func (a *Answers) WriteEnvrc(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("# Written by `bot init`; see .envrc.example for every setting\n")
+	for _, kv := range [][2]string{
+		{"DISCORD_TOKEN", a.Token},
+		{"DISCORD_GUILD_ID", a.GuildID},
+		{"DISCORD_CHANNEL_NAME", a.ChannelName},
+		{"DISCORD_TARGET_USER_IDS", strings.Join(a.TargetUserIDs, ",")},
+		{"DISCORD_JOLLYSKULL_ID", a.JollySkullID},
+	} {
+		fmt.Fprintf(&sb, "export %s=%s\n", kv[0], shellQuote(kv[1]))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
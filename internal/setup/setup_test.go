@@ -0,0 +1,164 @@
+package setup
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fakeAPI is a bot that is in one server with a few channels, members, and emojis.
+type fakeAPI struct{}
+
+var errNotFound = errors.New("HTTP 404 Not Found")
+
+func (fakeAPI) User(userID string, options ...discordgo.RequestOption) (*discordgo.User, error) {
+	return &discordgo.User{ID: "bot1", Username: "jolly"}, nil
+}
+
+func (fakeAPI) UserGuilds(limit int, beforeID, afterID string, withCounts bool, options ...discordgo.RequestOption) ([]*discordgo.UserGuild, error) {
+	return []*discordgo.UserGuild{{ID: "guild1", Name: "Skull Club"}}, nil
+}
+
+func (fakeAPI) Guild(guildID string, options ...discordgo.RequestOption) (*discordgo.Guild, error) {
+	if guildID != "guild1" {
+		return nil, errNotFound
+	}
+	return &discordgo.Guild{ID: guildID, Name: "Skull Club"}, nil
+}
+
+func (fakeAPI) GuildChannels(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Channel, error) {
+	return []*discordgo.Channel{
+		{Name: "general", Type: discordgo.ChannelTypeGuildText},
+		{Name: "jollyposting", Type: discordgo.ChannelTypeGuildText},
+		{Name: "jolly-archive", Type: discordgo.ChannelTypeGuildCategory},
+	}, nil
+}
+
+func (fakeAPI) GuildMember(guildID, userID string, options ...discordgo.RequestOption) (*discordgo.Member, error) {
+	if userID != "111" && userID != "222" {
+		return nil, errNotFound
+	}
+	return &discordgo.Member{User: &discordgo.User{ID: userID, Username: "user" + userID}}, nil
+}
+
+func (fakeAPI) GuildEmojis(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Emoji, error) {
+	return []*discordgo.Emoji{{ID: "999", Name: "JollySkull"}}, nil
+}
+
+func connectWith(token string) (API, error) {
+	if token != "good-token" {
+		return nil, errors.New("Discord rejected the token: HTTP 401 Unauthorized")
+	}
+	return fakeAPI{}, nil
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      []string
+		want       *Answers
+		wantErr    error
+		wantOutput []string
+	}{
+		{
+			name:  "defaults",
+			input: []string{"good-token", "", "", "111", ""},
+			want: &Answers{
+				Token:         "good-token",
+				GuildID:       "guild1",
+				ChannelName:   "jollyposting",
+				TargetUserIDs: []string{"111"},
+				JollySkullID:  "JollySkull:999",
+			},
+			wantOutput: []string{"Logged in as jolly", "guild1  Skull Club", "Watching #jollyposting", "Targeting user111", "Using :JollySkull:"},
+		},
+		{
+			name: "asks again after invalid answers",
+			input: []string{
+				"bad-token", "good-token",
+				"guild2", "guild1",
+				"jolly-archive", "jolly*",
+				"111, 333", "", "111, 222, 111",
+				":skull:", ":jollyskull:",
+			},
+			want: &Answers{
+				Token:         "good-token",
+				GuildID:       "guild1",
+				ChannelName:   "jolly*",
+				TargetUserIDs: []string{"111", "222"},
+				JollySkullID:  "JollySkull:999",
+			},
+			wantOutput: []string{
+				"HTTP 401 Unauthorized",
+				"the bot cannot see server guild2",
+				`no channel matches "jolly-archive"`,
+				"user 333 is not a member of the server",
+				`the server has no custom emoji named "skull"`,
+			},
+		},
+		{
+			name:  "unicode emoji",
+			input: []string{"good-token", "", "", "111", "🎅"},
+			want: &Answers{
+				Token:         "good-token",
+				GuildID:       "guild1",
+				ChannelName:   "jollyposting",
+				TargetUserIDs: []string{"111"},
+				JollySkullID:  "🎅",
+			},
+			wantOutput: []string{"Using 🎅"},
+		},
+		{name: "input ends early", input: []string{"good-token", ""}, wantErr: errEOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := Run(strings.NewReader(strings.Join(tt.input, "\n")+"\n"), &out, connectWith)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Run() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Run() = %+v, want %+v", got, tt.want)
+			}
+			for _, s := range tt.wantOutput {
+				if !strings.Contains(out.String(), s) {
+					t.Errorf("output does not contain %q:\n%s", s, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestAnswers_WriteEnvrc(t *testing.T) {
+	a := &Answers{
+		Token:         "tok'en",
+		GuildID:       "guild1",
+		ChannelName:   "jolly*",
+		TargetUserIDs: []string{"111", "222"},
+		JollySkullID:  "jollyskull:999",
+	}
+	var buf bytes.Buffer
+	if err := a.WriteEnvrc(&buf); err != nil {
+		t.Fatalf("WriteEnvrc() error = %v", err)
+	}
+
+	// The shell must read back exactly what was written
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell to source the file with")
+	}
+	script := buf.String() + `printf '%s\n' "$DISCORD_TOKEN" "$DISCORD_GUILD_ID" "$DISCORD_CHANNEL_NAME" "$DISCORD_TARGET_USER_IDS" "$DISCORD_JOLLYSKULL_ID"`
+	got, err := exec.Command(sh, "-c", script).Output()
+	if err != nil {
+		t.Fatalf("sourcing the file failed: %v\n%s", err, buf.String())
+	}
+	want := "tok'en\nguild1\njolly*\n111,222\njollyskull:999\n"
+	if string(got) != want {
+		t.Errorf("sourced values = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,82 @@
+// Package snowflake does time math on Discord IDs. A snowflake holds the
+// millisecond it was generated at in its high bits, above the worker, process,
+// and increment, so IDs order by time across shards and bound messages by
+// time without fetching them.
+package snowflake
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Epoch is the Discord epoch, the first second of 2015, in Unix milliseconds.
+const Epoch = 1420070400000
+
+// timestampShift is the number of low bits holding worker, process and sequence numbers.
+const timestampShift = 22
+
+// Compare orders two Discord snowflake IDs numerically, returning -1, 0 or +1.
+// Snowflakes are decimal strings without leading zeros, so a longer ID is always
+// larger and IDs of equal length compare lexically.
+func Compare(a, b string) int {
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// FromTime returns the smallest snowflake generated at t. It can be used as a
+// before/after bound when paginating messages by time.
+func FromTime(t time.Time) string {
+	ms := max(t.UnixMilli()-Epoch, 0)
+	return strconv.FormatUint(uint64(ms)<<timestampShift, 10)
+}
+
+// Time returns when the snowflake id was generated, to the millisecond.
+func Time(id string) (time.Time, error) {
+	v, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid snowflake %q: %w", id, err)
+	}
+	return time.UnixMilli(int64(v>>timestampShift) + Epoch).UTC(), nil
+}
+
+// Before reports whether the snowflake id was generated before t. IDs that
+// are not numeric sort before every time.
+func Before(id string, t time.Time) bool {
+	return Compare(id, FromTime(t)) < 0
+}
+
+// incrementMask keeps the 12-bit increment of a snowflake.
+const incrementMask = 1<<12 - 1
+
+// Generate returns a snowflake for t with increment in its low bits, so IDs
+// generated in the same millisecond stay unique and ordered by increment.
+func Generate(t time.Time, increment uint64) string {
+	ms := max(t.UnixMilli()-Epoch, 0)
+	return strconv.FormatUint(uint64(ms)<<timestampShift|increment&incrementMask, 10)
+}
+
+// Split divides the ID range (low, high] into at most n contiguous ranges of
+// roughly equal span and returns their bounds from high to low: the first
+// range is (bounds[1], bounds[0]], and so on. IDs that are not numeric, or a
+// range too small to divide, yield a single range.
+func Split(low, high string, n int) []string {
+	lo, errLo := strconv.ParseUint(low, 10, 64)
+	hi, errHi := strconv.ParseUint(high, 10, 64)
+	if errLo != nil || errHi != nil || n <= 1 || hi <= lo || hi-lo < uint64(n) {
+		return []string{high, low}
+	}
+
+	step := (hi - lo) / uint64(n)
+	bounds := make([]string, 0, n+1)
+	for i := range uint64(n) {
+		bounds = append(bounds, strconv.FormatUint(hi-i*step, 10))
+	}
+	return append(bounds, low)
+}
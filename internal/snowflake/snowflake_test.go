@@ -0,0 +1,147 @@
+package snowflake
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected int
+	}{
+		{"equal", "1325376000000000000", "1325376000000000000", 0},
+		{"smaller same length", "1325376000000000000", "1325376000000000001", -1},
+		{"larger same length", "1325376000000000001", "1325376000000000000", 1},
+		{"shorter is smaller", "999999999999999999", "1000000000000000000", -1},
+		{"longer is larger", "1000000000000000000", "999999999999999999", 1},
+		{"empty is smallest", "", "1", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Compare(tt.a, tt.b); got != tt.expected {
+				t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFromTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    time.Time
+		expected string
+	}{
+		{"discord epoch", time.UnixMilli(Epoch), "0"},
+		{"before epoch", time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC), "0"},
+		{"one millisecond", time.UnixMilli(Epoch + 1), "4194304"},
+		{"2025", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), "1323802873036800000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromTime(tt.input); got != tt.expected {
+				t.Errorf("FromTime(%v) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		expected time.Time
+		wantErr  bool
+	}{
+		{"discord epoch", "0", time.UnixMilli(Epoch).UTC(), false},
+		{"2025", "1323802873036800000", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"worker and increment ignored", "1323802873036800001", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"documented example", "175928847299117063", time.Date(2016, 4, 30, 11, 18, 25, 796e6, time.UTC), false},
+		{"non-numeric", "abc", time.Time{}, true},
+		{"empty", "", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Time(tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Time(%q) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+			}
+			if !got.Equal(tt.expected) {
+				t.Errorf("Time(%q) = %v, want %v", tt.id, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBefore(t *testing.T) {
+	march := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		id       string
+		expected bool
+	}{
+		{"earlier", FromTime(march.Add(-time.Millisecond)), true},
+		{"same millisecond", Generate(march, 7), false},
+		{"later", FromTime(march.AddDate(0, 1, 0)), false},
+		{"non-numeric", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Before(tt.id, march); got != tt.expected {
+				t.Errorf("Before(%q, %v) = %v, want %v", tt.id, march, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     time.Time
+		increment uint64
+		expected  string
+	}{
+		{"discord epoch", time.UnixMilli(Epoch), 0, "0"},
+		{"increment", time.UnixMilli(Epoch + 1), 5, "4194309"},
+		{"increment wraps", time.UnixMilli(Epoch + 1), 4096 + 5, "4194309"},
+		{"2025", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), 1, "1323802873036800001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Generate(tt.input, tt.increment); got != tt.expected {
+				t.Errorf("Generate(%v, %d) = %q, want %q", tt.input, tt.increment, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name      string
+		low, high string
+		n         int
+		expected  []string
+	}{
+		{"one part", "100", "200", 1, []string{"200", "100"}},
+		{"even split", "100", "200", 4, []string{"200", "175", "150", "125", "100"}},
+		{"remainder goes to the last range", "100", "210", 4, []string{"210", "183", "156", "129", "100"}},
+		{"range smaller than parts", "100", "102", 4, []string{"102", "100"}},
+		{"empty range", "200", "100", 4, []string{"100", "200"}},
+		{"non-numeric", "", "200", 4, []string{"200", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Split(tt.low, tt.high, tt.n); !slices.Equal(got, tt.expected) {
+				t.Errorf("Split(%q, %q, %d) = %q, want %q", tt.low, tt.high, tt.n, got, tt.expected)
+			}
+		})
+	}
+}
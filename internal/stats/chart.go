@@ -0,0 +1,55 @@
+package stats
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+)
+
+// Chart dimensions and colors, matching Discord's dark theme.
+const (
+	chartWidth   = 560
+	chartHeight  = 200
+	chartPadding = 16
+)
+
+var (
+	chartBackground = color.RGBA{0x2b, 0x2d, 0x31, 0xff}
+	chartBar        = color.RGBA{0x2e, 0xcc, 0x71, 0xff}
+	chartAxis       = color.RGBA{0x4e, 0x50, 0x58, 0xff}
+)
+
+// BarChart renders values as a PNG bar chart, one bar per value from left
+// to right. The chart carries no labels; callers describe it alongside.
+func BarChart(values []int) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(chartBackground), image.Point{}, draw.Src)
+
+	baseline := chartHeight - chartPadding
+	draw.Draw(img, image.Rect(chartPadding, baseline, chartWidth-chartPadding, baseline+1),
+		image.NewUniform(chartAxis), image.Point{}, draw.Src)
+
+	peak := 0
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+	if len(values) > 0 && peak > 0 {
+		slot := (chartWidth - 2*chartPadding) / len(values)
+		gap := slot / 5
+		for i, v := range values {
+			h := v * (baseline - chartPadding) / peak
+			x := chartPadding + i*slot + gap/2
+			bar := image.Rect(x, baseline-h, x+slot-gap, baseline)
+			draw.Draw(img, bar, image.NewUniform(chartBar), image.Point{}, draw.Src)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode chart: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,48 @@
+package stats
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+)
+
+func TestBarChart(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []int
+	}{
+		{name: "week of values", values: []int{1, 4, 0, 2, 8, 3, 5}},
+		{name: "all zero", values: make([]int, 7)},
+		{name: "no values"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := BarChart(tt.values)
+			if err != nil {
+				t.Fatalf("BarChart() unexpected error: %v", err)
+			}
+			img, err := png.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("BarChart() did not produce a PNG: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != chartWidth || b.Dy() != chartHeight {
+				t.Errorf("size = %dx%d, want %dx%d", b.Dx(), b.Dy(), chartWidth, chartHeight)
+			}
+		})
+	}
+
+	t.Run("tallest bar reaches the top", func(t *testing.T) {
+		data, _ := BarChart([]int{1, 2})
+		img, _ := png.Decode(bytes.NewReader(data))
+
+		slot := (chartWidth - 2*chartPadding) / 2
+		x := chartPadding + slot + slot/2 // Middle of the second bar
+		if got := img.At(x, chartPadding); got != chartBar {
+			t.Errorf("pixel at top of tallest bar = %v, want bar color", got)
+		}
+		if got := img.At(chartPadding+slot/2, chartPadding); got == chartBar {
+			t.Error("shorter bar should not reach the top")
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package stats
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// importHeader is the header row an import CSV must start with.
+var importHeader = []string{"date", "user_id", "replaced", "deleted"}
+
+// ErrAlreadyImported is returned when the same file was imported before.
+var ErrAlreadyImported = errors.New("file was already imported")
+
+// importRow is one line of an import CSV.
+type importRow struct {
+	date     string
+	userID   string
+	replaced int
+	deleted  int
+}
+
+// ImportCSV adds counts from a CSV with the header date,user_id,replaced,deleted
+// to the stats, so history from before the bot shows up in leaderboards.
+// Dates are UTC days as YYYY-MM-DD. Counts are added to what is already
+// recorded, so a file is only imported once unless force is set. It returns
+// the number of rows imported; nothing is imported if any row is invalid.
+func (r *Recorder) ImportCSV(data []byte, force bool) (int, error) {
+	rows, err := parseImport(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+
+	sum := sha256.Sum256(data)
+	marker := importKey(hex.EncodeToString(sum[:]))
+	if !force {
+		var at time.Time
+		ok, err := r.store.Get(marker, &at)
+		if err != nil {
+			return 0, fmt.Errorf("failed to check previous imports: %w", err)
+		}
+		if ok {
+			return 0, fmt.Errorf("%w on %s", ErrAlreadyImported, at.Format(time.DateOnly))
+		}
+	}
+
+	byDate := make(map[string][]importRow)
+	for _, row := range rows {
+		byDate[row.date] = append(byDate[row.date], row)
+	}
+	for _, date := range slices.Sorted(maps.Keys(byDate)) {
+		t, _ := time.Parse(dateLayout, date) // Validated by parseImport
+		_, err := r.update(t, func(d *Day) {
+			for _, row := range byDate[date] {
+				add(&d.Replaced, row.userID, row.replaced)
+				add(&d.Deleted, row.userID, row.deleted)
+			}
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := r.store.Put(marker, time.Now().UTC()); err != nil {
+		return 0, fmt.Errorf("failed to record import: %w", err)
+	}
+	return len(rows), nil
+}
+
+// parseImport reads and validates every row of an import CSV.
+func parseImport(r io.Reader) ([]importRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1 // Checked below, so a wrong header gets a clear error
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	if !slices.Equal(header, importHeader) {
+		return nil, fmt.Errorf("expected header %s, got %s", strings.Join(importHeader, ","), strings.Join(header, ","))
+	}
+
+	var rows []importRow
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		if len(record) != len(importHeader) {
+			return nil, fmt.Errorf("line %d: expected %d fields, got %d", line, len(importHeader), len(record))
+		}
+		row := importRow{date: record[0], userID: record[1]}
+		if _, err := time.Parse(dateLayout, row.date); err != nil {
+			return nil, fmt.Errorf("line %d: invalid date %q (expected YYYY-MM-DD)", line, row.date)
+		}
+		if row.userID == "" {
+			return nil, fmt.Errorf("line %d: missing user_id", line)
+		}
+		if row.replaced, err = parseCount(record[2]); err != nil {
+			return nil, fmt.Errorf("line %d: invalid replaced count: %w", line, err)
+		}
+		if row.deleted, err = parseCount(record[3]); err != nil {
+			return nil, fmt.Errorf("line %d: invalid deleted count: %w", line, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseCount parses a non-negative count, treating an empty field as zero.
+func parseCount(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("expected a non-negative integer, got %q", s)
+	}
+	return n, nil
+}
+
+// add adds n to k in m, leaving m untouched if n is zero.
+func add(m *map[string]int, k string, n int) {
+	if n == 0 {
+		return
+	}
+	if *m == nil {
+		*m = make(map[string]int)
+	}
+	(*m)[k] += n
+}
+
+func importKey(sum string) string {
+	return "stats/imports/" + sum
+}
@@ -0,0 +1,87 @@
+package stats
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"jolly-okurb/internal/store"
+)
+
+func TestRecorder_ImportCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		wantErr string
+		wantN   int
+	}{
+		{
+			name:  "rows are added to recorded counts",
+			csv:   "date,user_id,replaced,deleted\n2024-03-01,alice,10,2\n2024-03-01,bob,3,\n2025-01-06, alice,1,0\n",
+			wantN: 3,
+		},
+		{name: "header only", csv: "date,user_id,replaced,deleted\n"},
+		{name: "wrong header", csv: "day,user,count\n", wantErr: "expected header date,user_id,replaced,deleted"},
+		{name: "invalid date", csv: "date,user_id,replaced,deleted\n01/03/2024,alice,1,0\n", wantErr: `line 2: invalid date "01/03/2024"`},
+		{name: "missing user", csv: "date,user_id,replaced,deleted\n2024-03-01,,1,0\n", wantErr: "line 2: missing user_id"},
+		{name: "negative count", csv: "date,user_id,replaced,deleted\n2024-03-01,alice,1,0\n2024-03-02,alice,-1,0\n", wantErr: "line 3: invalid replaced count"},
+		{name: "wrong field count", csv: "date,user_id,replaced,deleted\n2024-03-01,alice,1\n", wantErr: "line 2: expected 4 fields, got 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(store.NewMemory())
+			monday := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+			r.RecordReplacement(monday, "alice", "💀")
+
+			n, err := r.ImportCSV([]byte(tt.csv), false)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ImportCSV() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if days, _ := r.Days(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 1); days[0].Total() != 0 {
+					t.Error("nothing should be imported when a row is invalid")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ImportCSV() unexpected error: %v", err)
+			}
+			if n != tt.wantN {
+				t.Errorf("imported %d rows, want %d", n, tt.wantN)
+			}
+			if tt.wantN == 0 {
+				return
+			}
+
+			march, _ := r.Days(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 1)
+			if d := march[0]; d.Replaced["alice"] != 10 || d.Deleted["alice"] != 2 || d.Replaced["bob"] != 3 || d.Deleted["bob"] != 0 {
+				t.Errorf("2024-03-01 = %+v", d)
+			}
+			if days, _ := r.Days(monday, 1); days[0].Replaced["alice"] != 2 {
+				t.Errorf("imported counts should add to recorded ones, got %+v", days[0])
+			}
+		})
+	}
+}
+
+func TestRecorder_ImportCSVOnce(t *testing.T) {
+	r := New(store.NewMemory())
+	data := []byte("date,user_id,replaced,deleted\n2024-03-01,alice,10,2\n")
+
+	if _, err := r.ImportCSV(data, false); err != nil {
+		t.Fatalf("first import: %v", err)
+	}
+	if _, err := r.ImportCSV(data, false); !errors.Is(err, ErrAlreadyImported) {
+		t.Fatalf("second import error = %v, want ErrAlreadyImported", err)
+	}
+	if _, err := r.ImportCSV(data, true); err != nil {
+		t.Fatalf("forced import: %v", err)
+	}
+
+	days, _ := r.Days(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 1)
+	if days[0].Replaced["alice"] != 20 {
+		t.Errorf("Replaced = %d, want the file imported twice", days[0].Replaced["alice"])
+	}
+}
@@ -0,0 +1,292 @@
+package stats
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"jolly-okurb/internal/store"
+)
+
+// dateLayout formats the UTC date that keys a day's stats.
+const dateLayout = "2006-01-02"
+
+// Day holds the actions taken on one UTC day.
+type Day struct {
+	Date     string         `json:"date"`
+	Replaced map[string]int `json:"replaced,omitempty"` // Reactions replaced, by user ID
+	Deleted  map[string]int `json:"deleted,omitempty"`  // Messages deleted, by author ID
+	Emojis   map[string]int `json:"emojis,omitempty"`   // Reactions replaced, by emoji
+	Skulls   map[string]int `json:"skulls,omitempty"`   // Skull emojis used by anyone, in reactions and messages, by emoji
+	Messages map[string]int `json:"messages,omitempty"` // Reactions replaced, by "<channel ID>/<message ID>"
+	Hours    [24]int        `json:"hours,omitzero"`     // Actions by UTC hour
+}
+
+// Total returns the number of actions taken on the day.
+func (d Day) Total() int {
+	n := 0
+	for _, c := range d.Replaced {
+		n += c
+	}
+	for _, c := range d.Deleted {
+		n += c
+	}
+	return n
+}
+
+// Recorder keeps daily action counts in the store. A nil Recorder records nothing.
+type Recorder struct {
+	store store.Store
+	mu    sync.Mutex // Serializes read-modify-write cycles
+}
+
+func New(st store.Store) *Recorder {
+	return &Recorder{store: st}
+}
+
+// RecordReplacement counts a skull reaction by userID replaced at t. It
+// returns the number of actions taken on the user's skulls that day.
+func (r *Recorder) RecordReplacement(t time.Time, userID, emoji string) (int, error) {
+	n, _, err := r.RecordReplacementOn(t, userID, emoji, "", "")
+	return n, err
+}
+
+// RecordReplacementOn is RecordReplacement that also counts the replacement
+// towards the message it was on, unless messageID is empty. repeat reports
+// whether a skull on the message was already replaced that day.
+func (r *Recorder) RecordReplacementOn(t time.Time, userID, emoji, channelID, messageID string) (n int, repeat bool, err error) {
+	d, err := r.update(t, func(d *Day) {
+		incr(&d.Replaced, userID)
+		incr(&d.Emojis, emoji)
+		if messageID != "" {
+			incr(&d.Messages, channelID+"/"+messageID)
+		}
+		d.Hours[t.UTC().Hour()]++
+	})
+	return d.Replaced[userID] + d.Deleted[userID], messageID != "" && d.Messages[channelID+"/"+messageID] > 1, err
+}
+
+// RecordSkullUses counts emojis, skull emojis used at t in a reaction or a
+// message, by anyone. It returns how often each of them was used that day, in
+// the order given.
+func (r *Recorder) RecordSkullUses(t time.Time, emojis ...string) ([]int, error) {
+	if len(emojis) == 0 {
+		return nil, nil
+	}
+	d, err := r.update(t, func(d *Day) {
+		for _, emoji := range emojis {
+			incr(&d.Skulls, emoji)
+		}
+	})
+	counts := make([]int, len(emojis))
+	for i, emoji := range emojis {
+		counts[i] = d.Skulls[emoji]
+	}
+	return counts, err
+}
+
+// RecordDeletion counts a skull-only message by authorID deleted at t. It
+// returns the number of actions taken on the author's skulls that day.
+func (r *Recorder) RecordDeletion(t time.Time, authorID string) (int, error) {
+	d, err := r.update(t, func(d *Day) {
+		incr(&d.Deleted, authorID)
+		d.Hours[t.UTC().Hour()]++
+	})
+	return d.Replaced[authorID] + d.Deleted[authorID], err
+}
+
+// Days returns the stats for n consecutive days starting on the day of from.
+// Days without activity are included with no counts.
+func (r *Recorder) Days(from time.Time, n int) ([]Day, error) {
+	days := make([]Day, n)
+	for i := range days {
+		d, err := r.day(from.AddDate(0, 0, i))
+		if err != nil {
+			return nil, err
+		}
+		days[i] = d
+	}
+	return days, nil
+}
+
+func (r *Recorder) day(t time.Time) (Day, error) {
+	date := t.UTC().Format(dateLayout)
+	d := Day{Date: date}
+	if _, err := r.store.Get(key(date), &d); err != nil {
+		return Day{}, fmt.Errorf("failed to load stats for %s: %w", date, err)
+	}
+	return d, nil
+}
+
+// update applies fn to the day of t, stores it, and returns the result.
+func (r *Recorder) update(t time.Time, fn func(*Day)) (Day, error) {
+	if r == nil {
+		return Day{}, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d, err := r.day(t)
+	if err != nil {
+		return Day{}, err
+	}
+	fn(&d)
+	if err := r.store.Put(key(d.Date), d); err != nil {
+		return Day{}, fmt.Errorf("failed to save stats for %s: %w", d.Date, err)
+	}
+	return d, nil
+}
+
+func incr(m *map[string]int, k string) {
+	if *m == nil {
+		*m = make(map[string]int)
+	}
+	(*m)[k]++
+}
+
+func key(date string) string {
+	return "stats/days/" + date
+}
+
+// Count is a key with how often it occurred.
+type Count struct {
+	Key string
+	N   int
+}
+
+// Summary aggregates a range of days. Replacements recorded without their
+// message count towards Replaced but neither Messages nor Repeats.
+type Summary struct {
+	Replaced    int // Reactions replaced, counting every one on the same message
+	Messages    int // Distinct messages reactions were replaced on
+	Repeats     int // Reactions replaced on a message after the first one on it
+	Deleted     int
+	TopTargets  []Count // Users by actions taken on them, most first
+	TopEmojis   []Count // Replaced emojis, most first
+	TopSkulls   []Count // Skull emojis used by anyone, most first
+	TopMessages []Count // Messages by reactions replaced on them, most first, keyed as in Day.Messages
+	Busiest     Day     // Day with the most actions; zero if there were none
+}
+
+// Summarize totals days and ranks targets, emojis, and skulls.
+func Summarize(days []Day) Summary {
+	var s Summary
+	targets := make(map[string]int)
+	emojis := make(map[string]int)
+	skulls := make(map[string]int)
+	messages := make(map[string]int)
+	for _, d := range days {
+		for user, n := range d.Replaced {
+			s.Replaced += n
+			targets[user] += n
+		}
+		for user, n := range d.Deleted {
+			s.Deleted += n
+			targets[user] += n
+		}
+		for emoji, n := range d.Emojis {
+			emojis[emoji] += n
+		}
+		for emoji, n := range d.Skulls {
+			skulls[emoji] += n
+		}
+		for msg, n := range d.Messages {
+			messages[msg] += n
+		}
+		if d.Total() > s.Busiest.Total() {
+			s.Busiest = d
+		}
+	}
+	s.TopTargets = rank(targets)
+	s.TopEmojis = rank(emojis)
+	s.TopSkulls = rank(skulls)
+	s.TopMessages = rank(messages)
+	s.Messages = len(messages)
+	for _, n := range messages {
+		s.Repeats += n - 1
+	}
+	return s
+}
+
+// Heatmap counts actions by UTC weekday and hour, indexed by time.Weekday.
+type Heatmap [7][24]int
+
+// HeatmapOf buckets the actions of days by weekday and hour. Days recorded
+// before hours were tracked count towards no bucket.
+func HeatmapOf(days []Day) Heatmap {
+	var h Heatmap
+	for _, d := range days {
+		t, err := time.Parse(dateLayout, d.Date)
+		if err != nil {
+			continue
+		}
+		for hour, n := range d.Hours {
+			h[t.Weekday()][hour] += n
+		}
+	}
+	return h
+}
+
+// Peak returns the busiest weekday and hour, and its count. The count is
+// zero if there were no actions.
+func (h Heatmap) Peak() (day time.Weekday, hour, n int) {
+	for d := range h {
+		for hr, c := range h[d] {
+			if c > n {
+				day, hour, n = time.Weekday(d), hr, c
+			}
+		}
+	}
+	return day, hour, n
+}
+
+// UserCounts is how many actions were taken on one user's skulls.
+type UserCounts struct {
+	UserID   string
+	Replaced int
+	Deleted  int
+}
+
+// Total returns the number of actions taken on the user's skulls.
+func (u UserCounts) Total() int {
+	return u.Replaced + u.Deleted
+}
+
+// PerUser totals days per user, most actions first.
+func PerUser(days []Day) []UserCounts {
+	byUser := make(map[string]*UserCounts)
+	get := func(id string) *UserCounts {
+		if byUser[id] == nil {
+			byUser[id] = &UserCounts{UserID: id}
+		}
+		return byUser[id]
+	}
+	for _, d := range days {
+		for user, n := range d.Replaced {
+			get(user).Replaced += n
+		}
+		for user, n := range d.Deleted {
+			get(user).Deleted += n
+		}
+	}
+
+	var users []UserCounts
+	for _, id := range slices.Sorted(maps.Keys(byUser)) {
+		users = append(users, *byUser[id])
+	}
+	slices.SortStableFunc(users, func(a, b UserCounts) int { return cmp.Compare(b.Total(), a.Total()) })
+	return users
+}
+
+// rank orders counts by N descending, then by key for a stable order.
+func rank(m map[string]int) []Count {
+	var counts []Count
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		counts = append(counts, Count{Key: k, N: m[k]})
+	}
+	slices.SortStableFunc(counts, func(a, b Count) int { return cmp.Compare(b.N, a.N) })
+	return counts
+}
@@ -0,0 +1,184 @@
+package stats
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"jolly-okurb/internal/store"
+)
+
+func TestRecorder(t *testing.T) {
+	r := New(store.NewMemory())
+	monday := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+	tuesday := monday.AddDate(0, 0, 1)
+
+	r.RecordReplacement(monday, "alice", "💀")
+	r.RecordReplacement(monday, "alice", "☠️")
+	r.RecordReplacementOn(tuesday, "bob", "💀", "c1", "m1")
+	r.RecordSkullUses(monday, "💀", "<:deadskull:1>")
+	if counts, err := r.RecordSkullUses(monday, "💀"); err != nil || !slices.Equal(counts, []int{2}) {
+		t.Errorf("RecordSkullUses() = %v, %v, want the day's count of 2", counts, err)
+	}
+	r.RecordSkullUses(tuesday)
+	if n, err := r.RecordDeletion(tuesday, "alice"); err != nil || n != 1 {
+		t.Errorf("RecordDeletion() = %d, %v, want alice's first action of the day", n, err)
+	}
+	if n, err := r.RecordReplacement(monday, "alice", "💀"); err != nil || n != 3 {
+		t.Errorf("RecordReplacement() = %d, %v, want alice's third action of the day", n, err)
+	}
+
+	days, err := r.Days(monday, 3)
+	if err != nil {
+		t.Fatalf("Days() unexpected error: %v", err)
+	}
+	if len(days) != 3 {
+		t.Fatalf("expected 3 days, got %d", len(days))
+	}
+
+	tests := []struct {
+		date  string
+		total int
+	}{
+		{"2025-01-06", 3},
+		{"2025-01-07", 2},
+		{"2025-01-08", 0},
+	}
+	for i, tt := range tests {
+		if days[i].Date != tt.date || days[i].Total() != tt.total {
+			t.Errorf("days[%d] = %s with %d actions, want %s with %d", i, days[i].Date, days[i].Total(), tt.date, tt.total)
+		}
+	}
+	if days[0].Emojis["💀"] != 2 || days[0].Emojis["☠️"] != 1 {
+		t.Errorf("Emojis = %v, want two 💀 and one ☠️", days[0].Emojis)
+	}
+	if days[0].Skulls["💀"] != 2 || days[0].Skulls["<:deadskull:1>"] != 1 || days[1].Skulls != nil {
+		t.Errorf("Skulls = %v, %v, want two 💀 and one deadskull on Monday only", days[0].Skulls, days[1].Skulls)
+	}
+	if days[0].Total() != 3 {
+		t.Errorf("Total() = %d, want skull uses not counted as actions", days[0].Total())
+	}
+	if len(days[0].Messages) != 0 || days[1].Messages["c1/m1"] != 1 {
+		t.Errorf("Messages = %v, %v, want only bob's replacement on m1", days[0].Messages, days[1].Messages)
+	}
+	if days[0].Hours[12] != 3 || days[1].Hours[12] != 2 {
+		t.Errorf("Hours[12] = %d, %d, want every action at noon", days[0].Hours[12], days[1].Hours[12])
+	}
+}
+
+func TestHeatmapOf(t *testing.T) {
+	var monday, tuesday [24]int
+	monday[9], monday[21] = 2, 5
+	tuesday[9] = 1
+	days := []Day{
+		{Date: "2025-01-06", Hours: monday},
+		{Date: "2025-01-07", Hours: tuesday},
+		{Date: "2025-01-13", Hours: monday},
+		{Date: "2025-01-14"}, // Recorded before hours were tracked
+	}
+
+	h := HeatmapOf(days)
+
+	tests := []struct {
+		day  time.Weekday
+		hour int
+		want int
+	}{
+		{time.Monday, 9, 4},
+		{time.Monday, 21, 10},
+		{time.Tuesday, 9, 1},
+		{time.Sunday, 9, 0},
+	}
+	for _, tt := range tests {
+		if got := h[tt.day][tt.hour]; got != tt.want {
+			t.Errorf("h[%s][%d] = %d, want %d", tt.day, tt.hour, got, tt.want)
+		}
+	}
+	if day, hour, n := h.Peak(); day != time.Monday || hour != 21 || n != 10 {
+		t.Errorf("Peak() = %s %d:00 with %d, want Monday 21:00 with 10", day, hour, n)
+	}
+	if _, _, n := HeatmapOf(nil).Peak(); n != 0 {
+		t.Errorf("Peak() of an empty heatmap = %d, want 0", n)
+	}
+}
+
+func TestRecorder_Repeat(t *testing.T) {
+	r := New(store.NewMemory())
+	monday := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		t          time.Time
+		messageID  string
+		wantRepeat bool
+	}{
+		{"first on the message", monday, "m1", false},
+		{"again on the message", monday.Add(time.Minute), "m1", true},
+		{"other message", monday, "m2", false},
+		{"message not known", monday, "", false},
+		{"next day", monday.AddDate(0, 0, 1), "m1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, repeat, err := r.RecordReplacementOn(tt.t, "alice", "💀", "c1", tt.messageID)
+			if err != nil || repeat != tt.wantRepeat {
+				t.Errorf("RecordReplacementOn() repeat = %v, %v, want %v", repeat, err, tt.wantRepeat)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	days := []Day{
+		{Date: "2025-01-06", Replaced: map[string]int{"alice": 2}, Emojis: map[string]int{"💀": 2}, Messages: map[string]int{"c1/m1": 2}},
+		{Date: "2025-01-07", Replaced: map[string]int{"bob": 3}, Deleted: map[string]int{"alice": 2}, Emojis: map[string]int{"💀": 1, "☠️": 2}, Messages: map[string]int{"c1/m1": 1, "c2/m2": 2}},
+		{Date: "2025-01-08", Skulls: map[string]int{"☠️": 1, "💀": 4}},
+	}
+
+	s := Summarize(days)
+
+	if s.Replaced != 5 || s.Deleted != 2 {
+		t.Errorf("Replaced, Deleted = %d, %d, want 5, 2", s.Replaced, s.Deleted)
+	}
+	if s.Messages != 2 || s.Repeats != 3 {
+		t.Errorf("Messages, Repeats = %d, %d, want 2, 3", s.Messages, s.Repeats)
+	}
+	if want := []Count{{"alice", 4}, {"bob", 3}}; !slices.Equal(s.TopTargets, want) {
+		t.Errorf("TopTargets = %v, want %v", s.TopTargets, want)
+	}
+	if want := []Count{{"💀", 3}, {"☠️", 2}}; !slices.Equal(s.TopEmojis, want) {
+		t.Errorf("TopEmojis = %v, want %v", s.TopEmojis, want)
+	}
+	if want := []Count{{"💀", 4}, {"☠️", 1}}; !slices.Equal(s.TopSkulls, want) {
+		t.Errorf("TopSkulls = %v, want %v", s.TopSkulls, want)
+	}
+	if want := []Count{{"c1/m1", 3}, {"c2/m2", 2}}; !slices.Equal(s.TopMessages, want) {
+		t.Errorf("TopMessages = %v, want %v", s.TopMessages, want)
+	}
+	if s.Busiest.Date != "2025-01-07" {
+		t.Errorf("Busiest = %s, want 2025-01-07", s.Busiest.Date)
+	}
+
+	if empty := Summarize([]Day{{Date: "2025-01-06"}}); empty.Busiest.Date != "" {
+		t.Errorf("Busiest should be unset without activity, got %s", empty.Busiest.Date)
+	}
+}
+
+func TestPerUser(t *testing.T) {
+	days := []Day{
+		{Date: "2025-01-06", Replaced: map[string]int{"alice": 2, "carol": 1}},
+		{Date: "2025-01-07", Replaced: map[string]int{"bob": 3}, Deleted: map[string]int{"alice": 2, "carol": 2}},
+		{Date: "2025-01-08"},
+	}
+
+	want := []UserCounts{
+		{UserID: "alice", Replaced: 2, Deleted: 2},
+		{UserID: "bob", Replaced: 3},
+		{UserID: "carol", Replaced: 1, Deleted: 2},
+	}
+	if got := PerUser(days); !slices.Equal(got, want) {
+		t.Errorf("PerUser() = %v, want %v", got, want)
+	}
+	if got := PerUser(nil); len(got) != 0 {
+		t.Errorf("PerUser(nil) = %v, want none", got)
+	}
+}
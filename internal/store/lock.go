@@ -0,0 +1,37 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrLocked is returned by LockFile when another process holds the lock.
+var ErrLocked = errors.New("store is in use by another process")
+
+// Lock is an exclusive lock on a store file. A File keeps the whole store in
+// memory and rewrites it on every change, so two processes writing the same
+// file would overwrite each other's changes; each takes the lock first.
+type Lock struct {
+	f *os.File
+}
+
+// LockFile takes the lock on the store at path, held on the file path+".lock",
+// without waiting. It returns ErrLocked if another process holds it. The lock
+// is released by Unlock or when the process exits.
+func LockFile(path string) (*Lock, error) {
+	f, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store lock: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Lock{f: f}, nil
+}
+
+// Unlock releases the lock.
+func (l *Lock) Unlock() error {
+	return l.f.Close()
+}
@@ -0,0 +1,11 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package store
+
+import "os"
+
+// lockFile does nothing where flock is not available; only one process may
+// use a store there at a time.
+func lockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,28 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	lock, err := LockFile(path)
+	if err != nil {
+		t.Fatalf("LockFile() unexpected error: %v", err)
+	}
+	if _, err := LockFile(path); !errors.Is(err, ErrLocked) {
+		t.Fatalf("LockFile() while locked error = %v, want ErrLocked", err)
+	}
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Unlock() unexpected error: %v", err)
+	}
+	relocked, err := LockFile(path)
+	if err != nil {
+		t.Fatalf("LockFile() after Unlock unexpected error: %v", err)
+	}
+	relocked.Unlock()
+}
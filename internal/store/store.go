@@ -0,0 +1,283 @@
+package store
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"jolly-okurb/internal/logx"
+)
+
+var logger = logx.For(logx.Store)
+
+// Store persists JSON-encodable values by key.
+type Store interface {
+	// Get decodes the value stored under key into v. It reports false if the key does not exist.
+	Get(key string, v any) (bool, error)
+	Put(key string, v any) error
+	Delete(key string) error
+}
+
+// Memory is a Store that keeps values in memory only.
+type Memory struct {
+	mu   sync.RWMutex
+	data map[string]json.RawMessage
+}
+
+func NewMemory() *Memory {
+	return &Memory{data: make(map[string]json.RawMessage)}
+}
+
+func (m *Memory) Get(key string, v any) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return get(m.data, key, v)
+}
+
+func (m *Memory) Put(key string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = raw
+	return nil
+}
+
+func (m *Memory) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, key)
+	return nil
+}
+
+// flushDelay is how long a File waits after a change before writing it, so
+// that a burst of changes, such as a historical scan's, is written once. It
+// is a variable so that tests can shorten it.
+var flushDelay = time.Second
+
+// File is a Store backed by a single JSON file. The whole file is kept in
+// memory and rewritten atomically, and durably, flushDelay after a change;
+// Flush writes pending changes at once, and must be called before exiting.
+type File struct {
+	mu    sync.RWMutex
+	path  string
+	data  map[string]json.RawMessage
+	aead  cipher.AEAD // Encrypts the file at rest; nil stores plain JSON
+	dirty bool        // Changed since the file was last written
+	timer *time.Timer // Pending write; nil if none
+}
+
+// encryptedHeader starts every encrypted store file and is authenticated with it.
+var encryptedHeader = []byte("jolly-okurb encrypted store v1\n")
+
+// OpenFile loads the store at path, starting empty if the file does not exist yet.
+func OpenFile(path string) (*File, error) {
+	return openFile(path, nil)
+}
+
+// OpenEncryptedFile loads the store at path like OpenFile, but keeps the file
+// encrypted with AES-GCM under key, which must be 16, 24, or 32 bytes. A plain
+// JSON store is read as is and encrypted on its next write.
+func OpenEncryptedFile(path string, key []byte) (*File, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create store cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create store cipher: %w", err)
+	}
+	return openFile(path, aead)
+}
+
+func openFile(path string, aead cipher.AEAD) (*File, error) {
+	f := &File{path: path, data: make(map[string]json.RawMessage), aead: aead}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store: %w", err)
+	}
+	if bytes.HasPrefix(raw, encryptedHeader) {
+		if aead == nil {
+			return nil, fmt.Errorf("store %s is encrypted, but no key was given", path)
+		}
+		if raw, err = f.decrypt(raw); err != nil {
+			return nil, fmt.Errorf("failed to decrypt store %s: %w", path, err)
+		}
+	}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &f.data); err != nil {
+			return nil, fmt.Errorf("failed to decode store %s: %w", path, err)
+		}
+	}
+	return f, nil
+}
+
+func (f *File) Get(key string, v any) (bool, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return get(f.data, key, v)
+}
+
+func (f *File) Put(key string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.data[key] = raw
+	f.changed()
+	return nil
+}
+
+func (f *File) Delete(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.data[key]; !ok {
+		return nil
+	}
+	delete(f.data, key)
+	f.changed()
+	return nil
+}
+
+// Flush writes the changes not written yet.
+func (f *File) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+	return f.flush()
+}
+
+// changed schedules a write of the store unless one is pending. Callers must
+// hold mu.
+func (f *File) changed() {
+	f.dirty = true
+	if f.timer == nil {
+		f.timer = time.AfterFunc(flushDelay, f.flushPending)
+	}
+}
+
+// flushPending writes the store once flushDelay has passed since a change.
+// A failed write is logged and tried again later.
+func (f *File) flushPending() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.timer = nil
+	if err := f.flush(); err != nil {
+		logger.Error("failed to write store, retrying", "path", f.path, "error", err)
+		f.changed()
+	}
+}
+
+// flush writes the store to a temporary file, syncs it, and renames it over
+// the original so a crash never leaves a half-written store behind, then
+// syncs the directory so the rename survives a crash too. It does nothing
+// if the store has not changed. Callers must hold mu.
+func (f *File) flush() error {
+	if !f.dirty {
+		return nil
+	}
+	raw, err := json.MarshalIndent(f.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode store: %w", err)
+	}
+	if f.aead != nil {
+		if raw, err = f.encrypt(raw); err != nil {
+			return err
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary store file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write store: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to replace store: %w", err)
+	}
+	if err := syncDir(filepath.Dir(f.path)); err != nil {
+		return fmt.Errorf("failed to sync store directory: %w", err)
+	}
+	f.dirty = false
+	return nil
+}
+
+// syncDir syncs the directory dir, making a rename in it durable. Windows
+// cannot sync directories, so it does nothing there.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// encrypt seals plaintext behind the header and a random nonce.
+func (f *File) encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, f.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate store nonce: %w", err)
+	}
+	out := append(bytes.Clone(encryptedHeader), nonce...)
+	return f.aead.Seal(out, nonce, plaintext, encryptedHeader), nil
+}
+
+// decrypt opens a file written by encrypt.
+func (f *File) decrypt(raw []byte) ([]byte, error) {
+	raw = raw[len(encryptedHeader):]
+	if len(raw) < f.aead.NonceSize() {
+		return nil, errors.New("file is truncated")
+	}
+	nonce, sealed := raw[:f.aead.NonceSize()], raw[f.aead.NonceSize():]
+	plaintext, err := f.aead.Open(nil, nonce, sealed, encryptedHeader)
+	if err != nil {
+		return nil, errors.New("wrong key or corrupt file")
+	}
+	return plaintext, nil
+}
+
+func get(data map[string]json.RawMessage, key string, v any) (bool, error) {
+	raw, ok := data[key]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, fmt.Errorf("failed to decode %s: %w", key, err)
+	}
+	return true, nil
+}
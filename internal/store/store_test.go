@@ -0,0 +1,243 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type record struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+
+	var got record
+	found, err := s.Get("missing", &got)
+	if err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	if found {
+		t.Error("Get() should report missing keys as not found")
+	}
+
+	want := record{Name: "skull", Count: 3}
+	if err := s.Put("key", want); err != nil {
+		t.Fatalf("Put() unexpected error: %v", err)
+	}
+	found, err = s.Get("key", &got)
+	if err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	if !found || got != want {
+		t.Errorf("Get() = %+v, %v, want %+v, true", got, found, want)
+	}
+
+	if err := s.Delete("key"); err != nil {
+		t.Fatalf("Delete() unexpected error: %v", err)
+	}
+	found, _ = s.Get("key", &got)
+	if found {
+		t.Error("Get() should not find deleted key")
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testStore(t, NewMemory())
+}
+
+func TestFile(t *testing.T) {
+	t.Run("basic operations", func(t *testing.T) {
+		s, err := OpenFile(filepath.Join(t.TempDir(), "store.json"))
+		if err != nil {
+			t.Fatalf("OpenFile() unexpected error: %v", err)
+		}
+		testStore(t, s)
+		if err := s.Flush(); err != nil {
+			t.Fatalf("Flush() unexpected error: %v", err)
+		}
+	})
+
+	t.Run("writes a burst of changes once, after a delay", func(t *testing.T) {
+		delay := flushDelay
+		flushDelay = 50 * time.Millisecond
+		t.Cleanup(func() { flushDelay = delay })
+
+		path := filepath.Join(t.TempDir(), "store.json")
+		s, err := OpenFile(path)
+		if err != nil {
+			t.Fatalf("OpenFile() unexpected error: %v", err)
+		}
+		for i := range 10 {
+			if err := s.Put("key", record{Count: i}); err != nil {
+				t.Fatalf("Put() unexpected error: %v", err)
+			}
+		}
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("store should not be written before the delay, Stat() error = %v", err)
+		}
+
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			reopened, err := OpenFile(path)
+			if err != nil {
+				t.Fatalf("OpenFile() unexpected error: %v", err)
+			}
+			var got record
+			if found, _ := reopened.Get("key", &got); found {
+				if got.Count != 9 {
+					t.Errorf("Get() after the delay = %+v, want the last change", got)
+				}
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("store was not written after the delay")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	t.Run("persists across reopen", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "store.json")
+		s, err := OpenFile(path)
+		if err != nil {
+			t.Fatalf("OpenFile() unexpected error: %v", err)
+		}
+		want := record{Name: "jollyskull", Count: 7}
+		if err := s.Put("key", want); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+		if err := s.Flush(); err != nil {
+			t.Fatalf("Flush() unexpected error: %v", err)
+		}
+
+		reopened, err := OpenFile(path)
+		if err != nil {
+			t.Fatalf("OpenFile() unexpected error: %v", err)
+		}
+		var got record
+		found, err := reopened.Get("key", &got)
+		if err != nil || !found || got != want {
+			t.Errorf("Get() after reopen = %+v, %v, %v, want %+v, true, nil", got, found, err, want)
+		}
+	})
+
+	t.Run("rejects corrupt file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "store.json")
+		if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := OpenFile(path); err == nil {
+			t.Error("OpenFile() should fail on a corrupt store file")
+		}
+	})
+}
+
+func TestEncryptedFile(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	want := record{Name: "jollyskull", Count: 7}
+
+	t.Run("basic operations", func(t *testing.T) {
+		s, err := OpenEncryptedFile(filepath.Join(t.TempDir(), "store.json"), key)
+		if err != nil {
+			t.Fatalf("OpenEncryptedFile() unexpected error: %v", err)
+		}
+		testStore(t, s)
+		if err := s.Flush(); err != nil {
+			t.Fatalf("Flush() unexpected error: %v", err)
+		}
+	})
+
+	t.Run("encrypts at rest and persists across reopen", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "store.json")
+		s, err := OpenEncryptedFile(path, key)
+		if err != nil {
+			t.Fatalf("OpenEncryptedFile() unexpected error: %v", err)
+		}
+		if err := s.Put("key", want); err != nil {
+			t.Fatalf("Put() unexpected error: %v", err)
+		}
+		if err := s.Flush(); err != nil {
+			t.Fatalf("Flush() unexpected error: %v", err)
+		}
+
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(raw, []byte("jollyskull")) {
+			t.Error("store file should not contain plaintext values")
+		}
+
+		reopened, err := OpenEncryptedFile(path, key)
+		if err != nil {
+			t.Fatalf("OpenEncryptedFile() unexpected error: %v", err)
+		}
+		var got record
+		found, err := reopened.Get("key", &got)
+		if err != nil || !found || got != want {
+			t.Errorf("Get() after reopen = %+v, %v, %v, want %+v, true, nil", got, found, err, want)
+		}
+	})
+
+	t.Run("encrypts a plain store on its next write", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "store.json")
+		plain, _ := OpenFile(path)
+		if err := plain.Put("key", want); err != nil {
+			t.Fatal(err)
+		}
+		plain.Flush()
+
+		s, err := OpenEncryptedFile(path, key)
+		if err != nil {
+			t.Fatalf("OpenEncryptedFile() unexpected error: %v", err)
+		}
+		var got record
+		if found, _ := s.Get("key", &got); !found || got != want {
+			t.Errorf("Get() = %+v, %v, want %+v, true", got, found, want)
+		}
+		if err := s.Put("other", want); err != nil {
+			t.Fatal(err)
+		}
+		s.Flush()
+		if raw, _ := os.ReadFile(path); !bytes.HasPrefix(raw, encryptedHeader) {
+			t.Error("store should be encrypted after a write")
+		}
+	})
+
+	failures := []struct {
+		name string
+		open func(path string) error
+	}{
+		{"wrong key", func(path string) error {
+			_, err := OpenEncryptedFile(path, []byte("fedcba9876543210fedcba9876543210"))
+			return err
+		}},
+		{"no key", func(path string) error {
+			_, err := OpenFile(path)
+			return err
+		}},
+		{"invalid key", func(path string) error {
+			_, err := OpenEncryptedFile(path, []byte("short"))
+			return err
+		}},
+	}
+	for _, tt := range failures {
+		t.Run("rejects "+tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "store.json")
+			s, _ := OpenEncryptedFile(path, key)
+			if err := s.Put("key", want); err != nil {
+				t.Fatal(err)
+			}
+			s.Flush()
+			if err := tt.open(path); err == nil {
+				t.Error("opening should fail")
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+// Package themes holds the reaction theme packs: sets of themed emojis the
+// bot reacts with instead of jollyskull. A pack replaces skulls and maps
+// other common negative emojis to themed ones. Packs are data, one JSON file
+// each, so adding one takes no code.
+//
+// Besides Unicode emojis, a pack may react with custom emojis, written as
+// :name:, whose images it bundles so they can be uploaded to a guild that
+// lacks them. Their IDs differ per guild, so the bot resolves them.
+package themes
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Pack is one theme.
+type Pack struct {
+	ID     string              `json:"-"`      // File name without .json
+	Name   string              `json:"name"`   // Shown to moderators
+	Skulls []string            `json:"skulls"` // Replace every skull emoji
+	Emojis map[string][]string `json:"emojis"` // Replacements of other emojis by Unicode emoji
+	Images map[string]string   `json:"images"` // Image files in packs/images of custom emojis by name
+}
+
+//go:embed packs/*.json packs/images
+var packFiles embed.FS
+
+// packs maps pack IDs to packs.
+var packs = loadPacks()
+
+func loadPacks() map[string]Pack {
+	files, err := packFiles.ReadDir("packs")
+	if err != nil {
+		panic(fmt.Sprintf("failed to read theme packs: %v", err))
+	}
+
+	packs := make(map[string]Pack, len(files))
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		data, err := packFiles.ReadFile(path.Join("packs", f.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("failed to read theme pack %s: %v", f.Name(), err))
+		}
+		var p Pack
+		if err := json.Unmarshal(data, &p); err != nil {
+			panic(fmt.Sprintf("failed to parse theme pack %s: %v", f.Name(), err))
+		}
+		p.ID = strings.TrimSuffix(f.Name(), ".json")
+		packs[p.ID] = p
+	}
+	return packs
+}
+
+// CustomName returns the name of emoji if it is a custom emoji reference,
+// written as :name:.
+func CustomName(emoji string) (string, bool) {
+	if len(emoji) < 3 || !strings.HasPrefix(emoji, ":") || !strings.HasSuffix(emoji, ":") {
+		return "", false
+	}
+	return emoji[1 : len(emoji)-1], true
+}
+
+// IDs returns the IDs of every pack in a stable order.
+func IDs() []string {
+	return slices.Sorted(maps.Keys(packs))
+}
+
+// Get returns the pack with id.
+func Get(id string) (Pack, bool) {
+	p, ok := packs[id]
+	return p, ok
+}
+
+// Maps reports whether the pack replaces emoji, a Unicode emoji, besides
+// skulls.
+func (p Pack) Maps(emoji string) bool {
+	return len(p.Emojis[emoji]) > 0
+}
+
+// Replacements returns what the pack replaces emoji with, using the skull
+// replacements if skull is set, or nil if the pack leaves emoji alone.
+func (p Pack) Replacements(emoji string, skull bool) []string {
+	if skull {
+		return p.Skulls
+	}
+	return p.Emojis[emoji]
+}
+
+// Contains reports whether emoji is one the pack reacts with.
+func (p Pack) Contains(emoji string) bool {
+	if slices.Contains(p.Skulls, emoji) {
+		return true
+	}
+	for _, replacements := range p.Emojis {
+		if slices.Contains(replacements, emoji) {
+			return true
+		}
+	}
+	return false
+}
+
+// CustomEmojis returns the names of the custom emojis the pack bundles, in a
+// stable order.
+func (p Pack) CustomEmojis() []string {
+	return slices.Sorted(maps.Keys(p.Images))
+}
+
+// Image returns the image of the pack's custom emoji name.
+func (p Pack) Image(name string) ([]byte, error) {
+	file, ok := p.Images[name]
+	if !ok {
+		return nil, fmt.Errorf("theme %s has no emoji %q", p.ID, name)
+	}
+	data, err := packFiles.ReadFile(path.Join("packs", "images", file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read emoji %s of theme %s: %w", name, p.ID, err)
+	}
+	return data, nil
+}
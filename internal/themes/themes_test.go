@@ -0,0 +1,94 @@
+package themes
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestPacks(t *testing.T) {
+	for _, id := range []string{"birthday", "christmas", "halloween"} {
+		if !slices.Contains(IDs(), id) {
+			t.Errorf("IDs() = %v, should include %q", IDs(), id)
+		}
+	}
+	for _, id := range IDs() {
+		p, _ := Get(id)
+		if p.Name == "" {
+			t.Errorf("%s: missing name", id)
+		}
+		if len(p.Skulls) == 0 {
+			t.Errorf("%s: should replace skulls", id)
+		}
+		for emoji, replacements := range p.Emojis {
+			if len(replacements) == 0 {
+				t.Errorf("%s: %s has no replacements", id, emoji)
+			}
+			if p.Contains(emoji) {
+				t.Errorf("%s: %s replaces itself or another replacement", id, emoji)
+			}
+		}
+		for _, name := range p.CustomEmojis() {
+			if !p.Contains(":" + name + ":") {
+				t.Errorf("%s: bundles %s without reacting with it", id, name)
+			}
+			if _, err := p.Image(name); err != nil {
+				t.Errorf("%s: %v", id, err)
+			}
+		}
+		for _, emoji := range slices.Concat(p.Skulls, slices.Concat(slices.Collect(maps.Values(p.Emojis))...)) {
+			if name, ok := CustomName(emoji); ok && p.Images[name] == "" {
+				t.Errorf("%s: reacts with %s without bundling its image", id, emoji)
+			}
+		}
+	}
+	if _, ok := Get("easter"); ok {
+		t.Error("Get() should not find a pack that does not exist")
+	}
+}
+
+func TestPack_Replacements(t *testing.T) {
+	p, _ := Get("christmas")
+	tests := []struct {
+		name  string
+		emoji string
+		skull bool
+		want  []string
+	}{
+		{"skull", "💀", true, []string{":jollysanta:", "🎅", "🎄"}},
+		{"custom skull", "deadskull", true, []string{":jollysanta:", "🎅", "🎄"}},
+		{"mapped", "👎", false, []string{"🎁"}},
+		{"unmapped", "👍", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Replacements(tt.emoji, tt.skull); !slices.Equal(got, tt.want) {
+				t.Errorf("Replacements(%q) = %v, want %v", tt.emoji, got, tt.want)
+			}
+			if got := p.Maps(tt.emoji); got != (!tt.skull && tt.want != nil) {
+				t.Errorf("Maps(%q) = %v", tt.emoji, got)
+			}
+		})
+	}
+	if !p.Contains("🎁") || p.Contains("💀") {
+		t.Error("Contains() should report the pack's replacements only")
+	}
+}
+
+func TestCustomName(t *testing.T) {
+	tests := []struct {
+		emoji  string
+		want   string
+		wantOK bool
+	}{
+		{":jollysanta:", "jollysanta", true},
+		{"🎅", "", false},
+		{"jollyskull:123", "", false},
+		{":jollysanta", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := CustomName(tt.emoji); got != tt.want || ok != tt.wantOK {
+			t.Errorf("CustomName(%q) = %q, %v, want %q, %v", tt.emoji, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// API version. Paths under apiPrefix only change in backward compatible
+// ways; a breaking change gets a new prefix.
+const (
+	apiVersion = "1.0.0"
+	apiPrefix  = "/api/v1"
+)
+
+// apiRoute is an admin API endpoint with what the OpenAPI document says about it.
+type apiRoute struct {
+	method   string
+	path     string // Relative to apiPrefix, with {name} path parameters
+	id       string // operationId
+	summary  string
+	params   []apiParam
+	response string // Name of the response schema in apiSchemas
+	notFound string // When the endpoint answers 404; empty if it never does
+	handler  http.HandlerFunc
+}
+
+type apiParam struct {
+	name        string
+	description string
+}
+
+// apiRoutes defines the admin API. Both the mux and the OpenAPI document are
+// built from it, so they cannot drift apart.
+func (s *Server) apiRoutes() []apiRoute {
+	token := apiParam{"token", "Token of a published stats page, from /jolly config publicstats"}
+	return []apiRoute{
+		{
+			method:   http.MethodGet,
+			path:     "/health",
+			id:       "getHealth",
+			summary:  "Report that the server is up",
+			response: "Health",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, map[string]string{"status": "ok"})
+			},
+		},
+		{
+			method:   http.MethodGet,
+			path:     "/stats/{token}",
+			id:       "getStats",
+			summary:  "Get the stats of the last 30 days published under a token",
+			params:   []apiParam{token},
+			response: "Stats",
+			notFound: "No guild publishes stats under the token",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				s.serve(w, r, "application/json", renderJSON)
+			},
+		},
+		{
+			method:   http.MethodGet,
+			path:     "/stats/{token}/heatmap",
+			id:       "getHeatmap",
+			summary:  "Get actions by weekday and hour for the stats published under a token",
+			params:   []apiParam{token},
+			response: "Heatmap",
+			notFound: "No guild publishes stats under the token",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				s.serve(w, r, "application/json", renderHeatmap)
+			},
+		},
+	}
+}
+
+// handleAPI registers the admin API and its OpenAPI document on mux.
+func (s *Server) handleAPI(mux *http.ServeMux) {
+	routes := s.apiRoutes()
+	for _, rt := range routes {
+		mux.HandleFunc(rt.method+" "+apiPrefix+rt.path, rt.handler)
+	}
+	mux.HandleFunc("GET "+apiPrefix+"/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, openAPIDocument(routes))
+	})
+}
+
+// openAPIDocument describes routes as an OpenAPI 3.1 document.
+func openAPIDocument(routes []apiRoute) map[string]any {
+	paths := make(map[string]any)
+	for _, rt := range routes {
+		var params []any
+		for _, p := range rt.params {
+			params = append(params, map[string]any{
+				"name":        p.name,
+				"in":          "path",
+				"required":    true,
+				"description": p.description,
+				"schema":      map[string]any{"type": "string"},
+			})
+		}
+		responses := map[string]any{
+			"200": map[string]any{
+				"description": "OK",
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/" + rt.response}},
+				},
+			},
+			"500": map[string]any{"description": "Internal error"},
+		}
+		if rt.notFound != "" {
+			responses["404"] = map[string]any{"description": rt.notFound}
+		}
+		op := map[string]any{"operationId": rt.id, "summary": rt.summary, "responses": responses}
+		if params != nil {
+			op["parameters"] = params
+		}
+
+		item, _ := paths[rt.path].(map[string]any)
+		if item == nil {
+			item = make(map[string]any)
+			paths[rt.path] = item
+		}
+		item[strings.ToLower(rt.method)] = op
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "jolly-okurb admin API",
+			"version":     apiVersion,
+			"description": "Read-only access to the bot's published stats. Responses are cached for a minute.",
+		},
+		"servers":    []any{map[string]any{"url": apiPrefix}},
+		"paths":      paths,
+		"components": map[string]any{"schemas": apiSchemas},
+	}
+}
+
+// apiSchemas are the JSON schemas of API responses, matching their Go types.
+var apiSchemas = map[string]any{
+	"Health": object(map[string]any{
+		"status": map[string]any{"type": "string", "const": "ok"},
+	}),
+	"Stats": object(map[string]any{
+		"since":    dateTime,
+		"until":    dateTime,
+		"replaced": integer,
+		"deleted":  integer,
+		"leaderboard": map[string]any{
+			"type": "array",
+			"items": object(map[string]any{
+				"name":       map[string]any{"type": "string", "description": "Username, or an anonymous rank unless the guild shows names"},
+				"avatar_url": map[string]any{"type": "string", "format": "uri"},
+				"count":      integer,
+			}, "name", "count"),
+		},
+		"heatmap": heatmapGrid,
+	}),
+	"Heatmap": object(map[string]any{
+		"since":    date,
+		"until":    date,
+		"timezone": map[string]any{"type": "string", "const": "UTC"},
+		"weekdays": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "minItems": 7, "maxItems": 7},
+		"counts":   heatmapGrid,
+	}),
+}
+
+var (
+	integer     = map[string]any{"type": "integer", "minimum": 0}
+	date        = map[string]any{"type": "string", "format": "date"}
+	dateTime    = map[string]any{"type": "string", "format": "date-time"}
+	heatmapGrid = map[string]any{
+		"description": "Actions by UTC weekday, Sunday first, then by hour",
+		"type":        "array",
+		"items":       map[string]any{"type": "array", "items": integer, "minItems": 24, "maxItems": 24},
+		"minItems":    7,
+		"maxItems":    7,
+	}
+)
+
+// object returns the schema of an object with properties, of which the
+// required ones default to all of them.
+func object(properties map[string]any, required ...string) map[string]any {
+	if required == nil {
+		required = slices.Sorted(maps.Keys(properties))
+	}
+	return map[string]any{"type": "object", "properties": properties, "required": required}
+}
+
+func renderJSON(page *StatsPage) ([]byte, error) {
+	if page.Leaderboard == nil {
+		p := *page
+		p.Leaderboard = []LeaderboardEntry{} // An empty list rather than null, as documented
+		page = &p
+	}
+	body, err := json.Marshal(page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render stats: %w", err)
+	}
+	return body, nil
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,120 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer_API(t *testing.T) {
+	page := &StatsPage{
+		Since:       time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Until:       time.Date(2025, 1, 30, 0, 0, 0, 0, time.UTC),
+		Replaced:    12,
+		Deleted:     3,
+		Leaderboard: []LeaderboardEntry{{Name: "Member #1", Count: 9}},
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		page       *StatsPage
+		wantStatus int
+		wantBody   []string
+	}{
+		{
+			name:       "health",
+			path:       "/api/v1/health",
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`{"status":"ok"}`},
+		},
+		{
+			name:       "stats",
+			path:       "/api/v1/stats/secret",
+			page:       page,
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`"since":"2025-01-01T00:00:00Z"`, `"replaced":12`, `"leaderboard":[{"name":"Member #1","count":9}]`, `"heatmap":[[0,`},
+		},
+		{
+			name:       "stats without a leaderboard",
+			path:       "/api/v1/stats/secret",
+			page:       &StatsPage{},
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`"leaderboard":[]`},
+		},
+		{
+			name:       "unknown token",
+			path:       "/api/v1/stats/guess",
+			page:       page,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "unversioned path",
+			path:       "/api/stats/secret",
+			page:       page,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(":0", &fakeSource{token: "secret", page: tt.page})
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && rec.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", rec.Header().Get("Content-Type"))
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("body does not contain %q:\n%s", want, rec.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_OpenAPI(t *testing.T) {
+	s := New(":0", &fakeSource{})
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Servers []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc.OpenAPI != "3.1.0" || len(doc.Servers) != 1 || doc.Servers[0].URL != "/api/v1" {
+		t.Errorf("openapi = %q, servers = %v", doc.OpenAPI, doc.Servers)
+	}
+
+	// Every route is documented, and every documented response has a schema
+	for _, rt := range s.apiRoutes() {
+		if _, ok := doc.Paths[rt.path]["get"]; !ok {
+			t.Errorf("path %s is not documented", rt.path)
+		}
+		if _, ok := doc.Components.Schemas[rt.response]; !ok {
+			t.Errorf("schema %q of %s is missing", rt.response, rt.path)
+		}
+	}
+	if len(doc.Paths) != len(s.apiRoutes()) {
+		t.Errorf("documented %d paths, want %d", len(doc.Paths), len(s.apiRoutes()))
+	}
+}
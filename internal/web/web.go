@@ -0,0 +1,233 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"jolly-okurb/internal/logx"
+)
+
+// logger is the logger of this package.
+var logger = logx.For(logx.HTTP)
+
+// cacheTTL is how long a rendered stats page is served before it is rebuilt.
+const cacheTTL = time.Minute
+
+// StatsPage is the data shown on a guild's public stats page.
+type StatsPage struct {
+	Since       time.Time          `json:"since"`
+	Until       time.Time          `json:"until"`
+	Replaced    int                `json:"replaced"`
+	Messages    int                `json:"messages"` // Distinct messages skulls were replaced on
+	Repeats     int                `json:"repeats"`  // Skulls replaced on a message after the first
+	Deleted     int                `json:"deleted"`
+	Leaderboard []LeaderboardEntry `json:"leaderboard"`
+	Heatmap     [7][24]int         `json:"heatmap"` // Actions by UTC weekday, Sunday first, and hour
+}
+
+// heatmapData is the JSON served for a stats page's heatmap, ready to feed
+// to a charting library.
+type heatmapData struct {
+	Since    string    `json:"since"`
+	Until    string    `json:"until"`
+	Timezone string    `json:"timezone"`
+	Weekdays []string  `json:"weekdays"`
+	Counts   [][24]int `json:"counts"` // One row per weekday, one column per hour
+}
+
+// LeaderboardEntry is one ranked target user.
+type LeaderboardEntry struct {
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatar_url,omitempty"` // Optional
+	Count     int    `json:"count"`
+}
+
+// StatsSource looks up the stats page published under a token.
+type StatsSource interface {
+	// PublicStats returns the page for token, or nil if no guild publishes one under it.
+	PublicStats(token string) (*StatsPage, error)
+}
+
+// Server is the admin HTTP server.
+type Server struct {
+	addr   string
+	source StatsSource
+	srv    *http.Server
+
+	mu    sync.Mutex
+	cache map[string]cachedPage
+	now   func() time.Time
+}
+
+type cachedPage struct {
+	body    []byte
+	expires time.Time
+}
+
+func New(addr string, source StatsSource) *Server {
+	s := &Server{
+		addr:   addr,
+		source: source,
+		cache:  make(map[string]cachedPage),
+		now:    time.Now,
+	}
+	s.srv = &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Handler returns the server's routes.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("GET /stats/{token}", s.handleStats)
+	s.handleAPI(mux)
+	return mux
+}
+
+// Run serves until ctx is done, then shuts down gracefully.
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.addr, err)
+	}
+	logger.Info("http server listening", "addr", ln.Addr().String())
+
+	errc := make(chan error, 1)
+	go func() { errc <- s.srv.Serve(ln) }()
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("http server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down http server: %w", err)
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	s.serve(w, r, "text/html; charset=utf-8", renderPage)
+}
+
+// serve writes the rendering of the page published under the request's token.
+func (s *Server) serve(w http.ResponseWriter, r *http.Request, contentType string, render func(*StatsPage) ([]byte, error)) {
+	body, err := s.cached(r.URL.Path, r.PathValue("token"), render)
+	if err != nil {
+		logger.Error("failed to render stats page", "path", r.URL.Path, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if body == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(cacheTTL.Seconds())))
+	w.Header().Set("Referrer-Policy", "no-referrer") // Keep the token out of outbound links
+	w.Write(body)
+}
+
+// cached returns the rendering of the page for token under key, from the
+// cache while fresh. It returns nil if the token is unknown.
+func (s *Server) cached(key, token string, render func(*StatsPage) ([]byte, error)) ([]byte, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok && s.now().Before(cached.expires) {
+		return cached.body, nil
+	}
+
+	page, err := s.source.PublicStats(token)
+	if err != nil || page == nil {
+		return nil, err
+	}
+	body, err := render(page)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[key] = cachedPage{body: body, expires: s.now().Add(cacheTTL)}
+	s.mu.Unlock()
+	return body, nil
+}
+
+func renderPage(page *StatsPage) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := statsTemplate.Execute(&buf, page); err != nil {
+		return nil, fmt.Errorf("failed to render stats page: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func renderHeatmap(page *StatsPage) ([]byte, error) {
+	data := heatmapData{
+		Since:    page.Since.Format(time.DateOnly),
+		Until:    page.Until.Format(time.DateOnly),
+		Timezone: "UTC",
+		Counts:   page.Heatmap[:],
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		data.Weekdays = append(data.Weekdays, d.String())
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render heatmap: %w", err)
+	}
+	return body, nil
+}
+
+var statsTemplate = template.Must(template.New("stats").Funcs(template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Jolly stats</title>
+<style>
+body { font-family: system-ui, sans-serif; background: #2b2d31; color: #dbdee1; max-width: 40rem; margin: 2rem auto; padding: 0 1rem; }
+h1 { color: #2ecc71; }
+table { width: 100%; border-collapse: collapse; }
+th, td { text-align: left; padding: .4rem; border-bottom: 1px solid #4e5058; }
+td.count { text-align: right; }
+img.avatar { width: 1.5rem; height: 1.5rem; border-radius: 50%; vertical-align: middle; margin-right: .4rem; }
+</style>
+</head>
+<body>
+<h1>💀 → Jolly stats</h1>
+<p>{{.Since.Format "Jan 2"}} – {{.Until.Format "Jan 2, 2006"}}</p>
+<p><strong>{{.Replaced}}</strong> skulls replaced{{if .Messages}} on <strong>{{.Messages}}</strong> messages ({{.Repeats}} repeats){{end}}, <strong>{{.Deleted}}</strong> messages deleted.</p>
+{{if .Leaderboard}}
+<h2>Leaderboard</h2>
+<table>
+<tr><th>#</th><th>Member</th><th class="count">Skulls</th></tr>
+{{range $i, $e := .Leaderboard}}<tr><td>{{inc $i}}</td><td>{{if $e.AvatarURL}}<img class="avatar" src="{{$e.AvatarURL}}" alt="">{{end}}{{$e.Name}}</td><td class="count">{{$e.Count}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,129 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSource serves one page under a fixed token and counts lookups.
+type fakeSource struct {
+	token string
+	page  *StatsPage
+	err   error
+	calls int
+}
+
+func (f *fakeSource) PublicStats(token string) (*StatsPage, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	if token != f.token {
+		return nil, nil
+	}
+	return f.page, nil
+}
+
+func TestServer_Stats(t *testing.T) {
+	page := &StatsPage{
+		Since:       time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Until:       time.Date(2025, 1, 30, 0, 0, 0, 0, time.UTC),
+		Replaced:    12,
+		Messages:    8,
+		Repeats:     4,
+		Deleted:     3,
+		Leaderboard: []LeaderboardEntry{{Name: "<alice>", AvatarURL: "https://cdn.example/alice.png", Count: 9}, {Name: "Member #2", Count: 6}},
+	}
+	page.Heatmap[1][21] = 7
+
+	tests := []struct {
+		name       string
+		path       string
+		err        error
+		wantStatus int
+		wantBody   []string
+	}{
+		{
+			name:       "published page",
+			path:       "/stats/secret",
+			wantStatus: http.StatusOK,
+			wantBody:   []string{"12</strong> skulls replaced on <strong>8</strong> messages (4 repeats)", "&lt;alice&gt;", `src="https://cdn.example/alice.png"`, "Member #2", "Jan 1 – Jan 30, 2025"},
+		},
+		{
+			name:       "unknown token",
+			path:       "/stats/guess",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "source error",
+			path:       "/stats/secret",
+			err:        errors.New("boom"),
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "heatmap data",
+			path:       "/api/v1/stats/secret/heatmap",
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`"since":"2025-01-01"`, `"timezone":"UTC"`, `"weekdays":["Sunday","Monday",`, `[0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,7,0,0]`},
+		},
+		{
+			name:       "heatmap of unknown token",
+			path:       "/api/v1/stats/guess/heatmap",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "health check",
+			path:       "/healthz",
+			wantStatus: http.StatusOK,
+			wantBody:   []string{"ok"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(":0", &fakeSource{token: "secret", page: page, err: tt.err})
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("body does not contain %q:\n%s", want, rec.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_StatsCache(t *testing.T) {
+	source := &fakeSource{token: "secret", page: &StatsPage{}}
+	s := New(":0", source)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	get := func() {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats/secret", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+		}
+	}
+
+	get()
+	get()
+	if source.calls != 1 {
+		t.Errorf("expected the second request to be served from cache, got %d lookups", source.calls)
+	}
+
+	now = now.Add(cacheTTL)
+	get()
+	if source.calls != 2 {
+		t.Errorf("expected an expired page to be rebuilt, got %d lookups", source.calls)
+	}
+}